@@ -17,4 +17,6 @@ const (
 	ErrCreateIPConfigsRequest uint = iota + 200
 	ErrRequestIPConfigFromCNS
 	ErrProcessIPConfigResponse
+	ErrGetAssignedIPsFromCNS
+	ErrIPNotAssigned
 )
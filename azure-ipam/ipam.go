@@ -5,16 +5,20 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"net"
+	"net/netip"
+	"slices"
 
 	"github.com/Azure/azure-container-networking/azure-ipam/internal/buildinfo"
 	"github.com/Azure/azure-container-networking/azure-ipam/ipconfig"
 	"github.com/Azure/azure-container-networking/cns"
 	cnscli "github.com/Azure/azure-container-networking/cns/client"
 	"github.com/Azure/azure-container-networking/cns/fsnotify"
+	"github.com/Azure/azure-container-networking/cns/restserver"
+	"github.com/Azure/azure-container-networking/cns/types"
 	cniSkel "github.com/containernetworking/cni/pkg/skel"
 	cniTypes "github.com/containernetworking/cni/pkg/types"
 	types100 "github.com/containernetworking/cni/pkg/types/100"
+	cniVersion "github.com/containernetworking/cni/pkg/version"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 )
@@ -39,6 +43,8 @@ type cnsClient interface {
 	RequestIPs(context.Context, cns.IPConfigsRequest) (*cns.IPConfigsResponse, error)
 	ReleaseIPs(context.Context, cns.IPConfigsRequest) error
 	ReleaseIPAddress(context.Context, cns.IPConfigRequest) error
+	GetIPAddressesMatchingStates(context.Context, ...types.IPState) ([]cns.IPConfigurationStatus, error)
+	GetEndpoint(context.Context, string) (*restserver.GetEndpointResponse, error)
 }
 
 // NewPlugin constructs a new IPAM plugin instance with given logger and CNS client
@@ -114,29 +120,14 @@ func (p *IPAMPlugin) CmdAdd(args *cniSkel.CmdArgs) error {
 	}
 	p.logger.Debug("Received CNS IP config response", zap.Any("response", resp))
 
-	// Get Pod IP and gateway IP from ip config response
-	podIPNet, err := ipconfig.ProcessIPConfigsResp(resp)
+	// Get pod IPs, gateways and routes from ip config response
+	cniResult, err := ipconfig.ProcessIPConfigsResp(resp, args.IfName)
 	if err != nil {
 		p.logger.Error("Failed to interpret CNS IPConfigResponse", zap.Error(err), zap.Any("response", resp))
 		return cniTypes.NewError(ErrProcessIPConfigResponse, err.Error(), "failed to interpret CNS IPConfigResponse")
 	}
-	cniResult := &types100.Result{}
-	cniResult.IPs = make([]*types100.IPConfig, len(*podIPNet))
-	for i, ipNet := range *podIPNet {
-		p.logger.Debug("Parsed pod IP", zap.String("podIPNet", ipNet.String()))
-		ipConfig := &types100.IPConfig{}
-		if ipNet.Addr().Is4() {
-			ipConfig.Address = net.IPNet{
-				IP:   net.ParseIP(ipNet.Addr().String()),
-				Mask: net.CIDRMask(ipNet.Bits(), 32), // nolint
-			}
-		} else {
-			ipConfig.Address = net.IPNet{
-				IP:   net.ParseIP(ipNet.Addr().String()),
-				Mask: net.CIDRMask(ipNet.Bits(), 128), // nolint
-			}
-		}
-		cniResult.IPs[i] = ipConfig
+	for _, ipConfig := range cniResult.IPs {
+		p.logger.Debug("Parsed pod IP", zap.String("podIPNet", ipConfig.Address.String()), zap.Stringer("gateway", ipConfig.Gateway))
 	}
 
 	// Get versioned result
@@ -222,12 +213,83 @@ func (p *IPAMPlugin) CmdDel(args *cniSkel.CmdArgs) error {
 	return nil
 }
 
-// CmdCheck handles CNI check command - not implemented
+// CmdCheck handles CNI check commands by verifying that the IPs in the previous result are still assigned to the pod
+// in CNS. Without a previous result, the pod is only required to have an IP assigned.
 func (p *IPAMPlugin) CmdCheck(args *cniSkel.CmdArgs) error {
-	p.logger.Info("CHECK called")
+	p.logger.Info("CHECK called", zap.Any("args", args))
+
+	nwCfg, err := parseNetConf(args.StdinData)
+	if err != nil {
+		p.logger.Error("Failed to parse CNI network config from stdin", zap.Error(err), zap.Any("argStdinData", args.StdinData))
+		return cniTypes.NewError(cniTypes.ErrDecodingFailure, err.Error(), "failed to parse CNI network config from stdin")
+	}
+	var expected []netip.Addr
+	if nwCfg.RawPrevResult != nil {
+		if err = cniVersion.ParsePrevResult(nwCfg); err != nil {
+			p.logger.Error("Failed to parse previous result", zap.Error(err))
+			return cniTypes.NewError(cniTypes.ErrDecodingFailure, err.Error(), "failed to parse previous result")
+		}
+		prevResult, err := types100.NewResultFromResult(nwCfg.PrevResult)
+		if err != nil {
+			p.logger.Error("Failed to convert previous result", zap.Error(err))
+			return cniTypes.NewError(cniTypes.ErrDecodingFailure, err.Error(), "failed to convert previous result")
+		}
+		for _, ipConfig := range prevResult.IPs {
+			if ip, ok := netip.AddrFromSlice(ipConfig.Address.IP); ok {
+				expected = append(expected, ip.Unmap())
+			}
+		}
+	}
+
+	assigned, err := p.assignedIPs(args)
+	if err != nil {
+		p.logger.Error("Failed to get assigned IPs from CNS", zap.Error(err))
+		return cniTypes.NewError(ErrGetAssignedIPsFromCNS, err.Error(), "failed to get assigned IPs from CNS")
+	}
+	p.logger.Debug("Received assigned IPs from CNS", zap.Any("assigned", assigned), zap.Any("expected", expected))
+
+	if len(assigned) == 0 {
+		return cniTypes.NewError(ErrIPNotAssigned, "no IPs assigned to the pod", "CNS has no IPs assigned to the pod")
+	}
+	for _, ip := range expected {
+		if !slices.Contains(assigned, ip) {
+			p.logger.Error("IP from previous result is not assigned in CNS", zap.Stringer("ip", ip))
+			return cniTypes.NewError(ErrIPNotAssigned, ip.String(), "IP from previous result is not assigned to the pod in CNS")
+		}
+	}
+
+	p.logger.Info("CHECK success")
+
 	return nil
 }
 
+// assignedIPs returns the IPs CNS has assigned to the pod, from the assigned IP configurations in CNS or,
+// when those can't be queried, from the endpoint state CNS keeps for the container.
+func (p *IPAMPlugin) assignedIPs(args *cniSkel.CmdArgs) ([]netip.Addr, error) {
+	statuses, err := p.cnsClient.GetIPAddressesMatchingStates(context.TODO(), types.Assigned)
+	if err == nil {
+		return ipconfig.AssignedPodIPs(args, statuses)
+	}
+	p.logger.Info("Failed to get assigned IPs from CNS, going to try the endpoint state", zap.Error(err))
+
+	resp, epErr := p.cnsClient.GetEndpoint(context.TODO(), args.ContainerID)
+	if epErr != nil {
+		return nil, errors.Wrapf(epErr, "failed to get endpoint state after failing to get assigned IPs: %v", err)
+	}
+	var ips []netip.Addr
+	for _, ipInfo := range resp.EndpointInfo.IfnameToIPMap {
+		if ipInfo == nil {
+			continue
+		}
+		for _, ipNet := range append(ipInfo.IPv4, ipInfo.IPv6...) {
+			if ip, ok := netip.AddrFromSlice(ipNet.IP); ok {
+				ips = append(ips, ip.Unmap())
+			}
+		}
+	}
+	return ips, nil
+}
+
 // Parse network config from given byte array
 func parseNetConf(b []byte) (*cniTypes.NetConf, error) {
 	netConf := &cniTypes.NetConf{}
@@ -10,6 +10,7 @@ import (
 	"github.com/Azure/azure-container-networking/azure-ipam/logger"
 	"github.com/Azure/azure-container-networking/cns"
 	"github.com/Azure/azure-container-networking/cns/client"
+	"github.com/Azure/azure-container-networking/cns/restserver"
 	"github.com/Azure/azure-container-networking/cns/types"
 	cniSkel "github.com/containernetworking/cni/pkg/skel"
 	cniTypes "github.com/containernetworking/cni/pkg/types"
@@ -25,7 +26,9 @@ var (
 )
 
 // MOckCNSClient is a mock implementation of the CNSClient interface
-type MockCNSClient struct{}
+type MockCNSClient struct {
+	failGetIPAddresses bool
+}
 
 func (c *MockCNSClient) RequestIPAddress(ctx context.Context, ipconfig cns.IPConfigRequest) (*cns.IPConfigResponse, error) {
 	switch ipconfig.InfraContainerID {
@@ -145,6 +148,51 @@ func (c *MockCNSClient) RequestIPs(ctx context.Context, ipconfig cns.IPConfigsRe
 			},
 		}
 		return result, nil
+	case "happyArgsMultiNIC":
+		result := &cns.IPConfigsResponse{
+			PodIPInfo: []cns.PodIpInfo{
+				{
+					PodIPConfig: cns.IPSubnet{
+						IPAddress:    "10.0.1.10",
+						PrefixLength: 24,
+					},
+					NetworkContainerPrimaryIPConfig: cns.IPConfiguration{
+						IPSubnet: cns.IPSubnet{
+							IPAddress:    "10.0.1.0",
+							PrefixLength: 24,
+						},
+						DNSServers:       []string{"168.63.129.16"},
+						GatewayIPAddress: "10.0.0.1",
+					},
+					NICType: cns.InfraNIC,
+					Routes: []cns.Route{
+						{IPAddress: "10.0.0.0/8", GatewayIPAddress: "10.0.0.1"},
+					},
+				},
+				{
+					PodIPConfig: cns.IPSubnet{
+						IPAddress:    "20.0.0.5",
+						PrefixLength: 24,
+					},
+					NetworkContainerPrimaryIPConfig: cns.IPConfiguration{
+						IPSubnet: cns.IPSubnet{
+							IPAddress:    "20.0.0.0",
+							PrefixLength: 24,
+						},
+						DNSServers:       []string{"168.63.129.16"},
+						GatewayIPAddress: "20.0.0.1",
+					},
+					NICType:           cns.NodeNetworkInterfaceFrontendNIC,
+					MacAddress:        "12-34-56-78-9A-BC",
+					InterfaceName:     "eth1",
+					SkipDefaultRoutes: true,
+					Routes: []cns.Route{
+						{IPAddress: "20.1.0.0/16"},
+					},
+				},
+			},
+		}
+		return result, nil
 	default:
 		result := &cns.IPConfigsResponse{
 			PodIPInfo: []cns.PodIpInfo{
@@ -219,6 +267,34 @@ func (c *MockCNSClient) ReleaseIPs(ctx context.Context, ipconfig cns.IPConfigsRe
 	}
 }
 
+func (c *MockCNSClient) GetIPAddressesMatchingStates(_ context.Context, _ ...types.IPState) ([]cns.IPConfigurationStatus, error) {
+	if c.failGetIPAddresses {
+		return nil, errFoo
+	}
+	return []cns.IPConfigurationStatus{
+		{IPAddress: "10.0.1.10", PodInfo: cns.NewPodInfo("happyArgsDual", "happyArgsDual-eth0", "testname", "testns")},
+		{IPAddress: "fd11:1234::1", PodInfo: cns.NewPodInfo("happyArgsDual", "happyArgsDual-eth0", "testname", "testns")},
+		{IPAddress: "10.0.1.11", PodInfo: cns.NewPodInfo("otherid", "otherid-eth0", "othername", "testns")},
+	}, nil
+}
+
+func (c *MockCNSClient) GetEndpoint(_ context.Context, endpointID string) (*restserver.GetEndpointResponse, error) {
+	if endpointID != "happyArgsDual" {
+		return nil, errFoo
+	}
+	return &restserver.GetEndpointResponse{
+		EndpointInfo: restserver.EndpointInfo{
+			PodName:      "testname",
+			PodNamespace: "testns",
+			IfnameToIPMap: map[string]*restserver.IPInfo{
+				"testifname": {
+					IPv4: []net.IPNet{{IP: net.IPv4(10, 0, 1, 10), Mask: net.CIDRMask(24, 32)}},
+				},
+			},
+		},
+	}, nil
+}
+
 // cniResultsWriter is a helper struct to write CNI results to a byte array
 type cniResultsWriter struct {
 	result *types100.Result
@@ -287,6 +363,13 @@ func TestCmdAdd(t *testing.T) {
 							IP:   net.IPv4(10, 0, 1, 10),
 							Mask: net.CIDRMask(24, 32),
 						},
+						Gateway: net.IPv4(10, 0, 0, 1),
+					},
+				},
+				Routes: []*cniTypes.Route{
+					{
+						Dst: net.IPNet{IP: net.IPv4zero, Mask: net.CIDRMask(0, 32)},
+						GW:  net.IPv4(10, 0, 0, 1),
 					},
 				},
 				DNS: cniTypes.DNS{},
@@ -304,18 +387,74 @@ func TestCmdAdd(t *testing.T) {
 							IP:   net.IPv4(10, 0, 1, 10),
 							Mask: net.CIDRMask(24, 32),
 						},
+						Gateway: net.IPv4(10, 0, 0, 1),
 					},
 					{
 						Address: net.IPNet{
 							IP:   net.ParseIP("fd11:1234::1"),
 							Mask: net.CIDRMask(120, 128),
 						},
+						Gateway: net.ParseIP("fe80::1234:5678:9abc"),
+					},
+				},
+				Routes: []*cniTypes.Route{
+					{
+						Dst: net.IPNet{IP: net.IPv4zero, Mask: net.CIDRMask(0, 32)},
+						GW:  net.IPv4(10, 0, 0, 1),
+					},
+					{
+						Dst: net.IPNet{IP: net.IPv6zero, Mask: net.CIDRMask(0, 128)},
+						GW:  net.ParseIP("fe80::1234:5678:9abc"),
 					},
 				},
 				DNS: cniTypes.DNS{},
 			},
 			wantErr: false,
 		},
+		{
+			name: "Happy CNI add multiple NICs",
+			args: buildArgs("happyArgsMultiNIC", happyPodArgs, happyNetConfByteArr),
+			want: &types100.Result{
+				CNIVersion: "1.0.0",
+				Interfaces: []*types100.Interface{
+					{Name: "testifname"},
+					{Name: "eth1", Mac: "12:34:56:78:9a:bc"},
+				},
+				IPs: []*types100.IPConfig{
+					{
+						Interface: types100.Int(0),
+						Address: net.IPNet{
+							IP:   net.IPv4(10, 0, 1, 10),
+							Mask: net.CIDRMask(24, 32),
+						},
+						Gateway: net.IPv4(10, 0, 0, 1),
+					},
+					{
+						Interface: types100.Int(1),
+						Address: net.IPNet{
+							IP:   net.IPv4(20, 0, 0, 5),
+							Mask: net.CIDRMask(24, 32),
+						},
+						Gateway: net.IPv4(20, 0, 0, 1),
+					},
+				},
+				Routes: []*cniTypes.Route{
+					{
+						Dst: net.IPNet{IP: net.IPv4(10, 0, 0, 0), Mask: net.CIDRMask(8, 32)},
+						GW:  net.IPv4(10, 0, 0, 1),
+					},
+					{
+						Dst: net.IPNet{IP: net.IPv4zero, Mask: net.CIDRMask(0, 32)},
+						GW:  net.IPv4(10, 0, 0, 1),
+					},
+					{
+						Dst: net.IPNet{IP: net.IPv4(20, 1, 0, 0), Mask: net.CIDRMask(16, 32)},
+					},
+				},
+				DNS: cniTypes.DNS{Nameservers: []string{"168.63.129.16"}},
+			},
+			wantErr: false,
+		},
 		{
 			name:    "Fail request CNS ipconfig during CmdAdd",
 			args:    buildArgs("failRequestCNSArgs", happyPodArgs, happyNetConfByteArr),
@@ -426,13 +565,87 @@ func TestCmdDel(t *testing.T) {
 }
 
 func TestCmdCheck(t *testing.T) {
-	mockCNSClient := &MockCNSClient{}
-	testLogger, cleanup, err := logger.New(loggerCfg)
-	if err != nil {
-		return
+	netConf := func(prevIPs ...string) []byte {
+		conf := map[string]interface{}{
+			"cniVersion": "1.0.0",
+			"name":       "happynetconf",
+		}
+		if len(prevIPs) > 0 {
+			ips := []map[string]string{}
+			for _, ip := range prevIPs {
+				ips = append(ips, map[string]string{"address": ip})
+			}
+			conf["prevResult"] = map[string]interface{}{
+				"cniVersion": "1.0.0",
+				"ips":        ips,
+			}
+		}
+		b, err := json.Marshal(conf)
+		require.NoError(t, err)
+		return b
+	}
+
+	tests := []struct {
+		name               string
+		args               *cniSkel.CmdArgs
+		failGetIPAddresses bool
+		wantErr            bool
+	}{
+		{
+			name: "Happy CNI check without previous result",
+			args: buildArgs("happyArgsDual", happyPodArgs, netConf()),
+		},
+		{
+			name: "Happy CNI check dual IP",
+			args: buildArgs("happyArgsDual", happyPodArgs, netConf("10.0.1.10/24", "fd11:1234::1/120")),
+		},
+		{
+			name: "Happy CNI check matched by pod after sandbox change",
+			args: buildArgs("newid", happyPodArgs, netConf("10.0.1.10/24")),
+		},
+		{
+			name:               "Happy CNI check using endpoint state",
+			args:               buildArgs("happyArgsDual", happyPodArgs, netConf("10.0.1.10/24")),
+			failGetIPAddresses: true,
+		},
+		{
+			name:    "Fail CNI check when IP is no longer assigned",
+			args:    buildArgs("happyArgsDual", happyPodArgs, netConf("10.0.1.10/24", "10.0.1.99/24")),
+			wantErr: true,
+		},
+		{
+			name:    "Fail CNI check when pod has no IPs",
+			args:    buildArgs("noIPs", "K8S_POD_NAMESPACE=testns;K8S_POD_NAME=noips", netConf()),
+			wantErr: true,
+		},
+		{
+			name:               "Fail CNI check when CNS can't be queried",
+			args:               buildArgs("noEndpoint", happyPodArgs, netConf("10.0.1.10/24")),
+			failGetIPAddresses: true,
+			wantErr:            true,
+		},
+		{
+			name:    "Fail parse netconf during CmdCheck",
+			args:    buildArgs("happyArgsDual", happyPodArgs, []byte("invalidNetConf")),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			mockCNSClient := &MockCNSClient{failGetIPAddresses: tt.failGetIPAddresses}
+			testLogger, cleanup, err := logger.New(loggerCfg)
+			if err != nil {
+				return
+			}
+			defer cleanup()
+			ipamPlugin, _ := NewPlugin(testLogger, mockCNSClient, nil)
+			err = ipamPlugin.CmdCheck(tt.args)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
 	}
-	defer cleanup()
-	ipamPlugin, _ := NewPlugin(testLogger, mockCNSClient, nil)
-	err = ipamPlugin.CmdCheck(nil)
-	require.NoError(t, err)
 }
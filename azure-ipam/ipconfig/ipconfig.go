@@ -3,11 +3,14 @@ package ipconfig
 import (
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/netip"
+	"slices"
 
 	"github.com/Azure/azure-container-networking/cns"
 	cniSkel "github.com/containernetworking/cni/pkg/skel"
 	cniTypes "github.com/containernetworking/cni/pkg/types"
+	types100 "github.com/containernetworking/cni/pkg/types/100"
 	"github.com/pkg/errors"
 )
 
@@ -63,23 +66,160 @@ func CreateIPConfigsReq(args *cniSkel.CmdArgs) (cns.IPConfigsRequest, error) {
 	return req, nil
 }
 
-func ProcessIPConfigsResp(resp *cns.IPConfigsResponse) (*[]netip.Prefix, error) {
-	podIPNets := make([]netip.Prefix, len(resp.PodIPInfo))
+// ProcessIPConfigsResp converts the PodIPInfo in a CNS IPConfigsResponse into a CNI result. Each address is given the
+// gateway CNS returned for it, and the routes CNS returned are added along with a default route per address family
+// through that gateway, unless CNS asked for default routes to be skipped. When the response spans more than one
+// interface the result lists them and each address references its interface, the one without a MAC address being ifName.
+func ProcessIPConfigsResp(resp *cns.IPConfigsResponse, ifName string) (*types100.Result, error) {
+	result := &types100.Result{}
+	interfaces := map[string]int{}
+	ipInterfaces := make([]int, 0, len(resp.PodIPInfo))
+	defaultRoutes := map[bool]*cniTypes.Route{}
+	routes := map[string]bool{}
+	addRoute := func(route *cniTypes.Route) {
+		key := route.String()
+		if routes[key] {
+			return
+		}
+		routes[key] = true
+		result.Routes = append(result.Routes, route)
+	}
 
 	for i := range resp.PodIPInfo {
+		info := &resp.PodIPInfo[i]
+		mac := info.MacAddress
+		if hw, err := net.ParseMAC(mac); err == nil {
+			mac = hw.String()
+		}
+		idx, ok := interfaces[mac]
+		if !ok {
+			idx = len(result.Interfaces)
+			interfaces[mac] = idx
+			iface := &types100.Interface{Name: info.InterfaceName, Mac: mac}
+			if mac == "" {
+				iface.Name = ifName
+			}
+			result.Interfaces = append(result.Interfaces, iface)
+		}
+
+		for _, route := range info.Routes {
+			r, err := parseRoute(route)
+			if err != nil {
+				return nil, err
+			}
+			addRoute(r)
+			if ones, _ := r.Dst.Mask.Size(); ones == 0 {
+				defaultRoutes[r.Dst.IP.To4() != nil] = r
+			}
+		}
+		for _, server := range info.NetworkContainerPrimaryIPConfig.DNSServers {
+			if !slices.Contains(result.DNS.Nameservers, server) {
+				result.DNS.Nameservers = append(result.DNS.Nameservers, server)
+			}
+		}
+
+		// interfaces without an address, such as backend NICs, are only listed
+		if info.PodIPConfig.IPAddress == "" && mac != "" {
+			continue
+		}
 		podCIDR := fmt.Sprintf(
 			"%s/%d",
-			resp.PodIPInfo[i].PodIPConfig.IPAddress,
-			resp.PodIPInfo[i].NetworkContainerPrimaryIPConfig.IPSubnet.PrefixLength,
+			info.PodIPConfig.IPAddress,
+			info.NetworkContainerPrimaryIPConfig.IPSubnet.PrefixLength,
 		)
 		podIPNet, err := netip.ParsePrefix(podCIDR)
 		if err != nil {
 			return nil, errors.Wrapf(err, "cns returned invalid pod CIDR %q", podCIDR)
 		}
-		podIPNets[i] = podIPNet
+		ipConfig := &types100.IPConfig{
+			Address: net.IPNet{
+				IP:   podIPNet.Addr().AsSlice(),
+				Mask: net.CIDRMask(podIPNet.Bits(), podIPNet.Addr().BitLen()),
+			},
+		}
+		if gw := info.NetworkContainerPrimaryIPConfig.GatewayIPAddress; gw != "" {
+			gwIP, err := netip.ParseAddr(gw)
+			if err != nil {
+				return nil, errors.Wrapf(err, "cns returned invalid gateway %q", gw)
+			}
+			// a gateway of the other address family cannot be used by this address
+			if gwIP.Is4() == podIPNet.Addr().Is4() {
+				ipConfig.Gateway = gwIP.AsSlice()
+			}
+		}
+		if ipConfig.Gateway != nil && !info.SkipDefaultRoutes && defaultRoutes[podIPNet.Addr().Is4()] == nil {
+			bits := podIPNet.Addr().BitLen()
+			r := &cniTypes.Route{
+				Dst: net.IPNet{IP: make(net.IP, bits/8), Mask: net.CIDRMask(0, bits)}, //nolint:gomnd // bytes in an address
+				GW:  ipConfig.Gateway,
+			}
+			defaultRoutes[podIPNet.Addr().Is4()] = r
+			addRoute(r)
+		}
+		result.IPs = append(result.IPs, ipConfig)
+		ipInterfaces = append(ipInterfaces, idx)
+	}
+
+	// a single interface is the one the caller is configuring, so it is left implicit
+	if len(result.Interfaces) <= 1 {
+		result.Interfaces = nil
+		return result, nil
+	}
+	for i := range result.IPs {
+		result.IPs[i].Interface = types100.Int(ipInterfaces[i])
 	}
+	return result, nil
+}
 
-	return &podIPNets, nil
+func parseRoute(route cns.Route) (*cniTypes.Route, error) {
+	dst, err := netip.ParsePrefix(route.IPAddress)
+	if err != nil {
+		addr, addrErr := netip.ParseAddr(route.IPAddress)
+		if addrErr != nil {
+			return nil, errors.Wrapf(err, "cns returned invalid route destination %q", route.IPAddress)
+		}
+		dst = netip.PrefixFrom(addr, addr.BitLen())
+	}
+	r := &cniTypes.Route{
+		Dst: net.IPNet{
+			IP:   dst.Masked().Addr().AsSlice(),
+			Mask: net.CIDRMask(dst.Bits(), dst.Addr().BitLen()),
+		},
+	}
+	if route.GatewayIPAddress != "" {
+		gw, err := netip.ParseAddr(route.GatewayIPAddress)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cns returned invalid route gateway %q", route.GatewayIPAddress)
+		}
+		r.GW = gw.AsSlice()
+	}
+	return r, nil
+}
+
+// AssignedPodIPs returns the addresses in statuses which CNS has assigned to the pod in args, matched by infra
+// container ID or by pod name and namespace.
+func AssignedPodIPs(args *cniSkel.CmdArgs, statuses []cns.IPConfigurationStatus) ([]netip.Addr, error) {
+	podConf, err := parsePodConf(args.Args)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse pod config from CNI args")
+	}
+	var ips []netip.Addr
+	for i := range statuses {
+		podInfo := statuses[i].PodInfo
+		if podInfo == nil {
+			continue
+		}
+		if podInfo.InfraContainerID() != args.ContainerID &&
+			(podInfo.Name() != string(podConf.K8S_POD_NAME) || podInfo.Namespace() != string(podConf.K8S_POD_NAMESPACE)) {
+			continue
+		}
+		ip, err := netip.ParseAddr(statuses[i].IPAddress)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cns returned invalid IP address %q", statuses[i].IPAddress)
+		}
+		ips = append(ips, ip)
+	}
+	return ips, nil
 }
 
 type k8sPodEnvArgs struct {
@@ -0,0 +1,185 @@
+package ipconfig
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/cns"
+	cniSkel "github.com/containernetworking/cni/pkg/skel"
+	cniTypes "github.com/containernetworking/cni/pkg/types"
+	types100 "github.com/containernetworking/cni/pkg/types/100"
+	"github.com/stretchr/testify/require"
+)
+
+func podIPInfo(ip string, prefixLength uint8, gateway string) cns.PodIpInfo {
+	return cns.PodIpInfo{
+		PodIPConfig: cns.IPSubnet{IPAddress: ip, PrefixLength: prefixLength},
+		NetworkContainerPrimaryIPConfig: cns.IPConfiguration{
+			IPSubnet:         cns.IPSubnet{PrefixLength: prefixLength},
+			DNSServers:       []string{"168.63.129.16"},
+			GatewayIPAddress: gateway,
+		},
+	}
+}
+
+func TestProcessIPConfigsResp(t *testing.T) {
+	frontend := podIPInfo("20.0.0.5", 24, "20.0.0.1")
+	frontend.NICType = cns.NodeNetworkInterfaceFrontendNIC
+	frontend.MacAddress = "12-34-56-78-9A-BC"
+	frontend.InterfaceName = "eth1"
+	frontend.SkipDefaultRoutes = true
+	frontend.Routes = []cns.Route{{IPAddress: "20.1.0.0/16"}}
+
+	backend := cns.PodIpInfo{NICType: cns.BackendNIC, MacAddress: "12:34:56:78:9a:bd", InterfaceName: "ib0"}
+
+	infra := podIPInfo("10.0.1.10", 24, "10.0.0.1")
+	infra.Routes = []cns.Route{{IPAddress: "10.0.0.0/8", GatewayIPAddress: "10.0.0.1"}}
+
+	tests := []struct {
+		name    string
+		resp    *cns.IPConfigsResponse
+		want    *types100.Result
+		wantErr bool
+	}{
+		{
+			name: "single dual-stack NIC returns gateways and routes per family",
+			resp: &cns.IPConfigsResponse{PodIPInfo: []cns.PodIpInfo{
+				infra,
+				podIPInfo("fd11:1234::1", 120, "fe80::1234:5678:9abc"),
+			}},
+			want: &types100.Result{
+				IPs: []*types100.IPConfig{
+					{
+						Address: net.IPNet{IP: net.IPv4(10, 0, 1, 10).To4(), Mask: net.CIDRMask(24, 32)},
+						Gateway: net.IPv4(10, 0, 0, 1).To4(),
+					},
+					{
+						Address: net.IPNet{IP: net.ParseIP("fd11:1234::1"), Mask: net.CIDRMask(120, 128)},
+						Gateway: net.ParseIP("fe80::1234:5678:9abc"),
+					},
+				},
+				Routes: []*cniTypes.Route{
+					{Dst: net.IPNet{IP: net.IPv4(10, 0, 0, 0).To4(), Mask: net.CIDRMask(8, 32)}, GW: net.IPv4(10, 0, 0, 1).To4()},
+					{Dst: net.IPNet{IP: net.IPv4zero.To4(), Mask: net.CIDRMask(0, 32)}, GW: net.IPv4(10, 0, 0, 1).To4()},
+					{Dst: net.IPNet{IP: net.IPv6zero, Mask: net.CIDRMask(0, 128)}, GW: net.ParseIP("fe80::1234:5678:9abc")},
+				},
+				DNS: cniTypes.DNS{Nameservers: []string{"168.63.129.16"}},
+			},
+		},
+		{
+			name: "multiple NICs return interfaces, gateways and routes",
+			resp: &cns.IPConfigsResponse{PodIPInfo: []cns.PodIpInfo{infra, frontend, backend}},
+			want: &types100.Result{
+				Interfaces: []*types100.Interface{
+					{Name: "eth0"},
+					{Name: "eth1", Mac: "12:34:56:78:9a:bc"},
+					{Name: "ib0", Mac: "12:34:56:78:9a:bd"},
+				},
+				IPs: []*types100.IPConfig{
+					{
+						Interface: types100.Int(0),
+						Address:   net.IPNet{IP: net.IPv4(10, 0, 1, 10).To4(), Mask: net.CIDRMask(24, 32)},
+						Gateway:   net.IPv4(10, 0, 0, 1).To4(),
+					},
+					{
+						Interface: types100.Int(1),
+						Address:   net.IPNet{IP: net.IPv4(20, 0, 0, 5).To4(), Mask: net.CIDRMask(24, 32)},
+						Gateway:   net.IPv4(20, 0, 0, 1).To4(),
+					},
+				},
+				Routes: []*cniTypes.Route{
+					{Dst: net.IPNet{IP: net.IPv4(10, 0, 0, 0).To4(), Mask: net.CIDRMask(8, 32)}, GW: net.IPv4(10, 0, 0, 1).To4()},
+					{Dst: net.IPNet{IP: net.IPv4zero.To4(), Mask: net.CIDRMask(0, 32)}, GW: net.IPv4(10, 0, 0, 1).To4()},
+					{Dst: net.IPNet{IP: net.IPv4(20, 1, 0, 0).To4(), Mask: net.CIDRMask(16, 32)}},
+				},
+				DNS: cniTypes.DNS{Nameservers: []string{"168.63.129.16"}},
+			},
+		},
+		{
+			name:    "invalid pod IP",
+			resp:    &cns.IPConfigsResponse{PodIPInfo: []cns.PodIpInfo{podIPInfo("10.0.1", 24, "")}},
+			wantErr: true,
+		},
+		{
+			name: "invalid route",
+			resp: &cns.IPConfigsResponse{PodIPInfo: []cns.PodIpInfo{{
+				PodIPConfig: cns.IPSubnet{IPAddress: "10.0.1.10", PrefixLength: 24},
+				Routes:      []cns.Route{{IPAddress: "10.0.0/8"}},
+			}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ProcessIPConfigsResp(tt.resp, "eth0")
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAssignedPodIPs(t *testing.T) {
+	statuses := []cns.IPConfigurationStatus{
+		{IPAddress: "10.0.1.10", PodInfo: cns.NewPodInfo("sandbox", "sandbox-eth0", "testname", "testns")},
+		{IPAddress: "fd11:1234::1", PodInfo: cns.NewPodInfo("oldsandbox", "oldsandbox-eth0", "testname", "testns")},
+		{IPAddress: "10.0.1.11", PodInfo: cns.NewPodInfo("other", "other-eth0", "othername", "testns")},
+		{IPAddress: "10.0.1.12"},
+	}
+
+	tests := []struct {
+		name     string
+		args     *cniSkel.CmdArgs
+		statuses []cns.IPConfigurationStatus
+		want     []netip.Addr
+		wantErr  bool
+	}{
+		{
+			name:     "matched by infra container ID or pod",
+			args:     &cniSkel.CmdArgs{ContainerID: "sandbox", Args: "K8S_POD_NAMESPACE=testns;K8S_POD_NAME=testname"},
+			statuses: statuses,
+			want:     []netip.Addr{netip.MustParseAddr("10.0.1.10"), netip.MustParseAddr("fd11:1234::1")},
+		},
+		{
+			name:     "matched by infra container ID of another pod name",
+			args:     &cniSkel.CmdArgs{ContainerID: "other", Args: "K8S_POD_NAMESPACE=testns;K8S_POD_NAME=renamed"},
+			statuses: statuses,
+			want:     []netip.Addr{netip.MustParseAddr("10.0.1.11")},
+		},
+		{
+			name:     "no IPs assigned to the pod",
+			args:     &cniSkel.CmdArgs{ContainerID: "none", Args: "K8S_POD_NAMESPACE=testns;K8S_POD_NAME=none"},
+			statuses: statuses,
+		},
+		{
+			name: "invalid IP",
+			args: &cniSkel.CmdArgs{ContainerID: "sandbox", Args: "K8S_POD_NAMESPACE=testns;K8S_POD_NAME=testname"},
+			statuses: []cns.IPConfigurationStatus{
+				{IPAddress: "10.0.1", PodInfo: cns.NewPodInfo("sandbox", "sandbox-eth0", "testname", "testns")},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "invalid CNI args",
+			args:    &cniSkel.CmdArgs{ContainerID: "sandbox", Args: "invalid"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AssignedPodIPs(tt.args, tt.statuses)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -1,10 +1,11 @@
 package logger
 
 import (
+	logv2 "github.com/Azure/azure-container-networking/log/v2"
+	cores "github.com/Azure/azure-container-networking/log/v2/cores"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
-	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 const (
@@ -18,36 +19,27 @@ type Config struct {
 	MaxBackups  int    // # of backups, no limitation by default
 }
 
-// NewLogger creates and returns a zap logger and a clean up function
+// New creates and returns a zap logger writing to the rotated log file, built by the
+// shared log/v2 pipeline, and a clean up function
 func New(cfg *Config) (*zap.Logger, func(), error) {
-	logLevel, err := zapcore.ParseLevel(cfg.Level)
-	if err != nil {
+	if _, err := zapcore.ParseLevel(cfg.Level); err != nil {
 		return nil, nil, errors.Wrapf(err, "failed to parse log level")
 	}
 	if cfg.Filepath == "" {
 		cfg.Filepath = Filepath
 	}
-	logger := newFileLogger(cfg, logLevel)
-	cleanup := func() {
-		_ = logger.Sync()
+	logger, cleanup, err := logv2.New(&logv2.Config{
+		Level: cfg.Level,
+		File: &cores.FileConfig{
+			Filepath:   cfg.Filepath,
+			Level:      cfg.Level,
+			MaxSize:    cfg.MaxSizeInMB,
+			MaxBackups: cfg.MaxBackups,
+		},
+	})
+	if err != nil {
+		cleanup()
+		return nil, nil, errors.Wrap(err, "failed to create logger")
 	}
 	return logger, cleanup, nil
 }
-
-// create and return a zap logger via lumberjack with rotation
-func newFileLogger(cfg *Config, logLevel zapcore.Level) *zap.Logger {
-	// define a lumberjack fileWriter
-	logFileWriter := zapcore.AddSync(&lumberjack.Logger{
-		Filename:   cfg.Filepath,
-		MaxSize:    cfg.MaxSizeInMB, // MegaBytes
-		MaxBackups: cfg.MaxBackups,
-	})
-	// define the log encoding
-	encoderConfig := zap.NewProductionEncoderConfig()
-	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
-	jsonEncoder := zapcore.NewJSONEncoder(encoderConfig)
-	// create a new zap logger
-	core := zapcore.NewCore(jsonEncoder, logFileWriter, logLevel)
-	logger := zap.New(core)
-	return logger
-}
@@ -5,14 +5,18 @@ package ipam
 
 import (
 	"encoding/json"
+	"fmt"
 	"net"
+	"os"
 	"strconv"
 
+	"github.com/Azure/azure-container-networking/aitelemetry"
 	"github.com/Azure/azure-container-networking/cni"
 	"github.com/Azure/azure-container-networking/cni/log"
 	"github.com/Azure/azure-container-networking/common"
 	"github.com/Azure/azure-container-networking/ipam"
 	"github.com/Azure/azure-container-networking/platform"
+	"github.com/Azure/azure-container-networking/telemetry"
 	cniSkel "github.com/containernetworking/cni/pkg/skel"
 	cniTypes "github.com/containernetworking/cni/pkg/types"
 	cniTypesCurr "github.com/containernetworking/cni/pkg/types/100"
@@ -82,11 +86,44 @@ func (plugin *ipamPlugin) Start(config *common.PluginConfig) error {
 		return err
 	}
 
+	plugin.am.SetConflictReporter(plugin.reportAddressConflict)
+
 	logger.Info("Plugin started")
 
 	return nil
 }
 
+// reportAddressConflict sends a metric and an event for an address quarantined after another host was found using it.
+func (plugin *ipamPlugin) reportAddressConflict(address string, conflict error) {
+	tb := telemetry.NewTelemetryBuffer(logger)
+	if err := tb.Connect(); err != nil {
+		logger.Error("Cannot connect to telemetry service to report address conflict", zap.Error(err))
+		return
+	}
+	defer tb.Close()
+
+	metric := telemetry.AIMetric{
+		Metric: aitelemetry.Metric{
+			Name:  telemetry.IPAddressConflictStr,
+			Value: 1.0,
+			CustomDimensions: map[string]string{
+				telemetry.IPAddressStr: address,
+				telemetry.VersionStr:   plugin.Version,
+			},
+		},
+	}
+	if err := telemetry.SendCNIMetric(&metric, tb); err != nil {
+		logger.Error("Couldn't send address conflict metric", zap.Error(err))
+	}
+
+	telemetry.SendCNIEvent(tb, &telemetry.CNIReport{
+		Name:         plugin.Name,
+		Version:      plugin.Version,
+		Context:      "AzureCNIIpam",
+		EventMessage: fmt.Sprintf("[%d] Quarantined address %s: %v", os.Getpid(), address, conflict),
+	})
+}
+
 // Stops the plugin.
 func (plugin *ipamPlugin) Stop() {
 	plugin.am.Uninitialize()
@@ -119,6 +156,12 @@ func (plugin *ipamPlugin) Configure(stdinData []byte) (*cni.NetworkConfig, error
 		plugin.SetOption(common.OptIpamQueryInterval, i)
 	}
 
+	// Probe for address conflicts before handing out addresses.
+	plugin.SetOption(common.OptIpamConflictProbe, nwCfg.IPAM.ConflictProbe)
+
+	// Limit the node IPv6 subnet, to its first /120 unless set.
+	plugin.SetOption(common.OptIpamSubnetMaskSizeLimit, nwCfg.IPAM.SubnetMaskSizeLimit)
+
 	err = plugin.am.StartSource(plugin.Options)
 	if err != nil {
 		return nil, err
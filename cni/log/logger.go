@@ -1,11 +1,12 @@
 package log
 
 import (
+	"encoding/json"
 	"os"
 
+	logv2 "github.com/Azure/azure-container-networking/log/v2"
+	cores "github.com/Azure/azure-container-networking/log/v2/cores"
 	"go.uber.org/zap"
-	"go.uber.org/zap/zapcore"
-	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 var (
@@ -18,31 +19,55 @@ const (
 	maxLogFileSizeInMb = 5
 	maxLogFileCount    = 8
 	etwCNIEventName    = "AzureCNI"
-	loggingLevel       = zapcore.DebugLevel
+	loggingLevel       = "debug"
 )
 
-func initZapLog(logFile string) *zap.Logger {
-	logFileCNIWriter := zapcore.AddSync(&lumberjack.Logger{
-		Filename:   LogPath + logFile,
-		MaxSize:    maxLogFileSizeInMb,
-		MaxBackups: maxLogFileCount,
-	})
+// Component names of the CNI loggers, which can be given their own Level in the
+// components of the logging config.
+const (
+	ComponentCNI       = "cni"
+	ComponentIPAM      = "ipam"
+	ComponentTelemetry = "telemetry"
+)
 
-	encoderConfig := zap.NewProductionEncoderConfig()
-	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
-	jsonEncoder := zapcore.NewJSONEncoder(encoderConfig)
+// loadConfig reads the logging config at ConfigPath, in the schema of log/v2, falling back to
+// the defaults if there is none. The file core always writes to logFile in LogPath, and the
+// platform cores are added to either config.
+func loadConfig(logFile string) *logv2.Config {
+	cfg := &logv2.Config{}
+	if b, err := os.ReadFile(ConfigPath); err != nil || json.Unmarshal(b, cfg) != nil {
+		cfg = &logv2.Config{
+			Level: loggingLevel,
+			File: &cores.FileConfig{
+				MaxSize:    maxLogFileSizeInMb,
+				MaxBackups: maxLogFileCount,
+			},
+		}
+	}
+	platformConfig(cfg)
+	if cfg.File == nil {
+		cfg.File = &cores.FileConfig{}
+	}
+	cfg.File.Filepath = LogPath + logFile
+	return cfg
+}
 
-	textFileCore := zapcore.NewCore(jsonEncoder, logFileCNIWriter, loggingLevel)
-	core, err := JoinPlatformCores(textFileCore, loggingLevel)
+func initZapLog(component, logFile string) *zap.Logger {
+	cfg := loadConfig(logFile)
+	z, closer, err := logv2.New(cfg, zap.AddCaller())
 	if err != nil {
-		// If we fail to join the platform cores, fallback to the original core.
-		core = textFileCore
+		// If we fail to build the configured cores, close those which were built and fallback to the log file alone.
+		closer()
+		cfg = &logv2.Config{Level: loggingLevel, File: cfg.File}
+		if z, _, err = logv2.New(cfg, zap.AddCaller()); err != nil {
+			return zap.NewNop()
+		}
 	}
-	return zap.New(core, zap.AddCaller()).With(zap.Int("pid", os.Getpid()))
+	return z.Named(component).With(zap.Int("pid", os.Getpid()))
 }
 
 var (
-	CNILogger       = initZapLog(zapCNILogFile)
-	IPamLogger      = initZapLog(zapIpamLogFile)
-	TelemetryLogger = initZapLog(zapTelemetryLogFile)
+	CNILogger       = initZapLog(ComponentCNI, zapCNILogFile)
+	IPamLogger      = initZapLog(ComponentIPAM, zapIpamLogFile)
+	TelemetryLogger = initZapLog(ComponentTelemetry, zapTelemetryLogFile)
 )
@@ -1,14 +1,14 @@
 package log
 
 import (
-	"go.uber.org/zap/zapcore"
+	logv2 "github.com/Azure/azure-container-networking/log/v2"
 )
 
 const (
 	// LogPath is the path where log files are stored.
 	LogPath = "/var/log/"
+	// ConfigPath is the path of the optional logging config of the CNI components.
+	ConfigPath = "/etc/cni/net.d/azure-vnet-log.json"
 )
 
-func JoinPlatformCores(c zapcore.Core, _ zapcore.Level) (zapcore.Core, error) {
-	return c, nil
-}
+func platformConfig(*logv2.Config) {}
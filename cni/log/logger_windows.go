@@ -1,34 +1,26 @@
 package log
 
 import (
-	"github.com/Azure/azure-container-networking/zapetw"
-	"github.com/pkg/errors"
-	"go.uber.org/zap"
-	"go.uber.org/zap/zapcore"
+	logv2 "github.com/Azure/azure-container-networking/log/v2"
+	cores "github.com/Azure/azure-container-networking/log/v2/cores"
 )
 
 const (
 	// LogPath is the path where log files are stored.
 	LogPath = ""
+	// ConfigPath is the path of the optional logging config of the CNI components.
+	ConfigPath = `C:\k\azurecni\netconf\azure-vnet-log.json`
 )
 
-func JoinPlatformCores(core zapcore.Core, loggingLevel zapcore.Level) (zapcore.Core, error) {
-	etwcore, err := etwCore(loggingLevel)
-	if err != nil {
-		return core, err
+// platformConfig sends the logs of the CNI components to ETW as well as to file, unless
+// the config has an ETW core of its own.
+func platformConfig(cfg *logv2.Config) {
+	if cfg.ETW != nil {
+		return
 	}
-	teecore := zapcore.NewTee(core, etwcore)
-	return teecore, nil
-}
-
-func etwCore(loggingLevel zapcore.Level) (zapcore.Core, error) {
-	encoderConfig := zap.NewProductionEncoderConfig()
-	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
-	jsonEncoder := zapcore.NewJSONEncoder(encoderConfig)
-
-	etwcore, _, err := zapetw.New("ACN-Monitoring", etwCNIEventName, jsonEncoder, loggingLevel)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to create ETW core")
+	cfg.ETW = &cores.ETWConfig{
+		EventName:    etwCNIEventName,
+		ProviderName: "ACN-Monitoring",
+		Level:        loggingLevel,
 	}
-	return etwcore, nil
 }
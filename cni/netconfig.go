@@ -5,8 +5,12 @@ package cni
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
 	"strings"
 
+	"github.com/Azure/azure-container-networking/cns"
 	"github.com/Azure/azure-container-networking/network/policy"
 	cniTypes "github.com/containernetworking/cni/pkg/types"
 )
@@ -15,6 +19,23 @@ const (
 	PolicyStr string = "Policy"
 )
 
+// MTU policy modes.
+const (
+	MTUModeFixed  = "fixed"
+	MTUModeMaster = "master"
+)
+
+// Bounds for a configured MTU.
+const (
+	minMTU = 68
+	maxMTU = 65535
+)
+
+// mtuOverrideNICTypes are the NIC types whose MTU can be overridden. A BackendNIC is left out: it is an InfiniBand VF
+// which is handed to the pod by its PnP ID without an endpoint or interface being created for it, so there is nothing
+// for CNI to program its MTU on and the VF driver keeps its own.
+var mtuOverrideNICTypes = []string{string(cns.InfraNIC), string(cns.DelegatedVMNIC), string(cns.NodeNetworkInterfaceAccelnetFrontendNIC)}
+
 // KVPair represents a K-V pair of a json object.
 type KVPair struct {
 	Name  string          `json:"name"`
@@ -40,14 +61,114 @@ type RuntimeDNSConfig struct {
 	Options  []string `json:"options,omitempty"`
 }
 
+// MTUPolicy controls the MTU set on endpoint interfaces.
+// When no policy is configured the endpoint clients keep their default behaviour.
+type MTUPolicy struct {
+	// Mode is "fixed" to use Value, or "master" to derive the MTU from the master interface.
+	Mode string `json:"mode,omitempty"`
+	// Value is the MTU used in fixed mode.
+	Value int `json:"value,omitempty"`
+	// Overhead is subtracted from the master interface MTU in master mode, e.g. for encapsulation.
+	Overhead int `json:"overhead,omitempty"`
+	// NICTypeOverrides sets a fixed MTU per NIC type (InfraNIC, FrontendNIC, FrontendNIC_Accelnet) and takes precedence
+	// over Mode. BackendNIC is not supported, see mtuOverrideNICTypes.
+	NICTypeOverrides map[string]int `json:"nicTypeOverrides,omitempty"`
+}
+
+// Validate checks the mode, the fixed value and every NIC type override of the policy, so that a bad policy is
+// rejected when the network configuration is parsed rather than when an interface of the NIC type is first added.
+// All problems found are joined in the returned error.
+func (p *MTUPolicy) Validate() error {
+	if p == nil {
+		return nil
+	}
+
+	var errs []error
+	switch p.Mode {
+	case "", MTUModeMaster:
+	case MTUModeFixed:
+		if _, err := p.Resolve("", 0); err != nil {
+			errs = append(errs, fmt.Errorf("mtuPolicy.value: %w", err))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("mtuPolicy.mode %q is not supported, expected one of %q",
+			p.Mode, []string{"", MTUModeFixed, MTUModeMaster}))
+	}
+
+	nicTypes := make([]string, 0, len(p.NICTypeOverrides))
+	for nicType := range p.NICTypeOverrides {
+		nicTypes = append(nicTypes, nicType)
+	}
+	sort.Strings(nicTypes)
+	for _, nicType := range nicTypes {
+		supported := false
+		for _, t := range mtuOverrideNICTypes {
+			supported = supported || t == nicType
+		}
+		if !supported {
+			errs = append(errs, fmt.Errorf("mtuPolicy.nicTypeOverrides: nic type %q is not supported, expected one of %q",
+				nicType, mtuOverrideNICTypes))
+			continue
+		}
+		if _, err := p.Resolve(nicType, 0); err != nil {
+			errs = append(errs, fmt.Errorf("mtuPolicy.nicTypeOverrides: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// NeedsMasterMTU returns true if resolving the MTU for the NIC type requires the master interface MTU.
+func (p *MTUPolicy) NeedsMasterMTU(nicType string) bool {
+	if p == nil {
+		return false
+	}
+	if _, ok := p.NICTypeOverrides[nicType]; ok {
+		return false
+	}
+	return p.Mode == MTUModeMaster
+}
+
+// Resolve returns the MTU to program for an interface of the given NIC type.
+// A zero MTU means no policy applies and the default behaviour should be kept.
+func (p *MTUPolicy) Resolve(nicType string, masterMTU int) (int, error) {
+	if p == nil {
+		return 0, nil
+	}
+
+	var mtu int
+	if override, ok := p.NICTypeOverrides[nicType]; ok {
+		mtu = override
+	} else {
+		switch p.Mode {
+		case "":
+			return 0, nil
+		case MTUModeFixed:
+			mtu = p.Value
+		case MTUModeMaster:
+			mtu = masterMTU - p.Overhead
+		default:
+			return 0, fmt.Errorf("unknown mtu policy mode %q", p.Mode)
+		}
+	}
+
+	if mtu < minMTU || mtu > maxMTU {
+		return 0, fmt.Errorf("resolved mtu %d for nic type %q is outside [%d, %d]", mtu, nicType, minMTU, maxMTU)
+	}
+
+	return mtu, nil
+}
+
 type IPAM struct {
-	Mode          string `json:"mode,omitempty"`
-	Type          string `json:"type"`
-	Environment   string `json:"environment,omitempty"`
-	AddrSpace     string `json:"addressSpace,omitempty"`
-	Subnet        string `json:"subnet,omitempty"`
-	Address       string `json:"ipAddress,omitempty"`
-	QueryInterval string `json:"queryInterval,omitempty"`
+	Mode                string `json:"mode,omitempty"`
+	Type                string `json:"type"`
+	Environment         string `json:"environment,omitempty"`
+	AddrSpace           string `json:"addressSpace,omitempty"`
+	Subnet              string `json:"subnet,omitempty"`
+	Address             string `json:"ipAddress,omitempty"`
+	QueryInterval       string `json:"queryInterval,omitempty"`
+	ConflictProbe       bool   `json:"conflictProbe,omitempty"`
+	SubnetMaskSizeLimit string `json:"subnetMaskSizeLimit,omitempty"`
 }
 
 // NetworkConfig represents Azure CNI plugin network configuration.
@@ -78,9 +199,18 @@ type NetworkConfig struct {
 	DNS                           cniTypes.DNS    `json:"dns,omitempty"`
 	RuntimeConfig                 RuntimeConfig   `json:"runtimeConfig,omitempty"`
 	WindowsSettings               WindowsSettings `json:"windowsSettings,omitempty"`
+	MTUPolicy                     *MTUPolicy      `json:"mtuPolicy,omitempty"`
+	NetworkAttachments            *Attachments    `json:"networkAttachments,omitempty"`
 	AdditionalArgs                []KVPair        `json:"AdditionalArgs,omitempty"`
 }
 
+// Attachments enables secondary network attachments requested through the
+// k8s.v1.cni.cncf.io/networks pod annotation and NetworkAttachmentDefinition objects.
+type Attachments struct {
+	// Kubeconfig used to read pods and NetworkAttachmentDefinitions. Defaults to the kubelet kubeconfig.
+	Kubeconfig string `json:"kubeconfig,omitempty"`
+}
+
 type WindowsSettings struct {
 	EnableLoopbackDSR           bool `json:"enableLoopbackDSR,omitempty"`
 	HnsTimeoutDurationInSeconds int  `json:"hnsTimeoutDurationInSeconds,omitempty"`
@@ -117,9 +247,58 @@ func ParseNetworkConfig(b []byte) (*NetworkConfig, error) {
 		nwCfg.CNIVersion = defaultVersion
 	}
 
+	if err := nwCfg.MTUPolicy.Validate(); err != nil {
+		return nil, err
+	}
+
 	return &nwCfg, nil
 }
 
+// NetworkConfigList represents a CNI network configuration list (conflist).
+type NetworkConfigList struct {
+	CNIVersion string            `json:"cniVersion,omitempty"`
+	Name       string            `json:"name,omitempty"`
+	Plugins    []json.RawMessage `json:"plugins,omitempty"`
+}
+
+// ParseNetworkConfigList unmarshals a conflist and returns the network configuration of every
+// plugin of the given type. As in libcni, name and cniVersion are inherited from the list.
+func ParseNetworkConfigList(b []byte, pluginType string) ([]*NetworkConfig, error) {
+	confList := NetworkConfigList{}
+	if err := json.Unmarshal(b, &confList); err != nil {
+		return nil, err
+	}
+
+	var nwCfgs []*NetworkConfig
+	for i, raw := range confList.Plugins {
+		var plugin struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(raw, &plugin); err != nil {
+			return nil, fmt.Errorf("failed to parse plugin %d: %w", i, err)
+		}
+		if plugin.Type != pluginType {
+			continue
+		}
+
+		nwCfg, err := ParseNetworkConfig(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse plugin %d: %w", i, err)
+		}
+		nwCfg.Name = confList.Name
+		if confList.CNIVersion != "" {
+			nwCfg.CNIVersion = confList.CNIVersion
+		}
+		nwCfgs = append(nwCfgs, nwCfg)
+	}
+
+	if len(nwCfgs) == 0 {
+		return nil, fmt.Errorf("no plugin of type %s found in conflist", pluginType)
+	}
+
+	return nwCfgs, nil
+}
+
 // GetPoliciesFromNwCfg returns network policies from network config.
 func GetPoliciesFromNwCfg(kvp []KVPair) []policy.Policy {
 	var policies []policy.Policy
@@ -0,0 +1,435 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/azure-container-networking/cni"
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/network"
+	cniInvoke "github.com/containernetworking/cni/pkg/invoke"
+	cniSkel "github.com/containernetworking/cni/pkg/skel"
+	cniTypesCurr "github.com/containernetworking/cni/pkg/types/100"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	// NetworksAnnotation lists the secondary networks requested by a pod.
+	NetworksAnnotation = "k8s.v1.cni.cncf.io/networks"
+
+	attachmentPluginType         = "azure-vnet"
+	attachmentIfNamePrefix       = "net"
+	attachmentIfNameEnv          = "CNI_IFNAME"
+	defaultAttachmentKubeconfig  = "/var/lib/kubelet/kubeconfig"
+	attachmentInterfaceKeyPrefix = "attachment-"
+)
+
+var (
+	errAttachmentStateless   = errors.New("network attachments are not supported in stateless cni mode")
+	errAttachmentPlatform    = errors.New("network attachments are not supported on this platform")
+	errAttachmentMode        = errors.New("network attachments require transparent mode")
+	errAttachmentMaster      = errors.New("attachment does not specify a master interface")
+	errAttachmentIpam        = errors.New("attachment does not specify an ipam plugin")
+	errAttachmentPlugin      = errors.New("attachment uses an unsupported plugin")
+	errAttachmentIfName      = errors.New("attachment interface name is already in use")
+	errAttachmentSelection   = errors.New("invalid network selection")
+	errAttachmentNotInConfig = errors.New("NetworkAttachmentDefinition has no azure-vnet plugin")
+)
+
+var networkAttachmentDefinitionResource = schema.GroupVersionResource{
+	Group:    "k8s.cni.cncf.io",
+	Version:  "v1",
+	Resource: "network-attachment-definitions",
+}
+
+// NetworkSelectionElement is one network requested in the k8s.v1.cni.cncf.io/networks annotation.
+type NetworkSelectionElement struct {
+	Name             string `json:"name"`
+	Namespace        string `json:"namespace,omitempty"`
+	InterfaceRequest string `json:"interface,omitempty"`
+}
+
+// AttachmentClient reads the networks requested by a pod and the NetworkAttachmentDefinitions they reference.
+type AttachmentClient interface {
+	GetPodAnnotations(ctx context.Context, namespace, name string) (map[string]string, error)
+	GetNetworkAttachmentConfig(ctx context.Context, namespace, name string) ([]byte, error)
+}
+
+// attachmentIpam runs the ipam plugin named in an attachment's configuration.
+type attachmentIpam interface {
+	Add(ipamType, ifName string, config []byte) (*cniTypesCurr.Result, error)
+	Delete(ipamType, ifName string, config []byte) error
+}
+
+type k8sAttachmentClient struct {
+	kubeClient    kubernetes.Interface
+	dynamicClient dynamic.Interface
+}
+
+// NewAttachmentClient creates an AttachmentClient from the kubeconfig at the given path.
+func NewAttachmentClient(kubeconfig string) (AttachmentClient, error) {
+	if kubeconfig == "" {
+		kubeconfig = defaultAttachmentKubeconfig
+	}
+
+	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load kubeconfig")
+	}
+
+	kubeClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create kubernetes client")
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create dynamic client")
+	}
+
+	return &k8sAttachmentClient{kubeClient: kubeClient, dynamicClient: dynamicClient}, nil
+}
+
+func (c *k8sAttachmentClient) GetPodAnnotations(ctx context.Context, namespace, name string) (map[string]string, error) {
+	pod, err := c.kubeClient.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get pod %s/%s", namespace, name)
+	}
+	return pod.Annotations, nil
+}
+
+func (c *k8sAttachmentClient) GetNetworkAttachmentConfig(ctx context.Context, namespace, name string) ([]byte, error) {
+	nad, err := c.dynamicClient.Resource(networkAttachmentDefinitionResource).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get NetworkAttachmentDefinition %s/%s", namespace, name)
+	}
+
+	config, found, err := unstructured.NestedString(nad.Object, "spec", "config")
+	if err != nil || !found || config == "" {
+		return nil, errors.Errorf("NetworkAttachmentDefinition %s/%s has no spec.config", namespace, name)
+	}
+	return []byte(config), nil
+}
+
+// delegatingAttachmentIpam invokes the attachment's ipam plugin binary.
+type delegatingAttachmentIpam struct{}
+
+func (delegatingAttachmentIpam) Add(ipamType, ifName string, config []byte) (*cniTypesCurr.Result, error) {
+	defer setAttachmentIfName(ifName)()
+	defer setAttachmentEnv(cni.Cmd, cni.CmdAdd)()
+
+	res, err := cniInvoke.DelegateAdd(context.TODO(), ipamType, config, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to delegate ADD to %s", ipamType)
+	}
+
+	result, err := cniTypesCurr.NewResultFromResult(res)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert ipam result")
+	}
+	return result, nil
+}
+
+func (delegatingAttachmentIpam) Delete(ipamType, ifName string, config []byte) error {
+	defer setAttachmentIfName(ifName)()
+	defer setAttachmentEnv(cni.Cmd, cni.CmdDel)()
+
+	err := cniInvoke.DelegateDel(context.TODO(), ipamType, config, nil)
+	return errors.Wrapf(err, "failed to delegate DEL to %s", ipamType)
+}
+
+// Points CNI_IFNAME at the attachment so ipam plugins key their reservations by it,
+// and returns a func restoring the original value.
+func setAttachmentIfName(ifName string) func() {
+	return setAttachmentEnv(attachmentIfNameEnv, ifName)
+}
+
+// Sets the environment variable for a delegated ipam call, and returns a func restoring the original value.
+func setAttachmentEnv(name, value string) func() {
+	prev := os.Getenv(name)
+	os.Setenv(name, value)
+	return func() {
+		os.Setenv(name, prev)
+	}
+}
+
+// ParseNetworkSelection parses the k8s.v1.cni.cncf.io/networks annotation, which is either a JSON list of
+// NetworkSelectionElement or a comma separated list of [namespace/]name[@interface].
+func ParseNetworkSelection(annotation, defaultNamespace string) ([]NetworkSelectionElement, error) {
+	annotation = strings.TrimSpace(annotation)
+	if annotation == "" {
+		return nil, nil
+	}
+
+	var elements []NetworkSelectionElement
+	if strings.HasPrefix(annotation, "[") {
+		if err := json.Unmarshal([]byte(annotation), &elements); err != nil {
+			return nil, errors.Wrapf(errAttachmentSelection, "%v", err)
+		}
+	} else {
+		for _, item := range strings.Split(annotation, ",") {
+			item = strings.TrimSpace(item)
+			element := NetworkSelectionElement{}
+			if i := strings.LastIndex(item, "@"); i >= 0 {
+				element.InterfaceRequest = item[i+1:]
+				item = item[:i]
+			}
+			if i := strings.Index(item, "/"); i >= 0 {
+				element.Namespace = item[:i]
+				item = item[i+1:]
+			}
+			element.Name = item
+			elements = append(elements, element)
+		}
+	}
+
+	for i := range elements {
+		if elements[i].Name == "" {
+			return nil, errors.Wrapf(errAttachmentSelection, "entry %d has no network name", i)
+		}
+		if elements[i].Namespace == "" {
+			elements[i].Namespace = defaultNamespace
+		}
+	}
+
+	return elements, nil
+}
+
+// Extracts the azure-vnet configuration from a NetworkAttachmentDefinition's spec.config, which may be a
+// single network configuration or a configuration list. The returned bytes are the configuration to pass to ipam.
+func parseAttachmentConfig(b []byte) (*cni.NetworkConfig, []byte, error) {
+	var probe struct {
+		Plugins []json.RawMessage `json:"plugins"`
+	}
+	if err := json.Unmarshal(b, &probe); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to parse attachment config")
+	}
+
+	if probe.Plugins != nil {
+		var err error
+		if b, err = selectAttachmentPlugin(b); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	nwCfg, err := cni.ParseNetworkConfig(b)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to parse attachment config")
+	}
+
+	switch {
+	case nwCfg.Type != "" && nwCfg.Type != attachmentPluginType:
+		return nil, nil, errors.Wrapf(errAttachmentPlugin, "%q", nwCfg.Type)
+	case nwCfg.Master == "":
+		return nil, nil, errAttachmentMaster
+	case nwCfg.IPAM.Type == "":
+		return nil, nil, errAttachmentIpam
+	case nwCfg.IPAM.Type == network.AzureCNS:
+		return nil, nil, errors.Wrapf(errAttachmentIpam, "%s cannot allocate addresses for attachments", network.AzureCNS)
+	}
+
+	return nwCfg, b, nil
+}
+
+// Returns the azure-vnet plugin of a configuration list, with the list's name and cniVersion applied.
+func selectAttachmentPlugin(b []byte) ([]byte, error) {
+	var list struct {
+		CNIVersion string                       `json:"cniVersion"`
+		Name       string                       `json:"name"`
+		Plugins    []map[string]json.RawMessage `json:"plugins"`
+	}
+	if err := json.Unmarshal(b, &list); err != nil {
+		return nil, errors.Wrap(err, "failed to parse attachment config list")
+	}
+
+	for _, plugin := range list.Plugins {
+		var pluginType string
+		if err := json.Unmarshal(plugin["type"], &pluginType); err != nil || pluginType != attachmentPluginType {
+			continue
+		}
+		plugin["name"], _ = json.Marshal(list.Name)
+		plugin["cniVersion"], _ = json.Marshal(list.CNIVersion)
+		return json.Marshal(plugin) //nolint:wrapcheck // marshalling a map of raw messages cannot fail
+	}
+
+	return nil, errAttachmentNotInConfig
+}
+
+// addAttachments allocates addresses for the secondary networks requested in the pod's networks annotation
+// and adds an interface info per attachment to ipamAddResult. Each attachment moves its master interface into
+// the pod through the secondary endpoint client and is named net1, net2 and so on unless an interface is requested.
+func (plugin *NetPlugin) addAttachments(nwCfg *cni.NetworkConfig, args *cniSkel.CmdArgs, podName, podNamespace string, ipamAddResult *IPAMAddResult) error {
+	switch {
+	case !isNetworkAttachmentSupported():
+		return errAttachmentPlatform
+	case nwCfg.Mode != OpModeTransparent:
+		return errAttachmentMode
+	case plugin.nm.IsStatelessCNIMode():
+		return errAttachmentStateless
+	}
+
+	if plugin.attachmentClient == nil {
+		client, err := NewAttachmentClient(nwCfg.NetworkAttachments.Kubeconfig)
+		if err != nil {
+			return err
+		}
+		plugin.attachmentClient = client
+	}
+	if plugin.attachmentIpam == nil {
+		plugin.attachmentIpam = delegatingAttachmentIpam{}
+	}
+
+	annotations, err := plugin.attachmentClient.GetPodAnnotations(context.TODO(), podNamespace, podName)
+	if err != nil {
+		return err
+	}
+
+	elements, err := ParseNetworkSelection(annotations[NetworksAnnotation], podNamespace)
+	if err != nil {
+		return err
+	}
+
+	ifNames := map[string]bool{args.IfName: true}
+	for i, element := range elements {
+		ifName := element.InterfaceRequest
+		if ifName == "" {
+			ifName = attachmentIfNamePrefix + strconv.Itoa(i+1)
+		}
+		if ifNames[ifName] {
+			return errors.Wrapf(errAttachmentIfName, "%s", ifName)
+		}
+		ifNames[ifName] = true
+
+		attachmentName := element.Namespace + "/" + element.Name
+		ifInfo, err := plugin.addAttachment(attachmentName, ifName, element)
+		if err != nil {
+			return errors.Wrapf(err, "failed to add attachment %s", attachmentName)
+		}
+
+		ipamAddResult.interfaceInfo[attachmentInterfaceKeyPrefix+ifName] = *ifInfo
+		sendEvent(plugin, fmt.Sprintf("Allocated attachment %s on %s: %+v", attachmentName, ifName, ifInfo.IPConfigs))
+	}
+
+	return nil
+}
+
+func (plugin *NetPlugin) addAttachment(attachmentName, ifName string, element NetworkSelectionElement) (*network.InterfaceInfo, error) {
+	rawConfig, err := plugin.attachmentClient.GetNetworkAttachmentConfig(context.TODO(), element.Namespace, element.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	attCfg, config, err := parseAttachmentConfig(rawConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	masterIf, err := plugin.findInterfaceByName(attCfg.Master)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Allocating attachment addresses",
+		zap.String("attachment", attachmentName),
+		zap.String("ifName", ifName),
+		zap.String("master", attCfg.Master),
+		zap.String("ipam", attCfg.IPAM.Type))
+
+	result, err := plugin.attachmentIpam.Add(attCfg.IPAM.Type, ifName, config)
+	if err != nil {
+		return nil, err
+	}
+
+	ifInfo := convertCniResultToInterfaceInfo(result)
+	ifInfo.NICType = cns.NodeNetworkInterfaceFrontendNIC
+	ifInfo.MacAddress = masterIf.HardwareAddr
+	ifInfo.Attachment = &network.AttachmentInfo{
+		Name:   attachmentName,
+		IfName: ifName,
+		Config: config,
+	}
+
+	// the secondary endpoint client expects routes; fall back to the on-link subnet routes of the allocated addresses
+	if len(ifInfo.Routes) == 0 {
+		for _, ipConfig := range ifInfo.IPConfigs {
+			subnet := net.IPNet{IP: ipConfig.Address.IP.Mask(ipConfig.Address.Mask), Mask: ipConfig.Address.Mask}
+			ifInfo.Routes = append(ifInfo.Routes, network.RouteInfo{Dst: subnet})
+		}
+	}
+
+	if len(ifInfo.IPConfigs) == 0 {
+		// release what the ipam may have reserved before failing
+		if delErr := plugin.attachmentIpam.Delete(attCfg.IPAM.Type, ifName, config); delErr != nil {
+			logger.Error("Failed to release attachment", zap.String("attachment", attachmentName), zap.Error(delErr))
+		}
+		return nil, errors.Errorf("ipam %s returned no addresses", attCfg.IPAM.Type)
+	}
+
+	return &ifInfo, nil
+}
+
+// releaseAttachment releases the addresses of an attachment through its own ipam.
+func (plugin *NetPlugin) releaseAttachment(attachment *network.AttachmentInfo) error {
+	if plugin.attachmentIpam == nil {
+		plugin.attachmentIpam = delegatingAttachmentIpam{}
+	}
+
+	attCfg, err := cni.ParseNetworkConfig(attachment.Config)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse config of attachment %s", attachment.Name)
+	}
+
+	logger.Info("Releasing attachment addresses", zap.String("attachment", attachment.Name), zap.String("ifName", attachment.IfName))
+	return plugin.attachmentIpam.Delete(attCfg.IPAM.Type, attachment.IfName, attachment.Config)
+}
+
+func (plugin *NetPlugin) findInterfaceByName(ifName string) (*net.Interface, error) {
+	interfaces, err := plugin.netClient.GetNetworkInterfaces()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list interfaces")
+	}
+
+	for i := range interfaces {
+		if interfaces[i].Name == ifName {
+			return &interfaces[i], nil
+		}
+	}
+
+	return nil, errors.Errorf("interface %s not found on host", ifName)
+}
+
+// Appends the attachment interfaces and their addresses to the cni result.
+func addAttachmentsToResult(epInfos []*network.EndpointInfo, netns string, result *cniTypesCurr.Result) {
+	for _, epInfo := range epInfos {
+		if epInfo.Attachment == nil {
+			continue
+		}
+
+		result.Interfaces = append(result.Interfaces, &cniTypesCurr.Interface{
+			Name:    epInfo.Attachment.IfName,
+			Mac:     epInfo.MacAddress.String(),
+			Mtu:     epInfo.MTU,
+			Sandbox: netns,
+		})
+		index := len(result.Interfaces) - 1
+
+		for _, address := range epInfo.IPAddresses {
+			result.IPs = append(result.IPs, &cniTypesCurr.IPConfig{
+				Interface: &index,
+				Address:   address,
+			})
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"runtime"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/cni"
+	"github.com/Azure/azure-container-networking/cns"
+	acnnetwork "github.com/Azure/azure-container-networking/network"
+	"github.com/Azure/azure-container-networking/telemetry"
+	cniSkel "github.com/containernetworking/cni/pkg/skel"
+	cniTypesCurr "github.com/containernetworking/cni/pkg/types/100"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+const attachmentConfig = `{
+	"cniVersion": "0.3.0",
+	"name": "storage",
+	"type": "azure-vnet",
+	"master": "eth1",
+	"ipam": {"type": "host-local", "ranges": [[{"subnet": "192.168.10.0/24"}]]}
+}`
+
+type mockAttachmentClient struct {
+	annotations map[string]string
+	configs     map[string]string
+}
+
+func (m *mockAttachmentClient) GetPodAnnotations(context.Context, string, string) (map[string]string, error) {
+	return m.annotations, nil
+}
+
+func (m *mockAttachmentClient) GetNetworkAttachmentConfig(_ context.Context, namespace, name string) ([]byte, error) {
+	config, ok := m.configs[namespace+"/"+name]
+	if !ok {
+		return nil, errors.Errorf("NetworkAttachmentDefinition %s/%s not found", namespace, name)
+	}
+	return []byte(config), nil
+}
+
+type mockAttachmentIpam struct {
+	added    []string
+	released []string
+}
+
+func (m *mockAttachmentIpam) Add(_, ifName string, _ []byte) (*cniTypesCurr.Result, error) {
+	m.added = append(m.added, ifName)
+	_, ipNet, _ := net.ParseCIDR("192.168.10.0/24")
+	ipNet.IP = net.ParseIP(fmt.Sprintf("192.168.10.%d", len(m.added)+1))
+	return &cniTypesCurr.Result{IPs: []*cniTypesCurr.IPConfig{{Address: *ipNet}}}, nil
+}
+
+func (m *mockAttachmentIpam) Delete(_, ifName string, _ []byte) error {
+	m.released = append(m.released, ifName)
+	return nil
+}
+
+func TestParseNetworkSelection(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+		want       []NetworkSelectionElement
+		wantErr    bool
+	}{
+		{
+			name:       "empty",
+			annotation: "",
+		},
+		{
+			name:       "comma separated",
+			annotation: "storage, other/backend@data",
+			want: []NetworkSelectionElement{
+				{Name: "storage", Namespace: "pod-ns"},
+				{Name: "backend", Namespace: "other", InterfaceRequest: "data"},
+			},
+		},
+		{
+			name:       "json",
+			annotation: `[{"name":"storage","interface":"net5"},{"name":"backend","namespace":"other"}]`,
+			want: []NetworkSelectionElement{
+				{Name: "storage", Namespace: "pod-ns", InterfaceRequest: "net5"},
+				{Name: "backend", Namespace: "other"},
+			},
+		},
+		{
+			name:       "missing name",
+			annotation: "other/",
+			wantErr:    true,
+		},
+		{
+			name:       "invalid json",
+			annotation: `[{"name":}]`,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseNetworkSelection(tt.annotation, "pod-ns")
+			if tt.wantErr {
+				require.ErrorIs(t, err, errAttachmentSelection)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseAttachmentConfig(t *testing.T) {
+	nwCfg, _, err := parseAttachmentConfig([]byte(attachmentConfig))
+	require.NoError(t, err)
+	require.Equal(t, "eth1", nwCfg.Master)
+	require.Equal(t, "host-local", nwCfg.IPAM.Type)
+
+	list := `{"cniVersion":"0.3.0","name":"storage","plugins":[{"type":"azure-vnet","master":"eth1","ipam":{"type":"host-local"}},{"type":"portmap"}]}`
+	nwCfg, config, err := parseAttachmentConfig([]byte(list))
+	require.NoError(t, err)
+	require.Equal(t, "storage", nwCfg.Name)
+	require.Contains(t, string(config), `"name":"storage"`)
+
+	_, _, err = parseAttachmentConfig([]byte(`{"name":"x","type":"macvlan","master":"eth1","ipam":{"type":"host-local"}}`))
+	require.ErrorIs(t, err, errAttachmentPlugin)
+
+	_, _, err = parseAttachmentConfig([]byte(`{"name":"x","type":"azure-vnet","ipam":{"type":"host-local"}}`))
+	require.ErrorIs(t, err, errAttachmentMaster)
+
+	_, _, err = parseAttachmentConfig([]byte(`{"name":"x","type":"azure-vnet","master":"eth1","ipam":{"type":"azure-cns"}}`))
+	require.ErrorIs(t, err, errAttachmentIpam)
+
+	_, _, err = parseAttachmentConfig([]byte(`{"name":"x","plugins":[{"type":"portmap"}]}`))
+	require.ErrorIs(t, err, errAttachmentNotInConfig)
+}
+
+func TestPluginAddWithAttachments(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("network attachments are linux only")
+	}
+
+	plugin, _ := cni.NewPlugin("name", "0.3.0")
+	eth1Mac, _ := net.ParseMAC("00:0d:3a:00:00:01")
+
+	localNwCfg := cni.NetworkConfig{
+		CNIVersion:         "0.3.0",
+		Name:               "attachments",
+		Mode:               OpModeTransparent,
+		Master:             eth0IfName,
+		NetworkAttachments: &cni.Attachments{},
+		IPAM:               cni.IPAM{Type: "azure-cns"},
+	}
+	args := &cniSkel.CmdArgs{
+		StdinData:   localNwCfg.Serialize(),
+		ContainerID: "test-container",
+		Netns:       "test-container",
+		Args:        fmt.Sprintf("K8S_POD_NAME=%v;K8S_POD_NAMESPACE=%v", "test-pod", "test-pod-ns"),
+		IfName:      eth0IfName,
+	}
+
+	ipam := &mockAttachmentIpam{}
+	netPlugin := &NetPlugin{
+		Plugin:      plugin,
+		nm:          acnnetwork.NewMockNetworkmanager(acnnetwork.NewMockEndpointClient(nil)),
+		ipamInvoker: NewMockIpamInvoker(false, false, false, false, false),
+		report:      &telemetry.CNIReport{},
+		tb:          &telemetry.TelemetryBuffer{},
+		netClient: &InterfaceGetterMock{
+			interfaces: []net.Interface{
+				{Name: eth0IfName},
+				{Name: "eth1", HardwareAddr: eth1Mac},
+			},
+		},
+		attachmentClient: &mockAttachmentClient{
+			annotations: map[string]string{NetworksAnnotation: "storage"},
+			configs:     map[string]string{"test-pod-ns/storage": attachmentConfig},
+		},
+		attachmentIpam: ipam,
+	}
+
+	require.NoError(t, netPlugin.Add(args))
+	require.Equal(t, []string{"net1"}, ipam.added)
+
+	epInfos := netPlugin.nm.GetEndpointInfosFromContainerID(args.ContainerID)
+	require.Len(t, epInfos, 2)
+	var attachment *acnnetwork.EndpointInfo
+	for _, epInfo := range epInfos {
+		if epInfo.Attachment != nil {
+			attachment = epInfo
+		}
+	}
+	require.NotNil(t, attachment)
+	require.Equal(t, cns.NodeNetworkInterfaceFrontendNIC, attachment.NICType)
+	require.Equal(t, "net1", attachment.Attachment.IfName)
+	require.Equal(t, "test-pod-ns/storage", attachment.Attachment.Name)
+	require.Equal(t, eth1Mac, attachment.MacAddress)
+
+	require.NoError(t, netPlugin.Delete(args))
+	require.Equal(t, []string{"net1"}, ipam.released)
+}
+
+func TestPluginAddWithAttachmentsFailureReleases(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("network attachments are linux only")
+	}
+
+	plugin, _ := cni.NewPlugin("name", "0.3.0")
+	eth1Mac, _ := net.ParseMAC("00:0d:3a:00:00:01")
+
+	localNwCfg := cni.NetworkConfig{
+		CNIVersion:         "0.3.0",
+		Name:               "attachments",
+		Mode:               OpModeTransparent,
+		Master:             eth0IfName,
+		NetworkAttachments: &cni.Attachments{},
+		IPAM:               cni.IPAM{Type: "azure-cns"},
+	}
+	args := &cniSkel.CmdArgs{
+		StdinData:   localNwCfg.Serialize(),
+		ContainerID: "test-container",
+		Netns:       "test-container",
+		Args:        fmt.Sprintf("K8S_POD_NAME=%v;K8S_POD_NAMESPACE=%v", "test-pod", "test-pod-ns"),
+		IfName:      eth0IfName,
+	}
+
+	ipam := &mockAttachmentIpam{}
+	netPlugin := &NetPlugin{
+		Plugin:      plugin,
+		nm:          acnnetwork.NewMockNetworkmanager(acnnetwork.NewMockEndpointClient(nil)),
+		ipamInvoker: NewMockIpamInvoker(false, false, false, false, false),
+		report:      &telemetry.CNIReport{},
+		tb:          &telemetry.TelemetryBuffer{},
+		netClient: &InterfaceGetterMock{
+			interfaces: []net.Interface{
+				{Name: eth0IfName},
+				{Name: "eth1", HardwareAddr: eth1Mac},
+			},
+		},
+		// the second network does not exist, so the first attachment must be released
+		attachmentClient: &mockAttachmentClient{
+			annotations: map[string]string{NetworksAnnotation: "storage,missing"},
+			configs:     map[string]string{"test-pod-ns/storage": attachmentConfig},
+		},
+		attachmentIpam: ipam,
+	}
+
+	err := netPlugin.Add(args)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "test-pod-ns/missing")
+	require.Equal(t, []string{"net1"}, ipam.released)
+}
@@ -0,0 +1,438 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cni"
+	"github.com/Azure/azure-container-networking/cni/util"
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/Azure/azure-container-networking/netio"
+	"github.com/Azure/azure-container-networking/netlink"
+	"github.com/Azure/azure-container-networking/network"
+	"github.com/Azure/azure-container-networking/telemetry"
+	"github.com/pkg/errors"
+)
+
+// The pod the dry-run ADD is issued for.
+const (
+	DryRunPodName      = "dry-run"
+	DryRunPodNamespace = "default"
+)
+
+var (
+	errDryRunMultitenancy = errors.New("dry-run is not supported with multiTenancy")
+	errDryRunMode         = errors.Errorf("dry-run only supports mode %q, as the bridge and OVS clients program ebtables and OVS directly", OpModeTransparent)
+	errDryRunPowershell   = errors.New("powershell is not available in dry-run")
+	errDryRunCNSAPI       = errors.New("only the RequestIPs and ReleaseIPs CNS APIs are answered in dry-run")
+)
+
+// DryRunConfig holds the addresses handed out by the fake CNS or IPAM used during a dry-run ADD.
+// The host subnet defaults to the subnet of the pod.
+type DryRunConfig struct {
+	PodIP            net.IPNet
+	Gateway          net.IP
+	HostPrimaryIP    net.IP
+	HostSubnetPrefix net.IPNet
+}
+
+// Recorder collects the host programming operations issued during a dry-run ADD.
+type Recorder struct {
+	sync.Mutex
+	ops []string
+}
+
+func (r *Recorder) record(format string, args ...any) {
+	r.Lock()
+	defer r.Unlock()
+	r.ops = append(r.ops, fmt.Sprintf(format, args...))
+}
+
+// Operations returns the recorded operations in the order they were issued.
+func (r *Recorder) Operations() []string {
+	r.Lock()
+	defer r.Unlock()
+	return append([]string(nil), r.ops...)
+}
+
+// NewDryRunPlugin returns a NetPlugin wired to the real network manager, but whose netlink, exec,
+// iptables and dhcp clients only record what they would program. Read-only lookups of existing
+// host interfaces still go to the host so the master interface can be resolved.
+// Addresses are requested from a fake CNS for the azure-cns IPAM, so that the CNS request path is
+// exercised, and handed out by a mock invoker for the other IPAMs, which would otherwise run their plugin.
+// Only the transparent mode is supported, as the bridge and OVS clients program ebtables and OVS
+// through clients of their own rather than the recording ones.
+func NewDryRunPlugin(name string, nwCfg *cni.NetworkConfig, cfg DryRunConfig, recorder *Recorder) (*NetPlugin, error) {
+	if nwCfg.MultiTenancy {
+		return nil, errDryRunMultitenancy
+	}
+	if nwCfg.Mode != OpModeTransparent {
+		return nil, errDryRunMode
+	}
+	if cfg.HostSubnetPrefix.IP == nil {
+		cfg.HostSubnetPrefix = net.IPNet{IP: cfg.PodIP.IP.Mask(cfg.PodIP.Mask), Mask: cfg.PodIP.Mask}
+	}
+
+	plugin, err := cni.NewPlugin(name, "")
+	if err != nil {
+		return nil, err
+	}
+
+	netioCli := newDryRunNetIO()
+	nm, err := network.NewNetworkManager(&dryRunNetlink{recorder: recorder, netio: netioCli}, &dryRunExecClient{recorder: recorder},
+		netioCli, network.NewMockNamespaceClient(), &dryRunIPTables{recorder: recorder}, &dryRunDHCP{recorder: recorder})
+	if err != nil {
+		return nil, err
+	}
+
+	var ipamInvoker IPAMInvoker
+	if nwCfg.IPAM.Type == network.AzureCNS {
+		ipamInvoker = NewCNSInvoker(DryRunPodName, DryRunPodNamespace, &dryRunCNSClient{recorder: recorder, cfg: cfg},
+			util.ExecutionMode(nwCfg.ExecutionMode), util.IpamMode(nwCfg.IPAM.Mode))
+	} else {
+		ipamInvoker = NewCustomMockIpamInvoker(map[string]network.InterfaceInfo{
+			string(cns.InfraNIC): {
+				IPConfigs:        []*network.IPConfig{{Address: cfg.PodIP, Gateway: cfg.Gateway}},
+				NICType:          cns.InfraNIC,
+				HostSubnetPrefix: cfg.HostSubnetPrefix,
+			},
+		})
+	}
+
+	return &NetPlugin{
+		Plugin:      plugin,
+		nm:          nm,
+		ipamInvoker: ipamInvoker,
+		netClient:   &netio.NetIO{},
+		tb:          &telemetry.TelemetryBuffer{},
+		report:      &telemetry.CNIReport{},
+	}, nil
+}
+
+// dryRunCNSClient answers IP requests with the addresses of the dry-run config instead of calling CNS.
+type dryRunCNSClient struct {
+	recorder *Recorder
+	cfg      DryRunConfig
+}
+
+func (c *dryRunCNSClient) RequestIPs(_ context.Context, ipconfigs cns.IPConfigsRequest) (*cns.IPConfigsResponse, error) {
+	c.recorder.record("cns request ips %s interface %s container %s", ipconfigs.OrchestratorContext, ipconfigs.PodInterfaceID, ipconfigs.InfraContainerID)
+	ones, _ := c.cfg.PodIP.Mask.Size()
+	hostPrimaryIP := ""
+	if c.cfg.HostPrimaryIP != nil {
+		hostPrimaryIP = c.cfg.HostPrimaryIP.String()
+	}
+	return &cns.IPConfigsResponse{
+		Response: cns.Response{ReturnCode: types.Success},
+		PodIPInfo: []cns.PodIpInfo{
+			{
+				PodIPConfig: cns.IPSubnet{IPAddress: c.cfg.PodIP.IP.String(), PrefixLength: uint8(ones)},
+				NetworkContainerPrimaryIPConfig: cns.IPConfiguration{
+					IPSubnet:         cns.IPSubnet{IPAddress: c.cfg.PodIP.IP.Mask(c.cfg.PodIP.Mask).String(), PrefixLength: uint8(ones)},
+					GatewayIPAddress: c.cfg.Gateway.String(),
+				},
+				HostPrimaryIPInfo: cns.HostIPInfo{
+					Gateway:   c.cfg.Gateway.String(),
+					PrimaryIP: hostPrimaryIP,
+					Subnet:    c.cfg.HostSubnetPrefix.String(),
+				},
+				NICType: cns.InfraNIC,
+			},
+		},
+	}, nil
+}
+
+func (c *dryRunCNSClient) RequestIPAddress(context.Context, cns.IPConfigRequest) (*cns.IPConfigResponse, error) {
+	return nil, errDryRunCNSAPI
+}
+
+func (c *dryRunCNSClient) ReleaseIPs(_ context.Context, ipconfigs cns.IPConfigsRequest) error {
+	c.recorder.record("cns release ips %s interface %s container %s", ipconfigs.OrchestratorContext, ipconfigs.PodInterfaceID, ipconfigs.InfraContainerID)
+	return nil
+}
+
+func (c *dryRunCNSClient) ReleaseIPAddress(context.Context, cns.IPConfigRequest) error {
+	return errDryRunCNSAPI
+}
+
+func (c *dryRunCNSClient) GetNetworkContainer(context.Context, []byte) (*cns.GetNetworkContainerResponse, error) {
+	return nil, errDryRunCNSAPI
+}
+
+func (c *dryRunCNSClient) GetAllNetworkContainers(context.Context, []byte) ([]cns.GetNetworkContainerResponse, error) {
+	return nil, errDryRunCNSAPI
+}
+
+// dryRunNetIO answers interface lookups for links created during the dry-run from memory
+// and falls back to the host for everything else.
+type dryRunNetIO struct {
+	sync.Mutex
+	host      netio.NetIO
+	links     map[string]*net.Interface
+	nextIndex int
+}
+
+func newDryRunNetIO() *dryRunNetIO {
+	return &dryRunNetIO{
+		links:     make(map[string]*net.Interface),
+		nextIndex: 10000,
+	}
+}
+
+func (n *dryRunNetIO) addLink(name string, mac net.HardwareAddr) {
+	n.Lock()
+	defer n.Unlock()
+	if mac == nil {
+		mac = net.HardwareAddr{0x02, 0, 0, 0, byte(n.nextIndex >> 8), byte(n.nextIndex)}
+	}
+	n.links[name] = &net.Interface{Index: n.nextIndex, Name: name, HardwareAddr: mac, MTU: 1500}
+	n.nextIndex++
+}
+
+func (n *dryRunNetIO) renameLink(name, newName string) {
+	n.Lock()
+	defer n.Unlock()
+	if iface, ok := n.links[name]; ok {
+		delete(n.links, name)
+		iface.Name = newName
+		n.links[newName] = iface
+	}
+}
+
+func (n *dryRunNetIO) deleteLink(name string) {
+	n.Lock()
+	defer n.Unlock()
+	delete(n.links, name)
+}
+
+func (n *dryRunNetIO) GetNetworkInterfaceByName(name string) (*net.Interface, error) {
+	n.Lock()
+	iface, ok := n.links[name]
+	n.Unlock()
+	if ok {
+		return iface, nil
+	}
+	return n.host.GetNetworkInterfaceByName(name)
+}
+
+func (n *dryRunNetIO) GetNetworkInterfaceAddrs(iface *net.Interface) ([]net.Addr, error) {
+	n.Lock()
+	_, ok := n.links[iface.Name]
+	n.Unlock()
+	if ok {
+		return []net.Addr{}, nil
+	}
+	return n.host.GetNetworkInterfaceAddrs(iface)
+}
+
+func (n *dryRunNetIO) GetNetworkInterfaceByMac(mac net.HardwareAddr) (*net.Interface, error) {
+	return n.host.GetNetworkInterfaceByMac(mac)
+}
+
+// dryRunNetlink records link, address and route changes instead of programming them.
+type dryRunNetlink struct {
+	recorder *Recorder
+	netio    *dryRunNetIO
+}
+
+func (d *dryRunNetlink) AddLink(link netlink.Link) error {
+	info := link.Info()
+	if veth, ok := link.(*netlink.VEthLink); ok {
+		d.recorder.record("ip link add %s type veth peer name %s", info.Name, veth.PeerName)
+		d.netio.addLink(veth.PeerName, nil)
+	} else {
+		d.recorder.record("ip link add %s type %s", info.Name, info.Type)
+	}
+	d.netio.addLink(info.Name, info.MacAddress)
+	return nil
+}
+
+func (d *dryRunNetlink) DeleteLink(name string) error {
+	d.recorder.record("ip link del %s", name)
+	d.netio.deleteLink(name)
+	return nil
+}
+
+func (d *dryRunNetlink) SetLinkName(name, newName string) error {
+	d.recorder.record("ip link set %s name %s", name, newName)
+	d.netio.renameLink(name, newName)
+	return nil
+}
+
+func (d *dryRunNetlink) SetLinkState(name string, up bool) error {
+	state := "down"
+	if up {
+		state = "up"
+	}
+	d.recorder.record("ip link set %s %s", name, state)
+	return nil
+}
+
+func (d *dryRunNetlink) SetLinkMTU(name string, mtu int) error {
+	d.recorder.record("ip link set %s mtu %d", name, mtu)
+	return nil
+}
+
+func (d *dryRunNetlink) SetLinkMaster(name, master string) error {
+	d.recorder.record("ip link set %s master %s", name, master)
+	return nil
+}
+
+func (d *dryRunNetlink) SetLinkNetNs(name string, fd uintptr) error {
+	d.recorder.record("ip link set %s netns %d", name, fd)
+	return nil
+}
+
+func (d *dryRunNetlink) SetLinkAddress(ifName string, hwAddress net.HardwareAddr) error {
+	d.recorder.record("ip link set %s address %s", ifName, hwAddress)
+	return nil
+}
+
+func (d *dryRunNetlink) SetLinkPromisc(ifName string, on bool) error {
+	d.recorder.record("ip link set %s promisc %t", ifName, on)
+	return nil
+}
+
+func (d *dryRunNetlink) SetLinkHairpin(bridgeName string, on bool) error {
+	d.recorder.record("bridge link set dev %s hairpin %t", bridgeName, on)
+	return nil
+}
+
+func (d *dryRunNetlink) SetOrRemoveLinkAddress(linkInfo netlink.LinkInfo, mode, linkState int) error {
+	d.recorder.record("ip neigh mode=%d state=%d dev %s lladdr %s ip %s", mode, linkState, linkInfo.Name, linkInfo.MacAddress, linkInfo.IPAddr)
+	return nil
+}
+
+func (d *dryRunNetlink) AddIPAddress(ifName string, ipAddress net.IP, ipNet *net.IPNet) error {
+	d.recorder.record("ip addr add %s dev %s", formatAddress(ipAddress, ipNet), ifName)
+	return nil
+}
+
+func (d *dryRunNetlink) DeleteIPAddress(ifName string, ipAddress net.IP, ipNet *net.IPNet) error {
+	d.recorder.record("ip addr del %s dev %s", formatAddress(ipAddress, ipNet), ifName)
+	return nil
+}
+
+func (d *dryRunNetlink) GetIPRoute(*netlink.Route) ([]*netlink.Route, error) {
+	return nil, nil
+}
+
+func (d *dryRunNetlink) AddIPRoute(route *netlink.Route) error {
+	d.recorder.record("ip route add %s", formatRoute(route))
+	return nil
+}
+
+func (d *dryRunNetlink) DeleteIPRoute(route *netlink.Route) error {
+	d.recorder.record("ip route del %s", formatRoute(route))
+	return nil
+}
+
+func formatAddress(ipAddress net.IP, ipNet *net.IPNet) string {
+	if ipNet == nil {
+		return ipAddress.String()
+	}
+	ones, _ := ipNet.Mask.Size()
+	return fmt.Sprintf("%s/%d", ipAddress, ones)
+}
+
+func formatRoute(route *netlink.Route) string {
+	dst := "default"
+	if route.Dst != nil {
+		dst = route.Dst.String()
+	}
+	s := dst
+	if route.Gw != nil {
+		s += " via " + route.Gw.String()
+	}
+	if route.Src != nil {
+		s += " src " + route.Src.String()
+	}
+	s += fmt.Sprintf(" dev-index %d", route.LinkIndex)
+	if route.Table != 0 {
+		s += fmt.Sprintf(" table %d", route.Table)
+	}
+	if route.Scope != 0 {
+		s += fmt.Sprintf(" scope %d", route.Scope)
+	}
+	return s
+}
+
+// dryRunExecClient records commands instead of running them.
+type dryRunExecClient struct {
+	recorder *Recorder
+}
+
+func (e *dryRunExecClient) ExecuteRawCommand(command string) (string, error) {
+	e.recorder.record("exec: %s", command)
+	return "", nil
+}
+
+func (e *dryRunExecClient) ExecuteCommand(_ context.Context, command string, args ...string) (string, error) {
+	e.recorder.record("exec: %s %v", command, args)
+	return "", nil
+}
+
+func (e *dryRunExecClient) GetLastRebootTime() (time.Time, error) {
+	return time.Time{}, nil
+}
+
+func (e *dryRunExecClient) ClearNetworkConfiguration() (bool, error) {
+	return false, nil
+}
+
+func (e *dryRunExecClient) ExecutePowershellCommand(command string) (string, error) {
+	return "", errDryRunPowershell
+}
+
+func (e *dryRunExecClient) ExecutePowershellCommandWithContext(_ context.Context, command string) (string, error) {
+	return "", errDryRunPowershell
+}
+
+func (e *dryRunExecClient) KillProcessByName(processName string) error {
+	e.recorder.record("kill: %s", processName)
+	return nil
+}
+
+// dryRunIPTables records iptables rules instead of installing them.
+type dryRunIPTables struct {
+	recorder *Recorder
+}
+
+func (c *dryRunIPTables) InsertIptableRule(version, tableName, chainName, match, target string) error {
+	c.recorder.record("%s -t %s -I %s %s -j %s", version, tableName, chainName, match, target)
+	return nil
+}
+
+func (c *dryRunIPTables) AppendIptableRule(version, tableName, chainName, match, target string) error {
+	c.recorder.record("%s -t %s -A %s %s -j %s", version, tableName, chainName, match, target)
+	return nil
+}
+
+func (c *dryRunIPTables) DeleteIptableRule(version, tableName, chainName, match, target string) error {
+	c.recorder.record("%s -t %s -D %s %s -j %s", version, tableName, chainName, match, target)
+	return nil
+}
+
+func (c *dryRunIPTables) CreateChain(version, tableName, chainName string) error {
+	c.recorder.record("%s -t %s -N %s", version, tableName, chainName)
+	return nil
+}
+
+func (c *dryRunIPTables) RunCmd(version, params string) error {
+	c.recorder.record("%s %s", version, params)
+	return nil
+}
+
+// dryRunDHCP records dhcp requests instead of sending them.
+type dryRunDHCP struct {
+	recorder *Recorder
+}
+
+func (c *dryRunDHCP) DiscoverRequest(_ context.Context, mac net.HardwareAddr, ifName string) error {
+	c.recorder.record("dhcp discover dev %s mac %s", ifName, mac)
+	return nil
+}
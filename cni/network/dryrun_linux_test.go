@@ -0,0 +1,73 @@
+//go:build linux
+// +build linux
+
+package network
+
+import (
+	"net"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/cni"
+	cniSkel "github.com/containernetworking/cni/pkg/skel"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRunPluginAdd(t *testing.T) {
+	hostIf := getFirstHostInterface(t)
+
+	nwCfg := &cni.NetworkConfig{
+		CNIVersion: "0.3.0",
+		Name:       "azure",
+		Type:       "azure-vnet",
+		Mode:       OpModeTransparent,
+		Master:     hostIf,
+		IPAM:       cni.IPAM{Type: "azure-cns"},
+	}
+	podIP := net.IPNet{IP: net.ParseIP("10.240.0.5"), Mask: net.CIDRMask(24, 32)}
+
+	recorder := &Recorder{}
+	cfg := DryRunConfig{PodIP: podIP, Gateway: net.ParseIP("10.240.0.1"), HostPrimaryIP: net.ParseIP("10.240.0.4")}
+	plugin, err := NewDryRunPlugin("net", nwCfg, cfg, recorder)
+	require.NoError(t, err)
+
+	err = plugin.Add(&cniSkel.CmdArgs{
+		StdinData:   nwCfg.Serialize(),
+		ContainerID: "dryrun-container",
+		Netns:       "dryrun-netns",
+		Args:        "K8S_POD_NAME=" + DryRunPodName + ";K8S_POD_NAMESPACE=" + DryRunPodNamespace,
+		IfName:      eth0IfName,
+	})
+	require.NoError(t, err)
+
+	// the addresses are requested from the fake CNS
+	ops := recorder.Operations()
+	require.NotEmpty(t, ops)
+	require.Contains(t, ops[0], "cns request ips")
+	require.Contains(t, ops[0], `"PodName":"dry-run"`)
+	require.Contains(t, ops, "ip addr add 10.240.0.5/24 dev eth0")
+}
+
+func TestDryRunPluginMode(t *testing.T) {
+	// the bridge and OVS clients would program ebtables and OVS on the host
+	for _, mode := range []string{"", "bridge", "tunnel", "transparent-vlan"} {
+		_, err := NewDryRunPlugin("net", &cni.NetworkConfig{Mode: mode}, DryRunConfig{}, &Recorder{})
+		require.ErrorIs(t, err, errDryRunMode, mode)
+	}
+}
+
+func TestDryRunPluginMultitenancy(t *testing.T) {
+	_, err := NewDryRunPlugin("net", &cni.NetworkConfig{MultiTenancy: true}, DryRunConfig{}, &Recorder{})
+	require.ErrorIs(t, err, errDryRunMultitenancy)
+}
+
+func getFirstHostInterface(t *testing.T) string {
+	ifaces, err := net.Interfaces()
+	require.NoError(t, err)
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback == 0 {
+			return iface.Name
+		}
+	}
+	t.Skip("no non-loopback interface on host")
+	return ""
+}
@@ -0,0 +1,38 @@
+package network
+
+import (
+	"net"
+
+	"github.com/Azure/azure-container-networking/cni"
+	"github.com/pkg/errors"
+)
+
+var errDryRunNotSupported = errors.New("dry-run is not supported on windows")
+
+// The pod the dry-run ADD is issued for.
+const (
+	DryRunPodName      = "dry-run"
+	DryRunPodNamespace = "default"
+)
+
+// DryRunConfig holds the addresses handed out by the fake CNS or IPAM used during a dry-run ADD.
+// The host subnet defaults to the subnet of the pod.
+type DryRunConfig struct {
+	PodIP            net.IPNet
+	Gateway          net.IP
+	HostPrimaryIP    net.IP
+	HostSubnetPrefix net.IPNet
+}
+
+// Recorder collects the host programming operations issued during a dry-run ADD.
+type Recorder struct{}
+
+// Operations returns the recorded operations in the order they were issued.
+func (r *Recorder) Operations() []string {
+	return nil
+}
+
+// NewDryRunPlugin is not supported on windows, where endpoints are programmed through HNS.
+func NewDryRunPlugin(string, *cni.NetworkConfig, DryRunConfig, *Recorder) (*NetPlugin, error) {
+	return nil, errDryRunNotSupported
+}
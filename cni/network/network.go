@@ -80,6 +80,8 @@ type NetPlugin struct {
 	*cni.Plugin
 	nm                 network.NetworkManager
 	ipamInvoker        IPAMInvoker
+	attachmentClient   AttachmentClient
+	attachmentIpam     attachmentIpam
 	report             *telemetry.CNIReport
 	tb                 *telemetry.TelemetryBuffer
 	nnsClient          NnsClient
@@ -241,6 +243,47 @@ func (plugin *NetPlugin) findInterfaceByMAC(macAddress string) string {
 	return ""
 }
 
+// getEndpointMTU resolves the mtu policy for an interface of the given nic type.
+// The master interface mtu is only looked up when the policy derives from it, and the default mtu is kept when
+// there is no master interface to derive it from.
+func (plugin *NetPlugin) getEndpointMTU(policy *cni.MTUPolicy, nicType cns.NICType, masterIfName string) (int, error) {
+	var masterMTU int
+	if policy.NeedsMasterMTU(string(nicType)) {
+		interfaces, err := plugin.netClient.GetNetworkInterfaces()
+		if err != nil {
+			return 0, errors.Wrap(err, "failed to get interfaces")
+		}
+		for i := range interfaces {
+			if interfaces[i].Name == masterIfName {
+				masterMTU = interfaces[i].MTU
+				break
+			}
+		}
+		if masterMTU == 0 {
+			logger.Warn("Master interface not found, keeping the default mtu",
+				zap.String("masterIfName", masterIfName), zap.String("nicType", string(nicType)))
+			return 0, nil
+		}
+	}
+
+	mtu, err := policy.Resolve(string(nicType), masterMTU)
+	return mtu, errors.Wrap(err, "invalid mtu policy")
+}
+
+// addMTUToResult surfaces the mtu resolved from the mtu policy on the interfaces the endpoints created.
+func addMTUToResult(epInfos []*network.EndpointInfo, result *cniTypesCurr.Result) {
+	for _, epInfo := range epInfos {
+		if epInfo.MTU == 0 {
+			continue
+		}
+		for _, iface := range result.Interfaces {
+			if iface.Name == epInfo.IfName {
+				iface.Mtu = epInfo.MTU
+			}
+		}
+	}
+}
+
 // findMasterInterfaceBySubnet returns the name of the master interface.
 func (plugin *NetPlugin) findMasterInterfaceBySubnet(nwCfg *cni.NetworkConfig, subnetPrefix *net.IPNet) string {
 	// An explicit master configuration wins. Explicitly specifying a master is
@@ -448,13 +491,18 @@ func (plugin *NetPlugin) Add(args *cniSkel.CmdArgs) error {
 			}
 		}
 
+		addMTUToResult(epInfos, cniResult)
+
 		// stdout multiple cniResults for containerd to create multiple pods
 		// containerd receives each cniResult as the stdout and create pod
 		addSnatInterface(nwCfg, cniResult) //nolint TODO: check whether Linux supports adding secondary snatinterface
 
+		addAttachmentsToResult(epInfos, args.Netns, cniResult)
+
 		// add IB NIC interfaceInfo to cniResult
 		for _, epInfo := range epInfos {
 			if epInfo.NICType == cns.BackendNIC {
+				// the mtu of a backend nic is not programmed, so it is not reported
 				cniResult.Interfaces = append(cniResult.Interfaces, &cniTypesCurr.Interface{
 					Name:  epInfo.MasterIfName,
 					Mac:   epInfo.MacAddress.String(),
@@ -605,9 +653,23 @@ func (plugin *NetPlugin) Add(args *cniSkel.CmdArgs) error {
 					}
 				}
 			}
+
+			for _, ifInfo := range ipamAddResult.interfaceInfo {
+				if ifInfo.Attachment != nil {
+					if er := plugin.releaseAttachment(ifInfo.Attachment); er != nil {
+						logger.Error("Failed to release attachment on failure", zap.Error(er))
+					}
+				}
+			}
 		}
 	}()
 
+	if nwCfg.NetworkAttachments != nil {
+		if err = plugin.addAttachments(nwCfg, args, k8sPodName, k8sNamespace, &ipamAddResult); err != nil {
+			return err
+		}
+	}
+
 	infraSeen := false
 	endpointIndex := 1
 	for key := range ipamAddResult.interfaceInfo {
@@ -734,6 +796,12 @@ func (plugin *NetPlugin) createEpInfo(opt *createEpInfoOpt) (*network.EndpointIn
 		return nil, err
 	}
 
+	mtu, err := plugin.getEndpointMTU(opt.nwCfg.MTUPolicy, opt.ifInfo.NICType, masterIfName)
+	if err != nil {
+		err = plugin.Errorf("Failed to resolve mtu: %v", err)
+		return nil, err
+	}
+
 	networkPolicies := opt.policies // save network policies before we modify the slice pointer for ep policies
 
 	// populate endpoint info
@@ -766,6 +834,9 @@ func (plugin *NetPlugin) createEpInfo(opt *createEpInfoOpt) (*network.EndpointIn
 		ifName = opt.args.IfName
 		endpointID = plugin.nm.GetEndpointID(opt.args.ContainerID, ifName)
 		*opt.infraSeen = true
+	} else if opt.ifInfo.Attachment != nil {
+		ifName = opt.ifInfo.Attachment.IfName
+		endpointID = plugin.nm.GetEndpointID(opt.args.ContainerID, ifName)
 	} else {
 		ifName = "eth" + strconv.Itoa(opt.endpointIndex)
 		endpointID = plugin.nm.GetEndpointID(opt.args.ContainerID, ifName)
@@ -811,6 +882,8 @@ func (plugin *NetPlugin) createEpInfo(opt *createEpInfoOpt) (*network.EndpointIn
 		// the following is used for creating an external interface if we can't find an existing network
 		HostSubnetPrefix: opt.ifInfo.HostSubnetPrefix.String(),
 		PnPID:            opt.ifInfo.PnPID,
+		MTU:              mtu,
+		Attachment:       opt.ifInfo.Attachment,
 	}
 
 	if err = addSubnetToEndpointInfo(*opt.ifInfo, &endpointInfo); err != nil {
@@ -1212,6 +1285,11 @@ func (plugin *NetPlugin) Delete(args *cniSkel.CmdArgs) error {
 					return plugin.RetriableError(fmt.Errorf("failed to release address: %w", err))
 				}
 			}
+		} else if epInfo.Attachment != nil {
+			// attachments were allocated by their own ipam, so release them there
+			if err = plugin.releaseAttachment(epInfo.Attachment); err != nil {
+				return plugin.RetriableError(fmt.Errorf("failed to release attachment: %w", err))
+			}
 		} else if epInfo.EnableInfraVnet { // remove in future PR
 			nwCfg.IPAM.Subnet = nwInfo.Subnets[0].Prefix.String()
 			nwCfg.IPAM.Address = epInfo.InfraVnetIP.IP.String()
@@ -130,6 +130,11 @@ func (plugin *NetPlugin) isDualNicFeatureSupported(netNs string) bool {
 	return false
 }
 
+// network attachments are moved into the pod by the secondary endpoint client
+func isNetworkAttachmentSupported() bool {
+	return true
+}
+
 func getOverlayGateway(_ *net.IPNet) (net.IP, error) {
 	return net.ParseIP("169.254.1.1"), nil
 }
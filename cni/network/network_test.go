@@ -19,6 +19,7 @@ import (
 	"github.com/Azure/azure-container-networking/nns"
 	"github.com/Azure/azure-container-networking/telemetry"
 	cniSkel "github.com/containernetworking/cni/pkg/skel"
+	cniTypesCurr "github.com/containernetworking/cni/pkg/types/100"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -41,13 +42,15 @@ func TestMain(m *testing.M) {
 		Master:            eth0IfName,
 		IPsToRouteViaHost: []string{"169.254.20.10"},
 		IPAM: struct {
-			Mode          string `json:"mode,omitempty"`
-			Type          string `json:"type"`
-			Environment   string `json:"environment,omitempty"`
-			AddrSpace     string `json:"addressSpace,omitempty"`
-			Subnet        string `json:"subnet,omitempty"`
-			Address       string `json:"ipAddress,omitempty"`
-			QueryInterval string `json:"queryInterval,omitempty"`
+			Mode                string `json:"mode,omitempty"`
+			Type                string `json:"type"`
+			Environment         string `json:"environment,omitempty"`
+			AddrSpace           string `json:"addressSpace,omitempty"`
+			Subnet              string `json:"subnet,omitempty"`
+			Address             string `json:"ipAddress,omitempty"`
+			QueryInterval       string `json:"queryInterval,omitempty"`
+			ConflictProbe       bool   `json:"conflictProbe,omitempty"`
+			SubnetMaskSizeLimit string `json:"subnetMaskSizeLimit,omitempty"`
 		}{
 			Type: "azure-cns",
 		},
@@ -1895,3 +1898,100 @@ func TestValidateArgs(t *testing.T) {
 		})
 	}
 }
+
+func TestGetEndpointMTU(t *testing.T) {
+	plugin := &NetPlugin{
+		netClient: &InterfaceGetterMock{
+			interfaces: []net.Interface{
+				{Name: "eth0", MTU: 1500},
+				{Name: "eth1", MTU: 9000},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		policy   *cni.MTUPolicy
+		nicType  cns.NICType
+		masterIf string
+		want     int
+		wantErr  bool
+	}{
+		{
+			name:     "no policy keeps the default",
+			policy:   nil,
+			nicType:  cns.InfraNIC,
+			masterIf: "eth0",
+			want:     0,
+		},
+		{
+			name:     "fixed mtu",
+			policy:   &cni.MTUPolicy{Mode: cni.MTUModeFixed, Value: 1400},
+			nicType:  cns.InfraNIC,
+			masterIf: "eth0",
+			want:     1400,
+		},
+		{
+			name:     "derive from master with overhead",
+			policy:   &cni.MTUPolicy{Mode: cni.MTUModeMaster, Overhead: 50},
+			nicType:  cns.InfraNIC,
+			masterIf: "eth1",
+			want:     8950,
+		},
+		{
+			name: "nic type override takes precedence",
+			policy: &cni.MTUPolicy{
+				Mode:             cni.MTUModeMaster,
+				Overhead:         50,
+				NICTypeOverrides: map[string]int{string(cns.NodeNetworkInterfaceFrontendNIC): 9000},
+			},
+			nicType:  cns.NodeNetworkInterfaceFrontendNIC,
+			masterIf: "missing",
+			want:     9000,
+		},
+		{
+			name:     "master interface not found keeps the default",
+			policy:   &cni.MTUPolicy{Mode: cni.MTUModeMaster},
+			nicType:  cns.InfraNIC,
+			masterIf: "missing",
+			want:     0,
+		},
+		{
+			name:     "mtu out of range",
+			policy:   &cni.MTUPolicy{Mode: cni.MTUModeFixed, Value: 10},
+			nicType:  cns.InfraNIC,
+			masterIf: "eth0",
+			wantErr:  true,
+		},
+		{
+			name:     "unknown mode",
+			policy:   &cni.MTUPolicy{Mode: "jumbo"},
+			nicType:  cns.InfraNIC,
+			masterIf: "eth0",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := plugin.getEndpointMTU(tt.policy, tt.nicType, tt.masterIf)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAddMTUToResult(t *testing.T) {
+	result := &cniTypesCurr.Result{Interfaces: []*cniTypesCurr.Interface{{Name: "snat0"}, {Name: "eth0"}}}
+	addMTUToResult([]*acnnetwork.EndpointInfo{
+		{IfName: "eth0", MTU: 1400},
+		{IfName: "eth1", MTU: 9000},
+	}, result)
+	require.Equal(t, 0, result.Interfaces[0].Mtu)
+	require.Equal(t, 1400, result.Interfaces[1].Mtu)
+}
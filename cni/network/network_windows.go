@@ -417,6 +417,11 @@ func (plugin *NetPlugin) isDualNicFeatureSupported(netNs string) bool {
 	return false
 }
 
+// network attachments are not supported on windows, where there is no endpoint client to move them into the pod
+func isNetworkAttachmentSupported() bool {
+	return false
+}
+
 func getOverlayGateway(podsubnet *net.IPNet) (net.IP, error) {
 	logger.Warn("No gateway specified for Overlay NC. CNI will choose one, but connectivity may break")
 	ncgw := podsubnet.IP
@@ -14,6 +14,7 @@ import (
 	zaplog "github.com/Azure/azure-container-networking/cni/log"
 	"github.com/Azure/azure-container-networking/cni/network"
 	"github.com/Azure/azure-container-networking/common"
+	"github.com/Azure/azure-container-networking/log"
 	"github.com/Azure/azure-container-networking/nns"
 	"github.com/Azure/azure-container-networking/platform"
 	"github.com/Azure/azure-container-networking/store"
@@ -198,6 +199,10 @@ func rootExecute() error {
 
 // Main is the entry point for CNI network plugin.
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == validateCmd {
+		os.Exit(runValidate(os.Args[2:], os.Stdout))
+	}
+
 	// Initialize and parse command line arguments.
 	common.ParseArgs(&args, printVersion)
 	vers := common.GetArg(common.OptVersion).(bool)
@@ -207,6 +212,9 @@ func main() {
 		os.Exit(0)
 	}
 
+	// the legacy log.Printf callers, such as netlink and platform, log through the CNI logger
+	log.SetZapLogger(zaplog.CNILogger)
+
 	if rootExecute() != nil {
 		os.Exit(1)
 	}
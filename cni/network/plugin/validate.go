@@ -0,0 +1,124 @@
+// Copyright 2017 Microsoft. All rights reserved.
+// MIT License
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+
+	"github.com/Azure/azure-container-networking/cni"
+	"github.com/Azure/azure-container-networking/cni/network"
+	cniSkel "github.com/containernetworking/cni/pkg/skel"
+	"github.com/pkg/errors"
+)
+
+const validateCmd = "validate"
+
+// Checks the conflist given on the command line and optionally runs a dry-run ADD against it.
+// Returns the process exit code.
+func runValidate(argv []string, out io.Writer) int {
+	flags := flag.NewFlagSet(validateCmd, flag.ContinueOnError)
+	flags.SetOutput(out)
+	dryRun := flags.Bool("dry-run", false, "run an ADD without programming the host and print the operations it would perform")
+	master := flags.String("master", "", "master interface to use for the dry-run ADD")
+	podIP := flags.String("pod-ip", "10.240.0.5/24", "pod address returned by the fake IPAM in CIDR notation")
+	gateway := flags.String("gateway", "10.240.0.1", "gateway returned by the fake IPAM")
+	hostIP := flags.String("host-ip", "10.240.0.4", "primary IP of the host returned by the fake CNS")
+	flags.Usage = func() {
+		fmt.Fprintf(out, "Usage: %s %s [flags] <conflist>\n", name, validateCmd)
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(argv); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	b, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(out, "failed to read conflist: %v\n", err)
+		return 1
+	}
+
+	nwCfgs, err := cni.ParseNetworkConfigList(b, name)
+	if err != nil {
+		fmt.Fprintf(out, "%v\n", err)
+		return 1
+	}
+
+	invalid := false
+	for _, nwCfg := range nwCfgs {
+		for _, err := range network.ValidateNetworkConfig(nwCfg) {
+			fmt.Fprintf(out, "%s: %v\n", nwCfg.Name, err)
+			invalid = true
+		}
+	}
+	if invalid {
+		return 1
+	}
+	fmt.Fprintf(out, "%s: configuration is valid\n", flags.Arg(0))
+
+	if !*dryRun {
+		return 0
+	}
+
+	ip, ipNet, err := net.ParseCIDR(*podIP)
+	if err != nil {
+		fmt.Fprintf(out, "invalid pod-ip: %v\n", err)
+		return 2
+	}
+	cfg := network.DryRunConfig{
+		PodIP:         net.IPNet{IP: ip, Mask: ipNet.Mask},
+		Gateway:       net.ParseIP(*gateway),
+		HostPrimaryIP: net.ParseIP(*hostIP),
+	}
+	if cfg.Gateway == nil {
+		fmt.Fprintf(out, "invalid gateway %q\n", *gateway)
+		return 2
+	}
+	if cfg.HostPrimaryIP == nil {
+		fmt.Fprintf(out, "invalid host-ip %q\n", *hostIP)
+		return 2
+	}
+
+	for _, nwCfg := range nwCfgs {
+		if *master != "" {
+			nwCfg.Master = *master
+		}
+		if err := dryRunAdd(nwCfg, cfg, out); err != nil {
+			fmt.Fprintf(out, "%s: dry-run failed: %v\n", nwCfg.Name, err)
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// Runs ADD for nwCfg with recording clients and prints the operations it would have performed.
+func dryRunAdd(nwCfg *cni.NetworkConfig, cfg network.DryRunConfig, out io.Writer) error {
+	recorder := &network.Recorder{}
+	netPlugin, err := network.NewDryRunPlugin(name, nwCfg, cfg, recorder)
+	if err != nil {
+		return err
+	}
+
+	err = netPlugin.Add(&cniSkel.CmdArgs{
+		StdinData:   nwCfg.Serialize(),
+		ContainerID: "dry-run-container",
+		Netns:       "/var/run/netns/dry-run",
+		Args:        fmt.Sprintf("K8S_POD_NAME=%s;K8S_POD_NAMESPACE=%s", network.DryRunPodName, network.DryRunPodNamespace),
+		IfName:      "eth0",
+	})
+	fmt.Fprintln(out)
+	for _, op := range recorder.Operations() {
+		fmt.Fprintf(out, "%s\n", op)
+	}
+	return errors.Wrap(err, "ADD failed")
+}
@@ -56,13 +56,6 @@ func rootExecute() error {
 		tb     *telemetry.TelemetryBuffer
 	)
 
-	log.SetName(name)
-	log.SetLevel(log.LevelInfo)
-	if err := log.SetTargetLogDirectory(log.TargetLogfile, ""); err != nil {
-		fmt.Printf("Failed to setup cni logging: %v\n", err)
-	}
-	defer log.Close()
-
 	config.Version = version
 	config.Stateless = stateless
 
@@ -160,6 +153,10 @@ func rootExecute() error {
 
 // Main is the entry point for CNI network plugin.
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == migrateCmd {
+		os.Exit(runMigrate(os.Args[2:], os.Stdout))
+	}
+
 	// Initialize and parse command line arguments.
 	common.ParseArgs(&args, printVersion)
 	vers := common.GetArg(common.OptVersion).(bool)
@@ -169,6 +166,9 @@ func main() {
 		os.Exit(0)
 	}
 
+	// the legacy log.Printf callers, such as netlink and platform, log through the CNI logger
+	log.SetZapLogger(zapLog.CNILogger)
+
 	if rootExecute() != nil {
 		os.Exit(1)
 	}
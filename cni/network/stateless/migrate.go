@@ -0,0 +1,121 @@
+// Copyright 2017 Microsoft. All rights reserved.
+// MIT License
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"runtime"
+	"time"
+
+	cnsclient "github.com/Azure/azure-container-networking/cns/client"
+	"github.com/Azure/azure-container-networking/netio"
+	acnnetwork "github.com/Azure/azure-container-networking/network"
+	"github.com/Azure/azure-container-networking/platform"
+	"github.com/Azure/azure-container-networking/processlock"
+	"github.com/Azure/azure-container-networking/store"
+)
+
+const (
+	migrateCmd     = "migrate-state"
+	cnsReqTimeout  = 15 * time.Second
+	migrateTimeout = 5 * time.Minute
+)
+
+// Moves the endpoint state of the stateful CNI statefile into CNS, or back into the statefile with --rollback.
+// Returns the process exit code.
+func runMigrate(argv []string, out io.Writer) int {
+	flags := flag.NewFlagSet(migrateCmd, flag.ContinueOnError)
+	flags.SetOutput(out)
+	stateFile := flags.String("state", platform.CNIStateFilePath, "path of the stateful CNI statefile")
+	rollback := flags.Bool("rollback", false, "rewrite the statefile from the endpoint state held by CNS")
+	dryRun := flags.Bool("dry-run", false, "report what would be migrated without writing to CNS or the statefile")
+	flags.Usage = func() {
+		fmt.Fprintf(out, "Usage: %s %s [flags]\n", name, migrateCmd)
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(argv); err != nil {
+		return 2
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return 2
+	}
+
+	client, err := cnsclient.New("", cnsReqTimeout)
+	if err != nil {
+		fmt.Fprintf(out, "failed to create CNS client: %v\n", err)
+		return 1
+	}
+
+	// take the same lock as the CNI so no ADD or DEL runs against the statefile while it is migrated
+	lockclient, err := processlock.NewFileLock(platform.CNILockPath + name + store.LockExtension)
+	if err != nil {
+		fmt.Fprintf(out, "failed to create statefile lock: %v\n", err)
+		return 1
+	}
+	st, err := store.NewJsonFileStore(*stateFile, lockclient, nil)
+	if err != nil {
+		fmt.Fprintf(out, "failed to open statefile: %v\n", err)
+		return 1
+	}
+	lockTimeout := store.DefaultLockTimeoutLinux
+	if runtime.GOOS == "windows" {
+		lockTimeout = store.DefaultLockTimeoutWindows
+	}
+	if err := st.Lock(lockTimeout); err != nil {
+		fmt.Fprintf(out, "failed to lock statefile: %v\n", err)
+		return 1
+	}
+	defer st.Unlock() //nolint:errcheck // nothing to do if the lock cannot be released on exit
+
+	migrator := acnnetwork.NewStateMigrator(st, client, &netio.NetIO{})
+	migrator.DryRun = *dryRun
+
+	ctx, cancel := context.WithTimeout(context.Background(), migrateTimeout)
+	defer cancel()
+
+	var report *acnnetwork.MigrationReport
+	if *rollback {
+		report, err = migrator.Rollback(ctx)
+	} else {
+		report, err = migrator.Migrate(ctx)
+	}
+	if report != nil {
+		printMigrationReport(out, report, *rollback, *dryRun)
+	}
+	if err != nil {
+		fmt.Fprintf(out, "%v\n", err)
+		return 1
+	}
+	if len(report.Unconvertible) > 0 {
+		return 1
+	}
+	return 0
+}
+
+func printMigrationReport(out io.Writer, report *acnnetwork.MigrationReport, rollback, dryRun bool) {
+	verb := "migrated to CNS"
+	if rollback {
+		verb = "restored to the statefile"
+	}
+	if dryRun {
+		verb = "would be " + verb
+	}
+
+	for _, containerID := range report.Migrated {
+		fmt.Fprintf(out, "%s: %s\n", containerID, verb)
+	}
+	for _, containerID := range report.Removed {
+		fmt.Fprintf(out, "%s: not found in CNS, removed from the statefile\n", containerID)
+	}
+	for _, u := range report.Unconvertible {
+		fmt.Fprintf(out, "%s: endpoint %s in network %s is unconvertible: %s\n", u.ContainerID, u.EndpointID, u.NetworkID, u.Reason)
+	}
+	fmt.Fprintf(out, "%d containers %s, %d removed, %d endpoints unconvertible\n",
+		len(report.Migrated), verb, len(report.Removed), len(report.Unconvertible))
+}
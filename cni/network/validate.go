@@ -0,0 +1,108 @@
+package network
+
+import (
+	"fmt"
+
+	"github.com/Azure/azure-container-networking/cni"
+	"github.com/Azure/azure-container-networking/cni/util"
+	"github.com/Azure/azure-container-networking/network"
+)
+
+const (
+	ipamV4 = "azure-vnet-ipam"
+
+	opModeBridge          = "bridge"
+	opModeTunnel          = "tunnel"
+	opModeTransparentVlan = "transparent-vlan"
+)
+
+var (
+	supportedModes          = []string{"", opModeBridge, opModeTunnel, OpModeTransparent, opModeTransparentVlan}
+	supportedExecutionModes = []string{"", string(util.Default), string(util.Baremetal), string(util.V4Swift)}
+	supportedIpamTypes      = []string{ipamV4, ipamV6, network.AzureCNS}
+	supportedIpamModes      = []string{"", string(util.V4Overlay), string(util.DualStackOverlay), string(util.Overlay)}
+	supportedIPV6Modes      = []string{"", network.IPV6Nat}
+)
+
+// ValidateNetworkConfig checks the azure-vnet network configuration for unsupported values and
+// field combinations which would otherwise only surface as errors during pod creation.
+// All problems found are returned.
+func ValidateNetworkConfig(nwCfg *cni.NetworkConfig) []error {
+	var errs []error
+	addErr := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if !contains(supportedModes, nwCfg.Mode) {
+		addErr("mode %q is not supported, expected one of %q", nwCfg.Mode, supportedModes)
+	}
+
+	if !contains(supportedExecutionModes, nwCfg.ExecutionMode) {
+		addErr("executionMode %q is not supported, expected one of %q", nwCfg.ExecutionMode, supportedExecutionModes)
+	}
+
+	if !contains(supportedIPV6Modes, nwCfg.IPV6Mode) {
+		addErr("ipv6Mode %q is not supported, expected one of %q", nwCfg.IPV6Mode, supportedIPV6Modes)
+	}
+
+	if !contains(supportedIpamModes, nwCfg.IPAM.Mode) {
+		addErr("ipam.mode %q is not supported, expected one of %q", nwCfg.IPAM.Mode, supportedIpamModes)
+	}
+
+	// swift v1 multitenancy gets its addresses from the network container, so the ipam section is unused.
+	if !nwCfg.MultiTenancy && !contains(supportedIpamTypes, nwCfg.IPAM.Type) {
+		addErr("ipam.type %q is not supported, expected one of %q", nwCfg.IPAM.Type, supportedIpamTypes)
+	}
+
+	if nwCfg.IPAM.Mode != "" && nwCfg.IPAM.Type != network.AzureCNS {
+		addErr("ipam.mode %q requires ipam.type %q", nwCfg.IPAM.Mode, network.AzureCNS)
+	}
+
+	if nwCfg.ExecutionMode == string(util.V4Swift) && nwCfg.IPAM.Type != network.AzureCNS {
+		addErr("executionMode %q requires ipam.type %q", nwCfg.ExecutionMode, network.AzureCNS)
+	}
+
+	if nwCfg.IPV6Mode != "" && nwCfg.IPAM.Type != ipamV4 {
+		addErr("ipv6Mode %q is only honoured with ipam.type %q", nwCfg.IPV6Mode, ipamV4)
+	}
+
+	if nwCfg.MultiTenancy {
+		if nwCfg.ExecutionMode == string(util.Baremetal) {
+			addErr("multiTenancy is not supported with executionMode %q", nwCfg.ExecutionMode)
+		}
+		if nwCfg.IPAM.Mode != "" {
+			addErr("multiTenancy is not supported with ipam.mode %q", nwCfg.IPAM.Mode)
+		}
+	} else if nwCfg.Mode == opModeTransparentVlan {
+		addErr("mode %q requires multiTenancy", nwCfg.Mode)
+	}
+
+	if nwCfg.NetworkAttachments != nil && nwCfg.Mode != OpModeTransparent {
+		addErr("networkAttachments require mode %q", OpModeTransparent)
+	}
+
+	errs = append(errs, validateMTUPolicy(nwCfg.MTUPolicy)...)
+
+	return errs
+}
+
+// validateMTUPolicy returns each problem the mtu policy validation found as its own error.
+func validateMTUPolicy(policy *cni.MTUPolicy) []error {
+	err := policy.Validate()
+	if joined, ok := err.(interface{ Unwrap() []error }); ok { //nolint:errorlint // splitting the joined errors
+		return joined.Unwrap()
+	}
+	if err != nil {
+		return []error{err}
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package network
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/cni"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateShippedConflists(t *testing.T) {
+	files, err := filepath.Glob("../*.conflist")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			b, err := os.ReadFile(file)
+			require.NoError(t, err)
+
+			nwCfgs, err := cni.ParseNetworkConfigList(b, "azure-vnet")
+			require.NoError(t, err)
+			require.Len(t, nwCfgs, 1)
+			require.Empty(t, ValidateNetworkConfig(nwCfgs[0]))
+		})
+	}
+}
+
+func TestValidateNetworkConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		nwCfg   cni.NetworkConfig
+		numErrs int
+	}{
+		{
+			name: "transparent with cns overlay",
+			nwCfg: cni.NetworkConfig{
+				Mode: OpModeTransparent,
+				IPAM: cni.IPAM{Type: "azure-cns", Mode: "v4overlay"},
+			},
+		},
+		{
+			name: "unknown mode",
+			nwCfg: cni.NetworkConfig{
+				Mode: "macvlan",
+				IPAM: cni.IPAM{Type: ipamV4},
+			},
+			numErrs: 1,
+		},
+		{
+			name: "overlay requires cns",
+			nwCfg: cni.NetworkConfig{
+				Mode: OpModeTransparent,
+				IPAM: cni.IPAM{Type: ipamV4, Mode: "overlay"},
+			},
+			numErrs: 1,
+		},
+		{
+			name: "v4swift requires cns",
+			nwCfg: cni.NetworkConfig{
+				Mode:          OpModeTransparent,
+				ExecutionMode: "v4swift",
+				IPAM:          cni.IPAM{Type: ipamV4},
+			},
+			numErrs: 1,
+		},
+		{
+			name: "transparent-vlan requires multitenancy",
+			nwCfg: cni.NetworkConfig{
+				Mode: opModeTransparentVlan,
+				IPAM: cni.IPAM{Type: "azure-cns"},
+			},
+			numErrs: 1,
+		},
+		{
+			name: "multitenancy with baremetal and overlay",
+			nwCfg: cni.NetworkConfig{
+				Mode:          opModeBridge,
+				MultiTenancy:  true,
+				ExecutionMode: "baremetal",
+				IPAM:          cni.IPAM{Type: "azure-cns", Mode: "overlay"},
+			},
+			numErrs: 2,
+		},
+		{
+			name: "ipv6nat with cns",
+			nwCfg: cni.NetworkConfig{
+				Mode:     opModeBridge,
+				IPV6Mode: "ipv6nat",
+				IPAM:     cni.IPAM{Type: "azure-cns"},
+			},
+			numErrs: 1,
+		},
+		{
+			name: "network attachments require transparent mode",
+			nwCfg: cni.NetworkConfig{
+				Mode:               opModeBridge,
+				IPAM:               cni.IPAM{Type: "azure-cns"},
+				NetworkAttachments: &cni.Attachments{},
+			},
+			numErrs: 1,
+		},
+		{
+			name: "invalid mtu policy",
+			nwCfg: cni.NetworkConfig{
+				Mode: OpModeTransparent,
+				IPAM: cni.IPAM{Type: "azure-cns"},
+				MTUPolicy: &cni.MTUPolicy{
+					Mode:             cni.MTUModeFixed,
+					Value:            10,
+					NICTypeOverrides: map[string]int{"UnknownNIC": 1500, "BackendNIC": 4092},
+				},
+			},
+			numErrs: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ValidateNetworkConfig(&tt.nwCfg)
+			require.Len(t, errs, tt.numErrs, "errors: %v", errs)
+		})
+	}
+}
+
+func TestParseNetworkConfigMTUPolicy(t *testing.T) {
+	_, err := cni.ParseNetworkConfig([]byte(`{"mtuPolicy":{"mode":"master","nicTypeOverrides":{"InfraNIC":1500}}}`))
+	require.NoError(t, err)
+
+	// every override is checked when the config is parsed, not only the one for the nic type being added
+	_, err = cni.ParseNetworkConfig([]byte(`{"mtuPolicy":{"mode":"master","nicTypeOverrides":{"InfraNIC":1500,"FrontendNIC":10}}}`))
+	require.ErrorContains(t, err, `"FrontendNIC"`)
+
+	_, err = cni.ParseNetworkConfig([]byte(`{"mtuPolicy":{"nicTypeOverrides":{"BackendNIC":4092}}}`))
+	require.ErrorContains(t, err, `nic type "BackendNIC" is not supported`)
+}
@@ -6,6 +6,7 @@ import (
 	"net"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Azure/azure-container-networking/cns/types"
 	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
@@ -35,9 +36,16 @@ const (
 	RequestIPConfigs                         = "/network/requestipconfigs"
 	ReleaseIPConfig                          = "/network/releaseipconfig"
 	ReleaseIPConfigs                         = "/network/releaseipconfigs"
+	PathDebugConfig                          = "/debug/config"
+	PathDebugLogLevel                        = "/debug/loglevel"
 	PathDebugIPAddresses                     = "/debug/ipaddresses"
 	PathDebugPodContext                      = "/debug/podcontext"
 	PathDebugRestData                        = "/debug/restdata"
+	PathDebugPNIReservations                 = "/debug/pnireservations"
+	PathDebugNodeSubnetIPs                   = "/debug/nodesubnetips"
+	PathDebugRoutes                          = "/debug/routes"
+	PathDebugNCHistory                       = "/debug/nc/history"
+	PathDebugIPAudit                         = "/debug/ipaudit"
 	NumberOfCPUCores                         = NumberOfCPUCoresPath
 	NMAgentSupportedAPIs                     = NmAgentSupportedApisPath
 	EndpointAPI                              = EndpointPath
@@ -124,6 +132,10 @@ type CreateNetworkContainerRequest struct {
 	MultiTenancyInfo           MultiTenancyInfo
 	CnetAddressSpace           []IPSubnet // To setup SNAT (should include service endpoint vips).
 	Routes                     []Route
+	// HostRoutes are programmed on the host by CNS when host route management is enabled. They are an addition to
+	// the wire contract which DNC has to set in the NC goal states it publishes; NCs converted from a
+	// NodeNetworkConfig have no host routes, as the NodeNetworkConfig does not carry routes.
+	HostRoutes                 []Route
 	AllowHostToNCCommunication bool
 	AllowNCToHostCommunication bool
 	EndpointPolicies           []NetworkContainerRequestPolicies
@@ -580,6 +592,171 @@ type GetPodContextResponse struct {
 	Response   Response
 }
 
+// PNIReservation is the usage of the IPs reserved for a PodNetworkInstance by its pods.
+type PNIReservation struct {
+	Namespace string
+	Name      string
+	Reserved  int
+	Used      int
+	Pods      []string
+}
+
+// GetPNIReservationsResponse is used in CNS Client debug mode to get the usage of the PodNetworkInstance reservations
+type GetPNIReservationsResponse struct {
+	Reservations []PNIReservation
+	Response     Response
+}
+
+// NodeSubnetIPChurn is a change in the secondary IPs of the NIC seen between two fetches from NMAgent in NodeSubnet mode.
+type NodeSubnetIPChurn struct {
+	Time    time.Time
+	Added   []string
+	Removed []string
+}
+
+// NodeSubnetDriftedIP is an IP assigned to a Pod which is no longer a secondary IP of the NIC in NodeSubnet mode.
+type NodeSubnetDriftedIP struct {
+	IPAddress    string
+	PodName      string
+	PodNamespace string
+	DetectedAt   time.Time
+	Evicted      bool
+}
+
+// GetNodeSubnetIPsResponse is used in CNS Client debug mode to get the recent changes in the secondary IPs of the NIC
+// and the assigned IPs which drifted off it in NodeSubnet mode
+type GetNodeSubnetIPsResponse struct {
+	Churn    []NodeSubnetIPChurn
+	Drifted  []NodeSubnetDriftedIP
+	Response Response
+}
+
+// ManagedRouteState is the state of a host route managed by CNS.
+type ManagedRouteState string
+
+const (
+	// ManagedRouteProgrammed routes are in the routing table of the host as desired.
+	ManagedRouteProgrammed ManagedRouteState = "Programmed"
+	// ManagedRouteConflict routes are not programmed as a route to the same destination was added by someone else,
+	// or is desired by another owner.
+	ManagedRouteConflict ManagedRouteState = "Conflict"
+	// ManagedRouteFailed routes could not be programmed, and are retried at the next reconcile.
+	ManagedRouteFailed ManagedRouteState = "Failed"
+)
+
+// ManagedRoute is a route CNS programs in the routing table of the host because its owner, such as an NC, desires it.
+type ManagedRoute struct {
+	Owner string
+	Route
+	State ManagedRouteState
+	Error string `json:",omitempty"`
+}
+
+// GetManagedRoutesResponse is used in CNS Client debug mode to get the host routes managed by CNS.
+type GetManagedRoutesResponse struct {
+	Routes        []ManagedRoute
+	LastReconcile time.Time
+	Response      Response
+}
+
+// NCHistoryNCIDParam is the query parameter of PathDebugNCHistory filtering the NC history by NC ID.
+const NCHistoryNCIDParam = "ncid"
+
+// NCHistorySource is what created, updated or deleted an NC.
+type NCHistorySource string
+
+const (
+	// NCHistorySourceDNC is a call of the NC APIs of CNS by DNC.
+	NCHistorySourceDNC NCHistorySource = "DNC"
+	// NCHistorySourceCRD is a reconcile of the NodeNetworkConfig or of the multitenant CRDs.
+	NCHistorySourceCRD NCHistorySource = "CRDReconcile"
+	// NCHistorySourceManagedSync is a sync of the NCs of the node with DNC in Managed mode.
+	NCHistorySourceManagedSync NCHistorySource = "ManagedSync"
+	// NCHistorySourceHostVersionSync is a sync of the NC versions programmed by NMAgent.
+	NCHistorySourceHostVersionSync NCHistorySource = "HostVersionSync"
+	// NCHistorySourceNodeSubnet is a fetch of the secondary IPs of the NIC in NodeSubnet mode.
+	NCHistorySourceNodeSubnet NCHistorySource = "NodeSubnet"
+	// NCHistorySourceStaleNCCleanup is the removal of NCs which are no longer in the goal state.
+	NCHistorySourceStaleNCCleanup NCHistorySource = "StaleNCCleanup"
+)
+
+// NCHistoryOperation is the change to an NC recorded in the NC history.
+type NCHistoryOperation string
+
+const (
+	NCHistoryCreate NCHistoryOperation = "Create"
+	NCHistoryUpdate NCHistoryOperation = "Update"
+	NCHistoryDelete NCHistoryOperation = "Delete"
+)
+
+// NCHistoryRecord is a create, update or delete of an NC in the NC history of CNS.
+type NCHistoryRecord struct {
+	Time      time.Time
+	NCID      string
+	Operation NCHistoryOperation
+	Source    NCHistorySource
+	// OldVersion and NewVersion are the versions of the NC published by DNC before and after the change.
+	OldVersion string
+	NewVersion string
+	// OldHostVersion and NewHostVersion are the versions of the NC programmed by NMAgent before and after the change.
+	OldHostVersion string
+	NewHostVersion string
+	// AddedIPs and RemovedIPs are the secondary IPs added to and removed from the NC, which are truncated when
+	// there are more of them than AddedIPCount and RemovedIPCount count.
+	AddedIPs       []string `json:",omitempty"`
+	RemovedIPs     []string `json:",omitempty"`
+	AddedIPCount   int
+	RemovedIPCount int
+	ReturnCode     types.ResponseCode
+}
+
+// GetNCHistoryResponse is used in CNS Client debug mode to get the NC history, oldest record first.
+type GetNCHistoryResponse struct {
+	Records  []NCHistoryRecord
+	Response Response
+}
+
+// IPAuditDiscrepancyKind is a kind of disagreement between the IPs assigned by CNS, the endpoints in the CNI
+// state and the Pods in the API server.
+type IPAuditDiscrepancyKind string
+
+const (
+	// IPAuditLeakedIP is an IP assigned by CNS to a Pod which is gone from the API server.
+	IPAuditLeakedIP IPAuditDiscrepancyKind = "LeakedIP"
+	// IPAuditOrphanEndpoint is an endpoint in the CNI state whose IP is not assigned by CNS or whose Pod is gone
+	// from the API server.
+	IPAuditOrphanEndpoint IPAuditDiscrepancyKind = "OrphanEndpoint"
+	// IPAuditPodWithoutIP is a running Pod to which CNS has not assigned an IP.
+	IPAuditPodWithoutIP IPAuditDiscrepancyKind = "PodWithoutIP"
+	// IPAuditDoubleAssignment is an IP which the sources assign to different Pods.
+	IPAuditDoubleAssignment IPAuditDiscrepancyKind = "DoubleAssignment"
+)
+
+// IPAuditDiscrepancy is a disagreement found by the IP auditor of CNS, which is reported until an audit no
+// longer finds it.
+type IPAuditDiscrepancy struct {
+	Kind         IPAuditDiscrepancyKind
+	IPAddress    string `json:",omitempty"`
+	PodName      string
+	PodNamespace string
+	// Detail describes the disagreement, such as the other Pod of a double assignment.
+	Detail    string `json:",omitempty"`
+	FirstSeen time.Time
+	// Released is whether a leaked IP was released by the auditor.
+	Released bool `json:",omitempty"`
+}
+
+// GetIPAuditResponse is used in CNS Client debug mode to get the discrepancies found by the last IP audit.
+type GetIPAuditResponse struct {
+	Discrepancies []IPAuditDiscrepancy
+	// LastAudit is when the last successful audit was.
+	LastAudit time.Time
+	// LastError is why the last audit failed, in which case the discrepancies of the last successful audit, which
+	// may be stale, are not returned.
+	LastError string `json:",omitempty"`
+	Response  Response
+}
+
 // IPAddressState Only used in the GetIPConfig API to return IPs that match a filter
 type IPAddressState struct {
 	IPAddress string
@@ -38,6 +38,13 @@ const (
 	K8sSWIFTV2 SWIFTV2Mode = "K8sSWIFTV2"
 )
 
+// Query parameters of the endpoint API. An update carrying the pod name and namespace records them on an endpoint
+// which has none, such as one CNS did not allocate the IPs of.
+const (
+	EndpointPodNameParam      = "podName"
+	EndpointPodNamespaceParam = "podNamespace"
+)
+
 // HTTPService describes the min API interface that every service should have.
 type HTTPService interface {
 	common.ServiceAPI
@@ -0,0 +1,166 @@
+// Package authz authorizes callers of the CNS HTTP API against a per-route allow-list.
+package authz
+
+import (
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/configuration"
+	"github.com/Azure/azure-container-networking/cns/logger"
+	"github.com/pkg/errors"
+)
+
+const defaultRouteLabel = "default"
+
+var errInvalidRule = errors.New("invalid authorization rule")
+
+type rule struct {
+	path           string
+	prefix         bool
+	methods        map[string]struct{}
+	uids           map[uint32]struct{}
+	processes      map[string]struct{}
+	subjects       map[string]struct{}
+	allowAnonymous bool
+}
+
+// Authorizer decides whether a caller may reach a CNS API route.
+type Authorizer struct {
+	rules        []rule
+	defaultAllow bool
+}
+
+// New compiles the authorization rules from the CNS config.
+func New(settings *configuration.AuthorizationSettings) (*Authorizer, error) {
+	a := &Authorizer{defaultAllow: settings.DefaultAllow}
+	for i := range settings.Rules {
+		cfg := &settings.Rules[i]
+		if cfg.Path == "" {
+			return nil, errors.Wrapf(errInvalidRule, "rule %d has no path", i)
+		}
+		if len(cfg.AllowUIDs) == 0 && len(cfg.AllowProcesses) == 0 && len(cfg.AllowSubjects) == 0 && !cfg.AllowAnonymous {
+			return nil, errors.Wrapf(errInvalidRule, "rule %d for %s allows no callers", i, cfg.Path)
+		}
+
+		r := rule{
+			path:           strings.TrimSuffix(cfg.Path, "*"),
+			prefix:         strings.HasSuffix(cfg.Path, "*"),
+			methods:        make(map[string]struct{}, len(cfg.Methods)),
+			uids:           make(map[uint32]struct{}, len(cfg.AllowUIDs)),
+			processes:      make(map[string]struct{}, len(cfg.AllowProcesses)),
+			subjects:       make(map[string]struct{}, len(cfg.AllowSubjects)),
+			allowAnonymous: cfg.AllowAnonymous,
+		}
+		for _, m := range cfg.Methods {
+			r.methods[strings.ToUpper(m)] = struct{}{}
+		}
+		for _, uid := range cfg.AllowUIDs {
+			r.uids[uid] = struct{}{}
+		}
+		for _, p := range cfg.AllowProcesses {
+			// only the full path of an executable identifies it, anyone can name a binary alike
+			if !filepath.IsAbs(p) {
+				return nil, errors.Wrapf(errInvalidRule, "rule %d for %s allows process %q which is not an absolute path", i, cfg.Path, p)
+			}
+			r.processes[filepath.Clean(p)] = struct{}{}
+		}
+		for _, s := range cfg.AllowSubjects {
+			r.subjects[s] = struct{}{}
+		}
+		a.rules = append(a.rules, r)
+	}
+	return a, nil
+}
+
+// routePath returns the route of the request path, which is cleaned and stripped of the V2 prefix so that the
+// aliases of a route can not be used to get around its rule.
+func routePath(p string) string {
+	cleaned := path.Clean("/" + p)
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	if trimmed := strings.TrimPrefix(cleaned, cns.V2Prefix); trimmed != cleaned && strings.HasPrefix(trimmed, "/") {
+		cleaned = trimmed
+	}
+	return cleaned
+}
+
+func (r *rule) matches(req *http.Request) bool {
+	route := routePath(req.URL.Path)
+	if r.prefix {
+		if !strings.HasPrefix(route, r.path) {
+			return false
+		}
+	} else if route != r.path {
+		return false
+	}
+	if len(r.methods) == 0 {
+		return true
+	}
+	_, ok := r.methods[req.Method]
+	return ok
+}
+
+func (r *rule) allows(id *Identity) bool {
+	if !id.Authenticated {
+		return r.allowAnonymous
+	}
+	switch id.Transport {
+	case TransportUnix:
+		if _, ok := r.uids[id.UID]; ok {
+			return true
+		}
+		if _, ok := r.processes[id.Process]; ok && id.Process != "" {
+			return true
+		}
+	case TransportTLS:
+		for _, s := range id.Subjects {
+			if _, ok := r.subjects[s]; ok {
+				return true
+			}
+		}
+	}
+	return r.allowAnonymous
+}
+
+// Authorize returns whether the caller of req may reach the requested route, along with the
+// route of the rule that decided it for use in metrics.
+func (a *Authorizer) Authorize(req *http.Request) (allowed bool, route string) {
+	id := IdentityFromRequest(req)
+	return a.authorize(req, &id)
+}
+
+func (a *Authorizer) authorize(req *http.Request, id *Identity) (allowed bool, route string) {
+	for i := range a.rules {
+		r := &a.rules[i]
+		if r.matches(req) {
+			route = r.path
+			if r.prefix {
+				route += "*"
+			}
+			return r.allows(id), route
+		}
+	}
+	return a.defaultAllow, defaultRouteLabel
+}
+
+// Middleware rejects requests that the policy does not allow with 403 Forbidden. Denied requests are
+// logged for audit and counted in the http_requests_denied_total metric.
+func (a *Authorizer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		id := IdentityFromRequest(req)
+		allowed, route := a.authorize(req, &id)
+		if allowed {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		deniedRequestCount.WithLabelValues(route, string(id.Transport)).Inc()
+		logger.Warnf("[Azure CNS] Denied %s %s from %s caller %s: uid=%d gid=%d pid=%d process=%q subjects=%v authenticated=%t rule=%s",
+			req.Method, req.URL.Path, id.Transport, id.RemoteAddr, id.UID, id.GID, id.PID, id.Process, id.Subjects, id.Authenticated, route)
+		http.Error(w, "caller is not authorized for "+req.URL.Path, http.StatusForbidden)
+	})
+}
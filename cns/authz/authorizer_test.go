@@ -0,0 +1,118 @@
+package authz
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/cns/configuration"
+	"github.com/Azure/azure-container-networking/cns/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	logger.InitLogger("testlogs", 0, 0, "./")
+	m.Run()
+}
+
+func withIdentity(r *http.Request, id *Identity) *http.Request {
+	return r.WithContext(contextWithIdentity(r.Context(), id))
+}
+
+func TestNewInvalidRules(t *testing.T) {
+	_, err := New(&configuration.AuthorizationSettings{Rules: []configuration.AuthorizationRule{{AllowAnonymous: true}}})
+	require.ErrorIs(t, err, errInvalidRule)
+
+	_, err = New(&configuration.AuthorizationSettings{Rules: []configuration.AuthorizationRule{{Path: "/network/endpoints/*"}}})
+	require.ErrorIs(t, err, errInvalidRule)
+
+	_, err = New(&configuration.AuthorizationSettings{Rules: []configuration.AuthorizationRule{{Path: "/network/endpoints/*", AllowProcesses: []string{"azure-vnet"}}}})
+	require.ErrorIs(t, err, errInvalidRule)
+}
+
+func TestAuthorize(t *testing.T) {
+	a, err := New(&configuration.AuthorizationSettings{
+		Rules: []configuration.AuthorizationRule{
+			{Path: "/network/endpoints/*", Methods: []string{"patch"}, AllowProcesses: []string{"/opt/cni/bin/azure-vnet"}},
+			{Path: "/network/endpoints/*", Methods: []string{http.MethodGet}, AllowUIDs: []uint32{0}},
+			{Path: "/network/createorupdatenetworkcontainer", AllowSubjects: []string{"dnc.azure.com"}},
+			{Path: "/network/requestipconfigs", AllowAnonymous: true},
+		},
+	})
+	require.NoError(t, err)
+
+	cni := &Identity{Transport: TransportUnix, UID: 0, Process: "/opt/cni/bin/azure-vnet", Authenticated: true}
+	other := &Identity{Transport: TransportUnix, UID: 1000, Process: "/usr/bin/curl", Authenticated: true}
+	anonymous := &Identity{Transport: TransportTCP}
+
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		id        *Identity
+		wantAllow bool
+		wantRoute string
+	}{
+		{"process allowed", http.MethodPatch, "/network/endpoints/abc", cni, true, "/network/endpoints/*"},
+		{"process denied", http.MethodPatch, "/network/endpoints/abc", other, false, "/network/endpoints/*"},
+		{"anonymous denied", http.MethodPatch, "/network/endpoints/abc", anonymous, false, "/network/endpoints/*"},
+		{"second rule by method", http.MethodGet, "/network/endpoints/abc", &Identity{Transport: TransportUnix, UID: 0, Authenticated: true}, true, "/network/endpoints/*"},
+		{"v2 alias matches the same rule", http.MethodPatch, "/v0.2/network/endpoints/abc", other, false, "/network/endpoints/*"},
+		{"uncleaned path matches the same rule", http.MethodPatch, "/network/../network//endpoints/abc", other, false, "/network/endpoints/*"},
+		{"v2 alias of an exact route", http.MethodPost, "/v0.2/network/createorupdatenetworkcontainer", cni, false, "/network/createorupdatenetworkcontainer"},
+		{"anonymous allowed", http.MethodPost, "/network/requestipconfigs", anonymous, true, "/network/requestipconfigs"},
+		{"unix caller is not a tls subject", http.MethodPost, "/network/createorupdatenetworkcontainer", cni, false, "/network/createorupdatenetworkcontainer"},
+		{"unmatched route denied by default", http.MethodPost, "/network/deletenetworkcontainer", cni, false, defaultRouteLabel},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			req := withIdentity(httptest.NewRequest(tt.method, tt.path, http.NoBody), tt.id)
+			allowed, route := a.Authorize(req)
+			assert.Equal(t, tt.wantAllow, allowed)
+			assert.Equal(t, tt.wantRoute, route)
+		})
+	}
+}
+
+func TestAuthorizeTLSSubject(t *testing.T) {
+	a, err := New(&configuration.AuthorizationSettings{
+		Rules: []configuration.AuthorizationRule{
+			{Path: "/network/createorupdatenetworkcontainer", AllowSubjects: []string{"dnc.azure.com"}},
+		},
+	})
+	require.NoError(t, err)
+
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "node"}, DNSNames: []string{"dnc.azure.com"}}
+	req := httptest.NewRequest(http.MethodPost, "/network/createorupdatenetworkcontainer", http.NoBody)
+	req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+
+	// unverified certificates do not identify the caller
+	allowed, _ := a.Authorize(req)
+	assert.False(t, allowed)
+
+	req.TLS.VerifiedChains = [][]*x509.Certificate{{cert}}
+	allowed, _ = a.Authorize(req)
+	assert.True(t, allowed)
+	assert.Equal(t, []string{"node", "dnc.azure.com"}, IdentityFromRequest(req).Subjects)
+}
+
+func TestMiddleware(t *testing.T) {
+	a, err := New(&configuration.AuthorizationSettings{DefaultAllow: true})
+	require.NoError(t, err)
+	handler := a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) }))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/network/endpoints/abc", http.NoBody))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	a.defaultAllow = false
+	handler = a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) }))
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/network/endpoints/abc", http.NoBody))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
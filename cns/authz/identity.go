@@ -0,0 +1,79 @@
+package authz
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+)
+
+// Transport is the kind of connection a caller reached CNS over.
+type Transport string
+
+const (
+	TransportTCP  Transport = "tcp"
+	TransportTLS  Transport = "tls"
+	TransportUnix Transport = "unix"
+)
+
+// Identity describes the caller of a CNS API request.
+type Identity struct {
+	Transport  Transport
+	RemoteAddr string
+	// Peer credentials of Unix socket callers, set when Authenticated is true and Transport is TransportUnix.
+	UID uint32
+	GID uint32
+	PID int32
+	// Process is the full path of the executable, only set if it is owned by root.
+	Process string
+	// Names from the verified client certificate of TLS callers.
+	Subjects []string
+	// Authenticated is false for callers that could not be identified.
+	Authenticated bool
+}
+
+type identityKey struct{}
+
+// ConnContext records the peer credentials of Unix socket connections on the connection context.
+// It is meant to be set as the ConnContext of the http.Server serving the CNS API.
+func ConnContext(ctx context.Context, c net.Conn) context.Context {
+	id := &Identity{Transport: TransportTCP, RemoteAddr: c.RemoteAddr().String()}
+	switch conn := c.(type) {
+	case *tls.Conn:
+		id.Transport = TransportTLS
+	case *net.UnixConn:
+		id.Transport = TransportUnix
+		if err := peerCredentials(conn, id); err == nil {
+			id.Authenticated = true
+		}
+	}
+	return contextWithIdentity(ctx, id)
+}
+
+func contextWithIdentity(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// IdentityFromRequest returns the identity of the caller of r. Requests served without ConnContext
+// are treated as plain TCP callers.
+func IdentityFromRequest(r *http.Request) Identity {
+	id := Identity{Transport: TransportTCP, RemoteAddr: r.RemoteAddr}
+	if connID, ok := r.Context().Value(identityKey{}).(*Identity); ok {
+		id = *connID
+	}
+
+	// the handshake has not happened when the connection is accepted, so the certificate is only known per request
+	if r.TLS != nil {
+		id.Transport = TransportTLS
+		if len(r.TLS.VerifiedChains) > 0 && len(r.TLS.PeerCertificates) > 0 {
+			cert := r.TLS.PeerCertificates[0]
+			id.Subjects = nil
+			if cert.Subject.CommonName != "" {
+				id.Subjects = append(id.Subjects, cert.Subject.CommonName)
+			}
+			id.Subjects = append(id.Subjects, cert.DNSNames...)
+			id.Authenticated = len(id.Subjects) > 0
+		}
+	}
+	return id
+}
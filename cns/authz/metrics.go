@@ -0,0 +1,25 @@
+package authz
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	routeLabel     = "route"
+	transportLabel = "transport"
+)
+
+var deniedRequestCount = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "http_requests_denied_total",
+		Help: "Number of CNS API requests denied by the authorization policy",
+	},
+	[]string{routeLabel, transportLabel},
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		deniedRequestCount,
+	)
+}
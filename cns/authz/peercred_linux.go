@@ -0,0 +1,59 @@
+package authz
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+// Reads the SO_PEERCRED credentials of the process on the other end of conn.
+func peerCredentials(conn *net.UnixConn, id *Identity) error {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return errors.Wrap(err, "failed to get raw connection")
+	}
+
+	var (
+		ucred   *unix.Ucred
+		credErr error
+	)
+	if err := raw.Control(func(fd uintptr) {
+		ucred, credErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	}); err != nil {
+		return errors.Wrap(err, "failed to access socket")
+	}
+	if credErr != nil {
+		return errors.Wrap(credErr, "failed to read peer credentials")
+	}
+
+	id.UID = ucred.Uid
+	id.GID = ucred.Gid
+	id.PID = ucred.Pid
+	// the executable is resolved by the kernel, unlike comm which the process can change itself
+	proc := fmt.Sprintf("/proc/%d", ucred.Pid)
+	if exe, err := os.Readlink(filepath.Join(proc, "exe")); err == nil && rootOwned(filepath.Join(proc, "exe"), filepath.Join(proc, "root"), exe) {
+		id.Process = exe
+	}
+	return nil
+}
+
+// rootOwned returns whether the executable and its directory are owned by root and not writable by anyone else,
+// so that its path can only name a binary installed by root.
+// The path is only meaningful in the mount namespace of the caller, so the executable is checked through its
+// /proc exe link and the directory is resolved under the root of the caller rather than that of CNS.
+func rootOwned(exeLink, root, exe string) bool {
+	for _, p := range []string{exeLink, filepath.Join(root, filepath.Dir(exe))} {
+		var st unix.Stat_t
+		if err := unix.Stat(p, &st); err != nil {
+			return false
+		}
+		if st.Uid != 0 || st.Mode&0o022 != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,89 @@
+//go:build linux
+// +build linux
+
+package authz
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnixSocketIdentity(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "cns.sock")
+	l, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+
+	ids := make(chan Identity, 1)
+	server := &http.Server{
+		ConnContext: ConnContext,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ids <- IdentityFromRequest(r)
+			w.WriteHeader(http.StatusOK)
+		}),
+	}
+	go server.Serve(l) //nolint:errcheck // closed by the test
+	defer server.Close()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		},
+	}}
+	resp, err := client.Get("http://localhost/network/endpoints/abc")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	id := <-ids
+	require.Equal(t, TransportUnix, id.Transport)
+	require.True(t, id.Authenticated)
+	require.Equal(t, uint32(os.Getuid()), id.UID)
+	require.Equal(t, int32(os.Getpid()), id.PID)
+	exe, err := os.Executable()
+	require.NoError(t, err)
+	if rootOwned(exe, "/", exe) {
+		require.Equal(t, exe, id.Process)
+	} else {
+		require.Empty(t, id.Process)
+	}
+}
+
+func TestRootOwned(t *testing.T) {
+	require.True(t, rootOwned("/bin/sh", "/", "/bin/sh"))
+	dir := t.TempDir()
+	exe := filepath.Join(dir, "azure-vnet")
+	require.NoError(t, os.WriteFile(exe, nil, 0o755)) //nolint:gosec // executable on purpose
+
+	// an executable anyone else can write to, or in a directory anyone can write to, could be replaced
+	require.NoError(t, os.Chmod(exe, 0o777)) //nolint:gosec // writable on purpose
+	require.False(t, rootOwned(exe, "/", exe))
+	require.NoError(t, os.Chmod(exe, 0o755)) //nolint:gosec // executable on purpose
+	require.NoError(t, os.Chmod(dir, 0o777)) //nolint:gosec // writable on purpose
+	require.False(t, rootOwned(exe, "/", exe))
+	require.False(t, rootOwned(filepath.Join(dir, "missing"), "/", filepath.Join(dir, "missing")))
+}
+
+func TestRootOwnedShadowedPath(t *testing.T) {
+	// a container whose binary sits at the path of a root-owned host binary must not pass for it
+	root := t.TempDir()
+	bin := filepath.Join(root, "bin")
+	require.NoError(t, os.Mkdir(bin, 0o755))                               //nolint:gosec // executable on purpose
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "sh"), nil, 0o755)) //nolint:gosec // executable on purpose
+	if os.Getuid() == 0 {
+		require.NoError(t, os.Chown(filepath.Join(bin, "sh"), 1000, 1000))
+		require.NoError(t, os.Chown(bin, 1000, 1000))
+	}
+	require.True(t, rootOwned("/bin/sh", "/", "/bin/sh"))
+	require.False(t, rootOwned(filepath.Join(bin, "sh"), root, "/bin/sh"))
+
+	// even when the binary is root-owned, the directory is resolved in the root of the caller
+	if os.Getuid() == 0 {
+		require.NoError(t, os.Chown(filepath.Join(bin, "sh"), 0, 0))
+		require.False(t, rootOwned(filepath.Join(bin, "sh"), root, "/bin/sh"))
+	}
+}
@@ -0,0 +1,14 @@
+package authz
+
+import (
+	"net"
+
+	"github.com/pkg/errors"
+)
+
+var errPeerCredentialsNotSupported = errors.New("peer credentials are not supported on windows")
+
+// Windows has no SO_PEERCRED, so Unix socket callers cannot be identified.
+func peerCredentials(*net.UnixConn, *Identity) error {
+	return errPeerCredentialsNotSupported
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"time"
@@ -19,6 +20,8 @@ import (
 const (
 	contentTypeJSON = "application/json"
 	defaultBaseURL  = "http://localhost:10090"
+	unixScheme      = "unix"
+	unixBaseURL     = "http://localhost"
 	// DefaultTimeout default timeout duration for CNS Client.
 	DefaultTimeout    = 5 * time.Second
 	headerContentType = "Content-Type"
@@ -36,6 +39,7 @@ var clientPaths = []string{
 	cns.PathDebugIPAddresses,
 	cns.PathDebugPodContext,
 	cns.PathDebugRestData,
+	cns.PathDebugNCHistory,
 	cns.UnpublishNetworkContainer,
 	cns.PublishNetworkContainer,
 	cns.CreateOrUpdateNetworkContainer,
@@ -67,20 +71,33 @@ func (e *ConnectionFailureErr) Error() string {
 }
 
 // New returns a new CNS client configured with the passed URL and timeout.
+// A unix:// URL connects to CNS over the unix domain socket at the URL path.
 func New(baseURL string, requestTimeout time.Duration) (*Client, error) {
 	if baseURL == "" {
 		baseURL = defaultBaseURL
 	}
 
+	httpClient := &http.Client{
+		Timeout: requestTimeout,
+	}
+	if u, err := url.Parse(baseURL); err == nil && u.Scheme == unixScheme {
+		socketPath := u.Path
+		dialer := &net.Dialer{}
+		httpClient.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dialer.DialContext(ctx, unixScheme, socketPath)
+			},
+		}
+		baseURL = unixBaseURL
+	}
+
 	routes, err := buildRoutes(baseURL, clientPaths)
 	if err != nil {
 		return nil, err
 	}
 
 	return &Client{
-		client: &http.Client{
-			Timeout: requestTimeout,
-		},
+		client: httpClient,
 		routes: routes,
 	}, nil
 }
@@ -562,6 +579,38 @@ func (c *Client) GetPodOrchestratorContext(ctx context.Context) (map[string][]st
 	return resp.PodContext, nil
 }
 
+// GetNCHistory gets the NC history of CNS, oldest record first, only of the NC if ncID is not empty.
+func (c *Client) GetNCHistory(ctx context.Context, ncID string) ([]cns.NCHistoryRecord, error) {
+	u := c.routes[cns.PathDebugNCHistory]
+	if ncID != "" {
+		u.RawQuery = url.Values{cns.NCHistoryNCIDParam: []string{ncID}}.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build request")
+	}
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "http request failed")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("http response %d", res.StatusCode)
+	}
+
+	var resp cns.GetNCHistoryResponse
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return nil, errors.Wrap(err, "failed to decode GetNCHistoryResponse")
+	}
+
+	if resp.Response.ReturnCode != 0 {
+		return nil, errors.New(resp.Response.Message)
+	}
+
+	return resp.Records, nil
+}
+
 // GetHTTPServiceData gets all public in-memory struct details for debugging purpose
 func (c *Client) GetHTTPServiceData(ctx context.Context) (*restserver.GetHTTPServiceDataResponse, error) {
 	u := c.routes[cns.PathDebugRestData]
@@ -1063,6 +1112,12 @@ func (c *Client) GetEndpoint(ctx context.Context, endpointID string) (*restserve
 // UpdateEndpoint calls the EndpointHandlerAPI in CNS
 // to update the state of a given EndpointID with either HNSEndpointID or HostVethName
 func (c *Client) UpdateEndpoint(ctx context.Context, endpointID string, ipInfo map[string]*restserver.IPInfo) (*cns.Response, error) {
+	return c.UpdateEndpointWithPodInfo(ctx, endpointID, cns.KubernetesPodInfo{}, ipInfo)
+}
+
+// UpdateEndpointWithPodInfo updates the endpoint like UpdateEndpoint, and records the pod of the endpoint if CNS has
+// none for it, as for an endpoint whose IPs CNS did not allocate.
+func (c *Client) UpdateEndpointWithPodInfo(ctx context.Context, endpointID string, pod cns.KubernetesPodInfo, ipInfo map[string]*restserver.IPInfo) (*cns.Response, error) {
 	// build the request
 	var body bytes.Buffer
 
@@ -1072,6 +1127,12 @@ func (c *Client) UpdateEndpoint(ctx context.Context, endpointID string, ipInfo m
 
 	u := c.routes[cns.EndpointAPI]
 	uString := u.String() + endpointID
+	if pod.PodName != "" || pod.PodNamespace != "" {
+		uString += "?" + url.Values{
+			cns.EndpointPodNameParam:      []string{pod.PodName},
+			cns.EndpointPodNamespaceParam: []string{pod.PodNamespace},
+		}.Encode()
+	}
 	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, uString, &body)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to build request")
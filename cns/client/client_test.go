@@ -325,6 +325,26 @@ func TestCNSClientDebugAPI(t *testing.T) {
 	t.Logf("PodIPConfigState: %+v", inmemory.HTTPRestServiceData.PodIPConfigState)
 }
 
+func TestNewUnixSocket(t *testing.T) {
+	socket := t.TempDir() + "/cns.sock"
+	l, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(cns.GetHomeAz, func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(cns.GetHomeAzResponse{HomeAzResponse: cns.HomeAzResponse{HomeAz: 1}})
+	})
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: time.Second}
+	go server.Serve(l) //nolint:errcheck // closed by the test
+	defer server.Close()
+
+	client, err := New("unix://"+socket, time.Second)
+	require.NoError(t, err)
+	resp, err := client.GetHomeAz(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), resp.HomeAzResponse.HomeAz)
+}
+
 func TestNew(t *testing.T) {
 	fqdnBaseURL := "http://testinstance.centraluseuap.cloudapp.azure.com"
 	fqdnWithPortBaseURL := fqdnBaseURL + ":10090"
@@ -2287,6 +2307,65 @@ func TestGetPodOrchestratorContext(t *testing.T) {
 	}
 }
 
+func TestGetNCHistory(t *testing.T) {
+	emptyRoutes, _ := buildRoutes(defaultBaseURL, clientPaths)
+	records := []cns.NCHistoryRecord{{NCID: "nc1", Operation: cns.NCHistoryCreate, Source: cns.NCHistorySourceDNC}}
+	tests := []struct {
+		name    string
+		mockdo  *mockdo
+		want    []cns.NCHistoryRecord
+		wantErr bool
+	}{
+		{
+			name: "happy case",
+			mockdo: &mockdo{
+				objToReturn:            &cns.GetNCHistoryResponse{Records: records},
+				httpStatusCodeToReturn: http.StatusOK,
+			},
+			want: records,
+		},
+		{
+			name: "bad decoding",
+			mockdo: &mockdo{
+				objToReturn:            []cns.GetNCHistoryResponse{},
+				httpStatusCodeToReturn: http.StatusOK,
+			},
+			wantErr: true,
+		},
+		{
+			name: "http status not ok",
+			mockdo: &mockdo{
+				httpStatusCodeToReturn: http.StatusInternalServerError,
+			},
+			wantErr: true,
+		},
+		{
+			name: "cns return code not zero",
+			mockdo: &mockdo{
+				objToReturn:            &cns.GetNCHistoryResponse{Response: cns.Response{ReturnCode: types.UnsupportedVerb}},
+				httpStatusCodeToReturn: http.StatusOK,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			client := &Client{
+				client: tt.mockdo,
+				routes: emptyRoutes,
+			}
+			got, err := client.GetNCHistory(context.TODO(), "nc1")
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
 func TestGetHTTPServiceData(t *testing.T) {
 	emptyRoutes, _ := buildRoutes(defaultBaseURL, clientPaths)
 	tests := []struct {
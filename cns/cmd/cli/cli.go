@@ -2,10 +2,12 @@ package cli
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/Azure/azure-container-networking/cns"
 	"github.com/Azure/azure-container-networking/cns/client"
@@ -18,6 +20,8 @@ const (
 	getCmdArg       = "get"
 	getInMemoryData = "getInMemory"
 	getPodCmdArg    = "getPodContexts"
+	getNCHistory    = "getNCHistory"
+	exportNCHistory = "exportNCHistory"
 )
 
 func HandleCNSClientCommands(ctx context.Context, cmd string, arg string) error {
@@ -36,6 +40,10 @@ func HandleCNSClientCommands(ctx context.Context, cmd string, arg string) error
 		return getPodCmd(ctx, cnsClient)
 	case strings.EqualFold(getInMemoryData, cmd):
 		return getInMemory(ctx, cnsClient)
+	case strings.EqualFold(getNCHistory, cmd):
+		return getNCHistoryCmd(ctx, cnsClient, arg)
+	case strings.EqualFold(exportNCHistory, cmd):
+		return exportNCHistoryCmd(ctx, cnsClient, arg)
 	default:
 		return fmt.Errorf("No debug cmd supplied, options are: %v", getCmdArg)
 	}
@@ -53,8 +61,10 @@ func getCmd(ctx context.Context, client *client.Client, arg string) error {
 		states = append(states, types.PendingProgramming)
 	case types.PendingRelease:
 		states = append(states, types.PendingRelease)
+	case types.Quarantined:
+		states = append(states, types.Quarantined)
 	default:
-		states = append(states, types.Assigned, types.Available, types.PendingProgramming, types.PendingRelease)
+		states = append(states, types.Assigned, types.Available, types.PendingProgramming, types.PendingRelease, types.Quarantined)
 	}
 
 	addr, err := client.GetIPAddressesMatchingStates(ctx, states...)
@@ -99,3 +109,36 @@ func getInMemory(ctx context.Context, client *client.Client) error {
 		data.HTTPRestServiceData.PodIPIDByPodInterfaceKey, data.HTTPRestServiceData.PodIPConfigState)
 	return nil
 }
+
+// getNCHistoryCmd writes the NC history to stdout, only of the NC if arg is an NC ID.
+func getNCHistoryCmd(ctx context.Context, client *client.Client, arg string) error {
+	records, err := client.GetNCHistory(ctx, arg)
+	if err != nil {
+		return err
+	}
+	for i := range records {
+		r := &records[i]
+		fmt.Printf("%s %s %s source=%s version=%s->%s hostVersion=%s->%s addedIPs=%d removedIPs=%d returnCode=%s\n",
+			r.Time.Format(time.RFC3339), r.NCID, r.Operation, r.Source, r.OldVersion, r.NewVersion,
+			r.OldHostVersion, r.NewHostVersion, r.AddedIPCount, r.RemovedIPCount, r.ReturnCode)
+	}
+	return nil
+}
+
+// exportNCHistoryCmd writes the NC history of every NC as JSON to the file arg, or to stdout if arg is empty.
+func exportNCHistoryCmd(ctx context.Context, client *client.Client, arg string) error {
+	records, err := client.GetNCHistory(ctx, "")
+	if err != nil {
+		return err
+	}
+	out := os.Stdout
+	if arg != "" {
+		if out, err = os.Create(arg); err != nil {
+			return err
+		}
+		defer out.Close()
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
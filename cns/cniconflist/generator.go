@@ -3,6 +3,7 @@ package cniconflist
 import (
 	"io"
 
+	"github.com/Azure/azure-container-networking/cni"
 	"github.com/pkg/errors"
 )
 
@@ -48,17 +49,20 @@ type IPAM struct {
 
 // V4OverlayGenerator generates the Azure CNI conflist for the ipv4 Overlay scenario
 type V4OverlayGenerator struct {
-	Writer io.WriteCloser
+	Writer    io.WriteCloser
+	MTUPolicy *cni.MTUPolicy
 }
 
 // DualStackOverlayGenerator generates the Azure CNI conflist for the dualstack Overlay scenario
 type DualStackOverlayGenerator struct {
-	Writer io.WriteCloser
+	Writer    io.WriteCloser
+	MTUPolicy *cni.MTUPolicy
 }
 
 // OverlayGenerator generates the Azure CNI conflist for all Overlay scenarios
 type OverlayGenerator struct {
-	Writer io.WriteCloser
+	Writer    io.WriteCloser
+	MTUPolicy *cni.MTUPolicy
 }
 
 // CiliumGenerator generates the Azure CNI conflist for the Cilium scenario
@@ -68,7 +72,8 @@ type CiliumGenerator struct {
 
 // SWIFTGenerator generates the Azure CNI conflist for the SWIFT scenario
 type SWIFTGenerator struct {
-	Writer io.WriteCloser
+	Writer    io.WriteCloser
+	MTUPolicy *cni.MTUPolicy
 }
 
 func (v *V4OverlayGenerator) Close() error {
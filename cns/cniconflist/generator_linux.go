@@ -34,6 +34,7 @@ func (v *V4OverlayGenerator) Generate() error {
 				Mode:              cninet.OpModeTransparent,
 				ExecutionMode:     string(util.V4Swift),
 				IPsToRouteViaHost: []string{nodeLocalDNSIP},
+				MTUPolicy:         v.MTUPolicy,
 				IPAM: cni.IPAM{
 					Type: network.AzureCNS,
 					Mode: string(util.V4Overlay),
@@ -62,6 +63,7 @@ func (v *DualStackOverlayGenerator) Generate() error {
 				Type:              overlaycniType,
 				Mode:              cninet.OpModeTransparent,
 				IPsToRouteViaHost: []string{nodeLocalDNSIP},
+				MTUPolicy:         v.MTUPolicy,
 				IPAM: cni.IPAM{
 					Type: network.AzureCNS,
 					Mode: string(util.DualStackOverlay),
@@ -90,6 +92,7 @@ func (v *OverlayGenerator) Generate() error {
 				Type:              overlaycniType,
 				Mode:              cninet.OpModeTransparent,
 				IPsToRouteViaHost: []string{nodeLocalDNSIP},
+				MTUPolicy:         v.MTUPolicy,
 				IPAM: cni.IPAM{
 					Type: network.AzureCNS,
 					Mode: string(util.Overlay),
@@ -145,6 +148,7 @@ func (v *SWIFTGenerator) Generate() error {
 				Mode:              cninet.OpModeTransparent,
 				ExecutionMode:     string(util.V4Swift),
 				IPsToRouteViaHost: []string{nodeLocalDNSIP},
+				MTUPolicy:         v.MTUPolicy,
 				IPAM: cni.IPAM{
 					Type: network.AzureCNS,
 				},
@@ -5,6 +5,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/Azure/azure-container-networking/cni"
 	"github.com/Azure/azure-container-networking/cns/cniconflist"
 	"github.com/stretchr/testify/assert"
 )
@@ -92,6 +93,28 @@ func TestGenerateSWIFTConflist(t *testing.T) {
 	assert.Equal(t, removeNewLines(fixtureBytes), removeNewLines(buffer.Bytes()))
 }
 
+func TestGenerateOverlayConflistWithMTUPolicy(t *testing.T) {
+	fixture := "testdata/fixtures/azure-linux-swift-overlay-mtu.conflist"
+
+	buffer := new(bytes.Buffer)
+	g := cniconflist.OverlayGenerator{
+		Writer: &bufferWriteCloser{buffer},
+		MTUPolicy: &cni.MTUPolicy{
+			Mode:             cni.MTUModeMaster,
+			Overhead:         50,
+			NICTypeOverrides: map[string]int{"FrontendNIC": 9000},
+		},
+	}
+	err := g.Generate()
+	assert.NoError(t, err)
+
+	fixtureBytes, err := os.ReadFile(fixture)
+	assert.NoError(t, err)
+
+	// remove newlines and carriage returns in case these UTs are running on Windows
+	assert.Equal(t, removeNewLines(fixtureBytes), removeNewLines(buffer.Bytes()))
+}
+
 // removeNewLines will remove the newlines and carriage returns from the byte slice
 func removeNewLines(b []byte) []byte {
 	var bb []byte //nolint:prealloc // can't prealloc since we don't know how many bytes will get removed
@@ -4,7 +4,10 @@
 package common
 
 import (
+	"context"
 	"errors"
+	"net"
+	"net/http"
 
 	"github.com/Azure/azure-container-networking/cns/logger"
 	acn "github.com/Azure/azure-container-networking/common"
@@ -41,6 +44,14 @@ type ServiceConfig struct {
 	Server      server
 	ChannelMode string
 	TLSSettings tls.TlsSettings
+	// Context is the lifetime of the service, the background work it starts such as certificate refreshes stops
+	// when it is done.
+	Context context.Context
+	// Middleware and ConnContext are applied to every listener of the service when set.
+	Middleware  func(http.Handler) http.Handler
+	ConnContext func(context.Context, net.Conn) context.Context
+	// UnixSocketPath additionally serves the service on a unix domain socket when set.
+	UnixSocketPath string
 }
 
 // server struct to store primaryInterfaceIP from VM, port where customer provides by -p and temporary flag EnableLocalServer
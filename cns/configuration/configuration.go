@@ -8,6 +8,7 @@ import (
 	"runtime"
 	"strings"
 
+	"github.com/Azure/azure-container-networking/cni"
 	"github.com/Azure/azure-container-networking/cns"
 	"github.com/Azure/azure-container-networking/cns/logger"
 	loggerv2 "github.com/Azure/azure-container-networking/cns/logger/v2"
@@ -22,43 +23,52 @@ const (
 )
 
 type CNSConfig struct {
-	AZRSettings                 AZRSettings
-	AsyncPodDeletePath          string
-	CNIConflistFilepath         string
-	CNIConflistScenario         string
-	ChannelMode                 string
-	EnableAPIServerHealthPing   bool
-	EnableAsyncPodDelete        bool
-	EnableCNIConflistGeneration bool
-	EnableIPAMv2                bool
-	EnableK8sDevicePlugin       bool
-	EnableLoggerV2              bool
-	EnablePprof                 bool
-	EnableStateMigration        bool
-	EnableSubnetScarcity        bool
-	EnableSwiftV2               bool
-	InitializeFromCNI           bool
-	KeyVaultSettings            KeyVaultSettings
-	Logger                      loggerv2.Config
-	MSISettings                 MSISettings
-	ManageEndpointState         bool
-	ManagedSettings             ManagedSettings
-	MellanoxMonitorIntervalSecs int
-	MetricsBindAddress          string
-	ProgramSNATIPTables         bool
-	SyncHostNCTimeoutMs         int
-	SyncHostNCVersionIntervalMs int
-	TLSCertificatePath          string
-	TLSEndpoint                 string
-	TLSPort                     string
-	TLSSubjectName              string
-	TelemetrySettings           TelemetrySettings
-	UseHTTPS                    bool
-	UseMTLS                     bool
-	WatchPods                   bool `json:"-"`
-	WireserverIP                string
-	GRPCSettings                GRPCSettings
-	MinTLSVersion               string
+	AZRSettings                  AZRSettings
+	AddressConflictProbe         AddressConflictProbeSettings
+	AsyncPodDeletePath           string
+	Authorization                AuthorizationSettings
+	CNIConflistFilepath          string
+	CNIConflistMTUPolicy         *cni.MTUPolicy
+	CNIConflistScenario          string
+	ChannelMode                  string
+	EnableAPIServerHealthPing    bool
+	EnableAsyncPodDelete         bool
+	EnableCNIConflistGeneration  bool
+	EnableIPAMv2                 bool
+	EnableK8sDevicePlugin        bool
+	EnableLoggerV2               bool
+	EnableNICHotPlug             bool
+	EnableOverlayExtensionConfig bool
+	EnablePNIReservations        bool
+	EnablePprof                  bool
+	EnableStateMigration         bool
+	EnableSubnetScarcity         bool
+	EnableSwiftV2                bool
+	HostRoutes                   HostRoutesSettings
+	IPAudit                      IPAuditSettings
+	InitializeFromCNI            bool
+	KeyVaultSettings             KeyVaultSettings
+	Logger                       loggerv2.Config
+	MSISettings                  MSISettings
+	ManageEndpointState          bool
+	ManagedSettings              ManagedSettings
+	MellanoxMonitorIntervalSecs  int
+	MetricsBindAddress           string
+	NodeSubnetIPDrift            NodeSubnetIPDriftSettings
+	ProgramSNATIPTables          bool
+	SyncHostNCTimeoutMs          int
+	SyncHostNCVersionIntervalMs  int
+	TLSCertificatePath           string
+	TLSEndpoint                  string
+	TLSPort                      string
+	TLSSubjectName               string
+	TelemetrySettings            TelemetrySettings
+	UseHTTPS                     bool
+	UseMTLS                      bool
+	WatchPods                    bool `json:"-"`
+	WireserverIP                 string
+	GRPCSettings                 GRPCSettings
+	MinTLSVersion                string
 }
 
 type TelemetrySettings struct {
@@ -101,6 +111,52 @@ type AZRSettings struct {
 	PopulateHomeAzCacheRetryIntervalSecs int
 }
 
+type NodeSubnetIPDriftSettings struct {
+	// Remediation of IPs assigned to Pods which are removed from the NIC in NodeSubnet mode: "keep" (default) only
+	// reports them, "pendingrelease" keeps them from being assigned again once released, and "evict" also evicts
+	// their Pods.
+	Policy string
+}
+
+type HostRoutesSettings struct {
+	// Enable programming the host routes of the NC goal states and of SwiftV2 on the host, and correcting them when
+	// they drift. The routes of Pods are programmed by CNI in the Pod network namespace and are not managed.
+	// Only NCs published by DNC with HostRoutes and SwiftV2 with an infra gateway have host routes; NCs from a
+	// NodeNetworkConfig have none.
+	Enable bool
+	// Time between reconciles of the routes in seconds, defaults to 60.
+	ReconcileIntervalSecs int
+	// Gateways on the infra NIC of the host through which SwiftV2 routes the infra VNET and service CIDRs, so that
+	// they are kept off a delegated NIC. No routes are programmed for a family without a gateway.
+	SwiftV2InfraGatewayV4 string
+	SwiftV2InfraGatewayV6 string
+}
+
+type IPAuditSettings struct {
+	// Enable periodically cross-checking the IPs assigned by CNS against the CNI state and the Pods in the API server.
+	// Only CRD mode (Swift) is audited, the IPs of node subnet and managed mode are not.
+	Enable bool
+	// Time between audits in seconds, defaults to 300.
+	IntervalSecs int
+	// ReleaseLeakedIPs releases the IPs assigned to Pods which are gone once they were found leaked for the grace period.
+	ReleaseLeakedIPs bool
+	// Time for which an IP must be found leaked before it is released in seconds, defaults to 600.
+	LeakedIPGracePeriodSecs int
+}
+
+type AddressConflictProbeSettings struct {
+	// Enable probing the link for another host using an IP before assigning it to a Pod.
+	Enable bool
+	// Interface to probe on, defaults to the interface with a subnet containing the IP.
+	Interface string
+	// Time to wait for an answer to each probe in milliseconds.
+	TimeoutMs int
+	// Number of probes sent for each IP.
+	Attempts int
+	// Time an IP found in use is kept from being assigned in seconds.
+	QuarantineDurationSecs int
+}
+
 type MSISettings struct {
 	ResourceID string
 }
@@ -111,6 +167,35 @@ type KeyVaultSettings struct {
 	RefreshIntervalInHrs int
 }
 
+// AuthorizationSettings configures caller authorization for the CNS HTTP API.
+type AuthorizationSettings struct {
+	// Enable turns on authorization for every route served by CNS.
+	Enable bool
+	// UnixSocketPath additionally serves the API on a Unix domain socket, where callers are identified by SO_PEERCRED.
+	UnixSocketPath string
+	// DefaultAllow admits requests to routes that no rule matches. Routes are denied by default.
+	DefaultAllow bool
+	// Rules are evaluated in order and the first rule matching the route and method decides.
+	Rules []AuthorizationRule
+}
+
+// AuthorizationRule allows a set of callers to reach a route.
+type AuthorizationRule struct {
+	// Path is the route the rule applies to. A trailing "*" matches every route with that prefix.
+	Path string
+	// Methods limits the rule to the given HTTP methods. Empty matches every method.
+	Methods []string
+	// AllowUIDs admits Unix socket callers running as one of the given users.
+	AllowUIDs []uint32
+	// AllowProcesses admits Unix socket callers whose executable has one of the given absolute paths. Only
+	// executables owned by root in a directory owned by root are identified.
+	AllowProcesses []string
+	// AllowSubjects admits TLS callers whose verified client certificate has one of the given common or DNS names.
+	AllowSubjects []string
+	// AllowAnonymous admits callers without an identity, such as plain TCP clients.
+	AllowAnonymous bool
+}
+
 type GRPCSettings struct {
 	Enable    bool
 	IPAddress string
@@ -199,6 +284,13 @@ func setAZRSettingsDefaults(azrSettings *AZRSettings) {
 	}
 }
 
+func setAddressConflictProbeDefaults(probeSettings *AddressConflictProbeSettings) {
+	if probeSettings.QuarantineDurationSecs == 0 {
+		// keep IPs found in use from being assigned for 10 minutes
+		probeSettings.QuarantineDurationSecs = 600
+	}
+}
+
 func setKeyVaultSettingsDefaults(kvs *KeyVaultSettings) {
 	if kvs.RefreshIntervalInHrs == 0 {
 		kvs.RefreshIntervalInHrs = 12 //nolint:gomnd // default times
@@ -211,6 +303,7 @@ func SetCNSConfigDefaults(config *CNSConfig) {
 	setManagedSettingDefaults(&config.ManagedSettings)
 	setKeyVaultSettingsDefaults(&config.KeyVaultSettings)
 	setAZRSettingsDefaults(&config.AZRSettings)
+	setAddressConflictProbeDefaults(&config.AddressConflictProbe)
 
 	if config.ChannelMode == "" {
 		config.ChannelMode = cns.Direct
@@ -241,7 +334,7 @@ func SetCNSConfigDefaults(config *CNSConfig) {
 		config.MinTLSVersion = "TLS 1.2"
 	}
 	config.GRPCSettings.Enable = false
-	config.WatchPods = config.EnableIPAMv2 || config.EnableSwiftV2
+	config.WatchPods = config.EnableIPAMv2 || config.EnableSwiftV2 || config.IPAudit.Enable
 }
 
 // isStalessCNIMode verify if the CNI is running stateless mode
@@ -215,6 +215,9 @@ func TestSetCNSConfigDefaults(t *testing.T) {
 				AZRSettings: AZRSettings{
 					PopulateHomeAzCacheRetryIntervalSecs: 60,
 				},
+				AddressConflictProbe: AddressConflictProbeSettings{
+					QuarantineDurationSecs: 600,
+				},
 				WireserverIP:       "168.63.129.16",
 				AsyncPodDeletePath: "/var/run/azure-vnet/deleteIDs",
 				GRPCSettings: GRPCSettings{
@@ -248,6 +251,9 @@ func TestSetCNSConfigDefaults(t *testing.T) {
 				AZRSettings: AZRSettings{
 					PopulateHomeAzCacheRetryIntervalSecs: 10,
 				},
+				AddressConflictProbe: AddressConflictProbeSettings{
+					QuarantineDurationSecs: 300,
+				},
 				GRPCSettings: GRPCSettings{
 					Enable:    false,
 					IPAddress: "192.168.1.1",
@@ -276,6 +282,9 @@ func TestSetCNSConfigDefaults(t *testing.T) {
 				AZRSettings: AZRSettings{
 					PopulateHomeAzCacheRetryIntervalSecs: 10,
 				},
+				AddressConflictProbe: AddressConflictProbeSettings{
+					QuarantineDurationSecs: 300,
+				},
 				WireserverIP:       "168.63.129.16",
 				AsyncPodDeletePath: "/var/run/azure-vnet/deleteIDs",
 				GRPCSettings: GRPCSettings{
@@ -0,0 +1,345 @@
+// Copyright Microsoft. All rights reserved.
+package configuration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Azure/azure-container-networking/aitelemetry"
+	"github.com/Azure/azure-container-networking/cns/fsnotify"
+	"github.com/Azure/azure-container-networking/cns/logger"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// maxReloadEvents is the number of reloads kept for the /debug/config endpoint.
+const maxReloadEvents = 32
+
+var errInvalidConfig = errors.New("invalid config")
+
+// liveFields are the fields which are applied to a running CNS when the config file changes.
+// Changes to any other field are reported but only take effect after CNS is restarted.
+var liveFields = map[string]struct{}{
+	"Logger.Level":                              {},
+	"MellanoxMonitorIntervalSecs":               {},
+	"SyncHostNCVersionIntervalMs":               {},
+	"TelemetrySettings.HeartBeatIntervalInMins": {},
+}
+
+// secretFields are the fields whose values are never logged, sent to telemetry or served on the /debug/config
+// endpoint. They are strings, or maps of strings of which every value is secret.
+var secretFields = map[string]struct{}{
+	"Logger.AppInsights.IKey":                         {},
+	"Logger.OTLP.Headers":                             {},
+	"Logger.Tracing.Headers":                          {},
+	"TelemetrySettings.AppInsightsInstrumentationKey": {},
+}
+
+// redactedValue replaces the value of a secret field which is set.
+const redactedValue = "REDACTED"
+
+// FieldChange is a single difference between two CNS configs.
+type FieldChange struct {
+	// Field is the dotted path of the field, such as "Logger.Level".
+	Field string `json:"field"`
+	Old   any    `json:"old"`
+	New   any    `json:"new"`
+	// Live is set when the change is applied without restarting CNS.
+	Live bool `json:"live"`
+}
+
+// ReloadEvent records one reload of the config file.
+type ReloadEvent struct {
+	Time    time.Time     `json:"time"`
+	Changes []FieldChange `json:"changes,omitempty"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// Diff returns the fields which differ between old and updated, ordered as they are declared in CNSConfig.
+// Nested structs are compared field by field; slices and maps are compared as a whole.
+func Diff(old, updated *CNSConfig) []FieldChange {
+	var changes []FieldChange
+	diffValues("", reflect.ValueOf(old).Elem(), reflect.ValueOf(updated).Elem(), &changes)
+	return changes
+}
+
+func diffValues(prefix string, old, updated reflect.Value, changes *[]FieldChange) {
+	t := old.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() || f.Tag.Get("json") == "-" {
+			continue
+		}
+		name := prefix + f.Name
+		o, u := old.Field(i), updated.Field(i)
+		if o.Kind() == reflect.Ptr && !o.IsNil() && !u.IsNil() && o.Elem().Kind() == reflect.Struct {
+			o, u = o.Elem(), u.Elem()
+		}
+		if o.Kind() == reflect.Struct {
+			diffValues(name+".", o, u, changes)
+			continue
+		}
+		if reflect.DeepEqual(o.Interface(), u.Interface()) {
+			continue
+		}
+		_, live := liveFields[name]
+		*changes = append(*changes, FieldChange{Field: name, Old: redactedAt(name, o), New: redactedAt(name, u), Live: live})
+	}
+}
+
+// Redacted returns a deep copy of config with the values of its secret fields replaced, so that it can be served.
+func Redacted(config *CNSConfig) *CNSConfig {
+	return redactedAt("", reflect.ValueOf(config).Elem()).(*CNSConfig)
+}
+
+// redactedAt returns a deep copy of the value of the field at the dotted path, with the values of the secret fields
+// it holds replaced. The whole config is at the empty path, and is returned as a pointer.
+func redactedAt(path string, v reflect.Value) any {
+	redacted := reflect.New(v.Type()).Elem()
+	redacted.Set(deepCopy(v))
+	for secret := range secretFields {
+		switch {
+		case path == "":
+			redactField(redacted, secret)
+		case secret == path:
+			redactField(redacted, "")
+		case strings.HasPrefix(secret, path+"."):
+			redactField(redacted, strings.TrimPrefix(secret, path+"."))
+		}
+	}
+	if path == "" {
+		return redacted.Addr().Interface()
+	}
+	return redacted.Interface()
+}
+
+// redactField replaces the value of the secret field at the dotted path in v, if it is set.
+func redactField(v reflect.Value, path string) {
+	if path != "" {
+		for _, name := range strings.Split(path, ".") {
+			if v.Kind() == reflect.Ptr {
+				if v.IsNil() {
+					return
+				}
+				v = v.Elem()
+			}
+			v = v.FieldByName(name)
+		}
+	}
+	switch v.Kind() { //nolint:exhaustive // secret fields are strings or maps of strings
+	case reflect.String:
+		if v.Len() > 0 {
+			v.SetString(redactedValue)
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			v.SetMapIndex(key, reflect.ValueOf(redactedValue))
+		}
+	}
+}
+
+// deepCopy returns a copy of v which shares no pointers, maps or slices with it. Unexported fields are copied as
+// they are, as they can not be set.
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() { //nolint:exhaustive // other kinds are values
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(deepCopy(v.Elem()))
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(deepCopy(v.Field(i)))
+			}
+		}
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), deepCopy(iter.Value()))
+		}
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return c
+	default:
+		return v
+	}
+}
+
+// copyField sets the field at the dotted path in dst to its value in src.
+func copyField(dst, src *CNSConfig, path string) {
+	d, s := reflect.ValueOf(dst).Elem(), reflect.ValueOf(src).Elem()
+	for _, name := range strings.Split(path, ".") {
+		if d.Kind() == reflect.Ptr {
+			if d.IsNil() || s.IsNil() {
+				return
+			}
+			d, s = d.Elem(), s.Elem()
+		}
+		d, s = d.FieldByName(name), s.FieldByName(name)
+	}
+	d.Set(s)
+}
+
+// validateLive checks the fields which would be applied live, so that a bad edit can not stop a running loop.
+func validateLive(config *CNSConfig) error {
+	if config.SyncHostNCVersionIntervalMs < 0 {
+		return errors.Wrapf(errInvalidConfig, "SyncHostNCVersionIntervalMs must not be negative, got %d", config.SyncHostNCVersionIntervalMs)
+	}
+	if config.MellanoxMonitorIntervalSecs < 0 {
+		return errors.Wrapf(errInvalidConfig, "MellanoxMonitorIntervalSecs must not be negative, got %d", config.MellanoxMonitorIntervalSecs)
+	}
+	if config.TelemetrySettings.HeartBeatIntervalInMins < 0 {
+		return errors.Wrapf(errInvalidConfig, "TelemetrySettings.HeartBeatIntervalInMins must not be negative, got %d", config.TelemetrySettings.HeartBeatIntervalInMins)
+	}
+	return nil
+}
+
+// Reloader watches the CNS config file and applies changes to live fields without a restart.
+type Reloader struct {
+	path    string
+	current atomic.Pointer[CNSConfig]
+
+	mu       sync.Mutex
+	file     *CNSConfig // last config read from the file, which the next read is compared against
+	events   []ReloadEvent
+	handlers []func(old, updated *CNSConfig)
+}
+
+// NewReloader creates a Reloader for the config file that ReadConfig resolves from cmdLineConfigPath.
+// current is the config CNS is running with and is never modified; each reload publishes a copy.
+func NewReloader(cmdLineConfigPath string, current *CNSConfig) (*Reloader, error) {
+	configpath, err := getConfigFilePath(cmdLineConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	file, err := readConfigFromFile(configpath)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		file = &CNSConfig{}
+	}
+	SetCNSConfigDefaults(file)
+	r := &Reloader{path: configpath, file: file}
+	r.current.Store(current)
+	return r, nil
+}
+
+// Current returns the effective CNS config. The returned config must not be modified.
+func (r *Reloader) Current() *CNSConfig {
+	return r.current.Load()
+}
+
+// Events returns the most recent reloads, oldest first.
+func (r *Reloader) Events() []ReloadEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]ReloadEvent(nil), r.events...)
+}
+
+// OnChange registers fn to be called after a reload applies live changes.
+// fn is called with the previous and the new effective config.
+func (r *Reloader) OnChange(fn func(old, updated *CNSConfig)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers = append(r.handlers, fn)
+}
+
+// Start watches the config file and reloads it on every change. Blocks until the context is closed.
+func (r *Reloader) Start(ctx context.Context, z *zap.Logger) error {
+	return fsnotify.WatchFile(ctx, r.path, func() { r.Reload() }, z) //nolint:wrapcheck // ignore
+}
+
+// Reload reads and validates the config file and applies changes to live fields.
+// It returns the recorded event, or nil if the file did not change.
+func (r *Reloader) Reload() *ReloadEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	updated, err := readConfigFromFile(r.path)
+	if err == nil {
+		SetCNSConfigDefaults(updated)
+		err = validateLive(updated)
+	}
+	if err != nil {
+		logger.Errorf("[Configuration] Rejected reload of %s, keeping the running config: %v", r.path, err)
+		return r.record(ReloadEvent{Time: time.Now(), Error: err.Error()})
+	}
+
+	changes := Diff(r.file, updated)
+	if len(changes) == 0 {
+		return nil
+	}
+	r.file = updated
+
+	old := r.current.Load()
+	next := *old
+	applied := 0
+	for _, c := range changes {
+		if !c.Live {
+			logger.Warnf("[Configuration] %s changed from %v to %v and requires a CNS restart to take effect", c.Field, c.Old, c.New)
+			continue
+		}
+		copyField(&next, updated, c.Field)
+		applied++
+	}
+	logger.Printf("[Configuration] Reloaded %s: applied %d of %d changes", r.path, applied, len(changes))
+	event := r.record(ReloadEvent{Time: time.Now(), Changes: changes})
+	if applied == 0 {
+		return event
+	}
+
+	r.current.Store(&next)
+	for _, fn := range r.handlers {
+		fn(old, &next)
+	}
+	return event
+}
+
+// record keeps the event for the /debug/config endpoint and sends it to telemetry. Callers must hold r.mu.
+func (r *Reloader) record(event ReloadEvent) *ReloadEvent {
+	if len(r.events) == maxReloadEvents {
+		r.events = r.events[1:]
+	}
+	r.events = append(r.events, event)
+
+	properties := map[string]string{}
+	if event.Error != "" {
+		properties[logger.CNSConfigReloadErrorPropertyStr] = event.Error
+	} else {
+		bb, err := json.Marshal(event.Changes)
+		if err != nil {
+			bb = []byte(fmt.Sprintf("%+v", event.Changes))
+		}
+		properties[logger.CNSConfigChangesPropertyStr] = string(bb)
+	}
+	logger.LogEvent(aitelemetry.Event{
+		EventName:  logger.ConfigReloadEventStr,
+		Properties: properties,
+	})
+	return &event
+}
@@ -0,0 +1,126 @@
+package configuration
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/cns/logger"
+	loggerv2 "github.com/Azure/azure-container-networking/cns/logger/v2"
+	cores "github.com/Azure/azure-container-networking/log/v2/cores"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	logger.InitLogger("testlogs", 0, 0, "./")
+	os.Exit(m.Run())
+}
+
+func TestDiff(t *testing.T) {
+	old := &CNSConfig{
+		SyncHostNCVersionIntervalMs: 1000,
+		Logger:                      loggerv2.Config{Level: "info"},
+		TelemetrySettings:           TelemetrySettings{HeartBeatIntervalInMins: 30},
+		MetricsBindAddress:          ":9090",
+	}
+	updated := &CNSConfig{
+		SyncHostNCVersionIntervalMs: 500,
+		Logger:                      loggerv2.Config{Level: "debug"},
+		TelemetrySettings:           TelemetrySettings{HeartBeatIntervalInMins: 30},
+		MetricsBindAddress:          ":9091",
+		WatchPods:                   true,
+	}
+
+	changes := Diff(old, updated)
+	assert.Equal(t, []FieldChange{
+		{Field: "Logger.Level", Old: "info", New: "debug", Live: true},
+		{Field: "MetricsBindAddress", Old: ":9090", New: ":9091", Live: false},
+		{Field: "SyncHostNCVersionIntervalMs", Old: 1000, New: 500, Live: true},
+	}, changes)
+	assert.Empty(t, Diff(old, old))
+}
+
+func TestRedacted(t *testing.T) {
+	config := &CNSConfig{
+		Logger: loggerv2.Config{
+			Level:       "info",
+			AppInsights: &cores.AppInsightsConfig{IKey: "logger-ikey"},
+			OTLP:        &cores.OTLPConfig{Endpoint: "collector:4318", Headers: map[string]string{"Authorization": "Bearer token"}},
+			Tracing:     &loggerv2.TracingConfig{Headers: map[string]string{"Authorization": "Bearer token"}},
+		},
+		TelemetrySettings: TelemetrySettings{AppInsightsInstrumentationKey: "ikey", HeartBeatIntervalInMins: 30},
+	}
+	redacted := Redacted(config)
+	assert.Equal(t, "REDACTED", redacted.TelemetrySettings.AppInsightsInstrumentationKey)
+	assert.Equal(t, 30, redacted.TelemetrySettings.HeartBeatIntervalInMins)
+	assert.Equal(t, "REDACTED", redacted.Logger.AppInsights.IKey)
+	assert.Equal(t, map[string]string{"Authorization": "REDACTED"}, redacted.Logger.OTLP.Headers)
+	assert.Equal(t, "collector:4318", redacted.Logger.OTLP.Endpoint)
+	assert.Equal(t, map[string]string{"Authorization": "REDACTED"}, redacted.Logger.Tracing.Headers)
+
+	// the config is not modified, and shares nothing with the copy
+	assert.Equal(t, "ikey", config.TelemetrySettings.AppInsightsInstrumentationKey)
+	assert.Equal(t, "logger-ikey", config.Logger.AppInsights.IKey)
+	assert.Equal(t, "Bearer token", config.Logger.OTLP.Headers["Authorization"])
+	assert.Equal(t, "Bearer token", config.Logger.Tracing.Headers["Authorization"])
+	assert.NotSame(t, config.Logger.OTLP, redacted.Logger.OTLP)
+	assert.Empty(t, Redacted(&CNSConfig{}).TelemetrySettings.AppInsightsInstrumentationKey)
+
+	// secrets are redacted from changes, including of the structs which hold them
+	changes := Diff(&CNSConfig{}, config)
+	assert.Contains(t, changes, FieldChange{Field: "TelemetrySettings.AppInsightsInstrumentationKey", Old: "", New: "REDACTED"})
+	for _, c := range changes {
+		switch c.Field {
+		case "Logger.AppInsights":
+			assert.Equal(t, "REDACTED", c.New.(*cores.AppInsightsConfig).IKey)
+		case "Logger.OTLP":
+			assert.Equal(t, map[string]string{"Authorization": "REDACTED"}, c.New.(*cores.OTLPConfig).Headers)
+		case "Logger.Tracing":
+			assert.Equal(t, map[string]string{"Authorization": "REDACTED"}, c.New.(*loggerv2.TracingConfig).Headers)
+		}
+	}
+	changes = Diff(config, Redacted(config))
+	assert.Contains(t, changes, FieldChange{Field: "Logger.OTLP.Headers", Old: map[string]string{"Authorization": "REDACTED"}, New: map[string]string{"Authorization": "REDACTED"}})
+	assert.Equal(t, "Bearer token", config.Logger.OTLP.Headers["Authorization"])
+}
+
+func TestReloaderReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cns_config.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"SyncHostNCVersionIntervalMs":1000,"MetricsBindAddress":":9090"}`), 0o600))
+	current, err := readConfigFromFile(path)
+	require.NoError(t, err)
+	SetCNSConfigDefaults(current)
+
+	r, err := NewReloader(path, current)
+	require.NoError(t, err)
+	var notified *CNSConfig
+	r.OnChange(func(_, updated *CNSConfig) { notified = updated })
+
+	// nothing changed
+	assert.Nil(t, r.Reload())
+
+	// a live field is applied, a restart-only field is reported but not applied
+	require.NoError(t, os.WriteFile(path, []byte(`{"SyncHostNCVersionIntervalMs":250,"MetricsBindAddress":":9091"}`), 0o600))
+	event := r.Reload()
+	require.NotNil(t, event)
+	assert.Empty(t, event.Error)
+	assert.Len(t, event.Changes, 2)
+	assert.Equal(t, 250, r.Current().SyncHostNCVersionIntervalMs)
+	assert.Equal(t, ":9090", r.Current().MetricsBindAddress)
+	assert.Equal(t, 1000, current.SyncHostNCVersionIntervalMs, "the running config must not be modified")
+	assert.Same(t, r.Current(), notified)
+
+	// invalid files are rejected and the effective config is kept
+	require.NoError(t, os.WriteFile(path, []byte(`{"SyncHostNCVersionIntervalMs":-1}`), 0o600))
+	event = r.Reload()
+	require.NotNil(t, event)
+	assert.Contains(t, event.Error, "SyncHostNCVersionIntervalMs")
+	require.NoError(t, os.WriteFile(path, []byte(`{`), 0o600))
+	event = r.Reload()
+	require.NotNil(t, event)
+	assert.NotEmpty(t, event.Error)
+	assert.Equal(t, 250, r.Current().SyncHostNCVersionIntervalMs)
+
+	assert.Len(t, r.Events(), 3)
+}
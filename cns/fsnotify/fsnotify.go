@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/Azure/azure-container-networking/cns"
+	acnfs "github.com/Azure/azure-container-networking/internal/fs"
 	"github.com/fsnotify/fsnotify"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
@@ -206,3 +207,13 @@ func (w *watcher) releaseIP(ctx context.Context, podInterfaceID, containerID str
 	}
 	return errors.Wrap(w.cli.ReleaseIPs(ctx, *ipconfigreq), "failed to release IP from CNS")
 }
+
+// WatchFile calls onChange whenever the file at path is written or replaced.
+// See fs.WatchFile for the events that are considered a change.
+// Blocks until the context is closed.
+func WatchFile(ctx context.Context, path string, onChange func(), logger *zap.Logger) error {
+	logger.Info("watching file for changes", zap.String("path", path))
+	return acnfs.WatchFile(ctx, path, onChange, func(err error) { //nolint:wrapcheck // ignore
+		logger.Error("fsnotify watcher error", zap.String("path", path), zap.Error(err))
+	})
+}
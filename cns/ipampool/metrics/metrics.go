@@ -81,6 +81,14 @@ var (
 		},
 		[]string{SubnetLabel, SubnetCIDRLabel, PodnetARMIDLabel},
 	)
+	IpamQuarantinedIPCount = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cx_ipam_quarantined_ips",
+			Help:        "IPs reserved but found in use by another host (Quarantined).",
+			ConstLabels: prometheus.Labels{customerMetricLabel: customerMetricLabelValue},
+		},
+		[]string{SubnetLabel, SubnetCIDRLabel, PodnetARMIDLabel},
+	)
 	IpamPrimaryIPCount = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name:        "cx_ipam_primary_ips",
@@ -140,6 +148,7 @@ func init() {
 		IpamMaxIPCount,
 		IpamPendingProgramIPCount,
 		IpamPendingReleaseIPCount,
+		IpamQuarantinedIPCount,
 		IpamPrimaryIPCount,
 		IpamSecondaryIPCount,
 		IpamRequestedIPConfigCount,
@@ -7,7 +7,7 @@ import (
 
 	"github.com/Azure/azure-container-networking/cns"
 	"github.com/Azure/azure-container-networking/cns/types"
-	"github.com/Azure/azure-container-networking/crd/clustersubnetstate/api/v1alpha1"
+	"github.com/Azure/azure-container-networking/crd/clustersubnetstate/api/v1alpha2"
 	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
 	"github.com/pkg/errors"
 	"golang.org/x/sync/errgroup"
@@ -22,7 +22,7 @@ type ipPoolState struct {
 	allocatedToPods int64
 	// available are the IPs in state "Available".
 	available int64
-	// currentAvailableIPs are the current available IPs: allocated - assigned - pendingRelease.
+	// currentAvailableIPs are the current available IPs: allocated - assigned - pendingRelease - quarantined.
 	currentAvailableIPs int64
 	// expectedAvailableIPs are the "future" available IPs, if the requested IP count is honored: requested - assigned.
 	expectedAvailableIPs int64
@@ -30,6 +30,8 @@ type ipPoolState struct {
 	pendingProgramming int64
 	// pendingRelease are the IPs in state "PendingRelease".
 	pendingRelease int64
+	// quarantined are the IPs in state "Quarantined".
+	quarantined int64
 	// requestedIPs are the IPs CNS has requested that it be allocated by DNC.
 	requestedIPs int64
 	// secondaryIPs are all the IPs given to CNS by DNC, not including the primary IP of the NC.
@@ -50,14 +52,14 @@ type metaState struct {
 type observer struct {
 	ipSrc  func() map[string]cns.IPConfigurationStatus
 	nncSrc func(context.Context) (*v1alpha.NodeNetworkConfig, error)
-	cssSrc func(context.Context) ([]v1alpha1.ClusterSubnetState, error)
+	cssSrc func(context.Context) ([]v1alpha2.ClusterSubnetState, error)
 }
 
 // NewLegacyMetricsObserver creates a closed functional scope which can be invoked to
 // observe the legacy IPAM pool metrics.
 //
 //nolint:lll // ignore line length
-func NewLegacyMetricsObserver(ipSrc func() map[string]cns.IPConfigurationStatus, nncSrc func(context.Context) (*v1alpha.NodeNetworkConfig, error), cssSrc func(context.Context) ([]v1alpha1.ClusterSubnetState, error)) func(context.Context) error {
+func NewLegacyMetricsObserver(ipSrc func() map[string]cns.IPConfigurationStatus, nncSrc func(context.Context) (*v1alpha.NodeNetworkConfig, error), cssSrc func(context.Context) ([]v1alpha2.ClusterSubnetState, error)) func(context.Context) error {
 	return (&observer{
 		ipSrc:  ipSrc,
 		nncSrc: nncSrc,
@@ -162,6 +164,8 @@ func (o *observer) observeMetrics(ctx context.Context) error {
 					state.pendingProgramming++
 				case types.PendingRelease:
 					state.pendingRelease++
+				case types.Quarantined:
+					state.quarantined++
 				}
 			}
 		}
@@ -170,7 +174,7 @@ func (o *observer) observeMetrics(ctx context.Context) error {
 
 	err := g.Wait()
 
-	state.currentAvailableIPs = state.secondaryIPs - state.allocatedToPods - state.pendingRelease
+	state.currentAvailableIPs = state.secondaryIPs - state.allocatedToPods - state.pendingRelease - state.quarantined
 	state.expectedAvailableIPs = state.requestedIPs - state.allocatedToPods
 
 	// Update the metrics.
@@ -183,6 +187,7 @@ func (o *observer) observeMetrics(ctx context.Context) error {
 	IpamMaxIPCount.WithLabelValues(labels...).Set(float64(meta.max))
 	IpamPendingProgramIPCount.WithLabelValues(labels...).Set(float64(state.pendingProgramming))
 	IpamPendingReleaseIPCount.WithLabelValues(labels...).Set(float64(state.pendingRelease))
+	IpamQuarantinedIPCount.WithLabelValues(labels...).Set(float64(state.quarantined))
 	IpamPrimaryIPCount.WithLabelValues(labels...).Set(float64(len(meta.primaryIPAddresses)))
 	IpamRequestedIPConfigCount.WithLabelValues(labels...).Set(float64(state.requestedIPs))
 	IpamSecondaryIPCount.WithLabelValues(labels...).Set(float64(state.secondaryIPs))
@@ -10,10 +10,11 @@ import (
 
 	"github.com/Azure/azure-container-networking/cns"
 	"github.com/Azure/azure-container-networking/cns/ipampool/metrics"
+	"github.com/Azure/azure-container-networking/cns/ipampool/scarcity"
 	"github.com/Azure/azure-container-networking/cns/logger"
 	"github.com/Azure/azure-container-networking/cns/metric"
 	"github.com/Azure/azure-container-networking/cns/types"
-	"github.com/Azure/azure-container-networking/crd/clustersubnetstate/api/v1alpha1"
+	"github.com/Azure/azure-container-networking/crd/clustersubnetstate/api/v1alpha2"
 	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
 	"github.com/avast/retry-go/v4"
 	"github.com/pkg/errors"
@@ -44,9 +45,11 @@ type metaState struct {
 	minFreeCount       int64
 	notInUseCount      int64
 	primaryIPAddresses map[string]struct{}
-	subnet             string
-	subnetARMID        string
-	subnetCIDR         string
+	// scarcity is how far the batch and free counts are scaled down as the subnet fills up, from 0 to 1.
+	scarcity    float64
+	subnet      string
+	subnetARMID string
+	subnetCIDR  string
 }
 
 type Options struct {
@@ -60,13 +63,13 @@ type Monitor struct {
 	metastate   metaState
 	nnccli      nodeNetworkConfigSpecUpdater
 	httpService cns.HTTPService
-	cssSource   <-chan v1alpha1.ClusterSubnetState
+	cssSource   <-chan v1alpha2.ClusterSubnetState
 	nncSource   chan v1alpha.NodeNetworkConfig
 	started     chan interface{}
 	once        sync.Once
 }
 
-func NewMonitor(httpService cns.HTTPService, nnccli nodeNetworkConfigSpecUpdater, cssSource <-chan v1alpha1.ClusterSubnetState, opts *Options) *Monitor {
+func NewMonitor(httpService cns.HTTPService, nnccli nodeNetworkConfigSpecUpdater, cssSource <-chan v1alpha2.ClusterSubnetState, opts *Options) *Monitor {
 	if opts.RefreshDelay < 1 {
 		opts.RefreshDelay = DefaultRefreshDelay
 	}
@@ -105,7 +108,8 @@ func (pm *Monitor) Start(ctx context.Context) error {
 			}
 		case css := <-pm.cssSource: // received an updated ClusterSubnetState
 			pm.metastate.exhausted = css.Status.Exhausted
-			logger.Printf("subnet exhausted status = %t", pm.metastate.exhausted)
+			pm.metastate.scarcity = scarcity.Level(&css.Status)
+			logger.Printf("subnet exhausted status = %t, utilization = %.2f, scarcity = %.2f", pm.metastate.exhausted, css.Status.Utilization(), pm.metastate.scarcity)
 			metrics.IpamSubnetExhaustionCount.With(prometheus.Labels{
 				metrics.SubnetLabel: pm.metastate.subnet, metrics.SubnetCIDRLabel: pm.metastate.subnetCIDR,
 				metrics.PodnetARMIDLabel: pm.metastate.subnetARMID, metrics.SubnetExhaustionStateLabel: strconv.FormatBool(pm.metastate.exhausted),
@@ -166,7 +170,7 @@ type ipPoolState struct {
 	allocatedToPods int64
 	// available are the IPs in state "Available".
 	available int64
-	// currentAvailableIPs are the current available IPs: allocated - assigned - pendingRelease.
+	// currentAvailableIPs are the current available IPs: allocated - assigned - pendingRelease - quarantined.
 	currentAvailableIPs int64
 	// expectedAvailableIPs are the "future" available IPs, if the requested IP count is honored: requested - assigned.
 	expectedAvailableIPs int64
@@ -174,6 +178,8 @@ type ipPoolState struct {
 	pendingProgramming int64
 	// pendingRelease are the IPs in state "PendingRelease".
 	pendingRelease int64
+	// quarantined are the IPs in state "Quarantined".
+	quarantined int64
 	// requestedIPs are the IPs CNS has requested that it be allocated by DNC.
 	requestedIPs int64
 	// secondaryIPs are all the IPs given to CNS by DNC, not including the primary IP of the NC.
@@ -196,9 +202,11 @@ func buildIPPoolState(ips map[string]cns.IPConfigurationStatus, spec v1alpha.Nod
 			state.pendingProgramming++
 		case types.PendingRelease:
 			state.pendingRelease++
+		case types.Quarantined:
+			state.quarantined++
 		}
 	}
-	state.currentAvailableIPs = state.secondaryIPs - state.allocatedToPods - state.pendingRelease
+	state.currentAvailableIPs = state.secondaryIPs - state.allocatedToPods - state.pendingRelease - state.quarantined
 	state.expectedAvailableIPs = state.requestedIPs - state.allocatedToPods
 	return state
 }
@@ -217,11 +225,16 @@ func (pm *Monitor) reconcile(ctx context.Context) error {
 		logger.Printf("ipam-pool-monitor state: %+v, meta: %+v", state, meta)
 	}
 
-	// if the subnet is exhausted, overwrite the batch/minfree/maxfree in the meta copy for this iteration
+	// if the subnet is exhausted, overwrite the batch/minfree/maxfree in the meta copy for this iteration,
+	// and if it is filling up scale them down progressively
 	if meta.exhausted {
 		meta.batch = 1
 		meta.minFreeCount = 1
 		meta.maxFreeCount = 2
+	} else if meta.scarcity > 0 {
+		meta.batch = scarcity.Scale(meta.batch, meta.scarcity, 1)
+		meta.minFreeCount = scarcity.Scale(meta.minFreeCount, meta.scarcity, 1)
+		meta.maxFreeCount = scarcity.Scale(meta.maxFreeCount, meta.scarcity, meta.minFreeCount+1)
 	}
 
 	switch {
@@ -493,6 +506,7 @@ func observeIPPoolState(state ipPoolState, meta metaState) {
 	metrics.IpamMaxIPCount.WithLabelValues(labels...).Set(float64(meta.max))
 	metrics.IpamPendingProgramIPCount.WithLabelValues(labels...).Set(float64(state.pendingProgramming))
 	metrics.IpamPendingReleaseIPCount.WithLabelValues(labels...).Set(float64(state.pendingRelease))
+	metrics.IpamQuarantinedIPCount.WithLabelValues(labels...).Set(float64(state.quarantined))
 	metrics.IpamPrimaryIPCount.WithLabelValues(labels...).Set(float64(len(meta.primaryIPAddresses)))
 	metrics.IpamRequestedIPConfigCount.WithLabelValues(labels...).Set(float64(state.requestedIPs))
 	metrics.IpamSecondaryIPCount.WithLabelValues(labels...).Set(float64(state.secondaryIPs))
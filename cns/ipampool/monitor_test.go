@@ -49,6 +49,7 @@ type testState struct {
 	pendingRelease          int64
 	releaseThresholdPercent int64
 	requestThresholdPercent int64
+	scarcity                float64
 	totalIPs                int64
 }
 
@@ -77,6 +78,7 @@ func initFakes(state testState, nnccli nodeNetworkConfigSpecUpdater) (*fakes.HTT
 		batch:     state.batch,
 		max:       state.max,
 		exhausted: state.exhausted,
+		scarcity:  state.scarcity,
 	}
 	fakecns.PoolMonitor = &directUpdatePoolMonitor{m: poolmonitor}
 	if err := fakecns.SetNumberOfAssignedIPs(state.assigned); err != nil {
@@ -132,6 +134,19 @@ func TestPoolSizeIncrease(t *testing.T) {
 			},
 			want: 9,
 		},
+		{
+			name: "subnet filling up",
+			in: testState{
+				allocated:               10,
+				assigned:                8,
+				batch:                   10,
+				max:                     30,
+				releaseThresholdPercent: 150,
+				requestThresholdPercent: 50,
+				scarcity:                .5,
+			},
+			want: 15,
+		},
 	}
 
 	for _, tt := range tests {
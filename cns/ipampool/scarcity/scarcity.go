@@ -0,0 +1,41 @@
+// Package scarcity scales down how many IPs a Node requests at a time as its subnet fills up, so that Nodes
+// back off gradually before the subnet is exhausted instead of all at once when it is.
+package scarcity
+
+import (
+	"math"
+	"time"
+
+	"github.com/Azure/azure-container-networking/crd/clustersubnetstate/api/v1alpha2"
+)
+
+const (
+	// lowUtilization is the subnet utilization up to which the pool is scaled as configured.
+	lowUtilization = 0.7
+	// highUtilization is the subnet utilization from which the pool is scaled as if the subnet were exhausted.
+	highUtilization = 0.95
+	// forecastHorizon is how far ahead the allocation trend of the subnet is projected.
+	forecastHorizon = time.Hour
+)
+
+// Level returns how scarce IPs are in the subnet, from 0 while the forecast utilization is below
+// lowUtilization, rising linearly to 1 as it reaches highUtilization or the subnet is exhausted.
+func Level(status *v1alpha2.ClusterSubnetStateStatus) float64 {
+	if status.Exhausted {
+		return 1
+	}
+	utilization := status.ForecastUtilization(forecastHorizon)
+	switch {
+	case utilization <= lowUtilization:
+		return 0
+	case utilization >= highUtilization:
+		return 1
+	default:
+		return (utilization - lowUtilization) / (highUtilization - lowUtilization)
+	}
+}
+
+// Scale scales down a configured IP count n for the scarcity level, to no less than floor.
+func Scale(n int64, level float64, floor int64) int64 {
+	return max(int64(math.Round(float64(n)*(1-level))), floor)
+}
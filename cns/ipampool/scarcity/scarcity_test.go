@@ -0,0 +1,64 @@
+package scarcity
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-container-networking/crd/clustersubnetstate/api/v1alpha2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLevel(t *testing.T) {
+	tests := []struct {
+		name   string
+		status v1alpha2.ClusterSubnetStateStatus
+		want   float64
+	}{
+		{
+			name:   "unknown size",
+			status: v1alpha2.ClusterSubnetStateStatus{Allocated: 100},
+			want:   0,
+		},
+		{
+			name:   "plenty",
+			status: v1alpha2.ClusterSubnetStateStatus{Total: 1000, Allocated: 500, Reserved: 5},
+			want:   0,
+		},
+		{
+			name:   "filling up",
+			status: v1alpha2.ClusterSubnetStateStatus{Total: 1000, Allocated: 825},
+			want:   .5,
+		},
+		{
+			name:   "forecast to fill up",
+			status: v1alpha2.ClusterSubnetStateStatus{Total: 1000, Allocated: 700, AllocationRatePerHour: 125},
+			want:   .5,
+		},
+		{
+			name:   "releasing",
+			status: v1alpha2.ClusterSubnetStateStatus{Total: 1000, Allocated: 825, AllocationRatePerHour: -100},
+			want:   .5,
+		},
+		{
+			name:   "nearly full",
+			status: v1alpha2.ClusterSubnetStateStatus{Total: 1000, Allocated: 960},
+			want:   1,
+		},
+		{
+			name:   "exhausted",
+			status: v1alpha2.ClusterSubnetStateStatus{Exhausted: true},
+			want:   1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, Level(&tt.status), 1e-9)
+		})
+	}
+}
+
+func TestScale(t *testing.T) {
+	assert.Equal(t, int64(16), Scale(16, 0, 1))
+	assert.Equal(t, int64(8), Scale(16, .5, 1))
+	assert.Equal(t, int64(1), Scale(16, 1, 1))
+	assert.Equal(t, int64(3), Scale(4, .9, 3))
+}
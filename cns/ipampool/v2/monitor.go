@@ -7,7 +7,8 @@ import (
 	"time"
 
 	"github.com/Azure/azure-container-networking/cns"
-	"github.com/Azure/azure-container-networking/crd/clustersubnetstate/api/v1alpha1"
+	"github.com/Azure/azure-container-networking/cns/ipampool/scarcity"
+	"github.com/Azure/azure-container-networking/crd/clustersubnetstate/api/v1alpha2"
 	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
@@ -34,6 +35,8 @@ type scaler struct {
 	buffer    float64
 	exhausted bool
 	max       int64
+	// scarcity is how far the batch is scaled down as the subnet fills up, from 0 to 1.
+	scarcity float64
 }
 
 type Monitor struct {
@@ -44,14 +47,14 @@ type Monitor struct {
 	demand                int64
 	request               int64
 	demandSource          <-chan int
-	cssSource             <-chan v1alpha1.ClusterSubnetState
+	cssSource             <-chan v1alpha2.ClusterSubnetState
 	nncSource             <-chan v1alpha.NodeNetworkConfig
 	started               chan interface{}
 	once                  sync.Once
 	legacyMetricsObserver func(context.Context) error
 }
 
-func NewMonitor(z *zap.Logger, store ipStateStore, nnccli nodeNetworkConfigSpecUpdater, demandSource <-chan int, nncSource <-chan v1alpha.NodeNetworkConfig, cssSource <-chan v1alpha1.ClusterSubnetState) *Monitor { //nolint:lll // it's fine
+func NewMonitor(z *zap.Logger, store ipStateStore, nnccli nodeNetworkConfigSpecUpdater, demandSource <-chan int, nncSource <-chan v1alpha.NodeNetworkConfig, cssSource <-chan v1alpha2.ClusterSubnetState) *Monitor { //nolint:lll // it's fine
 	return &Monitor{
 		z:                     z.With(zap.String("component", "ipam-pool-monitor")),
 		store:                 store,
@@ -80,7 +83,8 @@ func (pm *Monitor) Start(ctx context.Context) error {
 			pm.z.Info("demand update", zap.Int64("demand", pm.demand))
 		case css := <-pm.cssSource: // received an updated ClusterSubnetState, recalculate request
 			pm.scaler.exhausted = css.Status.Exhausted
-			pm.z.Info("exhaustion update", zap.Bool("exhausted", pm.scaler.exhausted))
+			pm.scaler.scarcity = scarcity.Level(&css.Status)
+			pm.z.Info("exhaustion update", zap.Bool("exhausted", pm.scaler.exhausted), zap.Float64("utilization", css.Status.Utilization()), zap.Float64("scarcity", pm.scaler.scarcity))
 		case nnc := <-pm.nncSource: // received a new NodeNetworkConfig, extract the data from it and recalculate request
 			pm.scaler.max = int64(math.Min(float64(nnc.Status.Scaler.MaxIPCount), DefaultMaxIPs))
 			pm.scaler.batch = int64(math.Min(math.Max(float64(nnc.Status.Scaler.BatchSize), 1), float64(pm.scaler.max)))
@@ -117,6 +121,9 @@ func (pm *Monitor) reconcile(ctx context.Context) error {
 	if s.exhausted {
 		s.batch = 1
 		s.buffer = 1
+	} else if s.scarcity > 0 {
+		// scale the batch down progressively as the subnet fills up, the buffer being a fraction of it
+		s.batch = scarcity.Scale(s.batch, s.scarcity, 1)
 	}
 
 	// calculate the target state from the current pool state and scaler
@@ -212,6 +212,20 @@ func TestReconcile(t *testing.T) {
 			store:       ipStateStoreMock{},
 			wantRequest: 96,
 		},
+		{
+			name:    "scarce scale up",
+			demand:  75,
+			request: 16,
+			scaler: scaler{
+				batch:    16,
+				buffer:   .5,
+				max:      250,
+				scarcity: .5,
+			},
+			nnccli:      nncClientMock{},
+			store:       ipStateStoreMock{},
+			wantRequest: 80,
+		},
 		{
 			name:    "capped scale up",
 			demand:  300,
@@ -0,0 +1,375 @@
+package ipaudit
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	defaultInterval    = 5 * time.Minute
+	defaultGracePeriod = 10 * time.Minute
+)
+
+// PodLister returns the Pods scheduled on the Node which are not on the host network.
+type PodLister func(context.Context) ([]v1.Pod, error)
+
+// EndpointSource returns the Pods of the endpoints in the CNI state, by IP, with every endpoint holding an IP when
+// more than one does. It is called at every audit, so that the state is read fresh.
+type EndpointSource func() (map[string][]cns.PodInfo, error)
+
+type ipStore interface {
+	GetPodIPConfigState() map[string]cns.IPConfigurationStatus
+	ReleaseLeakedIPConfigs(cns.PodInfo) error
+}
+
+// Config configures the Auditor.
+type Config struct {
+	// Interval between audits, 5 minutes if 0.
+	Interval time.Duration
+	// GracePeriod for which a leaked IP must be found before it is released, and for which a Pod must exist before
+	// it is reported without an IP, 10 minutes if 0.
+	GracePeriod time.Duration
+	// ReleaseLeakedIPs is whether the Auditor releases the IPs it finds leaked for the GracePeriod.
+	ReleaseLeakedIPs bool
+}
+
+// finding is a discrepancy found by an audit, with the IP config and the PodInfo held by CNS for it of a leaked IP.
+type finding struct {
+	cns.IPAuditDiscrepancy
+	ipConfigID string
+	podInfo    cns.PodInfo
+}
+
+// Auditor periodically cross-checks the IPs assigned by CNS against the endpoints in the CNI state and the Pods in
+// the API server, which can diverge after crashes, and reports the discrepancies. It only changes the state of CNS
+// when releasing leaked IPs is enabled.
+type Auditor struct {
+	z         *zap.Logger
+	ips       ipStore
+	pods      PodLister
+	endpoints EndpointSource
+	cfg       Config
+
+	mu sync.Mutex
+	// discrepancies are those found by the last successful audit, by key.
+	discrepancies map[string]cns.IPAuditDiscrepancy
+	lastAudit     time.Time
+	// lastErr is why the last audit failed, nil if it succeeded.
+	lastErr error
+}
+
+// New creates an Auditor of the IPs of the ipStore.
+func New(z *zap.Logger, ips ipStore, pods PodLister, endpoints EndpointSource, cfg Config) *Auditor {
+	if cfg.Interval == 0 {
+		cfg.Interval = defaultInterval
+	}
+	if cfg.GracePeriod == 0 {
+		cfg.GracePeriod = defaultGracePeriod
+	}
+	return &Auditor{
+		z:             z,
+		ips:           ips,
+		pods:          pods,
+		endpoints:     endpoints,
+		cfg:           cfg,
+		discrepancies: map[string]cns.IPAuditDiscrepancy{},
+	}
+}
+
+// Start audits the IPs until the context is cancelled. It should only be started once the Pods of the PodLister
+// are synced, as every IP assigned to a Pod which is not listed is leaked.
+func (a *Auditor) Start(ctx context.Context) {
+	ticker := time.NewTicker(a.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		if err := a.Audit(ctx); err != nil {
+			a.z.Error("failed to audit IPs", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Audit cross-checks the IPs once, and releases the IPs leaked for the grace period if enabled.
+func (a *Auditor) Audit(ctx context.Context) error {
+	// the IPs of CNS are read first, so that a Pod assigned an IP is already listed
+	ipConfigs := a.ips.GetPodIPConfigState()
+	pods, err := a.pods(ctx)
+	if err != nil {
+		return a.fail(errors.Wrap(err, "failed to list pods"))
+	}
+	endpoints, err := a.endpoints()
+	if err != nil {
+		return a.fail(errors.Wrap(err, "failed to read endpoint state"))
+	}
+	now := time.Now()
+	findings := classify(ipConfigs, endpoints, pods, now.Add(-a.cfg.GracePeriod))
+
+	a.mu.Lock()
+	for i := range findings {
+		f := &findings[i]
+		if previous, ok := a.discrepancies[keyOf(&f.IPAuditDiscrepancy)]; ok {
+			f.FirstSeen = previous.FirstSeen
+		} else {
+			f.FirstSeen = now
+			a.z.Info("found IP discrepancy", zap.String("kind", string(f.Kind)), zap.String("ip", f.IPAddress),
+				zap.String("pod", f.PodNamespace+"/"+f.PodName), zap.String("detail", f.Detail))
+		}
+	}
+	a.mu.Unlock()
+
+	// releasing takes the lock of CNS, so it is done without holding ours for Discrepancies not to wait on it
+	if a.cfg.ReleaseLeakedIPs {
+		for i := range findings {
+			if f := &findings[i]; f.Kind == cns.IPAuditLeakedIP && now.Sub(f.FirstSeen) >= a.cfg.GracePeriod {
+				a.release(f)
+			}
+		}
+	}
+
+	discrepancies := make(map[string]cns.IPAuditDiscrepancy, len(findings))
+	counts := map[cns.IPAuditDiscrepancyKind]int{}
+	for i := range findings {
+		discrepancies[keyOf(&findings[i].IPAuditDiscrepancy)] = findings[i].IPAuditDiscrepancy
+		counts[findings[i].Kind]++
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.lastErr = nil
+	a.discrepancies, a.lastAudit = discrepancies, now
+	for _, kind := range []cns.IPAuditDiscrepancyKind{cns.IPAuditLeakedIP, cns.IPAuditOrphanEndpoint, cns.IPAuditPodWithoutIP, cns.IPAuditDoubleAssignment} {
+		discrepancyCount.WithLabelValues(string(kind)).Set(float64(counts[kind]))
+	}
+	return nil
+}
+
+// fail records the failure of an audit, which is reported instead of the discrepancies of the last successful
+// audit as those may be stale.
+func (a *Auditor) fail(err error) error {
+	auditFailures.Inc()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.lastErr = errors.Wrapf(err, "audit at %s failed", time.Now().UTC().Format(time.RFC3339))
+	return err
+}
+
+// release releases the leaked IP of the finding, and every other IP CNS holds for its Pod, unless the IP is no
+// longer assigned to the same Pod sandbox and interface as when it was audited, as when it was released and
+// assigned again meanwhile.
+func (a *Auditor) release(f *finding) {
+	current, ok := a.ips.GetPodIPConfigState()[f.ipConfigID]
+	if !ok || current.GetState() != types.Assigned || !samePodInfo(current.PodInfo, f.podInfo) {
+		a.z.Info("leaked IP is no longer assigned to its pod, not releasing it", zap.String("ip", f.IPAddress),
+			zap.String("pod", f.podInfo.Key()))
+		return
+	}
+	if err := a.ips.ReleaseLeakedIPConfigs(f.podInfo); err != nil {
+		// retried at the next audit
+		a.z.Error("failed to release leaked IP", zap.String("ip", f.IPAddress), zap.String("pod", f.podInfo.Key()), zap.Error(err))
+		return
+	}
+	f.Released = true
+	releasedIPs.Inc()
+	a.z.Info("released leaked IP", zap.String("ip", f.IPAddress), zap.String("pod", f.podInfo.Key()))
+}
+
+// samePodInfo returns whether the PodInfos are of the same Pod sandbox and interface.
+func samePodInfo(current, audited cns.PodInfo) bool {
+	return current != nil && current.Key() == audited.Key() &&
+		current.InfraContainerID() == audited.InfraContainerID() && current.InterfaceID() == audited.InterfaceID()
+}
+
+// Discrepancies returns the discrepancies found by the last audit, sorted by kind and IP, and when the last
+// successful audit was. If the last audit failed, its error is returned instead of any discrepancies.
+func (a *Auditor) Discrepancies() ([]cns.IPAuditDiscrepancy, time.Time, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.lastErr != nil {
+		return nil, a.lastAudit, a.lastErr
+	}
+	discrepancies := make([]cns.IPAuditDiscrepancy, 0, len(a.discrepancies))
+	for key := range a.discrepancies {
+		discrepancies = append(discrepancies, a.discrepancies[key])
+	}
+	sort.Slice(discrepancies, func(i, j int) bool {
+		return keyOf(&discrepancies[i]) < keyOf(&discrepancies[j])
+	})
+	return discrepancies, a.lastAudit, nil
+}
+
+// keyOf identifies a discrepancy across audits.
+func keyOf(d *cns.IPAuditDiscrepancy) string {
+	return strings.Join([]string{string(d.Kind), d.IPAddress, d.PodNamespace, d.PodName}, "/")
+}
+
+// podRef is the namespace and name of a Pod.
+type podRef struct {
+	namespace, name string
+}
+
+func (p podRef) String() string {
+	return p.namespace + "/" + p.name
+}
+
+// endpointRef returns the Pod of the endpoint, and false if the CNI state does not know it, as for endpoints
+// added before the Pod name was recorded.
+func endpointRef(endpoint cns.PodInfo) (podRef, bool) {
+	ref := podRef{endpoint.Namespace(), endpoint.Name()}
+	return ref, ref.namespace != "" || ref.name != ""
+}
+
+// assignment is an IP config assigned to a Pod by CNS.
+type assignment struct {
+	ipConfigID string
+	pod        podRef
+	podInfo    cns.PodInfo
+}
+
+// classify returns the discrepancies between the IP configs of CNS, the endpoints of the CNI state and the Pods of
+// the API server. Pods created after createdBefore are not reported without an IP, as CNI may not have set them up
+// yet.
+func classify(ipConfigs map[string]cns.IPConfigurationStatus, endpoints map[string][]cns.PodInfo, pods []v1.Pod, createdBefore time.Time) []finding {
+	assigned := map[string][]assignment{}
+	assignedPods := map[podRef]struct{}{}
+	for id := range ipConfigs {
+		ipConfig := ipConfigs[id]
+		if ipConfig.GetState() != types.Assigned || ipConfig.PodInfo == nil {
+			continue
+		}
+		ref := podRef{ipConfig.PodInfo.Namespace(), ipConfig.PodInfo.Name()}
+		assigned[ipConfig.IPAddress] = append(assigned[ipConfig.IPAddress], assignment{ipConfigID: id, pod: ref, podInfo: ipConfig.PodInfo})
+		assignedPods[ref] = struct{}{}
+	}
+
+	// Pods which have finished have had their sandbox torn down, and hold no IP
+	live := map[podRef]*v1.Pod{}
+	podsByIP := map[string][]podRef{}
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Spec.HostNetwork || pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+		ref := podRef{pod.Namespace, pod.Name}
+		live[ref] = pod
+		for _, ip := range pod.Status.PodIPs {
+			podsByIP[ip.IP] = append(podsByIP[ip.IP], ref)
+		}
+	}
+
+	var findings []finding
+	for ip, assignments := range assigned {
+		owner := assignments[0]
+		// every Pod the IP is assigned to by any source, the owner in CNS first
+		claimants := []podRef{owner.pod}
+		for _, a := range assignments[1:] {
+			claimants = appendRef(claimants, a.pod)
+		}
+		claimants = appendEndpointRefs(claimants, endpoints[ip])
+		for _, ref := range podsByIP[ip] {
+			claimants = appendRef(claimants, ref)
+		}
+		if len(claimants) > 1 {
+			findings = append(findings, doubleAssignment(ip, claimants))
+			continue
+		}
+		if _, ok := live[owner.pod]; !ok {
+			f := newFinding(cns.IPAuditLeakedIP, ip, owner.pod, "pod is not in the API server")
+			if _, ok := endpoints[ip]; ok {
+				f.Detail += ", endpoint is in the CNI state"
+			}
+			f.ipConfigID, f.podInfo = owner.ipConfigID, owner.podInfo
+			findings = append(findings, f)
+		}
+	}
+
+	// IPs which CNS does not assign, but which more than one endpoint or Pod holds, or an endpoint alone holds
+	for ip, eps := range endpoints {
+		if _, ok := assigned[ip]; ok {
+			continue
+		}
+		claimants := appendEndpointRefs(nil, eps)
+		if len(claimants) == 0 {
+			continue
+		}
+		for _, ref := range podsByIP[ip] {
+			claimants = appendRef(claimants, ref)
+		}
+		if len(claimants) > 1 {
+			findings = append(findings, doubleAssignment(ip, claimants))
+			continue
+		}
+		ref := claimants[0]
+		detail := "IP is not assigned by CNS"
+		if _, ok := live[ref]; !ok {
+			detail += ", pod is not in the API server"
+		}
+		findings = append(findings, newFinding(cns.IPAuditOrphanEndpoint, ip, ref, detail))
+	}
+
+	for ref, pod := range live {
+		if _, ok := assignedPods[ref]; ok || !pod.CreationTimestamp.Time.Before(createdBefore) {
+			continue
+		}
+		findings = append(findings, newFinding(cns.IPAuditPodWithoutIP, "", ref, "pod phase is "+string(pod.Status.Phase)))
+	}
+
+	// IPs which neither CNS nor an endpoint holds, but which the API server reports for more than one Pod
+	for ip, refs := range podsByIP {
+		if _, ok := assigned[ip]; ok || len(refs) < 2 || len(appendEndpointRefs(nil, endpoints[ip])) > 0 {
+			continue
+		}
+		findings = append(findings, doubleAssignment(ip, refs))
+	}
+	return findings
+}
+
+// doubleAssignment returns the finding of an IP held by every claimant, reported for the first.
+func doubleAssignment(ip string, claimants []podRef) finding {
+	others := make([]string, 0, len(claimants)-1)
+	for _, ref := range claimants[1:] {
+		others = append(others, ref.String())
+	}
+	return newFinding(cns.IPAuditDoubleAssignment, ip, claimants[0], "also assigned to "+strings.Join(others, ", "))
+}
+
+// appendEndpointRefs appends the Pods of the endpoints the CNI state knows to refs.
+func appendEndpointRefs(refs []podRef, endpoints []cns.PodInfo) []podRef {
+	for _, endpoint := range endpoints {
+		if ref, known := endpointRef(endpoint); known {
+			refs = appendRef(refs, ref)
+		}
+	}
+	return refs
+}
+
+func newFinding(kind cns.IPAuditDiscrepancyKind, ip string, pod podRef, detail string) finding {
+	return finding{IPAuditDiscrepancy: cns.IPAuditDiscrepancy{
+		Kind:         kind,
+		IPAddress:    ip,
+		PodName:      pod.name,
+		PodNamespace: pod.namespace,
+		Detail:       detail,
+	}}
+}
+
+// appendRef appends the Pod to refs unless it is already in it.
+func appendRef(refs []podRef, ref podRef) []podRef {
+	for _, r := range refs {
+		if r == ref {
+			return refs
+		}
+	}
+	return append(refs, ref)
+}
@@ -0,0 +1,208 @@
+package ipaudit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeIPStore struct {
+	ipConfigs map[string]cns.IPConfigurationStatus
+	released  []string
+}
+
+func (f *fakeIPStore) GetPodIPConfigState() map[string]cns.IPConfigurationStatus {
+	ipConfigs := make(map[string]cns.IPConfigurationStatus, len(f.ipConfigs))
+	for id, ipConfig := range f.ipConfigs {
+		ipConfigs[id] = ipConfig
+	}
+	return ipConfigs
+}
+
+func (f *fakeIPStore) ReleaseLeakedIPConfigs(podInfo cns.PodInfo) error {
+	f.released = append(f.released, podInfo.Key())
+	for id, ipConfig := range f.ipConfigs {
+		if ipConfig.PodInfo != nil && ipConfig.PodInfo.Key() == podInfo.Key() {
+			ipConfig.PodInfo = nil
+			ipConfig.SetState(types.Available)
+			f.ipConfigs[id] = ipConfig
+		}
+	}
+	return nil
+}
+
+func assignedIPConfig(ip, name string) cns.IPConfigurationStatus {
+	ipConfig := cns.IPConfigurationStatus{
+		IPAddress: ip,
+		PodInfo:   cns.NewPodInfo(name+"-infra", name+"-eth0", name, "default"),
+	}
+	ipConfig.SetState(types.Assigned)
+	return ipConfig
+}
+
+func pod(name, ip string, created time.Time) v1.Pod {
+	p := v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", CreationTimestamp: metav1.NewTime(created)},
+		Status:     v1.PodStatus{Phase: v1.PodRunning},
+	}
+	if ip != "" {
+		p.Status.PodIPs = []v1.PodIP{{IP: ip}}
+	}
+	return p
+}
+
+func TestAuditor(t *testing.T) {
+	old := time.Now().Add(-time.Hour)
+	ips := &fakeIPStore{ipConfigs: map[string]cns.IPConfigurationStatus{
+		"1": assignedIPConfig("10.0.0.1", "running"),
+		"2": assignedIPConfig("10.0.0.2", "gone"),
+		"3": assignedIPConfig("10.0.0.3", "shared"),
+		"4": assignedIPConfig("10.0.0.4", "completed"),
+		"5": assignedIPConfig("10.0.0.5", "nameless"),
+	}}
+	completed := pod("completed", "10.0.0.4", old)
+	completed.Status.Phase = v1.PodSucceeded
+	pods := []v1.Pod{
+		pod("running", "10.0.0.1", old),
+		pod("shared", "10.0.0.3", old),
+		pod("other", "10.0.0.3", old),
+		pod("pending", "", old),
+		pod("new", "", time.Now()),
+		pod("nameless", "10.0.0.5", old),
+		completed,
+	}
+	endpoints := map[string][]cns.PodInfo{
+		"10.0.0.1": {cns.NewPodInfo("", "", "running", "default")},
+		"10.0.0.2": {cns.NewPodInfo("", "", "gone", "default")},
+		"10.0.0.9": {cns.NewPodInfo("", "", "stale", "default")},
+		// an IP which CNS does not assign held by two endpoints
+		"10.0.0.7": {cns.NewPodInfo("", "", "dup-a", "default"), cns.NewPodInfo("", "", "dup-b", "default")},
+		// endpoints whose Pod the CNI state does not know are neither claimants nor orphans
+		"10.0.0.5": {cns.NewPodInfo("nameless-infra", "", "", "")},
+		"10.0.0.8": {cns.NewPodInfo("unknown-infra", "", "", "")},
+	}
+	a := New(zap.NewNop(), ips, func(context.Context) ([]v1.Pod, error) { return pods, nil },
+		func() (map[string][]cns.PodInfo, error) { return endpoints, nil },
+		Config{GracePeriod: time.Minute, ReleaseLeakedIPs: true})
+
+	require.NoError(t, a.Audit(context.Background()))
+	discrepancies, lastAudit, err := a.Discrepancies()
+	require.NoError(t, err)
+	assert.False(t, lastAudit.IsZero())
+	got := map[string]cns.IPAuditDiscrepancyKind{}
+	for _, d := range discrepancies {
+		got[d.PodName] = d.Kind
+		assert.False(t, d.Released)
+	}
+	assert.Equal(t, map[string]cns.IPAuditDiscrepancyKind{
+		"gone":      cns.IPAuditLeakedIP,
+		"completed": cns.IPAuditLeakedIP,
+		"shared":    cns.IPAuditDoubleAssignment,
+		"stale":     cns.IPAuditOrphanEndpoint,
+		"dup-a":     cns.IPAuditDoubleAssignment,
+		"pending":   cns.IPAuditPodWithoutIP,
+		"other":     cns.IPAuditPodWithoutIP, // reports the IP of shared, which CNS did not assign to it
+	}, got)
+	assert.InDelta(t, 2, testutil.ToFloat64(discrepancyCount.WithLabelValues(string(cns.IPAuditLeakedIP))), 0)
+	assert.Empty(t, ips.released)
+
+	// the leaked IPs are only released once found leaked for the grace period
+	for key, d := range a.discrepancies {
+		d.FirstSeen = d.FirstSeen.Add(-time.Minute)
+		a.discrepancies[key] = d
+	}
+	released := testutil.ToFloat64(releasedIPs)
+	require.NoError(t, a.Audit(context.Background()))
+	assert.ElementsMatch(t, []string{"gone-eth0", "completed-eth0"}, ips.released)
+	assert.InDelta(t, released+2, testutil.ToFloat64(releasedIPs), 0)
+	discrepancies, _, err = a.Discrepancies()
+	require.NoError(t, err)
+	for _, d := range discrepancies {
+		assert.Equal(t, d.Kind == cns.IPAuditLeakedIP, d.Released)
+	}
+
+	// released IPs are no longer leaked, but the endpoint of the Pod which is gone is now an orphan
+	require.NoError(t, a.Audit(context.Background()))
+	discrepancies, _, err = a.Discrepancies()
+	require.NoError(t, err)
+	got = map[string]cns.IPAuditDiscrepancyKind{}
+	for _, d := range discrepancies {
+		got[d.PodName] = d.Kind
+	}
+	assert.Equal(t, cns.IPAuditOrphanEndpoint, got["gone"])
+	assert.NotContains(t, got, "completed")
+}
+
+func TestAuditorFailure(t *testing.T) {
+	ips := &fakeIPStore{ipConfigs: map[string]cns.IPConfigurationStatus{
+		"1": assignedIPConfig("10.0.0.1", "gone"),
+	}}
+	var endpointErr error
+	a := New(zap.NewNop(), ips, func(context.Context) ([]v1.Pod, error) { return nil, nil },
+		func() (map[string][]cns.PodInfo, error) { return map[string][]cns.PodInfo{}, endpointErr },
+		Config{GracePeriod: time.Minute})
+
+	require.NoError(t, a.Audit(context.Background()))
+	discrepancies, lastAudit, err := a.Discrepancies()
+	require.NoError(t, err)
+	require.Len(t, discrepancies, 1)
+
+	// a failed audit is reported with when the last successful one was, rather than its stale discrepancies
+	endpointErr = errors.New("state is corrupt")
+	failures := testutil.ToFloat64(auditFailures)
+	require.Error(t, a.Audit(context.Background()))
+	assert.InDelta(t, failures+1, testutil.ToFloat64(auditFailures), 0)
+	discrepancies, failedLastAudit, err := a.Discrepancies()
+	require.ErrorContains(t, err, "state is corrupt")
+	assert.Empty(t, discrepancies)
+	assert.Equal(t, lastAudit, failedLastAudit)
+
+	endpointErr = nil
+	require.NoError(t, a.Audit(context.Background()))
+	discrepancies, _, err = a.Discrepancies()
+	require.NoError(t, err)
+	assert.Len(t, discrepancies, 1)
+}
+
+func TestAuditorReassignedIP(t *testing.T) {
+	ips := &fakeIPStore{ipConfigs: map[string]cns.IPConfigurationStatus{
+		"1": assignedIPConfig("10.0.0.1", "gone"),
+	}}
+	var reassign bool
+	a := New(zap.NewNop(), ips, func(context.Context) ([]v1.Pod, error) {
+		if reassign {
+			// the IP is released and assigned to a new sandbox of the Pod after CNS is read
+			ipConfig := ips.ipConfigs["1"]
+			ipConfig.PodInfo = cns.NewPodInfo("gone-infra-2", "gone-eth0", "gone", "default")
+			ips.ipConfigs["1"] = ipConfig
+		}
+		return nil, nil
+	}, func() (map[string][]cns.PodInfo, error) { return map[string][]cns.PodInfo{}, nil },
+		Config{GracePeriod: time.Minute, ReleaseLeakedIPs: true})
+
+	require.NoError(t, a.Audit(context.Background()))
+	for key, d := range a.discrepancies {
+		d.FirstSeen = d.FirstSeen.Add(-time.Minute)
+		a.discrepancies[key] = d
+	}
+
+	reassign = true
+	require.NoError(t, a.Audit(context.Background()))
+	assert.Empty(t, ips.released)
+	ipConfig := ips.ipConfigs["1"]
+	assert.Equal(t, types.Assigned, ipConfig.GetState())
+	discrepancies, _, err := a.Discrepancies()
+	require.NoError(t, err)
+	require.Len(t, discrepancies, 1)
+	assert.False(t, discrepancies[0].Released)
+}
@@ -0,0 +1,36 @@
+package ipaudit
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	discrepancyCount = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "cns_ip_audit_discrepancies",
+			Help: "Discrepancies between the IPs assigned by CNS, the CNI state and the API server found by the last IP audit, by kind.",
+		},
+		[]string{"kind"},
+	)
+	releasedIPs = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "cns_ip_audit_released_ips_total",
+			Help: "Leaked IPs released by the IP audit.",
+		},
+	)
+	auditFailures = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "cns_ip_audit_failures_total",
+			Help: "IP audits which failed to read the Pods or the CNI state.",
+		},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		discrepancyCount,
+		releasedIPs,
+		auditFailures,
+	)
+}
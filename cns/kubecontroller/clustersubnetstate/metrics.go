@@ -1,6 +1,8 @@
 package clustersubnetstate
 
 import (
+	"github.com/Azure/azure-container-networking/cns/ipampool/scarcity"
+	"github.com/Azure/azure-container-networking/crd/clustersubnetstate/api/v1alpha2"
 	"github.com/prometheus/client_golang/prometheus"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
 )
@@ -8,6 +10,7 @@ import (
 // Constants to describe the error state boolean values for the cluster subnet state
 const (
 	cssReconcilerCRDWatcherStateLabel = "css_reconciler_crd_watcher_status"
+	cssSubnetLabel                    = "subnet"
 )
 
 var cssReconcilerErrorCount = prometheus.NewCounterVec(
@@ -18,8 +21,69 @@ var cssReconcilerErrorCount = prometheus.NewCounterVec(
 	[]string{cssReconcilerCRDWatcherStateLabel},
 )
 
+var (
+	cssSubnetTotalIPs = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "cluster_subnet_state_total_ips",
+			Help: "Number of IPs in the subnet",
+		},
+		[]string{cssSubnetLabel},
+	)
+	cssSubnetAllocatedIPs = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "cluster_subnet_state_allocated_ips",
+			Help: "Number of IPs in the subnet allocated to Nodes",
+		},
+		[]string{cssSubnetLabel},
+	)
+	cssSubnetReservedIPs = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "cluster_subnet_state_reserved_ips",
+			Help: "Number of IPs in the subnet which can not be allocated",
+		},
+		[]string{cssSubnetLabel},
+	)
+	cssSubnetUtilization = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "cluster_subnet_state_utilization_ratio",
+			Help: "Fraction of the IPs in the subnet which are allocated or reserved",
+		},
+		[]string{cssSubnetLabel},
+	)
+	cssSubnetAllocationRate = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "cluster_subnet_state_allocation_rate_per_hour",
+			Help: "Recent change of the IPs in the subnet allocated to Nodes per hour",
+		},
+		[]string{cssSubnetLabel},
+	)
+	cssSubnetScarcity = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "cluster_subnet_state_scarcity_level",
+			Help: "How much the IP pool scaling is scaled down for the subnet, from 0 to 1 when it is exhausted",
+		},
+		[]string{cssSubnetLabel},
+	)
+)
+
 func init() {
 	metrics.Registry.MustRegister(
 		cssReconcilerErrorCount,
+		cssSubnetTotalIPs,
+		cssSubnetAllocatedIPs,
+		cssSubnetReservedIPs,
+		cssSubnetUtilization,
+		cssSubnetAllocationRate,
+		cssSubnetScarcity,
 	)
 }
+
+func observeSubnetUsage(css *v1alpha2.ClusterSubnetState) {
+	labels := prometheus.Labels{cssSubnetLabel: css.Name}
+	cssSubnetTotalIPs.With(labels).Set(float64(css.Status.Total))
+	cssSubnetAllocatedIPs.With(labels).Set(float64(css.Status.Allocated))
+	cssSubnetReservedIPs.With(labels).Set(float64(css.Status.Reserved))
+	cssSubnetUtilization.With(labels).Set(css.Status.Utilization())
+	cssSubnetAllocationRate.With(labels).Set(float64(css.Status.AllocationRatePerHour))
+	cssSubnetScarcity.With(labels).Set(scarcity.Level(&css.Status))
+}
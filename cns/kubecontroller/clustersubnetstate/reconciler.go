@@ -5,25 +5,31 @@ import (
 
 	"github.com/Azure/azure-container-networking/crd/clustersubnetstate"
 	"github.com/Azure/azure-container-networking/crd/clustersubnetstate/api/v1alpha1"
+	"github.com/Azure/azure-container-networking/crd/clustersubnetstate/api/v1alpha2"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 type cssClient interface {
-	Get(context.Context, types.NamespacedName) (*v1alpha1.ClusterSubnetState, error)
+	Get(context.Context, types.NamespacedName) (*v1alpha2.ClusterSubnetState, error)
 }
 
 type Reconciler struct {
-	cli  cssClient
-	sink chan<- v1alpha1.ClusterSubnetState
+	cli    cssClient
+	sink   chan<- v1alpha2.ClusterSubnetState
+	legacy bool
 }
 
-func New(sink chan<- v1alpha1.ClusterSubnetState) *Reconciler {
+// New creates a Reconciler which sends the ClusterSubnetStates to the sink. If legacy is set, the v1alpha1 version
+// is watched and converted to v1alpha2, as the cluster does not serve v1alpha2 yet.
+func New(sink chan<- v1alpha2.ClusterSubnetState, legacy bool) *Reconciler {
 	return &Reconciler{
-		sink: sink,
+		sink:   sink,
+		legacy: legacy,
 	}
 }
 
@@ -34,14 +40,20 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 		return reconcile.Result{}, errors.Wrapf(err, "failed to get css %s", req.String())
 	}
 	cssReconcilerErrorCount.With(prometheus.Labels{cssReconcilerCRDWatcherStateLabel: "succeeded"}).Inc()
+	observeSubnetUsage(css)
 	r.sink <- *css
 	return reconcile.Result{}, nil
 }
 
 func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	var obj client.Object = &v1alpha2.ClusterSubnetState{}
 	r.cli = clustersubnetstate.NewClient(mgr.GetClient())
+	if r.legacy {
+		obj = &v1alpha1.ClusterSubnetState{}
+		r.cli = clustersubnetstate.NewLegacyClient(mgr.GetClient())
+	}
 	err := ctrl.NewControllerManagedBy(mgr).
-		For(&v1alpha1.ClusterSubnetState{}).
+		For(obj).
 		Complete(r)
 	return errors.Wrap(err, "failed to setup clustersubnetstate reconciler with manager")
 }
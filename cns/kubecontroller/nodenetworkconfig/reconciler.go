@@ -2,19 +2,22 @@ package nodenetworkconfig
 
 import (
 	"context"
+	"fmt"
+	"slices"
 	"sync"
 
 	"github.com/Azure/azure-container-networking/cns"
-	"github.com/Azure/azure-container-networking/cns/logger"
 	"github.com/Azure/azure-container-networking/cns/restserver"
 	cnstypes "github.com/Azure/azure-container-networking/cns/types"
 	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig"
 	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
 	"github.com/pkg/errors"
+	"go.uber.org/zap"
 	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
@@ -31,6 +34,10 @@ type nodeNetworkConfigListener interface {
 	Update(*v1alpha.NodeNetworkConfig) error
 }
 
+// failureEventThreshold is how many times in a row a NetworkContainer fails to be programmed before each
+// failure is also reported as an Event.
+const failureEventThreshold = 3
+
 type nncGetter interface {
 	Get(context.Context, types.NamespacedName) (*v1alpha.NodeNetworkConfig, error)
 }
@@ -43,18 +50,27 @@ type Reconciler struct {
 	once               sync.Once
 	started            chan interface{}
 	nodeIP             string
+	z                  *zap.Logger
+	// status and recorder report the results of programming the NCs on the NNC, and are set by SetupWithManager.
+	status   *statusWriter
+	recorder record.EventRecorder
+	// failures counts the consecutive failures to program each NC.
+	failures  map[string]int
+	exhausted bool
 }
 
 // NewReconciler creates a NodeNetworkConfig Reconciler which will get updates from the Kubernetes
 // apiserver for NNC events.
 // Provided nncListeners are passed the NNC after the Reconcile preprocesses it. Note: order matters! The
 // passed Listeners are notified in the order provided.
-func NewReconciler(cnscli cnsClient, ipampoolmonitorcli nodeNetworkConfigListener, nodeIP string) *Reconciler {
+func NewReconciler(z *zap.Logger, cnscli cnsClient, ipampoolmonitorcli nodeNetworkConfigListener, nodeIP string) *Reconciler {
 	return &Reconciler{
 		cnscli:             cnscli,
 		ipampoolmonitorcli: ipampoolmonitorcli,
 		started:            make(chan interface{}),
 		nodeIP:             nodeIP,
+		z:                  z,
+		failures:           map[string]int{},
 	}
 }
 
@@ -65,16 +81,18 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 	if err != nil {
 		if apierrors.IsNotFound(err) {
 			hasNNC.Set(0)
-			logger.Printf("[cns-rc] CRD not found, ignoring %v", err)
+			r.z.Info("NodeNetworkConfig not found, ignoring", zap.Error(err))
 			return reconcile.Result{}, errors.Wrapf(client.IgnoreNotFound(err), "NodeNetworkConfig %v not found", req.NamespacedName)
 		}
-		logger.Errorf("[cns-rc] Error retrieving CRD from cache : %v", err)
+		r.z.Error("failed to get NodeNetworkConfig from cache", zap.Error(err))
 		return reconcile.Result{}, errors.Wrapf(err, "failed to get NodeNetworkConfig %v", req.NamespacedName)
 	}
 	hasNNC.Set(1)
-	logger.Printf("[cns-rc] CRD Spec: %+v", nnc.Spec)
+	r.z.Info("reconciling NodeNetworkConfig", zap.Any("spec", nnc.Spec))
+	r.z.Debug("NodeNetworkConfig status", zap.Any("status", nnc.Status))
 
 	ipAssignments := 0
+	attempted := map[string]v1alpha.NetworkContainerStatus{}
 
 	// during node upgrades, an nnc may be updated with new ncs. at any given time, only the ncs
 	// that exist in the nnc are valid. any others that may have been previously created and no
@@ -86,6 +104,11 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 		validNCIDs[i] = nnc.Status.NetworkContainers[i].ID
 	}
 	r.cnscli.MustEnsureNoStaleNCs(validNCIDs)
+	for id := range r.failures {
+		if !slices.Contains(validNCIDs, id) {
+			delete(r.failures, id)
+		}
+	}
 
 	// for each NC, parse it in to a CreateNCRequest and forward it to the appropriate Listener
 	for i := range nnc.Status.NetworkContainers {
@@ -93,8 +116,8 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 		if r.nodeIP != "" {
 			if r.nodeIP != nnc.Status.NetworkContainers[i].NodeIP {
 				// skip this NC since it was created for a different node
-				logger.Printf("[cns-rc] skipping network container %s found in NNC because node IP doesn't match, got %s, expected %s",
-					nnc.Status.NetworkContainers[i].ID, nnc.Status.NetworkContainers[i].NodeIP, r.nodeIP)
+				r.z.Info("skipping network container for a different node IP", zap.String("nc", nnc.Status.NetworkContainers[i].ID),
+					zap.String("nodeIP", nnc.Status.NetworkContainers[i].NodeIP), zap.String("expected", r.nodeIP))
 				continue
 			}
 		}
@@ -113,23 +136,39 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 		}
 
 		if err != nil {
-			logger.Errorf("[cns-rc] failed to generate CreateNCRequest from NC: %v, assignmentMode %s", err,
-				nnc.Status.NetworkContainers[i].AssignmentMode)
+			r.z.Error("failed to generate CreateNCRequest from NC", zap.Error(err),
+				zap.String("assignmentMode", string(nnc.Status.NetworkContainers[i].AssignmentMode)))
+			attempted[nnc.Status.NetworkContainers[i].ID] = r.failed(nnc, &nnc.Status.NetworkContainers[i], cnstypes.InvalidRequest, err)
+			r.writeStatus(ctx, nnc, attempted)
 			return reconcile.Result{}, errors.Wrapf(err, "failed to generate CreateNCRequest from NC "+
 				"assignmentMode %s", nnc.Status.NetworkContainers[i].AssignmentMode)
 		}
 
 		responseCode := r.cnscli.CreateOrUpdateNetworkContainerInternal(req)
 		if err := restserver.ResponseCodeToError(responseCode); err != nil {
-			logger.Errorf("[cns-rc] Error creating or updating NC in reconcile: %v", err)
+			r.z.Error("failed to create or update network container", zap.Error(err), zap.String("nc", req.NetworkContainerid))
+			attempted[req.NetworkContainerid] = r.failed(nnc, &nnc.Status.NetworkContainers[i], responseCode, err)
+			r.writeStatus(ctx, nnc, attempted)
 			return reconcile.Result{}, errors.Wrap(err, "failed to create or update network container")
 		}
+		delete(r.failures, req.NetworkContainerid)
+		attempted[req.NetworkContainerid] = v1alpha.NetworkContainerStatus{
+			AppliedVersion: nnc.Status.NetworkContainers[i].Version,
+			ResponseCode:   int(responseCode),
+			Reason:         responseCode.String(),
+		}
 		ipAssignments += len(req.SecondaryIPConfigs)
 	}
 
 	// record assigned IPs metric
 	allocatedIPs.Set(float64(ipAssignments))
 
+	var conditions []metav1.Condition
+	if c, ok := r.checkExhausted(nnc, ipAssignments); ok {
+		conditions = append(conditions, c)
+	}
+	r.writeStatus(ctx, nnc, attempted, conditions...)
+
 	// push the NNC to the registered NNC listeners.
 	for _, l := range listenersToNotify {
 		if err := l.Update(nnc); err != nil {
@@ -140,11 +179,66 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 	// we have received and pushed an NNC update, we are "Started"
 	r.once.Do(func() {
 		close(r.started)
-		logger.Printf("[cns-rc] CNS NNC Reconciler Started")
+		r.z.Info("NodeNetworkConfig reconciler started")
 	})
 	return reconcile.Result{}, nil
 }
 
+// failed counts the failure to program the NC, reporting it as an Event once it has failed failureEventThreshold
+// times in a row, and returns its result.
+func (r *Reconciler) failed(nnc *v1alpha.NodeNetworkConfig, nc *v1alpha.NetworkContainer, code cnstypes.ResponseCode, err error) v1alpha.NetworkContainerStatus {
+	r.failures[nc.ID]++
+	if count := r.failures[nc.ID]; count >= failureEventThreshold && r.recorder != nil {
+		r.recorder.Eventf(nnc, v1.EventTypeWarning, "NetworkContainerProgrammingFailed",
+			"failed to program network container %s at version %d %d times in a row: %v", nc.ID, nc.Version, count, err)
+	}
+	return v1alpha.NetworkContainerStatus{
+		ResponseCode: int(code),
+		Reason:       code.String(),
+		Message:      err.Error(),
+	}
+}
+
+// checkExhausted returns the IPPoolExhausted Condition of the NNC, which is only known when the pool has a
+// MaxIPCount, and reports an Event when the pool becomes exhausted.
+func (r *Reconciler) checkExhausted(nnc *v1alpha.NodeNetworkConfig, ipAssignments int) (metav1.Condition, bool) {
+	maxIPCount := nnc.Status.Scaler.MaxIPCount
+	if maxIPCount <= 0 {
+		return metav1.Condition{}, false
+	}
+	exhausted := nnc.Spec.RequestedIPCount >= maxIPCount && int64(ipAssignments) >= maxIPCount
+	if exhausted && !r.exhausted && r.recorder != nil {
+		r.recorder.Eventf(nnc, v1.EventTypeWarning, v1alpha.IPPoolExhausted,
+			"IP pool has reached the max IP count of %d and can not grow", maxIPCount)
+	}
+	r.exhausted = exhausted
+	if exhausted {
+		return metav1.Condition{
+			Type:    v1alpha.IPPoolExhausted,
+			Status:  metav1.ConditionTrue,
+			Reason:  "MaxIPCountReached",
+			Message: fmt.Sprintf("%d of %d IPs allocated", ipAssignments, maxIPCount),
+		}, true
+	}
+	return metav1.Condition{
+		Type:    v1alpha.IPPoolExhausted,
+		Status:  metav1.ConditionFalse,
+		Reason:  "BelowMaxIPCount",
+		Message: fmt.Sprintf("%d of %d IPs allocated", ipAssignments, maxIPCount),
+	}, true
+}
+
+// writeStatus reports the results of programming the NCs on the NNC. Failing to report them does not fail the
+// reconcile, as the NCs are programmed regardless.
+func (r *Reconciler) writeStatus(ctx context.Context, nnc *v1alpha.NodeNetworkConfig, attempted map[string]v1alpha.NetworkContainerStatus, conditions ...metav1.Condition) {
+	if r.status == nil {
+		return
+	}
+	if err := r.status.write(ctx, nnc, attempted, conditions...); err != nil {
+		r.z.Error("failed to write NodeNetworkConfig status", zap.Error(err))
+	}
+}
+
 // Started blocks until the Reconciler has reconciled at least once,
 // then, and any time that it is called after that, it immediately returns true.
 // It accepts a cancellable Context and if the context is closed
@@ -163,7 +257,10 @@ func (r *Reconciler) Started(ctx context.Context) (bool, error) {
 // filterGenerationChange will check the old and new object's generation and only reconcile updates where the
 // generation is the same. This is typically used in IPAMv1 but should be set to false in IPAMv2.
 func (r *Reconciler) SetupWithManager(mgr ctrl.Manager, node *v1.Node, filterGenerationChange bool) error {
-	r.nnccli = nodenetworkconfig.NewClient(mgr.GetClient())
+	nnccli := nodenetworkconfig.NewClient(mgr.GetClient())
+	r.nnccli = nnccli
+	r.status = newStatusWriter(NewScopedClient(nnccli, types.NamespacedName{Namespace: "kube-system", Name: node.Name}))
+	r.recorder = mgr.GetEventRecorderFor("azure-cns")
 	err := ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha.NodeNetworkConfig{}).
 		WithEventFilter(predicate.Funcs{
@@ -175,6 +272,12 @@ func (r *Reconciler) SetupWithManager(mgr ctrl.Manager, node *v1.Node, filterGen
 				if ue.ObjectOld == nil || ue.ObjectNew == nil {
 					return false
 				}
+				// ignore the updates made by writing the status of the NCs.
+				oldNNC, okOld := ue.ObjectOld.(*v1alpha.NodeNetworkConfig)
+				newNNC, okNew := ue.ObjectNew.(*v1alpha.NodeNetworkConfig)
+				if okOld && okNew && onlyOwnStatusChanged(oldNNC, newNNC) {
+					return false
+				}
 				if filterGenerationChange {
 					return ue.ObjectOld.GetGeneration() == ue.ObjectNew.GetGeneration()
 				}
@@ -11,9 +11,12 @@ import (
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
@@ -192,7 +195,7 @@ func TestReconcile(t *testing.T) {
 		}
 
 		t.Run(tt.name, func(t *testing.T) {
-			r := NewReconciler(&tt.cnsClient, &tt.cnsClient, tt.nodeIP)
+			r := NewReconciler(zap.NewNop(), &tt.cnsClient, &tt.cnsClient, tt.nodeIP)
 			r.nnccli = &tt.ncGetter
 			got, err := r.Reconcile(context.Background(), tt.in)
 			if tt.wantErr {
@@ -249,7 +252,7 @@ func TestReconcileStaleNCs(t *testing.T) {
 		return &nncLog[len(nncLog)-1], nil
 	}
 
-	r := NewReconciler(&cnsClient, &cnsClient, nodeIP)
+	r := NewReconciler(zap.NewNop(), &cnsClient, &cnsClient, nodeIP)
 	r.nnccli = &mockNCGetter{get: nncIterator}
 
 	_, err := r.Reconcile(context.Background(), reconcile.Request{})
@@ -266,3 +269,62 @@ func TestReconcileStaleNCs(t *testing.T) {
 	assert.Contains(t, cnsClient.state.reqsByNCID, "nc3")
 	assert.Contains(t, cnsClient.state.reqsByNCID, "nc4")
 }
+
+func TestReconcileReportsStatusAndEvents(t *testing.T) {
+	logger.InitLogger("", 0, 0, "")
+
+	code := cnstypes.UnexpectedError
+	cnsClient := mockCNSClient{
+		state:            cnsClientState{reqsByNCID: make(map[string]*cns.CreateNetworkContainerRequest)},
+		createOrUpdateNC: func(*cns.CreateNetworkContainerRequest) cnstypes.ResponseCode { return code },
+		update:           func(*v1alpha.NodeNetworkConfig) error { return nil },
+	}
+	nnc := &v1alpha.NodeNetworkConfig{
+		Spec:   v1alpha.NodeNetworkConfigSpec{RequestedIPCount: 1},
+		Status: *validSwiftStatus.DeepCopy(),
+	}
+	nnc.Status.Scaler.MaxIPCount = 1
+	patcher := &mockStatusPatcher{}
+	recorder := record.NewFakeRecorder(10)
+
+	r := NewReconciler(zap.NewNop(), &cnsClient, &cnsClient, "")
+	r.nnccli = &mockNCGetter{get: func(context.Context, types.NamespacedName) (*v1alpha.NodeNetworkConfig, error) {
+		if len(patcher.patched) > 0 {
+			return patcher.patched[len(patcher.patched)-1], nil
+		}
+		return nnc, nil
+	}}
+	r.status = newStatusWriter(patcher)
+	r.recorder = recorder
+
+	// repeated failures are reported as Events once they reach the threshold
+	for i := 0; i < failureEventThreshold; i++ {
+		_, err := r.Reconcile(context.Background(), reconcile.Request{})
+		require.Error(t, err)
+	}
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, "NetworkContainerProgrammingFailed")
+	require.NotEmpty(t, patcher.patched)
+	got := patcher.patched[len(patcher.patched)-1]
+	assert.Equal(t, int(cnstypes.UnexpectedError), got.Status.NetworkContainerStatuses[0].ResponseCode)
+	assert.True(t, meta.IsStatusConditionFalse(got.Status.Conditions, v1alpha.NetworkContainersProgrammed))
+
+	// once programmed, the pool is at the max IP count and is reported exhausted once
+	code = cnstypes.Success
+	for i := 0; i < 2; i++ {
+		_, err := r.Reconcile(context.Background(), reconcile.Request{})
+		require.NoError(t, err)
+	}
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, v1alpha.IPPoolExhausted)
+	got = patcher.patched[len(patcher.patched)-1]
+	assert.Equal(t, v1alpha.NetworkContainerStatus{
+		ID:              ncID,
+		AppliedVersion:  version,
+		Reason:          "Success",
+		LastAttemptTime: got.Status.NetworkContainerStatuses[0].LastAttemptTime,
+	}, got.Status.NetworkContainerStatuses[0])
+	assert.True(t, meta.IsStatusConditionTrue(got.Status.Conditions, v1alpha.NetworkContainersProgrammed))
+	assert.True(t, meta.IsStatusConditionTrue(got.Status.Conditions, v1alpha.IPPoolExhausted))
+	assert.Empty(t, r.failures)
+}
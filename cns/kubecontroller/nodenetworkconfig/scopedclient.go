@@ -7,6 +7,7 @@ import (
 	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // ScopedClient is provided to interface with a single configured NodeNetworkConfig.
@@ -34,3 +35,13 @@ func (sc *ScopedClient) PatchSpec(ctx context.Context, spec *v1alpha.NodeNetwork
 	nnc, err := sc.Client.PatchSpec(ctx, sc.NamespacedName, spec, fieldManager)
 	return nnc, errors.Wrapf(err, "failed to patch nnc %v", sc.NamespacedName)
 }
+
+// PatchStatus patches the Status of the associated NodeNetworkConfig from original to modified.
+// It refuses to patch any other NodeNetworkConfig.
+func (sc *ScopedClient) PatchStatus(ctx context.Context, original, modified *v1alpha.NodeNetworkConfig) (*v1alpha.NodeNetworkConfig, error) {
+	if key := client.ObjectKeyFromObject(modified); key != sc.NamespacedName {
+		return nil, errors.Errorf("nnc %v is out of scope %v", key, sc.NamespacedName)
+	}
+	nnc, err := sc.Client.PatchStatus(ctx, original, modified)
+	return nnc, errors.Wrapf(err, "failed to patch nnc status %v", sc.NamespacedName)
+}
@@ -0,0 +1,123 @@
+package nodenetworkconfig
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	cnstypes "github.com/Azure/azure-container-networking/cns/types"
+	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// statusRefreshInterval is how often the LastAttemptTime of a NetworkContainer is written when nothing else about
+// the attempt changed, so that the NodeNetworkConfig is not patched on every reconcile.
+const statusRefreshInterval = 5 * time.Minute
+
+type nncStatusPatcher interface {
+	PatchStatus(ctx context.Context, original, modified *v1alpha.NodeNetworkConfig) (*v1alpha.NodeNetworkConfig, error)
+}
+
+// statusWriter writes the fields of the NodeNetworkConfig Status which CNS owns: the NetworkContainerStatuses and
+// the Conditions. The rest of the Status is written by DNC and is left untouched.
+type statusWriter struct {
+	cli nncStatusPatcher
+	now func() time.Time
+}
+
+func newStatusWriter(cli nncStatusPatcher) *statusWriter {
+	return &statusWriter{
+		cli: cli,
+		now: time.Now,
+	}
+}
+
+// write records the results of the NetworkContainers attempted in this reconcile, keeping the last results of the
+// ones which were not attempted, and sets NetworkContainersProgrammed and any passed Conditions.
+// Results of NetworkContainers which are no longer in the NodeNetworkConfig are dropped.
+func (w *statusWriter) write(ctx context.Context, nnc *v1alpha.NodeNetworkConfig, attempted map[string]v1alpha.NetworkContainerStatus, conditions ...metav1.Condition) error {
+	now := metav1.NewTime(w.now())
+	previous := make(map[string]v1alpha.NetworkContainerStatus, len(nnc.Status.NetworkContainerStatuses))
+	for _, s := range nnc.Status.NetworkContainerStatuses {
+		previous[s.ID] = s
+	}
+
+	modified := nnc.DeepCopy()
+	modified.Status.NetworkContainerStatuses = nil
+	var failed []string
+	for i := range nnc.Status.NetworkContainers {
+		id := nnc.Status.NetworkContainers[i].ID
+		last, hasLast := previous[id]
+		s, ok := attempted[id]
+		switch {
+		case ok:
+			s.ID = id
+			if s.ResponseCode != int(cnstypes.Success) {
+				// a failed attempt leaves the last version which was programmed in place
+				s.AppliedVersion = last.AppliedVersion
+			}
+			s.LastAttemptTime = now
+			if hasLast && sameAttempt(last, s) && now.Sub(last.LastAttemptTime.Time) < statusRefreshInterval {
+				s.LastAttemptTime = last.LastAttemptTime
+			}
+		case hasLast:
+			s = last
+		default:
+			continue
+		}
+		if s.ResponseCode != int(cnstypes.Success) {
+			failed = append(failed, id)
+		}
+		modified.Status.NetworkContainerStatuses = append(modified.Status.NetworkContainerStatuses, s)
+	}
+
+	programmed := metav1.Condition{
+		Type:               v1alpha.NetworkContainersProgrammed,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Programmed",
+		Message:            fmt.Sprintf("programmed %d network containers", len(modified.Status.NetworkContainerStatuses)),
+		LastTransitionTime: now,
+	}
+	if len(failed) > 0 {
+		programmed.Status = metav1.ConditionFalse
+		programmed.Reason = "ProgrammingFailed"
+		programmed.Message = fmt.Sprintf("failed to program %d of %d network containers: %s",
+			len(failed), len(modified.Status.NetworkContainerStatuses), strings.Join(failed, ", "))
+	}
+	for _, c := range append([]metav1.Condition{programmed}, conditions...) {
+		c.ObservedGeneration = nnc.Generation
+		if c.LastTransitionTime.IsZero() {
+			c.LastTransitionTime = now
+		}
+		meta.SetStatusCondition(&modified.Status.Conditions, c)
+	}
+
+	if equality.Semantic.DeepEqual(nnc.Status, modified.Status) {
+		return nil
+	}
+	_, err := w.cli.PatchStatus(ctx, nnc, modified)
+	return errors.Wrap(err, "failed to write nnc status")
+}
+
+// sameAttempt is whether two results differ only in when they were attempted.
+func sameAttempt(a, b v1alpha.NetworkContainerStatus) bool {
+	a.LastAttemptTime, b.LastAttemptTime = metav1.Time{}, metav1.Time{}
+	return a == b
+}
+
+// onlyOwnStatusChanged is whether the update changed nothing but the fields of the Status which CNS
+// writes, and so is the echo of a write by the statusWriter which does not need to be reconciled.
+func onlyOwnStatusChanged(oldNNC, newNNC *v1alpha.NodeNetworkConfig) bool {
+	if equality.Semantic.DeepEqual(oldNNC.Status.NetworkContainerStatuses, newNNC.Status.NetworkContainerStatuses) &&
+		equality.Semantic.DeepEqual(oldNNC.Status.Conditions, newNNC.Status.Conditions) {
+		return false
+	}
+	o, n := oldNNC.DeepCopy(), newNNC.DeepCopy()
+	o.Status.NetworkContainerStatuses, o.Status.Conditions = nil, nil
+	n.Status.NetworkContainerStatuses, n.Status.Conditions = nil, nil
+	return equality.Semantic.DeepEqual(o.Spec, n.Spec) && equality.Semantic.DeepEqual(o.Status, n.Status)
+}
@@ -0,0 +1,105 @@
+package nodenetworkconfig
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cnstypes "github.com/Azure/azure-container-networking/cns/types"
+	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type mockStatusPatcher struct {
+	patched []*v1alpha.NodeNetworkConfig
+}
+
+func (m *mockStatusPatcher) PatchStatus(_ context.Context, _, modified *v1alpha.NodeNetworkConfig) (*v1alpha.NodeNetworkConfig, error) {
+	m.patched = append(m.patched, modified)
+	return modified, nil
+}
+
+func TestStatusWriter(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	patcher := &mockStatusPatcher{}
+	w := &statusWriter{cli: patcher, now: func() time.Time { return now }}
+	nnc := &v1alpha.NodeNetworkConfig{
+		Status: v1alpha.NodeNetworkConfigStatus{
+			NetworkContainers: []v1alpha.NetworkContainer{{ID: "nc1", Version: 2}, {ID: "nc2", Version: 1}},
+		},
+	}
+	succeeded := v1alpha.NetworkContainerStatus{AppliedVersion: 2, Reason: cnstypes.Success.String()}
+	failed := v1alpha.NetworkContainerStatus{ResponseCode: int(cnstypes.UnexpectedError), Reason: cnstypes.UnexpectedError.String(), Message: "failed"}
+
+	// nc2 fails after nc1 is programmed
+	require.NoError(t, w.write(context.Background(), nnc, map[string]v1alpha.NetworkContainerStatus{"nc1": succeeded, "nc2": failed}))
+	require.Len(t, patcher.patched, 1)
+	nnc = patcher.patched[0]
+	assert.Equal(t, []v1alpha.NetworkContainerStatus{
+		{ID: "nc1", AppliedVersion: 2, Reason: "Success", LastAttemptTime: metav1.NewTime(start)},
+		{ID: "nc2", ResponseCode: int(cnstypes.UnexpectedError), Reason: "UnexpectedError", Message: "failed", LastAttemptTime: metav1.NewTime(start)},
+	}, nnc.Status.NetworkContainerStatuses)
+	programmed := meta.FindStatusCondition(nnc.Status.Conditions, v1alpha.NetworkContainersProgrammed)
+	require.NotNil(t, programmed)
+	assert.Equal(t, metav1.ConditionFalse, programmed.Status)
+	assert.Equal(t, "failed to program 1 of 2 network containers: nc2", programmed.Message)
+
+	// the same results are not written again until the refresh interval has passed
+	now = start.Add(time.Minute)
+	require.NoError(t, w.write(context.Background(), nnc, map[string]v1alpha.NetworkContainerStatus{"nc1": succeeded, "nc2": failed}))
+	assert.Len(t, patcher.patched, 1)
+	now = start.Add(statusRefreshInterval)
+	require.NoError(t, w.write(context.Background(), nnc, map[string]v1alpha.NetworkContainerStatus{"nc1": succeeded, "nc2": failed}))
+	require.Len(t, patcher.patched, 2)
+	nnc = patcher.patched[1]
+	assert.Equal(t, metav1.NewTime(now), nnc.Status.NetworkContainerStatuses[0].LastAttemptTime)
+
+	// a failure keeps the version last programmed, and the results of the NCs which were not attempted are kept
+	nnc.Status.NetworkContainers[0].Version = 3
+	require.NoError(t, w.write(context.Background(), nnc, map[string]v1alpha.NetworkContainerStatus{"nc1": failed}))
+	require.Len(t, patcher.patched, 3)
+	nnc = patcher.patched[2]
+	assert.Equal(t, int64(2), nnc.Status.NetworkContainerStatuses[0].AppliedVersion)
+	assert.Equal(t, int(cnstypes.UnexpectedError), nnc.Status.NetworkContainerStatuses[0].ResponseCode)
+	assert.Equal(t, "nc2", nnc.Status.NetworkContainerStatuses[1].ID)
+
+	// the results of NCs which were removed are dropped
+	nnc.Status.NetworkContainers = nnc.Status.NetworkContainers[:1]
+	succeeded.AppliedVersion = 3
+	require.NoError(t, w.write(context.Background(), nnc, map[string]v1alpha.NetworkContainerStatus{"nc1": succeeded}))
+	require.Len(t, patcher.patched, 4)
+	nnc = patcher.patched[3]
+	assert.Equal(t, []v1alpha.NetworkContainerStatus{
+		{ID: "nc1", AppliedVersion: 3, Reason: "Success", LastAttemptTime: metav1.NewTime(now)},
+	}, nnc.Status.NetworkContainerStatuses)
+	programmed = meta.FindStatusCondition(nnc.Status.Conditions, v1alpha.NetworkContainersProgrammed)
+	require.NotNil(t, programmed)
+	assert.Equal(t, metav1.ConditionTrue, programmed.Status)
+	assert.Equal(t, "programmed 1 network containers", programmed.Message)
+}
+
+func TestOnlyOwnStatusChanged(t *testing.T) {
+	old := &v1alpha.NodeNetworkConfig{
+		Spec:   v1alpha.NodeNetworkConfigSpec{RequestedIPCount: 10},
+		Status: v1alpha.NodeNetworkConfigStatus{NetworkContainers: []v1alpha.NetworkContainer{{ID: "nc1"}}},
+	}
+
+	ownStatus := old.DeepCopy()
+	ownStatus.Status.NetworkContainerStatuses = []v1alpha.NetworkContainerStatus{{ID: "nc1"}}
+	assert.True(t, onlyOwnStatusChanged(old, ownStatus))
+
+	unchanged := old.DeepCopy()
+	assert.False(t, onlyOwnStatusChanged(old, unchanged))
+
+	dncStatus := ownStatus.DeepCopy()
+	dncStatus.Status.NetworkContainers[0].Version = 1
+	assert.False(t, onlyOwnStatusChanged(old, dncStatus))
+
+	spec := ownStatus.DeepCopy()
+	spec.Spec.RequestedIPCount = 20
+	assert.False(t, onlyOwnStatusChanged(old, spec))
+}
@@ -0,0 +1,148 @@
+package overlayextensionconfig
+
+import (
+	"net"
+	"net/netip"
+
+	"github.com/Azure/azure-container-networking/iptables"
+	"github.com/Azure/azure-container-networking/netlink"
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// Chain is the nat chain, jumped to from POSTROUTING, which exempts traffic to the extension ranges from SNAT.
+	Chain = "AZURECNSOEC"
+	// rtprotOverlayExtension is the protocol of the routes to the extension ranges, by which they are found again.
+	rtprotOverlayExtension = 0xa5
+)
+
+type ipTablesClient interface {
+	RunCmd(version, params string) error
+	ChainExists(version, tableName, chainName string) bool
+	CreateChain(version, tableName, chainName string) error
+	RuleExists(version, tableName, chainName, match, target string) bool
+	InsertIptableRule(version, tableName, chainName, match, target string) error
+	AppendIptableRule(version, tableName, chainName, match, target string) error
+	DeleteIptableRule(version, tableName, chainName, match, target string) error
+}
+
+type routeClient interface {
+	GetIPRoute(filter *netlink.Route) ([]*netlink.Route, error)
+	AddIPRoute(route *netlink.Route) error
+	DeleteIPRoute(route *netlink.Route) error
+}
+
+// dataplane exempts traffic from the pods to an extension range from SNAT, and routes it out of the interface of
+// the default route, so that the extension range reaches the pod IPs directly.
+type dataplane struct {
+	ipt ipTablesClient
+	nl  routeClient
+}
+
+func NewDataplane() (Dataplane, error) {
+	return &dataplane{ipt: iptables.NewClient(), nl: netlink.NewNetlink()}, nil
+}
+
+// Programmed returns the ranges which have a route to them, which is added last and removed last by Add and Remove.
+func (d *dataplane) Programmed() ([]netip.Prefix, error) {
+	var ranges []netip.Prefix
+	seen := map[netip.Prefix]bool{}
+	for _, family := range []int{unix.AF_INET, unix.AF_INET6} {
+		routes, err := d.nl.GetIPRoute(&netlink.Route{Family: family, Protocol: rtprotOverlayExtension})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list extension range routes")
+		}
+		for _, route := range routes {
+			if route.Dst == nil {
+				continue
+			}
+			addr, ok := netip.AddrFromSlice(route.Dst.IP)
+			if !ok {
+				continue
+			}
+			ones, _ := route.Dst.Mask.Size()
+			ipRange := netip.PrefixFrom(addr.Unmap(), ones).Masked()
+			if !seen[ipRange] {
+				seen[ipRange] = true
+				ranges = append(ranges, ipRange)
+			}
+		}
+	}
+	return ranges, nil
+}
+
+func (d *dataplane) Add(ipRange netip.Prefix) error {
+	v, family := versionOf(ipRange)
+	if err := d.ipt.CreateChain(v, iptables.Nat, Chain); err != nil {
+		return errors.Wrapf(err, "failed to create chain %s", Chain)
+	}
+	if err := d.ipt.InsertIptableRule(v, iptables.Nat, iptables.Postrouting, "", Chain); err != nil {
+		return errors.Wrapf(err, "failed to jump to chain %s", Chain)
+	}
+	if err := d.ipt.AppendIptableRule(v, iptables.Nat, Chain, "-d "+ipRange.String(), iptables.Accept); err != nil {
+		return errors.Wrap(err, "failed to exempt range from SNAT")
+	}
+
+	dst := prefixToIPNet(ipRange)
+	existing, err := d.nl.GetIPRoute(&netlink.Route{Family: family, Dst: dst, Protocol: rtprotOverlayExtension})
+	if err != nil {
+		return errors.Wrap(err, "failed to list routes")
+	}
+	if len(existing) > 0 {
+		return nil
+	}
+	defaults, err := d.nl.GetIPRoute(&netlink.Route{Family: family, Dst: prefixToIPNet(netip.PrefixFrom(zeroAddr(family), 0))})
+	if err != nil {
+		return errors.Wrap(err, "failed to get default route")
+	}
+	if len(defaults) == 0 {
+		return errors.Errorf("no default route to route %s through", ipRange)
+	}
+	route := &netlink.Route{
+		Family:    family,
+		Dst:       dst,
+		Gw:        defaults[0].Gw,
+		LinkIndex: defaults[0].LinkIndex,
+		Protocol:  rtprotOverlayExtension,
+	}
+	return errors.Wrapf(d.nl.AddIPRoute(route), "failed to add route to %s", ipRange)
+}
+
+func (d *dataplane) Remove(ipRange netip.Prefix) error {
+	v, family := versionOf(ipRange)
+	match := "-d " + ipRange.String()
+	if d.ipt.RuleExists(v, iptables.Nat, Chain, match, iptables.Accept) {
+		if err := d.ipt.DeleteIptableRule(v, iptables.Nat, Chain, match, iptables.Accept); err != nil {
+			return errors.Wrap(err, "failed to delete SNAT exemption")
+		}
+	}
+	routes, err := d.nl.GetIPRoute(&netlink.Route{Family: family, Dst: prefixToIPNet(ipRange), Protocol: rtprotOverlayExtension})
+	if err != nil {
+		return errors.Wrap(err, "failed to list routes")
+	}
+	for _, route := range routes {
+		if err := d.nl.DeleteIPRoute(route); err != nil {
+			return errors.Wrapf(err, "failed to delete route to %s", ipRange)
+		}
+	}
+	return nil
+}
+
+func versionOf(ipRange netip.Prefix) (string, int) {
+	if ipRange.Addr().Is4() {
+		return iptables.V4, unix.AF_INET
+	}
+	return iptables.V6, unix.AF_INET6
+}
+
+func zeroAddr(family int) netip.Addr {
+	if family == unix.AF_INET {
+		return netip.IPv4Unspecified()
+	}
+	return netip.IPv6Unspecified()
+}
+
+func prefixToIPNet(p netip.Prefix) *net.IPNet {
+	return &net.IPNet{IP: p.Addr().AsSlice(), Mask: net.CIDRMask(p.Bits(), p.Addr().BitLen())}
+}
@@ -0,0 +1,126 @@
+package overlayextensionconfig
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/iptables"
+	"github.com/Azure/azure-container-networking/netlink"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+type fakeIPTables struct {
+	chains map[string]bool
+	// rules are keyed by table, chain, match and target
+	rules map[string]bool
+}
+
+func newFakeIPTables() *fakeIPTables {
+	return &fakeIPTables{chains: map[string]bool{}, rules: map[string]bool{}}
+}
+
+func (f *fakeIPTables) RunCmd(_, _ string) error {
+	return nil
+}
+
+func (f *fakeIPTables) ChainExists(version, table, chain string) bool {
+	return f.chains[version+"/"+table+"/"+chain]
+}
+
+func (f *fakeIPTables) CreateChain(version, table, chain string) error {
+	f.chains[version+"/"+table+"/"+chain] = true
+	return nil
+}
+
+func (f *fakeIPTables) RuleExists(version, table, chain, match, target string) bool {
+	return f.rules[version+"/"+table+"/"+chain+"/"+match+"/"+target]
+}
+
+func (f *fakeIPTables) InsertIptableRule(version, table, chain, match, target string) error {
+	f.rules[version+"/"+table+"/"+chain+"/"+match+"/"+target] = true
+	return nil
+}
+
+func (f *fakeIPTables) AppendIptableRule(version, table, chain, match, target string) error {
+	return f.InsertIptableRule(version, table, chain, match, target)
+}
+
+func (f *fakeIPTables) DeleteIptableRule(version, table, chain, match, target string) error {
+	delete(f.rules, version+"/"+table+"/"+chain+"/"+match+"/"+target)
+	return nil
+}
+
+type fakeRoutes struct {
+	routes []*netlink.Route
+}
+
+func (f *fakeRoutes) GetIPRoute(filter *netlink.Route) ([]*netlink.Route, error) {
+	var routes []*netlink.Route
+	for _, r := range f.routes {
+		if r.Family != filter.Family || (filter.Protocol != 0 && r.Protocol != filter.Protocol) {
+			continue
+		}
+		if filter.Dst != nil && r.Dst.String() != filter.Dst.String() {
+			continue
+		}
+		routes = append(routes, r)
+	}
+	return routes, nil
+}
+
+func (f *fakeRoutes) AddIPRoute(route *netlink.Route) error {
+	f.routes = append(f.routes, route)
+	return nil
+}
+
+func (f *fakeRoutes) DeleteIPRoute(route *netlink.Route) error {
+	for i, r := range f.routes {
+		if r == route {
+			f.routes = append(f.routes[:i], f.routes[i+1:]...)
+			return nil
+		}
+	}
+	return unix.ESRCH
+}
+
+func TestDataplane(t *testing.T) {
+	_, defaultDst, _ := net.ParseCIDR("0.0.0.0/0")
+	gw := net.ParseIP("10.224.0.1")
+	ipt, nl := newFakeIPTables(), &fakeRoutes{routes: []*netlink.Route{
+		{Family: unix.AF_INET, Dst: defaultDst, Gw: gw, LinkIndex: 2},
+	}}
+	dp := &dataplane{ipt: ipt, nl: nl}
+	ipRange := netip.MustParsePrefix("10.10.0.0/16")
+
+	require.NoError(t, dp.Add(ipRange))
+	require.NoError(t, dp.Add(ipRange))
+	assert.True(t, ipt.ChainExists(iptables.V4, iptables.Nat, Chain))
+	assert.True(t, ipt.RuleExists(iptables.V4, iptables.Nat, iptables.Postrouting, "", Chain))
+	assert.True(t, ipt.RuleExists(iptables.V4, iptables.Nat, Chain, "-d 10.10.0.0/16", iptables.Accept))
+	routes, err := nl.GetIPRoute(&netlink.Route{Family: unix.AF_INET, Protocol: rtprotOverlayExtension})
+	require.NoError(t, err)
+	require.Len(t, routes, 1)
+	assert.Equal(t, "10.10.0.0/16", routes[0].Dst.String())
+	assert.Equal(t, gw, routes[0].Gw)
+	assert.Equal(t, 2, routes[0].LinkIndex)
+
+	require.NoError(t, dp.Remove(ipRange))
+	assert.False(t, ipt.RuleExists(iptables.V4, iptables.Nat, Chain, "-d 10.10.0.0/16", iptables.Accept))
+	assert.Len(t, nl.routes, 1)
+
+	// the ranges programmed by a previous run are found by their routes
+	programmed, err := dp.Programmed()
+	require.NoError(t, err)
+	assert.Empty(t, programmed)
+	require.NoError(t, dp.Add(ipRange))
+	programmed, err = dp.Programmed()
+	require.NoError(t, err)
+	assert.Equal(t, []netip.Prefix{ipRange}, programmed)
+
+	// a range can not be routed without a default route
+	nl.routes = nil
+	require.Error(t, dp.Add(ipRange))
+}
@@ -0,0 +1,7 @@
+package overlayextensionconfig
+
+import "github.com/pkg/errors"
+
+func NewDataplane() (Dataplane, error) {
+	return nil, errors.New("overlay extension ranges are not supported on windows")
+}
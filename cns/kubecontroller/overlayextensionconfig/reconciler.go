@@ -0,0 +1,283 @@
+package overlayextensionconfig
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-container-networking/crd/overlayextensionconfig/api/v1alpha1"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// maxFailedNodesInMessage is how many of the Nodes which failed to program a range are named in the aggregate Message.
+const maxFailedNodesInMessage = 5
+
+// statusBackoff spreads out the retries of the Status patch, as every Node patches the same
+// OverlayExtensionConfig when its range is set and most of them conflict.
+var statusBackoff = wait.Backoff{
+	Steps:    10,
+	Duration: 50 * time.Millisecond,
+	Factor:   2,
+	Jitter:   1,
+	Cap:      10 * time.Second,
+}
+
+// Dataplane programs the host so that traffic between an extension IP range and the pods is routed directly,
+// and is not SNATed.
+type Dataplane interface {
+	// Programmed returns the ranges programmed on the host, such as by a previous run of CNS.
+	Programmed() ([]netip.Prefix, error)
+	Add(ipRange netip.Prefix) error
+	Remove(ipRange netip.Prefix) error
+}
+
+// Reconciler programs the ExtensionIPRange of each OverlayExtensionConfig on the Node, reports its progress in
+// the OverlayExtensionConfig Status, and removes the range when the OverlayExtensionConfig is deleted.
+type Reconciler struct {
+	cli client.Client
+	// reader reads the OverlayExtensionConfig from the apiserver to update its Status, as the cache is likely
+	// behind after a conflict with another Node.
+	reader   client.Reader
+	dp       Dataplane
+	nodeName string
+	z        *zap.Logger
+
+	mu         sync.Mutex
+	programmed map[types.NamespacedName]netip.Prefix
+}
+
+func New(z *zap.Logger, dp Dataplane, nodeName string) *Reconciler {
+	return &Reconciler{
+		dp:         dp,
+		nodeName:   nodeName,
+		z:          z,
+		programmed: map[types.NamespacedName]netip.Prefix{},
+	}
+}
+
+func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	oec := &v1alpha1.OverlayExtensionConfig{}
+	if err := r.cli.Get(ctx, req.NamespacedName, oec); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, r.remove(req.NamespacedName)
+		}
+		return reconcile.Result{}, errors.Wrapf(err, "failed to get oec %s", req.String())
+	}
+	if !oec.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, r.remove(req.NamespacedName)
+	}
+	if oec.Spec.ExtensionIPRange == "" {
+		// nothing to program until the range is set
+		return reconcile.Result{}, nil
+	}
+
+	ipRange, err := netip.ParsePrefix(oec.Spec.ExtensionIPRange)
+	if err != nil {
+		// the range is immutable, so retrying will not help
+		msg := fmt.Sprintf("invalid extensionIPRange %q: %v", oec.Spec.ExtensionIPRange, err)
+		return reconcile.Result{}, r.updateStatus(ctx, req.NamespacedName, v1alpha1.Failed, msg)
+	}
+	if err := r.add(req.NamespacedName, ipRange.Masked()); err != nil {
+		if serr := r.updateStatus(ctx, req.NamespacedName, v1alpha1.Failed, err.Error()); serr != nil {
+			r.z.Error("failed to report oec failure", zap.String("oec", req.String()), zap.Error(serr))
+		}
+		return reconcile.Result{}, errors.Wrapf(err, "failed to program oec %s", req.String())
+	}
+	return reconcile.Result{}, r.updateStatus(ctx, req.NamespacedName, v1alpha1.Succeeded, "")
+}
+
+func (r *Reconciler) add(key types.NamespacedName, ipRange netip.Prefix) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if current, ok := r.programmed[key]; ok && current == ipRange {
+		return nil
+	}
+	if err := r.dp.Add(ipRange); err != nil {
+		return errors.Wrapf(err, "failed to program extension range %s", ipRange)
+	}
+	r.programmed[key] = ipRange
+	r.z.Info("programmed extension range", zap.String("oec", key.String()), zap.Stringer("range", ipRange))
+	return nil
+}
+
+// remove removes the range programmed for the OverlayExtensionConfig, unless another one has the same range.
+func (r *Reconciler) remove(key types.NamespacedName) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ipRange, ok := r.programmed[key]
+	if !ok {
+		return nil
+	}
+	delete(r.programmed, key)
+	for _, other := range r.programmed {
+		if other == ipRange {
+			return nil
+		}
+	}
+	if err := r.dp.Remove(ipRange); err != nil {
+		// keep the range to retry removing it
+		r.programmed[key] = ipRange
+		return errors.Wrapf(err, "failed to remove extension range %s", ipRange)
+	}
+	r.z.Info("removed extension range", zap.String("oec", key.String()), zap.Stringer("range", ipRange))
+	return nil
+}
+
+// updateStatus records the state of the Node in the OverlayExtensionConfig Status, prunes the Nodes which no
+// longer exist and updates the aggregate State and Message. The Status is patched with optimistic locking and
+// retried with backoff if other Nodes update it at the same time.
+func (r *Reconciler) updateStatus(ctx context.Context, key types.NamespacedName, state v1alpha1.OECState, msg string) error {
+	gone := map[string]bool{}
+	err := retry.RetryOnConflict(statusBackoff, func() error {
+		oec := &v1alpha1.OverlayExtensionConfig{}
+		if err := r.reader.Get(ctx, key, oec); err != nil {
+			return err //nolint:wrapcheck // wrapped below
+		}
+		orig := oec.DeepCopy()
+		node := v1alpha1.OECNodeStatus{Name: r.nodeName, State: state, Message: msg}
+		nodes := make([]v1alpha1.OECNodeStatus, 0, len(oec.Status.Nodes)+1)
+		found := false
+		for i := range oec.Status.Nodes {
+			current := oec.Status.Nodes[i]
+			if current.Name == r.nodeName {
+				if !found {
+					nodes = append(nodes, node)
+				}
+				found = true
+				continue
+			}
+			exists, err := r.nodeExists(ctx, current, gone)
+			if err != nil {
+				return err
+			}
+			if exists {
+				nodes = append(nodes, current)
+			}
+		}
+		if !found {
+			nodes = append(nodes, node)
+		}
+		oec.Status.Nodes = nodes
+		oec.Status.State, oec.Status.Message = aggregate(oec.Status.Nodes)
+		if equality.Semantic.DeepEqual(orig.Status, oec.Status) {
+			return nil
+		}
+		patch := client.MergeFromWithOptions(orig, client.MergeFromWithOptimisticLock{})
+		return r.cli.Status().Patch(ctx, oec, patch) //nolint:wrapcheck // wrapped below
+	})
+	return errors.Wrapf(client.IgnoreNotFound(err), "failed to update status of oec %s", key.String())
+}
+
+// nodeExists returns whether the Node of the status is still in the cluster. Only the Nodes which have not
+// succeeded are looked up, as they are the ones which hold the aggregate State back, and the Nodes found gone
+// are remembered across retries.
+func (r *Reconciler) nodeExists(ctx context.Context, status v1alpha1.OECNodeStatus, gone map[string]bool) (bool, error) {
+	if status.State == v1alpha1.Succeeded {
+		return true, nil
+	}
+	if gone[status.Name] {
+		return false, nil
+	}
+	if err := r.reader.Get(ctx, types.NamespacedName{Name: status.Name}, &corev1.Node{}); err != nil {
+		if apierrors.IsNotFound(err) {
+			gone[status.Name] = true
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "failed to get node %s", status.Name)
+	}
+	return true, nil
+}
+
+// aggregate returns the State of an OverlayExtensionConfig from the states of the Nodes: Failed if any Node
+// failed, Succeeded if all succeeded, and Pending otherwise.
+func aggregate(nodes []v1alpha1.OECNodeStatus) (v1alpha1.OECState, string) {
+	var failed []string
+	succeeded := 0
+	for i := range nodes {
+		switch nodes[i].State {
+		case v1alpha1.Failed:
+			failed = append(failed, nodes[i].Name)
+		case v1alpha1.Succeeded:
+			succeeded++
+		}
+	}
+	switch {
+	case len(failed) > 0:
+		count := len(failed)
+		sort.Strings(failed)
+		if count > maxFailedNodesInMessage {
+			failed = append(failed[:maxFailedNodesInMessage], "...")
+		}
+		return v1alpha1.Failed, fmt.Sprintf("failed on %d of %d nodes: %s", count, len(nodes), strings.Join(failed, ", "))
+	case succeeded == len(nodes):
+		return v1alpha1.Succeeded, fmt.Sprintf("programmed on %d nodes", len(nodes))
+	default:
+		return v1alpha1.Pending, fmt.Sprintf("programmed on %d of %d nodes", succeeded, len(nodes))
+	}
+}
+
+// prune removes the ranges programmed by a previous run for OverlayExtensionConfigs which no longer exist. The
+// ranges which still exist are left in place, so that their traffic is not interrupted until they are reconciled.
+func (r *Reconciler) prune(ctx context.Context) error {
+	programmed, err := r.dp.Programmed()
+	if err != nil {
+		return errors.Wrap(err, "failed to list programmed extension ranges")
+	}
+	if len(programmed) == 0 {
+		return nil
+	}
+	oecs := &v1alpha1.OverlayExtensionConfigList{}
+	if err := r.reader.List(ctx, oecs); err != nil {
+		return errors.Wrap(err, "failed to list oecs")
+	}
+	desired := map[netip.Prefix]bool{}
+	for i := range oecs.Items {
+		if !oecs.Items[i].DeletionTimestamp.IsZero() {
+			continue
+		}
+		if ipRange, err := netip.ParsePrefix(oecs.Items[i].Spec.ExtensionIPRange); err == nil {
+			desired[ipRange.Masked()] = true
+		}
+	}
+	for _, ipRange := range programmed {
+		if desired[ipRange] {
+			continue
+		}
+		if err := r.dp.Remove(ipRange); err != nil {
+			return errors.Wrapf(err, "failed to remove stale extension range %s", ipRange)
+		}
+		r.z.Info("removed stale extension range", zap.Stringer("range", ipRange))
+	}
+	return nil
+}
+
+// SetupWithManager removes the ranges programmed by a previous run which no OverlayExtensionConfig asks for
+// any more, and registers the Reconciler with the manager.
+// Only changes to the Spec are reconciled, so that Status updates by other Nodes do not wake every Node.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.cli = mgr.GetClient()
+	r.reader = mgr.GetAPIReader()
+	if err := r.prune(context.Background()); err != nil {
+		return errors.Wrap(err, "failed to prune extension ranges")
+	}
+	err := ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.OverlayExtensionConfig{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+	return errors.Wrap(err, "failed to setup overlayextensionconfig reconciler with manager")
+}
@@ -0,0 +1,206 @@
+package overlayextensionconfig
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/crd/overlayextensionconfig/api/v1alpha1"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+type fakeDataplane struct {
+	ranges map[netip.Prefix]bool
+	adds   int
+	err    error
+}
+
+func (f *fakeDataplane) Programmed() ([]netip.Prefix, error) {
+	var ranges []netip.Prefix
+	for ipRange := range f.ranges {
+		ranges = append(ranges, ipRange)
+	}
+	return ranges, nil
+}
+
+func (f *fakeDataplane) Add(ipRange netip.Prefix) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.adds++
+	f.ranges[ipRange] = true
+	return nil
+}
+
+func (f *fakeDataplane) Remove(ipRange netip.Prefix) error {
+	if f.err != nil {
+		return f.err
+	}
+	delete(f.ranges, ipRange)
+	return nil
+}
+
+func newOEC(name, ipRange string, nodes ...v1alpha1.OECNodeStatus) *v1alpha1.OverlayExtensionConfig {
+	return &v1alpha1.OverlayExtensionConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       v1alpha1.OverlayExtensionConfigSpec{ExtensionIPRange: ipRange},
+		Status:     v1alpha1.OverlayExtensionConfigStatus{Nodes: nodes},
+	}
+}
+
+func newTestReconciler(t *testing.T, objs ...client.Object) (*Reconciler, *fakeDataplane, client.Client) {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+	cli := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objs...).
+		WithStatusSubresource(&v1alpha1.OverlayExtensionConfig{}).
+		Build()
+	dp := &fakeDataplane{ranges: map[netip.Prefix]bool{}}
+	r := New(zap.NewNop(), dp, "node-1")
+	r.cli, r.reader = cli, cli
+	return r, dp, cli
+}
+
+func newNode(name string) *corev1.Node {
+	return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}}
+}
+
+func reconcileOEC(t *testing.T, r *Reconciler, name string) error {
+	t.Helper()
+	_, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: name}})
+	return err
+}
+
+func getOEC(t *testing.T, cli client.Client, name string) *v1alpha1.OverlayExtensionConfig {
+	t.Helper()
+	oec := &v1alpha1.OverlayExtensionConfig{}
+	require.NoError(t, cli.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: name}, oec))
+	return oec
+}
+
+func TestReconcileProgramsRange(t *testing.T) {
+	r, dp, cli := newTestReconciler(t, newOEC("oec", "10.10.0.1/16"))
+
+	require.NoError(t, reconcileOEC(t, r, "oec"))
+	assert.Equal(t, map[netip.Prefix]bool{netip.MustParsePrefix("10.10.0.0/16"): true}, dp.ranges)
+
+	oec := getOEC(t, cli, "oec")
+	assert.Equal(t, []v1alpha1.OECNodeStatus{{Name: "node-1", State: v1alpha1.Succeeded}}, oec.Status.Nodes)
+	assert.Equal(t, v1alpha1.Succeeded, oec.Status.State)
+
+	// reconciling again does not program the range again
+	require.NoError(t, reconcileOEC(t, r, "oec"))
+	assert.Equal(t, 1, dp.adds)
+}
+
+func TestReconcileAggregatesNodes(t *testing.T) {
+	r, _, cli := newTestReconciler(t,
+		newOEC("pending", "10.10.0.0/16", v1alpha1.OECNodeStatus{Name: "node-0", State: v1alpha1.Pending}),
+		newOEC("failed", "10.20.0.0/16", v1alpha1.OECNodeStatus{Name: "node-2", State: v1alpha1.Failed, Message: "no route"}),
+		newNode("node-0"), newNode("node-2"),
+	)
+
+	require.NoError(t, reconcileOEC(t, r, "pending"))
+	oec := getOEC(t, cli, "pending")
+	assert.Equal(t, v1alpha1.Pending, oec.Status.State)
+	assert.Equal(t, "programmed on 1 of 2 nodes", oec.Status.Message)
+
+	require.NoError(t, reconcileOEC(t, r, "failed"))
+	oec = getOEC(t, cli, "failed")
+	assert.Equal(t, v1alpha1.Failed, oec.Status.State)
+	assert.Equal(t, "failed on 1 of 2 nodes: node-2", oec.Status.Message)
+}
+
+func TestReconcilePrunesDeletedNodes(t *testing.T) {
+	r, _, cli := newTestReconciler(t,
+		newOEC("oec", "10.10.0.0/16",
+			v1alpha1.OECNodeStatus{Name: "node-0", State: v1alpha1.Succeeded},
+			v1alpha1.OECNodeStatus{Name: "node-2", State: v1alpha1.Failed, Message: "no route"},
+			v1alpha1.OECNodeStatus{Name: "node-3", State: v1alpha1.Failed, Message: "no route"},
+		),
+		newNode("node-0"), newNode("node-3"),
+	)
+
+	// the failure of a deleted Node does not hold the aggregate State back
+	require.NoError(t, reconcileOEC(t, r, "oec"))
+	oec := getOEC(t, cli, "oec")
+	assert.Equal(t, []v1alpha1.OECNodeStatus{
+		{Name: "node-0", State: v1alpha1.Succeeded},
+		{Name: "node-3", State: v1alpha1.Failed, Message: "no route"},
+		{Name: "node-1", State: v1alpha1.Succeeded},
+	}, oec.Status.Nodes)
+	assert.Equal(t, "failed on 1 of 3 nodes: node-3", oec.Status.Message)
+
+	require.NoError(t, cli.Delete(context.Background(), newNode("node-3")))
+	require.NoError(t, reconcileOEC(t, r, "oec"))
+	oec = getOEC(t, cli, "oec")
+	assert.Len(t, oec.Status.Nodes, 2)
+	assert.Equal(t, v1alpha1.Succeeded, oec.Status.State)
+}
+
+func TestReconcileReportsFailure(t *testing.T) {
+	r, dp, cli := newTestReconciler(t, newOEC("invalid", "10.10.0.0"), newOEC("oec", "10.10.0.0/16"))
+
+	// an invalid range is reported without retrying
+	require.NoError(t, reconcileOEC(t, r, "invalid"))
+	oec := getOEC(t, cli, "invalid")
+	assert.Equal(t, v1alpha1.Failed, oec.Status.State)
+	assert.Contains(t, oec.Status.Nodes[0].Message, "invalid extensionIPRange")
+
+	// failing to program the range is reported and retried
+	dp.err = errors.New("iptables failed")
+	require.Error(t, reconcileOEC(t, r, "oec"))
+	oec = getOEC(t, cli, "oec")
+	assert.Equal(t, v1alpha1.Failed, oec.Status.State)
+	assert.Contains(t, oec.Status.Nodes[0].Message, "iptables failed")
+
+	dp.err = nil
+	require.NoError(t, reconcileOEC(t, r, "oec"))
+	assert.Equal(t, v1alpha1.Succeeded, getOEC(t, cli, "oec").Status.State)
+}
+
+func TestReconcileRemovesRangeOnDelete(t *testing.T) {
+	a, b := newOEC("a", "10.10.0.0/16"), newOEC("b", "10.10.0.0/16")
+	r, dp, cli := newTestReconciler(t, a, b)
+	require.NoError(t, reconcileOEC(t, r, "a"))
+	require.NoError(t, reconcileOEC(t, r, "b"))
+
+	// the range is kept while another OverlayExtensionConfig has it
+	require.NoError(t, cli.Delete(context.Background(), a))
+	require.NoError(t, reconcileOEC(t, r, "a"))
+	assert.Len(t, dp.ranges, 1)
+
+	require.NoError(t, cli.Delete(context.Background(), b))
+	require.NoError(t, reconcileOEC(t, r, "b"))
+	assert.Empty(t, dp.ranges)
+}
+
+func TestPruneKeepsExistingRanges(t *testing.T) {
+	deleting := newOEC("deleting", "10.30.0.0/16")
+	now := metav1.Now()
+	deleting.DeletionTimestamp = &now
+	deleting.Finalizers = []string{"test"}
+	r, dp, _ := newTestReconciler(t, newOEC("oec", "10.10.0.1/16"), deleting)
+	kept, stale, deleted := netip.MustParsePrefix("10.10.0.0/16"), netip.MustParsePrefix("10.20.0.0/16"), netip.MustParsePrefix("10.30.0.0/16")
+	dp.ranges = map[netip.Prefix]bool{kept: true, stale: true, deleted: true}
+
+	// only the ranges no oec asks for any more are removed, the others stay programmed
+	require.NoError(t, r.prune(context.Background()))
+	assert.Equal(t, map[netip.Prefix]bool{kept: true}, dp.ranges)
+
+	require.NoError(t, reconcileOEC(t, r, "oec"))
+	assert.Equal(t, map[netip.Prefix]bool{kept: true}, dp.ranges)
+}
@@ -80,6 +80,16 @@ func (p *watcher) NewNotifierFunc(listOpts *client.ListOptions, limiter limiter,
 	}
 }
 
+// List returns the Pods in the cache of the watcher, filtered by listOpts. It can only be called once the watcher is
+// set up with a manager whose cache is synced.
+func (p *watcher) List(ctx context.Context, listOpts *client.ListOptions) ([]v1.Pod, error) {
+	podList := &v1.PodList{}
+	if err := p.cli.List(ctx, podList, listOpts); err != nil {
+		return nil, errors.Wrap(err, "failed to list pods")
+	}
+	return podList.Items, nil
+}
+
 var hostNetworkIndexer = client.IndexerFunc(func(o client.Object) []string {
 	pod, ok := o.(*v1.Pod)
 	if !ok {
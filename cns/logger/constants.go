@@ -3,8 +3,11 @@ package logger
 
 const (
 	// Metrics
-	HeartBeatMetricStr       = "HeartBeat"
-	ConfigSnapshotMetricsStr = "ConfigSnapshot"
+	HeartBeatMetricStr        = "HeartBeat"
+	ConfigSnapshotMetricsStr  = "ConfigSnapshot"
+	ConfigReloadEventStr      = "ConfigReload"
+	IPAddressConflictEventStr = "IPAddressConflict"
+	NodeSubnetIPDriftEventStr = "NodeSubnetIPDrift"
 
 	// Dimensions
 	orchestratorTypeKey             = "OrchestratorType"
@@ -16,7 +19,14 @@ const (
 	HomeAZErrorMsgStr               = "HomeAZErrorMsg"
 	CNSConfigPropertyStr            = "CNSConfiguration"
 	CNSConfigMD5CheckSumPropertyStr = "CNSConfigurationMD5Checksum"
+	CNSConfigChangesPropertyStr     = "CNSConfigurationChanges"
+	CNSConfigReloadErrorPropertyStr = "CNSConfigurationReloadError"
 	apiServerKey                    = "APIServer"
+	IPAddressStr                    = "IPAddress"
+	ConflictErrorStr                = "ConflictError"
+	PodNameStr                      = "PodName"
+	PodNamespaceStr                 = "PodNamespace"
+	DriftPolicyStr                  = "DriftPolicy"
 
 	// CNS NC Snspshot properties
 	CnsNCSnapshotEventStr         = "CNSNCSnapshot"
@@ -5,6 +5,7 @@ import (
 
 	loggerv1 "github.com/Azure/azure-container-networking/cns/logger"
 	"github.com/Azure/azure-container-networking/internal/time"
+	logv2 "github.com/Azure/azure-container-networking/log/v2"
 	"github.com/pkg/errors"
 	"go.uber.org/zap/zapcore"
 )
@@ -42,6 +43,32 @@ func (c *Config) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// SetLevel changes the general logging Level of a logger built from this Config with New.
+// Components and cores with a more specific Level are not affected.
+func (c *Config) SetLevel(level string) error {
+	return c.shared().SetLevel(level) //nolint:wrapcheck // it's an internal pkg
+}
+
+// SetComponentLevel changes the Level of the named component of a logger built from this Config
+// with New. An empty level removes the override so that the component follows Level again.
+func (c *Config) SetComponentLevel(component, level string) error {
+	return c.shared().SetComponentLevel(component, level) //nolint:wrapcheck // it's an internal pkg
+}
+
+// Levels returns the current general Level and the Level of every component with an override.
+func (c *Config) Levels() (general string, components map[string]string) {
+	return c.shared().Levels()
+}
+
+// shared returns the log/v2 Config the logger was built from by New, or an unbuilt one
+// holding the Levels of this Config.
+func (c *Config) shared() *logv2.Config {
+	if c.built == nil {
+		return &logv2.Config{Level: c.Level, Components: c.Components}
+	}
+	return c.built
+}
+
 // Normalize checks the Config for missing/default values and sets them
 // if appropriate.
 func (c *Config) Normalize() {
@@ -70,5 +97,10 @@ func (c *Config) Normalize() {
 			c.AppInsights.MaxBatchSize = defaultMaxBatchSize
 		}
 	}
+	if c.Tracing != nil {
+		if c.Tracing.SampleRatio == 0 {
+			c.Tracing.SampleRatio = 1
+		}
+	}
 	c.normalize()
 }
@@ -1,7 +1,8 @@
 package logger
 
 import (
-	cores "github.com/Azure/azure-container-networking/cns/logger/v2/cores"
+	logv2 "github.com/Azure/azure-container-networking/log/v2"
+	cores "github.com/Azure/azure-container-networking/log/v2/cores"
 	"go.uber.org/zap/zapcore"
 )
 
@@ -9,10 +10,15 @@ const defaultFilePath = "/var/log/azure-cns.log"
 
 type Config struct {
 	// Level is the general logging Level. If cores have more specific config it will override this.
-	Level       string                   `json:"level"`
-	level       zapcore.Level            `json:"-"`
+	Level string        `json:"level"`
+	level zapcore.Level `json:"-"`
+	// Components overrides Level for the named components, such as "ipampool" or "restserver".
+	Components  map[string]string        `json:"components,omitempty"`
+	built       *logv2.Config            `json:"-"`
 	AppInsights *cores.AppInsightsConfig `json:"appInsights,omitempty"`
 	File        *cores.FileConfig        `json:"file,omitempty"`
+	OTLP        *cores.OTLPConfig        `json:"otlp,omitempty"`
+	Tracing     *TracingConfig           `json:"tracing,omitempty"`
 }
 
 func (c *Config) normalize() {}
@@ -4,8 +4,9 @@ import (
 	"encoding/json"
 	"testing"
 
-	cores "github.com/Azure/azure-container-networking/cns/logger/v2/cores"
+	cores "github.com/Azure/azure-container-networking/log/v2/cores"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 )
 
 func TestUnmarshalJSON(t *testing.T) {
@@ -53,3 +54,19 @@ func TestUnmarshalJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestSetLevel(t *testing.T) {
+	c := &Config{}
+	require.Error(t, c.SetLevel("debug"))
+
+	require.NoError(t, json.Unmarshal([]byte(`{"level":"info"}`), c))
+	z, closer, err := New(c)
+	require.NoError(t, err)
+	defer closer()
+	require.False(t, z.Core().Enabled(zap.DebugLevel))
+
+	require.NoError(t, c.SetLevel("debug"))
+	require.True(t, z.Core().Enabled(zap.DebugLevel))
+	require.Error(t, c.SetLevel("invalid"))
+	require.True(t, z.Core().Enabled(zap.DebugLevel))
+}
@@ -3,45 +3,46 @@
 package logger
 
 import (
-	cores "github.com/Azure/azure-container-networking/cns/logger/v2/cores"
+	logv2 "github.com/Azure/azure-container-networking/log/v2"
+	cores "github.com/Azure/azure-container-networking/log/v2/cores"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
-type compoundCloser []func()
+// Components of CNS which log through a logger of their own, named with zap.Logger.Named,
+// and so can be given a Level of their own. The restserver only logs API access, requests and
+// responses through its logger; its other logs go through the legacy logger and ignore its Level.
+const (
+	ComponentIPAMPool               = "ipampool"
+	ComponentRestServer             = "restserver"
+	ComponentNodeNetworkConfig      = "nodenetworkconfig"
+	ComponentOverlayExtensionConfig = "overlayextensionconfig"
+	ComponentNICHotPlug             = "nichotplug"
+	ComponentRoutes                 = "routes"
+	ComponentIPAudit                = "ipaudit"
+)
 
-func (c compoundCloser) Close() {
-	for _, closer := range c {
-		closer()
-	}
-}
+// Components lists every component of CNS which can be given a Level of its own.
+var Components = []string{ComponentIPAMPool, ComponentRestServer, ComponentNodeNetworkConfig, ComponentOverlayExtensionConfig, ComponentNICHotPlug, ComponentRoutes, ComponentIPAudit}
 
-// New creates a v2 CNS logger built with Zap.
+// New creates a v2 CNS logger from the shared log/v2 pipeline, always logging to stdout.
 func New(cfg *Config) (*zap.Logger, func(), error) {
 	cfg.Normalize()
-	core := cores.StdoutCore(cfg.level)
-	closer := compoundCloser{}
-	if cfg.File != nil {
-		fileCore, fileCloser, err := cores.FileCore(cfg.File)
-		closer = append(closer, fileCloser)
-		if err != nil {
-			return nil, closer.Close, err //nolint:wrapcheck // it's an internal pkg
-		}
-		core = zapcore.NewTee(core, fileCore)
-	}
-	if cfg.AppInsights != nil {
-		aiCore, aiCloser, err := cores.ApplicationInsightsCore(cfg.AppInsights)
-		closer = append(closer, aiCloser)
-		if err != nil {
-			return nil, closer.Close, err //nolint:wrapcheck // it's an internal pkg
-		}
-		core = zapcore.NewTee(core, aiCore)
+	shared := &logv2.Config{
+		Level:       cfg.Level,
+		Components:  cfg.Components,
+		Stdout:      &cores.StdoutConfig{},
+		File:        cfg.File,
+		AppInsights: cfg.AppInsights,
+		OTLP:        cfg.OTLP,
 	}
-	platformCore, platformCloser, err := platformCore(cfg)
-	closer = append(closer, platformCloser)
+	platformConfig(cfg, shared)
+	z, closer, err := logv2.New(shared)
 	if err != nil {
-		return nil, closer.Close, err
+		return nil, closer, err //nolint:wrapcheck // it's an internal pkg
 	}
-	core = zapcore.NewTee(core, platformCore)
-	return zap.New(core), closer.Close, nil
+	cfg.built = shared
+	// stamp the trace context on every line logged with a Context field
+	z = z.WithOptions(zap.WrapCore(func(c zapcore.Core) zapcore.Core { return contextCore{c} }))
+	return z, closer, nil
 }
@@ -1,10 +1,8 @@
 package logger
 
 import (
-	"go.uber.org/zap/zapcore"
+	logv2 "github.com/Azure/azure-container-networking/log/v2"
 )
 
-// On Linux, platformCore returns a no-op core.
-func platformCore(*Config) (zapcore.Core, func(), error) {
-	return zapcore.NewNopCore(), func() {}, nil
-}
+// On Linux, there are no platform cores to configure.
+func platformConfig(*Config, *logv2.Config) {}
@@ -1,14 +1,10 @@
 package logger
 
 import (
-	cores "github.com/Azure/azure-container-networking/cns/logger/v2/cores"
-	"go.uber.org/zap/zapcore"
+	logv2 "github.com/Azure/azure-container-networking/log/v2"
 )
 
-// On Windows, platformCore returns a zapcore.Core that sends logs to ETW.
-func platformCore(cfg *Config) (zapcore.Core, func(), error) {
-	if cfg.ETW == nil {
-		return zapcore.NewNopCore(), func() {}, nil
-	}
-	return cores.ETWCore(cfg.ETW) //nolint:wrapcheck // ignore
+// On Windows, platformConfig sends logs to ETW.
+func platformConfig(cfg *Config, shared *logv2.Config) {
+	shared.ETW = cfg.ETW
 }
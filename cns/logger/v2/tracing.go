@@ -0,0 +1,213 @@
+package logger
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	cores "github.com/Azure/azure-container-networking/log/v2/cores"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const tracingShutdownTimeout = 5 * time.Second
+
+// TracingConfig configures export of traces to an OTLP/HTTP collector.
+type TracingConfig struct {
+	// Endpoint is the host:port of the OTLP/HTTP collector. Defaults to the OTEL_EXPORTER_OTLP_ENDPOINT environment variable or localhost:4318.
+	Endpoint string `json:"endpoint"`
+	// Insecure sends traces over plain HTTP instead of HTTPS.
+	Insecure bool `json:"insecure"`
+	// Headers are added to every export request, for example to authenticate with the collector.
+	Headers     map[string]string `json:"headers"`
+	ServiceName string            `json:"serviceName"`
+	// SampleRatio is the fraction of traces started by CNS which are sampled. Traces continued
+	// from a caller follow the caller's sampling decision. Defaults to 1.
+	SampleRatio float64 `json:"sampleRatio"`
+}
+
+// NewTracerProvider builds the OTLP tracing pipeline and installs it as the global TracerProvider
+// along with W3C trace context propagation, which HTTPHandler and HTTPTransport use.
+// The returned function flushes and stops the pipeline.
+func NewTracerProvider(cfg *TracingConfig) (func(), error) {
+	var opts []otlptracehttp.Option
+	if cfg.Endpoint != "" {
+		opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+	}
+	if cfg.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	if len(cfg.Headers) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(cfg.Headers))
+	}
+	exporter, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		return func() {}, errors.Wrap(err, "failed to create OTLP trace exporter")
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(cores.ServiceResource(cfg.ServiceName)),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+		_ = provider.Shutdown(ctx)
+	}, nil
+}
+
+// HTTPHandler starts a span for every request served by next, continuing the trace of the caller if
+// it sent one. The span is available from the request context to handlers and the clients they call.
+func HTTPHandler(next http.Handler) http.Handler {
+	return otelhttp.NewHandler(next, "cns", otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+		return r.Method + " " + r.URL.Path
+	}))
+}
+
+// AccessLog logs every request served by next at debug level, with the trace context of the
+// request if it has one.
+func AccessLog(z *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ce := z.Check(zapcore.DebugLevel, "served request")
+			if ce == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+			fields := append([]zap.Field{
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.Path),
+				zap.String("remote", r.RemoteAddr),
+				zap.Int("status", rec.status),
+				zap.Duration("duration", time.Since(start)),
+			}, TraceFields(r.Context())...)
+			ce.Write(fields...)
+		})
+	}
+}
+
+// statusRecorder records the status code written to a ResponseWriter.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// HTTPTransport records a span for every request sent through rt and propagates the trace context
+// of the request to the server. A nil rt wraps http.DefaultTransport.
+func HTTPTransport(rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return otelhttp.NewTransport(rt)
+}
+
+// contextKey is the key of the field carrying the context of a log line.
+const contextKey = "ctx"
+
+// Context returns a field carrying ctx, which encoders skip. The trace and span IDs of the span in ctx are
+// stamped on every line logged with it by the loggers built by New, and the OTLP core correlates the record
+// with the span natively.
+func Context(ctx context.Context) zap.Field {
+	return zap.Field{Key: contextKey, Type: zapcore.SkipType, Interface: ctx}
+}
+
+// TraceFields returns the trace and span IDs of the span in ctx as log fields along with the Context field, or
+// nothing if ctx has no span.
+func TraceFields(ctx context.Context) []zap.Field {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	return []zap.Field{
+		zap.String("trace_id", sc.TraceID().String()),
+		zap.String("span_id", sc.SpanID().String()),
+		Context(ctx),
+	}
+}
+
+// WithTraceContext returns z with the trace context of ctx stamped on every line it logs.
+func WithTraceContext(ctx context.Context, z *zap.Logger) *zap.Logger {
+	fields := TraceFields(ctx)
+	if len(fields) == 0 {
+		return z
+	}
+	return z.With(fields...)
+}
+
+// contextCore stamps the trace and span IDs of the span in the Context field of a line, or of the logger it is
+// logged with, on the line.
+type contextCore struct {
+	zapcore.Core
+}
+
+func (c contextCore) With(fields []zapcore.Field) zapcore.Core {
+	return contextCore{c.Core.With(withTraceFields(fields))}
+}
+
+func (c contextCore) Check(e zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(e.Level) {
+		return ce
+	}
+	return ce.AddCore(e, c)
+}
+
+// Write passes the line with the trace fields to the cores of the wrapped core which log its level.
+func (c contextCore) Write(e zapcore.Entry, fields []zapcore.Field) error {
+	if ce := c.Core.Check(e, nil); ce != nil {
+		ce.Write(withTraceFields(fields)...)
+	}
+	return nil
+}
+
+// withTraceFields adds the trace and span IDs of the span in the Context field to fields, unless they have them.
+func withTraceFields(fields []zapcore.Field) []zapcore.Field {
+	var ctx context.Context
+	for i := range fields {
+		switch {
+		case fields[i].Key == "trace_id":
+			return fields
+		case fields[i].Key == contextKey && fields[i].Type == zapcore.SkipType:
+			if c, ok := fields[i].Interface.(context.Context); ok {
+				ctx = c
+			}
+		}
+	}
+	if ctx == nil {
+		return fields
+	}
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return fields
+	}
+	return append(fields[:len(fields):len(fields)],
+		zap.String("trace_id", sc.TraceID().String()),
+		zap.String("span_id", sc.SpanID().String()))
+}
+
+// TraceIDs returns the trace and span IDs of the span in ctx formatted for the legacy logger, or an empty string if
+// ctx has no span.
+func TraceIDs(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return ""
+	}
+	return "trace_id=" + sc.TraceID().String() + " span_id=" + sc.SpanID().String()
+}
@@ -0,0 +1,179 @@
+package logger
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	collogs "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	coltrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/protobuf/proto"
+)
+
+// collector is an in-process stand-in for an OTLP/HTTP collector which keeps everything exported to it.
+type collector struct {
+	*httptest.Server
+	mu     sync.Mutex
+	logs   []*collogs.ExportLogsServiceRequest
+	traces []*coltrace.ExportTraceServiceRequest
+}
+
+func newCollector(t *testing.T) *collector {
+	c := &collector{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/logs", func(w http.ResponseWriter, r *http.Request) {
+		req := &collogs.ExportLogsServiceRequest{}
+		c.decode(t, w, r, req)
+		c.mu.Lock()
+		c.logs = append(c.logs, req)
+		c.mu.Unlock()
+	})
+	mux.HandleFunc("/v1/traces", func(w http.ResponseWriter, r *http.Request) {
+		req := &coltrace.ExportTraceServiceRequest{}
+		c.decode(t, w, r, req)
+		c.mu.Lock()
+		c.traces = append(c.traces, req)
+		c.mu.Unlock()
+	})
+	c.Server = httptest.NewServer(mux)
+	t.Cleanup(c.Close)
+	return c
+}
+
+func (c *collector) decode(t *testing.T, w http.ResponseWriter, r *http.Request, m proto.Message) {
+	b, err := io.ReadAll(r.Body)
+	require.NoError(t, err)
+	require.NoError(t, proto.Unmarshal(b, m))
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	w.WriteHeader(http.StatusOK)
+}
+
+func (c *collector) endpoint() string {
+	return strings.TrimPrefix(c.URL, "http://")
+}
+
+// logTraceIDs returns the trace ID of every exported log record by its body.
+func (c *collector) logTraceIDs() map[string]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ids := map[string]string{}
+	for _, req := range c.logs {
+		for _, rl := range req.GetResourceLogs() {
+			for _, sl := range rl.GetScopeLogs() {
+				for _, lr := range sl.GetLogRecords() {
+					ids[lr.GetBody().GetStringValue()] = hex.EncodeToString(lr.GetTraceId())
+				}
+			}
+		}
+	}
+	return ids
+}
+
+// spanTraceIDs returns the trace ID of every exported span by its name.
+func (c *collector) spanTraceIDs() map[string]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ids := map[string]string{}
+	for _, req := range c.traces {
+		for _, rs := range req.GetResourceSpans() {
+			for _, ss := range rs.GetScopeSpans() {
+				for _, span := range ss.GetSpans() {
+					ids[span.GetName()] = hex.EncodeToString(span.GetTraceId())
+				}
+			}
+		}
+	}
+	return ids
+}
+
+func TestTraceContextPropagation(t *testing.T) {
+	c := newCollector(t)
+
+	cfg := &Config{}
+	require.NoError(t, json.Unmarshal([]byte(`{"level":"info","otlp":{"level":"info","endpoint":"`+c.endpoint()+`","insecure":true}}`), cfg))
+	z, closeLogger, err := New(cfg)
+	require.NoError(t, err)
+	shutdownTracing, err := NewTracerProvider(&TracingConfig{Endpoint: c.endpoint(), Insecure: true, SampleRatio: 1})
+	require.NoError(t, err)
+
+	// a downstream dependency, such as nmagent, which records the trace context it was sent
+	var traceparent string
+	downstream := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+	}))
+	defer downstream.Close()
+	client := &http.Client{Transport: HTTPTransport(nil)}
+
+	api := httptest.NewServer(HTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		WithTraceContext(r.Context(), z).Info("handling request")
+		req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, downstream.URL, http.NoBody)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+	})))
+	defer api.Close()
+
+	resp, err := http.Post(api.URL+"/network/requestipconfigs", "application/json", http.NoBody) //nolint:noctx // test request
+	require.NoError(t, err)
+	resp.Body.Close()
+	z.Info("outside of a request")
+
+	// flush both pipelines to the collector
+	closeLogger()
+	shutdownTracing()
+
+	spans := c.spanTraceIDs()
+	traceID := spans["POST /network/requestipconfigs"]
+	require.NotEmpty(t, traceID)
+	require.Equal(t, traceID, spans["HTTP GET"], "client span should be part of the request trace")
+	require.Contains(t, traceparent, traceID, "trace context should be propagated downstream")
+
+	logs := c.logTraceIDs()
+	require.Equal(t, traceID, logs["handling request"])
+	require.Contains(t, logs, "outside of a request")
+	require.Empty(t, logs["outside of a request"])
+}
+
+func TestTraceFields(t *testing.T) {
+	require.Empty(t, TraceFields(context.Background()))
+	z := zap.NewNop()
+	require.Same(t, z, WithTraceContext(context.Background(), z))
+}
+
+func TestContextCore(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	z := zap.New(contextCore{core})
+	shutdownTracing, err := NewTracerProvider(&TracingConfig{Endpoint: "localhost:0", Insecure: true, SampleRatio: 1})
+	require.NoError(t, err)
+	defer shutdownTracing()
+	ctx, span := otel.Tracer("test").Start(context.Background(), "request")
+	defer span.End()
+	traceID := span.SpanContext().TraceID().String()
+
+	// the trace context is stamped on lines logged with the Context field, by the line or by the logger
+	z.Info("line", Context(ctx))
+	z.With(Context(ctx)).Named("component").Info("logger")
+	z.Info("no context", Context(context.Background()))
+	z.Debug("disabled", Context(ctx))
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 3)
+	require.Equal(t, traceID, entries[0].ContextMap()["trace_id"])
+	require.Equal(t, traceID, entries[1].ContextMap()["trace_id"])
+	require.NotContains(t, entries[2].ContextMap(), "trace_id")
+
+	require.Contains(t, TraceIDs(ctx), "trace_id="+traceID)
+	require.Empty(t, TraceIDs(context.Background()))
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/Azure/azure-container-networking/crd/multitenancy/api/v1alpha1"
 	"github.com/pkg/errors"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	k8stypes "k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -31,6 +32,16 @@ const (
 
 type K8sSWIFTv2Middleware struct {
 	Cli client.Client
+	// Reservations, if set, limits the pods of each PodNetworkInstance to the pod slots it reserves.
+	Reservations *PNIReservations
+	// PodReader lists the pods of a PodNetworkInstance across the cluster to count the slots they use, as the cache
+	// of Cli only holds the pods of this Node. It must index the pods by PodNetworkInstanceIndex, and Cli is used
+	// if it is not set.
+	PodReader client.Reader
+	// InfraGatewayV4 and InfraGatewayV6 are the gateways on the infra NIC of the host through which HostRoutes
+	// routes the infra VNET and service CIDRs of each family.
+	InfraGatewayV4 string
+	InfraGatewayV6 string
 }
 
 // Verify interface compliance at compile time
@@ -49,6 +60,11 @@ func (k *K8sSWIFTv2Middleware) GetPodInfoForIPConfigsRequest(ctx context.Context
 	var mtpnc v1alpha1.MultitenantPodNetworkConfig
 	// if swiftv2 is enabled, get mtpnc
 	if isSwiftv2 {
+		respCode, message = k.reservePNISlot(ctx, &pod)
+		if respCode != types.Success {
+			return nil, respCode, message
+		}
+
 		mtpnc, respCode, message = k.getMTPNC(ctx, podInfo)
 		if respCode != types.Success {
 			return nil, respCode, message
@@ -199,6 +215,72 @@ func (k *K8sSWIFTv2Middleware) getMTPNC(ctx context.Context, podInfo cns.PodInfo
 	return mtpnc, types.Success, ""
 }
 
+// PodNetworkInstanceIndex is the field index of pods by the PodNetworkInstance they belong to.
+const PodNetworkInstanceIndex = "metadata.labels.podNetworkInstance"
+
+// PodNetworkInstanceIndexer indexes a pod by the name of its PodNetworkInstance.
+func PodNetworkInstanceIndexer(o client.Object) []string {
+	if name := o.GetLabels()[configuration.LabelPodNetworkInstanceSwiftV2]; name != "" {
+		return []string{name}
+	}
+	return nil
+}
+
+// reservePNISlot admits the pod against the reservation of its PodNetworkInstance, if it has one which reserves IPs.
+// The reservation is shared by the scheduled pods of the PodNetworkInstance on all Nodes, whether they have IPs yet
+// or not.
+func (k *K8sSWIFTv2Middleware) reservePNISlot(ctx context.Context, pod *v1.Pod) (respCode types.ResponseCode, message string) {
+	name := pod.Labels[configuration.LabelPodNetworkInstanceSwiftV2]
+	if k.Reservations == nil || name == "" {
+		return types.Success, ""
+	}
+	key := k8stypes.NamespacedName{Namespace: pod.Namespace, Name: name}
+	pni := v1alpha1.PodNetworkInstance{}
+	if err := k.Cli.Get(ctx, key, &pni); err != nil {
+		if apierrors.IsNotFound(err) {
+			// without a PodNetworkInstance there is nothing reserved to enforce, and the mtpnc will not be ready
+			k.Reservations.forget(key)
+			return types.Success, ""
+		}
+		return types.UnexpectedError, fmt.Errorf("failed to get pod's pni from cache : %w", err).Error()
+	}
+	reserved := pni.ReservedPodSlots()
+	if reserved == 0 {
+		k.Reservations.forget(key)
+		return types.Success, ""
+	}
+
+	reader := k.PodReader
+	if reader == nil {
+		reader = k.Cli
+	}
+	podList := v1.PodList{}
+	if err := reader.List(ctx, &podList, client.InNamespace(pod.Namespace), client.MatchingFields{PodNetworkInstanceIndex: name}); err != nil {
+		return types.UnexpectedError, fmt.Errorf("failed to list pods of pni %s : %w", key, err).Error()
+	}
+	podKey := pod.Namespace + "/" + pod.Name
+	pods := make([]pniPod, 0, len(podList.Items)+1)
+	listed := false
+	for i := range podList.Items {
+		p := &podList.Items[i]
+		if p.Status.Phase == v1.PodSucceeded || p.Status.Phase == v1.PodFailed || p.Spec.NodeName == "" {
+			continue
+		}
+		pk := p.Namespace + "/" + p.Name
+		listed = listed || pk == podKey
+		pods = append(pods, pniPod{key: pk, hasIPs: len(p.Status.PodIPs) > 0, created: p.CreationTimestamp.Time})
+	}
+	if !listed {
+		pods = append(pods, pniPod{key: podKey, created: pod.CreationTimestamp.Time})
+	}
+	if !k.Reservations.admit(key, reserved, podKey, pods) {
+		logger.Printf("[SWIFTv2Middleware] rejecting pod %s, all %d pod slots reserved for pni %s are used", podKey, reserved, key)
+		return types.PodNetworkInstanceReservationExhausted,
+			fmt.Sprintf("all %d pod slots reserved for PodNetworkInstance %s are used", reserved, key)
+	}
+	return types.Success, ""
+}
+
 // Updates Ip Config Request
 func (k *K8sSWIFTv2Middleware) UpdateIPConfigRequest(mtpnc v1alpha1.MultitenantPodNetworkConfig, req *cns.IPConfigsRequest) (
 	respCode types.ResponseCode,
@@ -238,6 +320,27 @@ func (k *K8sSWIFTv2Middleware) AddRoutes(cidrs []string, gatewayIP string) []cns
 	return routes
 }
 
+// HostRoutes returns the routes the host needs for SWIFT v2: the infra VNET and service CIDRs through the infra
+// gateway of their family, so that they are not routed through a delegated NIC. Families without a gateway are
+// left to the routing table of the host.
+func (k *K8sSWIFTv2Middleware) HostRoutes() ([]cns.Route, error) {
+	if k.InfraGatewayV4 == "" && k.InfraGatewayV6 == "" {
+		return nil, nil
+	}
+	v4Cidrs, v6Cidrs, err := k.GetInfravnetAndServiceCidrs()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get infravnet and service CIDRs")
+	}
+	var routes []cns.Route
+	if k.InfraGatewayV4 != "" {
+		routes = append(routes, k.AddRoutes(v4Cidrs, k.InfraGatewayV4)...)
+	}
+	if k.InfraGatewayV6 != "" {
+		routes = append(routes, k.AddRoutes(v6Cidrs, k.InfraGatewayV6)...)
+	}
+	return routes, nil
+}
+
 // Both Linux and Windows CNS gets infravnet and service CIDRs from configuration env
 // GetInfravnetAndServiceCidrs() returns v4CIDRs(infravnet and service cidrs) as first []string and v6CIDRs(infravnet and service) as second []string
 func (k *K8sSWIFTv2Middleware) GetInfravnetAndServiceCidrs() ([]string, []string, error) { //nolint
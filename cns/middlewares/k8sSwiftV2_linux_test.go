@@ -482,3 +482,22 @@ func TestAssignSubnetPrefixSuccess(t *testing.T) {
 	assert.Equal(t, ipInfo.HostPrimaryIPInfo.Gateway, "")
 	assert.Equal(t, ipInfo.HostPrimaryIPInfo.Subnet, "")
 }
+
+func TestHostRoutes(t *testing.T) {
+	t.Setenv(configuration.EnvServiceCIDRs, "10.0.0.0/16,16A0:0010:AB00:0000::/32")
+	t.Setenv(configuration.EnvInfraVNETCIDRs, "10.240.0.0/16,16A0:0020:AB00:0000::/32")
+
+	// nothing is routed on the host without a gateway
+	middleware := K8sSWIFTv2Middleware{Cli: mock.NewClient()}
+	routes, err := middleware.HostRoutes()
+	assert.NilError(t, err)
+	assert.Equal(t, len(routes), 0)
+
+	middleware.InfraGatewayV4 = "10.224.0.1"
+	routes, err = middleware.HostRoutes()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, routes, []cns.Route{
+		{IPAddress: "10.240.0.0/16", GatewayIPAddress: "10.224.0.1"},
+		{IPAddress: "10.0.0.0/16", GatewayIPAddress: "10.224.0.1"},
+	})
+}
@@ -0,0 +1,38 @@
+package middlewares
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	pniReservedSlots = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "pni_reserved_slots",
+			Help: "Pod slots reserved for the PodNetworkInstance.",
+		},
+		[]string{"namespace", "pni"},
+	)
+	pniUsedSlots = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "pni_used_slots",
+			Help: "Reserved pod slots of the PodNetworkInstance used by its pods.",
+		},
+		[]string{"namespace", "pni"},
+	)
+	pniRejectedPods = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "pni_rejected_pods_total",
+			Help: "Pods rejected because the reservation of the PodNetworkInstance was used up.",
+		},
+		[]string{"namespace", "pni"},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		pniReservedSlots,
+		pniUsedSlots,
+		pniRejectedPods,
+	)
+}
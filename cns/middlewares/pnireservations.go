@@ -0,0 +1,118 @@
+package middlewares
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+)
+
+// PNIReservations tracks how many of the pod slots reserved for each PodNetworkInstance are used by its pods, and
+// admits a pod only while its PodNetworkInstance has a slot left for it.
+type PNIReservations struct {
+	mu    sync.Mutex
+	usage map[k8stypes.NamespacedName]*pniUsage
+}
+
+type pniUsage struct {
+	reserved int
+	pods     map[string]struct{}
+}
+
+// pniPod is a scheduled pod of a PodNetworkInstance, as counted against its reservation.
+type pniPod struct {
+	key     string
+	hasIPs  bool
+	created time.Time
+}
+
+func NewPNIReservations() *PNIReservations {
+	return &PNIReservations{
+		usage: map[k8stypes.NamespacedName]*pniUsage{},
+	}
+}
+
+// admit returns whether the pod holds one of the slots reserved for its PodNetworkInstance. The slots are recounted
+// from the scheduled pods of the PodNetworkInstance on all Nodes on every admission, as CNS does not see the pods
+// being released. They are handed out in an order every CNS agrees on as long as their caches do: first to the pods
+// which have IPs or were admitted here, then to the oldest pods. A pending pod on another Node thus holds its slot
+// before its own CNS admits it, and two Nodes cannot both admit a pod into the last slot.
+func (r *PNIReservations) admit(pni k8stypes.NamespacedName, reserved int, pod string, pods []pniPod) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.usage[pni]
+	if !ok {
+		u = &pniUsage{}
+		r.usage[pni] = u
+	}
+	held := func(p *pniPod) bool {
+		_, admitted := u.pods[p.key]
+		return p.hasIPs || admitted
+	}
+	sort.SliceStable(pods, func(i, j int) bool {
+		if hi, hj := held(&pods[i]), held(&pods[j]); hi != hj {
+			return hi
+		}
+		if !pods[i].created.Equal(pods[j].created) {
+			return pods[i].created.Before(pods[j].created)
+		}
+		return pods[i].key < pods[j].key
+	})
+	used := map[string]struct{}{}
+	for i := range pods {
+		if len(used) == reserved {
+			break
+		}
+		used[pods[i].key] = struct{}{}
+	}
+	u.reserved, u.pods = reserved, used
+	pniReservedSlots.WithLabelValues(pni.Namespace, pni.Name).Set(float64(u.reserved))
+	pniUsedSlots.WithLabelValues(pni.Namespace, pni.Name).Set(float64(len(u.pods)))
+	if _, ok := used[pod]; ok {
+		return true
+	}
+	pniRejectedPods.WithLabelValues(pni.Namespace, pni.Name).Inc()
+	return false
+}
+
+// forget stops tracking a PodNetworkInstance which no longer exists or reserves no IPs.
+func (r *PNIReservations) forget(pni k8stypes.NamespacedName) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.usage[pni]; !ok {
+		return
+	}
+	delete(r.usage, pni)
+	pniReservedSlots.DeleteLabelValues(pni.Namespace, pni.Name)
+	pniUsedSlots.DeleteLabelValues(pni.Namespace, pni.Name)
+}
+
+// Usage returns the usage of the reservation of each tracked PodNetworkInstance.
+func (r *PNIReservations) Usage() []cns.PNIReservation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	reservations := make([]cns.PNIReservation, 0, len(r.usage))
+	for pni, u := range r.usage {
+		pods := make([]string, 0, len(u.pods))
+		for pod := range u.pods {
+			pods = append(pods, pod)
+		}
+		sort.Strings(pods)
+		reservations = append(reservations, cns.PNIReservation{
+			Namespace: pni.Namespace,
+			Name:      pni.Name,
+			Reserved:  u.reserved,
+			Used:      len(u.pods),
+			Pods:      pods,
+		})
+	}
+	sort.Slice(reservations, func(i, j int) bool {
+		if reservations[i].Namespace != reservations[j].Namespace {
+			return reservations[i].Namespace < reservations[j].Namespace
+		}
+		return reservations[i].Name < reservations[j].Name
+	})
+	return reservations
+}
@@ -0,0 +1,104 @@
+package middlewares
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/configuration"
+	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/Azure/azure-container-networking/crd/multitenancy/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newPNIPod(name, node, pni string, hasIPs bool, created time.Time) *v1.Pod {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "default",
+			Labels:            map[string]string{configuration.LabelPodNetworkInstanceSwiftV2: pni},
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: v1.PodSpec{NodeName: node},
+	}
+	if hasIPs {
+		pod.Status.PodIPs = []v1.PodIP{{IP: "10.0.0.1"}}
+	}
+	return pod
+}
+
+func TestReservePNISlot(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+	reserved := &v1alpha1.PodNetworkInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "reserved", Namespace: "default"},
+		Spec: v1alpha1.PodNetworkInstanceSpec{
+			PodNetworkConfigs: []v1alpha1.PodNetworkConfig{
+				{PodNetwork: "a", PodIPReservationSize: 4},
+				{PodNetwork: "b", PodIPReservationSize: 3},
+				{PodNetwork: "c"},
+			},
+		},
+	}
+	unreserved := &v1alpha1.PodNetworkInstance{ObjectMeta: metav1.ObjectMeta{Name: "unreserved", Namespace: "default"}}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(i int) time.Time { return start.Add(time.Duration(i) * time.Minute) }
+	running := newPNIPod("running", "node1", "reserved", true, at(0))
+	pods := []*v1.Pod{
+		running,
+		newPNIPod("other-node", "node2", "reserved", true, at(1)),
+		newPNIPod("pending-other-node", "node2", "reserved", false, at(2)),
+		newPNIPod("first", "node1", "reserved", false, at(3)),
+		newPNIPod("second", "node1", "reserved", false, at(4)),
+		newPNIPod("free", "node1", "unreserved", false, at(5)),
+		// not scheduled yet
+		newPNIPod("unscheduled", "", "reserved", false, at(0)),
+	}
+	objs := []client.Object{reserved, unreserved}
+	for _, pod := range pods {
+		objs = append(objs, pod)
+	}
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).
+		WithIndex(&v1.Pod{}, PodNetworkInstanceIndex, PodNetworkInstanceIndexer).
+		Build()
+	k := &K8sSWIFTv2Middleware{Cli: cli, Reservations: NewPNIReservations()}
+	reserve := func(k *K8sSWIFTv2Middleware, pod *v1.Pod) types.ResponseCode {
+		code, _ := k.reservePNISlot(context.Background(), pod)
+		return code
+	}
+
+	// the running pods use two of the three slots and the older pending pod on the other node holds the last one
+	assert.Equal(t, types.PodNetworkInstanceReservationExhausted, reserve(k, pods[3]))
+	assert.Equal(t, []cns.PNIReservation{
+		{Namespace: "default", Name: "reserved", Reserved: 3, Used: 3, Pods: []string{"default/other-node", "default/pending-other-node", "default/running"}},
+	}, k.Reservations.Usage())
+
+	// the slot of a deleted pod is freed for the oldest pending pod
+	require.NoError(t, cli.Delete(context.Background(), running))
+	assert.Equal(t, types.PodNetworkInstanceReservationExhausted, reserve(k, pods[4]))
+	assert.Equal(t, types.Success, reserve(k, pods[3]))
+	// a pod which was admitted keeps its slot when it asks again
+	assert.Equal(t, types.Success, reserve(k, pods[3]))
+
+	// the CNS of the other node agrees on which pods hold the slots, so the last slot is not admitted twice
+	other := &K8sSWIFTv2Middleware{Cli: cli, Reservations: NewPNIReservations()}
+	assert.Equal(t, types.Success, reserve(other, pods[2]))
+	assert.Equal(t, types.PodNetworkInstanceReservationExhausted, reserve(other, pods[4]))
+
+	// a PodNetworkInstance which reserves nothing is not enforced
+	assert.Equal(t, types.Success, reserve(k, pods[5]))
+	assert.Len(t, k.Reservations.Usage(), 1)
+
+	// without Reservations nothing is enforced
+	k.Reservations = nil
+	assert.Equal(t, types.Success, reserve(k, newPNIPod("third", "node1", "reserved", false, at(6))))
+}
@@ -0,0 +1,346 @@
+// Package nichotplug reacts to NICs being added to or removed from the VM at runtime, keeping the SWIFT v2 device
+// plugin counts in step with the NICs actually present and draining the pods bound to a NIC which disappeared.
+package nichotplug
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns/configuration"
+	"github.com/Azure/azure-container-networking/crd/multitenancy/api/v1alpha1"
+	"github.com/Azure/azure-container-networking/netlink"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const defaultScanInterval = time.Minute
+
+type interfaceLister interface {
+	GetNetworkInterfaces() ([]net.Interface, error)
+}
+
+type deviceTracker interface {
+	TrackDevices(deviceType v1alpha1.DeviceType, count int)
+}
+
+type pnpIDRefresher interface {
+	RefreshPnpIDMacAddressMapping(ctx context.Context) error
+}
+
+// Reconciler matches the NICs on the host by MAC address against the devices listed in the NodeInfo Status.
+// The host is scanned periodically, and whenever a link is added or removed when netlink events are available.
+type Reconciler struct {
+	cli          client.Client
+	nodeName     string
+	ifaces       interfaceLister
+	events       netlink.EventSubscriber
+	devices      deviceTracker
+	pnp          pnpIDRefresher
+	scanInterval time.Duration
+	z            *zap.Logger
+
+	// present are the devices of the NodeInfo found on the host or in the netns of their pod at the last reconcile,
+	// by normalized MAC.
+	present map[string]v1alpha1.DeviceType
+	// missing are the MACs of the devices present at the previous reconcile which were not found at the last one.
+	// A device is only removed once it is still missing at the next reconcile, as moving a NIC into the netns of
+	// its pod briefly hides it from the host.
+	missing map[string]struct{}
+	// draining are the MACs of the devices which disappeared, whose pods are drained until none are left.
+	draining map[string]struct{}
+}
+
+type Option func(*Reconciler)
+
+// WithEvents rescans the host on the link events of the subscriber, in addition to the periodic scans.
+func WithEvents(events netlink.EventSubscriber) Option {
+	return func(r *Reconciler) {
+		r.events = events
+	}
+}
+
+// WithPnpIDRefresher refreshes the PnP IDs of the NICs whenever a NIC is added or removed.
+func WithPnpIDRefresher(pnp pnpIDRefresher) Option {
+	return func(r *Reconciler) {
+		r.pnp = pnp
+	}
+}
+
+// WithScanInterval sets how often the host is scanned.
+func WithScanInterval(d time.Duration) Option {
+	return func(r *Reconciler) {
+		r.scanInterval = d
+	}
+}
+
+func New(z *zap.Logger, cli client.Client, nodeName string, ifaces interfaceLister, devices deviceTracker, opts ...Option) *Reconciler {
+	r := &Reconciler{
+		cli:          cli,
+		nodeName:     nodeName,
+		ifaces:       ifaces,
+		devices:      devices,
+		scanInterval: defaultScanInterval,
+		z:            z,
+		missing:      map[string]struct{}{},
+		draining:     map[string]struct{}{},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Run reconciles until the context is cancelled. Failures are logged and retried at the next scan.
+func (r *Reconciler) Run(ctx context.Context) {
+	var events <-chan netlink.Event
+	if r.events != nil {
+		var err error
+		if events, err = r.events.Subscribe(ctx, netlink.GroupLink); err != nil {
+			r.z.Warn("failed to subscribe to link events, only scanning periodically", zap.Error(err))
+			events = nil
+		}
+	}
+	ticker := time.NewTicker(r.scanInterval)
+	defer ticker.Stop()
+	for {
+		if err := r.reconcile(ctx); err != nil {
+			r.z.Error("failed to reconcile NICs", zap.Error(err))
+		}
+		if !r.wait(ctx, ticker.C, &events) {
+			return
+		}
+	}
+}
+
+// wait blocks until the next scan is due, returning false once the context is cancelled.
+func (r *Reconciler) wait(ctx context.Context, tick <-chan time.Time, events *<-chan netlink.Event) bool {
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-tick:
+			return true
+		case e, ok := <-*events:
+			if !ok {
+				r.z.Warn("link events closed, only scanning periodically")
+				*events = nil
+				continue
+			}
+			if e.Type == netlink.EventLinkAdded || e.Type == netlink.EventLinkRemoved || e.Type == netlink.EventOverrun {
+				// a NIC arriving or leaving comes with a burst of events, which one scan covers
+				drain(*events)
+				return true
+			}
+		}
+	}
+}
+
+func drain(events <-chan netlink.Event) {
+	for {
+		select {
+		case _, ok := <-events:
+			if !ok {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (r *Reconciler) reconcile(ctx context.Context) error {
+	nodeInfo := &v1alpha1.NodeInfo{}
+	if err := r.cli.Get(ctx, types.NamespacedName{Name: r.nodeName}, nodeInfo); err != nil {
+		return errors.Wrapf(err, "failed to get nodeinfo %s", r.nodeName)
+	}
+	ifaces, err := r.ifaces.GetNetworkInterfaces()
+	if err != nil {
+		return errors.Wrap(err, "failed to list network interfaces")
+	}
+	pods, err := r.boundPods(ctx)
+	if err != nil {
+		return err
+	}
+	attached := make(map[string]struct{}, len(ifaces))
+	for i := range ifaces {
+		if len(ifaces[i].HardwareAddr) > 0 {
+			attached[normalizeMAC(ifaces[i].HardwareAddr.String())] = struct{}{}
+		}
+	}
+	// while the network of a pod is set up, the secondary endpoint client moves its NIC into the netns of the pod,
+	// where the host does not see it. This happens before kubelet publishes the IPs of the pod, so such a NIC is
+	// counted as attached for as long as the pod is not terminated. A NIC held by a pod is therefore only seen
+	// removed once it leaves the NodeInfo.
+	for i := range pods {
+		if phase := pods[i].pod.Status.Phase; phase == v1.PodSucceeded || phase == v1.PodFailed {
+			continue
+		}
+		for _, mac := range pods[i].macs {
+			attached[mac] = struct{}{}
+		}
+	}
+
+	present := map[string]v1alpha1.DeviceType{}
+	counts := map[v1alpha1.DeviceType]int{
+		v1alpha1.DeviceTypeVnetNIC:       0,
+		v1alpha1.DeviceTypeInfiniBandNIC: 0,
+	}
+	for _, device := range nodeInfo.Status.DeviceInfos {
+		mac := normalizeMAC(device.MacAddress)
+		if _, ok := attached[mac]; !ok {
+			continue
+		}
+		present[mac] = device.DeviceType
+	}
+
+	changed := r.present == nil
+	for mac, deviceType := range present {
+		if _, ok := r.present[mac]; !ok && r.present != nil {
+			r.z.Info("NIC added", zap.String("mac", mac), zap.String("deviceType", string(deviceType)))
+			changed = true
+		}
+		delete(r.missing, mac)
+		delete(r.draining, mac)
+	}
+	// only NICs seen on the host are drained when they disappear, as a NIC in the NodeInfo may still be arriving
+	missing := map[string]struct{}{}
+	for mac, deviceType := range r.present {
+		if _, ok := present[mac]; ok {
+			continue
+		}
+		if _, ok := r.missing[mac]; !ok {
+			// kept present until the next reconcile confirms it is gone
+			missing[mac] = struct{}{}
+			present[mac] = deviceType
+			continue
+		}
+		r.z.Info("NIC removed", zap.String("mac", mac), zap.String("deviceType", string(deviceType)))
+		r.draining[mac] = struct{}{}
+		changed = true
+	}
+	r.missing = missing
+	r.present = present
+
+	for _, deviceType := range present {
+		if _, ok := counts[deviceType]; ok {
+			counts[deviceType]++
+		}
+	}
+	for deviceType, count := range counts {
+		r.devices.TrackDevices(deviceType, count)
+	}
+
+	if changed && r.pnp != nil {
+		if err := r.pnp.RefreshPnpIDMacAddressMapping(ctx); err != nil {
+			r.z.Error("failed to refresh PnP IDs of the NICs", zap.Error(err))
+		}
+	}
+	if len(r.draining) == 0 {
+		return nil
+	}
+	return r.drainPods(ctx, pods)
+}
+
+// boundPod is a SWIFT v2 pod on the Node with the normalized MACs of the NICs its MultitenantPodNetworkConfig binds
+// it to.
+type boundPod struct {
+	pod  *v1.Pod
+	macs []string
+}
+
+// boundPods lists the SWIFT v2 pods on the Node which have a MultitenantPodNetworkConfig. It fails if any of them
+// cannot be read, as a NIC held by that pod would otherwise look removed.
+func (r *Reconciler) boundPods(ctx context.Context) ([]boundPod, error) {
+	pods := &v1.PodList{}
+	if err := r.cli.List(ctx, pods, client.MatchingFieldsSelector{Selector: fields.OneTermEqualSelector("spec.nodeName", r.nodeName)}); err != nil {
+		return nil, errors.Wrap(err, "failed to list pods on the node")
+	}
+	var bound []boundPod
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if !isSwiftV2(pod) {
+			continue
+		}
+		mtpnc := &v1alpha1.MultitenantPodNetworkConfig{}
+		if err := r.cli.Get(ctx, client.ObjectKeyFromObject(pod), mtpnc); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, errors.Wrapf(err, "failed to get mtpnc of pod %s/%s", pod.Namespace, pod.Name)
+		}
+		var macs []string
+		if mtpnc.Status.MacAddress != "" {
+			macs = append(macs, normalizeMAC(mtpnc.Status.MacAddress))
+		}
+		for j := range mtpnc.Status.InterfaceInfos {
+			if mac := mtpnc.Status.InterfaceInfos[j].MacAddress; mac != "" {
+				macs = append(macs, normalizeMAC(mac))
+			}
+		}
+		bound = append(bound, boundPod{pod: pod, macs: macs})
+	}
+	return bound, nil
+}
+
+// drainPods evicts the pods bound by their MultitenantPodNetworkConfig to a NIC which disappeared. A NIC stops
+// being drained once no pods are bound to it.
+func (r *Reconciler) drainPods(ctx context.Context, pods []boundPod) error {
+	bound := map[string]int{}
+	var errs []error
+	for i := range pods {
+		pod := pods[i].pod
+		if !pod.DeletionTimestamp.IsZero() {
+			continue
+		}
+		mac, ok := r.boundTo(pods[i].macs)
+		if !ok {
+			continue
+		}
+		bound[mac]++
+		r.z.Info("evicting pod bound to removed NIC", zap.String("pod", pod.Namespace+"/"+pod.Name), zap.String("mac", mac))
+		eviction := &policyv1.Eviction{ObjectMeta: metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace}}
+		if err := r.cli.SubResource("eviction").Create(ctx, pod, eviction); err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, errors.Wrapf(err, "failed to evict pod %s/%s", pod.Namespace, pod.Name))
+		}
+	}
+	for mac := range r.draining {
+		if bound[mac] == 0 {
+			delete(r.draining, mac)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Errorf("failed to drain pods of removed NICs: %v", errs)
+	}
+	return nil
+}
+
+// boundTo returns the MAC of the draining NIC, if any, among the MACs a pod is bound to.
+func (r *Reconciler) boundTo(macs []string) (string, bool) {
+	for _, mac := range macs {
+		if _, ok := r.draining[mac]; ok {
+			return mac, true
+		}
+	}
+	return "", false
+}
+
+func isSwiftV2(pod *v1.Pod) bool {
+	_, podNetwork := pod.Labels[configuration.LabelPodSwiftV2]
+	_, podNetworkInstance := pod.Labels[configuration.LabelPodNetworkInstanceSwiftV2]
+	return podNetwork || podNetworkInstance
+}
+
+// normalizeMAC returns the MAC in lower case without separators, as the NodeInfo, the MTPNC and the host do not
+// agree on a format.
+func normalizeMAC(mac string) string {
+	return strings.ToLower(strings.NewReplacer(":", "", "-", "").Replace(mac))
+}
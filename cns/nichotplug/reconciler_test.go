@@ -0,0 +1,293 @@
+package nichotplug
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns/configuration"
+	"github.com/Azure/azure-container-networking/crd/multitenancy"
+	"github.com/Azure/azure-container-networking/crd/multitenancy/api/v1alpha1"
+	"github.com/Azure/azure-container-networking/netlink"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	vnetMAC = "00:0d:3a:00:00:01"
+	ibMAC   = "00:0d:3a:00:00:02"
+)
+
+type fakeInterfaces struct {
+	mu   sync.Mutex
+	macs []string
+}
+
+func (f *fakeInterfaces) set(macs ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.macs = macs
+}
+
+func (f *fakeInterfaces) GetNetworkInterfaces() ([]net.Interface, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ifaces := []net.Interface{{Name: "lo"}}
+	for _, mac := range f.macs {
+		hw, err := net.ParseMAC(mac)
+		if err != nil {
+			return nil, err //nolint:wrapcheck // test
+		}
+		ifaces = append(ifaces, net.Interface{HardwareAddr: hw})
+	}
+	return ifaces, nil
+}
+
+type fakeTracker struct {
+	mu     sync.Mutex
+	counts map[v1alpha1.DeviceType]int
+	calls  int
+}
+
+func (f *fakeTracker) TrackDevices(deviceType v1alpha1.DeviceType, count int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.counts[deviceType] = count
+	f.calls++
+}
+
+func (f *fakeTracker) get() (counts map[v1alpha1.DeviceType]int, calls int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	counts = map[v1alpha1.DeviceType]int{}
+	for k, v := range f.counts {
+		counts[k] = v
+	}
+	return counts, f.calls
+}
+
+type fakePnp struct{ refreshes int }
+
+func (f *fakePnp) RefreshPnpIDMacAddressMapping(context.Context) error {
+	f.refreshes++
+	return nil
+}
+
+func newSwiftV2Pod(name, mac string) (*v1.Pod, *v1alpha1.MultitenantPodNetworkConfig) {
+	meta := metav1.ObjectMeta{Name: name, Namespace: "default"}
+	pod := &v1.Pod{ObjectMeta: meta, Spec: v1.PodSpec{NodeName: "node1"}}
+	pod.Labels = map[string]string{configuration.LabelPodSwiftV2: "pn"}
+	mtpnc := &v1alpha1.MultitenantPodNetworkConfig{
+		ObjectMeta: meta,
+		Status: v1alpha1.MultitenantPodNetworkConfigStatus{
+			InterfaceInfos: []v1alpha1.InterfaceInfo{{MacAddress: mac}},
+		},
+	}
+	return pod, mtpnc
+}
+
+func newTestReconciler(t *testing.T, objs ...client.Object) (*Reconciler, client.Client, *fakeInterfaces, *fakeTracker, *fakePnp) {
+	t.Helper()
+	nodeInfo := &v1alpha1.NodeInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+		Status: v1alpha1.NodeInfoStatus{
+			DeviceInfos: []v1alpha1.DeviceInfo{
+				{DeviceType: v1alpha1.DeviceTypeVnetNIC, MacAddress: "000D3A000001"},
+				{DeviceType: v1alpha1.DeviceTypeInfiniBandNIC, MacAddress: "000D3A000002"},
+				// not attached yet
+				{DeviceType: v1alpha1.DeviceTypeVnetNIC, MacAddress: "000D3A000003"},
+			},
+		},
+	}
+	cli := fake.NewClientBuilder().
+		WithScheme(multitenancy.Scheme).
+		WithObjects(append(objs, nodeInfo)...).
+		WithIndex(&v1.Pod{}, "spec.nodeName", func(o client.Object) []string {
+			return []string{o.(*v1.Pod).Spec.NodeName}
+		}).
+		Build()
+	ifaces := &fakeInterfaces{macs: []string{vnetMAC, ibMAC}}
+	tracker := &fakeTracker{counts: map[v1alpha1.DeviceType]int{}}
+	pnp := &fakePnp{}
+	return New(zap.NewNop(), cli, "node1", ifaces, tracker, WithPnpIDRefresher(pnp)), cli, ifaces, tracker, pnp
+}
+
+func podExists(t *testing.T, cli client.Client, pod *v1.Pod) bool {
+	t.Helper()
+	err := cli.Get(context.Background(), client.ObjectKeyFromObject(pod), &v1.Pod{})
+	if apierrors.IsNotFound(err) {
+		return false
+	}
+	require.NoError(t, err)
+	return true
+}
+
+func setDevices(t *testing.T, cli client.Client, devices ...v1alpha1.DeviceInfo) {
+	t.Helper()
+	nodeInfo := &v1alpha1.NodeInfo{}
+	require.NoError(t, cli.Get(context.Background(), client.ObjectKey{Name: "node1"}, nodeInfo))
+	nodeInfo.Status.DeviceInfos = devices
+	require.NoError(t, cli.Update(context.Background(), nodeInfo))
+}
+
+func TestReconcile(t *testing.T) {
+	vnetPod, vnetMTPNC := newSwiftV2Pod("vnet", "00-0D-3A-00-00-01")
+	ibPod, ibMTPNC := newSwiftV2Pod("ib", ibMAC)
+	r, cli, ifaces, tracker, pnp := newTestReconciler(t, vnetPod, vnetMTPNC, ibPod, ibMTPNC)
+
+	// only the devices on the host are counted
+	require.NoError(t, r.reconcile(context.Background()))
+	counts, _ := tracker.get()
+	assert.Equal(t, map[v1alpha1.DeviceType]int{v1alpha1.DeviceTypeVnetNIC: 1, v1alpha1.DeviceTypeInfiniBandNIC: 1}, counts)
+	assert.Equal(t, 1, pnp.refreshes)
+
+	// nothing changed
+	require.NoError(t, r.reconcile(context.Background()))
+	assert.Equal(t, 1, pnp.refreshes)
+
+	// the vnet NIC is removed from the VM, which is only trusted once it is still gone at the next scan
+	ifaces.set(ibMAC)
+	setDevices(t, cli, v1alpha1.DeviceInfo{DeviceType: v1alpha1.DeviceTypeInfiniBandNIC, MacAddress: "000D3A000002"})
+	require.NoError(t, r.reconcile(context.Background()))
+	counts, _ = tracker.get()
+	assert.Equal(t, 1, counts[v1alpha1.DeviceTypeVnetNIC])
+	assert.Equal(t, 1, pnp.refreshes)
+	assert.True(t, podExists(t, cli, vnetPod))
+
+	// the pod bound to it is evicted
+	require.NoError(t, r.reconcile(context.Background()))
+	counts, _ = tracker.get()
+	assert.Equal(t, map[v1alpha1.DeviceType]int{v1alpha1.DeviceTypeVnetNIC: 0, v1alpha1.DeviceTypeInfiniBandNIC: 1}, counts)
+	assert.Equal(t, 2, pnp.refreshes)
+	assert.False(t, podExists(t, cli, vnetPod))
+	assert.True(t, podExists(t, cli, ibPod))
+
+	// once its pods are gone the NIC is no longer drained
+	require.NoError(t, r.reconcile(context.Background()))
+	assert.Empty(t, r.draining)
+
+	// the NIC comes back
+	ifaces.set(vnetMAC, ibMAC)
+	setDevices(t, cli,
+		v1alpha1.DeviceInfo{DeviceType: v1alpha1.DeviceTypeVnetNIC, MacAddress: "000D3A000001"},
+		v1alpha1.DeviceInfo{DeviceType: v1alpha1.DeviceTypeInfiniBandNIC, MacAddress: "000D3A000002"})
+	require.NoError(t, r.reconcile(context.Background()))
+	counts, _ = tracker.get()
+	assert.Equal(t, 1, counts[v1alpha1.DeviceTypeVnetNIC])
+	assert.Equal(t, 3, pnp.refreshes)
+}
+
+func TestReconcileNICInPodNetns(t *testing.T) {
+	pod, mtpnc := newSwiftV2Pod("vnet", vnetMAC)
+	pod.Status.PodIPs = []v1.PodIP{{IP: "10.0.0.1"}}
+	r, cli, ifaces, tracker, pnp := newTestReconciler(t, pod, mtpnc)
+	require.NoError(t, r.reconcile(context.Background()))
+
+	// the NIC is moved into the netns of its pod, so it is still counted and the pod is not evicted
+	ifaces.set(ibMAC)
+	for i := 0; i < 2; i++ {
+		require.NoError(t, r.reconcile(context.Background()))
+	}
+	counts, _ := tracker.get()
+	assert.Equal(t, map[v1alpha1.DeviceType]int{v1alpha1.DeviceTypeVnetNIC: 1, v1alpha1.DeviceTypeInfiniBandNIC: 1}, counts)
+	assert.Equal(t, 1, pnp.refreshes)
+	assert.Empty(t, r.draining)
+	assert.True(t, podExists(t, cli, pod))
+
+	// the pod is deleted and the NIC is back on the host
+	require.NoError(t, cli.Delete(context.Background(), pod))
+	ifaces.set(vnetMAC, ibMAC)
+	require.NoError(t, r.reconcile(context.Background()))
+	counts, _ = tracker.get()
+	assert.Equal(t, 1, counts[v1alpha1.DeviceTypeVnetNIC])
+	assert.Equal(t, 1, pnp.refreshes)
+}
+
+func TestReconcileNICMovedBeforePodIPs(t *testing.T) {
+	// CNI ADD moves the NIC into the netns of the pod before kubelet publishes its IPs, and the link event of the
+	// move triggers a scan right away
+	pod, mtpnc := newSwiftV2Pod("vnet", vnetMAC)
+	pod.Status.Phase = v1.PodPending
+	r, cli, ifaces, tracker, _ := newTestReconciler(t, pod, mtpnc)
+	require.NoError(t, r.reconcile(context.Background()))
+
+	ifaces.set(ibMAC)
+	for i := 0; i < 2; i++ {
+		require.NoError(t, r.reconcile(context.Background()))
+	}
+	counts, _ := tracker.get()
+	assert.Equal(t, 1, counts[v1alpha1.DeviceTypeVnetNIC])
+	assert.Empty(t, r.draining)
+	assert.True(t, podExists(t, cli, pod))
+}
+
+func TestReconcileNICMissingForOneScan(t *testing.T) {
+	r, _, ifaces, tracker, pnp := newTestReconciler(t)
+	require.NoError(t, r.reconcile(context.Background()))
+
+	// a NIC not seen for a single scan is not removed
+	ifaces.set(ibMAC)
+	require.NoError(t, r.reconcile(context.Background()))
+	ifaces.set(vnetMAC, ibMAC)
+	require.NoError(t, r.reconcile(context.Background()))
+	counts, _ := tracker.get()
+	assert.Equal(t, 1, counts[v1alpha1.DeviceTypeVnetNIC])
+	assert.Equal(t, 1, pnp.refreshes)
+	assert.Empty(t, r.missing)
+	assert.Empty(t, r.draining)
+
+	// a NIC whose pod terminated is no longer held by it
+	pod, mtpnc := newSwiftV2Pod("done", vnetMAC)
+	pod.Status.Phase = v1.PodSucceeded
+	r, _, ifaces, tracker, _ = newTestReconciler(t, pod, mtpnc)
+	require.NoError(t, r.reconcile(context.Background()))
+	ifaces.set(ibMAC)
+	for i := 0; i < 2; i++ {
+		require.NoError(t, r.reconcile(context.Background()))
+	}
+	counts, _ = tracker.get()
+	assert.Equal(t, 0, counts[v1alpha1.DeviceTypeVnetNIC])
+}
+
+func TestRunRescansOnLinkEvents(t *testing.T) {
+	r, _, ifaces, tracker, _ := newTestReconciler(t)
+	events := make(chan netlink.Event, 1)
+	r.events = &netlink.MockNetlink{SubscribeFn: func(context.Context, netlink.Group) (<-chan netlink.Event, error) {
+		return events, nil
+	}}
+	r.scanInterval = time.Hour
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		r.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	require.Eventually(t, func() bool {
+		_, calls := tracker.get()
+		return calls > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// the removal is confirmed by the scan of the next event
+	ifaces.set(ibMAC)
+	require.Eventually(t, func() bool {
+		select {
+		case events <- netlink.Event{Type: netlink.EventLinkRemoved, Link: &netlink.LinkUpdate{Name: "eth1"}}:
+		default:
+		}
+		counts, _ := tracker.get()
+		return counts[v1alpha1.DeviceTypeVnetNIC] == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,59 @@
+package nodesubnet
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DriftPolicy is how an IP assigned to a Pod is remediated once it is no longer a secondary IP of the NIC, for
+// example after it was removed in the portal. CNS can not take the IP away from the Pod, so it is kept in CNS until
+// the Pod releases it under every policy.
+type DriftPolicy string
+
+const (
+	// DriftPolicyKeep only reports the drifted IP. Once released it is Available until the next fetch removes it.
+	DriftPolicyKeep DriftPolicy = "keep"
+	// DriftPolicyPendingRelease marks the drifted IP PendingRelease, so that it is not assigned again once released.
+	DriftPolicyPendingRelease DriftPolicy = "pendingrelease"
+	// DriftPolicyEvict marks the drifted IP PendingRelease and evicts its Pod through the API server.
+	DriftPolicyEvict DriftPolicy = "evict"
+)
+
+var ErrUnknownDriftPolicy = errors.New("unknown drift policy")
+
+// ParseDriftPolicy parses the policy from the CNS configuration, defaulting to DriftPolicyKeep.
+func ParseDriftPolicy(s string) (DriftPolicy, error) {
+	switch policy := DriftPolicy(strings.ToLower(s)); policy {
+	case "":
+		return DriftPolicyKeep, nil
+	case DriftPolicyKeep, DriftPolicyPendingRelease, DriftPolicyEvict:
+		return policy, nil
+	default:
+		return "", errors.Wrapf(ErrUnknownDriftPolicy, "%q", s)
+	}
+}
+
+// PodEvicter evicts the Pod holding a drifted IP.
+type PodEvicter interface {
+	EvictPod(ctx context.Context, namespace, name string) error
+}
+
+// APIServerPodEvicter evicts Pods through the eviction API, which honours their PodDisruptionBudgets.
+type APIServerPodEvicter struct {
+	Client client.Client
+}
+
+func (e *APIServerPodEvicter) EvictPod(ctx context.Context, namespace, name string) error {
+	meta := metav1.ObjectMeta{Name: name, Namespace: namespace}
+	if err := e.Client.SubResource("eviction").Create(ctx, &v1.Pod{ObjectMeta: meta}, &policyv1.Eviction{ObjectMeta: meta}); err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to evict pod %s/%s", namespace, name)
+	}
+	return nil
+}
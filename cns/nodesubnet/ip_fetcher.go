@@ -4,8 +4,12 @@ import (
 	"context"
 	"log"
 	"net/netip"
+	"slices"
+	"sync"
 	"time"
 
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/logger"
 	"github.com/Azure/azure-container-networking/nmagent"
 	"github.com/Azure/azure-container-networking/refresh"
 	"github.com/pkg/errors"
@@ -16,6 +20,11 @@ const (
 	DefaultMinRefreshInterval = 4 * time.Second
 	// Default maximum time between secondary IP fetches
 	DefaultMaxRefreshInterval = 1024 * time.Second
+	// Number of changes in the secondary IPs which are remembered
+	maxChurnHistory = 64
+	// Number of consecutive failed fetches after which NMAgent is left alone for fetchCooloff
+	fetchFailureThreshold = 5
+	fetchCooloff          = 5 * time.Minute
 )
 
 var ErrRefreshSkipped = errors.New("refresh skipped due to throttling")
@@ -34,12 +43,19 @@ type IPConsumer interface {
 // interval will vary within the range of minRefreshInterval and
 // maxRefreshInterval. When no diff is observed after a fetch, the interval
 // doubles (subject to the maximum interval). When a diff is observed, the
-// interval resets to the minimum.
+// interval resets to the minimum. The intervals are jittered so that nodes do
+// not fetch in lockstep, failed fetches back off separately, and NMAgent is
+// left alone for a while after repeated failures.
 type IPFetcher struct {
 	// Node subnet config
 	intfFetcherClient InterfaceRetriever
 	consumer          IPConsumer
 	fetcher           *refresh.Fetcher[nmagent.Interfaces]
+
+	mu sync.Mutex
+	// secondary IPs of the last fetch, nil before the first one
+	last    map[netip.Addr]struct{}
+	history []cns.NodeSubnetIPChurn
 }
 
 // NewIPFetcher creates a new IPFetcher. If minInterval is 0, it will default to 4 seconds.
@@ -66,7 +82,23 @@ func NewIPFetcher(
 		consumer:          consumer,
 		fetcher:           nil,
 	}
-	fetcher := refresh.NewFetcher[nmagent.Interfaces](client.GetInterfaceIPInfo, minInterval, maxInterval, newIPFetcher.ProcessInterfaces, logger)
+	fetcher := refresh.NewFetcher[nmagent.Interfaces](client.GetInterfaceIPInfo, minInterval, maxInterval, newIPFetcher.ProcessInterfaces, logger,
+		refresh.WithJitter(refresh.DecorrelatedJitter),
+		refresh.WithErrorBackoff(maxInterval),
+		refresh.WithCircuitBreaker(fetchFailureThreshold, fetchCooloff),
+		refresh.WithHooks(refresh.Hooks{
+			OnInterval: func(interval time.Duration) {
+				fetchInterval.Set(interval.Seconds())
+			},
+			OnBreaker: func(state refresh.BreakerState) {
+				if state == refresh.BreakerOpen {
+					fetchBreakerOpen.Set(1)
+				} else {
+					fetchBreakerOpen.Set(0)
+				}
+			},
+		}),
+	)
 	newIPFetcher.fetcher = fetcher
 	return newIPFetcher
 }
@@ -83,6 +115,7 @@ func (c *IPFetcher) ProcessInterfaces(response nmagent.Interfaces) error {
 	}
 
 	_, secondaryIPs := flattenIPListFromResponse(&response)
+	c.recordChurn(secondaryIPs)
 	err := c.consumer.UpdateIPsForNodeSubnet(secondaryIPs)
 	if err != nil {
 		return errors.Wrap(err, "updating secondary IPs")
@@ -91,6 +124,60 @@ func (c *IPFetcher) ProcessInterfaces(response nmagent.Interfaces) error {
 	return nil
 }
 
+// recordChurn records the secondary IPs added to and removed from the NIC since the last fetch. The first fetch only
+// sets the baseline.
+func (c *IPFetcher) recordChurn(secondaryIPs []netip.Addr) {
+	current := make(map[netip.Addr]struct{}, len(secondaryIPs))
+	for _, ip := range secondaryIPs {
+		current[ip] = struct{}{}
+	}
+	secondaryIPCount.Set(float64(len(current)))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	last := c.last
+	c.last = current
+	if last == nil {
+		return
+	}
+
+	added, removed := diffIPs(last, current), diffIPs(current, last)
+	if len(added) == 0 && len(removed) == 0 {
+		return
+	}
+	secondaryIPsAdded.Add(float64(len(added)))
+	secondaryIPsRemoved.Add(float64(len(removed)))
+	logger.Printf("Secondary IPs changed, added %v, removed %v", added, removed)
+
+	c.history = append(c.history, cns.NodeSubnetIPChurn{Time: time.Now(), Added: added, Removed: removed})
+	if len(c.history) > maxChurnHistory {
+		c.history = c.history[len(c.history)-maxChurnHistory:]
+	}
+}
+
+// History returns the recent changes in the secondary IPs of the NIC, oldest first.
+func (c *IPFetcher) History() []cns.NodeSubnetIPChurn {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(c.history)
+}
+
+// diffIPs returns the IPs in to which are not in from, sorted.
+func diffIPs(from, to map[netip.Addr]struct{}) []string {
+	var ips []netip.Addr
+	for ip := range to {
+		if _, ok := from[ip]; !ok {
+			ips = append(ips, ip)
+		}
+	}
+	slices.SortFunc(ips, netip.Addr.Compare)
+	strs := make([]string, len(ips))
+	for i := range ips {
+		strs[i] = ips[i].String()
+	}
+	return strs
+}
+
 // Get the list of secondary IPs from fetched Interfaces
 func flattenIPListFromResponse(resp *nmagent.Interfaces) (primary netip.Addr, secondaryIPs []netip.Addr) {
 	var primaryIP netip.Addr
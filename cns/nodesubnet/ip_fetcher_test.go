@@ -3,6 +3,7 @@ package nodesubnet_test
 import (
 	"context"
 	"net/netip"
+	"slices"
 	"testing"
 
 	"github.com/Azure/azure-container-networking/cns/logger"
@@ -100,6 +101,65 @@ func TestFlatten(t *testing.T) {
 	}
 }
 
+func TestChurnHistory(t *testing.T) {
+	response := func(ips ...byte) nmagent.Interfaces {
+		addrs := []nmagent.NodeIP{{Address: nmagent.IPAddress(netip.AddrFrom4([4]byte{10, 240, 0, 4})), IsPrimary: true}}
+		for _, ip := range ips {
+			addrs = append(addrs, nmagent.NodeIP{Address: nmagent.IPAddress(netip.AddrFrom4([4]byte{10, 240, 0, ip}))})
+		}
+		return nmagent.Interfaces{
+			Entries: []nmagent.Interface{
+				{
+					IsPrimary:        true,
+					InterfaceSubnets: []nmagent.InterfaceSubnet{{Prefix: "10.240.0.0/16", IPAddress: addrs}},
+				},
+			},
+		}
+	}
+	fetcher := nodesubnet.NewIPFetcher(&TestClient{}, &TestConsumer{}, 0, 0, logger.Log)
+
+	// the first fetch is the baseline
+	checkErr(t, fetcher.ProcessInterfaces(response(5, 6)), false)
+	if len(fetcher.History()) != 0 {
+		t.Fatalf("expected no churn after the first fetch, got %v", fetcher.History())
+	}
+
+	// unchanged IPs are not recorded
+	checkErr(t, fetcher.ProcessInterfaces(response(6, 5)), false)
+	if len(fetcher.History()) != 0 {
+		t.Fatalf("expected no churn without changes, got %v", fetcher.History())
+	}
+
+	checkErr(t, fetcher.ProcessInterfaces(response(5, 10, 7)), false)
+	history := fetcher.History()
+	if len(history) != 1 {
+		t.Fatalf("expected 1 churn, got %v", history)
+	}
+	if !slices.Equal(history[0].Added, []string{"10.240.0.7", "10.240.0.10"}) {
+		t.Errorf("unexpected added IPs %v", history[0].Added)
+	}
+	if !slices.Equal(history[0].Removed, []string{"10.240.0.6"}) {
+		t.Errorf("unexpected removed IPs %v", history[0].Removed)
+	}
+}
+
+func TestParseDriftPolicy(t *testing.T) {
+	for s, want := range map[string]nodesubnet.DriftPolicy{
+		"":               nodesubnet.DriftPolicyKeep,
+		"keep":           nodesubnet.DriftPolicyKeep,
+		"PendingRelease": nodesubnet.DriftPolicyPendingRelease,
+		"evict":          nodesubnet.DriftPolicyEvict,
+	} {
+		got, err := nodesubnet.ParseDriftPolicy(s)
+		checkErr(t, err, false)
+		if got != want {
+			t.Errorf("ParseDriftPolicy(%q) = %q, want %q", s, got, want)
+		}
+	}
+	_, err := nodesubnet.ParseDriftPolicy("delete")
+	checkErr(t, err, true)
+}
+
 // checkErr is an assertion of the presence or absence of an error
 func checkErr(t *testing.T, err error, shouldErr bool) {
 	t.Helper()
@@ -0,0 +1,49 @@
+package nodesubnet
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	secondaryIPCount = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "nodesubnet_secondary_ips",
+			Help: "Secondary IPs of the NIC at the last fetch from NMAgent.",
+		},
+	)
+	secondaryIPsAdded = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "nodesubnet_secondary_ips_added_total",
+			Help: "Secondary IPs found added to the NIC between fetches from NMAgent.",
+		},
+	)
+	secondaryIPsRemoved = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "nodesubnet_secondary_ips_removed_total",
+			Help: "Secondary IPs found removed from the NIC between fetches from NMAgent.",
+		},
+	)
+	fetchInterval = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "nodesubnet_fetch_interval_seconds",
+			Help: "Wait until the next fetch of the secondary IPs from NMAgent.",
+		},
+	)
+	fetchBreakerOpen = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "nodesubnet_fetch_circuit_open",
+			Help: "Whether fetching the secondary IPs from NMAgent is paused after repeated failures.",
+		},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		secondaryIPCount,
+		secondaryIPsAdded,
+		secondaryIPsRemoved,
+		fetchInterval,
+		fetchBreakerOpen,
+	)
+}
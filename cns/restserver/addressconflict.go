@@ -0,0 +1,178 @@
+package restserver
+
+import (
+	"context"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-container-networking/aitelemetry"
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/logger"
+	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/Azure/azure-container-networking/probe"
+	"github.com/pkg/errors"
+)
+
+// maxAddressConflictRetries is how many times a request is assigned new IPs after the assigned ones are found in use.
+const maxAddressConflictRetries = 3
+
+// AddressConflictProber probes the link for another host using an IP, returning an error matching
+// probe.ErrConflict if one is.
+type AddressConflictProber interface {
+	Probe(ctx context.Context, ifname string, addr netip.Addr) error
+}
+
+type addressConflictProbe struct {
+	prober     AddressConflictProber
+	ifName     string
+	quarantine time.Duration
+}
+
+// SetAddressConflictProber enables probing IPs before they are assigned to Pods. IPs found in use are
+// Quarantined for the duration instead of being assigned. The probe is sent on ifName, or if it is empty on the
+// interface with a subnet containing the IP.
+// The Quarantined state is not persisted, so quarantines end when CNS restarts.
+func (service *HTTPRestService) SetAddressConflictProber(prober AddressConflictProber, ifName string, quarantine time.Duration) {
+	service.conflictProbe = &addressConflictProbe{
+		prober:     prober,
+		ifName:     ifName,
+		quarantine: quarantine,
+	}
+}
+
+// assignAvailableProbedIPConfigs assigns available IPs to the Pod like AssignAvailableIPConfigs, probing the link
+// for another host using them first if address conflict probing is enabled. The IPs found in use are released
+// and Quarantined, and other IPs are assigned instead.
+func (service *HTTPRestService) assignAvailableProbedIPConfigs(ctx context.Context, podInfo cns.PodInfo) ([]cns.PodIpInfo, error) {
+	if service.conflictProbe == nil {
+		return service.AssignAvailableIPConfigs(podInfo)
+	}
+
+	for attempt := 1; ; attempt++ {
+		service.releaseExpiredQuarantines()
+
+		podIPInfo, err := service.AssignAvailableIPConfigs(podInfo)
+		if err != nil {
+			return podIPInfo, err
+		}
+
+		conflicts := service.probeIPConfigs(ctx, podInfo)
+		if err := ctx.Err(); err != nil {
+			// the caller is gone, so the IPs it would not learn of are released rather than held
+			if releaseErr := service.releaseIPConfigs(podInfo); releaseErr != nil {
+				logger.Errorf("[assignAvailableProbedIPConfigs] Failed to release IPs of pod %+v after the request ended, err: %v", podInfo, releaseErr)
+			}
+			return []cns.PodIpInfo{}, errors.Wrap(err, "request ended while probing IPs")
+		}
+		if len(conflicts) == 0 {
+			return podIPInfo, nil
+		}
+
+		if err := service.releaseIPConfigs(podInfo); err != nil {
+			return []cns.PodIpInfo{}, errors.Wrap(err, "failed to release IPs found in use")
+		}
+		service.quarantineIPConfigs(conflicts)
+
+		if attempt >= maxAddressConflictRetries {
+			ips := make([]string, 0, len(conflicts))
+			for i := range conflicts {
+				ips = append(ips, conflicts[i].ipConfig.IPAddress)
+			}
+			return []cns.PodIpInfo{}, errors.Errorf("IPs %s are in use by other hosts after %d attempts", strings.Join(ips, ", "), attempt)
+		}
+	}
+}
+
+type addressConflict struct {
+	ipConfig cns.IPConfigurationStatus
+	err      error
+}
+
+// probeIPConfigs probes the IPs assigned to the Pod and returns those found in use. Failing to probe an IP
+// does not keep it from being assigned.
+func (service *HTTPRestService) probeIPConfigs(ctx context.Context, podInfo cns.PodInfo) []addressConflict {
+	service.RLock()
+	ipConfigs := make([]cns.IPConfigurationStatus, 0, len(service.PodIPIDByPodInterfaceKey[podInfo.Key()]))
+	for _, id := range service.PodIPIDByPodInterfaceKey[podInfo.Key()] {
+		if ipConfig, ok := service.PodIPConfigState[id]; ok {
+			ipConfigs = append(ipConfigs, ipConfig)
+		}
+	}
+	service.RUnlock()
+
+	var conflicts []addressConflict
+	for i := range ipConfigs {
+		if ctx.Err() != nil {
+			break
+		}
+		addr, err := netip.ParseAddr(ipConfigs[i].IPAddress)
+		if err != nil {
+			continue
+		}
+		ifName := service.conflictProbe.ifName
+		if ifName == "" {
+			var ok bool
+			// IPs on no local subnet, such as overlay IPs, have no link to probe.
+			if ifName, ok = probe.InterfaceFor(addr); !ok {
+				continue
+			}
+		}
+		err = service.conflictProbe.prober.Probe(ctx, ifName, addr)
+		if errors.Is(err, probe.ErrConflict) {
+			conflicts = append(conflicts, addressConflict{ipConfig: ipConfigs[i], err: err})
+			continue
+		}
+		if err != nil {
+			logger.Errorf("[probeIPConfigs] Failed to probe IP %s on %s for pod %+v, err: %v", addr, ifName, podInfo, err)
+		}
+	}
+	return conflicts
+}
+
+// quarantineIPConfigs sets the released IPs which were found in use to Quarantined.
+func (service *HTTPRestService) quarantineIPConfigs(conflicts []addressConflict) {
+	service.Lock()
+	defer service.Unlock()
+	for i := range conflicts {
+		ipConfig := conflicts[i].ipConfig
+		// the IP may have been removed or handed out again since it was released
+		if current, ok := service.PodIPConfigState[ipConfig.ID]; !ok || current.GetState() != types.Available {
+			continue
+		}
+		if _, err := service.updateIPConfigState(ipConfig.ID, types.Quarantined, nil); err != nil {
+			logger.Errorf("[quarantineIPConfigs] Failed to quarantine IP %s, err: %v", ipConfig.IPAddress, err)
+			continue
+		}
+		ipAddressConflictCount.Inc()
+		logger.LogEvent(aitelemetry.Event{
+			EventName: logger.IPAddressConflictEventStr,
+			Properties: map[string]string{
+				logger.IPAddressStr:     ipConfig.IPAddress,
+				logger.ConflictErrorStr: conflicts[i].err.Error(),
+			},
+			ResourceID: ipConfig.NCID,
+		})
+	}
+	service.publishIPStateMetrics()
+}
+
+// releaseExpiredQuarantines sets the Quarantined IPs which have been so for longer than the quarantine to Available.
+func (service *HTTPRestService) releaseExpiredQuarantines() {
+	service.Lock()
+	defer service.Unlock()
+	released := false
+	for id, ipConfig := range service.PodIPConfigState {
+		if ipConfig.GetState() != types.Quarantined || time.Since(ipConfig.LastStateTransition) < service.conflictProbe.quarantine {
+			continue
+		}
+		if _, err := service.updateIPConfigState(id, types.Available, nil); err != nil {
+			logger.Errorf("[releaseExpiredQuarantines] Failed to set IP %s to Available, err: %v", ipConfig.IPAddress, err)
+			continue
+		}
+		released = true
+	}
+	if released {
+		service.publishIPStateMetrics()
+	}
+}
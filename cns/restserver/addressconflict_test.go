@@ -0,0 +1,130 @@
+package restserver
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/Azure/azure-container-networking/probe"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeProber reports a conflict for the first conflicts probes, calling onProbe first if it is set.
+type fakeProber struct {
+	conflicts int
+	probed    []netip.Addr
+	onProbe   func()
+}
+
+func (f *fakeProber) Probe(ctx context.Context, _ string, addr netip.Addr) error {
+	if f.onProbe != nil {
+		f.onProbe()
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+	f.probed = append(f.probed, addr)
+	if len(f.probed) <= f.conflicts {
+		return &probe.ConflictError{Addr: addr, HardwareAddr: net.HardwareAddr{0, 0x0d, 0x3a, 0, 0, 1}}
+	}
+	return nil
+}
+
+func ipConfigState(svc *HTTPRestService, id string) types.IPState {
+	ipConfig := svc.PodIPConfigState[id]
+	return ipConfig.GetState()
+}
+
+// newConflictTestService returns a test service, restoring the shared one the other tests use afterwards.
+func newConflictTestService(t *testing.T) *HTTPRestService {
+	t.Helper()
+	prev := svc
+	t.Cleanup(func() { svc = prev })
+	return getTestService(cns.KubernetesCRD)
+}
+
+func newConflictTestRequest(t *testing.T, podInfo cns.PodInfo) cns.IPConfigsRequest {
+	b, err := podInfo.OrchestratorContext()
+	require.NoError(t, err)
+	return cns.IPConfigsRequest{
+		PodInterfaceID:      podInfo.InterfaceID(),
+		InfraContainerID:    podInfo.InfraContainerID(),
+		OrchestratorContext: b,
+	}
+}
+
+func TestAssignAvailableProbedIPConfigs(t *testing.T) {
+	svc := newConflictTestService(t)
+	ipconfigs := map[string]cns.IPConfigurationStatus{
+		testIPID1: newPodState(testIP1, testIPID1, testNCID, types.Available, 0),
+		testIPID2: newPodState(testIP2, testIPID2, testNCID, types.Available, 0),
+	}
+	require.NoError(t, updatePodIPConfigState(t, svc, ipconfigs, testNCID))
+
+	prober := &fakeProber{conflicts: 1}
+	svc.SetAddressConflictProber(prober, "eth0", time.Hour)
+
+	// the first IP is in use, so it is quarantined and the other one assigned
+	podIPInfo, err := requestIPConfigsHelper(context.Background(), svc, newConflictTestRequest(t, testPod1Info))
+	require.NoError(t, err)
+	require.Len(t, podIPInfo, 1)
+	require.Len(t, prober.probed, 2)
+	conflicting, assigned := prober.probed[0].String(), prober.probed[1].String()
+	require.Equal(t, assigned, podIPInfo[0].PodIPConfig.IPAddress)
+	quarantinedID := testIPID1
+	if conflicting == testIP2 {
+		quarantinedID = testIPID2
+	}
+	require.Equal(t, types.Quarantined, ipConfigState(svc, quarantinedID))
+	require.Empty(t, svc.PodIPConfigState[quarantinedID].PodInfo)
+
+	// quarantined IPs are not assigned
+	_, err = requestIPConfigsHelper(context.Background(), svc, newConflictTestRequest(t, testPod2Info))
+	require.Error(t, err)
+
+	// until the quarantine ends
+	svc.SetAddressConflictProber(prober, "eth0", 0)
+	podIPInfo, err = requestIPConfigsHelper(context.Background(), svc, newConflictTestRequest(t, testPod2Info))
+	require.NoError(t, err)
+	require.Equal(t, conflicting, podIPInfo[0].PodIPConfig.IPAddress)
+	require.Equal(t, types.Assigned, ipConfigState(svc, quarantinedID))
+}
+
+func TestAssignAvailableProbedIPConfigsRetriesExhausted(t *testing.T) {
+	svc := newConflictTestService(t)
+	ipconfigs := map[string]cns.IPConfigurationStatus{
+		testIPID1: newPodState(testIP1, testIPID1, testNCID, types.Available, 0),
+		testIPID2: newPodState(testIP2, testIPID2, testNCID, types.Available, 0),
+	}
+	require.NoError(t, updatePodIPConfigState(t, svc, ipconfigs, testNCID))
+
+	svc.SetAddressConflictProber(&fakeProber{conflicts: maxAddressConflictRetries}, "eth0", time.Hour)
+
+	_, err := requestIPConfigsHelper(context.Background(), svc, newConflictTestRequest(t, testPod1Info))
+	require.Error(t, err)
+	require.Equal(t, types.Quarantined, ipConfigState(svc, testIPID1))
+	require.Equal(t, types.Quarantined, ipConfigState(svc, testIPID2))
+	require.Empty(t, svc.PodIPIDByPodInterfaceKey[testPod1Info.Key()])
+}
+
+func TestAssignAvailableProbedIPConfigsCanceled(t *testing.T) {
+	svc := newConflictTestService(t)
+	ipconfigs := map[string]cns.IPConfigurationStatus{
+		testIPID1: newPodState(testIP1, testIPID1, testNCID, types.Available, 0),
+	}
+	require.NoError(t, updatePodIPConfigState(t, svc, ipconfigs, testNCID))
+
+	// the caller goes away while its IP is probed
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	svc.SetAddressConflictProber(&fakeProber{onProbe: cancel}, "eth0", time.Hour)
+
+	_, err := requestIPConfigsHelper(ctx, svc, newConflictTestRequest(t, testPod1Info))
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, types.Available, ipConfigState(svc, testIPID1))
+	require.Empty(t, svc.PodIPIDByPodInterfaceKey[testPod1Info.Key()])
+}
@@ -16,6 +16,7 @@ import (
 	"strings"
 
 	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/configuration"
 	"github.com/Azure/azure-container-networking/cns/hnsclient"
 	"github.com/Azure/azure-container-networking/cns/logger"
 	"github.com/Azure/azure-container-networking/cns/types"
@@ -49,7 +50,7 @@ func (service *HTTPRestService) setEnvironment(w http.ResponseWriter, r *http.Re
 
 	var req cns.SetEnvironmentRequest
 	err := common.Decode(w, r, &req)
-	logger.Request(service.Name, &req, err)
+	service.logRequest(r.Context(), service.Name, &req, err)
 
 	if err != nil {
 		return
@@ -68,7 +69,7 @@ func (service *HTTPRestService) setEnvironment(w http.ResponseWriter, r *http.Re
 	resp := &cns.Response{ReturnCode: 0}
 	err = common.Encode(w, &resp)
 
-	logger.Response(service.Name, resp, resp.ReturnCode, err)
+	service.logResponse(r.Context(), service.Name, resp, resp.ReturnCode, err)
 }
 
 // Handles CreateNetwork requests.
@@ -82,7 +83,7 @@ func (service *HTTPRestService) createNetwork(w http.ResponseWriter, r *http.Req
 	if service.state.Initialized {
 		var req cns.CreateNetworkRequest
 		err = common.Decode(w, r, &req)
-		logger.Request(service.Name, &req, err)
+		service.logRequest(r.Context(), service.Name, &req, err)
 
 		if err != nil {
 			//nolint:goconst // ignore const string
@@ -174,7 +175,7 @@ func (service *HTTPRestService) createNetwork(w http.ResponseWriter, r *http.Req
 		service.saveState()
 	}
 
-	logger.Response(service.Name, resp, resp.ReturnCode, err)
+	service.logResponse(r.Context(), service.Name, resp, resp.ReturnCode, err)
 }
 
 // Handles DeleteNetwork requests.
@@ -185,7 +186,7 @@ func (service *HTTPRestService) deleteNetwork(w http.ResponseWriter, r *http.Req
 	var returnCode types.ResponseCode
 	returnMessage := ""
 	err := common.Decode(w, r, &req)
-	logger.Request(service.Name, &req, err)
+	service.logRequest(r.Context(), service.Name, &req, err)
 
 	if err != nil {
 		return
@@ -230,7 +231,7 @@ func (service *HTTPRestService) deleteNetwork(w http.ResponseWriter, r *http.Req
 		service.saveState()
 	}
 
-	logger.Response(service.Name, resp, resp.ReturnCode, err)
+	service.logResponse(r.Context(), service.Name, resp, resp.ReturnCode, err)
 }
 
 // Handles CreateHnsNetwork requests.
@@ -243,7 +244,7 @@ func (service *HTTPRestService) createHnsNetwork(w http.ResponseWriter, r *http.
 
 	var req cns.CreateHnsNetworkRequest
 	err = common.Decode(w, r, &req)
-	logger.Request(service.Name, &req, err)
+	service.logRequest(r.Context(), service.Name, &req, err)
 
 	if err != nil {
 		//nolint:goconst
@@ -281,7 +282,7 @@ func (service *HTTPRestService) createHnsNetwork(w http.ResponseWriter, r *http.
 		service.saveState()
 	}
 
-	logger.Response(service.Name, resp, resp.ReturnCode, err)
+	service.logResponse(r.Context(), service.Name, resp, resp.ReturnCode, err)
 }
 
 // Handles deleteHnsNetwork requests.
@@ -294,7 +295,7 @@ func (service *HTTPRestService) deleteHnsNetwork(w http.ResponseWriter, r *http.
 	returnMessage := ""
 
 	err = common.Decode(w, r, &req)
-	logger.Request(service.Name, &req, err)
+	service.logRequest(r.Context(), service.Name, &req, err)
 
 	if err != nil {
 		//nolint:goconst
@@ -333,13 +334,13 @@ func (service *HTTPRestService) deleteHnsNetwork(w http.ResponseWriter, r *http.
 		service.saveState()
 	}
 
-	logger.Response(service.Name, resp, resp.ReturnCode, err)
+	service.logResponse(r.Context(), service.Name, resp, resp.ReturnCode, err)
 }
 
 // Retrieves the host local ip address. Containers can talk to host using this IP address.
 func (service *HTTPRestService) getHostLocalIP(w http.ResponseWriter, r *http.Request) {
 	logger.Printf("[Azure CNS] getHostLocalIP")
-	logger.Request(service.Name, "getHostLocalIP", nil)
+	service.logRequest(r.Context(), service.Name, "getHostLocalIP", nil)
 
 	var found bool
 	var errmsg string
@@ -385,54 +386,54 @@ func (service *HTTPRestService) getHostLocalIP(w http.ResponseWriter, r *http.Re
 
 	err := common.Encode(w, &hostLocalIPResponse)
 
-	logger.Response(service.Name, hostLocalIPResponse, resp.ReturnCode, err)
+	service.logResponse(r.Context(), service.Name, hostLocalIPResponse, resp.ReturnCode, err)
 }
 
 // Handles retrieval of ip addresses that are available to be reserved from ipam driver.
 func (service *HTTPRestService) getAvailableIPAddresses(w http.ResponseWriter, r *http.Request) {
 	logger.Printf("[Azure CNS] getAvailableIPAddresses")
-	logger.Request(service.Name, "getAvailableIPAddresses", nil)
+	service.logRequest(r.Context(), service.Name, "getAvailableIPAddresses", nil)
 
 	resp := cns.Response{ReturnCode: 0}
 	ipResp := &cns.GetIPAddressesResponse{Response: resp}
 	err := common.Encode(w, &ipResp)
 
-	logger.Response(service.Name, ipResp, resp.ReturnCode, err)
+	service.logResponse(r.Context(), service.Name, ipResp, resp.ReturnCode, err)
 }
 
 // Handles retrieval of reserved ip addresses from ipam driver.
 func (service *HTTPRestService) getReservedIPAddresses(w http.ResponseWriter, r *http.Request) {
 	logger.Printf("[Azure CNS] getReservedIPAddresses")
-	logger.Request(service.Name, "getReservedIPAddresses", nil)
+	service.logRequest(r.Context(), service.Name, "getReservedIPAddresses", nil)
 
 	resp := cns.Response{ReturnCode: 0}
 	ipResp := &cns.GetIPAddressesResponse{Response: resp}
 	err := common.Encode(w, &ipResp)
 
-	logger.Response(service.Name, ipResp, resp.ReturnCode, err)
+	service.logResponse(r.Context(), service.Name, ipResp, resp.ReturnCode, err)
 }
 
 // getAllIPAddresses retrieves all ip addresses from ipam driver.
 func (service *HTTPRestService) getAllIPAddresses(w http.ResponseWriter, r *http.Request) {
 	logger.Printf("[Azure CNS] getAllIPAddresses")
-	logger.Request(service.Name, "getAllIPAddresses", nil)
+	service.logRequest(r.Context(), service.Name, "getAllIPAddresses", nil)
 
 	resp := cns.Response{ReturnCode: 0}
 	ipResp := &cns.GetIPAddressesResponse{Response: resp}
 	err := common.Encode(w, &ipResp)
 
-	logger.Response(service.Name, ipResp, resp.ReturnCode, err)
+	service.logResponse(r.Context(), service.Name, ipResp, resp.ReturnCode, err)
 }
 
 // Handles health report requests.
 func (service *HTTPRestService) getHealthReport(w http.ResponseWriter, r *http.Request) {
 	logger.Printf("[Azure CNS] getHealthReport")
-	logger.Request(service.Name, "getHealthReport", nil)
+	service.logRequest(r.Context(), service.Name, "getHealthReport", nil)
 
 	resp := &cns.Response{ReturnCode: 0}
 	err := common.Encode(w, &resp)
 
-	logger.Response(service.Name, resp, resp.ReturnCode, err)
+	service.logResponse(r.Context(), service.Name, resp, resp.ReturnCode, err)
 }
 
 func (service *HTTPRestService) setOrchestratorType(w http.ResponseWriter, r *http.Request) {
@@ -479,23 +480,23 @@ func (service *HTTPRestService) setOrchestratorType(w http.ResponseWriter, r *ht
 	}
 
 	err = common.Encode(w, &resp)
-	logger.Response(service.Name, resp, resp.ReturnCode, err)
+	service.logResponse(r.Context(), service.Name, resp, resp.ReturnCode, err)
 }
 
 // getHomeAz retrieves home AZ of host
 func (service *HTTPRestService) getHomeAz(w http.ResponseWriter, r *http.Request) {
 	logger.Printf("[Azure CNS] getHomeAz")
-	logger.Request(service.Name, "getHomeAz", nil)
+	service.logRequest(r.Context(), service.Name, "getHomeAz", nil)
 	ctx := r.Context()
 
 	switch r.Method {
 	case http.MethodGet:
 		getHomeAzResponse := service.homeAzMonitor.GetHomeAz(ctx)
-		service.setResponse(w, getHomeAzResponse.Response.ReturnCode, getHomeAzResponse)
+		service.setResponse(w, r, getHomeAzResponse.Response.ReturnCode, getHomeAzResponse)
 	default:
 		returnMessage := "[Azure CNS] Error. getHomeAz did not receive a GET."
 		returnCode := types.UnsupportedVerb
-		service.setResponse(w, returnCode, cns.GetHomeAzResponse{
+		service.setResponse(w, r, returnCode, cns.GetHomeAzResponse{
 			Response: cns.Response{ReturnCode: returnCode, Message: returnMessage},
 		})
 	}
@@ -514,7 +515,7 @@ func (service *HTTPRestService) createOrUpdateNetworkContainer(w http.ResponseWr
 		return
 	}
 
-	logger.Request(service.Name, req.String(), nil)
+	service.logRequest(r.Context(), service.Name, req.String(), nil)
 	var returnCode types.ResponseCode
 	var returnMessage string
 	var err error
@@ -549,7 +550,7 @@ func (service *HTTPRestService) createOrUpdateNetworkContainer(w http.ResponseWr
 			}
 		}
 
-		returnCode, returnMessage = service.saveNetworkContainerGoalState(req)
+		returnCode, returnMessage = service.saveNetworkContainerGoalState(ncHistorySourceFrom(r.Context()), req)
 
 	default:
 		returnMessage = "[Azure CNS] Error. CreateOrUpdateNetworkContainer did not receive a POST."
@@ -569,7 +570,7 @@ func (service *HTTPRestService) createOrUpdateNetworkContainer(w http.ResponseWr
 		logNCSnapshot(req)
 	}
 
-	logger.Response(service.Name, reserveResp, resp.ReturnCode, err)
+	service.logResponse(r.Context(), service.Name, reserveResp, resp.ReturnCode, err)
 }
 
 func (service *HTTPRestService) getNetworkContainerByID(w http.ResponseWriter, r *http.Request) {
@@ -580,7 +581,7 @@ func (service *HTTPRestService) getNetworkContainerByID(w http.ResponseWriter, r
 	returnMessage := ""
 
 	err := common.Decode(w, r, &req)
-	logger.Request(service.Name, &req, err)
+	service.logRequest(r.Context(), service.Name, &req, err)
 	if err != nil {
 		return
 	}
@@ -592,7 +593,7 @@ func (service *HTTPRestService) getNetworkContainerByID(w http.ResponseWriter, r
 
 	reserveResp := &cns.GetNetworkContainerResponse{Response: resp}
 	err = common.Encode(w, &reserveResp)
-	logger.Response(service.Name, reserveResp, resp.ReturnCode, err)
+	service.logResponse(r.Context(), service.Name, reserveResp, resp.ReturnCode, err)
 }
 
 // the function is to get all network containers based on given OrchestratorContext
@@ -602,7 +603,7 @@ func (service *HTTPRestService) GetAllNetworkContainers(w http.ResponseWriter, r
 	var req cns.GetNetworkContainerRequest
 
 	err := common.Decode(w, r, &req)
-	logger.Request(service.Name, &req, err)
+	service.logRequest(r.Context(), service.Name, &req, err)
 	if err != nil {
 		logger.Errorf("[Azure CNS] failed to decode cns request with req %+v due to %+v", req, err)
 		return
@@ -635,7 +636,7 @@ func (service *HTTPRestService) GetAllNetworkContainers(w http.ResponseWriter, r
 	}
 
 	err = common.Encode(w, &resp)
-	logger.Response(service.Name, resp, resp.Response.ReturnCode, err)
+	service.logResponse(r.Context(), service.Name, resp, resp.Response.ReturnCode, err)
 }
 
 func (service *HTTPRestService) GetNetworkContainerByOrchestratorContext(w http.ResponseWriter, r *http.Request) {
@@ -644,14 +645,14 @@ func (service *HTTPRestService) GetNetworkContainerByOrchestratorContext(w http.
 	var req cns.GetNetworkContainerRequest
 
 	err := common.Decode(w, r, &req)
-	logger.Request(service.Name, &req, err)
+	service.logRequest(r.Context(), service.Name, &req, err)
 	if err != nil {
 		return
 	}
 
 	getNetworkContainerResponses := service.getAllNetworkContainerResponses(req) // nolint
 	err = common.Encode(w, &getNetworkContainerResponses[0])
-	logger.Response(service.Name, getNetworkContainerResponses[0], getNetworkContainerResponses[0].Response.ReturnCode, err)
+	service.logResponse(r.Context(), service.Name, getNetworkContainerResponses[0], getNetworkContainerResponses[0].Response.ReturnCode, err)
 }
 
 // getOrRefreshNetworkContainers is to check whether refresh association is needed. The state file in CNS will get updated if it is lost.
@@ -661,7 +662,7 @@ func (service *HTTPRestService) getOrRefreshNetworkContainers(w http.ResponseWri
 	switch r.Method {
 	case http.MethodGet:
 		logger.Printf("[Azure CNS] getOrRefreshNetworkContainers received GET")
-		service.handleGetNetworkContainers(w)
+		service.handleGetNetworkContainers(w, r)
 		return
 	case http.MethodPost:
 		logger.Printf("[Azure CNS] getOrRefreshNetworkContainers received POST")
@@ -670,7 +671,7 @@ func (service *HTTPRestService) getOrRefreshNetworkContainers(w http.ResponseWri
 	default:
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		err := errors.New("[Azure CNS] getOrRefreshNetworkContainers did not receive a GET or POST")
-		logger.Response(service.Name, nil, types.InvalidParameter, err)
+		service.logResponse(r.Context(), service.Name, nil, types.InvalidParameter, err)
 		return
 	}
 }
@@ -683,7 +684,7 @@ func (service *HTTPRestService) deleteNetworkContainer(w http.ResponseWriter, r
 	returnMessage := ""
 
 	err := common.Decode(w, r, &req)
-	logger.Request(service.Name, &req, err)
+	service.logRequest(r.Context(), service.Name, &req, err)
 	if err != nil {
 		return
 	}
@@ -711,6 +712,9 @@ func (service *HTTPRestService) deleteNetworkContainer(w http.ResponseWriter, r
 			if deleteErr := nc.Delete(ncid); deleteErr != nil { // nolint:gocritic
 				returnMessage = fmt.Sprintf("[Azure CNS] Error. DeleteNetworkContainer failed %v", deleteErr.Error())
 				returnCode = types.UnexpectedError
+				service.Lock()
+				service.recordNCHistoryUntransacted(newNCHistoryRecord(ncHistorySourceFrom(r.Context()), ncid, &containerStatus, nil, returnCode))
+				service.Unlock()
 				break
 			}
 		}
@@ -721,6 +725,8 @@ func (service *HTTPRestService) deleteNetworkContainer(w http.ResponseWriter, r
 		if service.state.ContainerStatus != nil {
 			delete(service.state.ContainerStatus, ncid)
 		}
+		service.recordNCHistoryUntransacted(newNCHistoryRecord(ncHistorySourceFrom(r.Context()), ncid, &containerStatus, nil, types.Success))
+		service.refreshHostRoutes()
 
 		if service.state.ContainerIDByOrchestratorContext != nil {
 			for orchestratorContext, networkContainerIDs := range service.state.ContainerIDByOrchestratorContext { //nolint:gocritic // copy is ok
@@ -747,7 +753,7 @@ func (service *HTTPRestService) deleteNetworkContainer(w http.ResponseWriter, r
 
 	reserveResp := &cns.DeleteNetworkContainerResponse{Response: resp}
 	err = common.Encode(w, &reserveResp)
-	logger.Response(service.Name, reserveResp, resp.ReturnCode, err)
+	service.logResponse(r.Context(), service.Name, reserveResp, resp.ReturnCode, err)
 }
 
 func (service *HTTPRestService) getInterfaceForContainer(w http.ResponseWriter, r *http.Request) {
@@ -758,7 +764,7 @@ func (service *HTTPRestService) getInterfaceForContainer(w http.ResponseWriter,
 	returnMessage := ""
 
 	err := common.Decode(w, r, &req)
-	logger.Request(service.Name, &req, err)
+	service.logRequest(r.Context(), service.Name, &req, err)
 	if err != nil {
 		return
 	}
@@ -801,7 +807,7 @@ func (service *HTTPRestService) getInterfaceForContainer(w http.ResponseWriter,
 
 	err = common.Encode(w, &getInterfaceForContainerResponse)
 
-	logger.Response(service.Name, getInterfaceForContainerResponse, resp.ReturnCode, err)
+	service.logResponse(r.Context(), service.Name, getInterfaceForContainerResponse, resp.ReturnCode, err)
 }
 
 func (service *HTTPRestService) attachNetworkContainerToNetwork(w http.ResponseWriter, r *http.Request) {
@@ -809,7 +815,7 @@ func (service *HTTPRestService) attachNetworkContainerToNetwork(w http.ResponseW
 
 	var req cns.ConfigureContainerNetworkingRequest
 	err := common.Decode(w, r, &req)
-	logger.Request(service.Name, &req, err)
+	service.logRequest(r.Context(), service.Name, &req, err)
 	if err != nil {
 		return
 	}
@@ -817,7 +823,7 @@ func (service *HTTPRestService) attachNetworkContainerToNetwork(w http.ResponseW
 	resp := service.attachOrDetachHelper(req, attach, r.Method)
 	attachResp := &cns.AttachContainerToNetworkResponse{Response: resp}
 	err = common.Encode(w, &attachResp)
-	logger.Response(service.Name, attachResp, resp.ReturnCode, err)
+	service.logResponse(r.Context(), service.Name, attachResp, resp.ReturnCode, err)
 }
 
 func (service *HTTPRestService) detachNetworkContainerFromNetwork(w http.ResponseWriter, r *http.Request) {
@@ -825,7 +831,7 @@ func (service *HTTPRestService) detachNetworkContainerFromNetwork(w http.Respons
 
 	var req cns.ConfigureContainerNetworkingRequest
 	err := common.Decode(w, r, &req)
-	logger.Request(service.Name, &req, err)
+	service.logRequest(r.Context(), service.Name, &req, err)
 	if err != nil {
 		return
 	}
@@ -833,14 +839,14 @@ func (service *HTTPRestService) detachNetworkContainerFromNetwork(w http.Respons
 	resp := service.attachOrDetachHelper(req, detach, r.Method)
 	detachResp := &cns.DetachContainerFromNetworkResponse{Response: resp}
 	err = common.Encode(w, &detachResp)
-	logger.Response(service.Name, detachResp, resp.ReturnCode, err)
+	service.logResponse(r.Context(), service.Name, detachResp, resp.ReturnCode, err)
 }
 
 // Retrieves the number of logic processors on a node. It will be primarily
 // used to enforce per VM delegated NIC limit by DNC.
 func (service *HTTPRestService) getNumberOfCPUCores(w http.ResponseWriter, r *http.Request) {
 	logger.Printf("[Azure-CNS] getNumberOfCPUCores")
-	logger.Request(service.Name, "getNumberOfCPUCores", nil)
+	service.logRequest(r.Context(), service.Name, "getNumberOfCPUCores", nil)
 
 	var (
 		num        int
@@ -864,7 +870,120 @@ func (service *HTTPRestService) getNumberOfCPUCores(w http.ResponseWriter, r *ht
 
 	err := common.Encode(w, &numOfCPUCoresResp)
 
-	logger.Response(service.Name, numOfCPUCoresResp, resp.ReturnCode, err)
+	service.logResponse(r.Context(), service.Name, numOfCPUCoresResp, resp.ReturnCode, err)
+}
+
+// HandleDebugConfig returns the effective CNS configuration, with its secrets redacted, and the most recent reloads
+// of the config file.
+func (service *HTTPRestService) HandleDebugConfig(w http.ResponseWriter, r *http.Request) {
+	opName := "handleDebugConfig"
+	var resp GetConfigResponse
+	switch {
+	case r.Method != http.MethodGet:
+		resp.Response = Response{ReturnCode: types.UnsupportedVerb, Message: "[Azure-CNS] handleDebugConfig API expects a GET."}
+	case service.configReloader == nil:
+		resp.Response = Response{ReturnCode: types.UnexpectedError, Message: "[Azure-CNS] config reloader is not set"}
+	default:
+		resp.Config = configuration.Redacted(service.configReloader.Current())
+		resp.Reloads = service.configReloader.Events()
+	}
+	err := common.Encode(w, &resp)
+	service.logResponse(r.Context(), opName, resp, resp.Response.ReturnCode, err)
+}
+
+// HandleDebugPNIReservations returns how many of the pod slots reserved for each PodNetworkInstance are used.
+func (service *HTTPRestService) HandleDebugPNIReservations(w http.ResponseWriter, r *http.Request) {
+	opName := "handleDebugPNIReservations"
+	var resp cns.GetPNIReservationsResponse
+	switch {
+	case r.Method != http.MethodGet:
+		resp.Response = cns.Response{ReturnCode: types.UnsupportedVerb, Message: "[Azure-CNS] handleDebugPNIReservations API expects a GET."}
+	case service.pniReservations == nil:
+		resp.Response = cns.Response{ReturnCode: types.UnsupportedAPI, Message: "[Azure-CNS] PodNetworkInstance reservations are not enforced"}
+	default:
+		resp.Reservations = service.pniReservations.Usage()
+	}
+	err := common.Encode(w, &resp)
+	service.logResponse(r.Context(), opName, resp, resp.Response.ReturnCode, err)
+}
+
+// HandleDebugNodeSubnetIPs returns the recent changes in the secondary IPs of the NIC and the IPs assigned to Pods
+// which are no longer on it in NodeSubnet mode.
+func (service *HTTPRestService) HandleDebugNodeSubnetIPs(w http.ResponseWriter, r *http.Request) {
+	opName := "handleDebugNodeSubnetIPs"
+	var resp cns.GetNodeSubnetIPsResponse
+	switch {
+	case r.Method != http.MethodGet:
+		resp.Response = cns.Response{ReturnCode: types.UnsupportedVerb, Message: "[Azure-CNS] handleDebugNodeSubnetIPs API expects a GET."}
+	case service.nodesubnetIPFetcher == nil:
+		resp.Response = cns.Response{ReturnCode: types.UnsupportedAPI, Message: "[Azure-CNS] CNS is not in NodeSubnet mode"}
+	default:
+		resp.Churn = service.nodesubnetIPFetcher.History()
+		resp.Drifted = service.nodeSubnetDriftedIPs()
+	}
+	err := common.Encode(w, &resp)
+	service.logResponse(r.Context(), opName, resp, resp.Response.ReturnCode, err)
+}
+
+// HandleDebugRoutes returns the routes of the NCs which CNS programs on the host, as of the last reconcile.
+func (service *HTTPRestService) HandleDebugRoutes(w http.ResponseWriter, r *http.Request) {
+	opName := "handleDebugRoutes"
+	var resp cns.GetManagedRoutesResponse
+	routeManager := service.getRouteManager()
+	switch {
+	case r.Method != http.MethodGet:
+		resp.Response = cns.Response{ReturnCode: types.UnsupportedVerb, Message: "[Azure-CNS] handleDebugRoutes API expects a GET."}
+	case routeManager == nil:
+		resp.Response = cns.Response{ReturnCode: types.UnsupportedAPI, Message: "[Azure-CNS] host route management is not enabled"}
+	default:
+		resp.Routes, resp.LastReconcile = routeManager.Routes()
+	}
+	err := common.Encode(w, &resp)
+	service.logResponse(r.Context(), opName, resp, resp.Response.ReturnCode, err)
+}
+
+// HandleDebugNCHistory returns the NC history, oldest record first, only of the NC in the ncid query parameter if
+// there is one.
+func (service *HTTPRestService) HandleDebugNCHistory(w http.ResponseWriter, r *http.Request) {
+	opName := "handleDebugNCHistory"
+	var resp cns.GetNCHistoryResponse
+	if r.Method != http.MethodGet {
+		resp.Response = cns.Response{ReturnCode: types.UnsupportedVerb, Message: "[Azure-CNS] handleDebugNCHistory API expects a GET."}
+	} else {
+		resp.Records = service.ncHistory(r.URL.Query().Get(cns.NCHistoryNCIDParam))
+	}
+	err := common.Encode(w, &resp)
+	service.logResponse(r.Context(), opName, resp, resp.Response.ReturnCode, err)
+}
+
+// HandleDebugIPAudit returns the discrepancies between the IPs assigned by CNS, the CNI state and the API server
+// found by the last IP audit.
+func (service *HTTPRestService) HandleDebugIPAudit(w http.ResponseWriter, r *http.Request) {
+	opName := "handleDebugIPAudit"
+	var resp cns.GetIPAuditResponse
+	switch {
+	case r.Method != http.MethodGet:
+		resp.Response = cns.Response{ReturnCode: types.UnsupportedVerb, Message: "[Azure-CNS] handleDebugIPAudit API expects a GET."}
+	case service.ipAuditor == nil:
+		resp.Response = cns.Response{ReturnCode: types.UnsupportedAPI, Message: "[Azure-CNS] IP audit is not enabled"}
+	default:
+		var err error
+		if resp.Discrepancies, resp.LastAudit, err = service.ipAuditor.Discrepancies(); err != nil {
+			resp.LastError = err.Error()
+		}
+	}
+	err := common.Encode(w, &resp)
+	service.logResponse(r.Context(), opName, resp, resp.Response.ReturnCode, err)
+}
+
+// HandleDebugLogLevel gets or changes the log levels of CNS at runtime. Unlike the other debug APIs it
+// speaks the log level schema shared with NPM, so that the same tooling can drive both.
+func (service *HTTPRestService) HandleDebugLogLevel(w http.ResponseWriter, r *http.Request) {
+	if service.logLevelHandler == nil {
+		http.Error(w, "log level control is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	service.logLevelHandler.ServeHTTP(w, r)
 }
 
 func extractNCParamsFromURL(networkContainerURL string) (cns.NetworkContainerParameters, error) {
@@ -918,7 +1037,7 @@ func (service *HTTPRestService) publishNetworkContainer(w http.ResponseWriter, r
 		return
 	}
 
-	logger.Request(service.Name, req, nil)
+	service.logRequest(r.Context(), service.Name, req, nil)
 
 	ncParams, err := extractNCParamsFromURL(req.CreateNetworkContainerURL)
 	if err != nil {
@@ -929,7 +1048,7 @@ func (service *HTTPRestService) publishNetworkContainer(w http.ResponseWriter, r
 			},
 		}
 		respondJSON(w, http.StatusBadRequest, resp)
-		logger.Response(service.Name, resp, resp.Response.ReturnCode, err)
+		service.logResponse(r.Context(), service.Name, resp, resp.Response.ReturnCode, err)
 		return
 	}
 
@@ -945,7 +1064,7 @@ func (service *HTTPRestService) publishNetworkContainer(w http.ResponseWriter, r
 			PublishErrorStr: err.Error(),
 		}
 		respondJSON(w, http.StatusOK, resp) // legacy behavior
-		logger.Response(service.Name, resp, resp.Response.ReturnCode, err)
+		service.logResponse(r.Context(), service.Name, resp, resp.Response.ReturnCode, err)
 		return
 	}
 
@@ -962,7 +1081,7 @@ func (service *HTTPRestService) publishNetworkContainer(w http.ResponseWriter, r
 			PublishResponseBody: joinBytes,
 		}
 		respondJSON(w, http.StatusOK, resp) // legacy behavior
-		logger.Response(service.Name, resp, resp.Response.ReturnCode, nil)
+		service.logResponse(r.Context(), service.Name, resp, resp.Response.ReturnCode, nil)
 		return
 	}
 
@@ -979,7 +1098,7 @@ func (service *HTTPRestService) publishNetworkContainer(w http.ResponseWriter, r
 			PublishErrorStr: err.Error(),
 		}
 		respondJSON(w, http.StatusOK, resp) // legacy behavior
-		logger.Response(service.Name, resp, resp.Response.ReturnCode, err)
+		service.logResponse(r.Context(), service.Name, resp, resp.Response.ReturnCode, err)
 		return
 	}
 
@@ -999,7 +1118,7 @@ func (service *HTTPRestService) publishNetworkContainer(w http.ResponseWriter, r
 	}
 
 	respondJSON(w, http.StatusOK, resp)
-	logger.Response(service.Name, resp, resp.Response.ReturnCode, nil)
+	service.logResponse(r.Context(), service.Name, resp, resp.Response.ReturnCode, nil)
 }
 
 func (service *HTTPRestService) unpublishNetworkContainer(w http.ResponseWriter, r *http.Request) {
@@ -1014,7 +1133,7 @@ func (service *HTTPRestService) unpublishNetworkContainer(w http.ResponseWriter,
 		return
 	}
 
-	logger.Request(service.Name, req, nil)
+	service.logRequest(r.Context(), service.Name, req, nil)
 
 	ncParams, err := extractNCParamsFromURL(req.DeleteNetworkContainerURL)
 	if err != nil {
@@ -1025,7 +1144,7 @@ func (service *HTTPRestService) unpublishNetworkContainer(w http.ResponseWriter,
 			},
 		}
 		respondJSON(w, http.StatusBadRequest, resp)
-		logger.Response(service.Name, resp, resp.Response.ReturnCode, err)
+		service.logResponse(r.Context(), service.Name, resp, resp.Response.ReturnCode, err)
 		return
 	}
 
@@ -1063,7 +1182,7 @@ func (service *HTTPRestService) unpublishNetworkContainer(w http.ResponseWriter,
 				UnpublishErrorStr: err.Error(),
 			}
 			respondJSON(w, http.StatusOK, resp) // legacy behavior
-			logger.Response(service.Name, resp, resp.Response.ReturnCode, err)
+			service.logResponse(r.Context(), service.Name, resp, resp.Response.ReturnCode, err)
 			return
 		}
 
@@ -1080,7 +1199,7 @@ func (service *HTTPRestService) unpublishNetworkContainer(w http.ResponseWriter,
 				UnpublishResponseBody: joinBytes,
 			}
 			respondJSON(w, http.StatusOK, resp) // legacy behavior
-			logger.Response(service.Name, resp, resp.Response.ReturnCode, nil)
+			service.logResponse(r.Context(), service.Name, resp, resp.Response.ReturnCode, nil)
 			return
 		}
 
@@ -1098,7 +1217,7 @@ func (service *HTTPRestService) unpublishNetworkContainer(w http.ResponseWriter,
 			UnpublishErrorStr: err.Error(),
 		}
 		respondJSON(w, http.StatusOK, resp) // legacy behavior
-		logger.Response(service.Name, resp, resp.Response.ReturnCode, err)
+		service.logResponse(r.Context(), service.Name, resp, resp.Response.ReturnCode, err)
 		return
 	}
 
@@ -1118,7 +1237,7 @@ func (service *HTTPRestService) unpublishNetworkContainer(w http.ResponseWriter,
 	}
 
 	respondJSON(w, http.StatusOK, resp)
-	logger.Response(service.Name, resp, resp.Response.ReturnCode, nil)
+	service.logResponse(r.Context(), service.Name, resp, resp.Response.ReturnCode, nil)
 }
 
 func (service *HTTPRestService) CreateHostNCApipaEndpoint(w http.ResponseWriter, r *http.Request) {
@@ -1133,7 +1252,7 @@ func (service *HTTPRestService) CreateHostNCApipaEndpoint(w http.ResponseWriter,
 	)
 
 	err = common.Decode(w, r, &req)
-	logger.Request(service.Name, &req, err)
+	service.logRequest(r.Context(), service.Name, &req, err)
 	if err != nil {
 		return
 	}
@@ -1177,7 +1296,7 @@ func (service *HTTPRestService) CreateHostNCApipaEndpoint(w http.ResponseWriter,
 	}
 
 	err = common.Encode(w, &response)
-	logger.Response(service.Name, response, response.Response.ReturnCode, err)
+	service.logResponse(r.Context(), service.Name, response, response.Response.ReturnCode, err)
 }
 
 func (service *HTTPRestService) DeleteHostNCApipaEndpoint(w http.ResponseWriter, r *http.Request) {
@@ -1191,7 +1310,7 @@ func (service *HTTPRestService) DeleteHostNCApipaEndpoint(w http.ResponseWriter,
 	)
 
 	err = common.Decode(w, r, &req)
-	logger.Request(service.Name, &req, err)
+	service.logRequest(r.Context(), service.Name, &req, err)
 	if err != nil {
 		return
 	}
@@ -1216,12 +1335,12 @@ func (service *HTTPRestService) DeleteHostNCApipaEndpoint(w http.ResponseWriter,
 	}
 
 	err = common.Encode(w, &response)
-	logger.Response(service.Name, response, response.Response.ReturnCode, err)
+	service.logResponse(r.Context(), service.Name, response, response.Response.ReturnCode, err)
 }
 
 // This function is used to query NMagents's supported APIs list
 func (service *HTTPRestService) nmAgentSupportedApisHandler(w http.ResponseWriter, r *http.Request) {
-	logger.Request(service.Name, "nmAgentSupportedApisHandler", nil)
+	service.logRequest(r.Context(), service.Name, "nmAgentSupportedApisHandler", nil)
 	var (
 		err, retErr   error
 		req           cns.NmAgentSupportedApisRequest
@@ -1233,7 +1352,7 @@ func (service *HTTPRestService) nmAgentSupportedApisHandler(w http.ResponseWrite
 	ctx := r.Context()
 
 	err = common.Decode(w, r, &req)
-	logger.Request(service.Name, &req, err)
+	service.logRequest(r.Context(), service.Name, &req, err)
 	if err != nil {
 		return
 	}
@@ -1259,12 +1378,12 @@ func (service *HTTPRestService) nmAgentSupportedApisHandler(w http.ResponseWrite
 
 	serviceErr := common.Encode(w, &nmAgentSupportedApisResponse)
 
-	logger.Response(service.Name, nmAgentSupportedApisResponse, resp.ReturnCode, serviceErr)
+	service.logResponse(r.Context(), service.Name, nmAgentSupportedApisResponse, resp.ReturnCode, serviceErr)
 }
 
 // getVMUniqueID retrieves VMUniqueID from the IMDS
 func (service *HTTPRestService) getVMUniqueID(w http.ResponseWriter, r *http.Request) {
-	logger.Request(service.Name, "getVMUniqueID", nil)
+	service.logRequest(r.Context(), service.Name, "getVMUniqueID", nil)
 	ctx := r.Context()
 
 	switch r.Method {
@@ -1278,7 +1397,7 @@ func (service *HTTPRestService) getVMUniqueID(w http.ResponseWriter, r *http.Req
 				},
 			}
 			respondJSON(w, http.StatusInternalServerError, resp)
-			logger.Response(service.Name, resp, resp.Response.ReturnCode, err)
+			service.logResponse(r.Context(), service.Name, resp, resp.Response.ReturnCode, err)
 			return
 		}
 
@@ -1289,13 +1408,13 @@ func (service *HTTPRestService) getVMUniqueID(w http.ResponseWriter, r *http.Req
 			VMUniqueID: vmUniqueID,
 		}
 		respondJSON(w, http.StatusOK, resp)
-		logger.Response(service.Name, resp, resp.Response.ReturnCode, err)
+		service.logResponse(r.Context(), service.Name, resp, resp.Response.ReturnCode, err)
 
 	default:
 		returnMessage := fmt.Sprintf("[Azure CNS] Error. getVMUniqueID did not receive a GET."+
 			" Received: %s", r.Method)
 		returnCode := types.UnsupportedVerb
-		service.setResponse(w, returnCode, cns.GetHomeAzResponse{
+		service.setResponse(w, r, returnCode, cns.GetHomeAzResponse{
 			Response: cns.Response{ReturnCode: returnCode, Message: returnMessage},
 		})
 	}
@@ -1303,7 +1422,7 @@ func (service *HTTPRestService) getVMUniqueID(w http.ResponseWriter, r *http.Req
 
 // This function is used to query all NCs on a node from NMAgent
 func (service *HTTPRestService) nmAgentNCListHandler(w http.ResponseWriter, r *http.Request) {
-	logger.Request(service.Name, "nmAgentNCListHandler", nil)
+	service.logRequest(r.Context(), service.Name, "nmAgentNCListHandler", nil)
 	var (
 		returnCode           types.ResponseCode
 		networkContainerList []string
@@ -1335,5 +1454,5 @@ func (service *HTTPRestService) nmAgentNCListHandler(w http.ResponseWriter, r *h
 	}
 
 	serviceErr := common.Encode(w, &NCListResponse)
-	logger.Response(service.Name, NCListResponse, resp.ReturnCode, serviceErr)
+	service.logResponse(r.Context(), service.Name, NCListResponse, resp.ReturnCode, serviceErr)
 }
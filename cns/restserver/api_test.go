@@ -14,6 +14,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"testing"
@@ -23,8 +24,10 @@ import (
 	"github.com/Azure/azure-container-networking/cns/configuration"
 	"github.com/Azure/azure-container-networking/cns/fakes"
 	"github.com/Azure/azure-container-networking/cns/logger"
+	loggerv2 "github.com/Azure/azure-container-networking/cns/logger/v2"
 	"github.com/Azure/azure-container-networking/cns/types"
 	acncommon "github.com/Azure/azure-container-networking/common"
+	logv2 "github.com/Azure/azure-container-networking/log/v2"
 	"github.com/Azure/azure-container-networking/nmagent"
 	"github.com/Azure/azure-container-networking/processlock"
 	"github.com/Azure/azure-container-networking/store"
@@ -33,9 +36,8 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-const (
-	cnsJsonFileName = "azure-cns.json"
-)
+// cnsJsonFileName is the state store of the test service, in a directory which TestMain removes once the tests ran.
+var cnsJsonFileName string
 
 type IPAddress struct {
 	XMLName   xml.Name `xml:"IPAddress"`
@@ -174,6 +176,13 @@ func TestMain(m *testing.M) {
 	var err error
 	logger.InitLogger("testlogs", 0, 0, "./")
 
+	stateDir, err := os.MkdirTemp("", "azure-cns")
+	if err != nil {
+		fmt.Printf("Failed to create CNS state directory. Error: %v", err)
+		os.Exit(1)
+	}
+	cnsJsonFileName = filepath.Join(stateDir, "azure-cns.json")
+
 	// Create the service. If CRD channel mode is needed, then at the start of the test,
 	// it can stop the service (service.Stop), invoke startService again with new ServiceConfig (with CRD mode)
 	// perform the test and then restore the service again.
@@ -208,6 +217,7 @@ func TestMain(m *testing.M) {
 	// Cleanup.
 	service.Stop()
 	nmAgentServer.Stop()
+	os.RemoveAll(stateDir)
 
 	os.Exit(exitCode)
 }
@@ -664,6 +674,71 @@ func TestGetNumOfCPUCores(t *testing.T) {
 	}
 }
 
+func TestHandleDebugConfig(t *testing.T) {
+	getConfig := func() GetConfigResponse {
+		req, err := http.NewRequest(http.MethodGet, cns.PathDebugConfig, http.NoBody)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		var resp GetConfigResponse
+		require.NoError(t, decodeResponse(w, &resp))
+		return resp
+	}
+
+	resp := getConfig()
+	require.Equal(t, types.UnexpectedError, resp.Response.ReturnCode)
+
+	path := filepath.Join(t.TempDir(), "cns_config.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"SyncHostNCVersionIntervalMs":1000}`), 0o600))
+	current := &configuration.CNSConfig{
+		SyncHostNCVersionIntervalMs: 1000,
+		TelemetrySettings:           configuration.TelemetrySettings{AppInsightsInstrumentationKey: "ikey"},
+	}
+	reloader, err := configuration.NewReloader(path, current)
+	require.NoError(t, err)
+	svc.SetConfigReloader(reloader)
+	defer svc.SetConfigReloader(nil)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"SyncHostNCVersionIntervalMs":250}`), 0o600))
+	require.NotNil(t, reloader.Reload())
+
+	resp = getConfig()
+	require.Equal(t, types.Success, resp.Response.ReturnCode)
+	require.Equal(t, 250, resp.Config.SyncHostNCVersionIntervalMs)
+	require.Equal(t, "REDACTED", resp.Config.TelemetrySettings.AppInsightsInstrumentationKey)
+	require.Equal(t, "ikey", reloader.Current().TelemetrySettings.AppInsightsInstrumentationKey)
+	require.Len(t, resp.Reloads, 1)
+	require.Equal(t, "SyncHostNCVersionIntervalMs", resp.Reloads[0].Changes[0].Field)
+}
+
+func TestHandleDebugLogLevel(t *testing.T) {
+	serve := func(method, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, cns.PathDebugLogLevel, strings.NewReader(body))
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	require.Equal(t, http.StatusServiceUnavailable, serve(http.MethodGet, "").Code)
+
+	cfg := &loggerv2.Config{Level: "info"}
+	_, closer, err := loggerv2.New(cfg)
+	require.NoError(t, err)
+	defer closer()
+	svc.SetLogLevelHandler(logv2.NewLevelHandler(cfg, loggerv2.Components, nil))
+	defer svc.SetLogLevelHandler(nil)
+
+	w := serve(http.MethodPut, `{"components":{"ipampool":"debug"},"ttl":"1h"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp logv2.LevelResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Equal(t, map[string]string{loggerv2.ComponentIPAMPool: "debug"}, resp.Components)
+	require.NotNil(t, resp.RevertAt)
+
+	require.Equal(t, http.StatusBadRequest, serve(http.MethodPut, `{"components":{"dataplane":"debug"}}`).Code)
+}
+
 func TestGetNetworkContainerVersionStatus(t *testing.T) {
 	setEnv(t)
 	setOrchestratorType(t, cns.Kubernetes)
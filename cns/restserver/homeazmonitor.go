@@ -10,6 +10,7 @@ import (
 	"github.com/Azure/azure-container-networking/cns/logger"
 	"github.com/Azure/azure-container-networking/cns/types"
 	"github.com/Azure/azure-container-networking/nmagent"
+	"github.com/Azure/azure-container-networking/refresh"
 	"github.com/patrickmn/go-cache"
 	"github.com/pkg/errors"
 )
@@ -18,24 +19,42 @@ const (
 	GetHomeAzAPIName = "GetHomeAz"
 	ContextTimeOut   = 5 * time.Second
 	homeAzCacheKey   = "HomeAz"
+	// failed refreshes back off up to this many times the configured interval
+	homeAzMaxErrorIntervalFactor = 16
+	// number of consecutive failures after which NMAgent is left alone for homeAzCooloff
+	homeAzFailureThreshold = 5
+	homeAzCooloff          = 10 * time.Minute
 )
 
+// HomeAzMonitor caches the home az of the VM, refreshing it from NMAgent at the configured interval. Refreshes are
+// jittered so that nodes do not call NMAgent in lockstep, and back off when NMAgent fails.
 type HomeAzMonitor struct {
 	nmagentClient
-	values *cache.Cache
-	// channel used as signal to end of the goroutine for populating home az cache
-	closing                  chan struct{}
-	cacheRefreshIntervalSecs time.Duration
+	values  *cache.Cache
+	fetcher *refresh.Fetcher[homeAz]
+	// cancel ends the refreshes, nil until started
+	cancel context.CancelFunc
+}
+
+// homeAz is a home az response as cached by the HomeAzMonitor.
+type homeAz cns.GetHomeAzResponse
+
+func (h homeAz) Equal(other homeAz) bool {
+	return h == other
 }
 
 // NewHomeAzMonitor creates a new HomeAzMonitor object
 func NewHomeAzMonitor(client nmagentClient, cacheRefreshIntervalSecs time.Duration) *HomeAzMonitor {
-	return &HomeAzMonitor{
-		nmagentClient:            client,
-		cacheRefreshIntervalSecs: cacheRefreshIntervalSecs,
-		values:                   cache.New(cache.NoExpiration, cache.NoExpiration),
-		closing:                  make(chan struct{}),
+	h := &HomeAzMonitor{
+		nmagentClient: client,
+		values:        cache.New(cache.NoExpiration, cache.NoExpiration),
 	}
+	h.fetcher = refresh.NewFetcher[homeAz](h.fetch, cacheRefreshIntervalSecs, cacheRefreshIntervalSecs, nil, logger.Log,
+		refresh.WithJitter(refresh.EqualJitter),
+		refresh.WithErrorBackoff(homeAzMaxErrorIntervalFactor*cacheRefreshIntervalSecs),
+		refresh.WithCircuitBreaker(homeAzFailureThreshold, homeAzCooloff),
+	)
+	return h
 }
 
 // GetHomeAz returns home az cache value directly
@@ -63,33 +82,28 @@ func (h *HomeAzMonitor) readCacheValue() cns.GetHomeAzResponse {
 // Start starts a new thread to refresh home az cache
 func (h *HomeAzMonitor) Start() {
 	logger.Printf("[HomeAzMonitor] start the goroutine for refreshing homeAz")
-	go h.refresh()
+	var ctx context.Context
+	ctx, h.cancel = context.WithCancel(context.Background())
+	h.fetcher.Start(ctx)
 }
 
 // Stop ends the refresh thread
 func (h *HomeAzMonitor) Stop() {
-	close(h.closing)
+	if h.cancel != nil {
+		h.cancel()
+	}
 }
 
-// refresh periodically pulls home az from nmagent
-func (h *HomeAzMonitor) refresh() {
-	// Ticker will not tick right away, so proactively make a call here to achieve that
-	ctx, cancel := context.WithTimeout(context.Background(), ContextTimeOut)
+// fetch populates the home az cache, failing if the home az could not be retrieved so that the refreshes back off.
+func (h *HomeAzMonitor) fetch(ctx context.Context) (homeAz, error) {
+	ctx, cancel := context.WithTimeout(ctx, ContextTimeOut)
+	defer cancel()
 	h.Populate(ctx)
-	cancel()
-
-	ticker := time.NewTicker(h.cacheRefreshIntervalSecs)
-	defer ticker.Stop()
-	for {
-		select {
-		case <-h.closing:
-			return
-		case <-ticker.C:
-			ctx, cancel = context.WithTimeout(context.Background(), ContextTimeOut)
-			h.Populate(ctx)
-			cancel()
-		}
+	resp := h.readCacheValue()
+	if resp.Response.ReturnCode != types.Success {
+		return homeAz(resp), errors.Errorf("failed to populate home az: %s", resp.Response.Message)
 	}
+	return homeAz(resp), nil
 }
 
 // Populate makes call to nmagent to retrieve home az if getHomeAz api is supported by nmagent
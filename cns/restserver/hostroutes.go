@@ -0,0 +1,64 @@
+package restserver
+
+import (
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/logger"
+)
+
+type hostRouteManager interface {
+	Refresh()
+	Routes() ([]cns.ManagedRoute, time.Time)
+}
+
+// hostRoutesMiddleware is implemented by the IP configs middlewares which need routes on the host.
+type hostRoutesMiddleware interface {
+	HostRoutes() ([]cns.Route, error)
+}
+
+// SetRouteManager sets the manager programming the routes of the NCs on the host, which is refreshed whenever an NC
+// is saved or deleted. The manager should get the desired routes from DesiredHostRoutes.
+func (service *HTTPRestService) SetRouteManager(manager hostRouteManager) {
+	service.Lock()
+	defer service.Unlock()
+	service.routeManager = manager
+}
+
+// getRouteManager returns the manager programming the routes of the NCs on the host, or nil if there is none.
+func (service *HTTPRestService) getRouteManager() hostRouteManager {
+	service.RLock()
+	defer service.RUnlock()
+	return service.routeManager
+}
+
+// DesiredHostRoutes returns the host routes of the goal state of each NC by NC ID, and the host routes of the IP
+// configs middleware by its type. The Routes of an NC and those the middleware adds to the IP configurations of Pods
+// are programmed by CNI in the network namespace of the Pod, and are not returned.
+func (service *HTTPRestService) DesiredHostRoutes() map[string][]cns.Route {
+	service.RLock()
+	defer service.RUnlock()
+	desired := map[string][]cns.Route{}
+	for ncID, nc := range service.state.ContainerStatus { //nolint:gocritic // ignore copy
+		if len(nc.CreateNetworkContainerRequest.HostRoutes) > 0 {
+			desired[ncID] = nc.CreateNetworkContainerRequest.HostRoutes
+		}
+	}
+	if middleware, ok := service.IPConfigsHandlerMiddleware.(hostRoutesMiddleware); ok {
+		routes, err := middleware.HostRoutes()
+		if err != nil {
+			logger.Errorf("[Azure CNS] Failed to get the host routes of the %s middleware: %v", service.IPConfigsHandlerMiddleware.Type(), err)
+		} else if len(routes) > 0 {
+			desired[string(service.IPConfigsHandlerMiddleware.Type())] = routes
+		}
+	}
+	return desired
+}
+
+// refreshHostRoutes reconciles the routes of the NCs on the host once their goal state changed. Must be called with
+// the lock held.
+func (service *HTTPRestService) refreshHostRoutes() {
+	if service.routeManager != nil {
+		service.routeManager.Refresh()
+	}
+}
@@ -0,0 +1,35 @@
+package restserver
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/configuration"
+	"github.com/Azure/azure-container-networking/cns/middlewares"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDesiredHostRoutes(t *testing.T) {
+	t.Setenv(configuration.EnvInfraVNETCIDRs, "10.240.0.0/16")
+	t.Setenv(configuration.EnvServiceCIDRs, "10.0.0.0/16")
+	service := getTestService(cns.KubernetesCRD)
+	service.state.ContainerStatus = map[string]containerstatus{
+		"nc1": {CreateNetworkContainerRequest: cns.CreateNetworkContainerRequest{
+			// routes of the pod netns are not programmed on the host
+			Routes:     []cns.Route{{IPAddress: "0.0.0.0/0", GatewayIPAddress: "10.1.0.1"}},
+			HostRoutes: []cns.Route{{IPAddress: "10.2.0.0/16", GatewayIPAddress: "10.1.0.1"}},
+		}},
+		"nc2": {CreateNetworkContainerRequest: cns.CreateNetworkContainerRequest{
+			Routes: []cns.Route{{IPAddress: "0.0.0.0/0", GatewayIPAddress: "10.3.0.1"}},
+		}},
+	}
+	service.AttachIPConfigsHandlerMiddleware(&middlewares.K8sSWIFTv2Middleware{InfraGatewayV4: "10.224.0.1"})
+
+	assert.Equal(t, map[string][]cns.Route{
+		"nc1": {{IPAddress: "10.2.0.0/16", GatewayIPAddress: "10.1.0.1"}},
+		string(cns.K8sSWIFTV2): {
+			{IPAddress: "10.240.0.0/16", GatewayIPAddress: "10.224.0.1"},
+			{IPAddress: "10.0.0.0/16", GatewayIPAddress: "10.224.0.1"},
+		},
+	}, service.DesiredHostRoutes())
+}
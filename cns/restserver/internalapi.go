@@ -118,7 +118,7 @@ func (service *HTTPRestService) SyncNodeStatus(dncEP, infraVnet, nodeID string,
 			if err != nil {
 				logger.Errorf("[Azure-CNS] Failed to marshal nc with nc id %s and content %v", ncid, ncsToBeAdded[ncid])
 			}
-			req, err = http.NewRequestWithContext(ctx, http.MethodPost, "", bytes.NewBuffer(body))
+			req, err = http.NewRequestWithContext(withNCHistorySource(ctx, cns.NCHistorySourceManagedSync), http.MethodPost, "", bytes.NewBuffer(body))
 			if err != nil {
 				logger.Errorf("[Azure CNS] Error received while creating http POST request for nc %v", ncsToBeAdded[ncid])
 			}
@@ -150,7 +150,7 @@ func (service *HTTPRestService) SyncNodeStatus(dncEP, infraVnet, nodeID string,
 		var body bytes.Buffer
 		json.NewEncoder(&body).Encode(&cns.DeleteNetworkContainerRequest{NetworkContainerid: nc})
 
-		req, err = http.NewRequest(http.MethodPost, "", &body)
+		req, err = http.NewRequestWithContext(withNCHistorySource(context.TODO(), cns.NCHistorySourceManagedSync), http.MethodPost, "", &body)
 		if err == nil {
 			req.Header.Set(common.JsonContent, common.JsonContent)
 			service.deleteNetworkContainer(httptest.NewRecorder(), req)
@@ -163,7 +163,8 @@ func (service *HTTPRestService) SyncNodeStatus(dncEP, infraVnet, nodeID string,
 
 // SyncHostNCVersion will check NC version from NMAgent and save it as host NC version in container status.
 // If NMAgent NC version got updated, CNS will refresh the pending programming IP status.
-func (service *HTTPRestService) SyncHostNCVersion(ctx context.Context, channelMode string) {
+// The error of the sync is logged and returned.
+func (service *HTTPRestService) SyncHostNCVersion(ctx context.Context, channelMode string) error {
 	service.Lock()
 	defer service.Unlock()
 	start := time.Now()
@@ -179,6 +180,7 @@ func (service *HTTPRestService) SyncHostNCVersion(ctx context.Context, channelMo
 	}
 	syncHostNCVersionCount.WithLabelValues(strconv.FormatBool(err == nil)).Inc()
 	syncHostNCVersionLatency.WithLabelValues(strconv.FormatBool(err == nil)).Observe(time.Since(start).Seconds())
+	return err
 }
 
 var errNonExistentContainerStatus = errors.New("nonExistantContainerstatus")
@@ -190,6 +192,13 @@ var errNonExistentContainerStatus = errors.New("nonExistantContainerstatus")
 func (service *HTTPRestService) syncHostNCVersion(ctx context.Context, channelMode string) (int, error) {
 	outdatedNCs := map[string]struct{}{}
 	programmedNCs := map[string]struct{}{}
+	updated := false
+	defer func() {
+		// persist the updated host versions along with their NC history
+		if updated {
+			_ = service.saveState()
+		}
+	}()
 	for idx := range service.state.ContainerStatus {
 		// Will open a separate PR to convert all the NC version related variable to int. Change from string to int is a pain.
 		localNCVersion, err := strconv.Atoi(service.state.ContainerStatus[idx].HostVersion)
@@ -262,9 +271,12 @@ func (service *HTTPRestService) syncHostNCVersion(ctx context.Context, channelMo
 			service.MarkIpsAsAvailableUntransacted(ncInfo.ID, nmaNCVersion)
 		}
 		logger.Printf("Updating NC %s host version from %s to %s", ncID, ncInfo.HostVersion, nmaNCVersionStr)
+		previous := ncInfo
 		ncInfo.HostVersion = nmaNCVersionStr
 		logger.Printf("Updated NC %s host version to %s", ncID, ncInfo.HostVersion)
 		service.state.ContainerStatus[ncID] = ncInfo
+		service.recordNCHistoryUntransacted(newNCHistoryRecord(cns.NCHistorySourceHostVersionSync, ncID, &previous, &ncInfo, types.Success))
+		updated = true
 		// if we successfully updated the NC, pop it from the needs update set.
 		delete(outdatedNCs, ncID)
 	}
@@ -360,7 +372,8 @@ func (service *HTTPRestService) ReconcileIPAssignment(podInfoByIP map[string]cns
 			PodInterfaceID:      podIPs.InterfaceID(),
 		}
 
-		if _, err := requestIPConfigsHelper(service, ipconfigsRequest); err != nil {
+		// the desired IPs are assigned without probing, which is all the context bounds
+		if _, err := requestIPConfigsHelper(context.Background(), service, ipconfigsRequest); err != nil {
 			logger.Errorf("requestIPConfigsHelper failed for pod key %s, podInfo %+v, ncIds %v, error: %v", podKey, podIPs, ncIDs, err)
 			return types.FailedToAllocateIPConfig
 		}
@@ -492,7 +505,7 @@ func (service *HTTPRestService) DeleteNetworkContainerInternal(
 	req cns.DeleteNetworkContainerRequest,
 ) types.ResponseCode {
 	ncid := req.NetworkContainerid
-	_, exist := service.getNetworkContainerDetails(ncid)
+	existing, exist := service.getNetworkContainerDetails(ncid)
 	if !exist {
 		logger.Printf("network container for id %v doesn't exist", ncid)
 		return types.Success
@@ -503,6 +516,8 @@ func (service *HTTPRestService) DeleteNetworkContainerInternal(
 	if service.state.ContainerStatus != nil {
 		delete(service.state.ContainerStatus, ncid)
 	}
+	service.recordNCHistoryUntransacted(newNCHistoryRecord(cns.NCHistorySourceCRD, ncid, &existing, nil, types.Success))
+	service.refreshHostRoutes()
 
 	if service.state.ContainerIDByOrchestratorContext != nil {
 		for orchestratorContext, networkContainerIDs := range service.state.ContainerIDByOrchestratorContext { //nolint:gocritic // copy is ok
@@ -537,7 +552,7 @@ func (service *HTTPRestService) MustEnsureNoStaleNCs(validNCIDs []string) {
 	}
 
 	mutated := false
-	for ncID := range service.state.ContainerStatus {
+	for ncID, nc := range service.state.ContainerStatus { //nolint:gocritic // ignore copy
 		if _, ok := valid[ncID]; !ok {
 			// stale NCs with assigned IPs are an unexpected CNS state which we need to alert on.
 			if assignedIPs, hasAssignedIPs := ncIDToAssignedIPs[ncID]; hasAssignedIPs {
@@ -548,12 +563,14 @@ func (service *HTTPRestService) MustEnsureNoStaleNCs(validNCIDs []string) {
 
 			logger.Errorf("[Azure CNS] Found stale NC ID %s in CNS state. Removing...", ncID)
 			delete(service.state.ContainerStatus, ncID)
+			service.recordNCHistoryUntransacted(newNCHistoryRecord(cns.NCHistorySourceStaleNCCleanup, ncID, &nc, nil, types.Success))
 			mutated = true
 		}
 	}
 
 	if mutated {
 		_ = service.saveState()
+		service.refreshHostRoutes()
 	}
 }
 
@@ -611,7 +628,7 @@ func (service *HTTPRestService) CreateOrUpdateNetworkContainerInternal(req *cns.
 	}
 
 	// This will Create Or Update the NC state.
-	returnCode, returnMessage := service.saveNetworkContainerGoalState(*req)
+	returnCode, returnMessage := service.saveNetworkContainerGoalState(cns.NCHistorySourceCRD, *req)
 
 	// If the NC was created successfully, log NC snapshot.
 	if returnCode == 0 {
@@ -72,7 +72,7 @@ func (service *HTTPRestService) requestIPConfigHandlerHelper(ctx context.Context
 
 	// record a pod requesting an IP
 	service.podsPendingIPAssignment.Push(podInfo.Key())
-	podIPInfo, err := requestIPConfigsHelper(service, ipconfigsRequest) //nolint:contextcheck // appease linter for revert PR
+	podIPInfo, err := requestIPConfigsHelper(ctx, service, ipconfigsRequest)
 	if err != nil {
 		return &cns.IPConfigsResponse{
 			Response: cns.Response{
@@ -200,7 +200,7 @@ func (service *HTTPRestService) RequestIPConfigHandler(w http.ResponseWriter, r
 	defer service.publishIPStateMetrics()
 	var ipconfigRequest cns.IPConfigRequest
 	err := common.Decode(w, r, &ipconfigRequest)
-	logger.Request(opName, ipconfigRequest, err)
+	service.logRequest(r.Context(), opName, ipconfigRequest, err)
 	if err != nil {
 		return
 	}
@@ -216,7 +216,7 @@ func (service *HTTPRestService) RequestIPConfigHandler(w http.ResponseWriter, r
 		}
 		w.Header().Set(cnsReturnCode, reserveResp.Response.ReturnCode.String())
 		err = common.Encode(w, &reserveResp)
-		logger.ResponseEx(opName, ipconfigRequest, reserveResp, reserveResp.Response.ReturnCode, err)
+		service.logResponseEx(r.Context(), opName, ipconfigRequest, reserveResp, reserveResp.Response.ReturnCode, err)
 		return
 	}
 
@@ -241,7 +241,7 @@ func (service *HTTPRestService) RequestIPConfigHandler(w http.ResponseWriter, r
 		}
 		w.Header().Set(cnsReturnCode, reserveResp.Response.ReturnCode.String())
 		err = common.Encode(w, &reserveResp)
-		logger.ResponseEx(opName, ipconfigsRequest, reserveResp, reserveResp.Response.ReturnCode, err)
+		service.logResponseEx(r.Context(), opName, ipconfigsRequest, reserveResp, reserveResp.Response.ReturnCode, err)
 		return
 	}
 
@@ -257,7 +257,7 @@ func (service *HTTPRestService) RequestIPConfigHandler(w http.ResponseWriter, r
 		}
 		w.Header().Set(cnsReturnCode, reserveResp.Response.ReturnCode.String())
 		err = common.Encode(w, &reserveResp)
-		logger.ResponseEx(opName, ipconfigRequest, reserveResp, reserveResp.Response.ReturnCode, err)
+		service.logResponseEx(r.Context(), opName, ipconfigRequest, reserveResp, reserveResp.Response.ReturnCode, err)
 		return
 	}
 	// As this API is expected to return IPConfigResponse, generate it from the IPConfigsResponse returned above.
@@ -267,7 +267,7 @@ func (service *HTTPRestService) RequestIPConfigHandler(w http.ResponseWriter, r
 	}
 	w.Header().Set(cnsReturnCode, reserveResp.Response.ReturnCode.String())
 	err = common.Encode(w, &reserveResp)
-	logger.ResponseEx(opName, ipconfigsRequest, reserveResp, reserveResp.Response.ReturnCode, err)
+	service.logResponseEx(r.Context(), opName, ipconfigsRequest, reserveResp, reserveResp.Response.ReturnCode, err)
 }
 
 // RequestIPConfigsHandler requests multiple IPConfigs from the CNS state
@@ -276,7 +276,7 @@ func (service *HTTPRestService) RequestIPConfigsHandler(w http.ResponseWriter, r
 	defer service.publishIPStateMetrics()
 	var ipconfigsRequest cns.IPConfigsRequest
 	err := common.Decode(w, r, &ipconfigsRequest)
-	logger.Request(opName, ipconfigsRequest, err)
+	service.logRequest(r.Context(), opName, ipconfigsRequest, err)
 	if err != nil {
 		return
 	}
@@ -302,13 +302,13 @@ func (service *HTTPRestService) RequestIPConfigsHandler(w http.ResponseWriter, r
 	if err != nil {
 		w.Header().Set(cnsReturnCode, ipConfigsResp.Response.ReturnCode.String())
 		err = common.Encode(w, &ipConfigsResp)
-		logger.ResponseEx(opName, ipconfigsRequest, ipConfigsResp, ipConfigsResp.Response.ReturnCode, err)
+		service.logResponseEx(r.Context(), opName, ipconfigsRequest, ipConfigsResp, ipConfigsResp.Response.ReturnCode, err)
 		return
 	}
 
 	w.Header().Set(cnsReturnCode, ipConfigsResp.Response.ReturnCode.String())
 	err = common.Encode(w, &ipConfigsResp)
-	logger.ResponseEx(opName, ipconfigsRequest, ipConfigsResp, ipConfigsResp.Response.ReturnCode, err)
+	service.logResponseEx(r.Context(), opName, ipconfigsRequest, ipConfigsResp, ipConfigsResp.Response.ReturnCode, err)
 }
 
 func (service *HTTPRestService) updateEndpointState(ipconfigsRequest cns.IPConfigsRequest, podInfo cns.PodInfo, podIPInfo []cns.PodIpInfo) error {
@@ -420,7 +420,7 @@ func (service *HTTPRestService) ReleaseIPConfigHandler(w http.ResponseWriter, r
 	defer service.publishIPStateMetrics()
 	var ipconfigRequest cns.IPConfigRequest
 	err := common.Decode(w, r, &ipconfigRequest)
-	logger.Request(opName, ipconfigRequest, err)
+	service.logRequest(r.Context(), opName, ipconfigRequest, err)
 	if err != nil {
 		resp := cns.Response{
 			ReturnCode: types.UnexpectedError,
@@ -429,7 +429,7 @@ func (service *HTTPRestService) ReleaseIPConfigHandler(w http.ResponseWriter, r
 		logger.Errorf("releaseIPConfigHandler decode failed becase %v, release IP config info %s", resp.Message, ipconfigRequest)
 		w.Header().Set(cnsReturnCode, resp.ReturnCode.String())
 		err = common.Encode(w, &resp)
-		logger.ResponseEx(opName, ipconfigRequest, resp, resp.ReturnCode, err)
+		service.logResponseEx(r.Context(), opName, ipconfigRequest, resp, resp.ReturnCode, err)
 		return
 	}
 
@@ -443,7 +443,7 @@ func (service *HTTPRestService) ReleaseIPConfigHandler(w http.ResponseWriter, r
 		}
 		w.Header().Set(cnsReturnCode, reserveResp.Response.ReturnCode.String())
 		err = common.Encode(w, &reserveResp)
-		logger.ResponseEx(opName, ipconfigRequest, reserveResp, reserveResp.Response.ReturnCode, err)
+		service.logResponseEx(r.Context(), opName, ipconfigRequest, reserveResp, reserveResp.Response.ReturnCode, err)
 		return
 	}
 
@@ -461,12 +461,12 @@ func (service *HTTPRestService) ReleaseIPConfigHandler(w http.ResponseWriter, r
 	if err != nil {
 		w.Header().Set(cnsReturnCode, resp.Response.ReturnCode.String())
 		err = common.Encode(w, &resp)
-		logger.ResponseEx(opName, ipconfigRequest, resp, resp.Response.ReturnCode, err)
+		service.logResponseEx(r.Context(), opName, ipconfigRequest, resp, resp.Response.ReturnCode, err)
 	}
 
 	w.Header().Set(cnsReturnCode, resp.Response.ReturnCode.String())
 	err = common.Encode(w, &resp)
-	logger.ResponseEx(opName, ipconfigRequest, resp, resp.Response.ReturnCode, err)
+	service.logResponseEx(r.Context(), opName, ipconfigRequest, resp, resp.Response.ReturnCode, err)
 }
 
 // ReleaseIPConfigsHandler frees multiple IPConfigs from the CNS state
@@ -475,7 +475,7 @@ func (service *HTTPRestService) ReleaseIPConfigsHandler(w http.ResponseWriter, r
 	defer service.publishIPStateMetrics()
 	var ipconfigsRequest cns.IPConfigsRequest
 	err := common.Decode(w, r, &ipconfigsRequest)
-	logger.Request("releaseIPConfigsHandler", ipconfigsRequest, err)
+	service.logRequest(r.Context(), "releaseIPConfigsHandler", ipconfigsRequest, err)
 	if err != nil {
 		resp := cns.Response{
 			ReturnCode: types.UnexpectedError,
@@ -484,7 +484,7 @@ func (service *HTTPRestService) ReleaseIPConfigsHandler(w http.ResponseWriter, r
 		logger.Errorf("releaseIPConfigsHandler decode failed because %v, release IP config info %+v", resp.Message, ipconfigsRequest)
 		w.Header().Set(cnsReturnCode, resp.ReturnCode.String())
 		err = common.Encode(w, &resp)
-		logger.ResponseEx(opName, ipconfigsRequest, resp, resp.ReturnCode, err)
+		service.logResponseEx(r.Context(), opName, ipconfigsRequest, resp, resp.ReturnCode, err)
 		return
 	}
 
@@ -492,12 +492,12 @@ func (service *HTTPRestService) ReleaseIPConfigsHandler(w http.ResponseWriter, r
 	if err != nil {
 		w.Header().Set(cnsReturnCode, resp.Response.ReturnCode.String())
 		err = common.Encode(w, &resp)
-		logger.ResponseEx(opName, ipconfigsRequest, resp, resp.Response.ReturnCode, err)
+		service.logResponseEx(r.Context(), opName, ipconfigsRequest, resp, resp.Response.ReturnCode, err)
 	}
 
 	w.Header().Set(cnsReturnCode, resp.Response.ReturnCode.String())
 	err = common.Encode(w, &resp)
-	logger.ResponseEx(opName, ipconfigsRequest, resp, resp.Response.ReturnCode, err)
+	service.logResponseEx(r.Context(), opName, ipconfigsRequest, resp, resp.Response.ReturnCode, err)
 }
 
 func (service *HTTPRestService) removeEndpointState(podInfo cns.PodInfo) error {
@@ -687,7 +687,7 @@ func (service *HTTPRestService) HandleDebugPodContext(w http.ResponseWriter, r *
 		PodContext: service.PodIPIDByPodInterfaceKey,
 	}
 	err := common.Encode(w, &resp)
-	logger.Response(opName, resp, resp.Response.ReturnCode, err)
+	service.logResponse(r.Context(), opName, resp, resp.Response.ReturnCode, err)
 }
 
 func (service *HTTPRestService) HandleDebugRestData(w http.ResponseWriter, r *http.Request) { //nolint
@@ -701,7 +701,7 @@ func (service *HTTPRestService) HandleDebugRestData(w http.ResponseWriter, r *ht
 		},
 	}
 	err := common.Encode(w, &resp)
-	logger.Response(opName, resp, resp.Response.ReturnCode, err)
+	service.logResponse(r.Context(), opName, resp, resp.Response.ReturnCode, err)
 }
 
 func (service *HTTPRestService) HandleDebugIPAddresses(w http.ResponseWriter, r *http.Request) {
@@ -715,7 +715,7 @@ func (service *HTTPRestService) HandleDebugIPAddresses(w http.ResponseWriter, r
 			},
 		}
 		err = common.Encode(w, &resp)
-		logger.ResponseEx(opName, req, resp, resp.Response.ReturnCode, err)
+		service.logResponseEx(r.Context(), opName, req, resp, resp.Response.ReturnCode, err)
 		return
 	}
 	// Get all IPConfigs matching a state and return in the response
@@ -723,7 +723,7 @@ func (service *HTTPRestService) HandleDebugIPAddresses(w http.ResponseWriter, r
 		IPConfigurationStatus: filter.MatchAnyIPConfigState(service.PodIPConfigState, filter.PredicatesForStates(req.IPConfigStateFilter...)...),
 	}
 	err := common.Encode(w, &resp)
-	logger.ResponseEx(opName, req, resp, resp.Response.ReturnCode, err)
+	service.logResponseEx(r.Context(), opName, req, resp, resp.Response.ReturnCode, err)
 }
 
 // GetAssignedIPConfigs returns a filtered list of IPs which are in
@@ -776,7 +776,13 @@ func (service *HTTPRestService) assignIPConfig(ipconfig cns.IPConfigurationStatu
 
 // unassignIPConfig unassigns the ipconfig from the passed Pod, sets the state as Available, does not take a lock.
 func (service *HTTPRestService) unassignIPConfig(ipconfig cns.IPConfigurationStatus, podInfo cns.PodInfo) (cns.IPConfigurationStatus, error) { //nolint:gocritic // ignore hugeparam
-	ipconfig, err := service.updateIPConfigState(ipconfig.ID, types.Available, nil)
+	// a drifted node subnet IP marked PendingRelease while assigned stays PendingRelease once released, so that it
+	// is not assigned again
+	state := types.Available
+	if _, drifted := service.nodeSubnetDrift.drifted[ipconfig.IPAddress]; drifted && ipconfig.GetState() == types.PendingRelease {
+		state = types.PendingRelease
+	}
+	ipconfig, err := service.updateIPConfigState(ipconfig.ID, state, nil)
 	if err != nil {
 		return cns.IPConfigurationStatus{}, err
 	}
@@ -1066,7 +1072,8 @@ func (service *HTTPRestService) AssignAvailableIPConfigs(podInfo cns.PodInfo) ([
 }
 
 // If IPConfigs are already assigned to the pod, it returns that else it returns the available ipconfigs.
-func requestIPConfigsHelper(service *HTTPRestService, req cns.IPConfigsRequest) ([]cns.PodIpInfo, error) {
+// ctx bounds the probing of the available ipconfigs, so that they are not held once the caller is gone.
+func requestIPConfigsHelper(ctx context.Context, service *HTTPRestService, req cns.IPConfigsRequest) ([]cns.PodIpInfo, error) {
 	// check if ipconfigs already assigned to this pod and return if exists or error
 	// if error, ipstate is nil, if exists, ipstate is not nil and error is nil
 	podInfo, err := cns.NewPodInfoFromIPConfigsRequest(req)
@@ -1080,7 +1087,7 @@ func requestIPConfigsHelper(service *HTTPRestService, req cns.IPConfigsRequest)
 
 	// if the desired IP configs are not specified, assign any free IPConfigs
 	if len(req.DesiredIPAddresses) == 0 {
-		return service.AssignAvailableIPConfigs(podInfo)
+		return service.assignAvailableProbedIPConfigs(ctx, podInfo)
 	}
 
 	if err := validateDesiredIPAddresses(req.DesiredIPAddresses); err != nil {
@@ -1115,7 +1122,7 @@ func (service *HTTPRestService) EndpointHandlerAPI(w http.ResponseWriter, r *htt
 			Message:    fmt.Sprintf("[EndpointHandlerAPI] EndpointHandlerAPI failed with error: %s", ErrOptManageEndpointState),
 		}
 		err := common.Encode(w, &response)
-		logger.Response(opName, response, response.ReturnCode, err)
+		service.logResponse(r.Context(), opName, response, response.ReturnCode, err)
 		return
 	}
 	switch r.Method {
@@ -1152,7 +1159,7 @@ func (service *HTTPRestService) GetEndpointHandler(w http.ResponseWriter, r *htt
 		}
 		w.Header().Set(cnsReturnCode, response.Response.ReturnCode.String())
 		err = common.Encode(w, &response)
-		logger.Response(opName, response, response.Response.ReturnCode, err)
+		service.logResponse(r.Context(), opName, response, response.Response.ReturnCode, err)
 		return
 	}
 	response := GetEndpointResponse{
@@ -1164,7 +1171,7 @@ func (service *HTTPRestService) GetEndpointHandler(w http.ResponseWriter, r *htt
 	}
 	w.Header().Set(cnsReturnCode, response.Response.ReturnCode.String())
 	err = common.Encode(w, &response)
-	logger.Response(opName, response, response.Response.ReturnCode, err)
+	service.logResponse(r.Context(), opName, response, response.Response.ReturnCode, err)
 }
 
 // GetEndpointHelper returns the state of the given endpointId
@@ -1211,7 +1218,7 @@ func (service *HTTPRestService) UpdateEndpointHandler(w http.ResponseWriter, r *
 	var req map[string]*IPInfo
 	err := common.Decode(w, r, &req)
 	endpointID := strings.TrimPrefix(r.URL.Path, cns.EndpointPath)
-	logger.Request(opName, &req, err)
+	service.logRequest(r.Context(), opName, &req, err)
 	// Check if the request is valid
 	if err != nil {
 		response := cns.Response{
@@ -1220,7 +1227,7 @@ func (service *HTTPRestService) UpdateEndpointHandler(w http.ResponseWriter, r *
 		}
 		w.Header().Set(cnsReturnCode, response.ReturnCode.String())
 		err = common.Encode(w, &response)
-		logger.Response(opName, response, response.ReturnCode, err)
+		service.logResponse(r.Context(), opName, response, response.ReturnCode, err)
 		return
 	}
 	if err = verifyUpdateEndpointStateRequest(req); err != nil {
@@ -1230,11 +1237,15 @@ func (service *HTTPRestService) UpdateEndpointHandler(w http.ResponseWriter, r *
 		}
 		w.Header().Set(cnsReturnCode, response.ReturnCode.String())
 		err = common.Encode(w, &response)
-		logger.Response(opName, response, response.ReturnCode, err)
+		service.logResponse(r.Context(), opName, response, response.ReturnCode, err)
 		return
 	}
 	// Update the endpoint state
-	err = service.UpdateEndpointHelper(endpointID, req)
+	pod := cns.KubernetesPodInfo{
+		PodName:      r.URL.Query().Get(cns.EndpointPodNameParam),
+		PodNamespace: r.URL.Query().Get(cns.EndpointPodNamespaceParam),
+	}
+	err = service.UpdateEndpointHelper(endpointID, pod, req)
 	if err != nil {
 		response := cns.Response{
 			ReturnCode: types.UnexpectedError,
@@ -1242,7 +1253,7 @@ func (service *HTTPRestService) UpdateEndpointHandler(w http.ResponseWriter, r *
 		}
 		w.Header().Set(cnsReturnCode, response.ReturnCode.String())
 		err = common.Encode(w, &response)
-		logger.Response(opName, response, response.ReturnCode, err)
+		service.logResponse(r.Context(), opName, response, response.ReturnCode, err)
 		return
 	}
 	response := cns.Response{
@@ -1251,11 +1262,12 @@ func (service *HTTPRestService) UpdateEndpointHandler(w http.ResponseWriter, r *
 	}
 	w.Header().Set(cnsReturnCode, response.ReturnCode.String())
 	err = common.Encode(w, &response)
-	logger.Response(opName, response, response.ReturnCode, err)
+	service.logResponse(r.Context(), opName, response, response.ReturnCode, err)
 }
 
-// UpdateEndpointHelper updates the state of the given endpointId with HNSId, VethName or other InterfaceInfo fields
-func (service *HTTPRestService) UpdateEndpointHelper(endpointID string, req map[string]*IPInfo) error {
+// UpdateEndpointHelper updates the state of the given endpointId with HNSId, VethName or other InterfaceInfo fields, and records
+// the pod of the endpoint if it has none.
+func (service *HTTPRestService) UpdateEndpointHelper(endpointID string, pod cns.KubernetesPodInfo, req map[string]*IPInfo) error {
 	if service.EndpointStateStore == nil {
 		return ErrStoreEmpty
 	}
@@ -1268,6 +1280,10 @@ func (service *HTTPRestService) UpdateEndpointHelper(endpointID string, req map[
 		endpointInfo = &EndpointInfo{PodName: "", PodNamespace: "", IfnameToIPMap: make(map[string]*IPInfo)}
 		service.EndpointState[endpointID] = endpointInfo
 	}
+	// the pod recorded at ip allocation is kept, the one of the request only fills in an endpoint without one
+	if endpointInfo.PodName == "" && endpointInfo.PodNamespace == "" {
+		endpointInfo.PodName, endpointInfo.PodNamespace = pod.PodName, pod.PodNamespace
+	}
 	// updating the InterfaceInfo map of endpoint states with the interfaceInfo map that is given by Stateless Azure CNI
 	for ifName, interfaceInfo := range req {
 		// updating the ipInfoMap
@@ -1293,6 +1309,16 @@ func updateIPInfoMap(iPInfo map[string]*IPInfo, interfaceInfo *IPInfo, ifName, e
 		}
 	}
 	logger.Printf("[updateEndpoint] Found existing endpoint state for infra container %s with  %s , [%+v]", endpointID, ifName, interfaceInfo)
+	// addresses are only taken from the request when CNS has none for the interface, which is the case when
+	// endpoint state is migrated from a stateful CNI; IPAM remains the owner of the addresses otherwise
+	if len(iPInfo[ifName].IPv4) == 0 && len(interfaceInfo.IPv4) > 0 {
+		iPInfo[ifName].IPv4 = interfaceInfo.IPv4
+		logger.Printf("[updateEndpoint] update the endpoint %s with IPv4 %v", endpointID, interfaceInfo.IPv4)
+	}
+	if len(iPInfo[ifName].IPv6) == 0 && len(interfaceInfo.IPv6) > 0 {
+		iPInfo[ifName].IPv6 = interfaceInfo.IPv6
+		logger.Printf("[updateEndpoint] update the endpoint %s with IPv6 %v", endpointID, interfaceInfo.IPv6)
+	}
 	if interfaceInfo.HnsEndpointID != "" {
 		iPInfo[ifName].HnsEndpointID = interfaceInfo.HnsEndpointID
 		logger.Printf("[updateEndpoint] update the endpoint %s with HNSID  %s", endpointID, interfaceInfo.HnsEndpointID)
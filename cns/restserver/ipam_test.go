@@ -103,7 +103,7 @@ func newPodState(ipaddress, id, ncid string, state types.IPState, ncVersion int)
 }
 
 func requestIPAddressAndGetState(t *testing.T, req cns.IPConfigsRequest) ([]cns.IPConfigurationStatus, error) {
-	podIPInfo, err := requestIPConfigsHelper(svc, req)
+	podIPInfo, err := requestIPConfigsHelper(context.Background(), svc, req)
 	if err != nil {
 		return []cns.IPConfigurationStatus{}, err
 	}
@@ -231,7 +231,7 @@ func endpointStateReadAndWrite(t *testing.T, ncStates []ncState) {
 	b, _ := testPod1Info.OrchestratorContext()
 	req.OrchestratorContext = b
 	req.Ifname = "eth0"
-	podIPInfo, err := requestIPConfigsHelper(svc, req)
+	podIPInfo, err := requestIPConfigsHelper(context.Background(), svc, req)
 	if err != nil {
 		t.Fatalf("Expected to not fail getting pod ip info: %+v", err)
 	}
@@ -1378,7 +1378,7 @@ func TestIPAMFailToRequestIPsWithNoNCsSpecificIP(t *testing.T) {
 	req.DesiredIPAddresses = make([]string, 1)
 	req.DesiredIPAddresses[0] = testIP1
 
-	_, err := requestIPConfigsHelper(svc, req)
+	_, err := requestIPConfigsHelper(context.Background(), svc, req)
 	if err == nil {
 		t.Fatalf("Expected error. Should not be able to request IPs when there are no NCs")
 	}
@@ -1394,7 +1394,7 @@ func TestIPAMFailToRequestIPsWithNoNCsAnyIP(t *testing.T) {
 	b, _ := testPod1Info.OrchestratorContext()
 	req.OrchestratorContext = b
 
-	_, err := requestIPConfigsHelper(svc, req)
+	_, err := requestIPConfigsHelper(context.Background(), svc, req)
 	if err == nil {
 		t.Fatalf("Expected error. Should not be able to request IPs when there are no NCs")
 	}
@@ -2152,7 +2152,7 @@ func createAndSaveMockNCRequest(t *testing.T, svc *HTTPRestService, ncID string,
 	require.NoError(t, err)
 
 	// save SwiftV2 NC state in CNS
-	returnCode, returnMessage := svc.saveNetworkContainerGoalState(*createNCReq)
+	returnCode, returnMessage := svc.saveNetworkContainerGoalState(cns.NCHistorySourceCRD, *createNCReq)
 	require.Equal(t, types.Success, returnCode)
 	require.Empty(t, returnMessage)
 }
@@ -2182,10 +2182,19 @@ func TestStatelessCNIStateFile(t *testing.T) {
 		HnsNetworkID:  "5c0712cd-824c-4898-b1c0-2fcb16ede4fb",
 		MacAddress:    "7c:1e:52:06:d3:4b",
 	}
+	// test Case 3 - endpoint migrated from a stateful CNI
+	endpointInfo3ContainerID := "2c4917617e15d24dc495e407d8eb5c88e4406e58fa209e4eb75a2c2fb7045eea"
+	endpointInfo3 := &EndpointInfo{PodName: "pod3", PodNamespace: "default", IfnameToIPMap: make(map[string]*IPInfo)}
+	endpointInfo3.IfnameToIPMap["eth0"] = &IPInfo{
+		IPv4:         []net.IPNet{{IP: net.IPv4(10, 0, 0, 5), Mask: net.IPv4Mask(255, 255, 255, 0)}},
+		NICType:      cns.InfraNIC,
+		HostVethName: "azv1234567890a",
+	}
 	// test cases
 	tests := []struct {
 		name       string
 		endpointID string
+		pod        cns.KubernetesPodInfo
 		req        map[string]*IPInfo
 		store      store.KeyValueStore
 		want       *EndpointInfo
@@ -2194,6 +2203,7 @@ func TestStatelessCNIStateFile(t *testing.T) {
 		{
 			name:       "single-tenancy: update endpoint without error",
 			endpointID: endpointInfo1ContainerID,
+			pod:        cns.KubernetesPodInfo{PodName: "other", PodNamespace: "other"}, // the pod recorded at allocation is kept
 			req:        req1,
 			store:      svc.EndpointStateStore,
 			want: &EndpointInfo{
@@ -2215,6 +2225,15 @@ func TestStatelessCNIStateFile(t *testing.T) {
 			want:       endpointInfo2,
 			wantErr:    false,
 		},
+		{
+			name:       "migration: create absent endpoint with addresses without error",
+			endpointID: endpointInfo3ContainerID,
+			pod:        cns.KubernetesPodInfo{PodName: "pod3", PodNamespace: "default"},
+			req:        endpointInfo3.IfnameToIPMap,
+			store:      svc.EndpointStateStore,
+			want:       endpointInfo3,
+			wantErr:    false,
+		},
 	}
 	ncStates := []ncState{
 		{
@@ -2238,7 +2257,7 @@ func TestStatelessCNIStateFile(t *testing.T) {
 	b, _ := testPod1Info.OrchestratorContext()
 	req.OrchestratorContext = b
 	req.Ifname = "eth0"
-	podIPInfo, err := requestIPConfigsHelper(svc, req)
+	podIPInfo, err := requestIPConfigsHelper(context.Background(), svc, req)
 	if err != nil {
 		t.Fatalf("Expected to not fail getting pod ip info: %+v", err)
 	}
@@ -2266,7 +2285,7 @@ func TestStatelessCNIStateFile(t *testing.T) {
 	for _, tt := range tests {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
-			err := svc.UpdateEndpointHelper(tt.endpointID, tt.req)
+			err := svc.UpdateEndpointHelper(tt.endpointID, tt.pod, tt.req)
 			if tt.wantErr {
 				assert.Error(t, err)
 				return
@@ -0,0 +1,31 @@
+package restserver
+
+import (
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/common"
+	"github.com/pkg/errors"
+)
+
+type ipAuditor interface {
+	Discrepancies() ([]cns.IPAuditDiscrepancy, time.Time, error)
+}
+
+// SetIPAuditor sets the auditor cross-checking the IPs assigned by CNS against the CNI state and the API server,
+// whose discrepancies are served by the debug API.
+func (service *HTTPRestService) SetIPAuditor(auditor ipAuditor) {
+	service.ipAuditor = auditor
+}
+
+// ReleaseLeakedIPConfigs releases the IPs assigned to the Pod and removes its endpoint state, as a release from CNI
+// would, for a Pod which is gone without CNI having released its IPs.
+func (service *HTTPRestService) ReleaseLeakedIPConfigs(podInfo cns.PodInfo) error {
+	defer service.publishIPStateMetrics()
+	if service.Options[common.OptManageEndpointState] == true {
+		if err := service.removeEndpointState(podInfo); err != nil {
+			return errors.Wrap(err, "failed to remove endpoint state")
+		}
+	}
+	return service.releaseIPConfigs(podInfo)
+}
@@ -0,0 +1,48 @@
+package restserver
+
+import (
+	"context"
+
+	"github.com/Azure/azure-container-networking/cns/logger"
+	loggerv2 "github.com/Azure/azure-container-networking/cns/logger/v2"
+	"github.com/Azure/azure-container-networking/cns/types"
+	"go.uber.org/zap"
+)
+
+// SetLogger sets the logger the APIs log their requests and responses to, with the trace context of each request
+// stamped on them. Without one they are logged to the global logger, with the trace context in the tag.
+func (service *HTTPRestService) SetLogger(z *zap.Logger) {
+	service.z = z
+}
+
+func (service *HTTPRestService) logRequest(ctx context.Context, tag string, request any, err error) {
+	if service.z == nil {
+		logger.Request(traceTag(ctx, tag), request, err)
+		return
+	}
+	loggerv2.WithTraceContext(ctx, service.z).Sugar().Infow("Request", "message", tag, "data", request, "error", err)
+}
+
+func (service *HTTPRestService) logResponse(ctx context.Context, tag string, response any, code types.ResponseCode, err error) {
+	if service.z == nil {
+		logger.Response(traceTag(ctx, tag), response, code, err)
+		return
+	}
+	loggerv2.WithTraceContext(ctx, service.z).Sugar().Infow("Response", "message", tag, "data", response, "code", code, "error", err)
+}
+
+func (service *HTTPRestService) logResponseEx(ctx context.Context, tag string, request, response any, code types.ResponseCode, err error) {
+	if service.z == nil {
+		logger.ResponseEx(traceTag(ctx, tag), request, response, code, err)
+		return
+	}
+	loggerv2.WithTraceContext(ctx, service.z).Sugar().Infow("ResponseEx", "message", tag, "request", request, "response", response, "code", code, "error", err)
+}
+
+// traceTag appends the trace context of ctx to the tag of a legacy log line.
+func traceTag(ctx context.Context, tag string) string {
+	if ids := loggerv2.TraceIDs(ctx); ids != "" {
+		return tag + " " + ids
+	}
+	return tag
+}
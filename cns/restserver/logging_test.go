@@ -0,0 +1,35 @@
+package restserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRequestLogsCarryTraceContext(t *testing.T) {
+	service := getTestService(cns.KubernetesCRD)
+	core, logs := observer.New(zap.InfoLevel)
+	service.SetLogger(zap.New(core))
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{2},
+		TraceFlags: trace.FlagsSampled,
+	})
+	req := httptest.NewRequest(http.MethodGet, cns.PathDebugNCHistory, http.NoBody)
+	req = req.WithContext(trace.ContextWithSpanContext(req.Context(), sc))
+	service.HandleDebugNCHistory(httptest.NewRecorder(), req)
+
+	entries := logs.FilterMessage("Response").All()
+	require.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, sc.TraceID().String(), fields["trace_id"])
+	assert.Equal(t, sc.SpanID().String(), fields["span_id"])
+}
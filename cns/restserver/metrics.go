@@ -111,6 +111,32 @@ var (
 		},
 		[]string{},
 	)
+	quarantinedIPCount = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cx_quarantined_ips_v2",
+			Help:        "Count of IPs in Quarantined State",
+			ConstLabels: prometheus.Labels{customerMetricLabel: customerMetricLabelValue},
+		},
+		[]string{},
+	)
+	ipAddressConflictCount = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "ip_address_conflict_total",
+			Help: "Count of IPs found in use by another host before being assigned",
+		},
+	)
+	nodeSubnetDriftedIPCount = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "nodesubnet_drifted_ips",
+			Help: "Number of IPs assigned to Pods which are no longer secondary IPs of the NIC",
+		},
+	)
+	nodeSubnetIPDriftCount = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "nodesubnet_ip_drift_total",
+			Help: "Count of IPs assigned to Pods found removed from the NIC",
+		},
+	)
 )
 
 func init() {
@@ -126,6 +152,10 @@ func init() {
 		availableIPCount,
 		pendingProgrammingIPCount,
 		pendingReleaseIPCount,
+		quarantinedIPCount,
+		ipAddressConflictCount,
+		nodeSubnetDriftedIPCount,
+		nodeSubnetIPDriftCount,
 	)
 }
 
@@ -162,6 +192,8 @@ type ipState struct {
 	programmingIPs int64
 	// releasingIPs are the IPs in state "PendingReleasr".
 	releasingIPs int64
+	// quarantinedIPs are the IPs in state "Quarantined".
+	quarantinedIPs int64
 }
 
 type asyncMetricsRecorder struct {
@@ -197,14 +229,18 @@ func (a *asyncMetricsRecorder) record() {
 		if ipConfig.GetState() == types.PendingRelease {
 			state.releasingIPs++
 		}
+		if ipConfig.GetState() == types.Quarantined {
+			state.quarantinedIPs++
+		}
 	}
 
-	logger.Printf("Allocated IPs: %d, Assigned IPs: %d, Available IPs: %d, PendingProgramming IPs: %d, PendingRelease IPs: %d",
+	logger.Printf("Allocated IPs: %d, Assigned IPs: %d, Available IPs: %d, PendingProgramming IPs: %d, PendingRelease IPs: %d, Quarantined IPs: %d",
 		state.allocatedIPs,
 		state.assignedIPs,
 		state.availableIPs,
 		state.programmingIPs,
 		state.releasingIPs,
+		state.quarantinedIPs,
 	)
 
 	labels := []string{}
@@ -213,6 +249,7 @@ func (a *asyncMetricsRecorder) record() {
 	availableIPCount.WithLabelValues(labels...).Set(float64(state.availableIPs))
 	pendingProgrammingIPCount.WithLabelValues(labels...).Set(float64(state.programmingIPs))
 	pendingReleaseIPCount.WithLabelValues(labels...).Set(float64(state.releasingIPs))
+	quarantinedIPCount.WithLabelValues(labels...).Set(float64(state.quarantinedIPs))
 }
 
 // publishIPStateMetrics logs and publishes the IP Config state metrics to Prometheus.
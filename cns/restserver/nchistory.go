@@ -0,0 +1,118 @@
+package restserver
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/types"
+)
+
+const (
+	// maxNCHistory bounds the records of the NC history kept in the state, dropping the oldest first.
+	maxNCHistory = 256
+	// maxNCHistoryIPs bounds the added and removed IPs listed in each record of the NC history.
+	maxNCHistoryIPs = 32
+)
+
+type ncHistorySourceKey struct{}
+
+// withNCHistorySource returns a context with which the NCs created, updated or deleted through the NC APIs are
+// recorded in the NC history as from the source instead of from DNC.
+func withNCHistorySource(ctx context.Context, source cns.NCHistorySource) context.Context {
+	return context.WithValue(ctx, ncHistorySourceKey{}, source)
+}
+
+func ncHistorySourceFrom(ctx context.Context) cns.NCHistorySource {
+	if source, ok := ctx.Value(ncHistorySourceKey{}).(cns.NCHistorySource); ok {
+		return source
+	}
+	return cns.NCHistorySourceDNC
+}
+
+// newNCHistoryRecord returns the record of the NC changing from previous to current, where previous is nil for a
+// create and current is nil for a delete.
+func newNCHistoryRecord(source cns.NCHistorySource, ncID string, previous, current *containerstatus, code types.ResponseCode) cns.NCHistoryRecord {
+	record := cns.NCHistoryRecord{
+		Time:       time.Now(),
+		NCID:       ncID,
+		Operation:  cns.NCHistoryUpdate,
+		Source:     source,
+		ReturnCode: code,
+	}
+	var before, after map[string]cns.SecondaryIPConfig
+	switch {
+	case previous == nil:
+		record.Operation = cns.NCHistoryCreate
+	case current == nil:
+		record.Operation = cns.NCHistoryDelete
+	}
+	if previous != nil {
+		record.OldVersion = previous.CreateNetworkContainerRequest.Version
+		record.OldHostVersion = previous.HostVersion
+		before = previous.CreateNetworkContainerRequest.SecondaryIPConfigs
+	}
+	if current != nil {
+		record.NewVersion = current.CreateNetworkContainerRequest.Version
+		record.NewHostVersion = current.HostVersion
+		after = current.CreateNetworkContainerRequest.SecondaryIPConfigs
+	}
+	added, removed := diffSecondaryIPs(before, after)
+	record.AddedIPCount, record.RemovedIPCount = len(added), len(removed)
+	record.AddedIPs, record.RemovedIPs = added[:min(len(added), maxNCHistoryIPs)], removed[:min(len(removed), maxNCHistoryIPs)]
+	return record
+}
+
+// diffSecondaryIPs returns the IPs of after which are not in before, and of before which are not in after, sorted.
+func diffSecondaryIPs(before, after map[string]cns.SecondaryIPConfig) (added, removed []string) {
+	beforeIPs := make(map[string]struct{}, len(before))
+	for _, ipConfig := range before {
+		beforeIPs[ipConfig.IPAddress] = struct{}{}
+	}
+	afterIPs := make(map[string]struct{}, len(after))
+	for _, ipConfig := range after {
+		afterIPs[ipConfig.IPAddress] = struct{}{}
+		if _, ok := beforeIPs[ipConfig.IPAddress]; !ok {
+			added = append(added, ipConfig.IPAddress)
+		}
+	}
+	for ip := range beforeIPs {
+		if _, ok := afterIPs[ip]; !ok {
+			removed = append(removed, ip)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+// recordNCHistoryUntransacted appends the record to the NC history, which is persisted with the state at the next
+// save. Successful updates which changed neither the versions nor the IPs of the NC are not recorded, so that
+// reconciles of an unchanged goal state do not push the changes out of the history.
+// Note: this func is an untransacted API as the caller will take a Service lock
+func (service *HTTPRestService) recordNCHistoryUntransacted(record cns.NCHistoryRecord) {
+	if record.Operation == cns.NCHistoryUpdate && record.ReturnCode == types.Success &&
+		record.OldVersion == record.NewVersion && record.OldHostVersion == record.NewHostVersion &&
+		record.AddedIPCount == 0 && record.RemovedIPCount == 0 {
+		return
+	}
+	history := append(service.state.NCHistory, record)
+	if len(history) > maxNCHistory {
+		history = history[len(history)-maxNCHistory:]
+	}
+	service.state.NCHistory = history
+}
+
+// ncHistory returns the records of the NC history of the NC, or of every NC if ncID is empty, oldest first.
+func (service *HTTPRestService) ncHistory(ncID string) []cns.NCHistoryRecord {
+	service.RLock()
+	defer service.RUnlock()
+	records := []cns.NCHistoryRecord{}
+	for i := range service.state.NCHistory {
+		if ncID == "" || service.state.NCHistory[i].NCID == ncID {
+			records = append(records, service.state.NCHistory[i])
+		}
+	}
+	return records
+}
@@ -0,0 +1,95 @@
+package restserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/fakes"
+	"github.com/Azure/azure-container-networking/cns/types"
+	nma "github.com/Azure/azure-container-networking/nmagent"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNCHistory(t *testing.T) {
+	restartService()
+	setEnv(t)
+	setOrchestratorTypeInternal(cns.KubernetesCRD)
+
+	ipIDs := []string{uuid.New().String(), uuid.New().String(), uuid.New().String()}
+	secondaryIPConfigs := map[string]cns.SecondaryIPConfig{
+		ipIDs[0]: newSecondaryIPConfig("10.0.0.16", 0),
+		ipIDs[1]: newSecondaryIPConfig("10.0.0.17", 0),
+	}
+	createNCReqInternal(t, secondaryIPConfigs, ncID, "0")
+	// an unchanged goal state is not recorded
+	createNCReqInternal(t, secondaryIPConfigs, ncID, "0")
+
+	delete(secondaryIPConfigs, ipIDs[0])
+	secondaryIPConfigs[ipIDs[2]] = newSecondaryIPConfig("10.0.0.18", 1)
+	createNCReqInternal(t, secondaryIPConfigs, ncID, "1")
+
+	cleanup := setMockNMAgent(svc, &fakes.NMAgentClientFake{
+		GetNCVersionListF: func(_ context.Context) (nma.NCVersionList, error) {
+			return nma.NCVersionList{Containers: []nma.NCVersion{{NetworkContainerID: ncID, Version: "1"}}}, nil
+		},
+	})
+	defer cleanup()
+	require.NoError(t, svc.SyncHostNCVersion(context.Background(), cns.CRD))
+
+	require.Equal(t, types.Success, svc.DeleteNetworkContainerInternal(cns.DeleteNetworkContainerRequest{NetworkContainerid: ncID}))
+
+	records := svc.ncHistory(ncID)
+	require.Len(t, records, 4)
+	assert.Equal(t, cns.NCHistoryCreate, records[0].Operation)
+	assert.Equal(t, cns.NCHistorySourceCRD, records[0].Source)
+	assert.Equal(t, "0", records[0].NewVersion)
+	assert.Equal(t, []string{"10.0.0.16", "10.0.0.17"}, records[0].AddedIPs)
+
+	assert.Equal(t, cns.NCHistoryUpdate, records[1].Operation)
+	assert.Equal(t, "0", records[1].OldVersion)
+	assert.Equal(t, "1", records[1].NewVersion)
+	assert.Equal(t, []string{"10.0.0.18"}, records[1].AddedIPs)
+	assert.Equal(t, []string{"10.0.0.16"}, records[1].RemovedIPs)
+
+	assert.Equal(t, cns.NCHistorySourceHostVersionSync, records[2].Source)
+	assert.Equal(t, "-1", records[2].OldHostVersion)
+	assert.Equal(t, "1", records[2].NewHostVersion)
+
+	assert.Equal(t, cns.NCHistoryDelete, records[3].Operation)
+	assert.Equal(t, "1", records[3].OldVersion)
+	assert.Equal(t, 2, records[3].RemovedIPCount)
+
+	assert.Empty(t, svc.ncHistory("other"))
+
+	// the history is served filtered by NC
+	req := httptest.NewRequest(http.MethodGet, cns.PathDebugNCHistory+"?"+cns.NCHistoryNCIDParam+"="+ncID, http.NoBody)
+	w := httptest.NewRecorder()
+	svc.HandleDebugNCHistory(w, req)
+	var resp cns.GetNCHistoryResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, types.Success, resp.Response.ReturnCode)
+	assert.Len(t, resp.Records, 4)
+}
+
+func TestNCHistoryBounded(t *testing.T) {
+	service := getTestService(cns.KubernetesCRD)
+	ips := map[string]cns.SecondaryIPConfig{}
+	for i := 0; i < maxNCHistoryIPs+1; i++ {
+		ips[uuid.New().String()] = newSecondaryIPConfig(fmt.Sprintf("10.0.1.%d", i), 0)
+	}
+	current := containerstatus{CreateNetworkContainerRequest: cns.CreateNetworkContainerRequest{SecondaryIPConfigs: ips}}
+	for i := 0; i <= maxNCHistory; i++ {
+		service.recordNCHistoryUntransacted(newNCHistoryRecord(cns.NCHistorySourceDNC, uuid.New().String(), nil, &current, types.Success))
+	}
+	records := service.ncHistory("")
+	require.Len(t, records, maxNCHistory)
+	assert.Len(t, records[0].AddedIPs, maxNCHistoryIPs)
+	assert.Equal(t, maxNCHistoryIPs+1, records[0].AddedIPCount)
+}
@@ -20,14 +20,18 @@ func (service *HTTPRestService) UpdateIPsForNodeSubnet(secondaryIPs []netip.Addr
 		secondaryIPStrs[i] = ip.String()
 	}
 
+	retained, detected := service.retainDriftedNodeSubnetIPs(secondaryIPStrs)
+	secondaryIPStrs = append(secondaryIPStrs, retained...)
+
 	networkContainerRequest := nodesubnet.CreateNodeSubnetNCRequest(secondaryIPStrs)
 
-	code, msg := service.saveNetworkContainerGoalState(*networkContainerRequest)
+	code, msg := service.saveNetworkContainerGoalState(cns.NCHistorySourceNodeSubnet, *networkContainerRequest)
 	if code != types.Success {
 		return errors.Errorf("failed to save fetched ips. code: %d, message %s", code, msg)
 	}
 
 	logger.Debugf("IP change processed successfully")
+	service.remediateNodeSubnetIPDrift(detected)
 
 	// saved NC successfully. UpdateIPsForNodeSubnet is called only when IPs are fetched from NMAgent.
 	// We now have IPs to serve IPAM requests. Generate conflist to indicate CNS is ready
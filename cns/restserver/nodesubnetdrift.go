@@ -0,0 +1,145 @@
+package restserver
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Azure/azure-container-networking/aitelemetry"
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/logger"
+	"github.com/Azure/azure-container-networking/cns/nodesubnet"
+	"github.com/Azure/azure-container-networking/cns/types"
+)
+
+// nodeSubnetEvictionTimeout bounds each eviction of a Pod holding a drifted IP.
+const nodeSubnetEvictionTimeout = 30 * time.Second
+
+type nodeSubnetIPDrift struct {
+	policy  nodesubnet.DriftPolicy
+	evicter nodesubnet.PodEvicter
+	// drifted are the IPs assigned to Pods which are no longer secondary IPs of the NIC, by IP.
+	drifted map[string]cns.NodeSubnetDriftedIP
+}
+
+// SetNodeSubnetIPDriftPolicy sets how IPs assigned to Pods which are removed from the NIC are remediated in
+// NodeSubnet mode. The evicter is only used by nodesubnet.DriftPolicyEvict. Drifted IPs are only reported until
+// it is called.
+func (service *HTTPRestService) SetNodeSubnetIPDriftPolicy(policy nodesubnet.DriftPolicy, evicter nodesubnet.PodEvicter) {
+	service.Lock()
+	defer service.Unlock()
+	service.nodeSubnetDrift.policy = policy
+	service.nodeSubnetDrift.evicter = evicter
+}
+
+// retainDriftedNodeSubnetIPs returns the IPs assigned to Pods which are not among the fetched secondary IPs. They
+// must be kept in the NodeSubnet NC until their Pods release them, as CNS can not take IPs away from Pods. The IPs
+// found drifted for the first time are returned as detected, and unless the policy keeps them the drifted IPs are
+// marked PendingRelease so that they are not assigned again.
+func (service *HTTPRestService) retainDriftedNodeSubnetIPs(fetched []string) (retained []string, detected []cns.NodeSubnetDriftedIP) {
+	service.Lock()
+	defer service.Unlock()
+
+	onNIC := make(map[string]struct{}, len(fetched))
+	for _, ip := range fetched {
+		onNIC[ip] = struct{}{}
+	}
+	markPendingRelease := service.nodeSubnetDrift.policy == nodesubnet.DriftPolicyPendingRelease ||
+		service.nodeSubnetDrift.policy == nodesubnet.DriftPolicyEvict
+
+	drifted := map[string]cns.NodeSubnetDriftedIP{}
+	for ipID, ipConfig := range service.PodIPConfigState { //nolint:gocritic // ignore copy
+		if ipConfig.NCID != nodesubnet.NodeSubnetNCID || ipConfig.PodInfo == nil {
+			continue
+		}
+		if _, ok := onNIC[ipConfig.IPAddress]; ok {
+			continue
+		}
+		state := ipConfig.GetState()
+		if state != types.Assigned && state != types.PendingRelease {
+			continue
+		}
+		d, ok := service.nodeSubnetDrift.drifted[ipConfig.IPAddress]
+		if !ok {
+			d = cns.NodeSubnetDriftedIP{
+				IPAddress:    ipConfig.IPAddress,
+				PodName:      ipConfig.PodInfo.Name(),
+				PodNamespace: ipConfig.PodInfo.Namespace(),
+				DetectedAt:   time.Now(),
+			}
+			detected = append(detected, d)
+		}
+		drifted[ipConfig.IPAddress] = d
+		retained = append(retained, ipConfig.IPAddress)
+		if markPendingRelease && state == types.Assigned {
+			ipConfig.SetState(types.PendingRelease)
+			service.PodIPConfigState[ipID] = ipConfig
+		}
+	}
+	service.nodeSubnetDrift.drifted = drifted
+	nodeSubnetDriftedIPCount.Set(float64(len(drifted)))
+	return retained, detected
+}
+
+// remediateNodeSubnetIPDrift reports the newly detected drifted IPs, and when the policy is to evict, evicts the
+// Pods holding drifted IPs which were not evicted yet.
+func (service *HTTPRestService) remediateNodeSubnetIPDrift(detected []cns.NodeSubnetDriftedIP) {
+	service.RLock()
+	policy, evicter := service.nodeSubnetDrift.policy, service.nodeSubnetDrift.evicter
+	var toEvict []cns.NodeSubnetDriftedIP
+	for _, d := range service.nodeSubnetDrift.drifted {
+		if !d.Evicted {
+			toEvict = append(toEvict, d)
+		}
+	}
+	service.RUnlock()
+
+	for _, d := range detected {
+		nodeSubnetIPDriftCount.Inc()
+		logger.Errorf("[Azure CNS] IP %s assigned to pod %s/%s is no longer a secondary IP of the NIC, policy %q",
+			d.IPAddress, d.PodNamespace, d.PodName, policy)
+		logger.LogEvent(aitelemetry.Event{
+			EventName: logger.NodeSubnetIPDriftEventStr,
+			Properties: map[string]string{
+				logger.IPAddressStr:    d.IPAddress,
+				logger.PodNameStr:      d.PodName,
+				logger.PodNamespaceStr: d.PodNamespace,
+				logger.DriftPolicyStr:  string(policy),
+			},
+			ResourceID: nodesubnet.NodeSubnetNCID,
+		})
+	}
+
+	if policy != nodesubnet.DriftPolicyEvict || evicter == nil {
+		return
+	}
+	for _, d := range toEvict {
+		ctx, cancel := context.WithTimeout(context.Background(), nodeSubnetEvictionTimeout)
+		err := evicter.EvictPod(ctx, d.PodNamespace, d.PodName)
+		cancel()
+		if err != nil {
+			// retried at the next fetch
+			logger.Errorf("[Azure CNS] Failed to evict pod %s/%s holding drifted IP %s: %v", d.PodNamespace, d.PodName, d.IPAddress, err)
+			continue
+		}
+		logger.Printf("[Azure CNS] Evicted pod %s/%s holding drifted IP %s", d.PodNamespace, d.PodName, d.IPAddress)
+		service.Lock()
+		if current, ok := service.nodeSubnetDrift.drifted[d.IPAddress]; ok {
+			current.Evicted = true
+			service.nodeSubnetDrift.drifted[d.IPAddress] = current
+		}
+		service.Unlock()
+	}
+}
+
+// nodeSubnetDriftedIPs returns the IPs assigned to Pods which are no longer secondary IPs of the NIC, sorted by IP.
+func (service *HTTPRestService) nodeSubnetDriftedIPs() []cns.NodeSubnetDriftedIP {
+	service.RLock()
+	defer service.RUnlock()
+	drifted := make([]cns.NodeSubnetDriftedIP, 0, len(service.nodeSubnetDrift.drifted))
+	for _, d := range service.nodeSubnetDrift.drifted {
+		drifted = append(drifted, d)
+	}
+	sort.Slice(drifted, func(i, j int) bool { return drifted[i].IPAddress < drifted[j].IPAddress })
+	return drifted
+}
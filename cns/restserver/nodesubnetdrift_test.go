@@ -0,0 +1,126 @@
+package restserver
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/nodesubnet"
+	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePodEvicter struct {
+	evicted []string
+	err     error
+}
+
+func (f *fakePodEvicter) EvictPod(_ context.Context, namespace, name string) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.evicted = append(f.evicted, namespace+"/"+name)
+	return nil
+}
+
+func parseAddrs(t *testing.T, ips ...string) []netip.Addr {
+	t.Helper()
+	addrs := make([]netip.Addr, len(ips))
+	for i, ip := range ips {
+		addrs[i] = netip.MustParseAddr(ip)
+	}
+	return addrs
+}
+
+func TestNodeSubnetIPDrift(t *testing.T) {
+	tests := []struct {
+		name          string
+		policy        nodesubnet.DriftPolicy
+		evictErr      error
+		driftedState  types.IPState
+		releasedState types.IPState
+		evicted       []string
+	}{
+		{
+			name:          "keep",
+			policy:        nodesubnet.DriftPolicyKeep,
+			driftedState:  types.Assigned,
+			releasedState: types.Available,
+		},
+		{
+			name:          "pending release",
+			policy:        nodesubnet.DriftPolicyPendingRelease,
+			driftedState:  types.PendingRelease,
+			releasedState: types.PendingRelease,
+		},
+		{
+			name:          "evict",
+			policy:        nodesubnet.DriftPolicyEvict,
+			driftedState:  types.PendingRelease,
+			releasedState: types.PendingRelease,
+			evicted:       []string{"default/pod"},
+		},
+		{
+			name:          "eviction fails",
+			policy:        nodesubnet.DriftPolicyEvict,
+			evictErr:      assert.AnError,
+			driftedState:  types.PendingRelease,
+			releasedState: types.PendingRelease,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := GetRestServiceObjectForNodeSubnetTest(t, &NoOpConflistGenerator{})
+			service.SetNodeOrchestrator(&cns.SetOrchestratorTypeRequest{OrchestratorType: cns.KubernetesCRD})
+			evicter := &fakePodEvicter{err: tt.evictErr}
+			service.SetNodeSubnetIPDriftPolicy(tt.policy, evicter)
+
+			require.NoError(t, service.UpdateIPsForNodeSubnet(parseAddrs(t, "10.0.0.5", "10.0.0.6", "10.0.0.7")))
+			podInfo := cns.NewPodInfo("container", "eth0", "pod", "default")
+			require.NoError(t, service.assignIPConfig(service.PodIPConfigState["10.0.0.6"], podInfo))
+
+			// both removed IPs leave the NIC, the assigned one is kept until the pod releases it
+			require.NoError(t, service.UpdateIPsForNodeSubnet(parseAddrs(t, "10.0.0.5")))
+			require.Len(t, service.PodIPConfigState, 2)
+			ipConfig := service.PodIPConfigState["10.0.0.6"]
+			assert.Equal(t, tt.driftedState, ipConfig.GetState())
+			drifted := service.nodeSubnetDriftedIPs()
+			require.Len(t, drifted, 1)
+			assert.Equal(t, "10.0.0.6", drifted[0].IPAddress)
+			assert.Equal(t, "default", drifted[0].PodNamespace)
+			assert.Equal(t, "pod", drifted[0].PodName)
+			assert.Equal(t, tt.evicted != nil, drifted[0].Evicted)
+			assert.Equal(t, tt.evicted, evicter.evicted)
+
+			// a successful eviction is not repeated, a failed one is retried
+			require.NoError(t, service.UpdateIPsForNodeSubnet(parseAddrs(t, "10.0.0.5")))
+			assert.Equal(t, tt.evicted, evicter.evicted)
+
+			require.NoError(t, service.releaseIPConfigs(podInfo))
+			ipConfig = service.PodIPConfigState["10.0.0.6"]
+			assert.Equal(t, tt.releasedState, ipConfig.GetState())
+
+			// once released the IP is removed at the next fetch
+			require.NoError(t, service.UpdateIPsForNodeSubnet(parseAddrs(t, "10.0.0.5")))
+			assert.Len(t, service.PodIPConfigState, 1)
+			assert.Empty(t, service.nodeSubnetDriftedIPs())
+		})
+	}
+}
+
+func TestReleasePendingReleaseIPNotDrifted(t *testing.T) {
+	service := getTestService(cns.KubernetesCRD)
+	ipconfigs := map[string]cns.IPConfigurationStatus{}
+	state, _ := newPodStateWithOrchestratorContext(testIP1, testPod1GUID, testNCID, types.Assigned, ipPrefixBitsv4, 0, testPod1Info)
+	ipconfigs[state.ID] = state
+	require.NoError(t, updatePodIPConfigState(t, service, ipconfigs, testNCID))
+	state.SetState(types.PendingRelease)
+	service.PodIPConfigState[state.ID] = state
+
+	// only drifted node subnet IPs are kept PendingRelease once released
+	require.NoError(t, service.releaseIPConfigs(testPod1Info))
+	released := service.PodIPConfigState[state.ID]
+	assert.Equal(t, types.Available, released.GetState())
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/Azure/azure-container-networking/cns"
 	"github.com/Azure/azure-container-networking/cns/common"
+	"github.com/Azure/azure-container-networking/cns/configuration"
 	"github.com/Azure/azure-container-networking/cns/dockerclient"
 	"github.com/Azure/azure-container-networking/cns/logger"
 	"github.com/Azure/azure-container-networking/cns/networkcontainers"
@@ -22,6 +23,7 @@ import (
 	nma "github.com/Azure/azure-container-networking/nmagent"
 	"github.com/Azure/azure-container-networking/store"
 	"github.com/pkg/errors"
+	"go.uber.org/zap"
 )
 
 // This file contains the initialization of RestServer.
@@ -92,6 +94,18 @@ type HTTPRestService struct {
 	PnpIDByMacAddress          map[string]string
 	imdsClient                 imdsClient
 	nodesubnetIPFetcher        *nodesubnet.IPFetcher
+	configReloader             *configuration.Reloader
+	logLevelHandler            http.Handler
+	conflictProbe              *addressConflictProbe
+	pniReservations            pniReservationSource
+	nodeSubnetDrift            nodeSubnetIPDrift
+	routeManager               hostRouteManager
+	ipAuditor                  ipAuditor
+	z                          *zap.Logger
+}
+
+type pniReservationSource interface {
+	Usage() []cns.PNIReservation
 }
 
 type CNIConflistGenerator interface {
@@ -125,6 +139,13 @@ type IPInfo struct {
 	NICType       cns.NICType
 }
 
+// GetConfigResponse is the effective CNS configuration and its recent reloads, served on the debug API paths.
+type GetConfigResponse struct {
+	Config   *configuration.CNSConfig    `json:"Config"`
+	Reloads  []configuration.ReloadEvent `json:"Reloads"`
+	Response Response                    `json:"Response"`
+}
+
 type GetHTTPServiceDataResponse struct {
 	HTTPRestServiceData HTTPRestServiceData `json:"HTTPRestServiceData"`
 	Response            Response            `json:"Response"`
@@ -171,6 +192,7 @@ type httpRestServiceState struct {
 	joinedNetworks                   map[string]struct{}
 	primaryInterface                 *wireserver.InterfaceInfo
 	PnpIDByMacAddress                map[string]string
+	NCHistory                        []cns.NCHistoryRecord `json:",omitempty"`
 }
 
 type networkInfo struct {
@@ -286,9 +308,16 @@ func (service *HTTPRestService) Init(config *common.ServiceConfig) error {
 	listener.AddHandler(cns.ReleaseIPConfig, NewHandlerFuncWithHistogram(service.ReleaseIPConfigHandler, HTTPRequestLatency))
 	listener.AddHandler(cns.ReleaseIPConfigs, NewHandlerFuncWithHistogram(service.ReleaseIPConfigsHandler, HTTPRequestLatency))
 	listener.AddHandler(cns.NmAgentSupportedApisPath, service.nmAgentSupportedApisHandler)
+	listener.AddHandler(cns.PathDebugConfig, service.HandleDebugConfig)
+	listener.AddHandler(cns.PathDebugLogLevel, service.HandleDebugLogLevel)
 	listener.AddHandler(cns.PathDebugIPAddresses, service.HandleDebugIPAddresses)
 	listener.AddHandler(cns.PathDebugPodContext, service.HandleDebugPodContext)
 	listener.AddHandler(cns.PathDebugRestData, service.HandleDebugRestData)
+	listener.AddHandler(cns.PathDebugPNIReservations, service.HandleDebugPNIReservations)
+	listener.AddHandler(cns.PathDebugNodeSubnetIPs, service.HandleDebugNodeSubnetIPs)
+	listener.AddHandler(cns.PathDebugRoutes, service.HandleDebugRoutes)
+	listener.AddHandler(cns.PathDebugNCHistory, service.HandleDebugNCHistory)
+	listener.AddHandler(cns.PathDebugIPAudit, service.HandleDebugIPAudit)
 	listener.AddHandler(cns.NetworkContainersURLPath, service.getOrRefreshNetworkContainers)
 	listener.AddHandler(cns.GetHomeAz, service.getHomeAz)
 	listener.AddHandler(cns.EndpointPath, service.EndpointHandlerAPI)
@@ -385,6 +414,21 @@ func (service *HTTPRestService) MustGenerateCNIConflistOnce() {
 	})
 }
 
+// SetConfigReloader sets the source of the effective CNS configuration served on /debug/config.
+func (service *HTTPRestService) SetConfigReloader(reloader *configuration.Reloader) {
+	service.configReloader = reloader
+}
+
+// SetLogLevelHandler sets the handler which serves the runtime log levels of CNS on /debug/loglevel.
+func (service *HTTPRestService) SetLogLevelHandler(h http.Handler) {
+	service.logLevelHandler = h
+}
+
+// SetPNIReservations sets the source of the PodNetworkInstance reservation usage served on /debug/pnireservations.
+func (service *HTTPRestService) SetPNIReservations(src pniReservationSource) {
+	service.pniReservations = src
+}
+
 func (service *HTTPRestService) AttachIPConfigsHandlerMiddleware(middleware cns.IPConfigsHandlerMiddleware) {
 	service.IPConfigsHandlerMiddleware = middleware
 }
@@ -58,6 +58,22 @@ func (service *HTTPRestService) SavePnpIDMacaddressMapping(ctx context.Context)
 	return nil
 }
 
+// RefreshPnpIDMacAddressMapping fetches the mapping again, such as after a NIC was added to or removed from the VM.
+func (service *HTTPRestService) RefreshPnpIDMacAddressMapping(ctx context.Context) error {
+	p := platform.NewExecClient(nil)
+	vfMacAddressMapping, err := platform.FetchMacAddressPnpIDMapping(ctx, p)
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch MACAddressPnpIDMapping")
+	}
+	service.Lock()
+	defer service.Unlock()
+	service.state.PnpIDByMacAddress = vfMacAddressMapping
+	if err = service.saveState(); err != nil {
+		logger.Errorf("Failed to save mapping to statefile: %v", err)
+	}
+	return nil
+}
+
 func (service *HTTPRestService) getPNPIDFromMacAddress(ctx context.Context, macAddress string) (string, error) {
 	// If map is empty in state file, CNS needs to populate state file before it returns back the response
 	if len(service.state.PnpIDByMacAddress) == 0 {
@@ -138,11 +154,30 @@ func (service *HTTPRestService) restoreState() {
 	}
 }
 
-func (service *HTTPRestService) saveNetworkContainerGoalState(req cns.CreateNetworkContainerRequest) (types.ResponseCode, string) { //nolint // legacy
+// saveNetworkContainerGoalState creates or updates the NC and records the change in the NC history as from the source.
+func (service *HTTPRestService) saveNetworkContainerGoalState(source cns.NCHistorySource, req cns.CreateNetworkContainerRequest) (types.ResponseCode, string) {
 	// we don't want to overwrite what other calls may have written
 	service.Lock()
 	defer service.Unlock()
 
+	var previous *containerstatus
+	if existing, ok := service.state.ContainerStatus[req.NetworkContainerid]; ok {
+		previous = &existing
+	}
+	returnCode, returnMessage := service.saveNetworkContainerGoalStateUntransacted(req)
+	current := service.state.ContainerStatus[req.NetworkContainerid]
+	service.recordNCHistoryUntransacted(newNCHistoryRecord(source, req.NetworkContainerid, previous, &current, returnCode))
+	if returnCode != types.Success {
+		return returnCode, returnMessage
+	}
+
+	service.saveState()
+	service.refreshHostRoutes()
+	return returnCode, returnMessage
+}
+
+// Note: this func is an untransacted API as the caller will take a Service lock
+func (service *HTTPRestService) saveNetworkContainerGoalStateUntransacted(req cns.CreateNetworkContainerRequest) (types.ResponseCode, string) { //nolint // legacy
 	var (
 		hostVersion                string
 		existingSecondaryIPConfigs map[string]cns.SecondaryIPConfig // uuid is key
@@ -246,7 +281,6 @@ func (service *HTTPRestService) saveNetworkContainerGoalState(req cns.CreateNetw
 		return types.UnsupportedNetworkContainerType, errMsg
 	}
 
-	service.saveState()
 	return 0, ""
 }
 
@@ -916,7 +950,7 @@ func (service *HTTPRestService) isNCWaitingForUpdate(ncVersion, ncid string, ncV
 }
 
 // handleGetNetworkContainers returns all NCs in CNS
-func (service *HTTPRestService) handleGetNetworkContainers(w http.ResponseWriter) {
+func (service *HTTPRestService) handleGetNetworkContainers(w http.ResponseWriter, r *http.Request) {
 	logger.Printf("[Azure CNS] handleGetNetworkContainers")
 	service.RLock()
 	networkContainers := make([]cns.GetNetworkContainerResponse, len(service.state.ContainerStatus))
@@ -946,7 +980,7 @@ func (service *HTTPRestService) handleGetNetworkContainers(w http.ResponseWriter
 		},
 	}
 	err := acn.Encode(w, &response)
-	logger.Response(service.Name, response, response.Response.ReturnCode, err)
+	service.logResponse(r.Context(), service.Name, response, response.Response.ReturnCode, err)
 }
 
 // handlePostNetworkContainers stores all the NCs (from the request that client sent) into CNS's state file
@@ -954,7 +988,7 @@ func (service *HTTPRestService) handlePostNetworkContainers(w http.ResponseWrite
 	logger.Printf("[Azure CNS] handlePostNetworkContainers")
 	var req cns.PostNetworkContainersRequest
 	err := acn.Decode(w, r, &req)
-	logger.Request(service.Name, &req, err)
+	service.logRequest(r.Context(), service.Name, &req, err)
 	if err != nil {
 		response := cns.PostNetworkContainersResponse{
 			Response: cns.Response{
@@ -963,7 +997,7 @@ func (service *HTTPRestService) handlePostNetworkContainers(w http.ResponseWrite
 			},
 		}
 		err = acn.Encode(w, &response)
-		logger.Response(service.Name, response, response.Response.ReturnCode, err)
+		service.logResponse(r.Context(), service.Name, response, response.Response.ReturnCode, err)
 		return
 	}
 	if err := req.Validate(); err != nil { //nolint:govet // shadow okay
@@ -977,7 +1011,7 @@ func (service *HTTPRestService) handlePostNetworkContainers(w http.ResponseWrite
 		Response: createNCsResp,
 	}
 	err = acn.Encode(w, &response)
-	logger.Response(service.Name, response, response.Response.ReturnCode, err)
+	service.logResponse(r.Context(), service.Name, response, response.Response.ReturnCode, err)
 }
 
 func (service *HTTPRestService) createNetworkContainers(createNetworkContainerRequests []cns.CreateNetworkContainerRequest) cns.Response {
@@ -995,7 +1029,7 @@ func (service *HTTPRestService) createNetworkContainers(createNetworkContainerRe
 			}
 		}
 		// Save NC Goal State details
-		saveNcReturnCode, saveNcReturnMessage := service.saveNetworkContainerGoalState(createNcReq)
+		saveNcReturnCode, saveNcReturnMessage := service.saveNetworkContainerGoalState(cns.NCHistorySourceDNC, createNcReq)
 		// If NC was created successfully, log NC snapshot.
 		if saveNcReturnCode != types.Success {
 			return cns.Response{
@@ -1013,9 +1047,9 @@ func (service *HTTPRestService) createNetworkContainers(createNetworkContainerRe
 }
 
 // setResponse encodes the http response
-func (service *HTTPRestService) setResponse(w http.ResponseWriter, returnCode types.ResponseCode, response interface{}) {
+func (service *HTTPRestService) setResponse(w http.ResponseWriter, r *http.Request, returnCode types.ResponseCode, response interface{}) {
 	serviceErr := acn.Encode(w, &response)
-	logger.Response(service.Name, response, returnCode, serviceErr)
+	service.logResponse(r.Context(), service.Name, response, returnCode, serviceErr)
 }
 
 // ncList contains comma-separated list of unique NCs
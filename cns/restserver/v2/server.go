@@ -2,6 +2,7 @@ package v2
 
 import (
 	"context"
+	"net"
 	"net/http"
 
 	"github.com/Azure/azure-container-networking/cns"
@@ -13,15 +14,28 @@ import (
 
 type Server struct {
 	*restserver.HTTPRestService
+	middleware  func(http.Handler) http.Handler
+	connContext func(context.Context, net.Conn) context.Context
 }
 
 func New(s *restserver.HTTPRestService) *Server {
-	return &Server{s}
+	return &Server{HTTPRestService: s}
+}
+
+// SetMiddleware wraps every request served by the local server in middleware, and sets the function used
+// to derive the context of each accepted connection. Both must be set before the server is started.
+func (s *Server) SetMiddleware(middleware func(http.Handler) http.Handler, connContext func(context.Context, net.Conn) context.Context) {
+	s.middleware = middleware
+	s.connContext = connContext
 }
 
 func (s Server) Start(ctx context.Context, addr string) error {
 	e := echo.New()
 	e.HideBanner = true
+	if s.middleware != nil {
+		e.Use(echo.WrapMiddleware(s.middleware))
+	}
+	e.Server.ConnContext = s.connContext
 	e.POST(cns.RequestIPConfig, echo.WrapHandler(restserver.NewHandlerFuncWithHistogram(s.RequestIPConfigHandler, restserver.HTTPRequestLatency)))
 	e.POST(cns.RequestIPConfigs, echo.WrapHandler(restserver.NewHandlerFuncWithHistogram(s.RequestIPConfigsHandler, restserver.HTTPRequestLatency)))
 	e.POST(cns.ReleaseIPConfig, echo.WrapHandler(restserver.NewHandlerFuncWithHistogram(s.ReleaseIPConfigHandler, restserver.HTTPRequestLatency)))
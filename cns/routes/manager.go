@@ -0,0 +1,213 @@
+package routes
+
+import (
+	"context"
+	"net/netip"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const defaultReconcileInterval = time.Minute
+
+// Source returns the routes desired on the host, by owner. It is called at every reconcile, so that routes whose
+// owner is gone are removed without being told.
+type Source func() map[string][]cns.Route
+
+// desiredRoute is a route desired by an owner, parsed from the cns.Route.
+type desiredRoute struct {
+	owner string
+	route cns.Route
+	dst   netip.Prefix
+	gw    netip.Addr
+}
+
+// programResult is the outcome of programming a desired route in the routing table.
+type programResult struct {
+	state cns.ManagedRouteState
+	err   error
+	// changed is whether the route had to be added or replaced.
+	changed bool
+}
+
+// dataplane programs the routing table of the host. It only ever adds, replaces or deletes routes it owns.
+type dataplane interface {
+	// program makes the routes owned by the dataplane exactly the desired routes, deleting the ones which are not
+	// desired, and returns the outcome for each desired route.
+	program(desired []desiredRoute) ([]programResult, error)
+}
+
+// Manager programs the routes desired by the owners of the Source in the routing table of the host, and reconciles
+// them periodically to correct drift such as the routes being deleted or changed by someone else. The routes it
+// programs are marked as its own, and routes it did not program are never changed or deleted.
+type Manager struct {
+	z        *zap.Logger
+	source   Source
+	dp       dataplane
+	interval time.Duration
+	refresh  chan struct{}
+
+	mu     sync.Mutex
+	routes []cns.ManagedRoute
+	// programmed are the destinations programmed at the last reconcile, to tell drift from routes added for the
+	// first time.
+	programmed    map[netip.Prefix]struct{}
+	lastReconcile time.Time
+}
+
+// NewManager creates a Manager reconciling the routes of the source every interval, or every minute if interval
+// is 0.
+func NewManager(z *zap.Logger, source Source, interval time.Duration) (*Manager, error) {
+	dp, err := newDataplane(z)
+	if err != nil {
+		return nil, err
+	}
+	return newManager(z, source, dp, interval), nil
+}
+
+func newManager(z *zap.Logger, source Source, dp dataplane, interval time.Duration) *Manager {
+	if interval == 0 {
+		interval = defaultReconcileInterval
+	}
+	return &Manager{
+		z:          z,
+		source:     source,
+		dp:         dp,
+		interval:   interval,
+		refresh:    make(chan struct{}, 1),
+		programmed: map[netip.Prefix]struct{}{},
+	}
+}
+
+// Start reconciles the routes until the context is cancelled. It should only be started once the desired routes
+// of the source are restored, as the routes owned by the Manager which are not desired are deleted.
+func (m *Manager) Start(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		if err := m.Reconcile(); err != nil {
+			m.z.Error("failed to reconcile routes", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-m.refresh:
+		}
+	}
+}
+
+// Refresh reconciles the routes now instead of at the end of the interval, for example once the desired routes
+// changed. It does not block, and refreshes requested while one is pending are coalesced.
+func (m *Manager) Refresh() {
+	select {
+	case m.refresh <- struct{}{}:
+	default:
+	}
+}
+
+// Reconcile programs the desired routes once.
+func (m *Manager) Reconcile() error {
+	desired, managed := m.desiredRoutes()
+	results, err := m.dp.program(desired)
+	if err != nil {
+		reconcileFailures.Inc()
+		return errors.Wrap(err, "failed to program routes")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	programmed := map[netip.Prefix]struct{}{}
+	i := 0
+	for j := range managed {
+		if managed[j].State != "" {
+			// invalid or conflicting with another owner
+			continue
+		}
+		d, res := desired[i], results[i]
+		i++
+		managed[j].State = res.state
+		if res.err != nil {
+			managed[j].Error = res.err.Error()
+			m.z.Error("failed to program route", zap.String("owner", d.owner), zap.String("dst", d.dst.String()), zap.Error(res.err))
+		}
+		if res.state != cns.ManagedRouteProgrammed {
+			continue
+		}
+		programmed[d.dst] = struct{}{}
+		if _, ok := m.programmed[d.dst]; ok && res.changed {
+			driftCorrections.Inc()
+			m.z.Info("corrected drifted route", zap.String("owner", d.owner), zap.String("dst", d.dst.String()))
+		}
+	}
+	m.routes, m.programmed, m.lastReconcile = managed, programmed, time.Now()
+	managedRoutes.Set(float64(len(programmed)))
+	return nil
+}
+
+// desiredRoutes returns the valid routes of the source without conflicts to program, and every route of the source
+// to report, with the state of those which are not programmed already set. The routes to program are in the order of
+// the routes to report.
+func (m *Manager) desiredRoutes() ([]desiredRoute, []cns.ManagedRoute) {
+	byOwner := m.source()
+	owners := make([]string, 0, len(byOwner))
+	for owner := range byOwner {
+		owners = append(owners, owner)
+	}
+	// the first owner of a destination in order wins it, so that conflicts resolve the same way every time
+	sort.Strings(owners)
+
+	var desired []desiredRoute
+	var managed []cns.ManagedRoute
+	claimed := map[netip.Prefix]string{}
+	for _, owner := range owners {
+		for _, route := range byOwner[owner] {
+			mr := cns.ManagedRoute{Owner: owner, Route: route}
+			d, err := parseRoute(owner, route)
+			switch {
+			case err != nil:
+				mr.State, mr.Error = cns.ManagedRouteFailed, err.Error()
+			case claimed[d.dst] != "":
+				mr.State, mr.Error = cns.ManagedRouteConflict, "destination is desired by "+claimed[d.dst]
+			default:
+				claimed[d.dst] = owner
+				desired = append(desired, d)
+			}
+			managed = append(managed, mr)
+		}
+	}
+	return desired, managed
+}
+
+func parseRoute(owner string, route cns.Route) (desiredRoute, error) {
+	dst, err := netip.ParsePrefix(route.IPAddress)
+	if err != nil {
+		return desiredRoute{}, errors.Wrapf(err, "invalid destination %q", route.IPAddress)
+	}
+	d := desiredRoute{owner: owner, route: route, dst: dst.Masked()}
+	if route.GatewayIPAddress != "" {
+		if d.gw, err = netip.ParseAddr(route.GatewayIPAddress); err != nil {
+			return desiredRoute{}, errors.Wrapf(err, "invalid gateway %q", route.GatewayIPAddress)
+		}
+		if d.gw.Is4() != dst.Addr().Is4() {
+			return desiredRoute{}, errors.Errorf("gateway %s is not of the family of destination %s", d.gw, dst)
+		}
+	}
+	if !d.gw.IsValid() && route.InterfaceToUse == "" {
+		return desiredRoute{}, errors.New("route has neither a gateway nor an interface")
+	}
+	return d, nil
+}
+
+// Routes returns the routes managed as of the last reconcile, and when it was.
+func (m *Manager) Routes() ([]cns.ManagedRoute, time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	routes := make([]cns.ManagedRoute, len(m.routes))
+	copy(routes, m.routes)
+	return routes, m.lastReconcile
+}
@@ -0,0 +1,151 @@
+package routes
+
+import (
+	"net"
+	"net/netip"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/netlink"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+)
+
+// rtprotCNS is the protocol of the routes programmed by the Manager, by which they are told from the routes of
+// everyone else.
+const rtprotCNS = 0xa6
+
+type routeClient interface {
+	GetIPRoute(filter *netlink.Route) ([]*netlink.Route, error)
+	AddIPRoute(route *netlink.Route) error
+	DeleteIPRoute(route *netlink.Route) error
+	ReplaceIPRoute(route *netlink.Route) error
+}
+
+type netlinkDataplane struct {
+	z         *zap.Logger
+	nl        routeClient
+	linkIndex func(name string) (int, error)
+}
+
+func newDataplane(z *zap.Logger) (dataplane, error) {
+	return &netlinkDataplane{z: z, nl: netlink.NewNetlink(), linkIndex: interfaceIndex}, nil
+}
+
+func interfaceIndex(name string) (int, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to get interface %s", name)
+	}
+	return iface.Index, nil
+}
+
+func (d *netlinkDataplane) program(desired []desiredRoute) ([]programResult, error) {
+	owned := map[netip.Prefix]*netlink.Route{}
+	for _, family := range []int{unix.AF_INET, unix.AF_INET6} {
+		routes, err := d.nl.GetIPRoute(&netlink.Route{Family: family, Protocol: rtprotCNS})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list routes")
+		}
+		for _, route := range routes {
+			owned[destinationOf(route)] = route
+		}
+	}
+
+	results := make([]programResult, len(desired))
+	for i := range desired {
+		results[i] = d.programRoute(desired[i], owned[desired[i].dst])
+		delete(owned, desired[i].dst)
+	}
+
+	// the routes left are no longer desired by any owner
+	for dst, route := range owned {
+		if err := d.nl.DeleteIPRoute(route); err != nil {
+			// retried at the next reconcile
+			d.z.Error("failed to delete route", zap.String("dst", dst.String()), zap.Error(err))
+			continue
+		}
+		d.z.Info("deleted route", zap.String("dst", dst.String()))
+	}
+	return results, nil
+}
+
+// programRoute makes the route to the destination the desired route, given the route to it owned by the Manager
+// if there is one.
+func (d *netlinkDataplane) programRoute(desired desiredRoute, owned *netlink.Route) programResult {
+	want, err := d.toNetlinkRoute(desired)
+	if err != nil {
+		return programResult{state: cns.ManagedRouteFailed, err: err}
+	}
+	if owned != nil {
+		if sameRoute(owned, want) {
+			return programResult{state: cns.ManagedRouteProgrammed}
+		}
+		// replaced in place, so that the destination is never left without a route
+		if err := d.nl.ReplaceIPRoute(want); err != nil {
+			return programResult{state: cns.ManagedRouteFailed, err: errors.Wrapf(err, "failed to replace changed route to %s", desired.dst)}
+		}
+		return programResult{state: cns.ManagedRouteProgrammed, changed: true}
+	}
+	foreign, err := d.nl.GetIPRoute(&netlink.Route{Family: want.Family, Dst: want.Dst})
+	if err != nil {
+		return programResult{state: cns.ManagedRouteFailed, err: errors.Wrapf(err, "failed to list routes to %s", desired.dst)}
+	}
+	if len(foreign) > 0 {
+		return programResult{state: cns.ManagedRouteConflict, err: errors.Errorf("route to %s exists with protocol %d", desired.dst, foreign[0].Protocol)}
+	}
+	if err := d.nl.AddIPRoute(want); err != nil {
+		return programResult{state: cns.ManagedRouteFailed, err: errors.Wrapf(err, "failed to add route to %s", desired.dst)}
+	}
+	return programResult{state: cns.ManagedRouteProgrammed, changed: true}
+}
+
+func (d *netlinkDataplane) toNetlinkRoute(desired desiredRoute) (*netlink.Route, error) {
+	family := unix.AF_INET6
+	if desired.dst.Addr().Is4() {
+		family = unix.AF_INET
+	}
+	route := &netlink.Route{
+		Family:   family,
+		Dst:      prefixToIPNet(desired.dst),
+		Table:    unix.RT_TABLE_MAIN,
+		Protocol: rtprotCNS,
+		Scope:    netlink.RT_SCOPE_UNIVERSE,
+	}
+	if desired.gw.IsValid() {
+		route.Gw = desired.gw.AsSlice()
+	} else {
+		route.Scope = netlink.RT_SCOPE_LINK
+	}
+	if desired.route.InterfaceToUse != "" {
+		index, err := d.linkIndex(desired.route.InterfaceToUse)
+		if err != nil {
+			return nil, err
+		}
+		route.LinkIndex = index
+	}
+	return route, nil
+}
+
+// sameRoute is whether the route in the routing table is the wanted route. The interface is only compared when one
+// is wanted, as the kernel picks the interface of routes without one.
+func sameRoute(have, want *netlink.Route) bool {
+	return have.Gw.Equal(want.Gw) && (want.LinkIndex == 0 || have.LinkIndex == want.LinkIndex)
+}
+
+// destinationOf returns the destination of the route, which is nil for default routes.
+func destinationOf(route *netlink.Route) netip.Prefix {
+	if route.Dst == nil {
+		if route.Family == unix.AF_INET {
+			return netip.PrefixFrom(netip.IPv4Unspecified(), 0)
+		}
+		return netip.PrefixFrom(netip.IPv6Unspecified(), 0)
+	}
+	addr, _ := netip.AddrFromSlice(route.Dst.IP)
+	ones, _ := route.Dst.Mask.Size()
+	return netip.PrefixFrom(addr.Unmap(), ones)
+}
+
+func prefixToIPNet(p netip.Prefix) *net.IPNet {
+	return &net.IPNet{IP: p.Addr().AsSlice(), Mask: net.CIDRMask(p.Bits(), p.Addr().BitLen())}
+}
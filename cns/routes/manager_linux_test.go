@@ -0,0 +1,143 @@
+package routes
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/netlink"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+)
+
+// fakeRouteClient is a routing table which filters and refuses duplicate routes like the kernel.
+type fakeRouteClient struct {
+	routes   []*netlink.Route
+	replaced int
+}
+
+func (f *fakeRouteClient) GetIPRoute(filter *netlink.Route) ([]*netlink.Route, error) {
+	var routes []*netlink.Route
+	for _, r := range f.routes {
+		if r.Family != filter.Family ||
+			(filter.Protocol != 0 && r.Protocol != filter.Protocol) ||
+			(filter.Dst != nil && destinationOf(r) != destinationOf(filter)) {
+			continue
+		}
+		routes = append(routes, r)
+	}
+	return routes, nil
+}
+
+func (f *fakeRouteClient) AddIPRoute(route *netlink.Route) error {
+	if existing, _ := f.GetIPRoute(&netlink.Route{Family: route.Family, Dst: route.Dst}); len(existing) > 0 {
+		return unix.EEXIST
+	}
+	f.routes = append(f.routes, route)
+	return nil
+}
+
+func (f *fakeRouteClient) DeleteIPRoute(route *netlink.Route) error {
+	for i, r := range f.routes {
+		if r.Family == route.Family && destinationOf(r) == destinationOf(route) && r.Protocol == route.Protocol {
+			f.routes = append(f.routes[:i], f.routes[i+1:]...)
+			return nil
+		}
+	}
+	return unix.ESRCH
+}
+
+// ReplaceIPRoute replaces the route to the destination in place, counting the replacements.
+func (f *fakeRouteClient) ReplaceIPRoute(route *netlink.Route) error {
+	f.replaced++
+	for i, r := range f.routes {
+		if r.Family == route.Family && destinationOf(r) == destinationOf(route) {
+			f.routes[i] = route
+			return nil
+		}
+	}
+	f.routes = append(f.routes, route)
+	return nil
+}
+
+func (f *fakeRouteClient) route(t *testing.T, dst string) *netlink.Route {
+	t.Helper()
+	p := netip.MustParsePrefix(dst)
+	for _, r := range f.routes {
+		if destinationOf(r) == p {
+			return r
+		}
+	}
+	return nil
+}
+
+func TestManager(t *testing.T) {
+	nl := &fakeRouteClient{routes: []*netlink.Route{
+		{Family: unix.AF_INET, Dst: prefixToIPNet(netip.MustParsePrefix("10.2.0.0/16")), Gw: net.ParseIP("10.0.0.1"), Protocol: unix.RTPROT_STATIC},
+	}}
+	dp := &netlinkDataplane{
+		z:  zap.NewNop(),
+		nl: nl,
+		linkIndex: func(name string) (int, error) {
+			require.Equal(t, "eth1", name)
+			return 3, nil
+		},
+	}
+	desired := map[string][]cns.Route{
+		"nc1": {
+			{IPAddress: "10.1.0.0/16", GatewayIPAddress: "10.0.0.1"},
+			{IPAddress: "10.2.0.0/16", GatewayIPAddress: "10.0.0.1"},
+			{IPAddress: "fd00::/64", InterfaceToUse: "eth1"},
+		},
+		"nc2": {
+			{IPAddress: "10.1.0.0/16", GatewayIPAddress: "10.0.0.2"},
+			{IPAddress: "10.3.0.0/16"},
+		},
+	}
+	m := newManager(zap.NewNop(), func() map[string][]cns.Route { return desired }, dp, 0)
+
+	require.NoError(t, m.Reconcile())
+	routes, _ := m.Routes()
+	require.Len(t, routes, 5)
+	states := make([]cns.ManagedRouteState, len(routes))
+	for i := range routes {
+		states[i] = routes[i].State
+	}
+	assert.Equal(t, []cns.ManagedRouteState{
+		cns.ManagedRouteProgrammed, // nc1 10.1.0.0/16
+		cns.ManagedRouteConflict,   // foreign route to 10.2.0.0/16
+		cns.ManagedRouteProgrammed, // nc1 fd00::/64
+		cns.ManagedRouteConflict,   // 10.1.0.0/16 is desired by nc1
+		cns.ManagedRouteFailed,     // neither gateway nor interface
+	}, states)
+	assert.Len(t, nl.routes, 3)
+	assert.Equal(t, unix.RTPROT_STATIC, nl.route(t, "10.2.0.0/16").Protocol)
+	v6 := nl.route(t, "fd00::/64")
+	assert.Equal(t, 3, v6.LinkIndex)
+	assert.Equal(t, netlink.RT_SCOPE_LINK, v6.Scope)
+	assert.Equal(t, rtprotCNS, v6.Protocol)
+
+	// a deleted route and a changed route are programmed again
+	corrections := testutil.ToFloat64(driftCorrections)
+	require.NoError(t, nl.DeleteIPRoute(v6))
+	nl.route(t, "10.1.0.0/16").Gw = net.ParseIP("10.0.0.9")
+	require.NoError(t, m.Reconcile())
+	assert.Equal(t, corrections+2, testutil.ToFloat64(driftCorrections))
+	assert.Equal(t, 1, nl.replaced, "a changed route is replaced rather than deleted and added")
+	assert.True(t, net.ParseIP("10.0.0.1").Equal(nl.route(t, "10.1.0.0/16").Gw))
+	assert.NotNil(t, nl.route(t, "fd00::/64"))
+
+	// the routes of an owner which is gone are deleted, but never the routes of someone else
+	delete(desired, "nc1")
+	require.NoError(t, m.Reconcile())
+	routes, _ = m.Routes()
+	require.Len(t, routes, 2)
+	assert.Equal(t, cns.ManagedRouteProgrammed, routes[0].State)
+	require.Len(t, nl.routes, 2)
+	assert.Equal(t, unix.RTPROT_STATIC, nl.route(t, "10.2.0.0/16").Protocol)
+	assert.True(t, net.ParseIP("10.0.0.2").Equal(nl.route(t, "10.1.0.0/16").Gw))
+}
@@ -0,0 +1,10 @@
+package routes
+
+import (
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+func newDataplane(*zap.Logger) (dataplane, error) {
+	return nil, errors.New("host route management is not supported on windows")
+}
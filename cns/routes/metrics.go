@@ -0,0 +1,35 @@
+package routes
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	managedRoutes = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "cns_managed_routes",
+			Help: "Host routes programmed by CNS at the last reconcile.",
+		},
+	)
+	driftCorrections = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "cns_managed_route_drift_corrections_total",
+			Help: "Host routes programmed by CNS which were found deleted or changed and programmed again.",
+		},
+	)
+	reconcileFailures = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "cns_managed_route_reconcile_failures_total",
+			Help: "Reconciles of the host routes programmed by CNS which failed to read the routing table.",
+		},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		managedRoutes,
+		driftCorrections,
+		reconcileFailures,
+	)
+}
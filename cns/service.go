@@ -101,6 +101,7 @@ func (service *Service) AddListener(config *common.ServiceConfig) error {
 	if err != nil {
 		return errors.Wrap(err, "Failed to construct url for node listener")
 	}
+	nodeListener.SetMiddleware(config.Middleware, config.ConnContext)
 
 	// only use TLS connection for DNC/CNS listener:
 	if config.TLSSettings.TLSPort != "" {
@@ -111,7 +112,11 @@ func (service *Service) AddListener(config *common.ServiceConfig) error {
 		tlsAddress := net.JoinHostPort(hostParts[0], config.TLSSettings.TLSPort)
 
 		// Start the listener and HTTP and HTTPS server.
-		tlsConfig, err := getTLSConfig(config.TLSSettings, config.ErrChan) //nolint
+		ctx := config.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		tlsConfig, err := getTLSConfig(ctx, config.TLSSettings, config.ErrChan) //nolint
 		if err != nil {
 			logger.Printf("Failed to compose Tls Configuration with error: %+v", err)
 			return errors.Wrap(err, "could not get tls config")
@@ -144,43 +149,35 @@ func (service *Service) Initialize(config *common.ServiceConfig) error {
 	return nil
 }
 
-func getTLSConfig(tlsSettings localtls.TlsSettings, errChan chan<- error) (*tls.Config, error) {
+func getTLSConfig(ctx context.Context, tlsSettings localtls.TlsSettings, errChan chan<- error) (*tls.Config, error) {
 	if tlsSettings.TLSCertificatePath != "" {
-		return getTLSConfigFromFile(tlsSettings)
+		return getTLSConfigFromFile(ctx, tlsSettings)
 	}
 
 	if tlsSettings.KeyVaultURL != "" {
-		return getTLSConfigFromKeyVault(tlsSettings, errChan)
+		return getTLSConfigFromKeyVault(ctx, tlsSettings, errChan)
 	}
 
 	return nil, errors.Errorf("invalid tls settings: %+v", tlsSettings)
 }
 
-func getTLSConfigFromFile(tlsSettings localtls.TlsSettings) (*tls.Config, error) {
-	tlsCertRetriever, err := localtls.GetTlsCertificateRetriever(tlsSettings)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to get certificate retriever")
+func getTLSConfigFromFile(ctx context.Context, tlsSettings localtls.TlsSettings) (*tls.Config, error) {
+	var clientCAs localtls.ClientCAsFunc
+	if tlsSettings.UseMTLS {
+		clientCAs = mtlsRootCAsFromCertificate
 	}
-
-	leafCertificate, err := tlsCertRetriever.GetCertificate()
+	cr, err := localtls.NewFileCertRefresher(tlsSettings, clientCAs, logger.Log)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to get certificate")
+		return nil, errors.Wrap(err, "could not create new file cert refresher")
 	}
 
-	if leafCertificate == nil {
-		return nil, errors.New("certificate retrieval returned empty")
-	}
-
-	privateKey, err := tlsCertRetriever.GetPrivateKey()
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to get certificate private key")
-	}
+	// the last good certificate keeps being served if the file can no longer be watched, so this is not fatal
+	go func() {
+		if err := cr.Refresh(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			logger.Errorf("stopped refreshing certificate from %s: %v", tlsSettings.TLSCertificatePath, err)
+		}
+	}()
 
-	tlsCert := tls.Certificate{
-		Certificate: [][]byte{leafCertificate.Raw},
-		PrivateKey:  privateKey,
-		Leaf:        leafCertificate,
-	}
 	minTLSVersionNumber, err := parseTLSVersionName(tlsSettings.MinTLSVersion)
 	if err != nil {
 		return nil, errors.Wrap(err, "parsing MinTLSVersion from config")
@@ -189,19 +186,25 @@ func getTLSConfigFromFile(tlsSettings localtls.TlsSettings) (*tls.Config, error)
 	tlsConfig := &tls.Config{
 		MaxVersion: tls.VersionTLS13,
 		MinVersion: minTLSVersionNumber,
-		Certificates: []tls.Certificate{
-			tlsCert,
+		GetCertificate: func(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return cr.GetCertificate(), nil
+		},
+		GetClientCertificate: func(_ *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return cr.GetCertificate(), nil
 		},
 	}
 
 	if tlsSettings.UseMTLS {
-		rootCAs, err := mtlsRootCAsFromCertificate(&tlsCert)
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to get root CAs for configuring mTLS")
-		}
 		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
-		tlsConfig.ClientCAs = rootCAs
-		tlsConfig.RootCAs = rootCAs
+		tlsConfig.ClientCAs = cr.GetClientCAs()
+		tlsConfig.RootCAs = cr.GetClientCAs()
+		// pick up the client CA pool of a rotated certificate on every handshake
+		base := tlsConfig.Clone()
+		tlsConfig.GetConfigForClient = func(_ *tls.ClientHelloInfo) (*tls.Config, error) {
+			cfg := base.Clone()
+			cfg.ClientCAs = cr.GetClientCAs()
+			return cfg, nil
+		}
 	}
 
 	logger.Debugf("TLS configured successfully from file: %+v", tlsSettings)
@@ -209,7 +212,7 @@ func getTLSConfigFromFile(tlsSettings localtls.TlsSettings) (*tls.Config, error)
 	return tlsConfig, nil
 }
 
-func getTLSConfigFromKeyVault(tlsSettings localtls.TlsSettings, errChan chan<- error) (*tls.Config, error) {
+func getTLSConfigFromKeyVault(ctx context.Context, tlsSettings localtls.TlsSettings, errChan chan<- error) (*tls.Config, error) {
 	credOpts := azidentity.ManagedIdentityCredentialOptions{ID: azidentity.ResourceID(tlsSettings.MSIResourceID)}
 	cred, err := azidentity.NewManagedIdentityCredential(&credOpts)
 	if err != nil {
@@ -221,8 +224,6 @@ func getTLSConfigFromKeyVault(tlsSettings localtls.TlsSettings, errChan chan<- e
 		return nil, errors.Wrap(err, "could not create new keyvault shim")
 	}
 
-	ctx := context.TODO()
-
 	cr, err := keyvault.NewCertRefresher(ctx, kvs, logger.Log, tlsSettings.KeyVaultCertificateName)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not create new cert refresher")
@@ -302,6 +303,11 @@ func (service *Service) StartListener(config *common.ServiceConfig) error {
 		if err := service.Listener.Start(config.ErrChan); err != nil {
 			return err
 		}
+		if config.UnixSocketPath != "" {
+			if err := service.Listener.StartUnix(config.ErrChan, config.UnixSocketPath); err != nil {
+				return errors.Wrap(err, "could not start unix socket listener")
+			}
+		}
 	} else {
 		return fmt.Errorf("Failed to start a listener, it is not initialized, config %+v", config)
 	}
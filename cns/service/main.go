@@ -20,6 +20,7 @@ import (
 
 	"github.com/Azure/azure-container-networking/aitelemetry"
 	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/authz"
 	cnsclient "github.com/Azure/azure-container-networking/cns/client"
 	cnscli "github.com/Azure/azure-container-networking/cns/cmd/cli"
 	"github.com/Azure/azure-container-networking/cns/cniconflist"
@@ -35,9 +36,11 @@ import (
 	"github.com/Azure/azure-container-networking/cns/ipampool"
 	"github.com/Azure/azure-container-networking/cns/ipampool/metrics"
 	ipampoolv2 "github.com/Azure/azure-container-networking/cns/ipampool/v2"
+	"github.com/Azure/azure-container-networking/cns/ipaudit"
 	cssctrl "github.com/Azure/azure-container-networking/cns/kubecontroller/clustersubnetstate"
 	mtpncctrl "github.com/Azure/azure-container-networking/cns/kubecontroller/multitenantpodnetworkconfig"
 	nncctrl "github.com/Azure/azure-container-networking/cns/kubecontroller/nodenetworkconfig"
+	oecctrl "github.com/Azure/azure-container-networking/cns/kubecontroller/overlayextensionconfig"
 	podctrl "github.com/Azure/azure-container-networking/cns/kubecontroller/pod"
 	"github.com/Azure/azure-container-networking/cns/logger"
 	loggerv2 "github.com/Azure/azure-container-networking/cns/logger/v2"
@@ -45,8 +48,11 @@ import (
 	"github.com/Azure/azure-container-networking/cns/middlewares"
 	"github.com/Azure/azure-container-networking/cns/multitenantcontroller"
 	"github.com/Azure/azure-container-networking/cns/multitenantcontroller/multitenantoperator"
+	"github.com/Azure/azure-container-networking/cns/nichotplug"
+	"github.com/Azure/azure-container-networking/cns/nodesubnet"
 	"github.com/Azure/azure-container-networking/cns/restserver"
 	restserverv2 "github.com/Azure/azure-container-networking/cns/restserver/v2"
+	"github.com/Azure/azure-container-networking/cns/routes"
 	cnipodprovider "github.com/Azure/azure-container-networking/cns/stateprovider/cni"
 	cnspodprovider "github.com/Azure/azure-container-networking/cns/stateprovider/cns"
 	cnstypes "github.com/Azure/azure-container-networking/cns/types"
@@ -55,15 +61,22 @@ import (
 	"github.com/Azure/azure-container-networking/crd"
 	"github.com/Azure/azure-container-networking/crd/clustersubnetstate"
 	cssv1alpha1 "github.com/Azure/azure-container-networking/crd/clustersubnetstate/api/v1alpha1"
+	cssv1alpha2 "github.com/Azure/azure-container-networking/crd/clustersubnetstate/api/v1alpha2"
 	"github.com/Azure/azure-container-networking/crd/multitenancy"
 	mtv1alpha1 "github.com/Azure/azure-container-networking/crd/multitenancy/api/v1alpha1"
 	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig"
 	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
+	oecv1alpha1 "github.com/Azure/azure-container-networking/crd/overlayextensionconfig/api/v1alpha1"
 	acnfs "github.com/Azure/azure-container-networking/internal/fs"
 	"github.com/Azure/azure-container-networking/log"
+	logv2 "github.com/Azure/azure-container-networking/log/v2"
+	"github.com/Azure/azure-container-networking/netio"
+	"github.com/Azure/azure-container-networking/netlink"
 	"github.com/Azure/azure-container-networking/nmagent"
 	"github.com/Azure/azure-container-networking/platform"
+	"github.com/Azure/azure-container-networking/probe"
 	"github.com/Azure/azure-container-networking/processlock"
+	"github.com/Azure/azure-container-networking/refresh"
 	localtls "github.com/Azure/azure-container-networking/server/tls"
 	"github.com/Azure/azure-container-networking/store"
 	"github.com/Azure/azure-container-networking/telemetry"
@@ -77,10 +90,12 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
 	kuberuntime "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -532,12 +547,15 @@ func main() {
 		err                error
 		config             common.ServiceConfig
 		endpointStateStore store.KeyValueStore
+		// crdClient is the cached client of the CRD manager, only set in CRD channel mode
+		crdClient client.Client
 	)
 
 	config.Version = version
 	config.Name = name
 	// Create a channel to receive unhandled errors from CNS.
 	config.ErrChan = rootErrCh
+	config.Context = rootCtx
 
 	// Create logging provider.
 	logger.InitLogger(name, logLevel, logTarget, logDirectory)
@@ -614,15 +632,15 @@ func main() {
 
 		switch scenario := cniConflistScenario(scenarioString); scenario {
 		case scenarioV4Overlay:
-			conflistGenerator = &cniconflist.V4OverlayGenerator{Writer: writer}
+			conflistGenerator = &cniconflist.V4OverlayGenerator{Writer: writer, MTUPolicy: cnsconfig.CNIConflistMTUPolicy}
 		case scenarioDualStackOverlay:
-			conflistGenerator = &cniconflist.DualStackOverlayGenerator{Writer: writer}
+			conflistGenerator = &cniconflist.DualStackOverlayGenerator{Writer: writer, MTUPolicy: cnsconfig.CNIConflistMTUPolicy}
 		case scenarioOverlay:
-			conflistGenerator = &cniconflist.OverlayGenerator{Writer: writer}
+			conflistGenerator = &cniconflist.OverlayGenerator{Writer: writer, MTUPolicy: cnsconfig.CNIConflistMTUPolicy}
 		case scenarioCilium:
 			conflistGenerator = &cniconflist.CiliumGenerator{Writer: writer}
 		case scenarioSWIFT:
-			conflistGenerator = &cniconflist.SWIFTGenerator{Writer: writer}
+			conflistGenerator = &cniconflist.SWIFTGenerator{Writer: writer, MTUPolicy: cnsconfig.CNIConflistMTUPolicy}
 		default:
 			logger.Errorf("unable to generate cni conflist for unknown scenario: %s", scenario)
 			os.Exit(1)
@@ -652,6 +670,39 @@ func main() {
 		logger.Log = loggerv2.AsV1(z, c)
 	}
 
+	// export traces of API requests and the calls CNS makes while serving them
+	if cnsconfig.Logger.Tracing != nil {
+		shutdownTracing, err := loggerv2.NewTracerProvider(cnsconfig.Logger.Tracing)
+		defer shutdownTracing()
+		if err != nil {
+			logger.Errorf("failed to start tracing, continuing without it: %v", err)
+		}
+	}
+
+	// watch the config file and apply changes to the fields which are safe to change at runtime
+	configReloader, err := configuration.NewReloader(cmdLineConfigPath, cnsconfig)
+	if err != nil {
+		logger.Errorf("fatal: failed to create cns config reloader: %v", err)
+		os.Exit(1)
+	}
+	logLevelHandler := logv2.NewLevelHandler(&cnsconfig.Logger, loggerv2.Components, func(previous, current logv2.LevelResponse) {
+		z.Info("log levels changed", zap.Any("previous", previous), zap.Any("current", current))
+	})
+	configReloader.OnChange(func(old, updated *configuration.CNSConfig) {
+		if old.Logger.Level == updated.Logger.Level {
+			return
+		}
+		// through the level handler, so that a pending override of the level reverts to the reloaded level
+		if err := logLevelHandler.SetConfiguredLevel(updated.Logger.Level); err != nil {
+			logger.Errorf("[Azure CNS] Failed to change log level to %s: %v", updated.Logger.Level, err)
+		}
+	})
+	go func() {
+		if err := configReloader.Start(rootCtx, z); err != nil && !errors.Is(err, context.Canceled) {
+			logger.Errorf("[Azure CNS] Stopped watching cns config for changes: %v", err)
+		}
+	}()
+
 	// start the healthz/readyz/metrics server
 	readyCh := make(chan any)
 	readyChecker := healthz.CheckHandler{
@@ -678,6 +729,7 @@ func main() {
 		return
 	}
 
+	nmaConfig.WrapTransport = tracingTransport(cnsconfig)
 	nmaClient, err := nmagent.NewClient(nmaConfig)
 	if err != nil {
 		logger.Errorf("[Azure CNS] Failed to start nmagent client due to error: %v", err)
@@ -756,14 +808,19 @@ func main() {
 		}
 	}
 
+	var wsTransport http.RoundTripper
+	if wrap := tracingTransport(cnsconfig); wrap != nil {
+		wsTransport = wrap(nil)
+	}
+
 	wsProxy := wireserver.Proxy{
 		Host:       cnsconfig.WireserverIP,
-		HTTPClient: &http.Client{},
+		HTTPClient: &http.Client{Transport: wsTransport},
 	}
 
 	wsclient := &wireserver.Client{
 		HostPort:   cnsconfig.WireserverIP,
-		HTTPClient: &http.Client{},
+		HTTPClient: &http.Client{Transport: wsTransport},
 		Logger:     logger.Log,
 	}
 
@@ -785,6 +842,18 @@ func main() {
 	httpRemoteRestService.SetOption(acn.OptHttpResponseHeaderTimeout, httpResponseHeaderTimeout)
 	httpRemoteRestService.SetOption(acn.OptProgramSNATIPTables, cnsconfig.ProgramSNATIPTables)
 	httpRemoteRestService.SetOption(acn.OptManageEndpointState, cnsconfig.ManageEndpointState)
+	httpRemoteRestService.SetConfigReloader(configReloader)
+	if cnsconfig.EnableLoggerV2 {
+		// the APIs log their requests and responses with the trace context of the request stamped on them, which
+		// the legacy logger otherwise gets in their tag
+		httpRemoteRestService.SetLogger(z.Named(loggerv2.ComponentRestServer))
+	}
+	httpRemoteRestService.SetLogLevelHandler(logLevelHandler)
+	if cnsconfig.AddressConflictProbe.Enable {
+		probeSettings := cnsconfig.AddressConflictProbe
+		prober := probe.New(z, time.Duration(probeSettings.TimeoutMs)*time.Millisecond, probeSettings.Attempts)
+		httpRemoteRestService.SetAddressConflictProber(prober, probeSettings.Interface, time.Duration(probeSettings.QuarantineDurationSecs)*time.Second)
+	}
 
 	// Create default ext network if commandline option is set
 	if len(strings.TrimSpace(createDefaultExtNetworkType)) > 0 {
@@ -812,6 +881,31 @@ func main() {
 			}
 		}
 
+		if cnsconfig.Authorization.Enable {
+			authorizer, err := authz.New(&cnsconfig.Authorization)
+			if err != nil {
+				logger.Errorf("Failed to create API authorizer, err:%v.\n", err)
+				return
+			}
+			config.Middleware = authorizer.Middleware
+			config.ConnContext = authz.ConnContext
+			config.UnixSocketPath = cnsconfig.Authorization.UnixSocketPath
+		}
+
+		authorize := config.Middleware
+		accessLog := loggerv2.AccessLog(z.Named(loggerv2.ComponentRestServer))
+		config.Middleware = func(next http.Handler) http.Handler {
+			if authorize != nil {
+				next = authorize(next)
+			}
+			// log and trace outside of authorization so that denied requests are seen too
+			next = accessLog(next)
+			if cnsconfig.Logger.Tracing != nil {
+				next = loggerv2.HTTPHandler(next)
+			}
+			return next
+		}
+
 		err = httpRemoteRestService.Init(&config)
 		if err != nil {
 			logger.Errorf("Failed to init HTTPService, err:%v.\n", err)
@@ -833,7 +927,17 @@ func main() {
 		// reg key value for PriorityVLANTag = 3  --> Packet priority and VLAN enabled
 		// for more details goto https://docs.nvidia.com/networking/display/winof2v230/Configuring+the+Driver+Registry+Keys#ConfiguringtheDriverRegistryKeys-GeneralRegistryKeysGeneralRegistryKeys
 		if platform.HasMellanoxAdapter() {
-			go platform.MonitorAndSetMellanoxRegKeyPriorityVLANTag(rootCtx, cnsconfig.MellanoxMonitorIntervalSecs)
+			mellanoxCtx, stopMellanoxMonitor := context.WithCancel(rootCtx)
+			go platform.MonitorAndSetMellanoxRegKeyPriorityVLANTag(mellanoxCtx, cnsconfig.MellanoxMonitorIntervalSecs)
+			// restart the monitor when its interval is changed
+			configReloader.OnChange(func(old, updated *configuration.CNSConfig) {
+				if old.MellanoxMonitorIntervalSecs == updated.MellanoxMonitorIntervalSecs {
+					return
+				}
+				stopMellanoxMonitor()
+				mellanoxCtx, stopMellanoxMonitor = context.WithCancel(rootCtx)
+				go platform.MonitorAndSetMellanoxRegKeyPriorityVLANTag(mellanoxCtx, updated.MellanoxMonitorIntervalSecs)
+			})
 		}
 
 		// if swiftv2 scenario is enabled, we need to initialize the ServiceFabric(standalone) swiftv2 middleware to process IPConfigsRequests
@@ -869,7 +973,7 @@ func main() {
 
 		logger.Printf("Set GlobalPodInfoScheme %v (InitializeFromCNI=%t)", cns.GlobalPodInfoScheme, cnsconfig.InitializeFromCNI)
 
-		err = InitializeCRDState(rootCtx, z, httpRemoteRestService, cnsconfig)
+		crdClient, err = InitializeCRDState(rootCtx, z, httpRemoteRestService, cnsconfig, configReloader)
 		if err != nil {
 			logger.Errorf("Failed to start CRD Controller, err:%v.\n", err)
 			return
@@ -909,6 +1013,11 @@ func main() {
 			return
 		}
 
+		if err = setNodeSubnetIPDriftPolicy(cnsconfig.NodeSubnetIPDrift, httpRemoteRestService); err != nil {
+			logger.Errorf("[Azure CNS] Failed to set node subnet IP drift policy: %v", err)
+			return
+		}
+
 		err = httpRemoteRestService.InitializeNodeSubnet(rootCtx, podInfoByIPProvider)
 		if err != nil {
 			logger.Errorf("[Azure CNS] Failed to initialize node subnet: %v", err)
@@ -919,13 +1028,30 @@ func main() {
 	// Initialize multi-tenant controller if the CNS is running in MultiTenantCRD mode.
 	// It must be started before we start HTTPRemoteRestService.
 	if config.ChannelMode == cns.MultiTenantCRD {
-		err = InitializeMultiTenantController(rootCtx, httpRemoteRestService, *cnsconfig)
+		err = InitializeMultiTenantController(rootCtx, httpRemoteRestService, *cnsconfig, configReloader)
 		if err != nil {
 			logger.Errorf("Failed to start multiTenantController, err:%v.\n", err)
 			return
 		}
 	}
 
+	// The routes of the NCs are only managed once their goal state is restored, as the routes which are not desired
+	// are deleted.
+	if cnsconfig.HostRoutes.Enable {
+		if config.ChannelMode == cns.CRD && cnsconfig.HostRoutes.SwiftV2InfraGatewayV4 == "" && cnsconfig.HostRoutes.SwiftV2InfraGatewayV6 == "" {
+			logger.Printf("[Azure CNS] Host route management is enabled, but the NCs of the NodeNetworkConfig carry no host routes " +
+				"and no SwiftV2 infra gateway is configured, so no routes will be programmed")
+		}
+		routeManager, routesErr := routes.NewManager(z.Named(loggerv2.ComponentRoutes), httpRemoteRestService.DesiredHostRoutes,
+			time.Duration(cnsconfig.HostRoutes.ReconcileIntervalSecs)*time.Second)
+		if routesErr != nil {
+			logger.Errorf("[Azure CNS] Failed to create route manager: %v", routesErr)
+			return
+		}
+		httpRemoteRestService.SetRouteManager(routeManager)
+		go routeManager.Start(rootCtx)
+	}
+
 	if cnsconfig.EnableSwiftV2 && cnsconfig.EnableK8sDevicePlugin {
 		// Create device plugin manager instance
 		pluginManager := deviceplugin.NewPluginManager(z)
@@ -964,8 +1090,15 @@ func main() {
 
 		// go routine to poll node info crd and update device counts
 		go func() {
-			if pollErr := pollNodeInfoCRDAndUpdatePlugin(ctx, z, pluginManager); pollErr != nil {
+			if pollErr := pollNodeInfoCRDAndUpdatePlugin(ctx, z, pluginManager, tracingTransport(cnsconfig)); pollErr != nil {
 				z.Error("Error in pollNodeInfoCRDAndUpdatePlugin", zap.Error(pollErr))
+				return
+			}
+			// once the initial devices are tracked, keep tracking NICs added to or removed from the VM
+			if cnsconfig.EnableNICHotPlug {
+				if hotPlugErr := runNICHotPlug(ctx, z.Named(loggerv2.ComponentNICHotPlug), crdClient, pluginManager, httpRemoteRestService); hotPlugErr != nil {
+					z.Error("Error in runNICHotPlug", zap.Error(hotPlugErr))
+				}
 			}
 		}()
 	}
@@ -1027,6 +1160,7 @@ func main() {
 
 		httpLocalRestService := restserverv2.New(httpRemoteRestService)
 		if httpLocalRestService != nil {
+			httpLocalRestService.SetMiddleware(config.Middleware, config.ConnContext)
 			go func() {
 				err = httpLocalRestService.Start(rootCtx, localServerURL)
 				if err != nil {
@@ -1069,7 +1203,17 @@ func main() {
 	}
 
 	if !disableTelemetry {
-		go metric.SendHeartBeat(rootCtx, time.Minute*time.Duration(cnsconfig.TelemetrySettings.HeartBeatIntervalInMins), homeAzMonitor, cnsconfig.ChannelMode)
+		heartbeatCtx, stopHeartbeat := context.WithCancel(rootCtx)
+		go metric.SendHeartBeat(heartbeatCtx, time.Minute*time.Duration(cnsconfig.TelemetrySettings.HeartBeatIntervalInMins), homeAzMonitor, cnsconfig.ChannelMode)
+		// restart the heartbeat when its interval is changed
+		configReloader.OnChange(func(old, updated *configuration.CNSConfig) {
+			if old.TelemetrySettings.HeartBeatIntervalInMins == updated.TelemetrySettings.HeartBeatIntervalInMins {
+				return
+			}
+			stopHeartbeat()
+			heartbeatCtx, stopHeartbeat = context.WithCancel(rootCtx)
+			go metric.SendHeartBeat(heartbeatCtx, time.Minute*time.Duration(updated.TelemetrySettings.HeartBeatIntervalInMins), homeAzMonitor, updated.ChannelMode)
+		})
 		go httpRemoteRestService.SendNCSnapShotPeriodically(rootCtx, cnsconfig.TelemetrySettings.SnapshotIntervalInMins)
 	}
 
@@ -1143,14 +1287,24 @@ func main() {
 	logger.Close()
 }
 
+// tracingTransport returns the wrapper tracing the calls CNS makes to NMAgent, wireserver and the API server when
+// tracing is configured, and nil otherwise so that the clients keep their plain transports.
+func tracingTransport(cnsconfig *configuration.CNSConfig) transport.WrapperFunc {
+	if cnsconfig.Logger.Tracing == nil {
+		return nil
+	}
+	return loggerv2.HTTPTransport
+}
+
 // Poll CRD until it's set and update PluginManager
-func pollNodeInfoCRDAndUpdatePlugin(ctx context.Context, zlog *zap.Logger, pluginManager *deviceplugin.PluginManager) error {
+func pollNodeInfoCRDAndUpdatePlugin(ctx context.Context, zlog *zap.Logger, pluginManager *deviceplugin.PluginManager, wrapTransport transport.WrapperFunc) error {
 	kubeConfig, err := ctrl.GetConfig()
 	if err != nil {
 		logger.Errorf("Failed to get kubeconfig for request controller: %v", err)
 		return errors.Wrap(err, "failed to get kubeconfig")
 	}
 	kubeConfig.UserAgent = "azure-cns-" + version
+	kubeConfig.Wrap(wrapTransport)
 
 	clientset, err := kubernetes.NewForConfig(kubeConfig)
 	if err != nil {
@@ -1228,13 +1382,58 @@ func pollNodeInfoCRDAndUpdatePlugin(ctx context.Context, zlog *zap.Logger, plugi
 	}
 }
 
-func InitializeMultiTenantController(ctx context.Context, httpRestService cns.HTTPService, cnsconfig configuration.CNSConfig) error {
+// runNICHotPlug reconciles the NICs on the host against the NodeInfo until the context is cancelled. It reads
+// through the cached client of the CRD manager, as every link event of pod churn triggers a scan.
+func runNICHotPlug(ctx context.Context, zlog *zap.Logger, cachedcli client.Client, pluginManager *deviceplugin.PluginManager, httpRestService *restserver.HTTPRestService) error {
+	if cachedcli == nil {
+		return errors.New("NIC hot-plug requires CRD channel mode")
+	}
+
+	nodeName, err := configuration.NodeName()
+	if err != nil {
+		return errors.Wrap(err, "failed to get NodeName")
+	}
+
+	nichotplug.New(zlog, cachedcli, nodeName, &netio.NetIO{}, pluginManager,
+		nichotplug.WithEvents(netlink.NewNetlink()),
+		nichotplug.WithPnpIDRefresher(httpRestService),
+	).Run(ctx)
+	return nil
+}
+
+// setNodeSubnetIPDriftPolicy sets how the IPs assigned to Pods which are removed from the NIC are remediated,
+// creating a client to evict their Pods if the policy asks for it.
+func setNodeSubnetIPDriftPolicy(settings configuration.NodeSubnetIPDriftSettings, httpRestService *restserver.HTTPRestService) error {
+	policy, err := nodesubnet.ParseDriftPolicy(settings.Policy)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse policy")
+	}
+	var evicter nodesubnet.PodEvicter
+	if policy == nodesubnet.DriftPolicyEvict {
+		kubeConfig, err := ctrl.GetConfig()
+		if err != nil {
+			return errors.Wrap(err, "failed to get kubeconfig")
+		}
+		kubeConfig.UserAgent = "azure-cns-" + version
+		cli, err := client.New(kubeConfig, client.Options{})
+		if err != nil {
+			return errors.Wrap(err, "failed to create ctrl client")
+		}
+		evicter = &nodesubnet.APIServerPodEvicter{Client: cli}
+	}
+	logger.Printf("[Azure CNS] Node subnet IP drift policy is %s", policy)
+	httpRestService.SetNodeSubnetIPDriftPolicy(policy, evicter)
+	return nil
+}
+
+func InitializeMultiTenantController(ctx context.Context, httpRestService cns.HTTPService, cnsconfig configuration.CNSConfig, configReloader *configuration.Reloader) error {
 	var multiTenantController multitenantcontroller.RequestController
 	kubeConfig, err := ctrl.GetConfig()
-	kubeConfig.UserAgent = fmt.Sprintf("azure-cns-%s", version)
 	if err != nil {
 		return err
 	}
+	kubeConfig.UserAgent = fmt.Sprintf("azure-cns-%s", version)
+	kubeConfig.Wrap(tracingTransport(&cnsconfig))
 
 	// convert interface type to implementation type
 	httpRestServiceImpl, ok := httpRestService.(*restserver.HTTPRestService)
@@ -1283,24 +1482,47 @@ func InitializeMultiTenantController(ctx context.Context, httpRestService cns.HT
 
 	// TODO: do we need this to be running?
 	logger.Printf("Starting SyncHostNCVersion")
-	go func() {
-		// Periodically poll vfp programmed NC version from NMAgent
-		tickerChannel := time.Tick(time.Duration(cnsconfig.SyncHostNCVersionIntervalMs) * time.Millisecond)
-		for {
-			select {
-			case <-tickerChannel:
-				timedCtx, cancel := context.WithTimeout(ctx, time.Duration(cnsconfig.SyncHostNCVersionIntervalMs)*time.Millisecond)
-				httpRestServiceImpl.SyncHostNCVersion(timedCtx, cnsconfig.ChannelMode)
-				cancel()
-			case <-ctx.Done():
-				return
-			}
-		}
-	}()
+	go syncHostNCVersion(ctx, httpRestServiceImpl, configReloader)
 
 	return nil
 }
 
+// hostNCVersionSync is the result of a sync of the host NC versions. Syncs are only told apart by whether they
+// fail, so the refresh.Fetcher running them keeps to the interval unless they fail.
+type hostNCVersionSync struct{}
+
+func (hostNCVersionSync) Equal(hostNCVersionSync) bool {
+	return true
+}
+
+// syncHostNCVersionMaxErrorInterval is the longest wait after failed syncs of the host NC versions.
+const syncHostNCVersionMaxErrorInterval = 30 * time.Second
+
+// syncHostNCVersion periodically polls NMAgent for the NC versions programmed in VFP until the context is closed.
+// The interval follows the effective config so that it can be changed without a restart, and backs off while the
+// syncs fail.
+func syncHostNCVersion(ctx context.Context, httpRestService *restserver.HTTPRestService, configReloader *configuration.Reloader) {
+	interval := func(cnsconfig *configuration.CNSConfig) time.Duration {
+		return time.Duration(cnsconfig.SyncHostNCVersionIntervalMs) * time.Millisecond
+	}
+	syncVersions := func(ctx context.Context) (hostNCVersionSync, error) {
+		cnsconfig := configReloader.Current()
+		timedCtx, cancel := context.WithTimeout(ctx, interval(cnsconfig))
+		defer cancel()
+		return hostNCVersionSync{}, httpRestService.SyncHostNCVersion(timedCtx, cnsconfig.ChannelMode)
+	}
+	current := interval(configReloader.Current())
+	fetcher := refresh.NewFetcher[hostNCVersionSync](syncVersions, current, current, nil, logger.Log,
+		refresh.WithErrorBackoff(syncHostNCVersionMaxErrorInterval))
+	configReloader.OnChange(func(old, updated *configuration.CNSConfig) {
+		if updated := interval(updated); updated != interval(old) {
+			fetcher.SetIntervals(updated, updated)
+		}
+	})
+	fetcher.Start(ctx)
+	<-ctx.Done()
+}
+
 type nodeNetworkConfigGetter interface {
 	Get(context.Context) (*v1alpha.NodeNetworkConfig, error)
 }
@@ -1371,15 +1593,15 @@ func reconcileInitialCNSState(ctx context.Context, cli nodeNetworkConfigGetter,
 	return nil
 }
 
-// InitializeCRDState builds and starts the CRD controllers.
+// InitializeCRDState builds and starts the CRD controllers, returning the cached client of their manager.
 //
 //nolint:gocyclo // legacy
-func InitializeCRDState(ctx context.Context, z *zap.Logger, httpRestService cns.HTTPService, cnsconfig *configuration.CNSConfig) error {
+func InitializeCRDState(ctx context.Context, z *zap.Logger, httpRestService cns.HTTPService, cnsconfig *configuration.CNSConfig, configReloader *configuration.Reloader) (client.Client, error) {
 	// convert interface type to implementation type
 	httpRestServiceImplementation, ok := httpRestService.(*restserver.HTTPRestService)
 	if !ok {
 		logger.Errorf("[Azure CNS] Failed to convert interface httpRestService to implementation: %v", httpRestService)
-		return fmt.Errorf("[Azure CNS] Failed to convert interface httpRestService to implementation: %v",
+		return nil, fmt.Errorf("[Azure CNS] Failed to convert interface httpRestService to implementation: %v",
 			httpRestService)
 	}
 
@@ -1393,24 +1615,25 @@ func InitializeCRDState(ctx context.Context, z *zap.Logger, httpRestService cns.
 	kubeConfig, err := ctrl.GetConfig()
 	if err != nil {
 		logger.Errorf("[Azure CNS] Failed to get kubeconfig for request controller: %v", err)
-		return errors.Wrap(err, "failed to get kubeconfig")
+		return nil, errors.Wrap(err, "failed to get kubeconfig")
 	}
 	kubeConfig.UserAgent = fmt.Sprintf("azure-cns-%s", version)
+	kubeConfig.Wrap(tracingTransport(cnsconfig))
 
 	clientset, err := kubernetes.NewForConfig(kubeConfig)
 	if err != nil {
-		return errors.Wrap(err, "failed to build clientset")
+		return nil, errors.Wrap(err, "failed to build clientset")
 	}
 
 	// get nodename for scoping kube requests to node.
 	nodeName, err := configuration.NodeName()
 	if err != nil {
-		return errors.Wrap(err, "failed to get NodeName")
+		return nil, errors.Wrap(err, "failed to get NodeName")
 	}
 
 	node, err := clientset.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
 	if err != nil {
-		return errors.Wrapf(err, "failed to get node %s", nodeName)
+		return nil, errors.Wrapf(err, "failed to get node %s", nodeName)
 	}
 
 	// check the Node labels for Swift V2
@@ -1418,34 +1641,34 @@ func InitializeCRDState(ctx context.Context, z *zap.Logger, httpRestService cns.
 		cnsconfig.EnableSwiftV2 = true
 		cnsconfig.WatchPods = true
 		if nodeInfoErr := createOrUpdateNodeInfoCRD(ctx, kubeConfig, node); nodeInfoErr != nil {
-			return errors.Wrap(nodeInfoErr, "error creating or updating nodeinfo crd")
+			return nil, errors.Wrap(nodeInfoErr, "error creating or updating nodeinfo crd")
 		}
 	}
 
 	// perform state migration from CNI in case CNS is set to manage the endpoint state and has emty state
 	if cnsconfig.EnableStateMigration && !httpRestServiceImplementation.EndpointStateStore.Exists() {
 		if err = PopulateCNSEndpointState(httpRestServiceImplementation.EndpointStateStore); err != nil {
-			return errors.Wrap(err, "failed to create CNS EndpointState From CNI")
+			return nil, errors.Wrap(err, "failed to create CNS EndpointState From CNI")
 		}
 		// endpoint state needs tobe loaded in memory so the subsequent Delete calls remove the state and release the IPs.
 		if err = httpRestServiceImplementation.EndpointStateStore.Read(restserver.EndpointStoreKey, &httpRestServiceImplementation.EndpointState); err != nil {
-			return errors.Wrap(err, "failed to restore endpoint state")
+			return nil, errors.Wrap(err, "failed to restore endpoint state")
 		}
 	}
 
 	podInfoByIPProvider, err := getPodInfoByIPProvider(ctx, cnsconfig, httpRestServiceImplementation, clientset, nodeName)
 	if err != nil {
-		return errors.Wrap(err, "failed to initialize ip state")
+		return nil, errors.Wrap(err, "failed to initialize ip state")
 	}
 
 	// create scoped kube clients.
 	directcli, err := client.New(kubeConfig, client.Options{Scheme: nodenetworkconfig.Scheme})
 	if err != nil {
-		return errors.Wrap(err, "failed to create ctrl client")
+		return nil, errors.Wrap(err, "failed to create ctrl client")
 	}
 	directnnccli := nodenetworkconfig.NewClient(directcli)
 	if err != nil {
-		return errors.Wrap(err, "failed to create NNC client")
+		return nil, errors.Wrap(err, "failed to create NNC client")
 	}
 	// TODO(rbtr): nodename and namespace should be in the cns config
 	directscopedcli := nncctrl.NewScopedClient(directnnccli, types.NamespacedName{Namespace: "kube-system", Name: nodeName})
@@ -1469,16 +1692,22 @@ func InitializeCRDState(ctx context.Context, z *zap.Logger, httpRestService cns.
 	hasNNCInitialized.Set(1)
 	scheme := kuberuntime.NewScheme()
 	if err := corev1.AddToScheme(scheme); err != nil { //nolint:govet // intentional shadow
-		return errors.Wrap(err, "failed to add corev1 to scheme")
+		return nil, errors.Wrap(err, "failed to add corev1 to scheme")
 	}
 	if err = v1alpha.AddToScheme(scheme); err != nil {
-		return errors.Wrap(err, "failed to add nodenetworkconfig/v1alpha to scheme")
+		return nil, errors.Wrap(err, "failed to add nodenetworkconfig/v1alpha to scheme")
 	}
 	if err = cssv1alpha1.AddToScheme(scheme); err != nil {
-		return errors.Wrap(err, "failed to add clustersubnetstate/v1alpha1 to scheme")
+		return nil, errors.Wrap(err, "failed to add clustersubnetstate/v1alpha1 to scheme")
+	}
+	if err = cssv1alpha2.AddToScheme(scheme); err != nil {
+		return nil, errors.Wrap(err, "failed to add clustersubnetstate/v1alpha2 to scheme")
 	}
 	if err = mtv1alpha1.AddToScheme(scheme); err != nil {
-		return errors.Wrap(err, "failed to add multitenantpodnetworkconfig/v1alpha1 to scheme")
+		return nil, errors.Wrap(err, "failed to add multitenantpodnetworkconfig/v1alpha1 to scheme")
+	}
+	if err = oecv1alpha1.AddToScheme(scheme); err != nil {
+		return nil, errors.Wrap(err, "failed to add overlayextensionconfig/v1alpha1 to scheme")
 	}
 
 	// Set Selector options on the Manager cache which are used
@@ -1502,8 +1731,27 @@ func InitializeCRDState(ctx context.Context, z *zap.Logger, httpRestService cns.
 		}
 	}
 
+	if cnsconfig.EnableSwiftV2 && cnsconfig.EnableNICHotPlug {
+		cacheOpts.ByObject[&mtv1alpha1.NodeInfo{}] = cache.ByObject{
+			Field: fields.SelectorFromSet(fields.Set{"metadata.name": nodeName}),
+		}
+	}
+
+	// the ClusterSubnetState CRD may still only serve v1alpha1 while the rollout of v1alpha2 completes, in which
+	// case v1alpha1 is watched and converted instead.
+	legacyCSS := false
 	if cnsconfig.EnableSubnetScarcity {
-		cacheOpts.ByObject[&cssv1alpha1.ClusterSubnetState{}] = cache.ByObject{
+		servesV1alpha2, err := clustersubnetstate.ServesV1alpha2(kubeConfig) //nolint:govet // intentional shadow
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to discover clustersubnetstate versions")
+		}
+		legacyCSS = !servesV1alpha2
+		var css client.Object = &cssv1alpha2.ClusterSubnetState{}
+		if legacyCSS {
+			logger.Printf("ClusterSubnetState v1alpha2 is not served, falling back to v1alpha1")
+			css = &cssv1alpha1.ClusterSubnetState{}
+		}
+		cacheOpts.ByObject[css] = cache.ByObject{
 			Namespaces: map[string]cache.Config{
 				"kube-system": {},
 			},
@@ -1519,7 +1767,7 @@ func InitializeCRDState(ctx context.Context, z *zap.Logger, httpRestService cns.
 
 	manager, err := ctrl.NewManager(kubeConfig, managerOpts)
 	if err != nil {
-		return errors.Wrap(err, "failed to create manager")
+		return nil, errors.Wrap(err, "failed to create manager")
 	}
 
 	// this cachedscopedclient is built using the Manager's cached client, which is
@@ -1532,15 +1780,18 @@ func InitializeCRDState(ctx context.Context, z *zap.Logger, httpRestService cns.
 
 	// Build the IPAM Pool monitor
 	var poolMonitor cns.IPAMPoolMonitor
-	cssCh := make(chan cssv1alpha1.ClusterSubnetState)
+	cssCh := make(chan cssv1alpha2.ClusterSubnetState)
 	ipDemandCh := make(chan int)
 	if cnsconfig.EnableIPAMv2 {
-		cssSrc := func(context.Context) ([]cssv1alpha1.ClusterSubnetState, error) { return nil, nil }
+		cssSrc := func(context.Context) ([]cssv1alpha2.ClusterSubnetState, error) { return nil, nil }
 		if cnsconfig.EnableSubnetScarcity {
 			cssSrc = clustersubnetstate.NewClient(manager.GetClient()).List
+			if legacyCSS {
+				cssSrc = clustersubnetstate.NewLegacyClient(manager.GetClient()).List
+			}
 		}
 		nncCh := make(chan v1alpha.NodeNetworkConfig)
-		pmv2 := ipampoolv2.NewMonitor(z, httpRestServiceImplementation, cachedscopedcli, ipDemandCh, nncCh, cssCh)
+		pmv2 := ipampoolv2.NewMonitor(z.Named(loggerv2.ComponentIPAMPool), httpRestServiceImplementation, cachedscopedcli, ipDemandCh, nncCh, cssCh)
 		obs := metrics.NewLegacyMetricsObserver(httpRestService.GetPodIPConfigState, cachedscopedcli.Get, cssSrc)
 		pmv2.WithLegacyMetricsObserver(obs)
 		poolMonitor = pmv2.AsV1(nncCh)
@@ -1555,24 +1806,37 @@ func InitializeCRDState(ctx context.Context, z *zap.Logger, httpRestService cns.
 
 	// get CNS Node IP to compare NC Node IP with this Node IP to ensure NCs were created for this node
 	nodeIP := configuration.NodeIP()
-	nncReconciler := nncctrl.NewReconciler(httpRestServiceImplementation, poolMonitor, nodeIP)
+	nncReconciler := nncctrl.NewReconciler(z.Named(loggerv2.ComponentNodeNetworkConfig), httpRestServiceImplementation, poolMonitor, nodeIP)
 	// pass Node to the Reconciler for Controller xref
 	// IPAMv1 - reconcile only status changes (where generation doesn't change).
 	// IPAMv2 - reconcile all updates.
 	filterGenerationChange := !cnsconfig.EnableIPAMv2
 	if err := nncReconciler.SetupWithManager(manager, node, filterGenerationChange); err != nil { //nolint:govet // intentional shadow
-		return errors.Wrapf(err, "failed to setup nnc reconciler with manager")
+		return nil, errors.Wrapf(err, "failed to setup nnc reconciler with manager")
 	}
 
 	if cnsconfig.EnableSubnetScarcity {
 		// ClusterSubnetState reconciler
-		cssReconciler := cssctrl.New(cssCh)
+		cssReconciler := cssctrl.New(cssCh, legacyCSS)
 		if err := cssReconciler.SetupWithManager(manager); err != nil {
-			return errors.Wrapf(err, "failed to setup css reconciler with manager")
+			return nil, errors.Wrapf(err, "failed to setup css reconciler with manager")
+		}
+	}
+
+	if cnsconfig.EnableOverlayExtensionConfig {
+		// OverlayExtensionConfig reconciler
+		dp, err := oecctrl.NewDataplane() //nolint:govet // intentional shadow
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create overlay extension dataplane")
+		}
+		oecReconciler := oecctrl.New(z.Named(loggerv2.ComponentOverlayExtensionConfig), dp, nodeName)
+		if err := oecReconciler.SetupWithManager(manager); err != nil {
+			return nil, errors.Wrapf(err, "failed to setup oec reconciler with manager")
 		}
 	}
 
 	// TODO: add pod listeners based on Swift V1 vs MT/V2 configuration
+	var ipAuditor *ipaudit.Auditor
 	if cnsconfig.WatchPods {
 		pw := podctrl.New(z)
 		if cnsconfig.EnableIPAMv2 {
@@ -1582,17 +1846,70 @@ func InitializeCRDState(ctx context.Context, z *zap.Logger, httpRestService cns.
 			pw.With(pw.NewNotifierFunc(hostNetworkListOpt, limit, ipampoolv2.PodIPDemandListener(ipDemandCh)))
 		}
 		if err := pw.SetupWithManager(ctx, manager); err != nil {
-			return errors.Wrapf(err, "failed to setup pod watcher with manager")
+			return nil, errors.Wrapf(err, "failed to setup pod watcher with manager")
+		}
+		if cnsconfig.IPAudit.Enable {
+			hostNetworkListOpt := &client.ListOptions{FieldSelector: fields.SelectorFromSet(fields.Set{"spec.hostNetwork": "false"})}
+			listPods := func(ctx context.Context) ([]corev1.Pod, error) {
+				return pw.List(ctx, hostNetworkListOpt)
+			}
+			ipAuditor = ipaudit.New(z.Named(loggerv2.ComponentIPAudit), httpRestServiceImplementation, listPods,
+				ipAuditEndpointSource(cnsconfig, httpRestServiceImplementation), ipaudit.Config{
+					Interval:         time.Duration(cnsconfig.IPAudit.IntervalSecs) * time.Second,
+					GracePeriod:      time.Duration(cnsconfig.IPAudit.LeakedIPGracePeriodSecs) * time.Second,
+					ReleaseLeakedIPs: cnsconfig.IPAudit.ReleaseLeakedIPs,
+				})
+			httpRestServiceImplementation.SetIPAuditor(ipAuditor)
 		}
 	}
 
 	if cnsconfig.EnableSwiftV2 {
 		if err := mtpncctrl.SetupWithManager(manager); err != nil {
-			return errors.Wrapf(err, "failed to setup mtpnc reconciler with manager")
+			return nil, errors.Wrapf(err, "failed to setup mtpnc reconciler with manager")
+		}
+		if cnsconfig.EnableNICHotPlug {
+			// the NIC hot-plug reconciler lists the pods of the Node from the cache
+			if err := manager.GetFieldIndexer().IndexField(ctx, &corev1.Pod{}, "spec.nodeName", func(o client.Object) []string {
+				return []string{o.(*corev1.Pod).Spec.NodeName}
+			}); err != nil {
+				return nil, errors.Wrap(err, "failed to index pods by node name")
+			}
 		}
 		// if SWIFT v2 is enabled on CNS, attach multitenant middleware to rest service
 		// switch here for AKS(K8s) swiftv2 middleware to process IP configs requests
-		swiftV2Middleware := &middlewares.K8sSWIFTv2Middleware{Cli: manager.GetClient()}
+		swiftV2Middleware := &middlewares.K8sSWIFTv2Middleware{
+			Cli:            manager.GetClient(),
+			InfraGatewayV4: cnsconfig.HostRoutes.SwiftV2InfraGatewayV4,
+			InfraGatewayV6: cnsconfig.HostRoutes.SwiftV2InfraGatewayV6,
+		}
+		if cnsconfig.EnablePNIReservations {
+			// limit the pods of each PodNetworkInstance to the pod slots it reserves, counting its pods on all Nodes
+			// through a cache of their own, as the cache of the manager only holds the pods of this Node
+			pniSelector, err := labels.Parse(configuration.LabelPodNetworkInstanceSwiftV2)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to build pni pod selector")
+			}
+			pniPods, err := cache.New(kubeConfig, cache.Options{
+				HTTPClient: manager.GetHTTPClient(),
+				Scheme:     scheme,
+				Mapper:     manager.GetRESTMapper(),
+				ByObject: map[client.Object]cache.ByObject{
+					&corev1.Pod{}: {Label: pniSelector},
+				},
+			})
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to create pni pod cache")
+			}
+			if err := pniPods.IndexField(ctx, &corev1.Pod{}, middlewares.PodNetworkInstanceIndex, middlewares.PodNetworkInstanceIndexer); err != nil {
+				return nil, errors.Wrap(err, "failed to index pods by pni")
+			}
+			if err := manager.Add(pniPods); err != nil {
+				return nil, errors.Wrap(err, "failed to add pni pod cache to manager")
+			}
+			swiftV2Middleware.Reservations = middlewares.NewPNIReservations()
+			swiftV2Middleware.PodReader = pniPods
+			httpRestServiceImplementation.SetPNIReservations(swiftV2Middleware.Reservations)
+		}
 		httpRestService.AttachIPConfigsHandlerMiddleware(swiftV2Middleware)
 	}
 
@@ -1641,24 +1958,19 @@ func InitializeCRDState(ctx context.Context, z *zap.Logger, httpRestService cns.
 		break
 	}
 
+	// the Pods are only audited once the cache of the manager is synced, as the IPs of Pods which are not listed
+	// are leaked.
+	if ipAuditor != nil {
+		go ipAuditor.Start(ctx)
+	}
+
 	go func() {
 		logger.Printf("Starting SyncHostNCVersion loop.")
-		// Periodically poll vfp programmed NC version from NMAgent
-		tickerChannel := time.Tick(time.Duration(cnsconfig.SyncHostNCVersionIntervalMs) * time.Millisecond)
-		for {
-			select {
-			case <-tickerChannel:
-				timedCtx, cancel := context.WithTimeout(ctx, time.Duration(cnsconfig.SyncHostNCVersionIntervalMs)*time.Millisecond)
-				httpRestServiceImplementation.SyncHostNCVersion(timedCtx, cnsconfig.ChannelMode)
-				cancel()
-			case <-ctx.Done():
-				logger.Printf("Stopping SyncHostNCVersion loop.")
-				return
-			}
-		}
+		syncHostNCVersion(ctx, httpRestServiceImplementation, configReloader)
+		logger.Printf("Stopping SyncHostNCVersion loop.")
 	}()
 	logger.Printf("Initialized SyncHostNCVersion loop.")
-	return nil
+	return manager.GetClient(), nil
 }
 
 // getPodInfoByIPProvider returns a PodInfoByIPProvider that reads endpoint state from the configured source
@@ -1690,6 +2002,21 @@ func getPodInfoByIPProvider(
 	return podInfoByIPProvider, nil
 }
 
+// ipAuditEndpointSource returns the source of the endpoints of the IP auditor, which reads the endpoint state of CNS
+// if it manages it and of CNI otherwise.
+func ipAuditEndpointSource(cnsconfig *configuration.CNSConfig, httpRestServiceImplementation *restserver.HTTPRestService) ipaudit.EndpointSource {
+	return func() (map[string][]cns.PodInfo, error) {
+		// the raw state is read rather than through a PodInfoByIPProvider, which fails on the IPs held by more
+		// than one endpoint that the auditor reports
+		if cnsconfig.ManageEndpointState {
+			endpoints, err := cnspodprovider.EndpointsByIP(httpRestServiceImplementation.EndpointStateStore)
+			return endpoints, errors.Wrap(err, "failed to get endpoints by IP")
+		}
+		endpoints, err := cnipodprovider.EndpointsByIP()
+		return endpoints, errors.Wrap(err, "failed to get endpoints by IP")
+	}
+}
+
 // createOrUpdateNodeInfoCRD polls imds to learn the VM Unique ID and then creates or updates the NodeInfo CRD
 // with that vm unique ID
 func createOrUpdateNodeInfoCRD(ctx context.Context, restConfig *rest.Config, node *corev1.Node) error {
@@ -8,8 +8,10 @@ import (
 	"testing"
 
 	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/configuration"
 	"github.com/Azure/azure-container-networking/cns/fakes"
 	"github.com/Azure/azure-container-networking/cns/logger"
+	loggerv2 "github.com/Azure/azure-container-networking/cns/logger/v2"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -69,3 +71,14 @@ func TestSendRegisterNodeRequest_StatusAccepted(t *testing.T) {
 
 	assert.Error(t, sendRegisterNodeRequest(ctx, mockClient, httpServiceFake, nodeRegisterReq, url))
 }
+
+func TestTracingTransport(t *testing.T) {
+	cnsconfig := &configuration.CNSConfig{}
+	assert.Nil(t, tracingTransport(cnsconfig), "clients are only wrapped when tracing is configured")
+
+	cnsconfig.Logger.Tracing = &loggerv2.TracingConfig{}
+	wrap := tracingTransport(cnsconfig)
+	if assert.NotNil(t, wrap) {
+		assert.NotSame(t, http.DefaultTransport, wrap(http.DefaultTransport))
+	}
+}
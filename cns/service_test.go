@@ -157,7 +157,7 @@ func TestNewService(t *testing.T) {
 		err = svc.StartListener(config)
 		require.NoError(t, err)
 
-		mTLSConfig, err := getTLSConfigFromFile(config.TLSSettings)
+		mTLSConfig, err := getTLSConfigFromFile(context.Background(), config.TLSSettings)
 		require.NoError(t, err)
 
 		client := &http.Client{
@@ -27,23 +27,40 @@ func podInfoProvider(exec kexec.Interface) (cns.PodInfoByIPProvider, error) {
 	}), nil
 }
 
+// EndpointsByIP execs out to the CNI and returns the PodInfo of every endpoint holding each IP, so that an IP
+// held by more than one endpoint can be reported rather than failing as PodInfoByIP does.
+func EndpointsByIP() (map[string][]cns.PodInfo, error) {
+	state, err := client.New(kexec.New()).GetEndpointState()
+	if err != nil {
+		return nil, fmt.Errorf("failed to invoke CNI client.GetEndpointState(): %w", err)
+	}
+	return cniStateToEndpointsByIP(state), nil
+}
+
 // cniStateToPodInfoByIP converts an AzureCNIState dumped from a CNI exec
 // into a PodInfo map, using the endpoint IPs as keys in the map.
 // for pods with multiple IPs (such as in dualstack cases), this means multiple keys in the map
 // will point to the same pod information.
 func cniStateToPodInfoByIP(state *api.AzureCNIState) (map[string]cns.PodInfo, error) {
 	podInfoByIP := map[string]cns.PodInfo{}
+	for ipKey, podInfos := range cniStateToEndpointsByIP(state) {
+		if len(podInfos) > 1 {
+			return nil, errors.Wrapf(cns.ErrDuplicateIP, "duplicate ip %s found for different pods: pod: %+v, pod: %+v", ipKey, podInfos[1], podInfos[0])
+		}
+		podInfoByIP[ipKey] = podInfos[0]
+	}
+	return podInfoByIP, nil
+}
+
+// cniStateToEndpointsByIP converts an AzureCNIState into the PodInfos of the endpoints holding each IP.
+func cniStateToEndpointsByIP(state *api.AzureCNIState) map[string][]cns.PodInfo {
+	endpointsByIP := map[string][]cns.PodInfo{}
 	for _, endpoint := range state.ContainerInterfaces {
 		for _, epIP := range endpoint.IPAddresses {
 			podInfo := cns.NewPodInfo(endpoint.ContainerID, endpoint.PodEndpointId, endpoint.PodName, endpoint.PodNamespace)
-
 			ipKey := epIP.IP.String()
-			if prevPodInfo, ok := podInfoByIP[ipKey]; ok {
-				return nil, errors.Wrapf(cns.ErrDuplicateIP, "duplicate ip %s found for different pods: pod: %+v, pod: %+v", ipKey, podInfo, prevPodInfo)
-			}
-
-			podInfoByIP[ipKey] = podInfo
+			endpointsByIP[ipKey] = append(endpointsByIP[ipKey], podInfo)
 		}
 	}
-	return podInfoByIP, nil
+	return endpointsByIP
 }
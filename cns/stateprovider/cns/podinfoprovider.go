@@ -39,33 +39,40 @@ func podInfoProvider(endpointStore store.KeyValueStore) (cns.PodInfoByIPProvider
 
 func endpointStateToPodInfoByIP(state map[string]*restserver.EndpointInfo) (map[string]cns.PodInfo, error) {
 	podInfoByIP := map[string]cns.PodInfo{}
+	for ip, podInfos := range endpointStateToEndpointsByIP(state) {
+		if len(podInfos) > 1 {
+			return nil, errors.Wrap(cns.ErrDuplicateIP, ip)
+		}
+		podInfoByIP[ip] = podInfos[0]
+	}
+	return podInfoByIP, nil
+}
+
+// EndpointsByIP reads the CNS endpoint store and returns the PodInfo of every endpoint holding each IP, so that an
+// IP held by more than one endpoint can be reported rather than failing as the PodInfoByIPProvider does.
+func EndpointsByIP(endpointStore store.KeyValueStore) (map[string][]cns.PodInfo, error) {
+	var state map[string]*restserver.EndpointInfo
+	if err := endpointStore.Read(restserver.EndpointStoreKey, &state); err != nil {
+		if errors.Is(err, store.ErrKeyNotFound) || errors.Is(err, store.ErrStoreEmpty) {
+			return map[string][]cns.PodInfo{}, nil
+		}
+		return nil, fmt.Errorf("failed to read endpoints state from store : %w", err)
+	}
+	return endpointStateToEndpointsByIP(state), nil
+}
+
+// endpointStateToEndpointsByIP returns the PodInfos of the endpoints holding each IP of the endpoint state.
+func endpointStateToEndpointsByIP(state map[string]*restserver.EndpointInfo) map[string][]cns.PodInfo {
+	endpointsByIP := map[string][]cns.PodInfo{}
 	for containerID, endpointInfo := range state { // for each endpoint
+		podInfo := cns.NewPodInfo(containerID, containerID, endpointInfo.PodName, endpointInfo.PodNamespace)
 		for _, ipinfo := range endpointInfo.IfnameToIPMap { // for each IP info object of the endpoint's interfaces
-			for _, ipv4conf := range ipinfo.IPv4 { // for each IPv4 config of the endpoint's interfaces
-				if _, ok := podInfoByIP[ipv4conf.IP.String()]; ok {
-					return nil, errors.Wrap(cns.ErrDuplicateIP, ipv4conf.IP.String())
-				}
-				podInfoByIP[ipv4conf.IP.String()] = cns.NewPodInfo(
-					containerID,
-					containerID,
-					endpointInfo.PodName,
-					endpointInfo.PodNamespace,
-				)
-			}
-			for _, ipv6conf := range ipinfo.IPv6 { // for each IPv6 config of the endpoint's interfaces
-				if _, ok := podInfoByIP[ipv6conf.IP.String()]; ok {
-					return nil, errors.Wrap(cns.ErrDuplicateIP, ipv6conf.IP.String())
-				}
-				podInfoByIP[ipv6conf.IP.String()] = cns.NewPodInfo(
-					containerID,
-					containerID,
-					endpointInfo.PodName,
-					endpointInfo.PodNamespace,
-				)
+			for _, ipconf := range append(append([]net.IPNet{}, ipinfo.IPv4...), ipinfo.IPv6...) { // for each IP config of the endpoint's interfaces
+				endpointsByIP[ipconf.IP.String()] = append(endpointsByIP[ipconf.IP.String()], podInfo)
 			}
 		}
 	}
-	return podInfoByIP, nil
+	return endpointsByIP
 }
 
 // MigrateCNISate returns an endpoint state of CNS by reading the CNI state file
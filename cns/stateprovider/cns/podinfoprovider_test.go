@@ -56,3 +56,26 @@ func TestNewCNSPodInfoProvider(t *testing.T) {
 		})
 	}
 }
+
+func TestEndpointsByIP(t *testing.T) {
+	endpointStore := store.NewMockStore("")
+	ip := []net.IPNet{{IP: net.IPv4(10, 241, 0, 65), Mask: net.IPv4Mask(255, 255, 255, 0)}}
+	endpointState := map[string]*restserver.EndpointInfo{
+		"a": {PodName: "pod-a", PodNamespace: "default", IfnameToIPMap: map[string]*restserver.IPInfo{"eth0": {IPv4: ip}}},
+		"b": {PodName: "pod-b", PodNamespace: "default", IfnameToIPMap: map[string]*restserver.IPInfo{"eth0": {IPv4: ip}}},
+	}
+
+	got, err := EndpointsByIP(endpointStore)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+
+	require.NoError(t, endpointStore.Write(restserver.EndpointStoreKey, endpointState))
+	_, err = endpointStateToPodInfoByIP(endpointState)
+	require.ErrorIs(t, err, cns.ErrDuplicateIP)
+	got, err = EndpointsByIP(endpointStore)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []cns.PodInfo{
+		cns.NewPodInfo("a", "a", "pod-a", "default"),
+		cns.NewPodInfo("b", "b", "pod-b", "default"),
+	}, got["10.241.0.65"])
+}
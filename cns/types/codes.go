@@ -45,6 +45,7 @@ const (
 	UnsupportedAPI                         ResponseCode = 43
 	FailedToAllocateBackendConfig          ResponseCode = 44
 	ConnectionError                        ResponseCode = 45
+	PodNetworkInstanceReservationExhausted ResponseCode = 46
 	UnexpectedError                        ResponseCode = 99
 	NmAgentNCVersionListError              ResponseCode = 100
 )
@@ -128,6 +129,8 @@ func (c ResponseCode) String() string {
 		return "StatusUnauthorized"
 	case FailedToAllocateBackendConfig:
 		return "FailedToAllocateBackendConfig"
+	case PodNetworkInstanceReservationExhausted:
+		return "PodNetworkInstanceReservationExhausted"
 	default:
 		return "UnknownError"
 	}
@@ -13,4 +13,6 @@ const (
 	PendingRelease IPState = "PendingRelease"
 	// PendingProgramming IPConfigState for allocated IPs pending programming.
 	PendingProgramming IPState = "PendingProgramming"
+	// Quarantined IPConfigState for allocated IPs found in use by another host, which are not assigned until the quarantine ends.
+	Quarantined IPState = "Quarantined"
 )
@@ -49,6 +49,12 @@ const (
 	OptIpamQueryInterval      = "ipam-query-interval"
 	OptIpamQueryIntervalAlias = "i"
 
+	// IPAM probing for address conflicts before handing out addresses.
+	OptIpamConflictProbe = "ipam-conflict-probe"
+
+	// IPAM limit of the node IPv6 subnet to a smaller subnet at its start, "/120" by default.
+	OptIpamSubnetMaskSizeLimit = "ipam-subnet-mask-size-limit"
+
 	// Interval to send reports to host
 	OptReportToHostInterval      = "report-interval"
 	OptReportToHostIntervalAlias = "hostinterval"
@@ -4,6 +4,7 @@
 package common
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"net"
@@ -24,7 +25,11 @@ type Listener struct {
 	active       bool
 	listener     net.Listener
 	tlsListener  net.Listener
+	unixListener net.Listener
+	unixPath     string
 	mux          *http.ServeMux
+	middleware   func(http.Handler) http.Handler
+	connContext  func(context.Context, net.Conn) context.Context
 }
 
 // NewListener creates a new Listener.
@@ -43,8 +48,9 @@ func NewListener(u *url.URL) (*Listener, error) {
 // StartTLS creates the listener socket and starts the HTTPS server.
 func (l *Listener) StartTLS(errChan chan<- error, tlsConfig *tls.Config, address string) error {
 	server := http.Server{
-		TLSConfig: tlsConfig,
-		Handler:   l.mux,
+		TLSConfig:   tlsConfig,
+		Handler:     l.handler(),
+		ConnContext: l.connContext,
 	}
 
 	// listen on a separate endpoint for secure tls connections
@@ -78,14 +84,47 @@ func (l *Listener) Start(errChan chan<- error) error {
 	log.Printf("[Listener] Started listening on %s.", l.localAddress)
 
 	// Launch goroutine for servicing requests.
+	server := http.Server{
+		Handler:     l.handler(),
+		ConnContext: l.connContext,
+	}
 	go func() {
-		errChan <- http.Serve(l.listener, l.mux)
+		errChan <- server.Serve(l.listener)
 	}()
 
 	l.active = true
 	return nil
 }
 
+// StartUnix creates a unix domain socket at path and serves the same handlers on it.
+// It is stopped together with the listener.
+func (l *Listener) StartUnix(errChan chan<- error, path string) error {
+	// remove a socket left behind by a previous run
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "failed to remove stale unix socket")
+	}
+
+	list, err := net.Listen("unix", path)
+	if err != nil {
+		log.Printf("[Listener] Failed to listen on unix socket: %+v", err)
+		return err
+	}
+
+	l.unixListener = list
+	l.unixPath = path
+	log.Printf("[Listener] Started listening on unix socket %s.", path)
+
+	server := http.Server{
+		Handler:     l.handler(),
+		ConnContext: l.connContext,
+	}
+	go func() {
+		errChan <- server.Serve(l.unixListener)
+	}()
+
+	return nil
+}
+
 // Stop stops listening for requests.
 func (l *Listener) Stop() {
 	// Ignore if not active.
@@ -103,6 +142,12 @@ func (l *Listener) Stop() {
 		log.Printf("[Listener] Stopped listening on tls endpoint %s", l.tlsListener.Addr())
 	}
 
+	if l.unixListener != nil {
+		_ = l.unixListener.Close()
+		_ = os.Remove(l.unixPath)
+		log.Printf("[Listener] Stopped listening on unix socket %s", l.unixPath)
+	}
+
 	// Delete the unix socket.
 	if l.protocol == "unix" {
 		_ = os.Remove(l.localAddress)
@@ -116,6 +161,20 @@ func (l *Listener) GetMux() *http.ServeMux {
 	return l.mux
 }
 
+// SetMiddleware wraps every request served by the listener in middleware, and sets the function used to
+// derive the context of each accepted connection. Both must be set before the listener is started.
+func (l *Listener) SetMiddleware(middleware func(http.Handler) http.Handler, connContext func(context.Context, net.Conn) context.Context) {
+	l.middleware = middleware
+	l.connContext = connContext
+}
+
+func (l *Listener) handler() http.Handler {
+	if l.middleware == nil {
+		return l.mux
+	}
+	return l.middleware(l.mux)
+}
+
 // GetEndpoints returns the list of registered protocol endpoints.
 func (l *Listener) GetEndpoints() []string {
 	return l.endpoints
@@ -0,0 +1,28 @@
+package v1alpha1
+
+import (
+	"github.com/Azure/azure-container-networking/crd/clustersubnetstate/api/v1alpha2"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+// ConvertTo converts the ClusterSubnetState to the v1alpha2 hub version, which does not know the subnet usage.
+func (in *ClusterSubnetState) ConvertTo(hub conversion.Hub) error {
+	out := hub.(*v1alpha2.ClusterSubnetState) //nolint:errcheck // the hub is always v1alpha2
+	out.ObjectMeta = in.ObjectMeta
+	out.Status = v1alpha2.ClusterSubnetStateStatus{
+		Exhausted: in.Status.Exhausted,
+		Timestamp: in.Status.Timestamp,
+	}
+	return nil
+}
+
+// ConvertFrom converts the v1alpha2 hub version to a ClusterSubnetState, dropping the subnet usage.
+func (in *ClusterSubnetState) ConvertFrom(hub conversion.Hub) error {
+	src := hub.(*v1alpha2.ClusterSubnetState) //nolint:errcheck // the hub is always v1alpha2
+	in.ObjectMeta = src.ObjectMeta
+	in.Status = ClusterSubnetStateStatus{
+		Exhausted: src.Status.Exhausted,
+		Timestamp: src.Status.Timestamp,
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-container-networking/crd/clustersubnetstate/api/v1alpha2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestConversion(t *testing.T) {
+	hub := &v1alpha2.ClusterSubnetState{
+		ObjectMeta: metav1.ObjectMeta{Name: "subnet", Namespace: "kube-system"},
+		Status: v1alpha2.ClusterSubnetStateStatus{
+			Exhausted:             true,
+			Timestamp:             "2024-01-01T00:00:00Z",
+			Total:                 256,
+			Allocated:             240,
+			Reserved:              5,
+			AllocationRatePerHour: 10,
+		},
+	}
+
+	css := &ClusterSubnetState{}
+	require.NoError(t, css.ConvertFrom(hub))
+	assert.Equal(t, hub.ObjectMeta, css.ObjectMeta)
+	assert.Equal(t, ClusterSubnetStateStatus{Exhausted: true, Timestamp: "2024-01-01T00:00:00Z"}, css.Status)
+
+	converted := &v1alpha2.ClusterSubnetState{}
+	require.NoError(t, css.ConvertTo(converted))
+	assert.Equal(t, hub.ObjectMeta, converted.ObjectMeta)
+	assert.Equal(t, v1alpha2.ClusterSubnetStateStatus{Exhausted: true, Timestamp: "2024-01-01T00:00:00Z"}, converted.Status)
+}
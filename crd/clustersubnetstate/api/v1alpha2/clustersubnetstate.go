@@ -0,0 +1,84 @@
+//go:build !ignore_uncovered
+// +build !ignore_uncovered
+
+package v1alpha2
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Important: Run "make" to regenerate code after modifying this file
+
+// +kubebuilder:object:root=true
+
+// ClusterSubnetState is the Schema for the ClusterSubnetState API
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Namespaced
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Exhausted",type=string,JSONPath=`.status.exhausted`
+// +kubebuilder:printcolumn:name="Total",type=integer,JSONPath=`.status.total`
+// +kubebuilder:printcolumn:name="Allocated",type=integer,JSONPath=`.status.allocated`
+// +kubebuilder:printcolumn:name="Reserved",type=integer,JSONPath=`.status.reserved`
+// +kubebuilder:printcolumn:name="Updated",type=string,JSONPath=`.status.timestamp`
+type ClusterSubnetState struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Status ClusterSubnetStateStatus `json:"status,omitempty"`
+}
+
+// ClusterSubnetStateStatus defines the observed state of ClusterSubnetState
+type ClusterSubnetStateStatus struct {
+	Exhausted bool   `json:"exhausted"`
+	Timestamp string `json:"timestamp"`
+	// Total is the number of IPs in the subnet, zero if it is not known.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Total int64 `json:"total,omitempty"`
+	// Allocated is the number of IPs in the subnet allocated to Nodes.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Allocated int64 `json:"allocated,omitempty"`
+	// Reserved is the number of IPs in the subnet which can not be allocated, such as those reserved by Azure.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Reserved int64 `json:"reserved,omitempty"`
+	// AllocationRatePerHour is the recent change of Allocated per hour, negative while IPs are being released.
+	// +optional
+	AllocationRatePerHour int64 `json:"allocationRatePerHour,omitempty"`
+}
+
+// Utilization returns the fraction of the IPs in the subnet which are allocated or reserved, or zero if the
+// size of the subnet is not known.
+func (s *ClusterSubnetStateStatus) Utilization() float64 {
+	if s.Total <= 0 {
+		return 0
+	}
+	return min(float64(s.Allocated+s.Reserved)/float64(s.Total), 1)
+}
+
+// ForecastUtilization returns the Utilization expected after d if IPs keep being allocated at the
+// AllocationRatePerHour. A falling rate does not lower the forecast below the current Utilization.
+func (s *ClusterSubnetStateStatus) ForecastUtilization(d time.Duration) float64 {
+	utilization := s.Utilization()
+	if s.Total <= 0 || s.AllocationRatePerHour <= 0 {
+		return utilization
+	}
+	return min(utilization+float64(s.AllocationRatePerHour)*d.Hours()/float64(s.Total), 1)
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterSubnetStateList contains a list of ClusterSubnetState
+type ClusterSubnetStateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterSubnetState `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterSubnetState{}, &ClusterSubnetStateList{})
+}
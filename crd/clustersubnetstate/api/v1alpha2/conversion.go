@@ -0,0 +1,4 @@
+package v1alpha2
+
+// Hub marks v1alpha2 as the version the other versions of ClusterSubnetState are converted through.
+func (*ClusterSubnetState) Hub() {}
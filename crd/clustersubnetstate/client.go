@@ -6,6 +6,7 @@ import (
 
 	"github.com/Azure/azure-container-networking/crd"
 	"github.com/Azure/azure-container-networking/crd/clustersubnetstate/api/v1alpha1"
+	"github.com/Azure/azure-container-networking/crd/clustersubnetstate/api/v1alpha2"
 	"github.com/pkg/errors"
 	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	typedv1 "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/typed/apiextensions/v1"
@@ -13,6 +14,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -24,6 +26,7 @@ var Scheme = runtime.NewScheme()
 func init() {
 	_ = scheme.AddToScheme(Scheme)
 	_ = v1alpha1.AddToScheme(Scheme)
+	_ = v1alpha2.AddToScheme(Scheme)
 }
 
 // Installer provides methods to manage the lifecycle of the ClusterSubnetState resource definition.
@@ -85,9 +88,32 @@ func (i *Installer) InstallOrUpdate(ctx context.Context) (*v1.CustomResourceDefi
 	return current, nil
 }
 
+// ServesV1alpha2 returns whether the cluster serves the v1alpha2 version of the ClusterSubnetState. The CRD may not
+// be updated to v1alpha2 yet while CNS rolls out, and the v1alpha1 version has to be read until it is.
+func ServesV1alpha2(c *rest.Config) (bool, error) {
+	cli, err := discovery.NewDiscoveryClientForConfig(c)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to create discovery client")
+	}
+	resources, err := cli.ServerResourcesForGroupVersion(v1alpha2.GroupVersion.String())
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "failed to discover %s", v1alpha2.GroupVersion.String())
+	}
+	for i := range resources.APIResources {
+		if resources.APIResources[i].Name == "clustersubnetstates" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // Client provides methods to interact with instances of the ClusterSubnetState custom resource.
 type Client struct {
-	cli client.Client
+	cli    client.Client
+	legacy bool
 }
 
 // NewClient creates a new ClusterSubnetState client from the passed ctrlcli.Client.
@@ -97,15 +123,45 @@ func NewClient(cli client.Client) *Client {
 	}
 }
 
+// NewLegacyClient creates a new ClusterSubnetState client from the passed ctrlcli.Client which reads the v1alpha1
+// version and converts it to v1alpha2, for clusters which do not serve v1alpha2 yet.
+func NewLegacyClient(cli client.Client) *Client {
+	return &Client{
+		cli:    cli,
+		legacy: true,
+	}
+}
+
 // Get returns the ClusterSubnetState identified by the NamespacedName.
-func (c *Client) Get(ctx context.Context, key types.NamespacedName) (*v1alpha1.ClusterSubnetState, error) {
-	clusterSubnetState := &v1alpha1.ClusterSubnetState{}
+func (c *Client) Get(ctx context.Context, key types.NamespacedName) (*v1alpha2.ClusterSubnetState, error) {
+	clusterSubnetState := &v1alpha2.ClusterSubnetState{}
+	if c.legacy {
+		legacy := &v1alpha1.ClusterSubnetState{}
+		if err := c.cli.Get(ctx, key, legacy); err != nil {
+			return clusterSubnetState, errors.Wrapf(err, "failed to get css %v", key)
+		}
+		err := legacy.ConvertTo(clusterSubnetState)
+		return clusterSubnetState, errors.Wrapf(err, "failed to convert css %v", key)
+	}
 	err := c.cli.Get(ctx, key, clusterSubnetState)
 	return clusterSubnetState, errors.Wrapf(err, "failed to get css %v", key)
 }
 
-func (c *Client) List(ctx context.Context) ([]v1alpha1.ClusterSubnetState, error) {
-	clusterSubnetStateList := &v1alpha1.ClusterSubnetStateList{}
+func (c *Client) List(ctx context.Context) ([]v1alpha2.ClusterSubnetState, error) {
+	if c.legacy {
+		legacyList := &v1alpha1.ClusterSubnetStateList{}
+		if err := c.cli.List(ctx, legacyList, client.InNamespace("kube-system")); err != nil {
+			return nil, errors.Wrap(err, "failed to list css")
+		}
+		items := make([]v1alpha2.ClusterSubnetState, len(legacyList.Items))
+		for i := range legacyList.Items {
+			if err := legacyList.Items[i].ConvertTo(&items[i]); err != nil {
+				return nil, errors.Wrapf(err, "failed to convert css %s", legacyList.Items[i].Name)
+			}
+		}
+		return items, nil
+	}
+	clusterSubnetStateList := &v1alpha2.ClusterSubnetStateList{}
 	err := c.cli.List(ctx, clusterSubnetStateList, client.InNamespace("kube-system"))
 	return clusterSubnetStateList.Items, errors.Wrap(err, "failed to list css")
 }
@@ -0,0 +1,35 @@
+package clustersubnetstate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/crd/clustersubnetstate/api/v1alpha1"
+	"github.com/Azure/azure-container-networking/crd/clustersubnetstate/api/v1alpha2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestLegacyClient(t *testing.T) {
+	css := &v1alpha1.ClusterSubnetState{
+		ObjectMeta: metav1.ObjectMeta{Name: "subnet", Namespace: "kube-system"},
+		Status:     v1alpha1.ClusterSubnetStateStatus{Exhausted: true, Timestamp: "2024-01-01T00:00:00Z"},
+	}
+	cli := NewLegacyClient(fake.NewClientBuilder().WithScheme(Scheme).WithObjects(css).Build())
+
+	got, err := cli.Get(context.Background(), types.NamespacedName{Namespace: "kube-system", Name: "subnet"})
+	require.NoError(t, err)
+	assert.Equal(t, "subnet", got.Name)
+	assert.Equal(t, v1alpha2.ClusterSubnetStateStatus{Exhausted: true, Timestamp: "2024-01-01T00:00:00Z"}, got.Status)
+
+	list, err := cli.List(context.Background())
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, got.Status, list[0].Status)
+
+	_, err = cli.Get(context.Background(), types.NamespacedName{Namespace: "kube-system", Name: "missing"})
+	require.Error(t, err)
+}
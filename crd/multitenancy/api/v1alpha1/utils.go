@@ -9,3 +9,19 @@ func (m *MultitenantPodNetworkConfig) IsReady() bool {
 	// Check if InterfaceInfos slice is not empty
 	return !reflect.DeepEqual(m.Status, MultitenantPodNetworkConfigStatus{})
 }
+
+// ReservedPodSlots returns how many pods the IPs reserved for the PodNetworkInstance can serve. Each pod takes an
+// IP from every PodNetwork, so it is the smallest reservation of the PodNetworks which reserve IPs, or the
+// deprecated PodIPReservationSize if there are no PodNetworkConfigs. Zero means that no IPs are reserved.
+func (p *PodNetworkInstance) ReservedPodSlots() int {
+	if len(p.Spec.PodNetworkConfigs) == 0 {
+		return p.Spec.PodIPReservationSize
+	}
+	slots := 0
+	for i := range p.Spec.PodNetworkConfigs {
+		if size := p.Spec.PodNetworkConfigs[i].PodIPReservationSize; size > 0 && (slots == 0 || size < slots) {
+			slots = size
+		}
+	}
+	return slots
+}
@@ -83,6 +83,37 @@ type NodeNetworkConfigStatus struct {
 	Scaler            Scaler             `json:"scaler,omitempty"`
 	Status            Status             `json:"status,omitempty"`
 	NetworkContainers []NetworkContainer `json:"networkContainers,omitempty"`
+	// NetworkContainerStatuses are the results of programming the NetworkContainers on the Node, written by CNS.
+	// +listType=map
+	// +listMapKey=id
+	NetworkContainerStatuses []NetworkContainerStatus `json:"networkContainerStatuses,omitempty"`
+	// Conditions are written by CNS.
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// Condition types of the NodeNetworkConfig.
+const (
+	// NetworkContainersProgrammed is True when CNS has programmed every NetworkContainer on the Node.
+	NetworkContainersProgrammed = "NetworkContainersProgrammed"
+	// IPPoolExhausted is True when the IP pool has been allocated up to the MaxIPCount and can not grow.
+	IPPoolExhausted = "IPPoolExhausted"
+)
+
+// NetworkContainerStatus is the result of the last attempt by CNS to program a NetworkContainer.
+type NetworkContainerStatus struct {
+	ID string `json:"id"`
+	// AppliedVersion is the Version of the NetworkContainer last programmed successfully.
+	// +kubebuilder:validation:Optional
+	AppliedVersion int64 `json:"appliedVersion"`
+	// ResponseCode is the CNS response code of the last attempt, 0 if it succeeded.
+	// +kubebuilder:validation:Optional
+	ResponseCode int `json:"responseCode"`
+	// Reason is the name of the ResponseCode.
+	Reason          string      `json:"reason,omitempty"`
+	Message         string      `json:"message,omitempty"`
+	LastAttemptTime metav1.Time `json:"lastAttemptTime,omitempty"`
 }
 
 // Scaler groups IP request params together
@@ -115,6 +115,15 @@ func (c *Client) PatchSpec(ctx context.Context, key types.NamespacedName, spec *
 	return obj, nil
 }
 
+// PatchStatus patches the Status of the NodeNetworkConfig with a merge patch from original to modified, so that
+// only the fields changed in modified are written and the rest of the Status is left to its other writers.
+func (c *Client) PatchStatus(ctx context.Context, original, modified *v1alpha.NodeNetworkConfig) (*v1alpha.NodeNetworkConfig, error) {
+	if err := c.cli.Status().Patch(ctx, modified, client.MergeFrom(original)); err != nil {
+		return nil, errors.Wrap(err, "failed to patch nnc status")
+	}
+	return modified, nil
+}
+
 // UpdateSpec does a fetch, deepcopy, and update of the NodeNetworkConfig with the passed spec.
 // Deprecated: UpdateSpec is deprecated and usage should migrate to PatchSpec.
 func (c *Client) UpdateSpec(ctx context.Context, key types.NamespacedName, spec *v1alpha.NodeNetworkConfigSpec) (*v1alpha.NodeNetworkConfig, error) {
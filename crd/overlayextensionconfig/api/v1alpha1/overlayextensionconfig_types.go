@@ -56,6 +56,19 @@ type OverlayExtensionConfigStatus struct {
 	// +kubebuilder:default="None"
 	State   OECState `json:"state,omitempty"`
 	Message string   `json:"message,omitempty"`
+	// Nodes is the progress of programming the ExtensionIPRange on each Node, which State aggregates.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Nodes []OECNodeStatus `json:"nodes,omitempty"`
+}
+
+// OECNodeStatus is the observed state of an OverlayExtensionConfig on a Node.
+type OECNodeStatus struct {
+	Name string `json:"name"`
+	// +kubebuilder:validation:Enum=None;Pending;Succeeded;Failed
+	State   OECState `json:"state"`
+	Message string   `json:"message,omitempty"`
 }
 
 func init() {
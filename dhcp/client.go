@@ -0,0 +1,366 @@
+package dhcp
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+var (
+	// DefaultRetransmitInterval is how long the client waits for a reply before sending a message again.
+	DefaultRetransmitInterval = 4 * time.Second
+	// ErrNak is returned when the server declines a request for a lease, which the client must not use any more.
+	ErrNak = errors.New("dhcp server declined the request")
+)
+
+const (
+	// minRenewRetry is the shortest time the client waits between renewal attempts (RFC 2131, Section 4.4.5).
+	minRenewRetry = 60 * time.Second
+	// maxAcquireRetry is the longest time the client waits between failed attempts to acquire a lease, the
+	// wait doubling from the retransmit interval (RFC 2131, Section 4.1).
+	maxAcquireRetry = 64 * time.Second
+)
+
+// requestedParameters are the options the client asks servers for.
+var requestedParameters = []byte{
+	optSubnetMask, optRouter, optDNS, optMTU, optLeaseTime, optServerID, optRenewalTime, optRebindingTime, optClasslessRoutes,
+}
+
+// transport sends and receives DHCP messages on an interface.
+type transport interface {
+	// send sends the message from src to dst, which is either the broadcast address or a server heard from at
+	// the hardware address dstHW. A nil dstHW broadcasts the message on the link.
+	send(msg []byte, src, dst net.IP, dstHW net.HardwareAddr) error
+	// receive returns the next DHCP message received and the hardware address it was sent from, or nil if none
+	// arrived within the poll interval.
+	receive() ([]byte, net.HardwareAddr, error)
+	Close() error
+}
+
+// LeaseClient obtains a lease for an interface and maintains it: it renews the lease with the server which
+// granted it, rebinds with any server if that one does not answer, and releases the lease when stopped.
+// The lease is only kept for as long as Run maintains it, so it is meant for a long-lived owner of the interface
+// rather than a plugin which exits once the interface is set up.
+type LeaseClient struct {
+	logger     *zap.Logger
+	mac        net.HardwareAddr
+	open       func() (transport, error)
+	retransmit time.Duration
+	now        func() time.Time
+
+	mu    sync.Mutex
+	lease *Lease
+}
+
+// NewLeaseClient returns a LeaseClient for the interface ifname with the hardware address mac.
+func (c *DHCP) NewLeaseClient(mac net.HardwareAddr, ifname string) *LeaseClient {
+	return &LeaseClient{
+		logger:     c.logger.With(zap.String("interface", ifname)),
+		mac:        mac,
+		open:       func() (transport, error) { return openTransport(ifname) },
+		retransmit: DefaultRetransmitInterval,
+		now:        time.Now,
+	}
+}
+
+// AcquireLease obtains a lease for the interface ifname with the hardware address mac, until ctx is done.
+func (c *DHCP) AcquireLease(ctx context.Context, mac net.HardwareAddr, ifname string) (*Lease, error) {
+	return c.NewLeaseClient(mac, ifname).Acquire(ctx)
+}
+
+// Lease returns the current lease, or nil if the client has none.
+func (lc *LeaseClient) Lease() *Lease {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	return lc.lease
+}
+
+func (lc *LeaseClient) setLease(lease *Lease) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	lc.lease = lease
+}
+
+// Acquire obtains a new lease with a DISCOVER, OFFER, REQUEST, ACK exchange, starting over if the server
+// declines the request. It retries until it gets a lease or ctx is done.
+func (lc *LeaseClient) Acquire(ctx context.Context) (*Lease, error) {
+	t, err := lc.open()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open dhcp transport")
+	}
+	defer t.Close()
+
+	for {
+		xid, err := GenerateTransactionID()
+		if err != nil {
+			return nil, err
+		}
+
+		discover := lc.newMessage(MessageDiscover, xid, nil)
+		offer, _, err := lc.exchange(ctx, t, discover, net.IPv4zero, net.IPv4bcast, nil, MessageOffer)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get an offer")
+		}
+		lc.logger.Info("Received DHCP offer", zap.Stringer("address", offer.YourIP), zap.Stringer("server", net.IP(offer.Options[optServerID])))
+
+		request := lc.newMessage(MessageRequest, xid, nil)
+		request.Options[optRequestedIP] = ipv4Bytes(offer.YourIP)
+		request.Options[optServerID] = offer.Options[optServerID]
+		sent := lc.now()
+		ack, serverHW, err := lc.exchange(ctx, t, request, net.IPv4zero, net.IPv4bcast, nil, MessageAck, MessageNak)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get an ack")
+		}
+		if ack.Type() == MessageNak {
+			lc.logger.Info("DHCP server declined the request, starting over", zap.Stringer("address", offer.YourIP))
+			continue
+		}
+
+		lease, err := newLease(ack, sent)
+		if err != nil {
+			return nil, err
+		}
+		lease.ServerHWAddr = serverHW
+		lc.setLease(lease)
+		lc.logger.Info("Acquired DHCP lease", zap.Stringer("address", &lease.Address), zap.Duration("leaseTime", lease.LeaseTime))
+		return lease, nil
+	}
+}
+
+// Renew asks the server which granted the lease to extend it, until ctx is done.
+func (lc *LeaseClient) Renew(ctx context.Context) (*Lease, error) {
+	lease := lc.Lease()
+	if lease == nil {
+		return nil, errors.New("no lease to renew")
+	}
+	return lc.extend(ctx, lease, lease.ServerID)
+}
+
+// Rebind asks any server to extend the lease, until ctx is done.
+func (lc *LeaseClient) Rebind(ctx context.Context) (*Lease, error) {
+	lease := lc.Lease()
+	if lease == nil {
+		return nil, errors.New("no lease to rebind")
+	}
+	return lc.extend(ctx, lease, net.IPv4bcast)
+}
+
+// extend sends a request for the leased address to dst, from the leased address. Requests unicast to the server
+// which granted the lease are sent to the hardware address it was heard from.
+func (lc *LeaseClient) extend(ctx context.Context, lease *Lease, dst net.IP) (*Lease, error) {
+	t, err := lc.open()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open dhcp transport")
+	}
+	defer t.Close()
+
+	xid, err := GenerateTransactionID()
+	if err != nil {
+		return nil, err
+	}
+	request := lc.newMessage(MessageRequest, xid, lease.Address.IP)
+	var dstHW net.HardwareAddr
+	if !dst.Equal(net.IPv4bcast) {
+		dstHW = lease.ServerHWAddr
+	}
+	sent := lc.now()
+	ack, serverHW, err := lc.exchange(ctx, t, request, lease.Address.IP, dst, dstHW, MessageAck, MessageNak)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get an ack")
+	}
+	if ack.Type() == MessageNak {
+		lc.setLease(nil)
+		return nil, ErrNak
+	}
+
+	extended, err := newLease(ack, sent)
+	if err != nil {
+		return nil, err
+	}
+	extended.ServerHWAddr = serverHW
+	if !extended.Address.IP.Equal(lease.Address.IP) {
+		lc.setLease(nil)
+		return nil, errors.Errorf("server acked %s instead of the leased %s", extended.Address.IP, lease.Address.IP)
+	}
+	lc.setLease(extended)
+	lc.logger.Info("Extended DHCP lease", zap.Stringer("address", &extended.Address), zap.Time("expires", extended.ExpiresAt()))
+	return extended, nil
+}
+
+// Release gives the lease back to the server which granted it. No reply is expected.
+func (lc *LeaseClient) Release(_ context.Context) error {
+	lease := lc.Lease()
+	if lease == nil {
+		return nil
+	}
+	lc.setLease(nil)
+
+	t, err := lc.open()
+	if err != nil {
+		return errors.Wrap(err, "failed to open dhcp transport")
+	}
+	defer t.Close()
+
+	xid, err := GenerateTransactionID()
+	if err != nil {
+		return err
+	}
+	release := lc.newMessage(MessageRelease, xid, lease.Address.IP)
+	release.Options[optServerID] = ipv4Bytes(lease.ServerID)
+	if err := t.send(release.Marshal(), lease.Address.IP, lease.ServerID, lease.ServerHWAddr); err != nil {
+		return errors.Wrap(err, "failed to send release")
+	}
+	lc.logger.Info("Released DHCP lease", zap.Stringer("address", &lease.Address))
+	return nil
+}
+
+// Run acquires a lease if the client has none and maintains it until ctx is done, then releases it.
+// onLease is called with every lease acquired or extended, for the interface to be configured with it, and
+// with nil whenever the lease is lost: when it expires, when the server declines to extend it and when it is
+// released on shutdown, for the address to be removed from the interface.
+func (lc *LeaseClient) Run(ctx context.Context, onLease func(*Lease)) error {
+	var acquireRetry time.Duration
+	for {
+		lease := lc.Lease()
+		held := lease != nil
+		now := lc.now()
+
+		var err error
+		switch {
+		case lease == nil || !now.Before(lease.ExpiresAt()):
+			if lease != nil {
+				lc.logger.Info("DHCP lease expired", zap.Stringer("address", &lease.Address))
+				lc.setLease(nil)
+				onLease(nil)
+				held = false
+			}
+			lease, err = lc.Acquire(ctx)
+		case now.Before(lease.RenewAt()):
+			lc.wait(ctx, lease.RenewAt())
+			if ctx.Err() != nil {
+				return lc.stop(onLease)
+			}
+			continue
+		case now.Before(lease.RebindAt()):
+			lease, err = lc.extendUntil(ctx, lease, lease.ServerID, lease.RebindAt())
+		default:
+			lease, err = lc.extendUntil(ctx, lease, net.IPv4bcast, lease.ExpiresAt())
+		}
+
+		if ctx.Err() != nil {
+			return lc.stop(onLease)
+		}
+		if err != nil {
+			lc.logger.Warn("Failed to maintain DHCP lease", zap.Error(err))
+			if lc.Lease() == nil {
+				if held {
+					// the server declined to extend the lease, so it must not be used any more
+					onLease(nil)
+				}
+				// acquiring can fail without waiting for a server, so back off before trying again
+				acquireRetry = min(max(2*acquireRetry, lc.retransmit), maxAcquireRetry) //nolint:gomnd // double the wait
+				lc.wait(ctx, lc.now().Add(acquireRetry))
+			}
+			continue
+		}
+		acquireRetry = 0
+		onLease(lease)
+	}
+}
+
+// extendUntil tries to extend the lease until the deadline, waiting between attempts half the time left
+// down to minRenewRetry (RFC 2131, Section 4.4.5).
+func (lc *LeaseClient) extendUntil(ctx context.Context, lease *Lease, dst net.IP, deadline time.Time) (*Lease, error) {
+	attemptDeadline := deadline
+	if wait := deadline.Sub(lc.now()) / 2; wait > minRenewRetry { //nolint:gomnd // half the time left
+		attemptDeadline = lc.now().Add(wait)
+	}
+	attemptCtx, cancel := context.WithDeadline(ctx, attemptDeadline)
+	defer cancel()
+	extended, err := lc.extend(attemptCtx, lease, dst)
+	if err != nil && !errors.Is(err, ErrNak) {
+		// wait out the rest of the attempt before trying again
+		lc.wait(ctx, attemptDeadline)
+	}
+	return extended, err
+}
+
+// stop releases the lease, if the client has one, and reports it lost to onLease.
+func (lc *LeaseClient) stop(onLease func(*Lease)) error {
+	if lc.Lease() == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), lc.retransmit)
+	defer cancel()
+	err := lc.Release(ctx)
+	onLease(nil)
+	return err
+}
+
+func (lc *LeaseClient) wait(ctx context.Context, until time.Time) {
+	timer := time.NewTimer(until.Sub(lc.now()))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
+func (lc *LeaseClient) newMessage(msgType MessageType, xid TransactionID, clientIP net.IP) *Message {
+	m := &Message{
+		Op:           opRequest,
+		XID:          xid,
+		ClientIP:     clientIP,
+		ClientHWAddr: lc.mac,
+		Options: map[byte][]byte{
+			optMessageType:   {byte(msgType)},
+			optParameterList: requestedParameters,
+			optClientID:      append([]byte{htypeEthernet}, lc.mac...),
+		},
+	}
+	// a client without an address asks for replies to be broadcast
+	if clientIP == nil {
+		m.Flags = flags
+	}
+	return m
+}
+
+// exchange sends the message until a reply of one of the types arrives or ctx is done, and returns the reply and
+// the hardware address of the server it came from.
+func (lc *LeaseClient) exchange(ctx context.Context, t transport, m *Message, src, dst net.IP, dstHW net.HardwareAddr,
+	types ...MessageType,
+) (*Message, net.HardwareAddr, error) {
+	msg := m.Marshal()
+	for {
+		if err := t.send(msg, src, dst, dstHW); err != nil {
+			return nil, nil, errors.Wrap(err, "failed to send")
+		}
+		retransmitAt := time.Now().Add(lc.retransmit)
+		for time.Now().Before(retransmitAt) {
+			if err := ctx.Err(); err != nil {
+				return nil, nil, errors.Wrap(err, "no reply")
+			}
+			b, from, err := t.receive()
+			if err != nil {
+				return nil, nil, errors.Wrap(err, "failed to receive")
+			}
+			if b == nil {
+				continue
+			}
+			reply, err := ParseMessage(b)
+			if err != nil || reply.Op != dhcpOpCodeReply || reply.XID != m.XID || !bytes.Equal(reply.ClientHWAddr, lc.mac) {
+				continue
+			}
+			for _, typ := range types {
+				if reply.Type() == typ {
+					return reply, from, nil
+				}
+			}
+		}
+	}
+}
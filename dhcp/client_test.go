@@ -0,0 +1,338 @@
+package dhcp
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var (
+	testMAC   = net.HardwareAddr{0, 0x0d, 0x3a, 0xaa, 0xbb, 0xcc}
+	serverMAC = net.HardwareAddr{0, 0x0d, 0x3a, 0xdd, 0xee, 0xff}
+)
+
+type packet struct {
+	msg   []byte
+	dst   net.IP
+	dstHW net.HardwareAddr
+	from  net.HardwareAddr
+}
+
+// memTransport is one end of an in-memory link, with the hardware address hw.
+type memTransport struct {
+	hw  net.HardwareAddr
+	in  <-chan packet
+	out chan<- packet
+}
+
+func (m *memTransport) send(msg []byte, _, dst net.IP, dstHW net.HardwareAddr) error {
+	select {
+	case m.out <- packet{msg: append([]byte(nil), msg...), dst: dst, dstHW: dstHW, from: m.hw}:
+	default:
+	}
+	return nil
+}
+
+func (m *memTransport) receive() ([]byte, net.HardwareAddr, error) {
+	select {
+	case p := <-m.in:
+		return p.msg, p.from, nil
+	case <-time.After(10 * time.Millisecond):
+		return nil, nil, nil
+	}
+}
+
+func (m *memTransport) Close() error { return nil }
+
+// testServer is a DHCP server stand-in leasing a single address.
+type testServer struct {
+	serverIP net.IP
+	addr     net.IP
+	// lease times in seconds
+	leaseTime, renewalTime, rebindingTime uint32
+
+	mu sync.Mutex
+	// nak declines the next request
+	nak bool
+	// ignoreRenew does not answer requests unicast to the server
+	ignoreRenew bool
+	received    []MessageType
+	// unicastHW are the hardware addresses of the messages which were not broadcast on the link
+	unicastHW []net.HardwareAddr
+	renewals  int
+	rebinds   int
+	released  []net.IP
+}
+
+func newTestServer() *testServer {
+	return &testServer{
+		serverIP:      net.IPv4(10, 1, 2, 254).To4(),
+		addr:          net.IPv4(10, 1, 2, 3).To4(),
+		leaseTime:     3600,
+		renewalTime:   1800,
+		rebindingTime: 3150,
+	}
+}
+
+func (s *testServer) handle(m *Message, dst net.IP, dstHW net.HardwareAddr) *Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.received = append(s.received, m.Type())
+	if dstHW != nil {
+		s.unicastHW = append(s.unicastHW, dstHW)
+	}
+
+	reply := &Message{
+		Op:           dhcpOpCodeReply,
+		XID:          m.XID,
+		Flags:        m.Flags,
+		ClientHWAddr: m.ClientHWAddr,
+		Options:      map[byte][]byte{optServerID: s.serverIP},
+	}
+	switch m.Type() {
+	case MessageDiscover:
+		reply.Options[optMessageType] = []byte{byte(MessageOffer)}
+		reply.YourIP = s.addr
+	case MessageRequest:
+		if m.ClientIP != nil && !m.ClientIP.IsUnspecified() {
+			if dst.Equal(net.IPv4bcast) {
+				s.rebinds++
+			} else {
+				s.renewals++
+				if s.ignoreRenew {
+					return nil
+				}
+			}
+		}
+		if s.nak {
+			s.nak = false
+			reply.Options[optMessageType] = []byte{byte(MessageNak)}
+			return reply
+		}
+		reply.Options[optMessageType] = []byte{byte(MessageAck)}
+		reply.YourIP = s.addr
+		reply.Options[optSubnetMask] = []byte{255, 255, 255, 0}
+		reply.Options[optRouter] = []byte{10, 1, 2, 1}
+		reply.Options[optDNS] = []byte{168, 63, 129, 16}
+		reply.Options[optLeaseTime] = binary.BigEndian.AppendUint32(nil, s.leaseTime)
+		reply.Options[optRenewalTime] = binary.BigEndian.AppendUint32(nil, s.renewalTime)
+		reply.Options[optRebindingTime] = binary.BigEndian.AppendUint32(nil, s.rebindingTime)
+	case MessageRelease:
+		s.released = append(s.released, m.ClientIP)
+		return nil
+	default:
+		return nil
+	}
+	return reply
+}
+
+// serve answers the messages received on the transport until ctx is done. Replies are broadcast.
+func (s *testServer) serve(ctx context.Context, t transport) {
+	for ctx.Err() == nil {
+		b, _, err := t.receive()
+		if err != nil || b == nil {
+			continue
+		}
+		m, err := ParseMessage(b)
+		if err != nil || m.Op != opRequest {
+			continue
+		}
+		// only the in-memory link tells the stand-in where a message was sent
+		dst, dstHW := net.IPv4bcast, net.HardwareAddr(nil)
+		if d, ok := t.(interface {
+			lastDst() (net.IP, net.HardwareAddr)
+		}); ok {
+			dst, dstHW = d.lastDst()
+		}
+		if reply := s.handle(m, dst, dstHW); reply != nil {
+			_ = t.send(reply.Marshal(), s.serverIP, net.IPv4bcast, nil)
+		}
+	}
+}
+
+// memServerTransport remembers the destination of the last packet it received.
+type memServerTransport struct {
+	memTransport
+	dst   net.IP
+	dstHW net.HardwareAddr
+}
+
+func (m *memServerTransport) receive() ([]byte, net.HardwareAddr, error) {
+	select {
+	case p := <-m.in:
+		m.dst, m.dstHW = p.dst, p.dstHW
+		return p.msg, p.from, nil
+	case <-time.After(10 * time.Millisecond):
+		return nil, nil, nil
+	}
+}
+
+func (m *memServerTransport) lastDst() (net.IP, net.HardwareAddr) { return m.dst, m.dstHW }
+
+// startMemServer serves the stand-in over an in-memory link, and returns the client for the other end.
+func startMemServer(ctx context.Context, s *testServer) *LeaseClient {
+	toServer, toClient := make(chan packet, 64), make(chan packet, 64)
+	go s.serve(ctx, &memServerTransport{memTransport: memTransport{hw: serverMAC, in: toServer, out: toClient}})
+	return &LeaseClient{
+		logger:     zap.NewNop(),
+		mac:        testMAC,
+		open:       func() (transport, error) { return &memTransport{hw: testMAC, in: toClient, out: toServer}, nil },
+		retransmit: 100 * time.Millisecond,
+		now:        time.Now,
+	}
+}
+
+func TestLeaseClientLifecycle(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s := newTestServer()
+	lc := startMemServer(ctx, s)
+
+	lease, err := lc.Acquire(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "10.1.2.3/24", lease.Address.String())
+	require.Equal(t, "10.1.2.1", lease.Router.String())
+	require.Equal(t, "10.1.2.254", lease.ServerID.String())
+	require.Equal(t, serverMAC, lease.ServerHWAddr)
+	require.Equal(t, time.Hour, lease.LeaseTime)
+	require.Equal(t, lease, lc.Lease())
+
+	renewed, err := lc.Renew(ctx)
+	require.NoError(t, err)
+	require.True(t, renewed.Acquired.After(lease.Acquired))
+	_, err = lc.Rebind(ctx)
+	require.NoError(t, err)
+
+	require.NoError(t, lc.Release(ctx))
+	require.Nil(t, lc.Lease())
+	require.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return len(s.released) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	require.Equal(t, []MessageType{MessageDiscover, MessageRequest, MessageRequest, MessageRequest, MessageRelease}, s.received)
+	// the renewal and the release are sent to the server, from a transport which has not heard from it
+	require.Equal(t, []net.HardwareAddr{serverMAC, serverMAC}, s.unicastHW)
+	require.Equal(t, 1, s.renewals)
+	require.Equal(t, 1, s.rebinds)
+}
+
+func TestLeaseClientNak(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s := newTestServer()
+	lc := startMemServer(ctx, s)
+
+	// a declined request starts the exchange over
+	s.nak = true
+	_, err := lc.Acquire(ctx)
+	require.NoError(t, err)
+	s.mu.Lock()
+	require.Equal(t, []MessageType{MessageDiscover, MessageRequest, MessageDiscover, MessageRequest}, s.received)
+	s.nak = true
+	s.mu.Unlock()
+
+	// a declined renewal loses the lease
+	_, err = lc.Renew(ctx)
+	require.ErrorIs(t, err, ErrNak)
+	require.Nil(t, lc.Lease())
+}
+
+func TestLeaseClientRun(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s := newTestServer()
+	s.leaseTime, s.renewalTime, s.rebindingTime = 4, 1, 2
+	// the granting server stops answering, so the lease is extended by rebinding
+	s.ignoreRenew = true
+	lc := startMemServer(ctx, s)
+
+	runCtx, stop := context.WithCancel(ctx)
+	leases := make(chan *Lease, 4)
+	done := make(chan error)
+	go func() { done <- lc.Run(runCtx, func(l *Lease) { leases <- l }) }()
+
+	acquired := <-leases
+	rebound := <-leases
+	require.Equal(t, acquired.Address.String(), rebound.Address.String())
+	require.False(t, rebound.Acquired.Before(acquired.RebindAt()))
+
+	stop()
+	require.NoError(t, <-done)
+	require.Nil(t, lc.Lease())
+	require.Nil(t, <-leases, "the released lease should be reported lost")
+
+	require.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return len(s.released) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	require.Positive(t, s.renewals)
+	require.Equal(t, 1, s.rebinds)
+}
+
+func TestLeaseClientRunLosesLease(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s := newTestServer()
+	s.leaseTime, s.renewalTime, s.rebindingTime = 4, 1, 2
+	lc := startMemServer(ctx, s)
+
+	runCtx, stop := context.WithCancel(ctx)
+	defer stop()
+	leases := make(chan *Lease, 4)
+	done := make(chan error)
+	go func() { done <- lc.Run(runCtx, func(l *Lease) { leases <- l }) }()
+
+	require.NotNil(t, <-leases)
+	s.mu.Lock()
+	s.nak = true
+	s.mu.Unlock()
+
+	// the declined renewal is reported as a lost lease
+	require.Nil(t, <-leases)
+	require.Nil(t, lc.Lease())
+
+	stop()
+	require.NoError(t, <-done)
+	require.Empty(t, leases, "no lease is left to release")
+}
+
+func TestLeaseClientRunBacksOff(t *testing.T) {
+	var mu sync.Mutex
+	opened := 0
+	lc := &LeaseClient{
+		logger: zap.NewNop(),
+		mac:    testMAC,
+		open: func() (transport, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			opened++
+			return nil, errors.New("interface is gone")
+		},
+		retransmit: 50 * time.Millisecond,
+		now:        time.Now,
+	}
+
+	// failing to acquire a lease right away waits 50ms, 100ms, 200ms... before trying again
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	require.NoError(t, lc.Run(ctx, func(*Lease) { t.Fatal("no lease can be acquired") }))
+	mu.Lock()
+	defer mu.Unlock()
+	require.GreaterOrEqual(t, opened, 3)
+	require.LessOrEqual(t, opened, 5)
+}
@@ -6,7 +6,6 @@ package dhcp
 import (
 	"bytes"
 	"context"
-	"crypto/rand"
 	"encoding/binary"
 	"io"
 	"net"
@@ -23,30 +22,13 @@ const (
 	bootRequest              = 1
 	ethPAll                  = 0x0003
 	MaxUDPReceivedPacketSize = 8192
-	dhcpServerPort           = 67
-	dhcpClientPort           = 68
-	dhcpOpCodeReply          = 2
-	bootpMinLen              = 300
-	bytesInAddress           = 4 // bytes in an ip address
-	macBytes                 = 6 // bytes in a mac address
 	udpProtocol              = 17
 
-	opRequest     = 1
-	htypeEthernet = 1
-	hlenEthernet  = 6
-	hops          = 0
-	secs          = 0
-	flags         = 0x8000 // Broadcast flag
+	hops = 0
+	secs = 0
 )
 
-// TransactionID represents a 4-byte DHCP transaction ID as defined in RFC 951,
-// Section 3.
-//
-// The TransactionID is used to match DHCP replies to their original request.
-type TransactionID [4]byte
-
 var (
-	magicCookie        = []byte{0x63, 0x82, 0x53, 0x63} // DHCP magic cookie
 	DefaultReadTimeout = 3 * time.Second
 	DefaultTimeout     = 3 * time.Second
 )
@@ -122,16 +104,6 @@ func (s *Socket) Close() error {
 	return nil
 }
 
-// GenerateTransactionID generates a random 32-bits number suitable for use as TransactionID
-func GenerateTransactionID() (TransactionID, error) {
-	var xid TransactionID
-	_, err := rand.Read(xid[:])
-	if err != nil {
-		return xid, errors.Errorf("could not get random number: %v", err)
-	}
-	return xid, nil
-}
-
 func makeListeningSocket(ifname string, timeout time.Duration) (int, error) {
 	// reference: https://manned.org/packet.7
 	// starts listening to the specified protocol, or none if zero
@@ -459,3 +431,115 @@ func (c *DHCP) DiscoverRequest(ctx context.Context, mac net.HardwareAddr, ifname
 	res := c.receiveDHCPResponse(ctx, reader, txid)
 	return res
 }
+
+// pollInterval is how long a packetTransport waits for a packet before receive returns.
+const pollInterval = 100 * time.Millisecond
+
+// packetTransport sends and receives DHCP messages on an interface with a packet socket, so it works
+// before the interface has an address. Unicast messages are sent to the hardware address given for the
+// destination, without needing ARP.
+type packetTransport struct {
+	fd      int
+	ifindex int
+	srcPort int
+	dstPort int
+}
+
+func openTransport(ifname string) (transport, error) {
+	return newPacketTransport(ifname, dhcpClientPort, dhcpServerPort)
+}
+
+// newPacketTransport returns a transport sending from srcPort to dstPort, and receiving messages sent the other way.
+func newPacketTransport(ifname string, srcPort, dstPort int) (*packetTransport, error) {
+	iface, err := net.InterfaceByName(ifname)
+	if err != nil {
+		return nil, errors.Wrap(err, "dhcp failed to get interface")
+	}
+	fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, int(htons(unix.ETH_P_IP)))
+	if err != nil {
+		return nil, errors.Wrap(err, "dhcp packet socket creation failure")
+	}
+	t := &packetTransport{
+		fd:      fd,
+		ifindex: iface.Index,
+		srcPort: srcPort,
+		dstPort: dstPort,
+	}
+	if err := unix.Bind(fd, &unix.SockaddrLinklayer{Ifindex: iface.Index, Protocol: htons(unix.ETH_P_IP)}); err != nil {
+		t.Close()
+		return nil, errors.Wrap(err, "dhcp failed to bind")
+	}
+	timeval := unix.NsecToTimeval(pollInterval.Nanoseconds())
+	if err := unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &timeval); err != nil {
+		t.Close()
+		return nil, errors.Wrap(err, "could not set timeout on socket")
+	}
+	return t, nil
+}
+
+func (t *packetTransport) send(msg []byte, src, dst net.IP, dstHW net.HardwareAddr) error {
+	packet, err := MakeRawUDPPacket(msg, net.UDPAddr{IP: dst.To4(), Port: t.dstPort}, net.UDPAddr{IP: src.To4(), Port: t.srcPort})
+	if err != nil {
+		return err
+	}
+	// the kernel does not fill in the header checksum of packets sent on packet sockets
+	binary.BigEndian.PutUint16(packet[10:12], ipv4HeaderChecksum(packet[:ipv4.HeaderLen]))
+
+	hw := dstHW
+	if hw == nil {
+		hw = net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	}
+	sa := &unix.SockaddrLinklayer{Ifindex: t.ifindex, Protocol: htons(unix.ETH_P_IP), Halen: macBytes}
+	copy(sa.Addr[:], hw)
+	return errors.Wrap(unix.Sendto(t.fd, packet, 0, sa), "failed unix send to")
+}
+
+func (t *packetTransport) receive() ([]byte, net.HardwareAddr, error) {
+	buf := make([]byte, MaxUDPReceivedPacketSize)
+	n, from, err := unix.Recvfrom(t.fd, buf, 0)
+	if err != nil {
+		if errors.Is(err, unix.EAGAIN) || errors.Is(err, unix.EWOULDBLOCK) || errors.Is(err, unix.EINTR) {
+			return nil, nil, nil
+		}
+		return nil, nil, errors.Wrap(err, "failed unix recv from")
+	}
+	ll, ok := from.(*unix.SockaddrLinklayer)
+	if !ok || ll.Pkttype == unix.PACKET_OUTGOING {
+		return nil, nil, nil
+	}
+
+	var iph ipv4.Header
+	if err := iph.Parse(buf[:n]); err != nil || iph.Protocol != udpProtocol || n < iph.Len+8 {
+		return nil, nil, nil
+	}
+	udph := buf[iph.Len:n]
+	if int(binary.BigEndian.Uint16(udph[0:2])) != t.dstPort || int(binary.BigEndian.Uint16(udph[2:4])) != t.srcPort {
+		return nil, nil, nil
+	}
+	end := iph.Len + int(binary.BigEndian.Uint16(udph[4:6]))
+	if end > n || end < iph.Len+8 {
+		return nil, nil, nil
+	}
+	return buf[iph.Len+8 : end], net.HardwareAddr(append([]byte(nil), ll.Addr[:ll.Halen]...)), nil
+}
+
+func (t *packetTransport) Close() error {
+	if err := unix.Close(t.fd); err != nil {
+		return errors.Wrap(err, "error closing dhcp packet socket")
+	}
+	return nil
+}
+
+func ipv4HeaderChecksum(header []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(header); i += 2 {
+		if i == 10 { //nolint:gomnd // the checksum itself
+			continue
+		}
+		sum += uint32(binary.BigEndian.Uint16(header[i : i+2]))
+	}
+	for sum>>16 != 0 {
+		sum = sum&0xffff + sum>>16
+	}
+	return ^uint16(sum)
+}
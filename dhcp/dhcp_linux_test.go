@@ -0,0 +1,67 @@
+//go:build linux
+// +build linux
+
+package dhcp
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-container-networking/netlink"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const (
+	clientIfName = "dhcptest0"
+	serverIfName = "dhcptest1"
+)
+
+// TestLeaseClientVEth acquires and releases a lease over a veth pair, with the server stand-in on the peer.
+func TestLeaseClientVEth(t *testing.T) {
+	nl := netlink.NewNetlink()
+	link := netlink.VEthLink{
+		LinkInfo: netlink.LinkInfo{
+			Type: netlink.LINK_TYPE_VETH,
+			Name: clientIfName,
+		},
+		PeerName: serverIfName,
+	}
+	if err := nl.AddLink(&link); err != nil {
+		t.Skipf("failed to create veth pair: %v", err)
+	}
+	defer nl.DeleteLink(clientIfName) //nolint:errcheck // best effort cleanup
+	require.NoError(t, nl.SetLinkState(clientIfName, true))
+	require.NoError(t, nl.SetLinkState(serverIfName, true))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	s := newTestServer()
+	serverTransport, err := newPacketTransport(serverIfName, dhcpServerPort, dhcpClientPort)
+	require.NoError(t, err)
+	defer serverTransport.Close()
+	go s.serve(ctx, serverTransport)
+
+	ifi, err := net.InterfaceByName(clientIfName)
+	require.NoError(t, err)
+	lc := New(zap.NewNop()).NewLeaseClient(ifi.HardwareAddr, clientIfName)
+	lc.retransmit = 500 * time.Millisecond
+
+	lease, err := lc.Acquire(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "10.1.2.3/24", lease.Address.String())
+	require.Equal(t, []net.IP{net.IPv4(168, 63, 129, 16).To4()}, lease.DNSServers)
+
+	_, err = lc.Renew(ctx)
+	require.NoError(t, err)
+
+	require.NoError(t, lc.Release(ctx))
+	require.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return len(s.released) == 1
+	}, 5*time.Second, 50*time.Millisecond)
+}
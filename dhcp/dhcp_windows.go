@@ -4,6 +4,7 @@ import (
 	"context"
 	"net"
 
+	"github.com/pkg/errors"
 	"go.uber.org/zap"
 )
 
@@ -20,3 +21,7 @@ func New(logger *zap.Logger) *DHCP {
 func (c *DHCP) DiscoverRequest(_ context.Context, _ net.HardwareAddr, _ string) error {
 	return nil
 }
+
+func openTransport(_ string) (transport, error) {
+	return nil, errors.New("dhcp lease client is not supported on windows")
+}
@@ -0,0 +1,157 @@
+package dhcp
+
+import (
+	"encoding/binary"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Route is a classless static route of a lease (RFC 3442).
+// A route with an unspecified gateway is to a destination on the link.
+type Route struct {
+	Destination net.IPNet
+	Gateway     net.IP
+}
+
+// Lease is an address leased from a DHCP server, along with the configuration it sent with it.
+type Lease struct {
+	Address net.IPNet
+	// Router is the default gateway. When the server sends classless static routes it is the gateway of the
+	// default route among them, as clients ignore the router option then (RFC 3442).
+	Router     net.IP
+	DNSServers []net.IP
+	// MTU is the interface MTU, zero if the server did not send one.
+	MTU    int
+	Routes []Route
+	// ServerID is the address of the server which granted the lease, to which renewals are sent.
+	ServerID net.IP
+	// ServerHWAddr is the hardware address the server was heard from, to which renewals are sent on the link.
+	ServerHWAddr net.HardwareAddr
+
+	LeaseTime     time.Duration
+	RenewalTime   time.Duration
+	RebindingTime time.Duration
+	// Acquired is when the request which granted or extended the lease was sent.
+	Acquired time.Time
+}
+
+// RenewAt returns when the client starts renewing the lease with the server which granted it (T1).
+func (l *Lease) RenewAt() time.Time {
+	return l.Acquired.Add(l.RenewalTime)
+}
+
+// RebindAt returns when the client starts asking any server to extend the lease (T2).
+func (l *Lease) RebindAt() time.Time {
+	return l.Acquired.Add(l.RebindingTime)
+}
+
+// ExpiresAt returns when the lease ends unless it is extended.
+func (l *Lease) ExpiresAt() time.Time {
+	return l.Acquired.Add(l.LeaseTime)
+}
+
+// newLease returns the lease granted by a DHCPACK to a request sent at acquired.
+func newLease(ack *Message, acquired time.Time) (*Lease, error) {
+	ip := ack.YourIP.To4()
+	if ip == nil || ip.IsUnspecified() {
+		return nil, errors.New("ack has no address")
+	}
+	lease := &Lease{
+		Address:  net.IPNet{IP: ip, Mask: ip.DefaultMask()},
+		ServerID: ipOption(ack, optServerID),
+		Acquired: acquired,
+	}
+	if mask := ack.Options[optSubnetMask]; len(mask) == bytesInAddress {
+		lease.Address.Mask = net.IPMask(mask)
+	}
+	if routers := ipListOption(ack, optRouter); len(routers) > 0 {
+		lease.Router = routers[0]
+	}
+	lease.DNSServers = ipListOption(ack, optDNS)
+	if mtu := ack.Options[optMTU]; len(mtu) == 2 { //nolint:gomnd // 16 bit option
+		lease.MTU = int(binary.BigEndian.Uint16(mtu))
+	}
+	if v, ok := ack.Options[optClasslessRoutes]; ok {
+		routes, err := parseClasslessRoutes(v)
+		if err != nil {
+			return nil, err
+		}
+		lease.Routes = routes
+		lease.Router = nil
+		for i := range routes {
+			if ones, _ := routes[i].Destination.Mask.Size(); ones == 0 {
+				lease.Router = routes[i].Gateway
+			}
+		}
+	}
+
+	leaseTime, ok := durationOption(ack, optLeaseTime)
+	if !ok {
+		return nil, errors.New("ack has no lease time")
+	}
+	lease.LeaseTime = leaseTime
+	// default T1 and T2 are 0.5 and 0.875 of the lease time (RFC 2131, Section 4.4.5)
+	lease.RenewalTime = leaseTime / 2       //nolint:gomnd // T1
+	lease.RebindingTime = leaseTime * 7 / 8 //nolint:gomnd // T2
+	if t1, ok := durationOption(ack, optRenewalTime); ok && t1 < leaseTime {
+		lease.RenewalTime = t1
+	}
+	if t2, ok := durationOption(ack, optRebindingTime); ok && t2 < leaseTime {
+		lease.RebindingTime = t2
+	}
+	if lease.RebindingTime < lease.RenewalTime {
+		lease.RebindingTime = lease.RenewalTime
+	}
+	return lease, nil
+}
+
+func ipOption(m *Message, code byte) net.IP {
+	if v := m.Options[code]; len(v) == bytesInAddress {
+		return net.IP(v)
+	}
+	return nil
+}
+
+func ipListOption(m *Message, code byte) []net.IP {
+	v := m.Options[code]
+	var ips []net.IP
+	for i := 0; i+bytesInAddress <= len(v); i += bytesInAddress {
+		ips = append(ips, net.IP(v[i:i+bytesInAddress]))
+	}
+	return ips
+}
+
+func durationOption(m *Message, code byte) (time.Duration, bool) {
+	v := m.Options[code]
+	if len(v) != 4 { //nolint:gomnd // 32 bit option
+		return 0, false
+	}
+	return time.Duration(binary.BigEndian.Uint32(v)) * time.Second, true
+}
+
+// parseClasslessRoutes parses the classless static route option, a sequence of the destination prefix length,
+// the significant octets of the destination, and the gateway (RFC 3442).
+func parseClasslessRoutes(v []byte) ([]Route, error) {
+	var routes []Route
+	for i := 0; i < len(v); {
+		ones := int(v[i])
+		if ones > 32 { //nolint:gomnd // bits in an IPv4 address
+			return nil, errors.Errorf("invalid classless static route prefix length %d", ones)
+		}
+		significant := (ones + 7) / 8 //nolint:gomnd // bytes needed for the prefix
+		if i+1+significant+bytesInAddress > len(v) {
+			return nil, errors.New("classless static routes option is truncated")
+		}
+		dst := make(net.IP, bytesInAddress)
+		copy(dst, v[i+1:i+1+significant])
+		gw := net.IP(append([]byte(nil), v[i+1+significant:i+1+significant+bytesInAddress]...))
+		routes = append(routes, Route{
+			Destination: net.IPNet{IP: dst, Mask: net.CIDRMask(ones, 32)}, //nolint:gomnd // bits in an IPv4 address
+			Gateway:     gw,
+		})
+		i += 1 + significant + bytesInAddress
+	}
+	return routes, nil
+}
@@ -0,0 +1,211 @@
+package dhcp
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"net"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	dhcpServerPort  = 67
+	dhcpClientPort  = 68
+	dhcpOpCodeReply = 2
+	bootpMinLen     = 300
+	bytesInAddress  = 4 // bytes in an ip address
+	macBytes        = 6 // bytes in a mac address
+
+	opRequest     = 1
+	htypeEthernet = 1
+	hlenEthernet  = 6
+	flags         = 0x8000 // Broadcast flag
+
+	bootpHeaderLen = 236
+	chaddrLen      = 16
+	snameLen       = 64
+	fileLen        = 128
+	maxOptionLen   = 255
+)
+
+// DHCP options (RFC 2132, RFC 3442).
+const (
+	optPad             = 0
+	optSubnetMask      = 1
+	optRouter          = 3
+	optDNS             = 6
+	optMTU             = 26
+	optRequestedIP     = 50
+	optLeaseTime       = 51
+	optMessageType     = 53
+	optServerID        = 54
+	optParameterList   = 55
+	optRenewalTime     = 58
+	optRebindingTime   = 59
+	optClientID        = 61
+	optClasslessRoutes = 121
+	optEnd             = 255
+)
+
+// MessageType is the DHCP message type of option 53.
+type MessageType byte
+
+const (
+	MessageDiscover MessageType = 1
+	MessageOffer    MessageType = 2
+	MessageRequest  MessageType = 3
+	MessageDecline  MessageType = 4
+	MessageAck      MessageType = 5
+	MessageNak      MessageType = 6
+	MessageRelease  MessageType = 7
+)
+
+// TransactionID represents a 4-byte DHCP transaction ID as defined in RFC 951,
+// Section 3.
+//
+// The TransactionID is used to match DHCP replies to their original request.
+type TransactionID [4]byte
+
+var magicCookie = []byte{0x63, 0x82, 0x53, 0x63} // DHCP magic cookie
+
+// GenerateTransactionID generates a random 32-bits number suitable for use as TransactionID
+func GenerateTransactionID() (TransactionID, error) {
+	var xid TransactionID
+	_, err := rand.Read(xid[:])
+	if err != nil {
+		return xid, errors.Errorf("could not get random number: %v", err)
+	}
+	return xid, nil
+}
+
+// Message is a DHCP message as defined in RFC 2131, Section 2.
+// Options holds the value of each option, with options split across several occurrences concatenated.
+type Message struct {
+	Op           byte
+	XID          TransactionID
+	Flags        uint16
+	ClientIP     net.IP
+	YourIP       net.IP
+	ServerIP     net.IP
+	RelayIP      net.IP
+	ClientHWAddr net.HardwareAddr
+	Options      map[byte][]byte
+}
+
+// Type returns the DHCP message type, or zero if the message has none.
+func (m *Message) Type() MessageType {
+	if v := m.Options[optMessageType]; len(v) == 1 {
+		return MessageType(v[0])
+	}
+	return 0
+}
+
+// Marshal encodes the message, padded to the minimum BOOTP message length.
+func (m *Message) Marshal() []byte {
+	var packet bytes.Buffer
+
+	packet.WriteByte(m.Op)
+	packet.WriteByte(htypeEthernet)
+	packet.WriteByte(hlenEthernet)
+	packet.WriteByte(0) // hops
+	packet.Write(m.XID[:])
+	_ = binary.Write(&packet, binary.BigEndian, uint16(0)) // secs
+	_ = binary.Write(&packet, binary.BigEndian, m.Flags)
+	for _, ip := range []net.IP{m.ClientIP, m.YourIP, m.ServerIP, m.RelayIP} {
+		packet.Write(ipv4Bytes(ip))
+	}
+	chaddr := make([]byte, chaddrLen)
+	copy(chaddr, m.ClientHWAddr)
+	packet.Write(chaddr)
+	packet.Write(make([]byte, snameLen+fileLen))
+	packet.Write(magicCookie)
+
+	// the message type goes first, the other options in order
+	codes := make([]int, 0, len(m.Options))
+	for code := range m.Options {
+		if code != optMessageType {
+			codes = append(codes, int(code))
+		}
+	}
+	sort.Ints(codes)
+	if v, ok := m.Options[optMessageType]; ok {
+		writeOption(&packet, optMessageType, v)
+	}
+	for _, code := range codes {
+		writeOption(&packet, byte(code), m.Options[byte(code)])
+	}
+	packet.WriteByte(optEnd)
+
+	if packet.Len() < bootpMinLen {
+		packet.Write(make([]byte, bootpMinLen-packet.Len()))
+	}
+	return packet.Bytes()
+}
+
+// writeOption writes the option, split into several occurrences if it is too long for one (RFC 3396).
+func writeOption(packet *bytes.Buffer, code byte, value []byte) {
+	for {
+		n := min(len(value), maxOptionLen)
+		packet.WriteByte(code)
+		packet.WriteByte(byte(n))
+		packet.Write(value[:n])
+		value = value[n:]
+		if len(value) == 0 {
+			return
+		}
+	}
+}
+
+func ipv4Bytes(ip net.IP) []byte {
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4
+	}
+	return make([]byte, bytesInAddress)
+}
+
+// ParseMessage decodes a DHCP message.
+func ParseMessage(b []byte) (*Message, error) {
+	if len(b) < bootpHeaderLen+len(magicCookie) {
+		return nil, errors.Errorf("message of %d bytes is too short", len(b))
+	}
+	if !bytes.Equal(b[bootpHeaderLen:bootpHeaderLen+len(magicCookie)], magicCookie) {
+		return nil, errors.New("message has no DHCP magic cookie")
+	}
+	hlen := int(b[2])
+	if hlen > chaddrLen {
+		return nil, errors.Errorf("invalid hardware address length %d", hlen)
+	}
+
+	m := &Message{
+		Op:           b[0],
+		Flags:        binary.BigEndian.Uint16(b[10:12]),
+		ClientIP:     net.IP(append([]byte(nil), b[12:16]...)),
+		YourIP:       net.IP(append([]byte(nil), b[16:20]...)),
+		ServerIP:     net.IP(append([]byte(nil), b[20:24]...)),
+		RelayIP:      net.IP(append([]byte(nil), b[24:28]...)),
+		ClientHWAddr: net.HardwareAddr(append([]byte(nil), b[28:28+hlen]...)),
+		Options:      map[byte][]byte{},
+	}
+	copy(m.XID[:], b[4:8])
+
+	options := b[bootpHeaderLen+len(magicCookie):]
+	for i := 0; i < len(options); {
+		code := options[i]
+		if code == optPad {
+			i++
+			continue
+		}
+		if code == optEnd {
+			break
+		}
+		if i+1 >= len(options) || i+2+int(options[i+1]) > len(options) {
+			return nil, errors.Errorf("option %d is truncated", code)
+		}
+		n := int(options[i+1])
+		m.Options[code] = append(m.Options[code], options[i+2:i+2+n]...)
+		i += 2 + n
+	}
+	return m, nil
+}
@@ -0,0 +1,102 @@
+package dhcp
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageRoundTrip(t *testing.T) {
+	m := &Message{
+		Op:           opRequest,
+		XID:          TransactionID{1, 2, 3, 4},
+		Flags:        flags,
+		ClientIP:     net.IPv4(10, 0, 0, 5),
+		ClientHWAddr: net.HardwareAddr{0, 0x0d, 0x3a, 1, 2, 3},
+		Options: map[byte][]byte{
+			optMessageType: {byte(MessageRequest)},
+			optServerID:    {10, 0, 0, 1},
+			// longer than a single option can hold
+			optClasslessRoutes: make([]byte, 300),
+		},
+	}
+	b := m.Marshal()
+	require.GreaterOrEqual(t, len(b), bootpMinLen)
+	// the message type is the first option
+	require.Equal(t, []byte{optMessageType, 1, byte(MessageRequest)}, b[bootpHeaderLen+4:bootpHeaderLen+7])
+
+	parsed, err := ParseMessage(b)
+	require.NoError(t, err)
+	require.Equal(t, MessageRequest, parsed.Type())
+	require.Equal(t, m.XID, parsed.XID)
+	require.Equal(t, m.Flags, parsed.Flags)
+	require.True(t, parsed.ClientIP.Equal(m.ClientIP))
+	require.True(t, parsed.YourIP.IsUnspecified())
+	require.Equal(t, m.ClientHWAddr, parsed.ClientHWAddr)
+	require.Equal(t, m.Options, parsed.Options)
+
+	_, err = ParseMessage(b[:100])
+	require.Error(t, err)
+	b[bootpHeaderLen] = 0
+	_, err = ParseMessage(b)
+	require.Error(t, err)
+}
+
+func TestNewLease(t *testing.T) {
+	acquired := time.Now()
+	ack := &Message{
+		YourIP: net.IPv4(10, 1, 2, 3),
+		Options: map[byte][]byte{
+			optMessageType: {byte(MessageAck)},
+			optSubnetMask:  {255, 255, 255, 0},
+			optRouter:      {10, 1, 2, 1},
+			optDNS:         {168, 63, 129, 16, 10, 1, 2, 1},
+			optMTU:         {0x05, 0xdc},
+			optServerID:    {10, 1, 2, 254},
+			optLeaseTime:   {0, 0, 0x0e, 0x10},
+		},
+	}
+
+	lease, err := newLease(ack, acquired)
+	require.NoError(t, err)
+	require.Equal(t, "10.1.2.3/24", lease.Address.String())
+	require.Equal(t, "10.1.2.1", lease.Router.String())
+	require.Len(t, lease.DNSServers, 2)
+	require.Equal(t, 1500, lease.MTU)
+	require.Equal(t, "10.1.2.254", lease.ServerID.String())
+	require.Equal(t, time.Hour, lease.LeaseTime)
+	require.Equal(t, acquired.Add(30*time.Minute), lease.RenewAt())
+	require.Equal(t, acquired.Add(time.Hour*7/8), lease.RebindAt())
+	require.Equal(t, acquired.Add(time.Hour), lease.ExpiresAt())
+
+	// classless static routes replace the router option
+	ack.Options[optClasslessRoutes] = []byte{
+		0, 10, 1, 2, 2, // default via 10.1.2.2
+		16, 169, 254, 0, 0, 0, 0, // 169.254.0.0/16 on the link
+		25, 192, 168, 1, 128, 10, 1, 2, 3, // 192.168.1.128/25 via 10.1.2.3
+	}
+	ack.Options[optRenewalTime] = []byte{0, 0, 0, 60}
+	ack.Options[optRebindingTime] = []byte{0, 0, 0, 120}
+	lease, err = newLease(ack, acquired)
+	require.NoError(t, err)
+	require.Equal(t, "10.1.2.2", lease.Router.String())
+	require.Len(t, lease.Routes, 3)
+	require.Equal(t, "0.0.0.0/0", lease.Routes[0].Destination.String())
+	require.Equal(t, "169.254.0.0/16", lease.Routes[1].Destination.String())
+	require.True(t, lease.Routes[1].Gateway.IsUnspecified())
+	require.Equal(t, "192.168.1.128/25", lease.Routes[2].Destination.String())
+	require.Equal(t, "10.1.2.3", lease.Routes[2].Gateway.String())
+	require.Equal(t, time.Minute, lease.RenewalTime)
+	require.Equal(t, 2*time.Minute, lease.RebindingTime)
+
+	ack.Options[optClasslessRoutes] = []byte{24, 10, 1}
+	_, err = newLease(ack, acquired)
+	require.Error(t, err)
+
+	delete(ack.Options, optClasslessRoutes)
+	delete(ack.Options, optLeaseTime)
+	_, err = newLease(ack, acquired)
+	require.Error(t, err)
+}
@@ -0,0 +1,61 @@
+package fs
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+)
+
+// WatchDebounce coalesces the burst of events an editor or a ConfigMap update produces for one change.
+const WatchDebounce = 250 * time.Millisecond
+
+// WatchFile calls onChange whenever the file at path is written or replaced.
+// The parent directory is watched rather than the file itself so that atomic
+// renames and Kubernetes ConfigMap or Secret symlink swaps are seen as well.
+// Errors reported by the underlying watcher are passed to onError.
+// Blocks until the context is closed.
+func WatchFile(ctx context.Context, path string, onChange func(), onError func(error)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errors.Wrap(err, "error creating fsnotify watcher")
+	}
+	defer watcher.Close()
+
+	path = filepath.Clean(path)
+	dir := filepath.Dir(path)
+	if err := watcher.Add(dir); err != nil {
+		return errors.Wrapf(err, "failed to add %s to fsnotify watcher", dir)
+	}
+
+	debounce := time.NewTimer(WatchDebounce)
+	if !debounce.Stop() {
+		<-debounce.C
+	}
+	defer debounce.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), "exiting WatchFile")
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return errors.New("fsnotify watcher closed")
+			}
+			if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) {
+				continue
+			}
+			// ConfigMap and Secret volumes swap the "..data" symlink to publish a new version of every key.
+			if filepath.Clean(event.Name) != path && filepath.Base(event.Name) != "..data" {
+				continue
+			}
+			debounce.Reset(WatchDebounce)
+		case <-debounce.C:
+			onChange()
+		case watcherErr := <-watcher.Errors:
+			onError(watcherErr)
+		}
+	}
+}
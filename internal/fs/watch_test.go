@@ -0,0 +1,49 @@
+package fs_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-container-networking/internal/fs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cns_config.json")
+	require.NoError(t, os.WriteFile(path, []byte("{}"), 0o600))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changed := make(chan struct{}, 1)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- fs.WatchFile(ctx, path, func() { changed <- struct{}{} }, func(err error) { t.Log(err) })
+	}()
+	// give the watcher time to register the directory
+	time.Sleep(100 * time.Millisecond)
+
+	// unrelated files in the directory are ignored
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other.json"), []byte("{}"), 0o600))
+	// an atomic replace of the watched file is seen
+	tmp := filepath.Join(dir, "cns_config.json.tmp")
+	require.NoError(t, os.WriteFile(tmp, []byte(`{"EnablePprof":true}`), 0o600))
+	require.NoError(t, os.Rename(tmp, path))
+
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for change")
+	}
+	select {
+	case <-changed:
+		t.Fatal("expected a single debounced change")
+	case <-time.After(2 * fs.WatchDebounce):
+	}
+
+	cancel()
+	require.ErrorIs(t, <-errCh, context.Canceled)
+}
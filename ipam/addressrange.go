@@ -0,0 +1,119 @@
+// Copyright 2017 Microsoft. All rights reserved.
+// MIT License
+
+package ipam
+
+import (
+	"encoding/binary"
+	"math"
+	"net"
+	"net/netip"
+)
+
+// Represents a contiguous range of addresses in an address pool.
+// Unlike the enumerated addresses of a pool, an address in the range only has a record
+// while it is allocated, so a range as large as a /64 takes no more state than its allocations.
+type addressRange struct {
+	First netip.Addr
+	Last  netip.Addr
+	// Next is where the search for an available address starts, so that
+	// released addresses are not immediately handed out again.
+	Next netip.Addr
+}
+
+// Creates a new addressRange covering the subnet, except for its first reserved addresses.
+func newAddressRange(subnet *net.IPNet, reserved int) (*addressRange, error) {
+	prefix, ok := netip.AddrFromSlice(subnet.IP)
+	if !ok {
+		return nil, errInvalidAddress
+	}
+	ones, _ := subnet.Mask.Size()
+	p := netip.PrefixFrom(prefix.Unmap(), ones).Masked()
+
+	first := p.Addr()
+	for i := 0; i < reserved; i++ {
+		first = first.Next()
+	}
+
+	b := p.Addr().As16()
+	hostBits := p.Addr().BitLen() - p.Bits()
+	for i := len(b) - 1; hostBits > 0; i-- {
+		n := min(hostBits, 8) //nolint:gomnd // bits in a byte
+		b[i] |= byte(1<<n - 1)
+		hostBits -= n
+	}
+	last := netip.AddrFrom16(b)
+	if p.Addr().Is4() {
+		last = last.Unmap()
+	}
+
+	if !first.IsValid() || !p.Contains(first) || last.Less(first) {
+		return nil, errInvalidAddress
+	}
+
+	return &addressRange{
+		First: first,
+		Last:  last,
+		Next:  first,
+	}, nil
+}
+
+// Returns if the address is in the range.
+func (r *addressRange) contains(addr netip.Addr) bool {
+	return addr.BitLen() == r.First.BitLen() && r.First.Compare(addr) <= 0 && addr.Compare(r.Last) <= 0
+}
+
+// Returns if the net.IP is in the range.
+func (r *addressRange) containsIP(ip net.IP) bool {
+	addr, ok := netip.AddrFromSlice(ip)
+	return ok && r.contains(addr.Unmap())
+}
+
+// Returns the number of addresses in the range, saturating at math.MaxUint64.
+func (r *addressRange) size() uint64 {
+	first, last := r.First.As16(), r.Last.As16()
+	firstHi, firstLo := binary.BigEndian.Uint64(first[:8]), binary.BigEndian.Uint64(first[8:])
+	lastHi, lastLo := binary.BigEndian.Uint64(last[:8]), binary.BigEndian.Uint64(last[8:])
+
+	hi := lastHi - firstHi
+	if lastLo < firstLo {
+		hi--
+	}
+	lo := lastLo - firstLo
+	if hi > 0 || lo == math.MaxUint64 {
+		return math.MaxUint64
+	}
+	return lo + 1
+}
+
+// Returns the address following addr, wrapping around to the start of the range.
+func (r *addressRange) following(addr netip.Addr) netip.Addr {
+	if addr == r.Last {
+		return r.First
+	}
+	return addr.Next()
+}
+
+// Returns the next address in the range which is not in use, starting the search at Next.
+// allocated is the number of addresses in use, which bounds how far the search has to go.
+func (r *addressRange) nextAvailable(inUse func(netip.Addr) bool, allocated int) (netip.Addr, bool) {
+	addr := r.Next
+	if !r.contains(addr) {
+		addr = r.First
+	}
+
+	limit := r.size()
+	if uint64(allocated) < limit {
+		limit = uint64(allocated) + 1
+	}
+
+	for i := uint64(0); i < limit; i++ {
+		if !inUse(addr) {
+			r.Next = r.following(addr)
+			return addr, true
+		}
+		addr = r.following(addr)
+	}
+
+	return netip.Addr{}, false
+}
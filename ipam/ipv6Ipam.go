@@ -3,19 +3,26 @@ package ipam
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net"
 	"os"
 	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/Azure/azure-container-networking/common"
 	"github.com/Masterminds/semver"
 	"go.uber.org/zap"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
@@ -26,9 +33,11 @@ const (
 	k8sMajorVerForNewPolicyDef       = "1"
 	k8sMinorVerForNewPolicyDef       = "16"
 
-	// by default, Kubernetes node is allocated a /64 for each node. That's too much state to preserve,
-	// so instead we take the first /120 of that /64 as usable IP's.
+	// by default, Kubernetes node is allocated a /64 for each node. Addresses are only recorded once
+	// allocated, but the first /120 of that /64 stays the usable range so that existing pools keep their
+	// subnet; a wider pool would hand out again the addresses held in the persisted /120 pool.
 	defaultIPv6SubnetMaskSizeLimit = "/120"
+	nodeInformerSyncTimeout        = 30 * time.Second
 	lessThan                       = -1
 	equalTo                        = 0
 	greaterThan                    = 1
@@ -44,9 +53,12 @@ type ipv6IpamSource struct {
 	subnetMaskSizeLimit string
 	kubeConfigPath      string
 	kubeClient          kubernetes.Interface
-	kubeNode            *v1.Node
+	informerStop        chan struct{}
+	subnet              *net.IPNet
+	appliedSubnet       string
 	isLoaded            bool
 	sink                addressConfigSink
+	sync.Mutex
 }
 
 // creates a new IPv6 Ipam source
@@ -60,6 +72,16 @@ func newIPv6IpamSource(options map[string]interface{}, isLoaded bool) (*ipv6Ipam
 		kubeConfigPath = defaultLinuxKubeConfigFilePath
 	}
 
+	// the limit can be widened up to the prefix length of the node subnet, such as "/64" to use it whole, on
+	// nodes without a persisted pool
+	subnetMaskSizeLimit, _ := options[common.OptIpamSubnetMaskSizeLimit].(string)
+	if subnetMaskSizeLimit == "" {
+		subnetMaskSizeLimit = defaultIPv6SubnetMaskSizeLimit
+	}
+	if err := validateSubnetMaskSizeLimit(subnetMaskSizeLimit); err != nil {
+		return nil, err
+	}
+
 	nodeName, err := os.Hostname()
 	if err != nil {
 		return nil, err
@@ -67,13 +89,22 @@ func newIPv6IpamSource(options map[string]interface{}, isLoaded bool) (*ipv6Ipam
 
 	return &ipv6IpamSource{
 		name:                name,
-		subnetMaskSizeLimit: defaultIPv6SubnetMaskSizeLimit,
+		subnetMaskSizeLimit: subnetMaskSizeLimit,
 		nodeHostname:        strings.ToLower(nodeName),
 		kubeConfigPath:      kubeConfigPath,
 		isLoaded:            isLoaded,
 	}, nil
 }
 
+// validates that the subnet mask size limit is an IPv6 prefix length such as "/120".
+func validateSubnetMaskSizeLimit(limit string) error {
+	size, err := strconv.Atoi(strings.TrimPrefix(limit, "/"))
+	if !strings.HasPrefix(limit, "/") || err != nil || size < 0 || size > 128 {
+		return fmt.Errorf("invalid IPv6 subnet mask size limit %q, expected a prefix length such as \"/120\"", limit)
+	}
+	return nil
+}
+
 // Starts the MAS source.
 func (source *ipv6IpamSource) start(sink addressConfigSink) error {
 	source.sink = sink
@@ -82,6 +113,10 @@ func (source *ipv6IpamSource) start(sink addressConfigSink) error {
 
 // Stops the MAS source.
 func (source *ipv6IpamSource) stop() {
+	if source.informerStop != nil {
+		close(source.informerStop)
+		source.informerStop = nil
+	}
 	source.sink = nil
 }
 
@@ -152,36 +187,26 @@ func (source *ipv6IpamSource) refresh() error {
 		return errors.New("ipv6ipam is nil")
 	}
 
-	if source.isLoaded {
-		logger.Info("ipv6 source already loaded")
-		return nil
-	}
-
-	if source.kubeClient == nil {
-		kubeClient, err := source.loadKubernetesConfig()
-		if err != nil {
-			logger.Error("Failed to load Kubernetes config", zap.Error(err))
+	if source.informerStop == nil {
+		if err := source.watchNode(); err != nil {
+			logger.Error("Failed to watch Kubernetes node", zap.Error(err))
 			return err
 		}
-
-		source.kubeClient = kubeClient
 	}
 
-	kubeNode, err := source.kubeClient.CoreV1().Nodes().Get(context.TODO(), source.nodeHostname, metav1.GetOptions{})
-	if err != nil {
-		logger.Error("Failed to retrieve node using", zap.String("hostName", source.nodeHostname), zap.Error(err))
-		return err
+	subnet := source.nodeSubnet()
+	if subnet == nil {
+		if source.isLoaded {
+			// keep the restored address space until the node is seen with an IPv6 subnet
+			return nil
+		}
+		return errors.New("[ipam] Failed to retrieve subnet, node does not have an IPv6 subnet allocated from Kubernetes")
 	}
 
-	source.kubeNode = kubeNode
-	logger.Info("Discovered", zap.Any("CIDR's", source.kubeNode.Spec.PodCIDRs))
-
-	// Query the list of Kubernetes Pod IPs
-	interfaceIPs, err := retrieveKubernetesPodIPs(source.kubeNode, source.subnetMaskSizeLimit)
-	if err != nil {
-		logger.Error("Failed retrieve Kubernetes IP's from subnet", zap.Error(err))
-		return err
+	if subnet.String() == source.appliedSubnet {
+		return nil
 	}
+	logger.Info("Discovered", zap.String("subnet", subnet.String()))
 
 	// Configure the local default address space.
 	local, err := source.sink.newAddressSpace(LocalDefaultAddressSpaceId, LocalScope)
@@ -190,22 +215,12 @@ func (source *ipv6IpamSource) refresh() error {
 		return err
 	}
 
-	for _, i := range interfaceIPs.Interfaces {
-		for _, s := range i.IPSubnets {
-			_, subnet, err := net.ParseCIDR(s.Prefix)
-			ifaceName := ""
-			priority := 0
-			ap, err := local.newAddressPool(ifaceName, priority, subnet)
-
-			for _, a := range s.IPAddresses {
-				address := net.ParseIP(a.Address)
-				_, err = ap.newAddressRecord(&address)
-				if err != nil {
-					logger.Error("Failed to create", zap.Any("address", address), zap.Error(err))
-					continue
-				}
-			}
-		}
+	// The pool covers the whole subnet, with address records created as addresses are allocated.
+	ifaceName := ""
+	priority := 0
+	if _, err = local.newSparseAddressPool(ifaceName, priority, subnet); err != nil {
+		logger.Error("Failed to create address pool", zap.String("subnet", subnet.String()), zap.Error(err))
+		return err
 	}
 
 	// Set the local address space as active.
@@ -213,87 +228,102 @@ func (source *ipv6IpamSource) refresh() error {
 		return err
 	}
 
+	source.appliedSubnet = subnet.String()
 	source.isLoaded = true
 	logger.Info("Address space successfully populated from Kubernetes API Server")
 
-	return err
+	return nil
 }
 
-// retrieves the allocated pod IP's, and populates the NetworkInterfaces struture
-func retrieveKubernetesPodIPs(node *v1.Node, subnetMaskBitSize string) (*NetworkInterfaces, error) {
-	var nodeCidr net.IP
-	var ipnetv6 *net.IPNet
-
-	// get IPv6 subnet allocated to node
-	for _, cidr := range node.Spec.PodCIDRs {
-		ipv6cidr, _, _ := net.ParseCIDR(cidr)
-		if ipv6cidr.To4() == nil {
-			nodeCidr = ipv6cidr
-			break
+// starts an informer on the Kubernetes node, which keeps the node's IPv6 subnet up to date.
+// When no address space was restored, waits for the node to be seen so that the first refresh can use it.
+func (source *ipv6IpamSource) watchNode() error {
+	if source.kubeClient == nil {
+		kubeClient, err := source.loadKubernetesConfig()
+		if err != nil {
+			logger.Error("Failed to load Kubernetes config", zap.Error(err))
+			return err
 		}
-	}
 
-	if nodeCidr == nil {
-		return nil, errors.New("[ipam] Failed to retrieve subnet, node does an IPv6 subnet allocated from Kubernetes")
+		source.kubeClient = kubeClient
 	}
 
-	subnet := nodeCidr.String() + subnetMaskBitSize
-	nodeCidr, ipnetv6, err := net.ParseCIDR(subnet)
+	factory := informers.NewSharedInformerFactoryWithOptions(source.kubeClient, 0,
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.FieldSelector = fields.OneTermEqualSelector("metadata.name", source.nodeHostname).String()
+		}))
+	informer := factory.Core().V1().Nodes().Informer()
+	registration, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    source.onNode,
+		UpdateFunc: func(_, obj interface{}) { source.onNode(obj) },
+	})
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	addresses := getIPsFromAddresses(nodeCidr, ipnetv6)
-	networkSubnet := IPSubnet{
-		Prefix: subnet,
-	}
+	source.informerStop = make(chan struct{})
+	factory.Start(source.informerStop)
 
-	// skip the first address, explicitly save all IP's from the given subnet
-	for i := 2; i < len(addresses); i++ {
-		ipaddress := IPAddress{
-			IsPrimary: false,
-			Address:   addresses[i].String(),
+	if !source.isLoaded {
+		ctx, cancel := context.WithTimeout(context.Background(), nodeInformerSyncTimeout)
+		defer cancel()
+		if !cache.WaitForCacheSync(ctx.Done(), registration.HasSynced) {
+			return errors.New("timed out waiting for the Kubernetes node")
 		}
-		networkSubnet.IPAddresses = append(networkSubnet.IPAddresses, ipaddress)
 	}
 
-	return &NetworkInterfaces{
-		Interfaces: []Interface{
-			{
-				IsPrimary: true,
-				IPSubnets: []IPSubnet{
-					networkSubnet,
-				},
-			},
-		},
-	}, nil
+	return nil
 }
 
-// retrieves all IP's from a given subnet
-func getIPsFromAddresses(ip net.IP, ipnet *net.IPNet) []net.IP {
-	ips := make([]net.IP, 0)
-	for ip := ip.Mask(ipnet.Mask); ipnet.Contains(ip); incrementIP(ip) {
-		ips = append(ips, duplicateIP(ip))
+// records the IPv6 subnet of the node from an informer event.
+func (source *ipv6IpamSource) onNode(obj interface{}) {
+	node, ok := obj.(*v1.Node)
+	if !ok || node.Name != source.nodeHostname {
+		return
+	}
+
+	subnet, err := retrieveNodeIPv6Subnet(node, source.subnetMaskSizeLimit)
+	if err != nil {
+		logger.Info("Node has no usable IPv6 subnet", zap.Any("CIDR's", node.Spec.PodCIDRs), zap.Error(err))
 	}
 
-	return ips
+	source.Lock()
+	defer source.Unlock()
+	source.subnet = subnet
 }
 
-// increment the IP by one
-func incrementIP(ip net.IP) {
-	for j := len(ip) - 1; j >= 0; j-- {
-		ip[j]++
-		if ip[j] > 0 {
+// returns the last seen IPv6 subnet of the node, or nil if it has none.
+func (source *ipv6IpamSource) nodeSubnet() *net.IPNet {
+	source.Lock()
+	defer source.Unlock()
+	return source.subnet
+}
+
+// retrieves the IPv6 subnet allocated to the node, limited to subnetMaskBitSize if it is set.
+func retrieveNodeIPv6Subnet(node *v1.Node, subnetMaskBitSize string) (*net.IPNet, error) {
+	var nodeCidr *net.IPNet
+
+	// get IPv6 subnet allocated to node
+	for _, cidr := range node.Spec.PodCIDRs {
+		ip, ipnet, err := net.ParseCIDR(cidr)
+		if err == nil && ip.To4() == nil {
+			nodeCidr = ipnet
 			break
 		}
 	}
-}
 
-// Create a copy of a net.IP, used to populate an slice of IP's in a subnet
-// net.IP is slice of bytes, slices are reference types, when calculating every
-// ip in a subnet, need to save current net.IP slice in it's own memory.
-func duplicateIP(ip net.IP) net.IP {
-	dup := make(net.IP, len(ip))
-	copy(dup, ip)
-	return dup
+	if nodeCidr == nil {
+		return nil, errors.New("[ipam] Failed to retrieve subnet, node does an IPv6 subnet allocated from Kubernetes")
+	}
+
+	if subnetMaskBitSize == "" {
+		return nodeCidr, nil
+	}
+
+	_, subnet, err := net.ParseCIDR(nodeCidr.IP.String() + subnetMaskBitSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return subnet, nil
 }
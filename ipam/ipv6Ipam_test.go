@@ -5,7 +5,7 @@ package ipam
 
 import (
 	"context"
-	"reflect"
+	"math"
 	"runtime"
 	"testing"
 
@@ -62,6 +62,30 @@ var _ = Describe("Test ipv6Ipam", func() {
 				Expect(ipv6IpamSource.name).To(Equal(name))
 				Expect(ipv6IpamSource.kubeConfigPath).To(Equal(kubeConfigPath))
 				Expect(ipv6IpamSource.isLoaded).To(Equal(isLoaded))
+				Expect(ipv6IpamSource.subnetMaskSizeLimit).To(Equal(defaultIPv6SubnetMaskSizeLimit))
+			})
+		})
+
+		Context("When creating with a subnet mask size limit", func() {
+			It("Should limit the node subnet to it", func() {
+				options := map[string]interface{}{
+					common.OptEnvironment:             common.OptEnvironmentIPv6NodeIpam,
+					common.OptIpamSubnetMaskSizeLimit: "/64",
+				}
+				ipv6IpamSource, err := newIPv6IpamSource(options, false)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ipv6IpamSource.subnetMaskSizeLimit).To(Equal("/64"))
+			})
+
+			It("Should fail if the limit is not a prefix length", func() {
+				for _, limit := range []string{"120", "/129", "/abc"} {
+					options := map[string]interface{}{
+						common.OptEnvironment:             common.OptEnvironmentIPv6NodeIpam,
+						common.OptIpamSubnetMaskSizeLimit: limit,
+					}
+					_, err := newIPv6IpamSource(options, false)
+					Expect(err).To(HaveOccurred())
+				}
 			})
 		})
 	})
@@ -86,37 +110,62 @@ var _ = Describe("Test ipv6Ipam", func() {
 		})
 	})
 
+	Describe("Test refresh", func() {
+		Context("When the node subnet changes", func() {
+			It("Should populate the whole /64 and follow the node through the informer", func() {
+				am, err := NewAddressManager()
+				Expect(err).NotTo(HaveOccurred())
+				sink := am.(*addressManager)
+				client := newKubernetesTestClient()
+				source := &ipv6IpamSource{
+					nodeHostname: testNodeName,
+					kubeClient:   client,
+				}
+				Expect(source.start(sink)).To(Succeed())
+				defer source.stop()
+
+				Expect(source.refresh()).To(Succeed())
+				pool := sink.AddrSpaces[LocalDefaultAddressSpaceId].Pools["ace:cab:deca:deed::/64"]
+				Expect(pool).NotTo(BeNil())
+				Expect(pool.Addresses).To(BeEmpty())
+				Expect(pool.getInfo().Capacity).To(Equal(math.MaxInt))
+
+				node, err := client.CoreV1().Nodes().Get(context.TODO(), testNodeName, metav1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				node.Spec.PodCIDRs = []string{"10.0.0.1/24", "ace:cab:deca:beef::/64"}
+				_, err = client.CoreV1().Nodes().Update(context.TODO(), node, metav1.UpdateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(func() string {
+					Expect(source.refresh()).To(Succeed())
+					return source.appliedSubnet
+				}).Should(Equal("ace:cab:deca:beef::/64"))
+				Expect(sink.AddrSpaces[LocalDefaultAddressSpaceId].Pools).To(HaveKey("ace:cab:deca:beef::/64"))
+				Expect(sink.AddrSpaces[LocalDefaultAddressSpaceId].Pools).NotTo(HaveKey("ace:cab:deca:deed::/64"))
+			})
+		})
+	})
+
 	Describe("TestIPv6Ipam", func() {
 		Context("When node have IPv6 subnet", func() {
-			It("Carve addresses successfully and Network interface match", func() {
+			It("Retrieve the node subnet limited to the subnet mask size", func() {
 				options := make(map[string]interface{})
 				options[common.OptEnvironment] = common.OptEnvironmentIPv6NodeIpam
 
 				client := newKubernetesTestClient()
 				node, _ := client.CoreV1().Nodes().Get(context.TODO(), testNodeName, metav1.GetOptions{})
 
-				testInterfaces, err := retrieveKubernetesPodIPs(node, testSubnetSize)
+				subnet, err := retrieveNodeIPv6Subnet(node, testSubnetSize)
 				Expect(err).NotTo(HaveOccurred())
+				Expect(subnet.String()).To(Equal("ace:cab:deca:deed::/126"))
 
-				correctInterfaces := &NetworkInterfaces{
-					Interfaces: []Interface{
-						{
-							IsPrimary: true,
-							IPSubnets: []IPSubnet{
-								{
-									Prefix: "ace:cab:deca:deed::/126",
-									IPAddresses: []IPAddress{
-										{Address: "ace:cab:deca:deed::2", IsPrimary: false},
-										{Address: "ace:cab:deca:deed::3", IsPrimary: false},
-									},
-								},
-							},
-						},
-					},
-				}
+				subnet, err = retrieveNodeIPv6Subnet(node, defaultIPv6SubnetMaskSizeLimit)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(subnet.String()).To(Equal("ace:cab:deca:deed::/120"))
 
-				equal := reflect.DeepEqual(testInterfaces, correctInterfaces)
-				Expect(equal).To(BeTrue())
+				subnet, err = retrieveNodeIPv6Subnet(node, "")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(subnet.String()).To(Equal("ace:cab:deca:deed::/64"))
 			})
 		})
 
@@ -135,7 +184,7 @@ var _ = Describe("Test ipv6Ipam", func() {
 					},
 				}
 
-				_, err := retrieveKubernetesPodIPs(testnode, testSubnetSize)
+				_, err := retrieveNodeIPv6Subnet(testnode, testSubnetSize)
 				Expect(err).To(HaveOccurred())
 			})
 		})
@@ -4,11 +4,16 @@
 package ipam
 
 import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
 	"sync"
 	"time"
 
 	"github.com/Azure/azure-container-networking/common"
 	"github.com/Azure/azure-container-networking/platform"
+	"github.com/Azure/azure-container-networking/probe"
 	"github.com/Azure/azure-container-networking/store"
 	"go.uber.org/zap"
 )
@@ -16,6 +21,11 @@ import (
 const (
 	// IPAM store key.
 	storeKey = "IPAM"
+
+	// How long an address found in use by another host is kept from being handed out.
+	conflictQuarantineDuration = 10 * time.Minute
+	// How many conflicting addresses a request skips before it fails.
+	maxConflictRetries = 3
 )
 
 // AddressManager manages the set of address spaces and pools allocated to containers.
@@ -26,9 +36,19 @@ type addressManager struct {
 	store      store.KeyValueStore
 	source     addressConfigSource
 	netApi     common.NetApi
+	prober     addressProber
+	reporter   ConflictReporter
 	sync.Mutex
 }
 
+// addressProber probes the link for another host using an address.
+type addressProber interface {
+	Probe(ctx context.Context, ifName string, addr netip.Addr) error
+}
+
+// ConflictReporter is called with every address quarantined after another host was found using it.
+type ConflictReporter func(address string, conflict error)
+
 // AddressManager API.
 type AddressManager interface {
 	Initialize(config *common.PluginConfig, rehydrateIpamInfoOnReboot bool, options map[string]interface{}) error
@@ -45,6 +65,8 @@ type AddressManager interface {
 
 	RequestAddress(asId, poolId, address string, options map[string]string) (string, error)
 	ReleaseAddress(asId, poolId, address string, options map[string]string) error
+
+	SetConflictReporter(reporter ConflictReporter)
 }
 
 // AddressConfigSource configures the address pools managed by AddressManager.
@@ -145,8 +167,13 @@ func (am *addressManager) restore(rehydrateIpamInfoOnReboot bool) error {
 						ap.as = as
 						ap.RefCount = 0
 
-						for _, ar := range ap.Addresses {
+						for k, ar := range ap.Addresses {
 							ar.InUse = false
+
+							// Records in a range only exist while allocated or quarantined.
+							if ap.Range != nil && ar.ID == "" && !ar.isQuarantined() && ap.Range.containsIP(ar.Addr) {
+								delete(ap.Addresses, k)
+							}
 						}
 					}
 				}
@@ -160,6 +187,7 @@ func (am *addressManager) restore(rehydrateIpamInfoOnReboot bool) error {
 }
 
 // Save writes address manager state to persistent store.
+// Pools with an address range only hold records for their allocated and quarantined addresses, so those are all that is persisted of them.
 func (am *addressManager) save() error {
 	// Skip if a store is not provided.
 	if am.store == nil {
@@ -215,6 +243,12 @@ func (am *addressManager) StartSource(options map[string]interface{}) error {
 		return errInvalidConfiguration
 	}
 
+	if probeConflicts, _ := options[common.OptIpamConflictProbe].(bool); probeConflicts {
+		am.prober = probe.New(logger, 0, 0)
+	} else {
+		am.prober = nil
+	}
+
 	if am.source != nil {
 		logger.Info("Starting source", zap.String("environment", environment))
 		err = am.source.start(am)
@@ -342,8 +376,32 @@ func (am *addressManager) GetPoolInfo(asId string, poolId string) (*AddressPoolI
 	return ap.getInfo(), nil
 }
 
-// RequestAddress reserves a new address from the address pool.
+// RequestAddress reserves a new address from the address pool. If conflict probing is enabled, the link is probed for
+// another host using the address once it is reserved, without holding the lock. Addresses found in use are quarantined
+// and another one is requested, unless a specific address was asked for.
 func (am *addressManager) RequestAddress(asId, poolId, address string, options map[string]string) (string, error) {
+	for attempt := 1; ; attempt++ {
+		addr, ifName, probed, err := am.reserveAddress(asId, poolId, address, options)
+		if err != nil || !probed {
+			return addr, err
+		}
+
+		conflict := am.probeAddress(ifName, addr)
+		if conflict == nil {
+			return addr, nil
+		}
+
+		am.quarantineAddress(asId, poolId, addr)
+		am.reportConflict(addr, conflict)
+		if address != "" || attempt >= maxConflictRetries {
+			return "", conflict
+		}
+	}
+}
+
+// Reserves an address from the pool, returning the interface of the pool and whether the address is to be probed.
+// An address already held by the ID was probed when it was first handed out.
+func (am *addressManager) reserveAddress(asId, poolId, address string, options map[string]string) (string, string, bool, error) {
 	am.Lock()
 	defer am.Unlock()
 
@@ -351,26 +409,99 @@ func (am *addressManager) RequestAddress(asId, poolId, address string, options m
 
 	as, err := am.getAddressSpace(asId)
 	if err != nil {
-		return "", err
+		return "", "", false, err
 	}
 
 	ap, err := as.getAddressPool(poolId)
 	if err != nil {
-		return "", err
+		return "", "", false, err
 	}
 
+	id := options[OptAddressID]
+	probed := am.prober != nil && options[OptAddressType] != OptAddressTypeGateway && (id == "" || ap.addrsByID[id] == nil)
+
 	addr, err := ap.requestAddress(address, options)
 	if err != nil {
-		return "", err
+		return "", "", false, err
 	}
 
 	err = am.save()
 	if err != nil {
 		ap.releaseAddress(addr, options)
-		return "", err
+		return "", "", false, err
+	}
+
+	return addr, ap.IfName, probed, nil
+}
+
+// Probes the link for another host using the address, returning an error only for a conflict.
+func (am *addressManager) probeAddress(ifName, address string) error {
+	prefix, err := netip.ParsePrefix(address)
+	if err != nil {
+		return nil
 	}
 
-	return addr, nil
+	if ifName == "" {
+		var ok bool
+		if ifName, ok = probe.InterfaceFor(prefix.Addr()); !ok {
+			return nil
+		}
+	}
+
+	err = am.prober.Probe(context.Background(), ifName, prefix.Addr())
+	if errors.Is(err, probe.ErrConflict) {
+		logger.Error("Address is in use by another host", zap.String("address", address), zap.Error(err))
+		return err
+	}
+	if err != nil {
+		// Failing to probe does not keep the address from being handed out.
+		logger.Warn("Failed to probe address", zap.String("address", address), zap.String("interface", ifName), zap.Error(err))
+	}
+	return nil
+}
+
+// Quarantines an address found in use by another host, releasing it from the request.
+func (am *addressManager) quarantineAddress(asId, poolId, address string) {
+	am.Lock()
+	defer am.Unlock()
+
+	ip, _, _ := net.ParseCIDR(address)
+	if ip == nil {
+		return
+	}
+
+	// The pool may have been removed while the address was probed, releasing it.
+	as, err := am.getAddressSpace(asId)
+	if err != nil {
+		return
+	}
+	ap, err := as.getAddressPool(poolId)
+	if err != nil {
+		return
+	}
+
+	ap.quarantineAddress(ip.String(), conflictQuarantineDuration)
+	if err := am.save(); err != nil {
+		logger.Error("Failed to save quarantined address", zap.String("address", address), zap.Error(err))
+	}
+}
+
+// Reports an address quarantined after another host was found using it, without holding the lock.
+func (am *addressManager) reportConflict(address string, conflict error) {
+	am.Lock()
+	reporter := am.reporter
+	am.Unlock()
+
+	if reporter != nil {
+		reporter(address, conflict)
+	}
+}
+
+// SetConflictReporter sets the reporter called with every address quarantined after another host was found using it.
+func (am *addressManager) SetConflictReporter(reporter ConflictReporter) {
+	am.Lock()
+	defer am.Unlock()
+	am.reporter = reporter
 }
 
 // ReleaseAddress releases a previously reserved address.
@@ -4,9 +4,11 @@
 package ipam
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net"
+	"net/netip"
 	"testing"
 	"time"
 
@@ -15,6 +17,7 @@ import (
 
 	"github.com/Azure/azure-container-networking/common"
 	"github.com/Azure/azure-container-networking/platform"
+	"github.com/Azure/azure-container-networking/probe"
 	"github.com/Azure/azure-container-networking/store"
 	"github.com/Azure/azure-container-networking/testutils"
 )
@@ -387,4 +390,58 @@ var _ = Describe("Test Manager", func() {
 			})
 		})
 	})
+
+	Describe("Test RequestAddress", func() {
+		Context("When the probed address is in use by another host", func() {
+			It("Should quarantine and report it, and probe without holding the lock", func() {
+				am, err := createAddressManager(nil)
+				Expect(err).ToNot(HaveOccurred())
+				amImpl := am.(*addressManager)
+				prober := &fakeProber{am: amImpl, conflicts: 1}
+				amImpl.prober = prober
+				var reported []string
+				am.SetConflictReporter(func(address string, _ error) {
+					reported = append(reported, address)
+				})
+
+				addr, err := am.RequestAddress(LocalDefaultAddressSpaceId, subnet2.String(), "", nil)
+				// the only address of the pool is quarantined, so none is left
+				Expect(err).To(HaveOccurred())
+				Expect(addr).To(BeEmpty())
+				Expect(prober.probed).To(Equal([]string{addr21.String()}))
+				Expect(prober.lockHeld).To(BeFalse())
+				Expect(reported).To(Equal([]string{addr21.String() + "/24"}))
+				Expect(amImpl.AddrSpaces[LocalDefaultAddressSpaceId].Pools[subnet2.String()].Addresses[addr21.String()].isQuarantined()).To(BeTrue())
+
+				prober.conflicts = 1
+				addr, err = am.RequestAddress(LocalDefaultAddressSpaceId, subnet1.String(), "", nil)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(prober.probed).To(HaveLen(3))
+				Expect(addr).To(Equal(prober.probed[2] + "/24"))
+				Expect(reported).To(Equal([]string{addr21.String() + "/24", prober.probed[1] + "/24"}))
+			})
+		})
+	})
 })
+
+// fakeProber reports the first conflicts addresses it probes as in use.
+type fakeProber struct {
+	am        *addressManager
+	conflicts int
+	probed    []string
+	lockHeld  bool
+}
+
+func (p *fakeProber) Probe(_ context.Context, _ string, addr netip.Addr) error {
+	if p.am.TryLock() {
+		p.am.Unlock()
+	} else {
+		p.lockHeld = true
+	}
+	p.probed = append(p.probed, addr.String())
+	if p.conflicts > 0 {
+		p.conflicts--
+		return &probe.ConflictError{Addr: addr}
+	}
+	return nil
+}
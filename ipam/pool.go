@@ -5,8 +5,11 @@ package ipam
 
 import (
 	"fmt"
+	"math"
 	"net"
+	"net/netip"
 	"strings"
+	"time"
 
 	"github.com/Azure/azure-container-networking/platform"
 	"go.uber.org/zap"
@@ -57,6 +60,7 @@ type addressPool struct {
 	Subnet    net.IPNet
 	Gateway   net.IP
 	Addresses map[string]*addressRecord
+	Range     *addressRange `json:",omitempty"`
 	addrsByID map[string]*addressRecord
 	IsIPv6    bool
 	Priority  int
@@ -77,11 +81,14 @@ type AddressPoolInfo struct {
 
 // Represents an IP address in a pool.
 type addressRecord struct {
-	ID        string
-	Addr      net.IP
-	InUse     bool
-	unhealthy bool
-	epoch     int
+	ID    string
+	Addr  net.IP
+	InUse bool
+	// QuarantinedUntil is set when the address was found in use by another host on the link,
+	// and it is not handed out again until then.
+	QuarantinedUntil time.Time
+	unhealthy        bool
+	epoch            int
 }
 
 //
@@ -197,6 +204,17 @@ func (as *addressSpace) merge(newas *addressSpace) {
 			pv.epoch = as.epoch
 		} else {
 			// This pool already exists.
+			// Its range, if any, is refreshed as a whole.
+			if pv.Range != nil {
+				if ap.Range == nil || ap.Range.First != pv.Range.First || ap.Range.Last != pv.Range.Last {
+					ap.Range = pv.Range
+				}
+				ap.epoch = as.epoch
+				for _, av := range ap.Addresses {
+					av.epoch = as.epoch
+				}
+			}
+
 			// Compare address records one by one.
 			for ak, av := range pv.Addresses {
 				ar := ap.Addresses[ak]
@@ -278,6 +296,23 @@ func (as *addressSpace) newAddressPool(ifName string, priority int, subnet *net.
 	return pool, nil
 }
 
+// Creates a new addressPool object whose addresses are a range covering the subnet
+// rather than enumerated records. The subnet address and the gateway are excluded from the range.
+func (as *addressSpace) newSparseAddressPool(ifName string, priority int, subnet *net.IPNet) (*addressPool, error) {
+	ar, err := newAddressRange(subnet, 2) //nolint:gomnd // the subnet address and the gateway
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := as.newAddressPool(ifName, priority, subnet)
+	if err != nil {
+		return pool, err
+	}
+	pool.Range = ar
+
+	return pool, nil
+}
+
 // Returns the address pool with the given pool ID.
 func (as *addressSpace) getAddressPool(poolId string) (*addressPool, error) {
 	ap := as.Pools[poolId]
@@ -347,7 +382,7 @@ func (as *addressSpace) requestPool(poolId string, subPoolId string, options map
 			}
 
 			// Prefer the pool with the highest number of addresses.
-			if len(pool.Addresses) > len(ap.Addresses) {
+			if pool.capacity() > ap.capacity() {
 				logger.Info("Pool is preferred because of capacity")
 				ap = pool
 			}
@@ -390,17 +425,18 @@ func (as *addressSpace) releasePool(poolId string) error {
 //
 // Returns address pool information.
 func (ap *addressPool) getInfo() *AddressPoolInfo {
-	var available int
+	var inUse uint64
 	var unhealthyAddrs []net.IP
 
 	for _, ar := range ap.Addresses {
-		if !ar.InUse {
-			available++
+		if ar.InUse || ar.isQuarantined() {
+			inUse++
 		}
 		if ar.unhealthy {
 			unhealthyAddrs = append(unhealthyAddrs, ar.Addr)
 		}
 	}
+	capacity := ap.capacity()
 
 	info := &AddressPoolInfo{
 		Subnet:         ap.Subnet,
@@ -408,13 +444,38 @@ func (ap *addressPool) getInfo() *AddressPoolInfo {
 		DnsServers:     []net.IP{dnsHostProxyAddress},
 		UnhealthyAddrs: unhealthyAddrs,
 		IsIPv6:         ap.IsIPv6,
-		Available:      available,
-		Capacity:       len(ap.Addresses),
+		Available:      saturatingInt(capacity - inUse),
+		Capacity:       saturatingInt(capacity),
 	}
 
 	return info
 }
 
+// Returns the number of addresses in the pool, saturating at math.MaxUint64.
+func (ap *addressPool) capacity() uint64 {
+	if ap.Range == nil {
+		return uint64(len(ap.Addresses))
+	}
+
+	// Records in a range pool are for allocated addresses in the range, which are already counted.
+	capacity := ap.Range.size()
+	for _, ar := range ap.Addresses {
+		if !ap.Range.containsIP(ar.Addr) {
+			if capacity < math.MaxUint64 {
+				capacity++
+			}
+		}
+	}
+	return capacity
+}
+
+func saturatingInt(n uint64) int {
+	if n > math.MaxInt {
+		return math.MaxInt
+	}
+	return int(n)
+}
+
 // Returns if an address pool is currently in use.
 func (ap *addressPool) isInUse() bool {
 	return ap.RefCount > 0
@@ -430,6 +491,16 @@ func (ap *addressPool) IsAnyRecordInUse() bool {
 	return false
 }
 
+// Returns if the address is quarantined after being found in use by another host.
+func (ar *addressRecord) isQuarantined() bool {
+	return time.Now().Before(ar.QuarantinedUntil)
+}
+
+// Returns if the address can be handed out to a new request.
+func (ar *addressRecord) isAvailable() bool {
+	return !ar.InUse && ar.ID == "" && !ar.isQuarantined()
+}
+
 // Creates a new addressRecord object.
 func (ap *addressPool) newAddressRecord(addr *net.IP) (*addressRecord, error) {
 	id := addr.String()
@@ -453,6 +524,29 @@ func (ap *addressPool) newAddressRecord(addr *net.IP) (*addressRecord, error) {
 	return ar, nil
 }
 
+// Returns the record for an address in the pool's range, creating it if needed,
+// or nil if the address is not in the range.
+func (ap *addressPool) rangeRecord(address string) *addressRecord {
+	if ap.Range == nil {
+		return nil
+	}
+	addr, err := netip.ParseAddr(address)
+	if err != nil || !ap.Range.contains(addr) {
+		return nil
+	}
+	if ar, ok := ap.Addresses[addr.String()]; ok {
+		return ar
+	}
+
+	ar := &addressRecord{
+		Addr:  addr.AsSlice(),
+		epoch: ap.epoch,
+	}
+	ap.Addresses[addr.String()] = ar
+
+	return ar
+}
+
 // Requests a new address from the address pool.
 func (ap *addressPool) requestAddress(address string, options map[string]string) (string, error) {
 	var ar *addressRecord
@@ -464,6 +558,9 @@ func (ap *addressPool) requestAddress(address string, options map[string]string)
 	if address != "" {
 		// Return the specific address requested.
 		ar = ap.Addresses[address]
+		if ar == nil {
+			ar = ap.rangeRecord(address)
+		}
 		if ar == nil {
 			logger.Error("Address request failed with", zap.Error(errAddressNotFound))
 			return "", errAddressNotFound
@@ -489,12 +586,22 @@ func (ap *addressPool) requestAddress(address string, options map[string]string)
 	// If no address was found, return any available address.
 	if ar == nil {
 		for _, ar = range ap.Addresses {
-			if !ar.InUse && ar.ID == "" {
+			if ar.isAvailable() {
 				break
 			}
 			ar = nil
 		}
 
+		if ar == nil && ap.Range != nil {
+			addr, ok := ap.Range.nextAvailable(func(addr netip.Addr) bool {
+				r := ap.Addresses[addr.String()]
+				return r != nil && !r.isAvailable()
+			}, len(ap.Addresses))
+			if ok {
+				ar = ap.rangeRecord(addr.String())
+			}
+		}
+
 		if ar == nil {
 			logger.Error("Address request failed with", zap.Error(errNoAvailableAddresses))
 			return "", errNoAvailableAddresses
@@ -566,11 +673,33 @@ func (ap *addressPool) releaseAddress(address string, options map[string]string)
 		ar.ID = ""
 	}
 
-	// Delete address record if it is no longer available.
-	if ar.epoch < ap.as.epoch {
+	// Delete address record if it is no longer available,
+	// or if it is in the pool's range and so only needs to exist while allocated.
+	if ar.epoch < ap.as.epoch || (ap.Range != nil && ar.ID == "" && !ar.isQuarantined() && ap.Range.containsIP(ar.Addr)) {
 		logger.Info("Deleting Address record from address pool as metadata doesn't have this address")
 		delete(ap.Addresses, address)
 	}
 
 	return nil
 }
+
+// Quarantines an address which was found in use by another host on the link, releasing it
+// if it was requested and keeping it from being handed out again for the duration.
+func (ap *addressPool) quarantineAddress(address string, duration time.Duration) {
+	ar := ap.Addresses[address]
+	if ar == nil {
+		ar = ap.rangeRecord(address)
+	}
+	if ar == nil {
+		return
+	}
+
+	logger.Info("Quarantining address", zap.String("address", address), zap.Duration("duration", duration))
+
+	if ar.ID != "" {
+		delete(ap.addrsByID, ar.ID)
+		ar.ID = ""
+	}
+	ar.InUse = false
+	ar.QuarantinedUntil = time.Now().Add(duration)
+}
@@ -1,8 +1,11 @@
 package ipam
 
 import (
+	"encoding/json"
+	"math"
 	"net"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -1110,4 +1113,178 @@ var _ = Describe("Test Pool", func() {
 			})
 		})
 	})
+
+	Describe("Test sparse address pool", func() {
+		var (
+			as *addressSpace
+			ap *addressPool
+		)
+
+		BeforeEach(func() {
+			var err error
+			as = &addressSpace{
+				Id:    LocalDefaultAddressSpaceId,
+				Scope: LocalScope,
+				Pools: map[string]*addressPool{},
+			}
+			_, subnet, _ := net.ParseCIDR("ace:cab:deca:deed::/64")
+			ap, err = as.newSparseAddressPool("", 0, subnet)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		Context("When the pool covers a /64", func() {
+			It("Should allocate without enumerating the subnet", func() {
+				Expect(ap.Addresses).To(BeEmpty())
+				Expect(ap.Range.First.String()).To(Equal("ace:cab:deca:deed::2"))
+				Expect(ap.Range.Last.String()).To(Equal("ace:cab:deca:deed:ffff:ffff:ffff:ffff"))
+				Expect(ap.Gateway.String()).To(Equal("ace:cab:deca:deed::1"))
+
+				addr, err := ap.requestAddress("", nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(addr).To(Equal("ace:cab:deca:deed::2/64"))
+				addr, err = ap.requestAddress("ace:cab:deca:deed::1:0", nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(addr).To(Equal("ace:cab:deca:deed::1:0/64"))
+				_, err = ap.requestAddress("ace:cab:deca:deed::1:0", nil)
+				Expect(err).To(Equal(errAddressInUse))
+				_, err = ap.requestAddress("ace:cab:deca:beef::2", nil)
+				Expect(err).To(Equal(errAddressNotFound))
+
+				Expect(ap.Addresses).To(HaveLen(2))
+				info := ap.getInfo()
+				Expect(info.Capacity).To(Equal(math.MaxInt))
+				Expect(info.Available).To(Equal(math.MaxInt))
+			})
+		})
+
+		Context("When addresses are released", func() {
+			It("Should only keep records for allocated addresses", func() {
+				options := map[string]string{OptAddressID: "container1"}
+				addr1, err := ap.requestAddress("", options)
+				Expect(err).NotTo(HaveOccurred())
+				addr2, err := ap.requestAddress("", nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(addr2).To(Equal("ace:cab:deca:deed::3/64"))
+
+				ip2, _, _ := net.ParseCIDR(addr2)
+				Expect(ap.releaseAddress(ip2.String(), nil)).To(Succeed())
+				Expect(ap.releaseAddress("", options)).To(Succeed())
+				Expect(ap.Addresses).To(BeEmpty())
+				Expect(ap.addrsByID).To(BeEmpty())
+
+				// released addresses are not handed out again straight away
+				addr3, err := ap.requestAddress("", nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(addr3).NotTo(Equal(addr1))
+				Expect(addr3).NotTo(Equal(addr2))
+
+				b, err := json.Marshal(as)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(b)).To(ContainSubstring("ace:cab:deca:deed::4"))
+				Expect(string(b)).NotTo(ContainSubstring("ace:cab:deca:deed::3\""))
+			})
+		})
+
+		Context("When the range is exhausted", func() {
+			It("Should fail and wrap around once an address is released", func() {
+				_, subnet, _ := net.ParseCIDR("ace:cab:deca:beef::/126")
+				small, err := as.newSparseAddressPool("", 0, subnet)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(small.getInfo().Capacity).To(Equal(2))
+
+				_, err = small.requestAddress("", nil)
+				Expect(err).NotTo(HaveOccurred())
+				_, err = small.requestAddress("", nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(small.getInfo().Available).To(Equal(0))
+				_, err = small.requestAddress("", nil)
+				Expect(err).To(Equal(errNoAvailableAddresses))
+
+				Expect(small.releaseAddress("ace:cab:deca:beef::2", nil)).To(Succeed())
+				addr, err := small.requestAddress("", nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(addr).To(Equal("ace:cab:deca:beef::2/126"))
+			})
+		})
+
+		Context("When the address space is merged", func() {
+			It("Should keep allocated addresses of the pool", func() {
+				_, err := ap.requestAddress("", nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				newas := &addressSpace{
+					Id:    LocalDefaultAddressSpaceId,
+					Scope: LocalScope,
+					Pools: map[string]*addressPool{},
+				}
+				_, subnet, _ := net.ParseCIDR("ace:cab:deca:deed::/64")
+				_, err = newas.newSparseAddressPool("", 0, subnet)
+				Expect(err).NotTo(HaveOccurred())
+
+				as.merge(newas)
+				Expect(as.Pools).To(HaveKey(ap.Id))
+				Expect(ap.Addresses).To(HaveKey("ace:cab:deca:deed::2"))
+				Expect(ap.Addresses["ace:cab:deca:deed::2"].unhealthy).To(BeFalse())
+				Expect(ap.Range.Next.String()).To(Equal("ace:cab:deca:deed::3"))
+			})
+		})
+	})
+
+	Describe("Test quarantineAddress", func() {
+		Context("When an enumerated address is quarantined", func() {
+			It("Should release it and skip it until the quarantine ends", func() {
+				_, subnet, _ := net.ParseCIDR("10.0.0.0/24")
+				ap := &addressPool{
+					Subnet:    *subnet,
+					Addresses: map[string]*addressRecord{},
+					addrsByID: map[string]*addressRecord{},
+				}
+				ap.Addresses["10.0.0.4"] = &addressRecord{Addr: net.ParseIP("10.0.0.4")}
+				options := map[string]string{OptAddressID: "container1"}
+
+				addr, err := ap.requestAddress("", options)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(addr).To(Equal("10.0.0.4/24"))
+
+				ap.quarantineAddress("10.0.0.4", time.Hour)
+				Expect(ap.Addresses["10.0.0.4"].InUse).To(BeFalse())
+				Expect(ap.addrsByID).NotTo(HaveKey("container1"))
+				Expect(ap.getInfo().Available).To(Equal(0))
+				_, err = ap.requestAddress("", options)
+				Expect(err).To(Equal(errNoAvailableAddresses))
+
+				ap.Addresses["10.0.0.4"].QuarantinedUntil = time.Now().Add(-time.Second)
+				Expect(ap.getInfo().Available).To(Equal(1))
+				addr, err = ap.requestAddress("", options)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(addr).To(Equal("10.0.0.4/24"))
+			})
+		})
+
+		Context("When an address in a range is quarantined", func() {
+			It("Should keep its record and skip it", func() {
+				as := &addressSpace{
+					Id:    LocalDefaultAddressSpaceId,
+					Scope: LocalScope,
+					Pools: map[string]*addressPool{},
+				}
+				_, subnet, _ := net.ParseCIDR("ace:cab:deca:beef::/126")
+				ap, err := as.newSparseAddressPool("", 0, subnet)
+				Expect(err).NotTo(HaveOccurred())
+
+				addr, err := ap.requestAddress("", nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(addr).To(Equal("ace:cab:deca:beef::2/126"))
+				ap.quarantineAddress("ace:cab:deca:beef::2", time.Hour)
+				Expect(ap.releaseAddress("ace:cab:deca:beef::2", nil)).To(Succeed())
+				Expect(ap.Addresses).To(HaveKey("ace:cab:deca:beef::2"))
+
+				addr, err = ap.requestAddress("", nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(addr).To(Equal("ace:cab:deca:beef::3/126"))
+				_, err = ap.requestAddress("", nil)
+				Expect(err).To(Equal(errNoAvailableAddresses))
+			})
+		})
+	})
 })
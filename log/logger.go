@@ -10,6 +10,9 @@ import (
 	"os"
 	"path"
 	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
 )
 
 // Log level
@@ -40,8 +43,15 @@ const (
 	maxLogFileSize   = 5 * 1024 * 1024
 	maxLogFileCount  = 8
 	rotationCheckFrq = 8
+
+	// maxCallerSkip is the most frames between a logging method calling zap and the caller it records, which are
+	// those of the package-level Request and Response functions.
+	maxCallerSkip = 3
 )
 
+// zapLoggers are the loggers set by SetZapLogger, by the number of frames they skip to record the caller.
+type zapLoggers [maxCallerSkip + 1]*zap.SugaredLogger
+
 // Logger object
 type Logger struct {
 	l            *log.Logger
@@ -54,6 +64,7 @@ type Logger struct {
 	callCount    int
 	directory    string
 	mutex        *sync.Mutex
+	z            atomic.Pointer[zapLoggers]
 }
 
 var pid = os.Getpid()
@@ -114,8 +125,33 @@ func (logger *Logger) SetLogFileLimits(maxFileSize int, maxFileCount int) {
 	logger.maxFileCount = maxFileCount
 }
 
+// SetZapLogger sends everything logged through the Logger to z instead of its target, so that
+// callers of this API can move onto the zap-based pipeline in log/v2 gradually. Once set, z
+// decides which levels are logged, and the caller it records is that of the Logger method or package-level
+// function called.
+func (logger *Logger) SetZapLogger(z *zap.Logger) {
+	var loggers zapLoggers
+	for skip := range loggers {
+		loggers[skip] = z.WithOptions(zap.AddCallerSkip(skip)).Sugar()
+	}
+	logger.z.Store(&loggers)
+}
+
+// zap returns the logger set by SetZapLogger which records the caller of the entry point skip frames above the
+// logging method calling it, or nil if there is none.
+func (logger *Logger) zap(skip int) *zap.SugaredLogger {
+	loggers := logger.z.Load()
+	if loggers == nil {
+		return nil
+	}
+	return loggers[skip+1]
+}
+
 // Close closes the log stream.
 func (logger *Logger) Close() {
+	if loggers := logger.z.Load(); loggers != nil {
+		_ = loggers[0].Sync()
+	}
 	if logger.out != nil {
 		logger.out.Close()
 	}
@@ -201,32 +237,42 @@ func (logger *Logger) rotate() error {
 
 // Request logs a structured request.
 func (logger *Logger) Request(tag string, request interface{}, err error) {
+	logger.request(1, tag, request, err)
+}
+
+// request logs a structured request for the entry point skip frames above.
+func (logger *Logger) request(skip int, tag string, request interface{}, err error) {
 	if err == nil {
-		logger.Printf("[%s] Received %T %+v.", tag, request, request)
+		logger.printf(skip+1, "[%s] Received %T %+v.", tag, request, request)
 	} else {
-		logger.Errorf("[%s] Failed to decode %T %+v %s.", tag, request, request, err.Error())
+		logger.errorf(skip+1, "[%s] Failed to decode %T %+v %s.", tag, request, request, err.Error())
 	}
 }
 
 // Response logs a structured response.
 func (logger *Logger) Response(tag string, response interface{}, returnCode int, returnStr string, err error) {
+	logger.response(1, tag, response, returnCode, returnStr, err)
+}
+
+// response logs a structured response for the entry point skip frames above.
+func (logger *Logger) response(skip int, tag string, response interface{}, returnCode int, returnStr string, err error) {
 	if err == nil && returnCode == 0 {
-		logger.Printf("[%s] Sent %T %+v.", tag, response, response)
+		logger.printf(skip+1, "[%s] Sent %T %+v.", tag, response, response)
 	} else if err != nil {
-		logger.Errorf("[%s] Code:%s, %+v %s.", tag, returnStr, response, err.Error())
+		logger.errorf(skip+1, "[%s] Code:%s, %+v %s.", tag, returnStr, response, err.Error())
 	} else {
-		logger.Errorf("[%s] Code:%s, %+v.", tag, returnStr, response)
+		logger.errorf(skip+1, "[%s] Code:%s, %+v.", tag, returnStr, response)
 	}
 }
 
 // ResponseEx logs a structured response and the request associate with it.
 func (logger *Logger) ResponseEx(tag string, request interface{}, response interface{}, returnCode int, returnStr string, err error) {
 	if err == nil && returnCode == 0 {
-		logger.Printf("[%s] Sent %T %+v %T %+v.", tag, request, request, response, response)
+		logger.printf(1, "[%s] Sent %T %+v %T %+v.", tag, request, request, response, response)
 	} else if err != nil {
-		logger.Errorf("[%s] Code:%s, %+v, %+v %s.", tag, returnStr, request, response, err.Error())
+		logger.errorf(1, "[%s] Code:%s, %+v, %+v %s.", tag, returnStr, request, response, err.Error())
 	} else {
-		logger.Errorf("[%s] Code:%s, %+v, %+v.", tag, returnStr, request, response)
+		logger.errorf(1, "[%s] Code:%s, %+v, %+v.", tag, returnStr, request, response)
 	}
 }
 
@@ -244,6 +290,15 @@ func (logger *Logger) logf(format string, args ...interface{}) {
 
 // Logf wraps logf.
 func (logger *Logger) Logf(format string, args ...interface{}) {
+	logger.logfAt(1, format, args...)
+}
+
+// logfAt logs like Logf for the entry point skip frames above.
+func (logger *Logger) logfAt(skip int, format string, args ...interface{}) {
+	if z := logger.zap(skip); z != nil {
+		z.Infof(format, args...)
+		return
+	}
 	logger.mutex.Lock()
 	logger.logf(format, args...)
 	logger.mutex.Unlock()
@@ -251,6 +306,15 @@ func (logger *Logger) Logf(format string, args ...interface{}) {
 
 // Printf logs a formatted string at info level.
 func (logger *Logger) Printf(format string, args ...interface{}) {
+	logger.printf(1, format, args...)
+}
+
+// printf logs like Printf for the entry point skip frames above.
+func (logger *Logger) printf(skip int, format string, args ...interface{}) {
+	if z := logger.zap(skip); z != nil {
+		z.Infof(format, args...)
+		return
+	}
 	if logger.level < LevelInfo {
 		return
 	}
@@ -260,6 +324,15 @@ func (logger *Logger) Printf(format string, args ...interface{}) {
 
 // Debugf logs a formatted string at info level.
 func (logger *Logger) Debugf(format string, args ...interface{}) {
+	logger.debugf(1, format, args...)
+}
+
+// debugf logs like Debugf for the entry point skip frames above.
+func (logger *Logger) debugf(skip int, format string, args ...interface{}) {
+	if z := logger.zap(skip); z != nil {
+		z.Debugf(format, args...)
+		return
+	}
 	if logger.level < LevelDebug {
 		return
 	}
@@ -269,11 +342,24 @@ func (logger *Logger) Debugf(format string, args ...interface{}) {
 
 // Errorf logs a formatted string at info level and sends the string to TelemetryBuffer.
 func (logger *Logger) Errorf(format string, args ...interface{}) {
+	logger.errorf(1, format, args...)
+}
+
+// errorf logs like Errorf for the entry point skip frames above.
+func (logger *Logger) errorf(skip int, format string, args ...interface{}) {
+	if z := logger.zap(skip); z != nil {
+		z.Errorf(format, args...)
+		return
+	}
 	logger.Logf(format, args...)
 }
 
 // Warnf logs a formatted string at warninglevel
 func (logger *Logger) Warnf(format string, args ...interface{}) {
+	if z := logger.zap(0); z != nil {
+		z.Warnf(format, args...)
+		return
+	}
 	if logger.level < LevelWarning {
 		return
 	}
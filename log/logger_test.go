@@ -7,11 +7,16 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"reflect"
+	"runtime"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 const (
@@ -131,3 +136,52 @@ func TestPid(t *testing.T) {
 		t.Fatalf("Unexpected log: %s.", log)
 	}
 }
+
+func TestSetZapLogger(t *testing.T) {
+	l, err := NewLoggerE(logName, LevelInfo, TargetStderr, "")
+	require.NoError(t, err)
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	l.SetZapLogger(zap.New(core))
+
+	// the level of the zap logger applies instead of the level of the Logger
+	l.Debugf("dropped %d", 1)
+	l.Printf("info %d", 2)
+	l.Warnf("warn %d", 3)
+	l.Errorf("error %d", 4)
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 3)
+	assert.Equal(t, "info 2", entries[0].Message)
+	assert.Equal(t, zapcore.InfoLevel, entries[0].Level)
+	assert.Equal(t, zapcore.WarnLevel, entries[1].Level)
+	assert.Equal(t, zapcore.ErrorLevel, entries[2].Level)
+
+	// the caller is that of the Logger method or package-level function called, however many frames it adds
+	t.Cleanup(func() { stdLog.z.Store(nil) })
+	z := zap.New(core, zap.AddCaller())
+	l.SetZapLogger(z)
+	SetZapLogger(z)
+	calls := []func(){
+		func() { l.Printf("caller") },
+		func() { l.Warnf("caller") },
+		func() { l.Request("tag", "request", nil) },
+		func() { l.Response("tag", "response", 1, "failed", nil) },
+		func() { l.ResponseEx("tag", "request", "response", 0, "", nil) },
+		func() { Printf("caller") },
+		func() { Logf("caller") },
+		func() { Errorf("caller") },
+		func() { Request("tag", "request", nil) },
+		func() { Response("tag", "response", 0, "", nil) },
+	}
+	for i, call := range calls {
+		logs.TakeAll()
+		call()
+		entries = logs.AllUntimed()
+		require.Len(t, entries, 1)
+		fn := runtime.FuncForPC(reflect.ValueOf(call).Pointer())
+		file, line := fn.FileLine(fn.Entry())
+		assert.Equal(t, file, entries[0].Caller.File, "call %d", i)
+		assert.Equal(t, line, entries[0].Caller.Line, "call %d", i)
+	}
+}
@@ -3,6 +3,8 @@
 
 package log
 
+import "go.uber.org/zap"
+
 // Standard logger is a pre-defined logger for convenience.
 // Set log directory as the current location
 var stdLog = NewLogger("azure-container-networking", LevelInfo, TargetStderr, "")
@@ -20,6 +22,11 @@ func SetLevel(level int) {
 	stdLog.SetLevel(level)
 }
 
+// SetZapLogger sends everything logged through the standard logger to z.
+func SetZapLogger(z *zap.Logger) {
+	stdLog.SetZapLogger(z)
+}
+
 func SetLogFileLimits(maxFileSize int, maxFileCount int) {
 	stdLog.SetLogFileLimits(maxFileSize, maxFileCount)
 }
@@ -37,27 +44,27 @@ func GetLogDirectory() string {
 }
 
 func Request(tag string, request interface{}, err error) {
-	stdLog.Request(tag, request, err)
+	stdLog.request(1, tag, request, err)
 }
 
 func Response(tag string, response interface{}, returnCode int, returnStr string, err error) {
-	stdLog.Response(tag, response, returnCode, returnStr, err)
+	stdLog.response(1, tag, response, returnCode, returnStr, err)
 }
 
 // Logf logs to the local log.
 func Logf(format string, args ...interface{}) {
-	stdLog.Logf(format, args...)
+	stdLog.logfAt(1, format, args...)
 }
 
 // Printf logs to the local log and send the log through the channel.
 func Printf(format string, args ...interface{}) {
-	stdLog.Printf(format, args...)
+	stdLog.printf(1, format, args...)
 }
 
 func Debugf(format string, args ...interface{}) {
-	stdLog.Debugf(format, args...)
+	stdLog.debugf(1, format, args...)
 }
 
 func Errorf(format string, args ...interface{}) {
-	stdLog.Errorf(format, args...)
+	stdLog.errorf(1, format, args...)
 }
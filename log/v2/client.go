@@ -0,0 +1,65 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// LevelClient gets and changes the Levels of a process serving a LevelHandler on PathLogLevel.
+type LevelClient struct {
+	endpoint string
+	client   *http.Client
+}
+
+// NewLevelClient returns a LevelClient for the process listening on endpoint, such as http://localhost:10090.
+func NewLevelClient(endpoint string, client *http.Client) *LevelClient {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &LevelClient{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		client:   client,
+	}
+}
+
+// Get returns the current Levels.
+func (c *LevelClient) Get(ctx context.Context) (*LevelResponse, error) {
+	return c.do(ctx, http.MethodGet, http.NoBody)
+}
+
+// Set applies req and returns the resulting Levels.
+func (c *LevelClient) Set(ctx context.Context, req *LevelRequest) (*LevelResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode request")
+	}
+	return c.do(ctx, http.MethodPut, bytes.NewReader(body))
+}
+
+func (c *LevelClient) do(ctx context.Context, method string, body io.Reader) (*LevelResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.endpoint+PathLogLevel, body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "request failed")
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024)) //nolint:gomnd // enough for an error message
+		return nil, errors.Errorf("%s %s: %s: %s", method, PathLogLevel, res.Status, strings.TrimSpace(string(msg)))
+	}
+	var resp LevelResponse
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return nil, errors.Wrap(err, "failed to decode response")
+	}
+	return &resp, nil
+}
@@ -0,0 +1,102 @@
+package log
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap/zapcore"
+)
+
+// UnmarshalJSON implements json.Unmarshaler for the Config.
+// It only differs from the default by parsing the Level string into a
+// zapcore.Level and validating the component Levels.
+func (c *Config) UnmarshalJSON(data []byte) error {
+	type Alias Config
+	aux := &struct {
+		*Alias
+	}{
+		Alias: (*Alias)(c),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil { //nolint:musttag // doesn't understand the embedding strategy
+		return errors.Wrap(err, "failed to unmarshal Config")
+	}
+	lvl, err := zapcore.ParseLevel(c.Level)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse Config Level")
+	}
+	c.level = lvl
+	for component, level := range c.Components {
+		if _, err := zapcore.ParseLevel(level); err != nil {
+			return errors.Wrapf(err, "failed to parse Level of component %s", component)
+		}
+	}
+	return nil
+}
+
+// Normalize checks the Config for missing/default values and sets them
+// if appropriate. Cores without a Level of their own log everything that
+// passes the general or component Level. The rotation of the file core is
+// left to its consumer, so that a zero MaxSize or MaxBackups keeps the
+// lumberjack defaults of 100MB and unlimited backups.
+func (c *Config) Normalize() {
+	if c.Stdout != nil && c.Stdout.Level == "" {
+		c.Stdout.Level = zapcore.DebugLevel.String()
+	}
+	if c.File != nil {
+		if c.File.Level == "" {
+			c.File.Level = zapcore.DebugLevel.String()
+		}
+	}
+	if c.AppInsights != nil && c.AppInsights.Level == "" {
+		c.AppInsights.Level = zapcore.DebugLevel.String()
+	}
+	if c.OTLP != nil && c.OTLP.Level == "" {
+		c.OTLP.Level = zapcore.DebugLevel.String()
+	}
+	c.normalize()
+}
+
+// SetLevel changes the general logging Level of a logger built from this Config with New.
+// Components with a Level of their own are not affected.
+func (c *Config) SetLevel(level string) error {
+	lvl, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse Level")
+	}
+	if c.levels == nil {
+		return errNotBuilt
+	}
+	c.levels.SetLevel(lvl)
+	return nil
+}
+
+// SetComponentLevel changes the Level of the named component of a logger built from this Config with New.
+// An empty level removes the override so that the component follows the general Level again.
+func (c *Config) SetComponentLevel(component, level string) error {
+	if c.levels == nil {
+		return errNotBuilt
+	}
+	if level == "" {
+		c.levels.ResetComponentLevel(component)
+		return nil
+	}
+	lvl, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse Level of component %s", component)
+	}
+	c.levels.SetComponentLevel(component, lvl)
+	return nil
+}
+
+// Levels returns the current general Level and the Level of every component with an override,
+// in the same shape as the Level and Components fields.
+func (c *Config) Levels() (general string, components map[string]string) {
+	if c.levels == nil {
+		return c.Level, c.Components
+	}
+	components = map[string]string{}
+	for name, lvl := range c.levels.ComponentLevels() {
+		components[name] = lvl.String()
+	}
+	return c.levels.Level().String(), components
+}
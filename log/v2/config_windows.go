@@ -0,0 +1,31 @@
+package log
+
+import (
+	cores "github.com/Azure/azure-container-networking/log/v2/cores"
+	"go.uber.org/zap/zapcore"
+)
+
+type Config struct {
+	// Level is the general logging Level. Components and cores with a more specific Level override it.
+	Level string        `json:"level"`
+	level zapcore.Level `json:"-"`
+	// Components overrides the Level of the named components, for example {"ipam": "debug"}.
+	Components  map[string]string        `json:"components,omitempty"`
+	levels      *Levels                  `json:"-"`
+	Stdout      *cores.StdoutConfig      `json:"stdout,omitempty"`
+	File        *cores.FileConfig        `json:"file,omitempty"`
+	AppInsights *cores.AppInsightsConfig `json:"appInsights,omitempty"`
+	OTLP        *cores.OTLPConfig        `json:"otlp,omitempty"`
+	ETW         *cores.ETWConfig         `json:"etw,omitempty"`
+}
+
+func (c *Config) normalize() {
+	if c.ETW != nil {
+		if c.ETW.Level == "" {
+			c.ETW.Level = zapcore.DebugLevel.String()
+		}
+		if c.ETW.ProviderName == "" {
+			c.ETW.ProviderName = "ACN-Monitoring"
+		}
+	}
+}
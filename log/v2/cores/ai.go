@@ -45,6 +45,10 @@ func (c *AppInsightsConfig) UnmarshalJSON(data []byte) error {
 // ApplicationInsightsCore builds a zapcore.Core that sends logs to Application Insights.
 // The first return is the core, the second is a function to close the sink.
 func ApplicationInsightsCore(cfg *AppInsightsConfig) (zapcore.Core, func(), error) {
+	lvl, err := zapcore.ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, func() {}, errors.Wrap(err, "failed to parse AppInsightsConfig Level")
+	}
 	// build the AI config
 	aicfg := *appinsights.NewTelemetryConfiguration(cfg.IKey)
 	aicfg.MaxBatchSize = cfg.MaxBatchSize
@@ -59,7 +63,7 @@ func ApplicationInsightsCore(cfg *AppInsightsConfig) (zapcore.Core, func(), erro
 		return nil, aiclose, errors.Wrap(err, "failed to open AI sink")
 	}
 	// build the AI core
-	core := zapai.NewCore(cfg.level, sink)
+	core := zapai.NewCore(lvl, sink)
 	core = core.WithFieldMappers(zapai.DefaultMappers)
 	// add normalized fields for the built-in AI Tags
 
@@ -41,8 +41,12 @@ func (cfg *ETWConfig) UnmarshalJSON(data []byte) error {
 // ETWCore builds a zapcore.Core that sends logs to ETW.
 // The first return is the core, the second is a function to close the sink.
 func ETWCore(cfg *ETWConfig) (zapcore.Core, func(), error) {
+	lvl, err := zapcore.ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, func() {}, errors.Wrap(err, "failed to parse ETWConfig Level")
+	}
 	encoderConfig := zap.NewProductionEncoderConfig()
 	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
 	jsonEncoder := zapcore.NewJSONEncoder(encoderConfig)
-	return zapetw.New(cfg.ProviderName, cfg.EventName, jsonEncoder, cfg.level) //nolint:wrapcheck // ignore
+	return zapetw.New(cfg.ProviderName, cfg.EventName, jsonEncoder, lvl) //nolint:wrapcheck // ignore
 }
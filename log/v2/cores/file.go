@@ -42,6 +42,10 @@ func (cfg *FileConfig) UnmarshalJSON(data []byte) error {
 // FileCore builds a zapcore.Core that writes to a file.
 // The first return is the core, the second is a function to close the file.
 func FileCore(cfg *FileConfig) (zapcore.Core, func(), error) {
+	lvl, err := zapcore.ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, func() {}, errors.Wrap(err, "failed to parse FileConfig Level")
+	}
 	filesink := &lumberjack.Logger{
 		Filename:   cfg.Filepath,
 		MaxSize:    cfg.MaxSize, // MB
@@ -50,5 +54,5 @@ func FileCore(cfg *FileConfig) (zapcore.Core, func(), error) {
 	encoderConfig := zap.NewProductionEncoderConfig()
 	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
 	jsonEncoder := zapcore.NewJSONEncoder(encoderConfig)
-	return zapcore.NewCore(jsonEncoder, zapcore.AddSync(filesink), cfg.level), func() { _ = filesink.Close() }, nil
+	return zapcore.NewCore(jsonEncoder, zapcore.AddSync(filesink), lvl), func() { _ = filesink.Close() }, nil
 }
@@ -0,0 +1,113 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/contrib/bridges/otelzap"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	defaultServiceName = "azure-cns"
+	// otlpShutdownTimeout bounds how long closing the core waits to flush buffered records.
+	otlpShutdownTimeout = 5 * time.Second
+)
+
+type OTLPConfig struct {
+	Level string        `json:"level"`
+	level zapcore.Level `json:"-"`
+	// Endpoint is the host:port of the OTLP/HTTP collector. Defaults to the OTEL_EXPORTER_OTLP_ENDPOINT environment variable or localhost:4318.
+	Endpoint string `json:"endpoint"`
+	// Insecure sends logs over plain HTTP instead of HTTPS.
+	Insecure bool `json:"insecure"`
+	// Headers are added to every export request, for example to authenticate with the collector.
+	Headers     map[string]string `json:"headers"`
+	ServiceName string            `json:"serviceName"`
+	Fields      []zapcore.Field   `json:"fields"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler for the Config.
+// It only differs from the default by parsing the
+// Level string into a zapcore.Level and setting the level field.
+func (cfg *OTLPConfig) UnmarshalJSON(data []byte) error {
+	type Alias OTLPConfig
+	aux := &struct {
+		*Alias
+	}{
+		Alias: (*Alias)(cfg),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return errors.Wrap(err, "failed to unmarshal OTLPConfig")
+	}
+	lvl, err := zapcore.ParseLevel(cfg.Level)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse OTLPConfig Level")
+	}
+	cfg.level = lvl
+	return nil
+}
+
+// OTLPCore builds a zapcore.Core that exports logs to an OTLP/HTTP collector.
+// Log calls which carry a context.Context field, such as those made through a logger
+// annotated with the trace context of a request, are correlated with that trace.
+// The first return is the core, the second is a function to flush and close the exporter.
+func OTLPCore(cfg *OTLPConfig) (zapcore.Core, func(), error) {
+	lvl, err := zapcore.ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, func() {}, errors.Wrap(err, "failed to parse OTLPConfig Level")
+	}
+	var opts []otlploghttp.Option
+	if cfg.Endpoint != "" {
+		opts = append(opts, otlploghttp.WithEndpoint(cfg.Endpoint))
+	}
+	if cfg.Insecure {
+		opts = append(opts, otlploghttp.WithInsecure())
+	}
+	if len(cfg.Headers) > 0 {
+		opts = append(opts, otlploghttp.WithHeaders(cfg.Headers))
+	}
+	exporter, err := otlploghttp.New(context.Background(), opts...)
+	if err != nil {
+		return nil, func() {}, errors.Wrap(err, "failed to create OTLP log exporter")
+	}
+
+	provider := sdklog.NewLoggerProvider(
+		sdklog.WithProcessor(sdklog.NewBatchProcessor(exporter)),
+		sdklog.WithResource(ServiceResource(cfg.ServiceName)),
+	)
+	closer := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), otlpShutdownTimeout)
+		defer cancel()
+		_ = provider.Shutdown(ctx)
+	}
+
+	core, err := zapcore.NewIncreaseLevelCore(otelzap.NewCore(defaultServiceName, otelzap.WithLoggerProvider(provider)), lvl)
+	if err != nil {
+		return nil, closer, errors.Wrap(err, "failed to set OTLP core level")
+	}
+	return core.With(cfg.Fields), closer, nil
+}
+
+// ServiceResource describes the process to an OTLP collector. Attributes from the
+// OTEL_RESOURCE_ATTRIBUTES and OTEL_SERVICE_NAME environment variables take precedence.
+func ServiceResource(serviceName string) *resource.Resource {
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+	res, err := resource.New(context.Background(),
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(semconv.ServiceName(serviceName)),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return resource.Default()
+	}
+	return res
+}
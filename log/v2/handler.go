@@ -0,0 +1,238 @@
+package log
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	acntime "github.com/Azure/azure-container-networking/internal/time"
+	"github.com/pkg/errors"
+	"go.uber.org/zap/zapcore"
+)
+
+// PathLogLevel is the path the LevelHandler is served on by convention.
+const PathLogLevel = "/debug/loglevel"
+
+// LevelController is a logger whose general and component Levels can be changed at runtime.
+// Config implements it.
+type LevelController interface {
+	Levels() (general string, components map[string]string)
+	SetLevel(level string) error
+	SetComponentLevel(component, level string) error
+}
+
+// LevelRequest changes the Levels of a logger. Empty fields are left unchanged, except that
+// an empty component Level removes the override of that component.
+type LevelRequest struct {
+	Level      string            `json:"level,omitempty"`
+	Components map[string]string `json:"components,omitempty"`
+	// TTL reverts the Levels to what they were before the first of a series of changes once it
+	// has passed, with the general Level set by SetConfiguredLevel since. A change without a TTL is
+	// kept and cancels any pending revert.
+	TTL acntime.Duration `json:"ttl"`
+}
+
+// LevelResponse reports the current Levels of a logger.
+type LevelResponse struct {
+	Level      string            `json:"level"`
+	Components map[string]string `json:"components,omitempty"`
+	// KnownComponents are the components which can be given a Level of their own.
+	KnownComponents []string `json:"knownComponents,omitempty"`
+	// RevertAt is when the Levels are reverted, if a change with a TTL is pending.
+	RevertAt *time.Time `json:"revertAt,omitempty"`
+}
+
+// LevelHandler serves GET and PUT of the Levels of a LevelController.
+type LevelHandler struct {
+	ctl        LevelController
+	components []string
+	onChange   func(previous, current LevelResponse)
+
+	mu       sync.Mutex
+	timer    *time.Timer
+	revertAt time.Time
+	// generation identifies the latest change, so that a revert superseded by a later change is ignored.
+	generation uint64
+	// saved are the Levels to revert to when the pending TTL passes.
+	saved *LevelResponse
+}
+
+// NewLevelHandler returns a LevelHandler for ctl. If components are given, only those can be
+// given a Level of their own. onChange, if not nil, is called after every change including reverts.
+func NewLevelHandler(ctl LevelController, components []string, onChange func(previous, current LevelResponse)) *LevelHandler {
+	return &LevelHandler{
+		ctl:        ctl,
+		components: components,
+		onChange:   onChange,
+	}
+}
+
+func (h *LevelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.mu.Lock()
+		resp := h.current()
+		h.mu.Unlock()
+		writeLevelResponse(w, resp)
+	case http.MethodPut:
+		var req LevelRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "failed to decode request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		resp, err := h.Set(&req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeLevelResponse(w, resp)
+	default:
+		w.Header().Set("Allow", http.MethodGet+", "+http.MethodPut)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Set validates and applies req, returning the resulting Levels.
+func (h *LevelHandler) Set(req *LevelRequest) (LevelResponse, error) {
+	if err := h.validate(req); err != nil {
+		return LevelResponse{}, err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	previous := h.current()
+	if req.TTL.Duration > 0 && h.saved == nil {
+		saved := previous
+		h.saved = &saved
+	}
+	if err := h.apply(req.Level, req.Components); err != nil {
+		return LevelResponse{}, err
+	}
+
+	if h.timer != nil {
+		h.timer.Stop()
+		h.timer = nil
+	}
+	h.generation++
+	if req.TTL.Duration > 0 {
+		generation := h.generation
+		h.revertAt = time.Now().Add(req.TTL.Duration)
+		h.timer = time.AfterFunc(req.TTL.Duration, func() { h.revert(generation) })
+	} else {
+		h.saved = nil
+	}
+	current := h.current()
+	if h.onChange != nil {
+		h.onChange(previous, current)
+	}
+	return current, nil
+}
+
+// SetConfiguredLevel changes the general Level the logger is configured with, such as when its config file is
+// reloaded. If a pending change with a TTL overrides the general Level, the override is kept and the configured
+// Level is reverted to once the TTL passes, instead of the Level from before the change.
+func (h *LevelHandler) SetConfiguredLevel(level string) error {
+	if _, err := zapcore.ParseLevel(level); err != nil {
+		return errors.Wrap(err, "invalid level")
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	previous := h.current()
+	if h.saved != nil {
+		overridden := previous.Level != h.saved.Level
+		h.saved.Level = level
+		if overridden {
+			return nil
+		}
+	}
+	if err := h.apply(level, nil); err != nil {
+		return err
+	}
+	if h.onChange != nil {
+		h.onChange(previous, h.current())
+	}
+	return nil
+}
+
+// revert restores the Levels saved before the first change with a TTL.
+func (h *LevelHandler) revert(generation uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.saved == nil || generation != h.generation {
+		return
+	}
+	previous := h.current()
+	components := map[string]string{}
+	// remove the overrides added since, and restore the ones which were changed
+	for name := range previous.Components {
+		components[name] = ""
+	}
+	for name, level := range h.saved.Components {
+		components[name] = level
+	}
+	_ = h.apply(h.saved.Level, components)
+	h.saved, h.timer = nil, nil
+	if h.onChange != nil {
+		h.onChange(previous, h.current())
+	}
+}
+
+func (h *LevelHandler) validate(req *LevelRequest) error {
+	if req.Level != "" {
+		if _, err := zapcore.ParseLevel(req.Level); err != nil {
+			return errors.Wrap(err, "invalid level")
+		}
+	}
+	for name, level := range req.Components {
+		if len(h.components) > 0 && !slices.Contains(h.components, name) {
+			return errors.Errorf("unknown component %q, known components are %v", name, h.components)
+		}
+		if level == "" {
+			continue
+		}
+		if _, err := zapcore.ParseLevel(level); err != nil {
+			return errors.Wrapf(err, "invalid level for component %s", name)
+		}
+	}
+	if req.TTL.Duration < 0 {
+		return errors.New("ttl must not be negative")
+	}
+	return nil
+}
+
+func (h *LevelHandler) apply(level string, components map[string]string) error {
+	if level != "" {
+		if err := h.ctl.SetLevel(level); err != nil {
+			return errors.Wrap(err, "failed to set level")
+		}
+	}
+	for name, componentLevel := range components {
+		if err := h.ctl.SetComponentLevel(name, componentLevel); err != nil {
+			return errors.Wrapf(err, "failed to set level of component %s", name)
+		}
+	}
+	return nil
+}
+
+// current returns the Levels of the controller. The caller must hold h.mu.
+func (h *LevelHandler) current() LevelResponse {
+	general, components := h.ctl.Levels()
+	resp := LevelResponse{
+		Level:           general,
+		Components:      components,
+		KnownComponents: h.components,
+	}
+	if h.saved != nil {
+		revertAt := h.revertAt
+		resp.RevertAt = &revertAt
+	}
+	return resp
+}
+
+func writeLevelResponse(w http.ResponseWriter, resp LevelResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
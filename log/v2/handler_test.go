@@ -0,0 +1,119 @@
+package log
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	acntime "github.com/Azure/azure-container-networking/internal/time"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLevelsComponents(t *testing.T) {
+	levels := NewLevels(zapcore.InfoLevel)
+	core, logs := observer.New(zapcore.DebugLevel)
+	z := zap.New(levels.Core(core))
+
+	levels.SetComponentLevel("ipampool", zapcore.DebugLevel)
+	z.Named("ipampool").Named("scaler").Debug("nested component")
+	z.Named("restserver").Debug("other component")
+	z.Debug("root")
+	require.Equal(t, 1, logs.FilterMessage("nested component").Len())
+	require.Equal(t, 1, logs.Len())
+
+	levels.ResetComponentLevel("ipampool")
+	require.False(t, levels.Enabled(zapcore.DebugLevel))
+	z.Named("ipampool").Debug("reset component")
+	require.Equal(t, 1, logs.Len())
+}
+
+func TestLevelHandler(t *testing.T) {
+	cfg := &Config{Level: "info"}
+	_, closer, err := New(cfg)
+	require.NoError(t, err)
+	defer closer()
+
+	changes := 0
+	h := NewLevelHandler(cfg, []string{"ipampool", "restserver"}, func(_, _ LevelResponse) { changes++ })
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+	c := NewLevelClient(srv.URL, nil)
+	ctx := context.Background()
+
+	resp, err := c.Get(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "info", resp.Level)
+	require.Nil(t, resp.RevertAt)
+
+	// unknown components and invalid levels are rejected without applying anything
+	_, err = c.Set(ctx, &LevelRequest{Level: "debug", Components: map[string]string{"unknown": "debug"}})
+	require.ErrorContains(t, err, "unknown component")
+	_, err = c.Set(ctx, &LevelRequest{Components: map[string]string{"ipampool": "loud"}})
+	require.Error(t, err)
+	general, _ := cfg.Levels()
+	require.Equal(t, "info", general)
+
+	// a change with a TTL is reverted once it passes, including overrides added by later changes
+	resp, err = c.Set(ctx, &LevelRequest{Level: "debug", TTL: acntime.Duration{Duration: time.Hour}})
+	require.NoError(t, err)
+	require.Equal(t, "debug", resp.Level)
+	require.NotNil(t, resp.RevertAt)
+	resp, err = c.Set(ctx, &LevelRequest{Components: map[string]string{"ipampool": "error"}, TTL: acntime.Duration{Duration: 50 * time.Millisecond}})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"ipampool": "error"}, resp.Components)
+	require.Eventually(t, func() bool {
+		resp, err := c.Get(ctx)
+		return err == nil && resp.RevertAt == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	general, components := cfg.Levels()
+	require.Equal(t, "info", general)
+	require.Empty(t, components)
+	require.Equal(t, 3, changes)
+
+	// a change without a TTL cancels the pending revert
+	_, err = c.Set(ctx, &LevelRequest{Level: "warn", TTL: acntime.Duration{Duration: 50 * time.Millisecond}})
+	require.NoError(t, err)
+	resp, err = c.Set(ctx, &LevelRequest{Components: map[string]string{"restserver": "debug"}})
+	require.NoError(t, err)
+	require.Nil(t, resp.RevertAt)
+	time.Sleep(100 * time.Millisecond)
+	general, components = cfg.Levels()
+	require.Equal(t, "warn", general)
+	require.Equal(t, map[string]string{"restserver": "debug"}, components)
+}
+
+func TestLevelHandlerSetConfiguredLevel(t *testing.T) {
+	cfg := &Config{Level: "info"}
+	_, closer, err := New(cfg)
+	require.NoError(t, err)
+	defer closer()
+	h := NewLevelHandler(cfg, nil, nil)
+
+	require.Error(t, h.SetConfiguredLevel("loud"))
+	require.NoError(t, h.SetConfiguredLevel("warn"))
+	general, _ := cfg.Levels()
+	require.Equal(t, "warn", general)
+
+	// the configured Level does not override a pending change of the general Level, but is reverted to
+	_, err = h.Set(&LevelRequest{Level: "debug", TTL: acntime.Duration{Duration: 50 * time.Millisecond}})
+	require.NoError(t, err)
+	require.NoError(t, h.SetConfiguredLevel("error"))
+	general, _ = cfg.Levels()
+	require.Equal(t, "debug", general)
+	require.Eventually(t, func() bool {
+		general, _ := cfg.Levels()
+		return general == "error"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// a pending change of component Levels only leaves the general Level to the configured one
+	_, err = h.Set(&LevelRequest{Components: map[string]string{"ipampool": "debug"}, TTL: acntime.Duration{Duration: time.Hour}})
+	require.NoError(t, err)
+	require.NoError(t, h.SetConfiguredLevel("info"))
+	general, components := cfg.Levels()
+	require.Equal(t, "info", general)
+	require.Equal(t, map[string]string{"ipampool": "debug"}, components)
+}
@@ -0,0 +1,127 @@
+package log
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Levels is a general Level with per-component overrides, all of which can be changed at runtime.
+// A component is a logger named with zap.Logger.Named; the loggers it names in turn, such as
+// "ipampool.scaler", share its override.
+type Levels struct {
+	general zap.AtomicLevel
+
+	mu         sync.RWMutex
+	components map[string]zapcore.Level
+	// minComponent is the lowest component Level, so that Enabled can answer without locking.
+	minComponent atomic.Int32
+}
+
+// NewLevels returns Levels with the general Level lvl and no component overrides.
+func NewLevels(lvl zapcore.Level) *Levels {
+	l := &Levels{
+		general:    zap.NewAtomicLevelAt(lvl),
+		components: map[string]zapcore.Level{},
+	}
+	l.minComponent.Store(math.MaxInt32)
+	return l
+}
+
+// Level returns the general Level.
+func (l *Levels) Level() zapcore.Level {
+	return l.general.Level()
+}
+
+// SetLevel changes the general Level.
+func (l *Levels) SetLevel(lvl zapcore.Level) {
+	l.general.SetLevel(lvl)
+}
+
+// ComponentLevels returns a copy of the component overrides.
+func (l *Levels) ComponentLevels() map[string]zapcore.Level {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	components := make(map[string]zapcore.Level, len(l.components))
+	for name, lvl := range l.components {
+		components[name] = lvl
+	}
+	return components
+}
+
+// SetComponentLevel overrides the general Level for the named component.
+func (l *Levels) SetComponentLevel(component string, lvl zapcore.Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.components[component] = lvl
+	l.updateMin()
+}
+
+// ResetComponentLevel removes the override of the named component, which follows the general Level again.
+func (l *Levels) ResetComponentLevel(component string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.components, component)
+	l.updateMin()
+}
+
+func (l *Levels) updateMin() {
+	var lowest int32 = math.MaxInt32
+	for _, lvl := range l.components {
+		lowest = min(lowest, int32(lvl))
+	}
+	l.minComponent.Store(lowest)
+}
+
+// Enabled reports whether lvl is enabled for any component.
+func (l *Levels) Enabled(lvl zapcore.Level) bool {
+	return l.general.Enabled(lvl) || int32(lvl) >= l.minComponent.Load()
+}
+
+// enabledFor reports whether lvl is enabled for the logger with the given name.
+func (l *Levels) enabledFor(name string, lvl zapcore.Level) bool {
+	if name != "" && l.minComponent.Load() != math.MaxInt32 {
+		component, _, _ := strings.Cut(name, ".")
+		l.mu.RLock()
+		override, ok := l.components[component]
+		l.mu.RUnlock()
+		if ok {
+			return lvl >= override
+		}
+	}
+	return l.general.Enabled(lvl)
+}
+
+// Core gates every entry written to core by the Level of the component which logged it.
+// Unlike zapcore.NewIncreaseLevelCore, the Levels may be lower than the Level of core.
+func (l *Levels) Core(core zapcore.Core) zapcore.Core {
+	return &levelCore{Core: core, levels: l}
+}
+
+type levelCore struct {
+	zapcore.Core
+	levels *Levels
+}
+
+func (c *levelCore) Enabled(lvl zapcore.Level) bool {
+	return c.levels.Enabled(lvl)
+}
+
+func (c *levelCore) Level() zapcore.Level {
+	return zapcore.LevelOf(c.levels)
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), levels: c.levels}
+}
+
+func (c *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.levels.enabledFor(ent.LoggerName, ent.Level) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
@@ -0,0 +1,90 @@
+// Package log provides the logging pipeline shared by the ACN components. A logger is
+// composed of stdout, file, Application Insights, OTLP and ETW (on Windows) cores from a
+// single Config, with a general Level and per-component Level overrides which can be
+// changed at runtime.
+package log
+
+import (
+	cores "github.com/Azure/azure-container-networking/log/v2/cores"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var errNotBuilt = errors.New("logger has not been built from this Config")
+
+type compoundCloser []func()
+
+func (c compoundCloser) Close() {
+	for _, closer := range c {
+		closer()
+	}
+}
+
+// New creates a logger from the Config. Entries are gated by the general Level, or by the
+// Level of the component which logged them, and then written to every configured core
+// which enables them. The logger of a component is derived from it with Named.
+// The second return flushes and closes the cores.
+func New(cfg *Config, opts ...zap.Option) (*zap.Logger, func(), error) {
+	cfg.Normalize()
+	lvl, err := zapcore.ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, func() {}, errors.Wrap(err, "failed to parse Config Level")
+	}
+	cfg.level = lvl
+	levels := NewLevels(lvl)
+	for component, level := range cfg.Components {
+		componentLvl, err := zapcore.ParseLevel(level)
+		if err != nil {
+			return nil, func() {}, errors.Wrapf(err, "failed to parse Level of component %s", component)
+		}
+		levels.SetComponentLevel(component, componentLvl)
+	}
+	cfg.levels = levels
+
+	core := zapcore.NewNopCore()
+	closer := compoundCloser{}
+	if cfg.Stdout != nil {
+		stdoutLvl, err := zapcore.ParseLevel(cfg.Stdout.Level)
+		if err != nil {
+			return nil, closer.Close, errors.Wrap(err, "failed to parse StdoutConfig Level")
+		}
+		core = zapcore.NewTee(core, cores.StdoutCore(stdoutLvl).With(cfg.Stdout.Fields))
+	}
+	if cfg.File != nil {
+		fileCore, fileCloser, err := cores.FileCore(cfg.File)
+		closer = append(closer, fileCloser)
+		if err != nil {
+			return nil, closer.Close, err //nolint:wrapcheck // it's an internal pkg
+		}
+		core = zapcore.NewTee(core, fileCore.With(cfg.File.Fields))
+	}
+	if cfg.AppInsights != nil {
+		aiCore, aiCloser, err := cores.ApplicationInsightsCore(cfg.AppInsights)
+		closer = append(closer, aiCloser)
+		if err != nil {
+			return nil, closer.Close, err //nolint:wrapcheck // it's an internal pkg
+		}
+		core = zapcore.NewTee(core, aiCore)
+	}
+	if cfg.OTLP != nil {
+		otlpCore, otlpCloser, err := cores.OTLPCore(cfg.OTLP)
+		closer = append(closer, otlpCloser)
+		if err != nil {
+			return nil, closer.Close, err //nolint:wrapcheck // it's an internal pkg
+		}
+		core = zapcore.NewTee(core, otlpCore)
+	}
+	platformCore, platformCloser, err := platformCore(cfg)
+	closer = append(closer, platformCloser)
+	if err != nil {
+		return nil, closer.Close, err
+	}
+	core = zapcore.NewTee(core, platformCore)
+
+	z := zap.New(levels.Core(core), opts...)
+	return z, func() {
+		_ = z.Sync()
+		closer.Close()
+	}, nil
+}
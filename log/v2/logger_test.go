@@ -0,0 +1,111 @@
+package log
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	cores "github.com/Azure/azure-container-networking/log/v2/cores"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		have    []byte
+		want    *Config
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			have: []byte(`{"level":"info","components":{"ipam":"debug"}}`),
+			want: &Config{
+				Level:      "info",
+				Components: map[string]string{"ipam": "debug"},
+			},
+		},
+		{
+			name:    "invalid level",
+			have:    []byte(`{"level":"invalid"}`),
+			wantErr: true,
+		},
+		{
+			name:    "invalid component level",
+			have:    []byte(`{"level":"info","components":{"ipam":"invalid"}}`),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Config{}
+			err := json.Unmarshal(tt.have, c)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, c)
+		})
+	}
+}
+
+func TestComponentLevels(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log")
+	cfg := &Config{
+		Level:      "info",
+		Components: map[string]string{"ipam": "debug"},
+		File:       &cores.FileConfig{Filepath: path},
+	}
+	z, closer, err := New(cfg)
+	require.NoError(t, err)
+
+	cni := z.Named("cni")
+	ipam := z.Named("ipam")
+	cni.Debug("cni debug")
+	cni.Info("cni info")
+	ipam.Debug("ipam debug")
+
+	// levels can be changed at runtime, for components with and without an override
+	require.NoError(t, cfg.SetLevel("debug"))
+	require.NoError(t, cfg.SetComponentLevel("ipam", "error"))
+	cni.Debug("cni debug after")
+	ipam.Info("ipam info after")
+
+	require.NoError(t, cfg.SetComponentLevel("ipam", ""))
+	ipam.Debug("ipam debug reset")
+	closer()
+
+	general, components := cfg.Levels()
+	require.Equal(t, "debug", general)
+	require.Empty(t, components)
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	logs := string(b)
+	for _, want := range []string{"cni info", "ipam debug", "cni debug after", "ipam debug reset"} {
+		require.Contains(t, logs, want)
+	}
+	for _, unwanted := range []string{"cni debug\"", "ipam info after"} {
+		require.NotContains(t, logs, unwanted)
+	}
+	require.Equal(t, 4, strings.Count(logs, "\n"))
+}
+
+func TestSetLevelNotBuilt(t *testing.T) {
+	cfg := &Config{Level: "info"}
+	require.ErrorIs(t, cfg.SetLevel("debug"), errNotBuilt)
+	require.ErrorIs(t, cfg.SetComponentLevel("ipam", "debug"), errNotBuilt)
+	general, _ := cfg.Levels()
+	require.Equal(t, "info", general)
+}
+
+func TestNormalizeKeepsRotation(t *testing.T) {
+	cfg := &Config{Level: "info", File: &cores.FileConfig{}}
+	cfg.Normalize()
+	// zero keeps the lumberjack defaults of 100MB and unlimited backups
+	require.Zero(t, cfg.File.MaxSize)
+	require.Zero(t, cfg.File.MaxBackups)
+	require.Equal(t, "debug", cfg.File.Level)
+}
@@ -0,0 +1,92 @@
+// Copyright 2017 Microsoft. All rights reserved.
+// MIT License
+
+package netlink
+
+import (
+	"net"
+
+	"github.com/pkg/errors"
+)
+
+// ErrSubscriptionNotSupported is returned when netlink subscriptions are not available on the platform.
+var ErrSubscriptionNotSupported = errors.New("netlink subscriptions are not supported on this platform")
+
+// Group is a bitmask of rtnetlink multicast groups to subscribe to.
+// The values match the kernel RTMGRP_* legacy group masks.
+type Group uint32
+
+const (
+	GroupLink      Group = 0x1
+	GroupIPv4Addr  Group = 0x10
+	GroupIPv4Route Group = 0x40
+	GroupIPv6Addr  Group = 0x100
+	GroupIPv6Route Group = 0x400
+	GroupAddr            = GroupIPv4Addr | GroupIPv6Addr
+	GroupRoute           = GroupIPv4Route | GroupIPv6Route
+	GroupAll             = GroupLink | GroupAddr | GroupRoute
+)
+
+// EventType identifies the kind of change reported by an Event.
+type EventType int
+
+const (
+	// EventOverrun is delivered when the kernel dropped notifications because the
+	// subscriber could not keep up. Consumers should resync their view with a full dump.
+	EventOverrun EventType = iota
+	EventLinkAdded
+	EventLinkRemoved
+	EventAddrAdded
+	EventAddrRemoved
+	EventRouteAdded
+	EventRouteRemoved
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventOverrun:
+		return "Overrun"
+	case EventLinkAdded:
+		return "LinkAdded"
+	case EventLinkRemoved:
+		return "LinkRemoved"
+	case EventAddrAdded:
+		return "AddrAdded"
+	case EventAddrRemoved:
+		return "AddrRemoved"
+	case EventRouteAdded:
+		return "RouteAdded"
+	case EventRouteRemoved:
+		return "RouteRemoved"
+	default:
+		return "Unknown"
+	}
+}
+
+// LinkUpdate describes a network interface carried by a link event.
+// RTM_NEWLINK is also sent for attribute and state changes on an existing link,
+// so EventLinkAdded does not necessarily mean the link was just created.
+type LinkUpdate struct {
+	Index      int
+	Name       string
+	Flags      net.Flags
+	MTU        uint
+	MacAddress net.HardwareAddr
+}
+
+// AddrUpdate describes an interface address carried by an address event.
+type AddrUpdate struct {
+	LinkIndex int
+	Family    int
+	Scope     int
+	IPNet     *net.IPNet
+}
+
+// Event is a typed rtnetlink notification. Exactly one of Link, Addr or Route
+// is set, depending on Type. Overrun events carry no payload.
+type Event struct {
+	Type  EventType
+	Link  *LinkUpdate
+	Addr  *AddrUpdate
+	Route *Route
+}
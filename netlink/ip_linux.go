@@ -213,21 +213,19 @@ func (Netlink) GetIPRoute(filter *Route) ([]*Route, error) {
 
 // setIpRoute sends an IP route set request.
 func setIpRoute(route *Route, add bool) error {
-	var msgType, flags int
+	if add {
+		return sendIpRoute(route, unix.RTM_NEWROUTE, unix.NLM_F_CREATE|unix.NLM_F_EXCL|unix.NLM_F_ACK)
+	}
+	return sendIpRoute(route, unix.RTM_DELROUTE, unix.NLM_F_ACK)
+}
 
+// sendIpRoute sends an IP route request of the message type with the flags.
+func sendIpRoute(route *Route, msgType, flags int) error {
 	s, err := getSocket()
 	if err != nil {
 		return err
 	}
 
-	if add {
-		msgType = unix.RTM_NEWROUTE
-		flags = unix.NLM_F_CREATE | unix.NLM_F_EXCL | unix.NLM_F_ACK
-	} else {
-		msgType = unix.RTM_DELROUTE
-		flags = unix.NLM_F_ACK
-	}
-
 	req := newRequest(msgType, flags)
 
 	msg := newRtMsg(route.Family)
@@ -289,6 +287,11 @@ func (Netlink) DeleteIPRoute(route *Route) error {
 	return setIpRoute(route, false)
 }
 
+// ReplaceIPRoute adds an IP route to the route table, or atomically replaces the route to the same destination.
+func (Netlink) ReplaceIPRoute(route *Route) error {
+	return sendIpRoute(route, unix.RTM_NEWROUTE, unix.NLM_F_CREATE|unix.NLM_F_REPLACE|unix.NLM_F_ACK)
+}
+
 // GetIPAddressFamily returns the address family of an IP address.
 func GetIPAddressFamily(ip net.IP) int {
 	if len(ip) <= net.IPv4len {
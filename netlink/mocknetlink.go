@@ -1,6 +1,7 @@
 package netlink
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net"
@@ -23,6 +24,7 @@ type MockNetlink struct {
 	deleteRouteFn routeValidateFn
 	addRouteFn    routeValidateFn
 	DeleteLinkFn  func(name string) error
+	SubscribeFn   func(ctx context.Context, groups Group) (<-chan Event, error)
 }
 
 func NewMockNetlink(returnError bool, errorString string) *MockNetlink {
@@ -123,3 +125,18 @@ func (f *MockNetlink) DeleteIPRoute(r *Route) error {
 	}
 	return f.error()
 }
+
+func (f *MockNetlink) Subscribe(ctx context.Context, groups Group) (<-chan Event, error) {
+	if f.SubscribeFn != nil {
+		return f.SubscribeFn(ctx, groups)
+	}
+	if err := f.error(); err != nil {
+		return nil, err
+	}
+	events := make(chan Event)
+	go func() {
+		<-ctx.Done()
+		close(events)
+	}()
+	return events, nil
+}
@@ -3,7 +3,10 @@
 
 package netlink
 
-import "net"
+import (
+	"context"
+	"net"
+)
 
 // Link represents a network interface.
 type Link interface {
@@ -85,3 +88,7 @@ func (Netlink) AddIPRoute(route *Route) error {
 func (Netlink) DeleteIPRoute(route *Route) error {
 	return nil
 }
+
+func (Netlink) Subscribe(context.Context, Group) (<-chan Event, error) {
+	return nil, ErrSubscriptionNotSupported
+}
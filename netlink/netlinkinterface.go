@@ -4,6 +4,7 @@
 package netlink
 
 import (
+	"context"
 	"net"
 )
 
@@ -25,3 +26,8 @@ type NetlinkInterface interface {
 	AddIPRoute(route *Route) error
 	DeleteIPRoute(route *Route) error
 }
+
+// EventSubscriber delivers rtnetlink link, address and route change notifications.
+type EventSubscriber interface {
+	Subscribe(ctx context.Context, groups Group) (<-chan Event, error)
+}
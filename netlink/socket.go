@@ -145,16 +145,7 @@ func (s *socket) receiveResponse(sent *message) ([]*message, error) {
 		// Process received messages.
 		for _, nlMsg := range nlMsgs {
 			// Convert to message object.
-			msg := message{
-				NlMsghdr: unix.NlMsghdr{
-					Len:   nlMsg.Header.Len,
-					Type:  nlMsg.Header.Type,
-					Flags: nlMsg.Header.Flags,
-					Seq:   nlMsg.Header.Seq,
-					Pid:   nlMsg.Header.Pid,
-				},
-				data: nlMsg.Data,
-			}
+			msg := newMessageFromNetlink(&nlMsg)
 
 			// Ignore if the message is not in response to the sent message.
 			if msg.Seq != sent.Seq || msg.Pid != sent.Pid {
@@ -179,24 +170,8 @@ func (s *socket) receiveResponse(sent *message) ([]*message, error) {
 			// Log response message.
 			log.Debugf("[netlink] Received %+v\n", msg)
 
-			// Parse body.
-			msg.payload = append(msg.payload, nil)
-
 			// Parse attributes.
-			// Ignore failures as not all messages have attributes.
-			nlAttrs, _ := syscall.ParseNetlinkRouteAttr(&nlMsg)
-
-			// Convert to attribute objects.
-			for _, nlAttr := range nlAttrs {
-				attr := attribute{
-					NlAttr: unix.NlAttr{
-						Len:  nlAttr.Attr.Len,
-						Type: nlAttr.Attr.Type,
-					},
-					value: nlAttr.Value,
-				}
-				msg.payload = append(msg.payload, &attr)
-			}
+			msg.parseAttributes(&nlMsg)
 
 			multi = ((msg.Flags & unix.NLM_F_MULTI) != 0)
 			done = (msg.Type == unix.NLMSG_DONE)
@@ -206,7 +181,7 @@ func (s *socket) receiveResponse(sent *message) ([]*message, error) {
 				break
 			}
 
-			messages = append(messages, &msg)
+			messages = append(messages, msg)
 		}
 
 		// Exit if response is a single message,
@@ -218,3 +193,39 @@ func (s *socket) receiveResponse(sent *message) ([]*message, error) {
 
 	return messages, nil
 }
+
+// Converts a raw netlink message to a message object.
+func newMessageFromNetlink(nlMsg *syscall.NetlinkMessage) *message {
+	return &message{
+		NlMsghdr: unix.NlMsghdr{
+			Len:   nlMsg.Header.Len,
+			Type:  nlMsg.Header.Type,
+			Flags: nlMsg.Header.Flags,
+			Seq:   nlMsg.Header.Seq,
+			Pid:   nlMsg.Header.Pid,
+		},
+		data: nlMsg.Data,
+	}
+}
+
+// Parses the body and attributes of a raw netlink message into the message payload.
+func (msg *message) parseAttributes(nlMsg *syscall.NetlinkMessage) {
+	// Parse body.
+	msg.payload = append(msg.payload, nil)
+
+	// Parse attributes.
+	// Ignore failures as not all messages have attributes.
+	nlAttrs, _ := syscall.ParseNetlinkRouteAttr(nlMsg)
+
+	// Convert to attribute objects.
+	for _, nlAttr := range nlAttrs {
+		attr := attribute{
+			NlAttr: unix.NlAttr{
+				Len:  nlAttr.Attr.Len,
+				Type: nlAttr.Attr.Type,
+			},
+			value: nlAttr.Value,
+		}
+		msg.payload = append(msg.payload, &attr)
+	}
+}
@@ -0,0 +1,277 @@
+// Copyright 2017 Microsoft. All rights reserved.
+// MIT License
+
+//go:build linux
+// +build linux
+
+package netlink
+
+import (
+	"context"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/Azure/azure-container-networking/log"
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// Size of the receive buffer for notifications. Link messages carry stats and can exceed a page.
+	subscriptionRecvBufSize = 64 * 1024
+	// Interval at which a blocked receive wakes up to check for cancellation.
+	subscriptionPollInterval = 500 * time.Millisecond
+	// Capacity of the event channel returned to subscribers.
+	subscriptionEventBuf = 128
+)
+
+// Subscribe joins the requested rtnetlink multicast groups and delivers decoded
+// link, address and route notifications on the returned channel. The channel is
+// closed when ctx is cancelled or the socket fails irrecoverably.
+func (Netlink) Subscribe(ctx context.Context, groups Group) (<-chan Event, error) {
+	if groups == 0 {
+		return nil, errors.New("no netlink groups requested")
+	}
+
+	fd, err := newSubscriptionSocket(groups)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan Event, subscriptionEventBuf)
+	go func() {
+		defer close(events)
+		defer unix.Close(fd)
+		receiveEvents(ctx, fd, events)
+	}()
+
+	return events, nil
+}
+
+// Creates a netlink socket bound to the given multicast groups.
+func newSubscriptionSocket(groups Group) (int, error) {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW|unix.SOCK_CLOEXEC, unix.NETLINK_ROUTE)
+	if err != nil {
+		return -1, errors.Wrap(err, "failed to create netlink subscription socket")
+	}
+
+	tv := unix.NsecToTimeval(subscriptionPollInterval.Nanoseconds())
+	if err := unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv); err != nil {
+		unix.Close(fd)
+		return -1, errors.Wrap(err, "failed to set netlink subscription receive timeout")
+	}
+
+	sa := &unix.SockaddrNetlink{
+		Family: unix.AF_NETLINK,
+		Groups: uint32(groups),
+	}
+	if err := unix.Bind(fd, sa); err != nil {
+		unix.Close(fd)
+		return -1, errors.Wrap(err, "failed to bind netlink subscription socket")
+	}
+
+	log.Printf("[netlink] Subscribed to groups 0x%x.\n", uint32(groups))
+	return fd, nil
+}
+
+// Reads notifications from fd and sends decoded events until ctx is done.
+func receiveEvents(ctx context.Context, fd int, events chan<- Event) {
+	buffer := make([]byte, subscriptionRecvBufSize)
+
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		n, _, err := unix.Recvfrom(fd, buffer, 0)
+		if err != nil {
+			switch {
+			case errors.Is(err, unix.EAGAIN), errors.Is(err, unix.EINTR):
+				continue
+			case errors.Is(err, unix.ENOBUFS):
+				log.Printf("[netlink] Subscription overrun, notifications were dropped.\n")
+				if !sendEvent(ctx, events, Event{Type: EventOverrun}) {
+					return
+				}
+				continue
+			default:
+				log.Errorf("[netlink] Subscription receive failed, err=%v\n", err)
+				return
+			}
+		}
+
+		if n < unix.NLMSG_HDRLEN {
+			continue
+		}
+
+		// The decoded events alias the attribute bytes of their message, so every
+		// receive is parsed from a copy the next receive cannot overwrite.
+		data := make([]byte, n)
+		copy(data, buffer[:n])
+
+		nlMsgs, err := syscall.ParseNetlinkMessage(data)
+		if err != nil {
+			log.Printf("[netlink] Failed to parse notification, err=%v\n", err)
+			continue
+		}
+
+		for i := range nlMsgs {
+			event, ok := decodeEvent(&nlMsgs[i])
+			if !ok {
+				continue
+			}
+
+			if !sendEvent(ctx, events, event) {
+				return
+			}
+		}
+	}
+}
+
+// Sends an event, returning false if ctx was cancelled first.
+func sendEvent(ctx context.Context, events chan<- Event, event Event) bool {
+	select {
+	case events <- event:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Decodes a raw notification into a typed event.
+func decodeEvent(nlMsg *syscall.NetlinkMessage) (Event, bool) {
+	msg := newMessageFromNetlink(nlMsg)
+	msg.parseAttributes(nlMsg)
+
+	switch msg.Type {
+	case unix.RTM_NEWLINK, unix.RTM_DELLINK:
+		if len(msg.data) < unix.SizeofIfInfomsg {
+			return Event{}, false
+		}
+		event := Event{Type: EventLinkAdded, Link: deserializeLinkUpdate(msg)}
+		if msg.Type == unix.RTM_DELLINK {
+			event.Type = EventLinkRemoved
+		}
+		return event, true
+
+	case unix.RTM_NEWADDR, unix.RTM_DELADDR:
+		if len(msg.data) < unix.SizeofIfAddrmsg {
+			return Event{}, false
+		}
+		addr := deserializeAddrUpdate(msg)
+		if addr.IPNet == nil {
+			return Event{}, false
+		}
+		event := Event{Type: EventAddrAdded, Addr: addr}
+		if msg.Type == unix.RTM_DELADDR {
+			event.Type = EventAddrRemoved
+		}
+		return event, true
+
+	case unix.RTM_NEWROUTE, unix.RTM_DELROUTE:
+		if len(msg.data) < unix.SizeofRtMsg {
+			return Event{}, false
+		}
+		route, err := deserializeRoute(msg)
+		if err != nil || route.Flags&unix.RTM_F_CLONED != 0 {
+			return Event{}, false
+		}
+		event := Event{Type: EventRouteAdded, Route: route}
+		if msg.Type == unix.RTM_DELROUTE {
+			event.Type = EventRouteRemoved
+		}
+		return event, true
+	}
+
+	return Event{}, false
+}
+
+// Decodes an interface info message into a LinkUpdate.
+func deserializeLinkUpdate(msg *message) *LinkUpdate {
+	link := &LinkUpdate{
+		Index: int(int32(encoder.Uint32(msg.data[4:8]))),
+		Flags: linkFlags(encoder.Uint32(msg.data[8:12])),
+	}
+
+	for _, attr := range msg.getAttributes(nil) {
+		switch attr.Type {
+		case unix.IFLA_IFNAME:
+			link.Name = zeroTerminated(attr.value)
+		case unix.IFLA_MTU:
+			if len(attr.value) >= 4 {
+				link.MTU = uint(encoder.Uint32(attr.value[0:4]))
+			}
+		case unix.IFLA_ADDRESS:
+			link.MacAddress = net.HardwareAddr(attr.value)
+		}
+	}
+
+	return link
+}
+
+// Decodes an interface address message into an AddrUpdate.
+func deserializeAddrUpdate(msg *message) *AddrUpdate {
+	family := int(msg.data[0])
+	prefixLen := int(msg.data[1])
+	addr := &AddrUpdate{
+		Family:    family,
+		Scope:     int(msg.data[3]),
+		LinkIndex: int(encoder.Uint32(msg.data[4:8])),
+	}
+
+	// IFA_LOCAL is the interface address on point-to-point links,
+	// where IFA_ADDRESS is the peer. Prefer it when present.
+	var ip, local net.IP
+	for _, attr := range msg.getAttributes(nil) {
+		switch attr.Type {
+		case unix.IFA_ADDRESS:
+			ip = net.IP(attr.value)
+		case unix.IFA_LOCAL:
+			local = net.IP(attr.value)
+		}
+	}
+	if local != nil {
+		ip = local
+	}
+
+	if ip != nil {
+		addr.IPNet = &net.IPNet{
+			IP:   ip,
+			Mask: net.CIDRMask(prefixLen, 8*len(ip)),
+		}
+	}
+
+	return addr
+}
+
+// Converts kernel interface flags to net.Flags.
+func linkFlags(rawFlags uint32) net.Flags {
+	var f net.Flags
+	if rawFlags&unix.IFF_UP != 0 {
+		f |= net.FlagUp
+	}
+	if rawFlags&unix.IFF_BROADCAST != 0 {
+		f |= net.FlagBroadcast
+	}
+	if rawFlags&unix.IFF_LOOPBACK != 0 {
+		f |= net.FlagLoopback
+	}
+	if rawFlags&unix.IFF_POINTOPOINT != 0 {
+		f |= net.FlagPointToPoint
+	}
+	if rawFlags&unix.IFF_MULTICAST != 0 {
+		f |= net.FlagMulticast
+	}
+	return f
+}
+
+// Returns the string value of a null-terminated byte slice.
+func zeroTerminated(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,125 @@
+// Copyright 2017 Microsoft. All rights reserved.
+// MIT License
+
+//go:build linux
+// +build linux
+
+package netlink
+
+import (
+	"context"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+const subscribeIfName = "nlsubtest"
+
+// waitForEvent reads from events until match returns true or the timeout elapses.
+func waitForEvent(t *testing.T, events <-chan Event, match func(Event) bool) Event {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case ev, ok := <-events:
+			require.True(t, ok, "event channel closed unexpectedly")
+			if match(ev) {
+				return ev
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for netlink event")
+		}
+	}
+}
+
+// TestSubscribeLinkAndAddress tests that link and address changes are delivered as events.
+func TestSubscribeLinkAndAddress(t *testing.T) {
+	nl := NewNetlink()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := nl.Subscribe(ctx, GroupLink|GroupIPv4Addr)
+	require.NoError(t, err)
+
+	dummy, err := addDummyInterface(subscribeIfName)
+	require.NoError(t, err)
+	//nolint:errcheck // cleanup only
+	defer nl.DeleteLink(subscribeIfName)
+
+	ev := waitForEvent(t, events, func(ev Event) bool {
+		return ev.Type == EventLinkAdded && ev.Link.Name == subscribeIfName
+	})
+	require.Equal(t, dummy.Index, ev.Link.Index)
+	require.Equal(t, dummy.HardwareAddr, ev.Link.MacAddress)
+
+	ip, ipNet, _ := net.ParseCIDR("10.10.10.1/24")
+	require.NoError(t, nl.AddIPAddress(subscribeIfName, ip, ipNet))
+
+	ev = waitForEvent(t, events, func(ev Event) bool {
+		return ev.Type == EventAddrAdded && ev.Addr.LinkIndex == dummy.Index
+	})
+	require.True(t, ev.Addr.IPNet.IP.Equal(ip))
+	ones, _ := ev.Addr.IPNet.Mask.Size()
+	require.Equal(t, 24, ones)
+
+	require.NoError(t, nl.DeleteLink(subscribeIfName))
+	waitForEvent(t, events, func(ev Event) bool {
+		return ev.Type == EventLinkRemoved && ev.Link.Index == dummy.Index
+	})
+
+	cancel()
+	require.Eventually(t, func() bool {
+		select {
+		case _, ok := <-events:
+			return !ok
+		default:
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+// TestSubscribeNoGroups tests that subscribing without groups fails.
+func TestSubscribeNoGroups(t *testing.T) {
+	_, err := NewNetlink().Subscribe(context.Background(), 0)
+	require.Error(t, err)
+}
+
+// TestDecodeRouteEvent tests decoding of a route notification with the attribute parser.
+func TestDecodeRouteEvent(t *testing.T) {
+	rt := newRtMsg(unix.AF_INET)
+	rt.Table = unix.RT_TABLE_MAIN
+	rt.Dst_len = 16
+
+	msg := newMessage(unix.RTM_DELROUTE, 0)
+	msg.addPayload(rt)
+	msg.addPayload(newAttributeIpAddress(unix.RTA_DST, net.ParseIP("10.1.0.0")))
+	msg.addPayload(newAttributeIpAddress(unix.RTA_GATEWAY, net.ParseIP("10.0.0.1")))
+	msg.addPayload(newAttributeUint32(unix.RTA_OIF, 7))
+
+	nlMsgs, err := syscall.ParseNetlinkMessage(msg.serialize())
+	require.NoError(t, err)
+	require.Len(t, nlMsgs, 1)
+
+	ev, ok := decodeEvent(&nlMsgs[0])
+	require.True(t, ok)
+	require.Equal(t, EventRouteRemoved, ev.Type)
+	require.Equal(t, "10.1.0.0/16", ev.Route.Dst.String())
+	require.True(t, ev.Route.Gw.Equal(net.ParseIP("10.0.0.1")))
+	require.Equal(t, 7, ev.Route.LinkIndex)
+}
+
+// TestDecodeIgnoresUnrelatedMessages tests that messages outside the subscribed families are dropped.
+func TestDecodeIgnoresUnrelatedMessages(t *testing.T) {
+	msg := newMessage(unix.RTM_NEWNEIGH, 0)
+	msg.addPayload(&neighMsg{Family: unix.AF_INET})
+
+	nlMsgs, err := syscall.ParseNetlinkMessage(msg.serialize())
+	require.NoError(t, err)
+
+	_, ok := decodeEvent(&nlMsgs[0])
+	require.False(t, ok)
+}
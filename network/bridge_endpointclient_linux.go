@@ -70,6 +70,11 @@ func (client *LinuxBridgeEndpointClient) AddEndpoints(epInfo *EndpointInfo) erro
 	}
 
 	client.containerMac = containerIf.HardwareAddr
+
+	if epInfo.MTU > 0 {
+		setVethMTU(client.netlink, epInfo.MTU, client.hostVethName, client.containerVethName)
+	}
+
 	return nil
 }
 
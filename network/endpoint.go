@@ -5,6 +5,7 @@ package network
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net"
 	"strings"
@@ -59,6 +60,8 @@ type endpoint struct {
 	SecondaryInterfaces map[string]*InterfaceInfo
 	// Store nic type since we no longer populate SecondaryInterfaces
 	NICType cns.NICType
+	// Attachment is set when the endpoint was created for a NetworkAttachmentDefinition
+	Attachment *AttachmentInfo `json:",omitempty"`
 }
 
 // EndpointInfo contains read-only information about an endpoint.
@@ -98,7 +101,9 @@ type EndpointInfo struct {
 	SkipDefaultRoutes        bool
 	HNSEndpointID            string
 	HNSNetworkID             string
-	HostIfName               string // unused in windows, and in linux
+	HostIfName               string          // unused in windows, and in linux
+	MTU                      int             // resolved from the cni mtu policy; zero keeps the endpoint client default
+	Attachment               *AttachmentInfo // set for secondary networks requested through a NetworkAttachmentDefinition
 	// Fields related to the network are below
 	MasterIfName                  string
 	AdapterName                   string
@@ -139,6 +144,17 @@ type InterfaceInfo struct {
 	NCResponse        *cns.GetNetworkContainerResponse
 	PnPID             string
 	EndpointPolicies  []policy.Policy
+	Attachment        *AttachmentInfo
+}
+
+// AttachmentInfo describes a secondary network attachment requested through a NetworkAttachmentDefinition.
+type AttachmentInfo struct {
+	// Name is the namespaced name of the NetworkAttachmentDefinition.
+	Name string
+	// IfName is the name of the interface inside the container.
+	IfName string
+	// Config is the attachment's network configuration, kept so its ipam can release the addresses on delete.
+	Config json.RawMessage
 }
 
 type IPConfig struct {
@@ -301,6 +317,7 @@ func (ep *endpoint) getInfo() *EndpointInfo {
 		HNSEndpointID:            ep.HnsId,
 		HostIfName:               ep.HostIfName,
 		NICType:                  ep.NICType,
+		Attachment:               ep.Attachment,
 	}
 
 	info.Routes = append(info.Routes, ep.Routes...)
@@ -128,6 +128,7 @@ func (nw *network) newEndpointImpl(
 		Routes:                   epInfo.Routes,
 		SecondaryInterfaces:      make(map[string]*InterfaceInfo),
 		NICType:                  epInfo.NICType,
+		Attachment:               epInfo.Attachment,
 	}
 	if nw.extIf != nil {
 		ep.Gateways = []net.IP{nw.extIf.IPv4Gateway}
@@ -531,6 +532,16 @@ func (nm *networkManager) updateRoutes(existingEp *EndpointInfo, targetEp *Endpo
 	return nil
 }
 
+// setVethMTU sets the mtu on each end of a veth pair. Failures are logged and do not fail the endpoint.
+func setVethMTU(nl netlink.NetlinkInterface, mtu int, ifNames ...string) {
+	for _, ifName := range ifNames {
+		logger.Info("Setting mtu on veth interface", zap.Int("MTU", mtu), zap.String("ifName", ifName))
+		if err := nl.SetLinkMTU(ifName, mtu); err != nil {
+			logger.Error("Setting mtu failed for veth", zap.String("ifName", ifName), zap.Error(err))
+		}
+	}
+}
+
 func getDefaultGateway(routes []RouteInfo) net.IP {
 	_, defDstIP, _ := net.ParseCIDR("0.0.0.0/0")
 	for _, route := range routes {
@@ -0,0 +1,359 @@
+// Copyright 2017 Microsoft. All rights reserved.
+// MIT License
+
+package network
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/restserver"
+	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/Azure/azure-container-networking/netio"
+	"github.com/Azure/azure-container-networking/store"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// MigrationClient is the subset of the CNS client used to move endpoint state between the CNI statefile and CNS.
+type MigrationClient interface {
+	GetEndpoint(ctx context.Context, endpointID string) (*restserver.GetEndpointResponse, error)
+	UpdateEndpointWithPodInfo(ctx context.Context, endpointID string, pod cns.KubernetesPodInfo, ipInfo map[string]*restserver.IPInfo) (*cns.Response, error)
+}
+
+// UnconvertibleEndpoint is a legacy endpoint that could not be carried over, with the reason why.
+type UnconvertibleEndpoint struct {
+	NetworkID   string
+	EndpointID  string
+	ContainerID string
+	Reason      string
+}
+
+// MigrationReport summarizes a state migration or rollback.
+type MigrationReport struct {
+	// Migrated holds the container IDs whose state was written to CNS, or restored from CNS on rollback.
+	Migrated []string
+	// Removed holds the container IDs dropped from the statefile on rollback because CNS no longer knows them.
+	Removed []string
+	// Unconvertible holds the endpoints that were left untouched.
+	Unconvertible []UnconvertibleEndpoint
+}
+
+// StateMigrator moves endpoint state from the stateful CNI statefile into CNS, and back for rollback.
+// The caller is expected to hold the statefile lock for the duration of Migrate and Rollback.
+type StateMigrator struct {
+	store  store.KeyValueStore
+	client MigrationClient
+	netio  netio.NetIOInterface
+	// DryRun reports what would be changed without writing to CNS or the statefile.
+	DryRun bool
+}
+
+// NewStateMigrator creates a StateMigrator for the given legacy store and CNS client.
+// netioCli is used to verify endpoints against the interfaces present on the host.
+func NewStateMigrator(st store.KeyValueStore, client MigrationClient, netioCli netio.NetIOInterface) *StateMigrator {
+	return &StateMigrator{
+		store:  st,
+		client: client,
+		netio:  netioCli,
+	}
+}
+
+// legacyEndpoint is an endpoint read from the statefile along with the network it belongs to.
+type legacyEndpoint struct {
+	networkID string
+	nw        *network
+	ep        *endpoint
+}
+
+// Migrate converts every endpoint in the statefile to CNS endpoint state and upserts it into CNS, one
+// container at a time. A container is only migrated if all of its endpoints convert and verify against
+// the host. The statefile itself is left untouched so it remains available for Rollback.
+func (m *StateMigrator) Migrate(ctx context.Context) (*MigrationReport, error) {
+	nm, err := m.readLegacyState()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &MigrationReport{}
+	containers := legacyEndpointsByContainer(nm, report)
+	for _, containerID := range sortedKeys(containers) {
+		endpointInfo, unconvertible := m.convertContainer(containers[containerID])
+		if len(unconvertible) > 0 {
+			report.Unconvertible = append(report.Unconvertible, unconvertible...)
+			continue
+		}
+
+		// CNS keeps the pod it recorded at ip allocation, and records the pod of the statefile otherwise
+		if !m.DryRun {
+			pod := cns.KubernetesPodInfo{PodName: endpointInfo.PodName, PodNamespace: endpointInfo.PodNamespace}
+			if _, err := m.client.UpdateEndpointWithPodInfo(ctx, containerID, pod, endpointInfo.IfnameToIPMap); err != nil {
+				logger.Error("Failed to migrate endpoint state to CNS", zap.String("containerID", containerID), zap.Error(err))
+				report.Unconvertible = append(report.Unconvertible, reasonForAll(containers[containerID], "CNS update failed: "+err.Error())...)
+				continue
+			}
+		}
+		logger.Info("Migrated endpoint state to CNS", zap.String("containerID", containerID), zap.Bool("dryRun", m.DryRun))
+		report.Migrated = append(report.Migrated, containerID)
+	}
+
+	return report, nil
+}
+
+// Rollback rewrites the statefile from the endpoint state held by CNS. Containers are restored from the
+// CNS copy of their state, and containers that CNS no longer knows about, because the pod was deleted
+// while running stateless, are dropped. Containers created after the migration are not in the statefile
+// and cannot be rolled back since CNS does not record the network they belong to.
+func (m *StateMigrator) Rollback(ctx context.Context) (*MigrationReport, error) {
+	nm, err := m.readLegacyState()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &MigrationReport{}
+	containers := legacyEndpointsByContainer(nm, report)
+	for _, containerID := range sortedKeys(containers) {
+		response, err := m.client.GetEndpoint(ctx, containerID)
+		if err != nil {
+			if response != nil && response.Response.ReturnCode == types.NotFound {
+				for _, lep := range containers[containerID] {
+					delete(lep.nw.Endpoints, lep.ep.Id)
+				}
+				logger.Info("Dropping endpoint state unknown to CNS", zap.String("containerID", containerID))
+				report.Removed = append(report.Removed, containerID)
+				continue
+			}
+			report.Unconvertible = append(report.Unconvertible, reasonForAll(containers[containerID], "CNS lookup failed: "+err.Error())...)
+			continue
+		}
+
+		unconvertible := restoreContainer(containers[containerID], &response.EndpointInfo)
+		if len(unconvertible) > 0 {
+			report.Unconvertible = append(report.Unconvertible, unconvertible...)
+			continue
+		}
+		report.Migrated = append(report.Migrated, containerID)
+	}
+
+	if m.DryRun {
+		return report, nil
+	}
+
+	nm.store = m.store
+	if err := nm.save(); err != nil {
+		return report, errors.Wrap(err, "failed to write statefile")
+	}
+	return report, nil
+}
+
+// Reads the statefile into a bare network manager without touching the host.
+func (m *StateMigrator) readLegacyState() (*networkManager, error) {
+	nm := &networkManager{ExternalInterfaces: make(map[string]*externalInterface)}
+	if err := m.store.Read(storeKey, nm); err != nil {
+		if errors.Is(err, store.ErrKeyNotFound) || errors.Is(err, store.ErrStoreEmpty) {
+			return nil, ErrLegacyStateNotFound
+		}
+		return nil, errors.Wrap(err, "failed to read statefile")
+	}
+
+	for _, extIf := range nm.ExternalInterfaces {
+		for _, nw := range extIf.Networks {
+			nw.extIf = extIf
+		}
+	}
+	return nm, nil
+}
+
+// Groups the endpoints of the statefile by container ID. Endpoints without a container ID cannot be
+// addressed in CNS and are reported as unconvertible.
+func legacyEndpointsByContainer(nm *networkManager, report *MigrationReport) map[string][]legacyEndpoint {
+	containers := make(map[string][]legacyEndpoint)
+	for _, extIf := range nm.ExternalInterfaces {
+		for networkID, nw := range extIf.Networks {
+			for _, ep := range nw.Endpoints {
+				if ep.ContainerID == "" {
+					report.Unconvertible = append(report.Unconvertible, UnconvertibleEndpoint{
+						NetworkID:  networkID,
+						EndpointID: ep.Id,
+						Reason:     "endpoint has no container id",
+					})
+					continue
+				}
+				containers[ep.ContainerID] = append(containers[ep.ContainerID], legacyEndpoint{networkID: networkID, nw: nw, ep: ep})
+			}
+		}
+	}
+	return containers
+}
+
+// Converts the endpoints of one container to CNS endpoint state, verifying each against the host.
+// If any endpoint fails, every endpoint of the container is reported so the container is skipped as a whole.
+func (m *StateMigrator) convertContainer(leps []legacyEndpoint) (*restserver.EndpointInfo, []UnconvertibleEndpoint) {
+	endpointInfo := &restserver.EndpointInfo{
+		PodName:       leps[0].ep.PODName,
+		PodNamespace:  leps[0].ep.PODNameSpace,
+		IfnameToIPMap: make(map[string]*restserver.IPInfo),
+	}
+
+	var failed []UnconvertibleEndpoint
+	for _, lep := range leps {
+		ifName, ipInfo, err := endpointToIPInfo(lep.ep)
+		if err == nil {
+			if _, ok := endpointInfo.IfnameToIPMap[ifName]; ok {
+				err = errors.Errorf("interface %s is used by more than one endpoint", ifName)
+			}
+		}
+		if err == nil {
+			err = m.verifyEndpoint(lep.ep)
+		}
+		if err != nil {
+			failed = append(failed, unconvertible(lep, err.Error()))
+			continue
+		}
+		endpointInfo.IfnameToIPMap[ifName] = ipInfo
+	}
+
+	if len(failed) == 0 {
+		return endpointInfo, nil
+	}
+	for _, lep := range leps {
+		if !containsEndpoint(failed, lep.ep.Id) {
+			failed = append(failed, unconvertible(lep, "another endpoint of the container is unconvertible"))
+		}
+	}
+	return nil, failed
+}
+
+// Converts a legacy endpoint to the interface name and IPInfo CNS keys it by. Endpoints written before
+// the nic type was recorded are infra endpoints.
+func endpointToIPInfo(ep *endpoint) (string, *restserver.IPInfo, error) {
+	ifName := ep.IfName
+	if ifName == "" {
+		ifName = InfraInterfaceName
+	}
+	nicType := ep.NICType
+	if nicType == "" {
+		nicType = cns.InfraNIC
+	}
+
+	ipInfo := &restserver.IPInfo{
+		NICType:       nicType,
+		HnsEndpointID: ep.HnsId,
+		HnsNetworkID:  ep.HNSNetworkID,
+		HostVethName:  ep.HostIfName,
+	}
+	if len(ep.MacAddress) > 0 {
+		ipInfo.MacAddress = ep.MacAddress.String()
+	}
+	for _, ipAddr := range ep.IPAddresses {
+		if ipAddr.IP.To4() != nil {
+			ipInfo.IPv4 = append(ipInfo.IPv4, ipAddr)
+		} else {
+			ipInfo.IPv6 = append(ipInfo.IPv6, ipAddr)
+		}
+	}
+
+	if nicType == cns.InfraNIC && len(ep.IPAddresses) == 0 {
+		return "", nil, errors.New("infra endpoint has no ip addresses")
+	}
+	if ipInfo.HostVethName == "" && ipInfo.HnsEndpointID == "" && ipInfo.MacAddress == "" {
+		return "", nil, errors.New("endpoint has no host interface, hns endpoint or mac address")
+	}
+	return ifName, ipInfo, nil
+}
+
+// Applies the CNS state of a container onto its legacy endpoints. Every legacy endpoint must have a
+// matching interface in CNS, otherwise the container is left as it is in the statefile.
+func restoreContainer(leps []legacyEndpoint, endpointInfo *restserver.EndpointInfo) []UnconvertibleEndpoint {
+	ipInfos := make(map[string]*restserver.IPInfo, len(endpointInfo.IfnameToIPMap))
+	for ifName, ipInfo := range endpointInfo.IfnameToIPMap {
+		// state created by a stateful cni before the interface name was recorded
+		if ifName == "" {
+			ifName = InfraInterfaceName
+		}
+		ipInfos[ifName] = ipInfo
+	}
+
+	var failed []UnconvertibleEndpoint
+	for _, lep := range leps {
+		ifName := lep.ep.IfName
+		if ifName == "" {
+			ifName = InfraInterfaceName
+		}
+		if _, ok := ipInfos[ifName]; !ok {
+			failed = append(failed, unconvertible(lep, fmt.Sprintf("interface %s not found in CNS", ifName)))
+		}
+	}
+	if len(failed) > 0 {
+		return failed
+	}
+
+	for _, lep := range leps {
+		ifName := lep.ep.IfName
+		if ifName == "" {
+			ifName = InfraInterfaceName
+		}
+		ipInfo := ipInfos[ifName]
+		if ipInfo.HostVethName != "" {
+			lep.ep.HostIfName = ipInfo.HostVethName
+		}
+		if ipInfo.HnsEndpointID != "" {
+			lep.ep.HnsId = ipInfo.HnsEndpointID
+		}
+		if ipInfo.HnsNetworkID != "" {
+			lep.ep.HNSNetworkID = ipInfo.HnsNetworkID
+		}
+		if ipInfo.NICType != "" {
+			lep.ep.NICType = ipInfo.NICType
+		}
+		if mac, err := net.ParseMAC(ipInfo.MacAddress); err == nil {
+			lep.ep.MacAddress = mac
+		}
+		if len(ipInfo.IPv4)+len(ipInfo.IPv6) > 0 {
+			lep.ep.IPAddresses = append(append([]net.IPNet{}, ipInfo.IPv4...), ipInfo.IPv6...)
+		}
+		if endpointInfo.PodName != "" {
+			lep.ep.PODName = endpointInfo.PodName
+			lep.ep.PODNameSpace = endpointInfo.PodNamespace
+		}
+	}
+	return nil
+}
+
+func unconvertible(lep legacyEndpoint, reason string) UnconvertibleEndpoint {
+	return UnconvertibleEndpoint{
+		NetworkID:   lep.networkID,
+		EndpointID:  lep.ep.Id,
+		ContainerID: lep.ep.ContainerID,
+		Reason:      reason,
+	}
+}
+
+func reasonForAll(leps []legacyEndpoint, reason string) []UnconvertibleEndpoint {
+	ret := make([]UnconvertibleEndpoint, 0, len(leps))
+	for _, lep := range leps {
+		ret = append(ret, unconvertible(lep, reason))
+	}
+	return ret
+}
+
+func containsEndpoint(eps []UnconvertibleEndpoint, endpointID string) bool {
+	for i := range eps {
+		if eps[i].EndpointID == endpointID {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(containers map[string][]legacyEndpoint) []string {
+	keys := make([]string, 0, len(containers))
+	for k := range containers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,25 @@
+// Copyright 2017 Microsoft. All rights reserved.
+// MIT License
+
+package network
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// Verifies that the network namespace and host veth of a legacy endpoint still exist on the host.
+func (m *StateMigrator) verifyEndpoint(ep *endpoint) error {
+	if ep.NetworkNameSpace != "" {
+		if _, err := os.Stat(ep.NetworkNameSpace); err != nil {
+			return errors.Errorf("network namespace %s not found", ep.NetworkNameSpace)
+		}
+	}
+	if ep.HostIfName != "" {
+		if _, err := m.netio.GetNetworkInterfaceByName(ep.HostIfName); err != nil {
+			return errors.Errorf("host interface %s not found", ep.HostIfName)
+		}
+	}
+	return nil
+}
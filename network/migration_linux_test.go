@@ -0,0 +1,203 @@
+//go:build linux
+// +build linux
+
+package network
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/restserver"
+	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/Azure/azure-container-networking/netio"
+	"github.com/Azure/azure-container-networking/store"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+var errMockCNSNotFound = errors.New("endpoint not found")
+
+// mockMigrationClient keeps endpoint state in memory the way CNS does.
+type mockMigrationClient struct {
+	endpoints map[string]*restserver.EndpointInfo
+}
+
+func (c *mockMigrationClient) GetEndpoint(_ context.Context, endpointID string) (*restserver.GetEndpointResponse, error) {
+	response := &restserver.GetEndpointResponse{}
+	endpointInfo, ok := c.endpoints[endpointID]
+	if !ok {
+		response.Response.ReturnCode = types.NotFound
+		return response, errMockCNSNotFound
+	}
+	response.EndpointInfo = *endpointInfo
+	return response, nil
+}
+
+func (c *mockMigrationClient) UpdateEndpointWithPodInfo(_ context.Context, endpointID string, pod cns.KubernetesPodInfo, ipInfo map[string]*restserver.IPInfo) (*cns.Response, error) {
+	c.endpoints[endpointID] = &restserver.EndpointInfo{PodName: pod.PodName, PodNamespace: pod.PodNamespace, IfnameToIPMap: ipInfo}
+	return &cns.Response{}, nil
+}
+
+func newLegacyStore(t *testing.T, endpoints map[string]*endpoint) store.KeyValueStore {
+	st := store.NewMockStore("")
+	nm := &networkManager{
+		ExternalInterfaces: map[string]*externalInterface{
+			"eth0": {
+				Name: "eth0",
+				Networks: map[string]*network{
+					"azure": {Id: "azure", Mode: opModeTransparent, Endpoints: endpoints},
+				},
+			},
+		},
+	}
+	require.NoError(t, st.Write(storeKey, nm))
+	return st
+}
+
+func TestStateMigratorMigrate(t *testing.T) {
+	netns := filepath.Join(t.TempDir(), "netns")
+	require.NoError(t, os.WriteFile(netns, nil, 0o600))
+
+	st := newLegacyStore(t, map[string]*endpoint{
+		"aaaaaaaa-eth0": {
+			Id:               "aaaaaaaa-eth0",
+			ContainerID:      "aaaaaaaa",
+			IfName:           "eth0",
+			HostIfName:       "azvaaaaaaaa",
+			NetworkNameSpace: netns,
+			PODName:          "pod-a",
+			PODNameSpace:     "default",
+			IPAddresses: []net.IPNet{
+				{IP: net.ParseIP("10.240.0.5"), Mask: net.CIDRMask(16, 32)},
+				{IP: net.ParseIP("fd00::5"), Mask: net.CIDRMask(64, 128)},
+			},
+		},
+		"bbbbbbbb-eth0": {
+			Id:               "bbbbbbbb-eth0",
+			ContainerID:      "bbbbbbbb",
+			IfName:           "eth0",
+			HostIfName:       "azvbbbbbbbb",
+			NetworkNameSpace: filepath.Join(t.TempDir(), "gone"),
+			IPAddresses:      []net.IPNet{{IP: net.ParseIP("10.240.0.6"), Mask: net.CIDRMask(16, 32)}},
+		},
+		"cccccccc-eth0": {
+			Id:          "cccccccc-eth0",
+			ContainerID: "cccccccc",
+			IfName:      "eth0",
+			HostIfName:  "azvcccccccc",
+		},
+		"no-container": {
+			Id:          "no-container",
+			HostIfName:  "azvdddddddd",
+			IPAddresses: []net.IPNet{{IP: net.ParseIP("10.240.0.8"), Mask: net.CIDRMask(16, 32)}},
+		},
+	})
+	client := &mockMigrationClient{endpoints: map[string]*restserver.EndpointInfo{}}
+
+	migrator := NewStateMigrator(st, client, netio.NewMockNetIO(false, 0))
+	migrator.DryRun = true
+	report, err := migrator.Migrate(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"aaaaaaaa"}, report.Migrated)
+	require.Empty(t, client.endpoints)
+
+	migrator.DryRun = false
+	report, err = migrator.Migrate(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"aaaaaaaa"}, report.Migrated)
+	require.Len(t, report.Unconvertible, 3)
+
+	reasons := map[string]string{}
+	for _, u := range report.Unconvertible {
+		reasons[u.EndpointID] = u.Reason
+	}
+	require.Contains(t, reasons["bbbbbbbb-eth0"], "network namespace")
+	require.Equal(t, "infra endpoint has no ip addresses", reasons["cccccccc-eth0"])
+	require.Equal(t, "endpoint has no container id", reasons["no-container"])
+
+	require.Equal(t, "pod-a", client.endpoints["aaaaaaaa"].PodName)
+	require.Equal(t, "default", client.endpoints["aaaaaaaa"].PodNamespace)
+	ipInfo := client.endpoints["aaaaaaaa"].IfnameToIPMap["eth0"]
+	require.Equal(t, cns.InfraNIC, ipInfo.NICType)
+	require.Equal(t, "azvaaaaaaaa", ipInfo.HostVethName)
+	require.Len(t, ipInfo.IPv4, 1)
+	require.Len(t, ipInfo.IPv6, 1)
+}
+
+func TestStateMigratorMigrateMissingVeth(t *testing.T) {
+	st := newLegacyStore(t, map[string]*endpoint{
+		"aaaaaaaa-eth0": {
+			Id:          "aaaaaaaa-eth0",
+			ContainerID: "aaaaaaaa",
+			IfName:      "eth0",
+			HostIfName:  "azvaaaaaaaa",
+			IPAddresses: []net.IPNet{{IP: net.ParseIP("10.240.0.5"), Mask: net.CIDRMask(16, 32)}},
+		},
+	})
+	client := &mockMigrationClient{endpoints: map[string]*restserver.EndpointInfo{}}
+
+	report, err := NewStateMigrator(st, client, netio.NewMockNetIO(true, 1)).Migrate(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, report.Migrated)
+	require.Len(t, report.Unconvertible, 1)
+	require.Equal(t, "host interface azvaaaaaaaa not found", report.Unconvertible[0].Reason)
+	require.Empty(t, client.endpoints)
+}
+
+func TestStateMigratorRollback(t *testing.T) {
+	st := newLegacyStore(t, map[string]*endpoint{
+		"aaaaaaaa-eth0": {
+			Id:          "aaaaaaaa-eth0",
+			ContainerID: "aaaaaaaa",
+			IfName:      "eth0",
+			HostIfName:  "azvaaaaaaaa",
+			IPAddresses: []net.IPNet{{IP: net.ParseIP("10.240.0.5"), Mask: net.CIDRMask(16, 32)}},
+		},
+		"bbbbbbbb-eth0": {
+			Id:          "bbbbbbbb-eth0",
+			ContainerID: "bbbbbbbb",
+			IfName:      "eth0",
+			HostIfName:  "azvbbbbbbbb",
+			IPAddresses: []net.IPNet{{IP: net.ParseIP("10.240.0.6"), Mask: net.CIDRMask(16, 32)}},
+		},
+	})
+	// the pod behind bbbbbbbb was deleted while running stateless, and aaaaaaaa was migrated
+	// before the interface name was recorded
+	client := &mockMigrationClient{endpoints: map[string]*restserver.EndpointInfo{
+		"aaaaaaaa": {
+			PodName:      "pod-a",
+			PodNamespace: "default",
+			IfnameToIPMap: map[string]*restserver.IPInfo{
+				"": {
+					IPv4:         []net.IPNet{{IP: net.ParseIP("10.240.0.7"), Mask: net.CIDRMask(16, 32)}},
+					HostVethName: "azvrecreated",
+				},
+			},
+		},
+	}}
+
+	report, err := NewStateMigrator(st, client, netio.NewMockNetIO(false, 0)).Rollback(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"aaaaaaaa"}, report.Migrated)
+	require.Equal(t, []string{"bbbbbbbb"}, report.Removed)
+	require.Empty(t, report.Unconvertible)
+
+	nm := &networkManager{}
+	require.NoError(t, st.Read(storeKey, nm))
+	endpoints := nm.ExternalInterfaces["eth0"].Networks["azure"].Endpoints
+	require.Len(t, endpoints, 1)
+	ep := endpoints["aaaaaaaa-eth0"]
+	require.Equal(t, "azvrecreated", ep.HostIfName)
+	require.Equal(t, "pod-a", ep.PODName)
+	require.Equal(t, "10.240.0.7", ep.IPAddresses[0].IP.String())
+}
+
+func TestStateMigratorEmptyStore(t *testing.T) {
+	client := &mockMigrationClient{endpoints: map[string]*restserver.EndpointInfo{}}
+	_, err := NewStateMigrator(store.NewMockStore(""), client, netio.NewMockNetIO(false, 0)).Migrate(context.Background())
+	require.ErrorIs(t, err, ErrLegacyStateNotFound)
+}
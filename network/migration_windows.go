@@ -0,0 +1,19 @@
+// Copyright 2017 Microsoft. All rights reserved.
+// MIT License
+
+package network
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Verifies that the hns endpoint of a legacy endpoint still exists on the host.
+func (m *StateMigrator) verifyEndpoint(ep *endpoint) error {
+	if ep.HnsId == "" {
+		return nil
+	}
+	if _, err := Hnsv2.GetEndpointByID(ep.HnsId); err != nil {
+		return errors.Errorf("hns endpoint %s not found", ep.HnsId)
+	}
+	return nil
+}
@@ -94,6 +94,10 @@ func (client *OVSEndpointClient) AddEndpoints(epInfo *EndpointInfo) error {
 
 	client.containerMac = containerIf.HardwareAddr.String()
 
+	if epInfo.MTU > 0 {
+		setVethMTU(client.netlink, epInfo.MTU, client.hostVethName, client.containerVethName)
+	}
+
 	if err := client.AddSnatEndpoint(); err != nil {
 		return err
 	}
@@ -59,8 +59,15 @@ func (client *SecondaryEndpointClient) AddEndpoints(epInfo *EndpointInfo) error
 	}
 
 	epInfo.IfName = iface.Name
-	if _, exists := client.ep.SecondaryInterfaces[iface.Name]; exists {
-		return newErrorSecondaryEndpointClient(errors.New(iface.Name + " already exists"))
+	if _, exists := client.ep.SecondaryInterfaces[containerIfName(epInfo)]; exists {
+		return newErrorSecondaryEndpointClient(errors.New(containerIfName(epInfo) + " already exists"))
+	}
+
+	if epInfo.MTU > 0 {
+		logger.Info("Setting mtu on secondary interface", zap.Int("MTU", epInfo.MTU), zap.String("ifName", iface.Name))
+		if err = client.netlink.SetLinkMTU(iface.Name, epInfo.MTU); err != nil {
+			return newErrorSecondaryEndpointClient(err)
+		}
 	}
 
 	ipconfigs := make([]*IPConfig, len(epInfo.IPAddresses))
@@ -68,7 +75,8 @@ func (client *SecondaryEndpointClient) AddEndpoints(epInfo *EndpointInfo) error
 		ipconfigs[i] = &IPConfig{Address: ipconfig}
 	}
 
-	client.ep.SecondaryInterfaces[iface.Name] = &InterfaceInfo{
+	// attachments are renamed once inside the container, so they are tracked by their container name
+	client.ep.SecondaryInterfaces[containerIfName(epInfo)] = &InterfaceInfo{
 		Name:              iface.Name,
 		MacAddress:        epInfo.MacAddress,
 		IPConfigs:         ipconfigs,
@@ -97,6 +105,14 @@ func (client *SecondaryEndpointClient) MoveEndpointsToContainerNS(epInfo *Endpoi
 }
 
 func (client *SecondaryEndpointClient) SetupContainerInterfaces(epInfo *EndpointInfo) error {
+	if epInfo.Attachment != nil && epInfo.Attachment.IfName != epInfo.IfName {
+		logger.Info("[net] Renaming attachment link", zap.String("IfName", epInfo.IfName), zap.String("newName", epInfo.Attachment.IfName))
+		if err := client.netlink.SetLinkName(epInfo.IfName, epInfo.Attachment.IfName); err != nil {
+			return newErrorSecondaryEndpointClient(err)
+		}
+		epInfo.IfName = epInfo.Attachment.IfName
+	}
+
 	logger.Info("[net] Setting link state up.", zap.String("IfName", epInfo.IfName))
 	if err := client.netlink.SetLinkState(epInfo.IfName, true); err != nil {
 		return newErrorSecondaryEndpointClient(err)
@@ -132,6 +148,12 @@ func (client *SecondaryEndpointClient) ConfigureContainerInterfacesAndRoutes(epI
 
 	ifInfo.Routes = append(ifInfo.Routes, epInfo.Routes...)
 
+	// attachments get their addresses from their own ipam rather than the host, so there is no mapping to create
+	if epInfo.Attachment != nil {
+		logger.Info("Finished configuring container interfaces and routes for attachment", zap.String("attachment", epInfo.Attachment.Name))
+		return nil
+	}
+
 	// issue dhcp discover packet to ensure mapping created for dns via wireserver to work
 	// we do not use the response for anything
 	numSecs := 3
@@ -188,8 +210,18 @@ func (client *SecondaryEndpointClient) DeleteEndpoints(ep *endpoint) error {
 		}
 	}()
 	// TODO: For stateless cni linux, check if delegated vmnic type, and if so, delete using this *endpoint* struct's ifname
-	for iface := range ep.SecondaryInterfaces {
-		if err := client.netlink.SetLinkNetNs(iface, uintptr(vmns)); err != nil {
+	for iface, ifInfo := range ep.SecondaryInterfaces {
+		// restore the host name of a renamed attachment before handing it back to the host
+		hostIfName := iface
+		if ifInfo != nil && ifInfo.Name != "" && ifInfo.Name != iface {
+			if err := client.netlink.SetLinkName(iface, ifInfo.Name); err != nil {
+				logger.Error("Failed to rename interface", zap.String("IfName", iface), zap.Error(newErrorSecondaryEndpointClient(err)))
+				continue
+			}
+			hostIfName = ifInfo.Name
+		}
+
+		if err := client.netlink.SetLinkNetNs(hostIfName, uintptr(vmns)); err != nil {
 			logger.Error("Failed to move interface", zap.String("IfName", iface), zap.Error(newErrorSecondaryEndpointClient(err)))
 			continue
 		}
@@ -199,3 +231,11 @@ func (client *SecondaryEndpointClient) DeleteEndpoints(ep *endpoint) error {
 
 	return nil
 }
+
+// Returns the name the interface will have inside the container.
+func containerIfName(epInfo *EndpointInfo) string {
+	if epInfo.Attachment != nil && epInfo.Attachment.IfName != "" {
+		return epInfo.Attachment.IfName
+	}
+	return epInfo.IfName
+}
@@ -378,3 +378,38 @@ func TestSecondaryConfigureContainerInterfacesAndRoutes(t *testing.T) {
 		})
 	}
 }
+
+func TestSecondaryAttachmentEndpoint(t *testing.T) {
+	nl := netlink.NewMockNetlink(false, "")
+	plc := platform.NewMockExecClient(false)
+	mac, _ := net.ParseMAC("ab:cd:ef:12:34:56")
+
+	client := &SecondaryEndpointClient{
+		netlink:        nl,
+		plClient:       plc,
+		netUtilsClient: networkutils.NewNetworkUtils(nl, plc),
+		netioshim:      netio.NewMockNetIO(false, 0),
+		ep:             &endpoint{SecondaryInterfaces: make(map[string]*InterfaceInfo)},
+		// no dhcp client, attachments must not send a discover
+	}
+	epInfo := &EndpointInfo{
+		MacAddress: mac,
+		Attachment: &AttachmentInfo{Name: "default/storage", IfName: "net1"},
+		IPAddresses: []net.IPNet{
+			{IP: net.ParseIP("192.168.0.4"), Mask: net.CIDRMask(subnetv4Mask, ipv4Bits)},
+		},
+		Routes: []RouteInfo{
+			{Dst: net.IPNet{IP: net.ParseIP("192.168.0.0"), Mask: net.CIDRMask(subnetv4Mask, ipv4Bits)}},
+		},
+	}
+
+	require.NoError(t, client.AddEndpoints(epInfo))
+	require.Equal(t, "eth1", epInfo.IfName)
+	require.Equal(t, "eth1", client.ep.SecondaryInterfaces["net1"].Name, "attachments are tracked by their container name")
+
+	require.NoError(t, client.SetupContainerInterfaces(epInfo))
+	require.Equal(t, "net1", epInfo.IfName)
+
+	require.NoError(t, client.ConfigureContainerInterfacesAndRoutes(epInfo))
+	require.Len(t, client.ep.SecondaryInterfaces["net1"].Routes, 1)
+}
@@ -122,16 +122,12 @@ func (client *TransparentEndpointClient) AddEndpoints(epInfo *EndpointInfo) erro
 
 	client.hostVethMac = hostVethIf.HardwareAddr
 
-	logger.Info("Setting mtu on veth interface", zap.Int("MTU", primaryIf.MTU), zap.String("hostVethName", client.hostVethName))
-	if err := client.netlink.SetLinkMTU(client.hostVethName, primaryIf.MTU); err != nil {
-		logger.Error("Setting mtu failed for hostveth", zap.String("hostVethName", client.hostVethName),
-			zap.Error(err))
-	}
-
-	if err := client.netlink.SetLinkMTU(client.containerVethName, primaryIf.MTU); err != nil {
-		logger.Error("Setting mtu failed for containerveth", zap.String("containerVethName", client.containerVethName),
-			zap.Error(err))
+	// copy the primary interface mtu unless the mtu policy resolved a value
+	mtu := primaryIf.MTU
+	if epInfo.MTU > 0 {
+		mtu = epInfo.MTU
 	}
+	setVethMTU(client.netlink, mtu, client.hostVethName, client.containerVethName)
 
 	return nil
 }
@@ -21,10 +21,15 @@ func NewClient(c Config) (*Client, error) {
 		return nil, errors.Wrap(err, "validating config")
 	}
 
+	transport := http.DefaultTransport
+	if c.WrapTransport != nil {
+		transport = c.WrapTransport(transport)
+	}
+
 	client := &Client{
 		httpClient: &http.Client{
 			Transport: &internal.WireserverTransport{
-				Transport: http.DefaultTransport,
+				Transport: transport,
 			},
 		},
 		host:      c.Host,
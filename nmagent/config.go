@@ -2,6 +2,7 @@ package nmagent
 
 import (
 	"net"
+	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
@@ -22,6 +23,12 @@ type Config struct {
 	// Optional Config //
 	/////////////////////
 	UseTLS bool // forces all connections to use TLS
+
+	// WrapTransport wraps the transport used for every request, for example to
+	// instrument it. The wrapped transport sits inside the client's own
+	// wireserver handling, so it sees requests once they were rewritten for the
+	// wireserver.
+	WrapTransport func(http.RoundTripper) http.RoundTripper
 }
 
 // Validate reports whether this configuration is a valid configuration for a
@@ -9,6 +9,8 @@ import (
 
 	"github.com/Azure/azure-container-networking/common"
 	"github.com/Azure/azure-container-networking/log"
+	logv2 "github.com/Azure/azure-container-networking/log/v2"
+	cores "github.com/Azure/azure-container-networking/log/v2/cores"
 	"github.com/Azure/azure-container-networking/npm"
 	npmconfig "github.com/Azure/azure-container-networking/npm/config"
 	restserver "github.com/Azure/azure-container-networking/npm/http/server"
@@ -31,6 +33,9 @@ import (
 	"k8s.io/utils/exec"
 )
 
+// npmLogComponent is the component name of the NPM logger, which can be given its own Level in the Logging config.
+const npmLogComponent = "npm"
+
 var npmV2DataplaneCfg = &dataplane.Config{
 	IPSetManagerCfg: &ipsets.IPSetManagerCfg{
 		// NOTE: NetworkName and IPSetMode must be set later by the npm ConfigMap or default config
@@ -81,7 +86,7 @@ func start(config npmconfig.Config, flags npmconfig.Flags) error {
 
 	var err error
 
-	err = initLogging()
+	err = initLogging(&config)
 	if err != nil {
 		return err
 	}
@@ -223,7 +228,7 @@ func start(config npmconfig.Config, flags npmconfig.Flags) error {
 	select {}
 }
 
-func initLogging() error {
+func initLogging(config *npmconfig.Config) error {
 	log.SetName("azure-npm")
 	log.SetLevel(log.LevelInfo)
 	if err := log.SetTargetLogDirectory(log.TargetStdout, ""); err != nil {
@@ -231,6 +236,18 @@ func initLogging() error {
 		return fmt.Errorf("%w", err)
 	}
 
+	// move the legacy logger onto the shared zap pipeline
+	cfg := config.Logging
+	if cfg == nil {
+		cfg = &logv2.Config{Level: "info", Stdout: &cores.StdoutConfig{}}
+		config.Logging = cfg
+	}
+	z, _, err := logv2.New(cfg)
+	if err != nil {
+		log.Logf("Failed to build logger, continuing with the legacy logger, err:%v.", err)
+		return nil
+	}
+	log.SetZapLogger(z.Named(npmLogComponent))
 	return nil
 }
 
@@ -58,7 +58,7 @@ func startDaemon(config npmconfig.Config) error {
 
 	addr := config.Transport.Address + ":" + strconv.Itoa(config.Transport.ServicePort)
 	ctx := context.Background()
-	err := initLogging()
+	err := initLogging(&config)
 	if err != nil {
 		klog.Errorf("failed to init logging : %v", err)
 		return err
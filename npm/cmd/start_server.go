@@ -54,7 +54,7 @@ func startControlplane(config npmconfig.Config, flags npmconfig.Flags) error {
 
 	var err error
 
-	err = initLogging()
+	err = initLogging(&config)
 	if err != nil {
 		klog.Errorf("failed to init logging : %v", err)
 		return err
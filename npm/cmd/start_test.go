@@ -4,12 +4,16 @@ import (
 	"testing"
 
 	"github.com/Azure/azure-container-networking/log"
+	npmconfig "github.com/Azure/azure-container-networking/npm/config"
 	"github.com/stretchr/testify/require"
 )
 
 func TestInitLogging(t *testing.T) {
 	expectedLogPath := log.LogPath
-	err := initLogging()
+	config := npmconfig.DefaultConfig
+	err := initLogging(&config)
 	require.NoError(t, err)
 	require.Equal(t, expectedLogPath, log.GetLogDirectory())
+	require.NotNil(t, config.Logging)
+	require.Nil(t, npmconfig.DefaultConfig.Logging)
 }
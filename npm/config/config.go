@@ -1,6 +1,9 @@
 package npmconfig
 
-import "github.com/Azure/azure-container-networking/npm/util"
+import (
+	logv2 "github.com/Azure/azure-container-networking/log/v2"
+	"github.com/Azure/azure-container-networking/npm/util"
+)
 
 const (
 	defaultResyncPeriod         = 15
@@ -86,6 +89,9 @@ type Config struct {
 	NetPolInvervalInMilliseconds int     `json:"NetPolInvervalInMilliseconds,omitempty"`
 	Toggles                      Toggles `json:"Toggles,omitempty"`
 	LogLevel                     string  `json:"LogLevel,omitempty"`
+	// Logging configures the logs of NPM in the schema shared with the other ACN components.
+	// Logs are written to stdout at info level when it is not set.
+	Logging *logv2.Config `json:"Logging,omitempty"`
 }
 
 type Toggles struct {
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"flag"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	logv2 "github.com/Azure/azure-container-networking/log/v2"
+	"github.com/Azure/azure-container-networking/npm/util"
+	"github.com/pkg/errors"
+	"go.uber.org/zap/zapcore"
+	"k8s.io/klog"
+	klogv2 "k8s.io/klog/v2"
+)
+
+// ComponentDataplane is the component of NPM which programs ipsets, iptables and HNS policies.
+const ComponentDataplane = "dataplane"
+
+// componentFiles are the klog -vmodule patterns matching the source files of each component, whose
+// debug logs are gated by klog.V(util.KlogDebugVerbosity).
+var componentFiles = map[string][]string{
+	ComponentDataplane: {"dataplane*", "ipsetmanager*", "policymanager*", "chain-management*", "dirtycache*"},
+}
+
+// Components lists every component of NPM which can be given a level of its own.
+var Components = []string{ComponentDataplane}
+
+// klogLevels is a logv2.LevelController for NPM. The general level drives the klog verbosity and the
+// logger built from the Logging config, and component levels drive the klog verbosity of their files.
+type klogLevels struct {
+	// flags are those of klog, which NPM logs through, and of klog/v2, which the Kubernetes clients log through.
+	flags  []*flag.FlagSet
+	logger *logv2.Config
+
+	mu         sync.Mutex
+	general    zapcore.Level
+	components map[string]zapcore.Level
+}
+
+func newKlogLevels(logger *logv2.Config) *klogLevels {
+	flags := flag.NewFlagSet("klog", flag.ContinueOnError)
+	klog.InitFlags(flags)
+	flagsV2 := flag.NewFlagSet("klogv2", flag.ContinueOnError)
+	klogv2.InitFlags(flagsV2)
+	general := zapcore.InfoLevel
+	if logger != nil {
+		if lvl, err := zapcore.ParseLevel(logger.Level); err == nil {
+			general = lvl
+		}
+	}
+	return &klogLevels{
+		flags:      []*flag.FlagSet{flags, flagsV2},
+		logger:     logger,
+		general:    general,
+		components: map[string]zapcore.Level{},
+	}
+}
+
+func (k *klogLevels) Levels() (general string, components map[string]string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	components = map[string]string{}
+	for name, lvl := range k.components {
+		components[name] = lvl.String()
+	}
+	return k.general.String(), components
+}
+
+func (k *klogLevels) SetLevel(level string) error {
+	lvl, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse level")
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if err := k.setFlag("v", strconv.Itoa(verbosity(lvl))); err != nil {
+		return errors.Wrap(err, "failed to set klog verbosity")
+	}
+	if k.logger != nil {
+		if err := k.logger.SetLevel(level); err != nil {
+			return errors.Wrap(err, "failed to set logger level")
+		}
+	}
+	k.general = lvl
+	return nil
+}
+
+func (k *klogLevels) SetComponentLevel(component, level string) error {
+	if _, ok := componentFiles[component]; !ok {
+		return errors.Errorf("unknown component %s", component)
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	components := map[string]zapcore.Level{}
+	for name, lvl := range k.components {
+		components[name] = lvl
+	}
+	if level == "" {
+		delete(components, component)
+	} else {
+		lvl, err := zapcore.ParseLevel(level)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse level of component %s", component)
+		}
+		components[component] = lvl
+	}
+	if err := k.setFlag("vmodule", vmodule(components)); err != nil {
+		return errors.Wrap(err, "failed to set klog vmodule")
+	}
+	k.components = components
+	return nil
+}
+
+func (k *klogLevels) setFlag(name, value string) error {
+	for _, flags := range k.flags {
+		if err := flags.Set(name, value); err != nil {
+			return err //nolint:wrapcheck // wrapped by the caller
+		}
+	}
+	return nil
+}
+
+func verbosity(lvl zapcore.Level) int {
+	if lvl <= zapcore.DebugLevel {
+		return util.KlogDebugVerbosity
+	}
+	return 0
+}
+
+// vmodule builds the klog -vmodule value giving the files of each component its verbosity.
+func vmodule(components map[string]zapcore.Level) string {
+	patterns := []string{}
+	for name, lvl := range components {
+		for _, file := range componentFiles[name] {
+			patterns = append(patterns, file+"="+strconv.Itoa(verbosity(lvl)))
+		}
+	}
+	sort.Strings(patterns)
+	return strings.Join(patterns, ",")
+}
@@ -0,0 +1,38 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKlogLevels(t *testing.T) {
+	k := newKlogLevels(nil)
+
+	// both klog and klog/v2 follow the levels
+	flag := func(name string) []string {
+		values := []string{}
+		for _, flags := range k.flags {
+			values = append(values, flags.Lookup(name).Value.String())
+		}
+		return values
+	}
+
+	require.NoError(t, k.SetLevel("debug"))
+	require.Equal(t, []string{"4", "4"}, flag("v"))
+	require.NoError(t, k.SetLevel("info"))
+	require.Equal(t, []string{"0", "0"}, flag("v"))
+
+	require.NoError(t, k.SetComponentLevel(ComponentDataplane, "debug"))
+	for _, vmodule := range flag("vmodule") {
+		require.Contains(t, vmodule, "ipsetmanager*=4")
+	}
+	general, components := k.Levels()
+	require.Equal(t, "info", general)
+	require.Equal(t, map[string]string{ComponentDataplane: "debug"}, components)
+
+	require.NoError(t, k.SetComponentLevel(ComponentDataplane, ""))
+	require.Equal(t, []string{"", ""}, flag("vmodule"))
+	require.Error(t, k.SetComponentLevel("controlplane", "debug"))
+	require.Error(t, k.SetLevel("loud"))
+}
@@ -8,6 +8,7 @@ import (
 	_ "net/http/pprof"
 
 	"github.com/Azure/azure-container-networking/log"
+	logv2 "github.com/Azure/azure-container-networking/log/v2"
 	npmconfig "github.com/Azure/azure-container-networking/npm/config"
 	"github.com/Azure/azure-container-networking/npm/http/api"
 	"github.com/Azure/azure-container-networking/npm/metrics"
@@ -32,6 +33,13 @@ func NPMRestServerListenAndServe(config npmconfig.Config, npmEncoder json.Marsha
 		rs.router.Handle(api.ClusterMetricsPath, metrics.GetHandler(metrics.ClusterMetrics))
 	}
 
+	if config.Toggles.EnableHTTPDebugAPI {
+		levels := logv2.NewLevelHandler(newKlogLevels(config.Logging), Components, func(previous, current logv2.LevelResponse) {
+			klog.Infof("log levels changed from %+v to %+v", previous, current)
+		})
+		rs.router.Handle(api.LogLevelPath, levels).Methods(http.MethodGet, http.MethodPut)
+	}
+
 	// the nil check is for fan-out npm
 	if config.Toggles.EnableHTTPDebugAPI && npmEncoder != nil {
 		// ACN CLI debug handlers
@@ -257,8 +257,7 @@ func (dp *DataPlane) AddToSets(setNames []*ipsets.IPSetMetadata, podMetadata *Po
 	}
 
 	if dp.shouldUpdatePod() && podMetadata.NodeName == dp.nodeName {
-		// TODO: Refactor non-error/warning klogs with Zap and set the following logs to "debug" level
-		// klog.Infof("[DataPlane] Updating Sets to Add for pod key %s", podMetadata.PodKey)
+		klog.V(util.KlogDebugVerbosity).Infof("[DataPlane] Updating Sets to Add for pod key %s", podMetadata.PodKey)
 
 		// lock updatePodCache while reading/modifying or setting the updatePod in the cache
 		dp.updatePodCache.Lock()
@@ -280,8 +279,7 @@ func (dp *DataPlane) RemoveFromSets(setNames []*ipsets.IPSetMetadata, podMetadat
 	}
 
 	if dp.shouldUpdatePod() && podMetadata.NodeName == dp.nodeName {
-		// TODO: Refactor non-error/warning klogs with Zap and set the following logs to "debug" level
-		// klog.Infof("[DataPlane] Updating Sets to Remove for pod key %s", podMetadata.PodKey)
+		klog.V(util.KlogDebugVerbosity).Infof("[DataPlane] Updating Sets to Remove for pod key %s", podMetadata.PodKey)
 
 		// lock updatePodCache while reading/modifying or setting the updatePod in the cache
 		dp.updatePodCache.Lock()
@@ -330,12 +328,10 @@ func (dp *DataPlane) ApplyDataPlane() error {
 	newCount := dp.applyInfo.numBatches
 	dp.applyInfo.Unlock()
 
-	// TODO: Refactor non-error/warning klogs with Zap and set the following logs to "debug" level
-	// klog.Infof("[DataPlane] [%s] new batch count: %d", contextApplyDP, newCount)
+	klog.V(util.KlogDebugVerbosity).Infof("[DataPlane] [%s] new batch count: %d", contextApplyDP, newCount)
 
 	if newCount >= dp.ApplyMaxBatches {
-		// TODO: Refactor non-error/warning klogs with Zap and set the following logs to "debug" level
-		// klog.Infof("[DataPlane] [%s] applying now since reached maximum batch count: %d", contextApplyDP, newCount)
+		klog.V(util.KlogDebugVerbosity).Infof("[DataPlane] [%s] applying now since reached maximum batch count: %d", contextApplyDP, newCount)
 		return dp.applyDataPlaneNow(contextApplyDP)
 	}
 
@@ -343,14 +339,12 @@ func (dp *DataPlane) ApplyDataPlane() error {
 }
 
 func (dp *DataPlane) applyDataPlaneNow(context string) error {
-	// TODO: Refactor non-error/warning klogs with Zap and set the following logs to "debug" level
-	// klog.Infof("[DataPlane] [ApplyDataPlane] [%s] starting to apply ipsets", context)
+	klog.V(util.KlogDebugVerbosity).Infof("[DataPlane] [ApplyDataPlane] [%s] starting to apply ipsets", context)
 	err := dp.ipsetMgr.ApplyIPSets()
 	if err != nil {
 		return fmt.Errorf("[DataPlane] [%s] error while applying IPSets: %w", context, err)
 	}
-	// TODO: Refactor non-error/warning klogs with Zap and set the following logs to "debug" level
-	// klog.Infof("[DataPlane] [ApplyDataPlane] [%s] finished applying ipsets", context)
+	klog.V(util.KlogDebugVerbosity).Infof("[DataPlane] [ApplyDataPlane] [%s] finished applying ipsets", context)
 
 	// see comment in RemovePolicy() for why this is here
 	dp.setRemovePolicyFailure(false)
@@ -371,8 +365,7 @@ func (dp *DataPlane) applyDataPlaneNow(context string) error {
 		}
 		dp.updatePodCache.Unlock()
 
-		// TODO: Refactor non-error/warning klogs with Zap and set the following logs to "debug" level
-		// klog.Infof("[DataPlane] [ApplyDataPlane] [%s] refreshing endpoints before updating pods", context)
+		klog.V(util.KlogDebugVerbosity).Infof("[DataPlane] [ApplyDataPlane] [%s] refreshing endpoints before updating pods", context)
 
 		err := dp.refreshPodEndpoints()
 		if err != nil {
@@ -381,16 +374,14 @@ func (dp *DataPlane) applyDataPlaneNow(context string) error {
 			return nil
 		}
 
-		// TODO: Refactor non-error/warning klogs with Zap and set the following logs to "debug" level
-		// klog.Infof("[DataPlane] [ApplyDataPlane] [%s] refreshed endpoints", context)
+		klog.V(util.KlogDebugVerbosity).Infof("[DataPlane] [ApplyDataPlane] [%s] refreshed endpoints", context)
 
 		// lock updatePodCache while driving goal state to kernel
 		// prevents another ApplyDataplane call from updating the same pods
 		dp.updatePodCache.Lock()
 		defer dp.updatePodCache.Unlock()
 
-		// TODO: Refactor non-error/warning klogs with Zap and set the following logs to "debug" level
-		// klog.Infof("[DataPlane] [ApplyDataPlane] [%s] starting to update pods", context)
+		klog.V(util.KlogDebugVerbosity).Infof("[DataPlane] [ApplyDataPlane] [%s] starting to update pods", context)
 		for !dp.updatePodCache.isEmpty() {
 			pod := dp.updatePodCache.dequeue()
 			if pod == nil {
@@ -408,16 +399,14 @@ func (dp *DataPlane) applyDataPlaneNow(context string) error {
 			}
 		}
 
-		// TODO: Refactor non-error/warning klogs with Zap and set the following logs to "debug" level
-		// klog.Infof("[DataPlane] [ApplyDataPlane] [%s] finished updating pods", context)
+		klog.V(util.KlogDebugVerbosity).Infof("[DataPlane] [ApplyDataPlane] [%s] finished updating pods", context)
 	}
 	return nil
 }
 
 // AddPolicy takes in a translated NPMNetworkPolicy object and applies on dataplane
 func (dp *DataPlane) AddPolicy(policy *policies.NPMNetworkPolicy) error {
-	// TODO: Refactor non-error/warning klogs with Zap and set the following logs to "debug" level
-	// klog.Infof("[DataPlane] Add Policy called for %s", policy.PolicyKey)
+	klog.V(util.KlogDebugVerbosity).Infof("[DataPlane] Add Policy called for %s", policy.PolicyKey)
 
 	if !dp.netPolInBackground {
 		return dp.addPolicies([]*policies.NPMNetworkPolicy{policy})
@@ -431,12 +420,10 @@ func (dp *DataPlane) AddPolicy(policy *policies.NPMNetworkPolicy) error {
 	dp.netPolQueue.enqueue(policy)
 	newCount := dp.netPolQueue.len()
 
-	// TODO: Refactor non-error/warning klogs with Zap and set the following logs to "debug" level
-	// klog.Infof("[DataPlane] [%s] new pending netpol count: %d", contextAddNetPol, newCount)
+	klog.V(util.KlogDebugVerbosity).Infof("[DataPlane] [%s] new pending netpol count: %d", contextAddNetPol, newCount)
 
 	if newCount >= dp.MaxPendingNetPols {
-		// TODO: Refactor non-error/warning klogs with Zap and set the following logs to "debug" level
-		// klog.Infof("[DataPlane] [%s] applying now since reached maximum batch count: %d", contextAddNetPol, newCount)
+		klog.V(util.KlogDebugVerbosity).Infof("[DataPlane] [%s] applying now since reached maximum batch count: %d", contextAddNetPol, newCount)
 		dp.addPoliciesWithRetry(contextAddNetPol)
 	}
 	return nil
@@ -446,14 +433,12 @@ func (dp *DataPlane) AddPolicy(policy *policies.NPMNetworkPolicy) error {
 // The caller must lock netPolQueue.
 func (dp *DataPlane) addPoliciesWithRetry(context string) {
 	netPols := dp.netPolQueue.dump()
-	// TODO: Refactor non-error/warning klogs with Zap and set the following logs to "debug" level
-	// klog.Infof("[DataPlane] adding policies %+v", netPols)
+	klog.V(util.KlogDebugVerbosity).Infof("[DataPlane] adding policies %+v", netPols)
 
 	err := dp.addPolicies(netPols)
 	if err == nil {
 		// clear queue and return on success
-		// TODO: Refactor non-error/warning klogs with Zap and set the following logs to "debug" level
-		// klog.Infof("[DataPlane] [%s] added policies successfully", context)
+		klog.V(util.KlogDebugVerbosity).Infof("[DataPlane] [%s] added policies successfully", context)
 		dp.netPolQueue.clear()
 		return
 	}
@@ -466,8 +451,7 @@ func (dp *DataPlane) addPoliciesWithRetry(context string) {
 		err = dp.addPolicies([]*policies.NPMNetworkPolicy{netPol})
 		if err == nil {
 			// remove from queue on success
-			// TODO: Refactor non-error/warning klogs with Zap and set the following logs to "debug" level
-			// klog.Infof("[DataPlane] [%s] added policy successfully one at a time. policyKey: %s", context, netPol.PolicyKey)
+			klog.V(util.KlogDebugVerbosity).Infof("[DataPlane] [%s] added policy successfully one at a time. policyKey: %s", context, netPol.PolicyKey)
 			dp.netPolQueue.delete(netPol.PolicyKey)
 		} else {
 			// keep in queue on failure
@@ -485,8 +469,7 @@ func (dp *DataPlane) addPolicies(netPols []*policies.NPMNetworkPolicy) error {
 	}
 
 	if len(netPols) == 0 {
-		// TODO: Refactor non-error/warning klogs with Zap and set the following logs to "debug" level
-		// klog.Infof("[DataPlane] expected to have at least one NetPol in dp.addPolicies()")
+		klog.V(util.KlogDebugVerbosity).Infof("[DataPlane] expected to have at least one NetPol in dp.addPolicies()")
 		return nil
 	}
 
@@ -543,18 +526,15 @@ func (dp *DataPlane) addPolicies(netPols []*policies.NPMNetworkPolicy) error {
 			// increment batch and apply IPSets if needed
 			dp.applyInfo.numBatches++
 			newCount := dp.applyInfo.numBatches
-			// TODO: Refactor non-error/warning klogs with Zap and set the following logs to "debug" level
-			// klog.Infof("[DataPlane] [%s] new batch count: %d", contextAddNetPolBootup, newCount)
+			klog.V(util.KlogDebugVerbosity).Infof("[DataPlane] [%s] new batch count: %d", contextAddNetPolBootup, newCount)
 			if newCount >= dp.ApplyMaxBatches {
-				// TODO: Refactor non-error/warning klogs with Zap and set the following logs to "debug" level
-				// klog.Infof("[DataPlane] [%s] applying now since reached maximum batch count: %d", contextAddNetPolBootup, newCount)
+				klog.V(util.KlogDebugVerbosity).Infof("[DataPlane] [%s] applying now since reached maximum batch count: %d", contextAddNetPolBootup, newCount)
 				// klog.Infof("[DataPlane] [%s] starting to apply ipsets", contextAddNetPolBootup)
 				err = dp.ipsetMgr.ApplyIPSets()
 				if err != nil {
 					return fmt.Errorf("[DataPlane] [%s] error while applying IPSets: %w", contextAddNetPolBootup, err)
 				}
-				// TODO: Refactor non-error/warning klogs with Zap and set the following logs to "debug" level
-				// klog.Infof("[DataPlane] [%s] finished applying ipsets", contextAddNetPolBootup)
+				klog.V(util.KlogDebugVerbosity).Infof("[DataPlane] [%s] finished applying ipsets", contextAddNetPolBootup)
 
 				// see comment in RemovePolicy() for why this is here
 				dp.setRemovePolicyFailure(false)
@@ -594,8 +574,7 @@ func (dp *DataPlane) addPolicies(netPols []*policies.NPMNetworkPolicy) error {
 
 // RemovePolicy takes in network policyKey (namespace/name of network policy) and removes it from dataplane and cache
 func (dp *DataPlane) RemovePolicy(policyKey string) error {
-	// TODO: Refactor non-error/warning klogs with Zap and set the following logs to "debug" level
-	// klog.Infof("[DataPlane] Remove Policy called for %s", policyKey)
+	klog.V(util.KlogDebugVerbosity).Infof("[DataPlane] Remove Policy called for %s", policyKey)
 
 	if dp.netPolInBackground {
 		// make sure to not add this NetPol if we're deleting it
@@ -671,12 +650,10 @@ func (dp *DataPlane) RemovePolicy(policyKey string) error {
 // UpdatePolicy takes in updated policy object, calculates the delta and applies changes
 // onto dataplane accordingly
 func (dp *DataPlane) UpdatePolicy(policy *policies.NPMNetworkPolicy) error {
-	// TODO: Refactor non-error/warning klogs with Zap and set the following logs to "debug" level
-	// klog.Infof("[DataPlane] Update Policy called for %s", policy.PolicyKey)
+	klog.V(util.KlogDebugVerbosity).Infof("[DataPlane] Update Policy called for %s", policy.PolicyKey)
 	ok := dp.policyMgr.PolicyExists(policy.PolicyKey)
 	if !ok {
-		// TODO: Refactor non-error/warning klogs with Zap and set the following logs to "debug" level
-		// klog.Infof("[DataPlane] Policy %s is not found.", policy.PolicyKey)
+		klog.V(util.KlogDebugVerbosity).Infof("[DataPlane] Policy %s is not found.", policy.PolicyKey)
 		return dp.AddPolicy(policy)
 	}
 
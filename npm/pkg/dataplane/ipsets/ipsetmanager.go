@@ -93,8 +93,10 @@ func (iMgr *IPSetManager) Reconcile() {
 	}
 	numRemovedSets := originalNumSets - len(iMgr.setMap)
 	if numRemovedSets > 0 {
-		// TODO: Refactor non-error/warning klogs with Zap and set the following logs to "debug" level
-		// klog.Infof("[IPSetManager] removed %d empty/unreferenced ipsets, updating toDeleteCache to: %+v", numRemovedSets, iMgr.dirtyCache.printDeleteCache())
+		// printing the cache is costly, so only do it when the log is enabled
+		if klog.V(util.KlogDebugVerbosity) {
+			klog.Infof("[IPSetManager] removed %d empty/unreferenced ipsets, updating toDeleteCache to: %+v", numRemovedSets, iMgr.dirtyCache.printDeleteCache())
+		}
 	}
 }
 
@@ -309,11 +311,10 @@ func (iMgr *IPSetManager) RemoveFromSets(removeFromSets []*IPSetMetadata, ip, po
 		}
 		// in case the IP belongs to a new Pod, then ignore this Delete call as this might be stale
 		if cachedPodKey != podKey {
-			// TODO: Refactor non-error/warning klogs with Zap and set the following logs to "debug" level
-			// klog.Infof(
-			// 	"[IPSetManager] DeleteFromSet: PodOwner has changed for Ip: %s, setName:%s, Old podKey: %s, new podKey: %s. Ignore the delete as this is stale update",
-			// 	ip, prefixedName, cachedPodKey, podKey,
-			// )
+			klog.V(util.KlogDebugVerbosity).Infof(
+				"[IPSetManager] DeleteFromSet: PodOwner has changed for Ip: %s, setName:%s, Old podKey: %s, new podKey: %s. Ignore the delete as this is stale update",
+				ip, prefixedName, cachedPodKey, podKey,
+			)
 			continue
 		}
 
@@ -455,16 +456,17 @@ func (iMgr *IPSetManager) ApplyIPSets() error {
 	defer iMgr.Unlock()
 
 	if iMgr.dirtyCache.numSetsToAddOrUpdate() == 0 && iMgr.dirtyCache.numSetsToDelete() == 0 {
-		// TODO: Refactor non-error/warning klogs with Zap and set the following logs to "debug" level
-		// klog.Info("[IPSetManager] No IPSets to apply")
+		klog.V(util.KlogDebugVerbosity).Info("[IPSetManager] No IPSets to apply")
 		return nil
 	}
 
-	// TODO: Refactor non-error/warning klogs with Zap and set the following logs to "debug" level
-	// klog.Infof(
-	// 	"[IPSetManager] dirty caches. toAddUpdateCache: %s, toDeleteCache: %s",
-	// 	iMgr.dirtyCache.printAddOrUpdateCache(), iMgr.dirtyCache.printDeleteCache(),
-	// )
+	// printing the caches is costly, so only do it when the log is enabled
+	if klog.V(util.KlogDebugVerbosity) {
+		klog.Infof(
+			"[IPSetManager] dirty caches. toAddUpdateCache: %s, toDeleteCache: %s",
+			iMgr.dirtyCache.printAddOrUpdateCache(), iMgr.dirtyCache.printDeleteCache(),
+		)
+	}
 	iMgr.sanitizeDirtyCache()
 
 	// Call the appropriate apply ipsets
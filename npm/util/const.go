@@ -4,6 +4,10 @@ package util
 
 import "k8s.io/klog"
 
+// KlogDebugVerbosity is the klog verbosity of the debug logs of NPM, which NPM runs at when a debug level is
+// requested of it or of one of its components.
+const KlogDebugVerbosity = 4
+
 // kubernetes related constants.
 const (
 	KubeSystemFlag             string = "kube-system"
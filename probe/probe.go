@@ -0,0 +1,97 @@
+// Package probe detects whether an IP address is already in use on the local link before it
+// is handed out, using ARP probes (RFC 5227) for IPv4 and Duplicate Address Detection (RFC 4862)
+// for IPv6.
+package probe
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+var (
+	// DefaultTimeout is how long each probe attempt waits for an answer.
+	DefaultTimeout = 200 * time.Millisecond
+	// DefaultAttempts is how many probes are sent before an address is considered free.
+	DefaultAttempts = 2
+)
+
+// ErrConflict is matched by the error returned when another host answers for the probed address.
+var ErrConflict = errors.New("address is in use on the link")
+
+// ConflictError reports the address found in use and the hardware address of the host using it.
+type ConflictError struct {
+	Addr         netip.Addr
+	HardwareAddr net.HardwareAddr
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("address %s is in use on the link by %s", e.Addr, e.HardwareAddr)
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict //nolint:errorlint // sentinel comparison
+}
+
+// Prober probes the local link for hosts using an address.
+type Prober struct {
+	logger   *zap.Logger
+	timeout  time.Duration
+	attempts int
+}
+
+// New returns a Prober which sends attempts probes, waiting timeout for an answer to each.
+// Non-positive values use DefaultTimeout and DefaultAttempts.
+func New(logger *zap.Logger, timeout time.Duration, attempts int) *Prober {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	if attempts <= 0 {
+		attempts = DefaultAttempts
+	}
+	return &Prober{
+		logger:   logger,
+		timeout:  timeout,
+		attempts: attempts,
+	}
+}
+
+// InterfaceFor returns the name of the local interface with a subnet containing addr,
+// which is the link a conflicting host would be on.
+func InterfaceFor(addr netip.Addr) (string, bool) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return "", false
+	}
+	for i := range ifaces {
+		if ifaces[i].Flags&net.FlagLoopback != 0 || ifaces[i].Flags&net.FlagUp == 0 {
+			continue
+		}
+		addrs, err := ifaces[i].Addrs()
+		if err != nil {
+			continue
+		}
+		for _, a := range addrs {
+			ipnet, ok := a.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			ip, ok := netip.AddrFromSlice(ipnet.IP)
+			if !ok {
+				continue
+			}
+			ones, _ := ipnet.Mask.Size()
+			if netip.PrefixFrom(ip.Unmap(), ones).Masked().Contains(addr.Unmap()) {
+				return ifaces[i].Name, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,289 @@
+//go:build linux
+// +build linux
+
+package probe
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"net/netip"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	ethPARP  = 0x0806
+	ethPIPv6 = 0x86dd
+
+	arpLen         = 28
+	arpHTEthernet  = 1
+	arpPTIPv4      = 0x0800
+	arpOpRequest   = 1
+	macBytes       = 6
+	ipv4Bytes      = 4
+	ipv6HeaderLen  = 40
+	icmpv6Proto    = 58
+	icmpv6NSLen    = 24
+	icmpv6NS       = 135
+	icmpv6NA       = 136
+	ndpHopLimit    = 255
+	pollInterval   = 50 * time.Millisecond
+	maxReceiveSize = 1500
+)
+
+var broadcastMAC = net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+
+// virtualGatewayMAC is the hardware address the Azure fabric, and the host in bridge mode, answers address resolution
+// with for every address of the virtual network, so its answers do not mean another host is using the address.
+var virtualGatewayMAC = net.HardwareAddr{0x12, 0x34, 0x56, 0x78, 0x9a, 0xbc}
+
+// linkSocket sends and receives link layer payloads on a single interface.
+type linkSocket interface {
+	send(payload []byte, dst net.HardwareAddr) error
+	// recv returns the payload length and the source of the next packet, or zero if none
+	// arrived within the poll interval. Packets sent by this host are skipped.
+	recv(buf []byte) (int, net.HardwareAddr, error)
+	Close() error
+}
+
+type packetSocket struct {
+	fd       int
+	ifindex  int
+	protocol uint16
+}
+
+func htons(v uint16) uint16 {
+	b := make([]byte, 2) //nolint:gomnd // uint16
+	binary.BigEndian.PutUint16(b, v)
+	return binary.NativeEndian.Uint16(b)
+}
+
+func newPacketSocket(ifi *net.Interface, protocol uint16) (*packetSocket, error) {
+	fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, int(htons(protocol)))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open packet socket")
+	}
+	s := &packetSocket{fd: fd, ifindex: ifi.Index, protocol: protocol}
+	if err := unix.Bind(fd, &unix.SockaddrLinklayer{Protocol: htons(protocol), Ifindex: ifi.Index}); err != nil {
+		s.Close()
+		return nil, errors.Wrapf(err, "failed to bind packet socket to %s", ifi.Name)
+	}
+	tv := unix.NsecToTimeval(pollInterval.Nanoseconds())
+	if err := unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv); err != nil {
+		s.Close()
+		return nil, errors.Wrap(err, "failed to set receive timeout")
+	}
+	return s, nil
+}
+
+// joinMulticast receives frames sent to the multicast hardware address.
+func (s *packetSocket) joinMulticast(mac net.HardwareAddr) error {
+	mreq := &unix.PacketMreq{Ifindex: int32(s.ifindex), Type: unix.PACKET_MR_MULTICAST, Alen: macBytes}
+	copy(mreq.Address[:], mac)
+	return errors.Wrap(unix.SetsockoptPacketMreq(s.fd, unix.SOL_PACKET, unix.PACKET_ADD_MEMBERSHIP, mreq), "failed to join multicast group")
+}
+
+func (s *packetSocket) send(payload []byte, dst net.HardwareAddr) error {
+	sa := &unix.SockaddrLinklayer{Protocol: htons(s.protocol), Ifindex: s.ifindex, Halen: macBytes}
+	copy(sa.Addr[:], dst)
+	return errors.Wrap(unix.Sendto(s.fd, payload, 0, sa), "failed to send probe")
+}
+
+func (s *packetSocket) recv(buf []byte) (int, net.HardwareAddr, error) {
+	n, from, err := unix.Recvfrom(s.fd, buf, 0)
+	if err != nil {
+		if errors.Is(err, unix.EAGAIN) || errors.Is(err, unix.EWOULDBLOCK) || errors.Is(err, unix.EINTR) {
+			return 0, nil, nil
+		}
+		return 0, nil, errors.Wrap(err, "failed to receive")
+	}
+	ll, ok := from.(*unix.SockaddrLinklayer)
+	if !ok || ll.Pkttype == unix.PACKET_OUTGOING {
+		return 0, nil, nil
+	}
+	return n, net.HardwareAddr(ll.Addr[:ll.Halen]), nil
+}
+
+func (s *packetSocket) Close() error {
+	return errors.Wrap(unix.Close(s.fd), "failed to close packet socket")
+}
+
+// Probe checks whether another host on the link of ifname is using addr. It returns a
+// *ConflictError matching ErrConflict if a host answers, and nil if none does.
+func (p *Prober) Probe(ctx context.Context, ifname string, addr netip.Addr) error {
+	addr = addr.Unmap()
+	ifi, err := net.InterfaceByName(ifname)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get interface %s", ifname)
+	}
+	if len(ifi.HardwareAddr) != macBytes {
+		return errors.Errorf("interface %s has no ethernet address to probe from", ifname)
+	}
+
+	var (
+		protocol uint16
+		payload  []byte
+		dst      net.HardwareAddr
+		conflict func([]byte) bool
+	)
+	if addr.Is4() {
+		protocol, payload, dst = ethPARP, arpProbe(ifi.HardwareAddr, addr), broadcastMAC
+		conflict = func(pkt []byte) bool { return arpConflict(pkt, addr) }
+	} else {
+		protocol, payload, dst = ethPIPv6, neighborSolicitation(addr), solicitedNodeMAC(addr)
+		conflict = func(pkt []byte) bool { return ndpConflict(pkt, addr) }
+	}
+
+	sock, err := newPacketSocket(ifi, protocol)
+	if err != nil {
+		return err
+	}
+	defer sock.Close()
+	if addr.Is6() {
+		// another host doing DAD for the same address sends to the solicited-node group
+		if err := sock.joinMulticast(dst); err != nil {
+			p.logger.Debug("not receiving solicited-node multicast", zap.String("interface", ifname), zap.Error(err))
+		}
+	}
+
+	return p.probe(ctx, sock, ifi.HardwareAddr, addr, payload, dst, conflict)
+}
+
+// probe sends payload to dst up to p.attempts times, and returns a conflict for the first
+// packet received from another host for which conflict returns true.
+func (p *Prober) probe(ctx context.Context, sock linkSocket, self net.HardwareAddr, addr netip.Addr,
+	payload []byte, dst net.HardwareAddr, conflict func([]byte) bool,
+) error {
+	buf := make([]byte, maxReceiveSize)
+	for attempt := 0; attempt < p.attempts; attempt++ {
+		if err := sock.send(payload, dst); err != nil {
+			return err
+		}
+		deadline := time.Now().Add(p.timeout)
+		for time.Now().Before(deadline) {
+			if err := ctx.Err(); err != nil {
+				return errors.Wrap(err, "probe cancelled")
+			}
+			n, from, err := sock.recv(buf)
+			if err != nil {
+				return err
+			}
+			if n == 0 || bytes.Equal(from, self) || bytes.Equal(from, virtualGatewayMAC) {
+				continue
+			}
+			if conflict(buf[:n]) {
+				p.logger.Info("address conflict detected",
+					zap.String("address", addr.String()), zap.String("hardwareAddr", from.String()))
+				return &ConflictError{Addr: addr, HardwareAddr: from}
+			}
+		}
+	}
+	return nil
+}
+
+// arpProbe builds an ARP probe for addr: a request with an all zero sender protocol address,
+// so that no host updates its ARP cache from it.
+func arpProbe(self net.HardwareAddr, addr netip.Addr) []byte {
+	pkt := make([]byte, arpLen)
+	binary.BigEndian.PutUint16(pkt[0:2], arpHTEthernet)
+	binary.BigEndian.PutUint16(pkt[2:4], arpPTIPv4)
+	pkt[4] = macBytes
+	pkt[5] = ipv4Bytes
+	binary.BigEndian.PutUint16(pkt[6:8], arpOpRequest)
+	copy(pkt[8:14], self)
+	target := addr.As4()
+	copy(pkt[24:28], target[:])
+	return pkt
+}
+
+// arpConflict returns if the ARP packet is from a host using addr, or from a host probing for it.
+func arpConflict(pkt []byte, addr netip.Addr) bool {
+	if len(pkt) < arpLen ||
+		binary.BigEndian.Uint16(pkt[0:2]) != arpHTEthernet ||
+		binary.BigEndian.Uint16(pkt[2:4]) != arpPTIPv4 ||
+		pkt[4] != macBytes || pkt[5] != ipv4Bytes {
+		return false
+	}
+	sender, _ := netip.AddrFromSlice(pkt[14:18])
+	target, _ := netip.AddrFromSlice(pkt[24:28])
+	if sender == addr {
+		return true
+	}
+	return binary.BigEndian.Uint16(pkt[6:8]) == arpOpRequest && sender.IsUnspecified() && target == addr
+}
+
+func solicitedNodeAddr(addr netip.Addr) netip.Addr {
+	a := addr.As16()
+	return netip.AddrFrom16([16]byte{0xff, 0x02, 10: 0, 11: 0x01, 12: 0xff, 13: a[13], 14: a[14], 15: a[15]})
+}
+
+func solicitedNodeMAC(addr netip.Addr) net.HardwareAddr {
+	a := addr.As16()
+	return net.HardwareAddr{0x33, 0x33, 0xff, a[13], a[14], a[15]}
+}
+
+// neighborSolicitation builds the IPv6 packet of a Duplicate Address Detection neighbor
+// solicitation for addr, sent from the unspecified address to its solicited-node group.
+func neighborSolicitation(addr netip.Addr) []byte {
+	pkt := make([]byte, ipv6HeaderLen+icmpv6NSLen)
+	pkt[0] = 6 << 4 //nolint:gomnd // IP version
+	binary.BigEndian.PutUint16(pkt[4:6], icmpv6NSLen)
+	pkt[6] = icmpv6Proto
+	pkt[7] = ndpHopLimit
+	dst := solicitedNodeAddr(addr).As16()
+	copy(pkt[24:40], dst[:])
+
+	icmp := pkt[ipv6HeaderLen:]
+	icmp[0] = icmpv6NS
+	target := addr.As16()
+	copy(icmp[8:24], target[:])
+	binary.BigEndian.PutUint16(icmp[2:4], icmpv6Checksum(pkt[8:24], pkt[24:40], icmp))
+	return pkt
+}
+
+// icmpv6Checksum computes the checksum of the ICMPv6 message over the IPv6 pseudo-header.
+func icmpv6Checksum(src, dst, msg []byte) uint16 {
+	var sum uint32
+	add := func(b []byte) {
+		for i := 0; i+1 < len(b); i += 2 {
+			sum += uint32(binary.BigEndian.Uint16(b[i : i+2]))
+		}
+		if len(b)%2 == 1 {
+			sum += uint32(b[len(b)-1]) << 8 //nolint:gomnd // pad to 16 bits
+		}
+	}
+	add(src)
+	add(dst)
+	sum += uint32(len(msg)) + icmpv6Proto
+	add(msg)
+	for sum>>16 != 0 {
+		sum = sum&0xffff + sum>>16
+	}
+	return ^uint16(sum)
+}
+
+// ndpConflict returns if the IPv6 packet is a neighbor advertisement for addr, or a neighbor
+// solicitation from another host doing Duplicate Address Detection for it.
+func ndpConflict(pkt []byte, addr netip.Addr) bool {
+	if len(pkt) < ipv6HeaderLen+icmpv6NSLen || pkt[0]>>4 != 6 || pkt[6] != icmpv6Proto {
+		return false
+	}
+	src, _ := netip.AddrFromSlice(pkt[8:24])
+	icmp := pkt[ipv6HeaderLen:]
+	target, _ := netip.AddrFromSlice(icmp[8:24])
+	if target != addr {
+		return false
+	}
+	switch icmp[0] {
+	case icmpv6NA:
+		return true
+	case icmpv6NS:
+		return src.IsUnspecified()
+	}
+	return false
+}
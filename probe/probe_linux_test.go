@@ -0,0 +1,129 @@
+//go:build linux
+// +build linux
+
+package probe
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	selfMAC  = net.HardwareAddr{0x00, 0x0d, 0x3a, 0x00, 0x00, 0x01}
+	otherMAC = net.HardwareAddr{0x00, 0x0d, 0x3a, 0x00, 0x00, 0x02}
+)
+
+type packet struct {
+	payload []byte
+	from    net.HardwareAddr
+}
+
+type fakeSocket struct {
+	sent     [][]byte
+	received []packet
+}
+
+func (f *fakeSocket) send(payload []byte, _ net.HardwareAddr) error {
+	f.sent = append(f.sent, payload)
+	return nil
+}
+
+func (f *fakeSocket) recv(buf []byte) (int, net.HardwareAddr, error) {
+	if len(f.received) == 0 {
+		time.Sleep(time.Millisecond)
+		return 0, nil, nil
+	}
+	p := f.received[0]
+	f.received = f.received[1:]
+	return copy(buf, p.payload), p.from, nil
+}
+
+func (f *fakeSocket) Close() error { return nil }
+
+func TestARP(t *testing.T) {
+	addr := netip.MustParseAddr("10.240.0.10")
+	pkt := arpProbe(selfMAC, addr)
+	require.Len(t, pkt, arpLen)
+	require.Equal(t, []byte(selfMAC), pkt[8:14])
+	require.Equal(t, []byte{0, 0, 0, 0}, pkt[14:18])
+	require.Equal(t, []byte{10, 240, 0, 10}, pkt[24:28])
+
+	// another host probing for the same address
+	require.True(t, arpConflict(pkt, addr))
+	require.False(t, arpConflict(pkt, netip.MustParseAddr("10.240.0.11")))
+
+	// a reply from the host using the address
+	reply := arpProbe(otherMAC, netip.MustParseAddr("10.240.0.1"))
+	reply[7] = 2
+	copy(reply[14:18], []byte{10, 240, 0, 10})
+	require.True(t, arpConflict(reply, addr))
+	require.False(t, arpConflict(reply[:20], addr))
+}
+
+func TestNDP(t *testing.T) {
+	addr := netip.MustParseAddr("fd00::1:2:abcd")
+	pkt := neighborSolicitation(addr)
+	require.Len(t, pkt, ipv6HeaderLen+icmpv6NSLen)
+	require.Equal(t, netip.MustParseAddr("ff02::1:ff02:abcd").AsSlice(), pkt[24:40])
+	require.Equal(t, net.HardwareAddr{0x33, 0x33, 0xff, 0x02, 0xab, 0xcd}, solicitedNodeMAC(addr))
+	// the checksum of a message including its checksum sums to zero
+	require.Zero(t, icmpv6Checksum(pkt[8:24], pkt[24:40], pkt[ipv6HeaderLen:]))
+
+	// another host doing DAD for the same address
+	require.True(t, ndpConflict(pkt, addr))
+	require.False(t, ndpConflict(pkt, netip.MustParseAddr("fd00::1:2:abce")))
+
+	// a solicitation from an assigned address is address resolution, not DAD
+	resolution := append([]byte(nil), pkt...)
+	copy(resolution[8:24], netip.MustParseAddr("fd00::5").AsSlice())
+	require.False(t, ndpConflict(resolution, addr))
+
+	// an advertisement from the host using the address
+	advert := append([]byte(nil), resolution...)
+	advert[ipv6HeaderLen] = icmpv6NA
+	require.True(t, ndpConflict(advert, addr))
+}
+
+func TestProbe(t *testing.T) {
+	addr := netip.MustParseAddr("10.240.0.10")
+	p := New(nil, 10*time.Millisecond, 2)
+	conflict := func(pkt []byte) bool { return arpConflict(pkt, addr) }
+	probe := arpProbe(selfMAC, addr)
+
+	// no answer: every attempt is sent and the address is free
+	sock := &fakeSocket{}
+	require.NoError(t, p.probe(context.Background(), sock, selfMAC, addr, probe, broadcastMAC, conflict))
+	require.Len(t, sock.sent, 2)
+
+	// our own probe looped back is not a conflict
+	sock = &fakeSocket{received: []packet{{payload: probe, from: selfMAC}}}
+	require.NoError(t, p.probe(context.Background(), sock, selfMAC, addr, probe, broadcastMAC, conflict))
+
+	// neither is the virtual gateway answering for every address
+	reply := arpProbe(virtualGatewayMAC, netip.MustParseAddr("10.240.0.1"))
+	reply[7] = 2
+	copy(reply[14:18], []byte{10, 240, 0, 10})
+	sock = &fakeSocket{received: []packet{{payload: reply, from: virtualGatewayMAC}}}
+	require.NoError(t, p.probe(context.Background(), sock, selfMAC, addr, probe, broadcastMAC, conflict))
+
+	// another host answering is
+	sock = &fakeSocket{received: []packet{
+		{payload: arpProbe(otherMAC, netip.MustParseAddr("10.240.0.20")), from: otherMAC},
+		{payload: arpProbe(otherMAC, addr), from: otherMAC},
+	}}
+	err := p.probe(context.Background(), sock, selfMAC, addr, probe, broadcastMAC, conflict)
+	require.ErrorIs(t, err, ErrConflict)
+	var conflictErr *ConflictError
+	require.ErrorAs(t, err, &conflictErr)
+	require.Equal(t, otherMAC, conflictErr.HardwareAddr)
+	require.Len(t, sock.sent, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.Error(t, p.probe(ctx, &fakeSocket{}, selfMAC, addr, probe, broadcastMAC, conflict))
+}
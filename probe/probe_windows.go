@@ -0,0 +1,12 @@
+package probe
+
+import (
+	"context"
+	"net/netip"
+)
+
+// Probe is not supported on Windows, where the host network service detects address conflicts,
+// so every address is reported as free.
+func (p *Prober) Probe(_ context.Context, _ string, _ netip.Addr) error {
+	return nil
+}
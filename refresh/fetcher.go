@@ -2,6 +2,7 @@ package refresh
 
 import (
 	"context"
+	"sync"
 	"time"
 )
 
@@ -14,16 +15,45 @@ const (
 // maxInterval. When no diff is observed after a fetch, the interval doubles (subject to the maximum interval).
 // When a diff is observed, the interval resets to the minimum. The interval can be made unchanging by setting
 // minInterval and maxInterval to the same desired value.
-
+// Options add jitter to the intervals, back off on failed fetches, and stop fetching from a failing source for a
+// while with a circuit breaker.
 type Fetcher[T equaler[T]] struct {
-	fetchFunc       func(context.Context) (T, error)
-	cache           T
+	fetchFunc   func(context.Context) (T, error)
+	consumeFunc func(T) error
+	logger      Logger
+	ticker      TickProvider
+	refresh     chan struct{}
+	opts        options
+	rand        func() float64
+	now         func() time.Time
+
+	mu    sync.Mutex
+	cache T
+	// cached is whether a fetch succeeded yet, until which the cache holds nothing to compare with.
+	cached          bool
 	minInterval     time.Duration
 	maxInterval     time.Duration
 	currentInterval time.Duration
-	ticker          TickProvider
-	consumeFunc     func(T) error
-	logger          Logger
+	// errorInterval is the interval since the last failed fetch, 0 after a successful one.
+	errorInterval time.Duration
+	// lastWait is the last interval waited, which decorrelated jitter derives the next one from.
+	lastWait  time.Duration
+	failures  int
+	breaker   BreakerState
+	openUntil time.Time
+	lastFetch time.Time
+	lastErr   error
+}
+
+// State is the state of a Fetcher.
+type State struct {
+	// Interval is the wait from the last fetch until the next one.
+	Interval time.Duration
+	// ConsecutiveFailures is the number of fetches which failed since the last successful one.
+	ConsecutiveFailures int
+	Breaker             BreakerState
+	LastFetch           time.Time
+	LastError           error
 }
 
 // NewFetcher creates a new Fetcher. If minInterval is 0, it will default to 4 seconds.
@@ -33,6 +63,7 @@ func NewFetcher[T equaler[T]](
 	maxInterval time.Duration,
 	consumeFunc func(T) error,
 	logger Logger,
+	opts ...Option,
 ) *Fetcher[T] {
 	if minInterval == 0 {
 		minInterval = DefaultMinInterval
@@ -44,33 +75,34 @@ func NewFetcher[T equaler[T]](
 
 	maxInterval = max(minInterval, maxInterval)
 
-	return &Fetcher[T]{
+	f := &Fetcher[T]{
 		fetchFunc:       fetchFunc,
 		minInterval:     minInterval,
 		maxInterval:     maxInterval,
 		currentInterval: minInterval,
+		lastWait:        minInterval,
 		consumeFunc:     consumeFunc,
 		logger:          logger,
+		refresh:         make(chan struct{}, 1),
+		rand:            defaultRand,
+		now:             time.Now,
+		breaker:         BreakerClosed,
 	}
+	for _, opt := range opts {
+		opt(&f.opts)
+	}
+	return f
 }
 
 func (f *Fetcher[T]) Start(ctx context.Context) {
 	go func() {
 		// do an initial fetch
-		res, err := f.fetchFunc(ctx)
-		if err != nil {
-			f.logger.Printf("Error invoking fetch: %v", err)
-		}
-
-		f.cache = res
-		if f.consumeFunc != nil {
-			if err := f.consumeFunc(res); err != nil {
-				f.logger.Errorf("Error consuming data: %v", err)
-			}
-		}
+		wait := f.fetch(ctx)
 
 		if f.ticker == nil {
-			f.ticker = NewTimedTickProvider(f.currentInterval)
+			f.ticker = NewTimedTickProvider(wait)
+		} else {
+			f.ticker.Reset(wait)
 		}
 
 		defer f.ticker.Stop()
@@ -80,31 +112,147 @@ func (f *Fetcher[T]) Start(ctx context.Context) {
 			case <-ctx.Done():
 				f.logger.Printf("Fetcher stopped")
 				return
-			case <-f.ticker.C():
-				result, err := f.fetchFunc(ctx)
-				if err != nil {
-					f.logger.Errorf("Error fetching data: %v", err)
-				} else {
-					if result.Equal(f.cache) {
-						f.updateFetchIntervalForNoObservedDiff()
-						f.logger.Printf("No diff observed in fetch, not invoking the consumer")
-					} else {
-						f.cache = result
-						f.updateFetchIntervalForObservedDiff()
-						if f.consumeFunc != nil {
-							if err := f.consumeFunc(result); err != nil {
-								f.logger.Errorf("Error consuming data: %v", err)
-							}
-						}
-					}
+			case <-f.refresh:
+				if f.isOpen() {
+					f.logger.Printf("Circuit breaker is open, ignoring refresh")
+					continue
 				}
-
-				f.ticker.Reset(f.currentInterval)
+			case <-f.ticker.C():
 			}
+			f.ticker.Reset(f.fetch(ctx))
 		}
 	}()
 }
 
+// Refresh makes the Fetcher fetch now instead of at the end of the interval, unless the circuit breaker is open.
+// It does not block, and refreshes requested while one is pending are coalesced.
+func (f *Fetcher[T]) Refresh() {
+	select {
+	case f.refresh <- struct{}{}:
+	default:
+	}
+}
+
+// SetIntervals changes the range of the interval, taking effect from the next fetch.
+func (f *Fetcher[T]) SetIntervals(minInterval, maxInterval time.Duration) {
+	if minInterval == 0 {
+		minInterval = DefaultMinInterval
+	}
+	if maxInterval == 0 {
+		maxInterval = DefaultMaxInterval
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.minInterval = minInterval
+	f.maxInterval = max(minInterval, maxInterval)
+	f.currentInterval = min(max(f.currentInterval, f.minInterval), f.maxInterval)
+}
+
+// State returns the current state of the Fetcher.
+func (f *Fetcher[T]) State() State {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return State{
+		Interval:            f.lastWait,
+		ConsecutiveFailures: f.failures,
+		Breaker:             f.breaker,
+		LastFetch:           f.lastFetch,
+		LastError:           f.lastErr,
+	}
+}
+
+func (f *Fetcher[T]) isOpen() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.breaker == BreakerOpen && f.now().Before(f.openUntil)
+}
+
+// fetch fetches and consumes the data if it changed, returning the wait until the next fetch.
+func (f *Fetcher[T]) fetch(ctx context.Context) time.Duration {
+	f.mu.Lock()
+	if f.breaker == BreakerOpen {
+		f.setBreaker(BreakerHalfOpen)
+	}
+	f.mu.Unlock()
+
+	start := f.now()
+	result, err := f.fetchFunc(ctx)
+	if f.opts.hooks.OnFetch != nil {
+		f.opts.hooks.OnFetch(f.now().Sub(start), err)
+	}
+
+	f.mu.Lock()
+	f.lastFetch, f.lastErr = start, err
+	var wait time.Duration
+	consume := false
+	if err != nil {
+		f.logger.Errorf("Error fetching data: %v", err)
+		wait = f.onFailure()
+	} else {
+		if f.breaker != BreakerClosed {
+			f.setBreaker(BreakerClosed)
+		}
+		f.failures, f.errorInterval = 0, 0
+		switch {
+		case !f.cached:
+			f.cache, f.cached = result, true
+			consume = true
+		case result.Equal(f.cache):
+			f.updateFetchIntervalForNoObservedDiff()
+			f.logger.Debugf("No diff observed in fetch, not invoking the consumer")
+		default:
+			f.cache = result
+			f.updateFetchIntervalForObservedDiff()
+			consume = true
+		}
+		wait = f.currentInterval
+	}
+	// the cool-off of the circuit breaker is a minimum and is not jittered
+	if f.breaker != BreakerOpen {
+		wait = f.jitter(wait)
+	}
+	f.lastWait = wait
+	f.mu.Unlock()
+
+	if consume && f.consumeFunc != nil {
+		if err := f.consumeFunc(result); err != nil {
+			f.logger.Errorf("Error consuming data: %v", err)
+		}
+	}
+	if f.opts.hooks.OnInterval != nil {
+		f.opts.hooks.OnInterval(wait)
+	}
+	return wait
+}
+
+// onFailure records a failed fetch and returns the wait until the next fetch. Must be called with the lock held.
+func (f *Fetcher[T]) onFailure() time.Duration {
+	f.failures++
+	if f.opts.breakerThreshold > 0 && f.failures >= f.opts.breakerThreshold {
+		f.logger.Warnf("%d consecutive fetches failed, not fetching for %v", f.failures, f.opts.breakerCooloff)
+		f.openUntil = f.now().Add(f.opts.breakerCooloff)
+		f.setBreaker(BreakerOpen)
+		return f.opts.breakerCooloff
+	}
+	if f.opts.errorMaxInterval == 0 {
+		return f.currentInterval
+	}
+	if f.errorInterval == 0 {
+		f.errorInterval = f.minInterval
+	} else {
+		f.errorInterval = min(f.errorInterval*2, max(f.opts.errorMaxInterval, f.minInterval)) // nolint:gomnd // doubling logic
+	}
+	return f.errorInterval
+}
+
+// setBreaker changes the state of the circuit breaker. Must be called with the lock held.
+func (f *Fetcher[T]) setBreaker(state BreakerState) {
+	f.breaker = state
+	if f.opts.hooks.OnBreaker != nil {
+		f.opts.hooks.OnBreaker(state)
+	}
+}
+
 func (f *Fetcher[T]) updateFetchIntervalForNoObservedDiff() {
 	f.currentInterval = min(f.currentInterval*2, f.maxInterval) // nolint:gomnd // doubling logic
 }
@@ -2,15 +2,20 @@ package refresh_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/netip"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/Azure/azure-container-networking/cns/logger"
 	"github.com/Azure/azure-container-networking/cns/nodesubnet"
 	"github.com/Azure/azure-container-networking/nmagent"
 	"github.com/Azure/azure-container-networking/refresh"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // Mock client that simply tracks if refresh has been called
@@ -147,6 +152,149 @@ func TestRefresh(t *testing.T) {
 	}
 }
 
+// value is fetched by the Fetchers under test
+type value int
+
+func (v value) Equal(o value) bool {
+	return v == o
+}
+
+// scriptedSource returns the results in order, repeating the last one
+type scriptedSource struct {
+	results []error
+	calls   atomic.Int32
+}
+
+var errFetch = errors.New("fetch failed")
+
+func (s *scriptedSource) fetch(context.Context) (value, error) {
+	i := int(s.calls.Add(1)) - 1
+	return 0, s.results[min(i, len(s.results)-1)]
+}
+
+func waitForFetches(t *testing.T, s *scriptedSource, n int32, fetcher *refresh.Fetcher[value], check func(refresh.State) bool) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		return s.calls.Load() == n && check(fetcher.State())
+	}, 5*time.Second, time.Millisecond)
+}
+
+func TestErrorBackoffAndCircuitBreaker(t *testing.T) {
+	source := &scriptedSource{results: []error{errFetch, errFetch, errFetch, errFetch, nil}}
+	var mu sync.Mutex
+	var transitions []refresh.BreakerState
+	fetcher := refresh.NewFetcher[value](source.fetch, time.Second, time.Minute, nil, logger.Log,
+		refresh.WithErrorBackoff(3*time.Second),
+		refresh.WithCircuitBreaker(4, time.Hour),
+		refresh.WithHooks(refresh.Hooks{OnBreaker: func(state refresh.BreakerState) {
+			mu.Lock()
+			defer mu.Unlock()
+			transitions = append(transitions, state)
+		}}),
+	)
+	ticker := refresh.NewMockTickProvider()
+	fetcher.SetTicker(ticker)
+	now := time.Now()
+	fetcher.SetNow(func() time.Time { return now })
+	ctx, cancel := testContext(t)
+	defer cancel()
+	fetcher.Start(ctx)
+
+	// failed fetches back off from the minimum interval up to the maximum error interval
+	for i, interval := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second} {
+		if i > 0 {
+			ticker.Tick()
+		}
+		waitForFetches(t, source, int32(i+1), fetcher, func(s refresh.State) bool {
+			return s.ConsecutiveFailures == i+1 && s.Interval == interval
+		})
+	}
+
+	// the breaker opens and refreshes are ignored during the cool-off
+	ticker.Tick()
+	waitForFetches(t, source, 4, fetcher, func(s refresh.State) bool {
+		return s.Breaker == refresh.BreakerOpen && s.Interval == time.Hour
+	})
+	fetcher.Refresh()
+
+	// the fetch at the end of the cool-off succeeds and closes the breaker
+	ticker.Tick()
+	waitForFetches(t, source, 5, fetcher, func(s refresh.State) bool {
+		return s.Breaker == refresh.BreakerClosed && s.ConsecutiveFailures == 0 && s.Interval == time.Second
+	})
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []refresh.BreakerState{refresh.BreakerOpen, refresh.BreakerHalfOpen, refresh.BreakerClosed}, transitions)
+}
+
+func TestJitter(t *testing.T) {
+	tests := []struct {
+		name      string
+		jitter    refresh.Jitter
+		min, max  time.Duration
+		intervals []time.Duration
+	}{
+		{
+			name:      "full",
+			jitter:    refresh.FullJitter,
+			min:       10 * time.Second,
+			max:       10 * time.Second,
+			intervals: []time.Duration{5 * time.Second, 5 * time.Second},
+		},
+		{
+			// the interval doubles as the data is unchanged, the jitter stays within three times the last wait
+			name:      "decorrelated",
+			jitter:    refresh.DecorrelatedJitter,
+			min:       time.Second,
+			max:       time.Minute,
+			intervals: []time.Duration{time.Second, 2 * time.Second, 3500 * time.Millisecond},
+		},
+		{
+			name:      "equal",
+			jitter:    refresh.EqualJitter,
+			min:       10 * time.Second,
+			max:       10 * time.Second,
+			intervals: []time.Duration{7500 * time.Millisecond, 7500 * time.Millisecond},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &scriptedSource{results: []error{nil}}
+			fetcher := refresh.NewFetcher[value](source.fetch, tt.min, tt.max, nil, logger.Log, refresh.WithJitter(tt.jitter))
+			ticker := refresh.NewMockTickProvider()
+			fetcher.SetTicker(ticker)
+			fetcher.SetRand(func() float64 { return 0.5 })
+			ctx, cancel := testContext(t)
+			defer cancel()
+			fetcher.Start(ctx)
+			for i, interval := range tt.intervals {
+				if i > 0 {
+					ticker.Tick()
+				}
+				waitForFetches(t, source, int32(i+1), fetcher, func(s refresh.State) bool {
+					return s.Interval == interval
+				})
+			}
+		})
+	}
+}
+
+func TestRefreshNow(t *testing.T) {
+	source := &scriptedSource{results: []error{nil}}
+	var fetches atomic.Int32
+	fetcher := refresh.NewFetcher[value](source.fetch, time.Hour, time.Hour, nil, logger.Log,
+		refresh.WithHooks(refresh.Hooks{OnFetch: func(time.Duration, error) { fetches.Add(1) }}))
+	fetcher.SetTicker(refresh.NewMockTickProvider())
+	ctx, cancel := testContext(t)
+	defer cancel()
+	fetcher.Start(ctx)
+	waitForFetches(t, source, 1, fetcher, func(refresh.State) bool { return true })
+
+	fetcher.Refresh()
+	waitForFetches(t, source, 2, fetcher, func(refresh.State) bool { return true })
+	require.Eventually(t, func() bool { return fetches.Load() == 2 }, 5*time.Second, time.Millisecond)
+}
+
 // testContext creates a context from the provided testing.T that will be
 // canceled if the test suite is terminated.
 func testContext(t *testing.T) (context.Context, context.CancelFunc) {
@@ -1,5 +1,15 @@
 package refresh
 
+import "time"
+
 func (f *Fetcher[T]) SetTicker(t TickProvider) {
 	f.ticker = t
 }
+
+func (f *Fetcher[T]) SetRand(r func() float64) {
+	f.rand = r
+}
+
+func (f *Fetcher[T]) SetNow(now func() time.Time) {
+	f.now = now
+}
@@ -0,0 +1,108 @@
+package refresh
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Jitter randomizes the intervals of a Fetcher, so that the Fetchers of many nodes started together do not all
+// fetch from the same source at the same time.
+type Jitter int
+
+const (
+	// NoJitter waits exactly the interval.
+	NoJitter Jitter = iota
+	// FullJitter waits a random duration up to the interval, which halves the mean interval.
+	FullJitter
+	// DecorrelatedJitter waits a random duration between the minimum interval and three times the last wait,
+	// up to the interval.
+	DecorrelatedJitter
+	// EqualJitter waits half the interval and a random duration up to the other half, which keeps the interval
+	// close to the configured one when the minimum and maximum intervals are the same.
+	EqualJitter
+)
+
+// BreakerState is the state of the circuit breaker of a Fetcher.
+type BreakerState string
+
+const (
+	// BreakerClosed fetches as usual.
+	BreakerClosed BreakerState = "closed"
+	// BreakerOpen does not fetch until the cool-off period ends.
+	BreakerOpen BreakerState = "open"
+	// BreakerHalfOpen tries a single fetch after the cool-off period, which closes the breaker if it succeeds and
+	// opens it again otherwise.
+	BreakerHalfOpen BreakerState = "halfopen"
+)
+
+// Hooks are called as a Fetcher runs, for example to record metrics. Any of them may be nil.
+type Hooks struct {
+	// OnFetch is called after every fetch with how long it took and its error.
+	OnFetch func(latency time.Duration, err error)
+	// OnInterval is called after every fetch with the wait until the next one.
+	OnInterval func(interval time.Duration)
+	// OnBreaker is called whenever the circuit breaker changes state.
+	OnBreaker func(state BreakerState)
+}
+
+type options struct {
+	jitter           Jitter
+	errorMaxInterval time.Duration
+	breakerThreshold int
+	breakerCooloff   time.Duration
+	hooks            Hooks
+}
+
+// Option configures a Fetcher.
+type Option func(*options)
+
+// WithJitter randomizes the intervals of the Fetcher.
+func WithJitter(jitter Jitter) Option {
+	return func(o *options) {
+		o.jitter = jitter
+	}
+}
+
+// WithErrorBackoff backs off on failed fetches independently of the interval for unchanged data: the wait after
+// a failed fetch starts at the minimum interval and doubles with every consecutive failure up to maxInterval.
+// Without it, failed fetches are retried at the current interval.
+func WithErrorBackoff(maxInterval time.Duration) Option {
+	return func(o *options) {
+		o.errorMaxInterval = maxInterval
+	}
+}
+
+// WithCircuitBreaker stops fetching for the cool-off period once threshold consecutive fetches failed.
+func WithCircuitBreaker(threshold int, cooloff time.Duration) Option {
+	return func(o *options) {
+		o.breakerThreshold = threshold
+		o.breakerCooloff = cooloff
+	}
+}
+
+// WithHooks calls the hooks as the Fetcher runs.
+func WithHooks(hooks Hooks) Option {
+	return func(o *options) {
+		o.hooks = hooks
+	}
+}
+
+func defaultRand() float64 {
+	return rand.Float64() //nolint:gosec // jitter does not need a secure source
+}
+
+// jitter applies the jitter of the Fetcher to the wait. Must be called with the lock held.
+func (f *Fetcher[T]) jitter(wait time.Duration) time.Duration {
+	switch f.opts.jitter {
+	case FullJitter:
+		wait = time.Duration(f.rand() * float64(wait))
+	case DecorrelatedJitter:
+		upper := max(3*f.lastWait, f.minInterval) //nolint:gomnd // decorrelated jitter triples the last wait
+		wait = min(wait, f.minInterval+time.Duration(f.rand()*float64(upper-f.minInterval)))
+	case EqualJitter:
+		wait = wait/2 + time.Duration(f.rand()*float64(wait/2)) //nolint:gomnd // half the wait is fixed
+	case NoJitter:
+	}
+	// tickers can not wait for nothing
+	return max(wait, time.Millisecond)
+}
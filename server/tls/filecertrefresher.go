@@ -0,0 +1,177 @@
+// Copyright 2020 Microsoft. All rights reserved.
+
+package tls
+
+import (
+	"context"
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"sync"
+	"time"
+
+	acnfs "github.com/Azure/azure-container-networking/internal/fs"
+	"github.com/pkg/errors"
+)
+
+var (
+	errCertificateNotValid = errors.New("certificate is not valid")
+	errKeyMismatch         = errors.New("private key does not match certificate")
+)
+
+type logger interface {
+	Printf(format string, args ...any)
+	Errorf(format string, args ...any)
+}
+
+// ClientCAsFunc derives the pool of CAs trusted for mTLS client certificates from the serving certificate.
+type ClientCAsFunc func(*tls.Certificate) (*x509.CertPool, error)
+
+// FileCertRefresher offers a mechanism to present the latest version of the tls.Certificate at TLSCertificatePath,
+// reloaded whenever the file changes on disk. A certificate which fails validation is rejected and the last good
+// certificate continues to be presented.
+type FileCertRefresher struct {
+	settings  TlsSettings
+	clientCAs ClientCAsFunc
+	logger    logger
+
+	m    sync.RWMutex
+	cert *tls.Certificate
+	cas  *x509.CertPool
+}
+
+// NewFileCertRefresher returns a FileCertRefresher. When there's no error, the FileCertRefresher's GetCertificate method
+// is ready for use, returning the tls.Certificate read from disk during construction. A certificate which is expired or
+// not valid yet is only logged, so that the service starts and picks up the rotated certificate. If clientCAs is not nil
+// it is used to build the client CA pool returned by GetClientCAs whenever the certificate is loaded.
+func NewFileCertRefresher(settings TlsSettings, clientCAs ClientCAsFunc, l logger) (*FileCertRefresher, error) {
+	r := &FileCertRefresher{
+		settings:  settings,
+		clientCAs: clientCAs,
+		logger:    l,
+	}
+
+	cert, cas, err := r.load(false)
+	if err != nil {
+		certificateReloads.WithLabelValues(settings.TLSCertificatePath, reloadFailure).Inc()
+		return nil, errors.Wrap(err, "could not load initial cert")
+	}
+	r.cert, r.cas = cert, cas
+	certificateExpiry.WithLabelValues(settings.TLSCertificatePath).Set(float64(cert.Leaf.NotAfter.Unix()))
+	r.logger.Printf("initial certificate loaded: %s", r)
+	return r, nil
+}
+
+func (r *FileCertRefresher) String() string {
+	return fmt.Sprintf("path: %s, %s", r.settings.TLSCertificatePath, describeCertificate(r.cert.Leaf))
+}
+
+// GetCertificate returns the latest certificate read from disk.
+func (r *FileCertRefresher) GetCertificate() *tls.Certificate {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	return r.cert
+}
+
+// GetClientCAs returns the client CA pool built from the latest certificate, or nil if no ClientCAsFunc was provided.
+func (r *FileCertRefresher) GetClientCAs() *x509.CertPool {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	return r.cas
+}
+
+// Refresh reloads the certificate every time the file changes.
+// It blocks until the context is done or the file can no longer be watched.
+func (r *FileCertRefresher) Refresh(ctx context.Context) error {
+	err := acnfs.WatchFile(ctx, r.settings.TLSCertificatePath, func() { _ = r.Reload() }, func(err error) {
+		r.logger.Errorf("error watching certificate %s: %v", r.settings.TLSCertificatePath, err)
+	})
+	return errors.Wrap(err, "refresh stopped")
+}
+
+// Reload reads and validates the certificate on disk and starts presenting it.
+// If the certificate can not be loaded the last good certificate is kept and an error is returned.
+func (r *FileCertRefresher) Reload() error {
+	cert, cas, err := r.load(true)
+	if err != nil {
+		certificateReloads.WithLabelValues(r.settings.TLSCertificatePath, reloadFailure).Inc()
+		r.logger.Errorf("rejected certificate at %s, keeping certificate %s: %v", r.settings.TLSCertificatePath, describeCertificate(r.GetCertificate().Leaf), err)
+		return err
+	}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if cert.Leaf.Equal(r.cert.Leaf) {
+		r.logger.Printf("certificate unchanged. certificate %s", r)
+		return nil
+	}
+
+	old := describeCertificate(r.cert.Leaf)
+	r.cert, r.cas = cert, cas
+	certificateReloads.WithLabelValues(r.settings.TLSCertificatePath, reloadSuccess).Inc()
+	certificateExpiry.WithLabelValues(r.settings.TLSCertificatePath).Set(float64(cert.Leaf.NotAfter.Unix()))
+	r.logger.Printf("certificate refreshed. old certificate: %s, certificate: %s", old, r)
+	return nil
+}
+
+// load reads the certificate and private key from disk and validates them. Unless strict, a certificate outside of its
+// validity period is logged and loaded anyway.
+func (r *FileCertRefresher) load(strict bool) (*tls.Certificate, *x509.CertPool, error) {
+	retriever, err := GetTlsCertificateRetriever(r.settings)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to get certificate retriever")
+	}
+	leaf, err := retriever.GetCertificate()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to get certificate")
+	}
+	key, err := retriever.GetPrivateKey()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to get certificate private key")
+	}
+	if err := validateCertificate(leaf, key, time.Now()); err != nil {
+		if strict || !errors.Is(err, errCertificateNotValid) {
+			return nil, nil, err
+		}
+		r.logger.Errorf("loading certificate at %s which is not valid, waiting for it to be rotated: %v", r.settings.TLSCertificatePath, err)
+	}
+
+	cert := &tls.Certificate{
+		Certificate: [][]byte{leaf.Raw},
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}
+	if r.clientCAs == nil {
+		return cert, nil, nil
+	}
+	cas, err := r.clientCAs(cert)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to get client CAs")
+	}
+	return cert, cas, nil
+}
+
+// validateCertificate checks that the certificate is within its validity period and that the key belongs to it.
+func validateCertificate(leaf *x509.Certificate, key crypto.PrivateKey, now time.Time) error {
+	if now.Before(leaf.NotBefore) {
+		return errors.Wrapf(errCertificateNotValid, "not valid before %s", leaf.NotBefore)
+	}
+	if now.After(leaf.NotAfter) {
+		return errors.Wrapf(errCertificateNotValid, "expired on %s", leaf.NotAfter)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return errors.Wrapf(errKeyMismatch, "unsupported private key type %T", key)
+	}
+	pub, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !pub.Equal(leaf.PublicKey) {
+		return errKeyMismatch
+	}
+	return nil
+}
+
+func describeCertificate(leaf *x509.Certificate) string {
+	return fmt.Sprintf("subject: %s, serial: %s, expiration: %s", leaf.Subject, leaf.SerialNumber, leaf.NotAfter)
+}
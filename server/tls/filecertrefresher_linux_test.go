@@ -0,0 +1,132 @@
+// Copyright 2020 Microsoft. All rights reserved.
+
+package tls
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testLogger struct {
+	t *testing.T
+}
+
+func (l testLogger) Printf(format string, args ...any) {
+	l.t.Logf(format, args...)
+}
+
+func (l testLogger) Errorf(format string, args ...any) {
+	l.t.Logf(format, args...)
+}
+
+// writeTestCertificate writes a self-signed certificate and its private key to path.
+// If mismatchedKey is set the private key written does not belong to the certificate.
+func writeTestCertificate(t *testing.T, path string, serial int64, notAfter time.Time, mismatchedKey bool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	derBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	if mismatchedKey {
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+	}
+	keyBytes, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	bundle := pem.EncodeToMemory(&pem.Block{Type: CertLabel, Bytes: derBytes})
+	bundle = append(bundle, pem.EncodeToMemory(&pem.Block{Type: PrivateKeyLabel, Bytes: keyBytes})...)
+	// replace the file atomically the way cert-manager and Secret volumes do
+	tmp := path + ".tmp"
+	require.NoError(t, os.WriteFile(tmp, bundle, 0o600))
+	require.NoError(t, os.Rename(tmp, path))
+}
+
+func TestFileCertRefresherReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cert.pem")
+	writeTestCertificate(t, path, 1, time.Now().Add(time.Hour), false)
+
+	clientCAs := func(cert *tls.Certificate) (*x509.CertPool, error) {
+		pool := x509.NewCertPool()
+		pool.AddCert(cert.Leaf)
+		return pool, nil
+	}
+	r, err := NewFileCertRefresher(TlsSettings{TLSCertificatePath: path}, clientCAs, testLogger{t})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, r.GetCertificate().Leaf.SerialNumber.Int64())
+	firstCAs := r.GetClientCAs()
+	require.NotNil(t, firstCAs)
+
+	// a valid certificate is picked up along with its client CA pool
+	writeTestCertificate(t, path, 2, time.Now().Add(time.Hour), false)
+	require.NoError(t, r.Reload())
+	require.EqualValues(t, 2, r.GetCertificate().Leaf.SerialNumber.Int64())
+	require.NotSame(t, firstCAs, r.GetClientCAs())
+
+	// invalid certificates are rejected and the last good one is kept
+	writeTestCertificate(t, path, 3, time.Now().Add(-time.Minute), false)
+	require.ErrorIs(t, r.Reload(), errCertificateNotValid)
+	writeTestCertificate(t, path, 4, time.Now().Add(time.Hour), true)
+	require.ErrorIs(t, r.Reload(), errKeyMismatch)
+	require.NoError(t, os.WriteFile(path, []byte("not a certificate"), 0o600))
+	require.Error(t, r.Reload())
+	require.EqualValues(t, 2, r.GetCertificate().Leaf.SerialNumber.Int64())
+}
+
+func TestFileCertRefresherRefresh(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cert.pem")
+	writeTestCertificate(t, path, 1, time.Now().Add(time.Hour), false)
+
+	r, err := NewFileCertRefresher(TlsSettings{TLSCertificatePath: path}, nil, testLogger{t})
+	require.NoError(t, err)
+	require.Nil(t, r.GetClientCAs())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = r.Refresh(ctx) }()
+	// give the watcher time to register the directory
+	time.Sleep(100 * time.Millisecond)
+
+	writeTestCertificate(t, path, 2, time.Now().Add(time.Hour), false)
+	require.Eventually(t, func() bool {
+		return r.GetCertificate().Leaf.SerialNumber.Int64() == 2
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestNewFileCertRefresherExpired(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cert.pem")
+	writeTestCertificate(t, path, 1, time.Now().Add(-time.Minute), false)
+
+	// an expired certificate does not keep the service from starting, but is not swapped in later
+	r, err := NewFileCertRefresher(TlsSettings{TLSCertificatePath: path}, nil, testLogger{t})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), r.GetCertificate().Leaf.SerialNumber.Int64())
+
+	writeTestCertificate(t, path, 2, time.Now().Add(-time.Minute), false)
+	require.ErrorIs(t, r.Reload(), errCertificateNotValid)
+	require.Equal(t, int64(1), r.GetCertificate().Leaf.SerialNumber.Int64())
+
+	writeTestCertificate(t, path, 3, time.Now().Add(time.Hour), false)
+	require.NoError(t, r.Reload())
+	require.Equal(t, int64(3), r.GetCertificate().Leaf.SerialNumber.Int64())
+}
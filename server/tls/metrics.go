@@ -0,0 +1,41 @@
+package tls
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	pathLabel     = "path"
+	resultLabel   = "result"
+	reloadSuccess = "success"
+	reloadFailure = "failure"
+)
+
+var (
+	// certificateExpiry is the expiration time of the certificate currently presented from a file. To drive alerting
+	// on upcoming expiry, compare it to time(); a value which stops advancing after a rotation means the new
+	// certificate was rejected.
+	certificateExpiry = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "tls_certificate_expiration_timestamp_seconds",
+			Help: "Expiration time of the TLS certificate presented from a file, as a unix timestamp.",
+		},
+		[]string{pathLabel},
+	)
+	// certificateReloads counts attempts to load a TLS certificate from a file by result.
+	certificateReloads = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "tls_certificate_reloads_total",
+			Help: "Number of times a TLS certificate was loaded from a file, by result.",
+		},
+		[]string{pathLabel, resultLabel},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		certificateExpiry,
+		certificateReloads,
+	)
+}
@@ -9,6 +9,7 @@ const (
 	CNIDelTimeMetricStr    = "CNIDelTimeMs"
 	CNIUpdateTimeMetricStr = "CNIUpdateTimeMs"
 	CNILockTimeoutStr      = "CNILockTimeoutError"
+	IPAddressConflictStr   = "IPAddressConflict"
 
 	// Dimension Names
 	ContextStr        = "Context"
@@ -20,6 +21,7 @@ const (
 	CNIModeStr        = "CNIMode"
 	CNINetworkModeStr = "CNINetworkMode"
 	OSTypeStr         = "OSType"
+	IPAddressStr      = "IPAddress"
 
 	// Values
 	SucceededStr     = "Succeeded"
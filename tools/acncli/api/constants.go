@@ -25,6 +25,9 @@ const (
 	FlagConflistDirectory = "conflist-directory"
 	FlagVersion           = "version"
 
+	// Log level Flags
+	FlagEndpoint = "endpoint"
+
 	// CNI Log Flags
 	FlagFollow      = "follow"
 	FlagLogFilePath = "log-file"
@@ -61,6 +64,7 @@ const (
 	AzureTelemetryBin    = "azure-vnet-telemetry"
 	AzureTelemetryConfig = "azure-vnet-telemetry.config"
 	AzureCNSIPAM         = "azure-cns"
+	AzureNPM             = "azure-npm"
 	AzureVNETIPAM        = "azure-vnet-ipam"
 	ConflistExtension    = ".conflist"
 
@@ -113,6 +117,12 @@ var (
 		FlagNetworkName:                DefaultNetworkName,
 	}
 
+	// LogLevelEndpoints are the local endpoints of the processes serving /debug/loglevel.
+	LogLevelEndpoints = map[string]string{
+		AzureCNSIPAM: "http://localhost:10090",
+		AzureNPM:     "http://localhost:10091",
+	}
+
 	DefaultToggles = map[string]bool{
 		FlagFollow: false,
 	}
@@ -0,0 +1,95 @@
+//go:build !ignore_uncovered
+// +build !ignore_uncovered
+
+package loglevel
+
+import (
+	"strings"
+	"time"
+
+	acntime "github.com/Azure/azure-container-networking/internal/time"
+	logv2 "github.com/Azure/azure-container-networking/log/v2"
+	"github.com/Azure/azure-container-networking/tools/acncli/api"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// LogLevelCmd returns the command which gets and changes the log levels of CNS or NPM at runtime.
+func LogLevelCmd() *cobra.Command {
+	var target, endpoint string
+	cmd := &cobra.Command{
+		Use:   "loglevel",
+		Short: "Get or change the log levels of a running CNS or NPM",
+	}
+	cmd.PersistentFlags().StringVar(&target, api.FlagTarget, api.AzureCNSIPAM, "process to talk to, azure-cns or azure-npm")
+	cmd.PersistentFlags().StringVar(&endpoint, api.FlagEndpoint, "", "endpoint of the process, defaults to the local endpoint of the target")
+
+	client := func() (*logv2.LevelClient, error) {
+		if endpoint == "" {
+			var ok bool
+			if endpoint, ok = api.LogLevelEndpoints[target]; !ok {
+				return nil, errors.Errorf("unknown target %q", target)
+			}
+		}
+		return logv2.NewLevelClient(endpoint, nil), nil
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "get",
+		Short: "Print the current log levels",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			c, err := client()
+			if err != nil {
+				return err
+			}
+			resp, err := c.Get(cmd.Context())
+			if err != nil {
+				return errors.Wrap(err, "failed to get log levels")
+			}
+			api.PrettyPrint(resp)
+			return nil
+		},
+	})
+
+	var level string
+	var components []string
+	var ttl time.Duration
+	setCmd := &cobra.Command{
+		Use:   "set",
+		Short: "Change the log levels, optionally reverting them after a TTL",
+		Example: "  acncli loglevel set --level debug --ttl 15m\n" +
+			"  acncli loglevel set --component ipampool=debug --component restserver=debug\n" +
+			"  acncli loglevel set --target azure-npm --component dataplane=debug --ttl 10m",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			req := &logv2.LevelRequest{Level: level, TTL: acntime.Duration{Duration: ttl}}
+			for _, component := range components {
+				name, componentLevel, ok := strings.Cut(component, "=")
+				if !ok {
+					return errors.Errorf("component %q is not of the form name=level", component)
+				}
+				if req.Components == nil {
+					req.Components = map[string]string{}
+				}
+				req.Components[name] = componentLevel
+			}
+			if req.Level == "" && len(req.Components) == 0 {
+				return errors.New("at least one of --level or --component is required")
+			}
+			c, err := client()
+			if err != nil {
+				return err
+			}
+			resp, err := c.Set(cmd.Context(), req)
+			if err != nil {
+				return errors.Wrap(err, "failed to set log levels")
+			}
+			api.PrettyPrint(resp)
+			return nil
+		},
+	}
+	setCmd.Flags().StringVar(&level, "level", "", "general log level: debug, info, warn or error")
+	setCmd.Flags().StringArrayVar(&components, "component", nil, "level of a component as name=level, an empty level removes the override")
+	setCmd.Flags().DurationVar(&ttl, "ttl", 0, "revert the change after this long, zero keeps it")
+	cmd.AddCommand(setCmd)
+	return cmd
+}
@@ -6,6 +6,7 @@ package cmd
 import (
 	"fmt"
 
+	"github.com/Azure/azure-container-networking/tools/acncli/cmd/loglevel"
 	"github.com/Azure/azure-container-networking/tools/acncli/cmd/npm"
 
 	"github.com/Azure/azure-container-networking/tools/acncli/cmd/cni"
@@ -41,6 +42,7 @@ func NewRootCmd(version string) *cobra.Command {
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(cni.CNICmd())
 	rootCmd.AddCommand(npm.NPMRootCmd())
+	rootCmd.AddCommand(loglevel.LogLevelCmd())
 	rootCmd.SetVersionTemplate(version)
 	return rootCmd
 }